@@ -0,0 +1,58 @@
+// Agent that scrapes a local JVM endpoint and pushes metrics to a pondy server
+// Usage: go run ./cmd/agent -server https://pondy.example.com -target orders -endpoint http://localhost:8080/actuator/metrics
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jiin/pondy/internal/agent"
+)
+
+var (
+	server   = flag.String("server", "", "pondy server URL")
+	apiKey   = flag.String("key", os.Getenv("PONDY_API_KEY"), "API key (default: $PONDY_API_KEY)")
+	target   = flag.String("target", "", "Push target name configured on the server")
+	instance = flag.String("instance", "", "Instance name (default: hostname)")
+	endpoint = flag.String("endpoint", "http://localhost:8080/actuator/metrics", "Local metrics endpoint")
+	typ      = flag.String("type", "actuator", "Endpoint type: actuator or jolokia")
+	poolType = flag.String("pool-type", "", "Connection pool type (default: auto)")
+	interval = flag.Duration("interval", 10*time.Second, "Scrape interval")
+	buffer   = flag.Int("buffer", 8640, "Samples buffered while the server is unreachable")
+)
+
+func main() {
+	flag.Parse()
+
+	name := *instance
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+
+	a, err := agent.New(agent.Config{
+		ServerURL:  *server,
+		APIKey:     *apiKey,
+		Target:     *target,
+		Instance:   name,
+		Endpoint:   *endpoint,
+		Type:       *typ,
+		PoolType:   *poolType,
+		Interval:   *interval,
+		BufferSize: *buffer,
+	})
+	if err != nil {
+		log.Fatalf("Failed to start agent: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Pushing %s metrics from %s to %s every %v", *target, *endpoint, *server, *interval)
+	a.Run(ctx)
+	log.Printf("Agent stopped")
+}
@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/collector"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// MaxBatchSize matches the server's per-request ingestion limit
+const MaxBatchSize = 1000
+
+// Config holds agent settings
+type Config struct {
+	ServerURL  string        // pondy server, e.g., https://pondy.example.com
+	APIKey     string        // Key from the server's server.api_keys
+	Target     string        // Push target name configured on the server
+	Instance   string        // Instance name reported to the server
+	Endpoint   string        // Local actuator or Jolokia endpoint
+	Type       string        // actuator or jolokia (default: actuator)
+	PoolType   string        // Connection pool type (default: auto)
+	Interval   time.Duration // Scrape interval (default: 10s)
+	BufferSize int           // Samples kept while the server is unreachable (default: 8640)
+}
+
+// Agent scrapes a local endpoint and pushes metrics to a pondy server
+// Samples are buffered while the server is unreachable and sent once it recovers
+type Agent struct {
+	cfg       Config
+	collector collector.Collector
+	client    *http.Client
+	ingestURL string
+
+	mu     sync.Mutex
+	buffer []models.PoolMetrics
+}
+
+// New creates an agent using the collector for the configured endpoint type
+func New(cfg Config) (*Agent, error) {
+	if cfg.ServerURL == "" || cfg.Target == "" || cfg.Endpoint == "" {
+		return nil, fmt.Errorf("server, target and endpoint are required")
+	}
+	if cfg.Instance == "" {
+		cfg.Instance = "default"
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 8640 // 24h at the default interval
+	}
+
+	c, err := collector.NewCollector(
+		config.TargetConfig{Name: cfg.Target, Type: cfg.Type, PoolType: cfg.PoolType, Interval: cfg.Interval},
+		config.InstanceConfig{ID: cfg.Instance, Endpoint: cfg.Endpoint},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Agent{
+		cfg:       cfg,
+		collector: c,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		ingestURL: strings.TrimSuffix(cfg.ServerURL, "/") + "/api/v1/ingest/metrics",
+	}, nil
+}
+
+// Run scrapes and pushes on every interval until ctx is cancelled
+// A final push is attempted on shutdown
+func (a *Agent) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		a.scrape(ctx)
+		a.flushAndLog(ctx)
+
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			a.flushAndLog(shutdownCtx)
+			cancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrape collects one sample and buffers it
+func (a *Agent) scrape(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, collector.CollectionTimeout)
+	defer cancel()
+
+	metrics, err := a.collector.CollectWithContext(ctx)
+	if err != nil && (metrics == nil || metrics.Status != models.StatusNoPool) {
+		log.Printf("Failed to collect from %s: %v", a.cfg.Endpoint, err)
+		return
+	}
+	a.enqueue(*metrics)
+}
+
+// enqueue buffers a sample, dropping the oldest when the buffer is full
+func (a *Agent) enqueue(m models.PoolMetrics) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.buffer = append(a.buffer, m)
+	if over := len(a.buffer) - a.cfg.BufferSize; over > 0 {
+		log.Printf("Buffer full, dropping %d oldest samples", over)
+		a.buffer = append([]models.PoolMetrics(nil), a.buffer[over:]...)
+	}
+}
+
+// Buffered returns the number of samples waiting to be pushed
+func (a *Agent) Buffered() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.buffer)
+}
+
+func (a *Agent) flushAndLog(ctx context.Context) {
+	if err := a.Flush(ctx); err != nil {
+		log.Printf("Push to %s failed (%d samples buffered): %v", a.cfg.ServerURL, a.Buffered(), err)
+	}
+}
+
+// Flush pushes buffered samples in batches, oldest first
+// Samples stay buffered when the server is unreachable or returns a retryable error
+func (a *Agent) Flush(ctx context.Context) error {
+	for {
+		a.mu.Lock()
+		n := len(a.buffer)
+		if n > MaxBatchSize {
+			n = MaxBatchSize
+		}
+		batch := append([]models.PoolMetrics(nil), a.buffer[:n]...)
+		a.mu.Unlock()
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := a.push(ctx, batch)
+		if err != nil && !isPermanent(err) {
+			return err
+		}
+		if err != nil {
+			// The server will never accept this batch, don't block newer samples
+			log.Printf("Dropping %d samples rejected by server: %v", len(batch), err)
+		}
+
+		a.mu.Lock()
+		a.buffer = a.buffer[len(batch):]
+		a.mu.Unlock()
+	}
+}
+
+// pushError is a non-2xx ingestion response
+type pushError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *pushError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// isPermanent reports whether retrying the same batch cannot succeed
+// Auth and rate limit failures are retried since they can be fixed server-side
+func isPermanent(err error) bool {
+	pe, ok := err.(*pushError)
+	if !ok {
+		return false
+	}
+	return pe.StatusCode == http.StatusBadRequest || pe.StatusCode == http.StatusRequestEntityTooLarge
+}
+
+func (a *Agent) push(ctx context.Context, batch []models.PoolMetrics) error {
+	body, err := json.Marshal(struct {
+		Metrics []models.PoolMetrics `json:"metrics"`
+	}{batch})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.ingestURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.cfg.APIKey)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	return &pushError{StatusCode: resp.StatusCode, Message: errResp.Error}
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func newTestAgent(t *testing.T, serverURL string, bufferSize int) *Agent {
+	t.Helper()
+	a, err := New(Config{
+		ServerURL:  serverURL,
+		APIKey:     "secret",
+		Target:     "orders",
+		Endpoint:   "http://localhost:8080/actuator/metrics",
+		BufferSize: bufferSize,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return a
+}
+
+func TestNew_RequiresSettings(t *testing.T) {
+	if _, err := New(Config{ServerURL: "http://pondy", Target: "orders"}); err == nil {
+		t.Error("New() should fail without endpoint")
+	}
+}
+
+func TestAgent_EnqueueDropsOldest(t *testing.T) {
+	a := newTestAgent(t, "http://pondy", 3)
+	for i := 1; i <= 5; i++ {
+		a.enqueue(models.PoolMetrics{Active: i})
+	}
+
+	if a.Buffered() != 3 {
+		t.Fatalf("Buffered() = %d, want 3", a.Buffered())
+	}
+	if a.buffer[0].Active != 3 {
+		t.Errorf("oldest sample = %d, want 3", a.buffer[0].Active)
+	}
+}
+
+func TestAgent_Flush(t *testing.T) {
+	var status atomic.Int32
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/ingest/metrics" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if code := int(status.Load()); code != http.StatusAccepted {
+			w.WriteHeader(code)
+			return
+		}
+		var req struct {
+			Metrics []models.PoolMetrics `json:"metrics"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		received.Add(int32(len(req.Metrics)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	a := newTestAgent(t, srv.URL+"/", 0)
+	for i := 0; i < MaxBatchSize+5; i++ {
+		a.enqueue(models.PoolMetrics{TargetName: "orders", Timestamp: time.Now()})
+	}
+
+	// Outage keeps samples buffered
+	status.Store(http.StatusServiceUnavailable)
+	if err := a.Flush(context.Background()); err == nil {
+		t.Error("Flush() should fail while the server is unavailable")
+	}
+	if a.Buffered() != MaxBatchSize+5 {
+		t.Errorf("Buffered() = %d, want %d", a.Buffered(), MaxBatchSize+5)
+	}
+
+	// Recovery sends everything in batches
+	status.Store(http.StatusAccepted)
+	if err := a.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if a.Buffered() != 0 || received.Load() != MaxBatchSize+5 {
+		t.Errorf("buffered = %d, received = %d", a.Buffered(), received.Load())
+	}
+
+	// Rejected batches are dropped instead of blocking the buffer
+	a.enqueue(models.PoolMetrics{TargetName: "orders"})
+	status.Store(http.StatusBadRequest)
+	if err := a.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if a.Buffered() != 0 {
+		t.Errorf("Buffered() = %d, want 0", a.Buffered())
+	}
+}
@@ -1,9 +1,12 @@
 package alerter
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jiin/pondy/internal/config"
@@ -11,6 +14,12 @@ import (
 	"github.com/jiin/pondy/internal/storage"
 )
 
+// Repeat notification settings
+const (
+	repeatCheckInterval = 30 * time.Second
+	maxRepeatAlerts     = 1000
+)
+
 // Manager manages alert evaluation and notification
 type Manager struct {
 	mu        sync.RWMutex
@@ -19,7 +28,27 @@ type Manager struct {
 	channels  []Channel
 	dbRules   []models.AlertRule                // rules from database
 	lastFired map[string]time.Time // cooldown tracking: "target/instance/rule" -> last fired time
+	paused    map[string]bool      // targets whose alerts are suppressed, by name
+	intervals map[string]time.Duration // collection interval of each target, for nodata rules
+	projects  map[string]string        // project of each target, for project-scoped rules and windows
+	targetGroups map[string]string     // group of each target, for group-scoped maintenance windows
+	targetLabels map[string]map[string]string // labels of each target, for label-scoped rules
+	loc       *time.Location           // configured timezone, for recurring and cron maintenance windows
 	stop      chan struct{}
+	ctx       context.Context // Storage calls of the manager; cancelled by Stop
+	cancel    context.CancelFunc
+
+	groupMu sync.Mutex
+	groups  map[string]*pendingGroup // alerts waiting for a digest, by group key
+
+	evalMu    sync.Mutex
+	evaluated map[string]time.Time // newest sample evaluated of each instance, by alert key prefix
+
+	flapMu sync.Mutex
+	flaps  map[string]*flapState // recent state changes of each rule on an instance, by alert key
+
+	sent   atomic.Int64 // successful channel deliveries since start
+	failed atomic.Int64 // failed channel deliveries since start
 }
 
 // NewManager creates a new alert manager
@@ -31,16 +60,26 @@ func NewManager(store storage.Storage, cfg *config.AlertingConfig) *Manager {
 		dbRules:   make([]models.AlertRule, 0),
 		lastFired: make(map[string]time.Time),
 		stop:      make(chan struct{}),
+		groups:    make(map[string]*pendingGroup),
+		evaluated: make(map[string]time.Time),
+		flaps:     make(map[string]*flapState),
 	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
 
-	m.initChannels(cfg)
-	m.loadDBRules()
+	m.channels = buildChannels(cfg)
+	m.syncConfigRules(cfg)
+	m.restoreCooldowns(time.Now())
+	m.restoreFlapping(time.Now())
+
+	go m.repeatLoop()
+	go m.noDataLoop()
+	go m.evaluationLoop()
 	return m
 }
 
 // loadDBRules loads alert rules from database
 func (m *Manager) loadDBRules() {
-	rules, err := m.store.GetAlertRules()
+	rules, err := m.store.GetAlertRules(m.ctx)
 	if err != nil {
 		log.Printf("Alerter: failed to load DB rules: %v", err)
 		return
@@ -51,6 +90,32 @@ func (m *Manager) loadDBRules() {
 	log.Printf("Alerter: loaded %d rules from database", len(rules))
 }
 
+// restoreCooldowns rebuilds the cooldowns from the alerts fired within the cooldown, so that
+// conditions that fired and resolved just before a restart aren't notified again right after it
+// Suppressed alerts were never notified and don't start a cooldown
+func (m *Manager) restoreCooldowns(now time.Time) {
+	if m.cfg == nil {
+		return
+	}
+	alerts, _, err := m.store.QueryAlerts(m.ctx, models.AlertQuery{
+		From:      now.Add(-m.cfg.GetCooldown()),
+		SortBy:    models.AlertSortFiredAt,
+		Ascending: true,
+	})
+	if err != nil {
+		log.Printf("Alerter: failed to restore cooldowns: %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, alert := range alerts {
+		if alert.Status != models.AlertStatusSuppressed {
+			m.lastFired[m.alertKey(alert.TargetName, alert.InstanceName, alert.RuleName)] = alert.FiredAt
+		}
+	}
+}
+
 // ReloadRules reloads alert rules from database
 func (m *Manager) ReloadRules() {
 	m.loadDBRules()
@@ -63,9 +128,9 @@ type channelFactory struct {
 	create  func() Channel
 }
 
-// initChannels initializes notification channels from config
-func (m *Manager) initChannels(cfg *config.AlertingConfig) {
-	m.channels = make([]Channel, 0)
+// buildChannels creates notification channels from config
+func buildChannels(cfg *config.AlertingConfig) []Channel {
+	channels := make([]Channel, 0)
 
 	// Define all available channels
 	factories := []channelFactory{
@@ -80,7 +145,7 @@ func (m *Manager) initChannels(cfg *config.AlertingConfig) {
 	// Register enabled channels
 	for _, f := range factories {
 		if f.enabled {
-			m.channels = append(m.channels, f.create())
+			channels = append(channels, f.create())
 			log.Printf("Alerter: %s channel enabled", f.name)
 		}
 	}
@@ -88,24 +153,145 @@ func (m *Manager) initChannels(cfg *config.AlertingConfig) {
 	// Register plugin channels
 	for _, pluginCfg := range cfg.Channels.Plugins {
 		if pluginCfg.Enabled {
-			m.channels = append(m.channels, NewPluginChannel(pluginCfg))
+			channels = append(channels, NewPluginChannel(pluginCfg))
 			log.Printf("Alerter: Plugin channel '%s' enabled", pluginCfg.Name)
 		}
 	}
+
+	return channels
 }
 
 // UpdateConfig updates the alerter configuration
+// Channels are built before taking the lock so config and channels are swapped together
 func (m *Manager) UpdateConfig(cfg *config.AlertingConfig) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	channels := buildChannels(cfg)
 
+	m.mu.Lock()
 	m.cfg = cfg
-	m.initChannels(cfg)
-	log.Printf("Alerter: configuration updated, %d rules, %d channels", len(cfg.Rules), len(m.channels))
+	m.channels = channels
+	m.mu.Unlock()
+	m.syncConfigRules(cfg)
+
+	log.Printf("Alerter: configuration updated, %d rules, %d channels", len(cfg.Rules), len(channels))
+}
+
+// SetPausedTargets replaces the targets whose alerts are suppressed
+func (m *Manager) SetPausedTargets(names []string) {
+	paused := make(map[string]bool, len(names))
+	for _, name := range names {
+		paused[name] = true
+	}
+
+	m.mu.Lock()
+	m.paused = paused
+	m.mu.Unlock()
+}
+
+// SetTargetIntervals replaces the targets checked by nodata rules and their collection intervals
+func (m *Manager) SetTargetIntervals(intervals map[string]time.Duration) {
+	m.mu.Lock()
+	m.intervals = intervals
+	m.mu.Unlock()
+}
+
+// SetTargetProjects replaces the project of each target
+func (m *Manager) SetTargetProjects(projects map[string]string) {
+	m.mu.Lock()
+	m.projects = projects
+	m.mu.Unlock()
+}
+
+// SetTargetGroups replaces the group of each target
+func (m *Manager) SetTargetGroups(groups map[string]string) {
+	m.mu.Lock()
+	m.targetGroups = groups
+	m.mu.Unlock()
+}
+
+// SetTargetLabels replaces the labels of each target
+func (m *Manager) SetTargetLabels(labels map[string]map[string]string) {
+	m.mu.Lock()
+	m.targetLabels = labels
+	m.mu.Unlock()
+}
+
+// SetLocation sets the timezone maintenance window schedules are evaluated in
+func (m *Manager) SetLocation(loc *time.Location) {
+	m.mu.Lock()
+	m.loc = loc
+	m.mu.Unlock()
+}
+
+// inMaintenance checks whether the target is in a maintenance window now
+func (m *Manager) inMaintenance(target string) (bool, error) {
+	m.mu.RLock()
+	loc, group := m.loc, m.targetGroups[target]
+	m.mu.RUnlock()
+	now := time.Now()
+	if loc != nil {
+		now = now.In(loc)
+	}
+	return m.store.IsInMaintenanceWindow(m.ctx, target, group, m.projectOf(target), now)
+}
+
+// projectOf returns the project of a target; unknown targets belong to the default project
+func (m *Manager) projectOf(target string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if p, ok := m.projects[target]; ok {
+		return p
+	}
+	return config.DefaultProject
+}
+
+// groupOf returns the group of a target
+func (m *Manager) groupOf(target string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.targetGroups[target]
+}
+
+// labelsOf returns the labels of a target
+func (m *Manager) labelsOf(target string) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.targetLabels[target]
+}
+
+// isPaused returns whether alerts for the target are suppressed
+func (m *Manager) isPaused(target string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.paused[target]
 }
 
-// Check evaluates metrics against alert rules
+// Check evaluates metrics against alert rules as they are collected
+// With inline_check disabled, samples are left to the evaluation loop, except failed scrapes:
+// they aren't stored, so the loop never sees them
 func (m *Manager) Check(metrics *models.PoolMetrics) {
+	m.mu.RLock()
+	cfg := m.cfg
+	m.mu.RUnlock()
+
+	failed := metrics.ScrapeFailures > 0
+	if cfg == nil || !cfg.Enabled || (!failed && !cfg.IsInlineCheck()) {
+		return
+	}
+
+	// Load active silences once per check
+	silences, err := m.store.GetActiveSilences(m.ctx)
+	if err != nil {
+		log.Printf("Alerter: error loading silences: %v", err)
+	}
+	// A failed scrape doesn't stand for the stored samples, so it doesn't mark them evaluated
+	if !failed {
+		m.markEvaluated(metrics)
+	}
+	m.evaluate(metrics, silences)
+}
+
+// evaluate fires and resolves the alerts of all rules for a sample
+func (m *Manager) evaluate(metrics *models.PoolMetrics, silences []models.Silence) {
 	m.mu.RLock()
 	cfg := m.cfg
 	dbRules := m.dbRules
@@ -115,82 +301,265 @@ func (m *Manager) Check(metrics *models.PoolMetrics) {
 		return
 	}
 
-	// Check if target is in a maintenance window
-	inMaintenance, err := m.store.IsInMaintenanceWindow(metrics.TargetName)
+	// Paused targets are not alerted on, e.g., metrics still pushed during a migration
+	if m.isPaused(metrics.TargetName) {
+		return
+	}
+
+	// Rules triggered during a maintenance window are recorded as suppressed instead of notified
+	inMaintenance, err := m.inMaintenance(metrics.TargetName)
 	if err != nil {
 		log.Printf("Alerter: error checking maintenance window: %v", err)
 	}
+	evaluate := m.evaluateRule
 	if inMaintenance {
-		// Skip alert processing during maintenance
-		log.Printf("Alerter: skipping alert check for %s (in maintenance window)", metrics.TargetName)
-		return
+		evaluate = m.evaluateSuppressed
 	}
 
 	ctx := NewRuleContext(metrics)
-
-	// Evaluate config-based rules
+	ctx.Group = m.groupOf(metrics.TargetName)
+	ctx.Labels = m.labelsOf(metrics.TargetName)
+	ctx.SetHistory(func(from, to time.Time) ([]models.PoolMetrics, error) {
+		return m.store.GetHistoryByInstance(m.ctx, metrics.TargetName, metrics.InstanceName, from, to)
+	})
+
+	// Evaluate config-based rules unless they are synced into the database rules;
+	// anomaly and leak rules run on their own schedule
 	for _, rule := range cfg.Rules {
-		m.evaluateRule(&rule, ctx)
-	}
-
-	// Evaluate database rules
-	for _, dbRule := range dbRules {
-		if dbRule.Enabled {
-			configRule := &config.AlertRule{
-				Name:      dbRule.Name,
-				Condition: dbRule.Condition,
-				Severity:  dbRule.Severity,
-				Message:   dbRule.Message,
-				Enabled:   &dbRule.Enabled,
-			}
-			m.evaluateRule(configRule, ctx)
+		if !rule.IsCondition() || cfg.SyncRules {
+			continue
 		}
+		evaluate(&rule, ctx, silences)
 	}
 
-	// Also check for resolved alerts
-	m.checkResolutions(ctx)
+	// Evaluate database rules; project rules only apply to the project's targets
+	project := m.projectOf(metrics.TargetName)
+	for i := range dbRules {
+		dbRule := &dbRules[i]
+		if dbRule.Enabled && (dbRule.Project == "" || dbRule.Project == project) {
+			evaluate(ruleFromDB(dbRule), ctx, silences)
+		}
+	}
+
+	// Active alerts are not resolved during maintenance
+	if !inMaintenance {
+		m.checkResolutions(ctx)
+	}
 }
 
 // evaluateRule evaluates a single rule
-func (m *Manager) evaluateRule(rule *config.AlertRule, ctx *RuleContext) {
+func (m *Manager) evaluateRule(rule *config.AlertRule, ctx *RuleContext, silences []models.Silence) {
+	if !ctx.appliesTo(rule) {
+		return
+	}
+
 	triggered, err := EvaluateRule(rule, ctx)
 	if err != nil {
 		log.Printf("Alerter: rule %s evaluation error: %v", rule.Name, err)
 		return
 	}
 
-	alertKey := m.alertKey(ctx.TargetName, ctx.InstanceName, rule.Name)
+	if triggered {
+		m.trigger(rule, ctx, silences)
+	}
+	m.escalate(rule, ctx, silences)
+}
+
+// evaluateSuppressed evaluates a rule during a maintenance window
+// A triggered rule is recorded as a suppressed alert, which is closed once the condition clears
+func (m *Manager) evaluateSuppressed(rule *config.AlertRule, ctx *RuleContext, silences []models.Silence) {
+	if !ctx.appliesTo(rule) {
+		return
+	}
 
+	triggered, err := EvaluateRule(rule, ctx)
+	if err != nil {
+		log.Printf("Alerter: rule %s evaluation error: %v", rule.Name, err)
+		return
+	}
 	if triggered {
-		// Atomic check-and-set for cooldown to prevent race condition
-		now := time.Now()
-		m.mu.Lock()
-		lastFired, exists := m.lastFired[alertKey]
-		cooldown := m.cfg.GetCooldown()
-		if exists && now.Sub(lastFired) < cooldown {
-			// Still in cooldown period
-			m.mu.Unlock()
+		m.suppress(rule, ctx, silences)
+		return
+	}
+	resolved, err := ResolveRule(rule, ctx)
+	if err != nil {
+		log.Printf("Alerter: rule %s resolve condition error: %v", rule.Name, err)
+		return
+	}
+	if resolved {
+		m.clearSuppressed(rule, ctx)
+	}
+}
+
+// suppress records a triggered rule as a suppressed alert unless one is already open,
+// it is silenced or an alert fired before the window is still active
+func (m *Manager) suppress(rule *config.AlertRule, ctx *RuleContext, silences []models.Silence) {
+	suppressed, err := m.store.GetSuppressedAlertByRule(m.ctx, ctx.TargetName, ctx.InstanceName, rule.Name)
+	if err != nil {
+		log.Printf("Alerter: error checking suppressed alert: %v", err)
+		return
+	}
+	if suppressed != nil || findSilence(silences, ctx.TargetName, ctx.InstanceName, rule.Name, rule.Severity) != nil {
+		return
+	}
+
+	existingAlert, err := m.store.GetActiveAlertByRule(m.ctx, ctx.TargetName, ctx.InstanceName, rule.Name)
+	if err != nil {
+		log.Printf("Alerter: error checking existing alert: %v", err)
+		return
+	}
+	if existingAlert != nil {
+		return
+	}
+
+	message := RenderMessage(rule.Message, ctx)
+	alert := &models.Alert{
+		TargetName:   ctx.TargetName,
+		InstanceName: ctx.InstanceName,
+		RuleName:     rule.Name,
+		Severity:     rule.Severity,
+		Message:      message,
+		Status:       models.AlertStatusSuppressed,
+		FiredAt:      time.Now(),
+		Snapshot:     ctx.Snapshot(),
+	}
+	if err := m.store.SaveAlert(m.ctx, alert); err != nil {
+		log.Printf("Alerter: failed to save suppressed alert: %v", err)
+		return
+	}
+
+	log.Printf("Alerter: suppressed alert %s for %s/%s (in maintenance window): %s",
+		rule.Name, ctx.TargetName, ctx.InstanceName, message)
+}
+
+// clearSuppressed closes the open suppressed alert of a rule whose condition no longer holds
+func (m *Manager) clearSuppressed(rule *config.AlertRule, ctx *RuleContext) {
+	suppressed, err := m.store.GetSuppressedAlertByRule(m.ctx, ctx.TargetName, ctx.InstanceName, rule.Name)
+	if err != nil {
+		log.Printf("Alerter: error checking suppressed alert: %v", err)
+		return
+	}
+	if suppressed != nil {
+		m.closeSuppressed(suppressed)
+	}
+}
+
+// closeSuppressed marks a suppressed alert as ended without notifying
+func (m *Manager) closeSuppressed(alert *models.Alert) {
+	now := time.Now()
+	alert.ResolvedAt = &now
+	if err := m.store.UpdateAlert(m.ctx, alert); err != nil {
+		log.Printf("Alerter: failed to update suppressed alert: %v", err)
+	}
+}
+
+// trigger fires an alert for a triggered rule unless it is silenced, cooling down or already active
+// A suppressed alert left by a maintenance window fires only if fire_after_maintenance is enabled
+func (m *Manager) trigger(rule *config.AlertRule, ctx *RuleContext, silences []models.Silence) {
+	alertKey := m.alertKey(ctx.TargetName, ctx.InstanceName, rule.Name)
+
+	if silence := findSilence(silences, ctx.TargetName, ctx.InstanceName, rule.Name, rule.Severity); silence != nil {
+		log.Printf("Alerter: alert %s for %s/%s silenced by silence #%d",
+			rule.Name, ctx.TargetName, ctx.InstanceName, silence.ID)
+		return
+	}
+
+	suppressed, err := m.store.GetSuppressedAlertByRule(m.ctx, ctx.TargetName, ctx.InstanceName, rule.Name)
+	if err != nil {
+		log.Printf("Alerter: error checking suppressed alert: %v", err)
+		return
+	}
+	if suppressed != nil {
+		m.mu.RLock()
+		fire := m.cfg.ShouldFireAfterMaintenance()
+		m.mu.RUnlock()
+		if !fire {
 			return
 		}
-		// Reserve the cooldown slot immediately to prevent duplicate alerts
-		m.lastFired[alertKey] = now
+		m.closeSuppressed(suppressed)
+	}
+
+	// Atomic check-and-set for cooldown to prevent race condition
+	now := time.Now()
+	m.mu.Lock()
+	lastFired, exists := m.lastFired[alertKey]
+	cooldown := m.cfg.GetCooldown()
+	if exists && now.Sub(lastFired) < cooldown {
+		// Still in cooldown period
 		m.mu.Unlock()
+		return
+	}
+	// Reserve the cooldown slot immediately to prevent duplicate alerts
+	m.lastFired[alertKey] = now
+	m.mu.Unlock()
+
+	// Check if there's already an active alert for this rule
+	existingAlert, err := m.store.GetActiveAlertByRule(m.ctx, ctx.TargetName, ctx.InstanceName, rule.Name)
+	if err != nil {
+		log.Printf("Alerter: error checking existing alert: %v", err)
+		return
+	}
+
+	if existingAlert != nil {
+		// Alert already exists, skip
+		return
+	}
+
+	// Create new alert (cooldown already set above)
+	m.fireAlert(rule, ctx, now)
+}
+
+// Report fires or resolves an alert whose condition is evaluated outside the rule engine,
+// such as SLO burn rates. Silences, cooldown and maintenance windows apply as for rules.
+func (m *Manager) Report(rule *config.AlertRule, ctx *RuleContext, triggered bool) {
+	m.mu.RLock()
+	cfg := m.cfg
+	m.mu.RUnlock()
+
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
 
-		// Check if there's already an active alert for this rule
-		existingAlert, err := m.store.GetActiveAlertByRule(ctx.TargetName, ctx.InstanceName, rule.Name)
+	if !triggered {
+		existingAlert, err := m.store.GetActiveAlertByRule(m.ctx, ctx.TargetName, ctx.InstanceName, rule.Name)
 		if err != nil {
 			log.Printf("Alerter: error checking existing alert: %v", err)
 			return
 		}
-
 		if existingAlert != nil {
-			// Alert already exists, skip
-			return
+			m.resolveAlert(existingAlert, ctx)
 		}
+		m.clearSuppressed(rule, ctx)
+		return
+	}
 
-		// Create new alert (cooldown already set above)
-		m.fireAlert(rule, ctx, now)
+	if m.isPaused(ctx.TargetName) {
+		return
 	}
+	inMaintenance, err := m.inMaintenance(ctx.TargetName)
+	if err != nil {
+		log.Printf("Alerter: error checking maintenance window: %v", err)
+	}
+
+	silences, err := m.store.GetActiveSilences(m.ctx)
+	if err != nil {
+		log.Printf("Alerter: error loading silences: %v", err)
+	}
+	if inMaintenance {
+		m.suppress(rule, ctx, silences)
+		return
+	}
+	m.trigger(rule, ctx, silences)
+}
+
+// findSilence returns the first silence matching the alert labels, or nil
+func findSilence(silences []models.Silence, target, instance, rule, severity string) *models.Silence {
+	for i := range silences {
+		if silences[i].Matches(target, instance, rule, severity) {
+			return &silences[i]
+		}
+	}
+	return nil
 }
 
 // fireAlert creates and sends a new alert
@@ -206,31 +575,55 @@ func (m *Manager) fireAlert(rule *config.AlertRule, ctx *RuleContext, now time.T
 		Message:      message,
 		Status:       models.AlertStatusFired,
 		FiredAt:      now,
+		Snapshot:     ctx.Snapshot(),
+	}
+	// A rule already past its escalation threshold fires at the escalated severity
+	if severity := escalation(rule, ctx); severity != "" {
+		alert.Severity = severity
+		alert.EscalatedFrom = rule.Severity
+		alert.EscalatedAt = &now
 	}
+	m.recordChange(alert, now)
 
 	// Save to database
-	if err := m.store.SaveAlert(alert); err != nil {
+	if err := m.store.SaveAlert(m.ctx, alert); err != nil {
 		log.Printf("Alerter: failed to save alert: %v", err)
 		return
 	}
+	if alert.EscalatedAt != nil {
+		m.recordEscalation(alert, alert.EscalatedFrom, now)
+	}
 
 	// Cooldown already set in evaluateRule atomically
 
-	// Send notifications
-	m.sendNotifications(alert)
+	// Flapping alerts are notified once the rule settles
+	if alert.Flapping {
+		log.Printf("Alerter: fired flapping alert %s for %s/%s, notification held back",
+			rule.Name, ctx.TargetName, ctx.InstanceName)
+		return
+	}
 
-	// Update notified timestamp
-	notifiedAt := time.Now()
-	alert.NotifiedAt = &notifiedAt
-	alert.Channels = m.getEnabledChannelNames()
-	if err := m.store.UpdateAlert(alert); err != nil {
-		log.Printf("Alerter: failed to update alert after notification: %v", err)
+	// Grouped alerts are sent as a digest when the grouping window closes
+	if !m.enqueueGroup(alert, ctx) {
+		m.sendNotifications(alert, ctx, models.DeliveryEventFired)
+		m.markNotified(alert)
 	}
 
 	log.Printf("Alerter: fired alert %s for %s/%s: %s",
 		rule.Name, ctx.TargetName, ctx.InstanceName, message)
 }
 
+// markNotified records when and where an alert was sent
+// Only those columns are written, so a stale copy doesn't undo a resolve or escalation
+func (m *Manager) markNotified(alert *models.Alert) {
+	notifiedAt := time.Now()
+	alert.NotifiedAt = &notifiedAt
+	alert.Channels = m.getRoutedChannelNames(alert.RuleName)
+	if err := m.store.MarkAlertNotified(m.ctx, alert.ID, notifiedAt, alert.Channels); err != nil {
+		log.Printf("Alerter: failed to update alert after notification: %v", err)
+	}
+}
+
 // checkResolutions checks if any active alerts should be resolved
 func (m *Manager) checkResolutions(ctx *RuleContext) {
 	m.mu.RLock()
@@ -244,88 +637,205 @@ func (m *Manager) checkResolutions(ctx *RuleContext) {
 
 	// Check config-based rules
 	for _, rule := range cfg.Rules {
+		if !rule.IsCondition() || cfg.SyncRules {
+			continue
+		}
 		m.checkRuleResolution(&rule, ctx)
 	}
 
 	// Check database rules
-	for _, dbRule := range dbRules {
-		if dbRule.Enabled {
-			configRule := &config.AlertRule{
-				Name:      dbRule.Name,
-				Condition: dbRule.Condition,
-				Severity:  dbRule.Severity,
-				Message:   dbRule.Message,
-				Enabled:   &dbRule.Enabled,
-			}
-			m.checkRuleResolution(configRule, ctx)
+	for i := range dbRules {
+		if dbRules[i].Enabled {
+			m.checkRuleResolution(ruleFromDB(&dbRules[i]), ctx)
 		}
 	}
 }
 
 // checkRuleResolution checks if a specific rule should be resolved
 func (m *Manager) checkRuleResolution(rule *config.AlertRule, ctx *RuleContext) {
-	triggered, err := EvaluateRule(rule, ctx)
+	if !ctx.appliesTo(rule) {
+		return
+	}
+
+	// With a resolve condition, alerts stay active between the two thresholds
+	resolved, err := ResolveRule(rule, ctx)
 	if err != nil {
+		log.Printf("Alerter: rule %s resolve condition error: %v", rule.Name, err)
 		return
 	}
 
-	if !triggered {
-		// Rule is not triggered, check if there's an active alert to resolve
-		existingAlert, err := m.store.GetActiveAlertByRule(ctx.TargetName, ctx.InstanceName, rule.Name)
+	if resolved {
+		// Rule is resolved, check if there's an active alert to resolve
+		existingAlert, err := m.store.GetActiveAlertByRule(m.ctx, ctx.TargetName, ctx.InstanceName, rule.Name)
 		if err != nil {
 			return
 		}
 
 		if existingAlert != nil {
-			m.resolveAlert(existingAlert)
+			m.resolveAlert(existingAlert, ctx)
 		}
+		m.clearSuppressed(rule, ctx)
 	}
 }
 
+// repeatLoop periodically re-notifies alerts that stay fired and settles flapping rules
+func (m *Manager) repeatLoop() {
+	ticker := time.NewTicker(repeatCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.sendRepeatNotifications(time.Now())
+			m.settleFlapping(time.Now())
+		}
+	}
+}
+
+// sendRepeatNotifications re-sends active alerts whose repeat interval has elapsed
+func (m *Manager) sendRepeatNotifications(now time.Time) {
+	m.mu.RLock()
+	cfg := m.cfg
+	m.mu.RUnlock()
+
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+
+	alerts, err := m.store.GetAlerts(m.ctx, models.AlertStatusFired, maxRepeatAlerts)
+	if err != nil {
+		log.Printf("Alerter: error loading active alerts for repeat: %v", err)
+		return
+	}
+
+	var silences []models.Silence
+	silencesLoaded := false
+
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.Flapping || !shouldRepeat(alert, m.repeatInterval(cfg, alert.RuleName), now) || m.isPaused(alert.TargetName) {
+			continue
+		}
+
+		// Load silences lazily so idle loops don't hit the database
+		if !silencesLoaded {
+			silences, err = m.store.GetActiveSilences(m.ctx)
+			if err != nil {
+				log.Printf("Alerter: error loading silences: %v", err)
+			}
+			silencesLoaded = true
+		}
+		if findSilence(silences, alert.TargetName, alert.InstanceName, alert.RuleName, alert.Severity) != nil {
+			continue
+		}
+
+		inMaintenance, err := m.inMaintenance(alert.TargetName)
+		if err != nil {
+			log.Printf("Alerter: error checking maintenance window: %v", err)
+		}
+		if inMaintenance {
+			continue
+		}
+
+		// Render reminders with the latest metrics when available
+		var ctx *RuleContext
+		if latest, err := m.store.GetLatestByInstance(m.ctx, alert.TargetName, alert.InstanceName); err == nil && latest != nil {
+			ctx = NewRuleContext(latest)
+		}
+		m.sendNotifications(repeatAlert(alert, now), ctx, models.DeliveryEventRepeat)
+
+		notifiedAt := now
+		alert.NotifiedAt = &notifiedAt
+		if err := m.store.UpdateAlert(m.ctx, alert); err != nil {
+			log.Printf("Alerter: failed to update alert after repeat notification: %v", err)
+		}
+
+		log.Printf("Alerter: repeated alert %s for %s/%s (firing since %s)",
+			alert.RuleName, alert.TargetName, alert.InstanceName, alert.FiredAt.Format(time.RFC3339))
+	}
+}
+
+// shouldRepeat checks if a fired alert is due for another notification
+// Acknowledged alerts are not repeated
+func shouldRepeat(alert *models.Alert, interval time.Duration, now time.Time) bool {
+	if interval <= 0 || alert.Status != models.AlertStatusFired || alert.AcknowledgedAt != nil {
+		return false
+	}
+	last := alert.FiredAt
+	if alert.NotifiedAt != nil {
+		last = *alert.NotifiedAt
+	}
+	return now.Sub(last) >= interval
+}
+
+// repeatAlert returns a copy of the alert with a reminder message
+func repeatAlert(alert *models.Alert, now time.Time) *models.Alert {
+	repeat := *alert
+	repeat.Message = fmt.Sprintf("[Repeat] %s (firing for %s)",
+		alert.Message, now.Sub(alert.FiredAt).Round(time.Second))
+	return &repeat
+}
+
 // resolveAlert marks an alert as resolved
-func (m *Manager) resolveAlert(alert *models.Alert) {
+func (m *Manager) resolveAlert(alert *models.Alert, ctx *RuleContext) {
 	now := time.Now()
 	alert.Status = models.AlertStatusResolved
 	alert.ResolvedAt = &now
+	flapping := m.recordChange(alert, now)
 
-	if err := m.store.UpdateAlert(alert); err != nil {
+	if err := m.store.UpdateAlert(m.ctx, alert); err != nil {
 		log.Printf("Alerter: failed to update resolved alert: %v", err)
 		return
 	}
 
-	// Send resolution notifications
-	m.sendResolutionNotifications(alert)
+	// Send resolution notifications, unless the rule is flapping
+	if flapping {
+		log.Printf("Alerter: resolved flapping alert %s for %s/%s, notification held back",
+			alert.RuleName, alert.TargetName, alert.InstanceName)
+		return
+	}
+	m.sendResolutionNotifications(alert, ctx)
 
 	log.Printf("Alerter: resolved alert %s for %s/%s",
 		alert.RuleName, alert.TargetName, alert.InstanceName)
 }
 
-// sendNotifications sends alert to all enabled channels
-func (m *Manager) sendNotifications(alert *models.Alert) {
-	m.mu.RLock()
-	channels := m.channels
-	m.mu.RUnlock()
-
-	for _, ch := range channels {
-		if ch.IsEnabled() {
-			if err := ch.Send(alert); err != nil {
-				log.Printf("Alerter: failed to send to %s: %v", ch.Name(), err)
-			}
+// sendNotifications sends alert to the enabled channels its rule routes to
+// ctx may be nil when the triggering metrics are not available
+func (m *Manager) sendNotifications(alert *models.Alert, ctx *RuleContext, event string) {
+	m.linkAlert(alert)
+	for _, ch := range m.routedChannels(alert.RuleName) {
+		err := m.deliver(ch, []int64{alert.ID}, event, func() error {
+			return sendAlert(ch, alert, ctx)
+		})
+		if err != nil {
+			log.Printf("Alerter: failed to send to %s: %v", ch.Name(), err)
 		}
 	}
 }
 
-// sendResolutionNotifications sends resolution to all enabled channels
-func (m *Manager) sendResolutionNotifications(alert *models.Alert) {
-	m.mu.RLock()
-	channels := m.channels
-	m.mu.RUnlock()
+// sendAlert sends an alert to one channel, using the rule context when supported
+func sendAlert(ch Channel, alert *models.Alert, ctx *RuleContext) error {
+	if tc, ok := ch.(TemplatedChannel); ok {
+		return tc.SendWithContext(alert, ctx)
+	}
+	return ch.Send(alert)
+}
 
-	for _, ch := range channels {
-		if ch.IsEnabled() {
-			if err := ch.SendResolved(alert); err != nil {
-				log.Printf("Alerter: failed to send resolution to %s: %v", ch.Name(), err)
+// sendResolutionNotifications sends resolution to the channels the rule routes to
+func (m *Manager) sendResolutionNotifications(alert *models.Alert, ctx *RuleContext) {
+	m.linkAlert(alert)
+	for _, ch := range m.routedChannels(alert.RuleName) {
+		err := m.deliver(ch, []int64{alert.ID}, models.DeliveryEventResolved, func() error {
+			if tc, ok := ch.(TemplatedChannel); ok {
+				return tc.SendResolvedWithContext(alert, ctx)
 			}
+			return ch.SendResolved(alert)
+		})
+		if err != nil {
+			log.Printf("Alerter: failed to send resolution to %s: %v", ch.Name(), err)
 		}
 	}
 }
@@ -335,13 +845,11 @@ func (m *Manager) alertKey(target, instance, rule string) string {
 	return target + "/" + instance + "/" + rule
 }
 
-// getEnabledChannelNames returns comma-separated list of enabled channel names
-func (m *Manager) getEnabledChannelNames() string {
+// getRoutedChannelNames returns comma-separated list of channels a rule's alerts are sent to
+func (m *Manager) getRoutedChannelNames(ruleName string) string {
 	var names []string
-	for _, ch := range m.channels {
-		if ch.IsEnabled() {
-			names = append(names, ch.Name())
-		}
+	for _, ch := range m.routedChannels(ruleName) {
+		names = append(names, ch.Name())
 	}
 	return strings.Join(names, ",")
 }
@@ -387,7 +895,7 @@ func (m *Manager) TestAlertWithOptions(opts TestAlertOptions) error {
 	if len(opts.Channels) > 0 {
 		m.sendToChannels(alert, opts.Channels)
 	} else {
-		m.sendNotifications(alert)
+		m.sendNotifications(alert, nil, models.DeliveryEventTest)
 	}
 
 	return nil
@@ -395,6 +903,7 @@ func (m *Manager) TestAlertWithOptions(opts TestAlertOptions) error {
 
 // sendToChannels sends alert to specific channels
 func (m *Manager) sendToChannels(alert *models.Alert, channelNames []string) {
+	m.linkAlert(alert)
 	m.mu.RLock()
 	channels := m.channels
 	m.mu.RUnlock()
@@ -406,7 +915,10 @@ func (m *Manager) sendToChannels(alert *models.Alert, channelNames []string) {
 
 	for _, ch := range channels {
 		if channelSet[strings.ToLower(ch.Name())] {
-			if err := ch.Send(alert); err != nil {
+			err := m.deliver(ch, []int64{alert.ID}, models.DeliveryEventTest, func() error {
+				return ch.Send(alert)
+			})
+			if err != nil {
 				log.Printf("Alerter: failed to send to %s: %v", ch.Name(), err)
 			}
 		}
@@ -428,11 +940,14 @@ func (m *Manager) GetEnabledChannels() []string {
 }
 
 // GetStats returns alert statistics
-func (m *Manager) GetStats() (*models.AlertStats, error) {
-	return m.store.GetAlertStats()
+func (m *Manager) GetStats(ctx context.Context) (*models.AlertStats, error) {
+	return m.store.GetAlertStats(ctx)
 }
 
 // Stop stops the alert manager
+// Alerts waiting for a digest are sent before returning
 func (m *Manager) Stop() {
 	close(m.stop)
+	m.flushAllGroups()
+	m.cancel()
 }
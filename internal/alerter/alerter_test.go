@@ -0,0 +1,200 @@
+package alerter
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestShouldRepeat(t *testing.T) {
+	now := time.Now()
+	notified := now.Add(-10 * time.Minute)
+
+	tests := []struct {
+		name     string
+		alert    models.Alert
+		interval time.Duration
+		want     bool
+	}{
+		{"disabled", models.Alert{Status: models.AlertStatusFired, FiredAt: now.Add(-time.Hour)}, 0, false},
+		{"resolved", models.Alert{Status: models.AlertStatusResolved, FiredAt: now.Add(-time.Hour)}, time.Minute, false},
+		{"due since fired", models.Alert{Status: models.AlertStatusFired, FiredAt: now.Add(-time.Hour)}, 30 * time.Minute, true},
+		{"not due since notified", models.Alert{Status: models.AlertStatusFired, FiredAt: now.Add(-time.Hour), NotifiedAt: &notified}, 30 * time.Minute, false},
+		{"due since notified", models.Alert{Status: models.AlertStatusFired, FiredAt: now.Add(-time.Hour), NotifiedAt: &notified}, 5 * time.Minute, true},
+		{"acknowledged", models.Alert{Status: models.AlertStatusFired, FiredAt: now.Add(-time.Hour), AcknowledgedAt: &notified}, 5 * time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRepeat(&tt.alert, tt.interval, now); got != tt.want {
+				t.Errorf("shouldRepeat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepeatAlert(t *testing.T) {
+	now := time.Now()
+	alert := &models.Alert{ID: 7, Message: "Pool usage is high", FiredAt: now.Add(-90 * time.Minute)}
+
+	repeat := repeatAlert(alert, now)
+	if repeat.ID != 7 {
+		t.Errorf("ID = %d, want 7", repeat.ID)
+	}
+	if !strings.HasPrefix(repeat.Message, "[Repeat] Pool usage is high") || !strings.Contains(repeat.Message, "1h30m0s") {
+		t.Errorf("Unexpected message: %s", repeat.Message)
+	}
+	if alert.Message != "Pool usage is high" {
+		t.Error("repeatAlert should not modify the original alert")
+	}
+}
+
+func TestCheck_SuppressedDuringMaintenance(t *testing.T) {
+	for _, fireAfter := range []bool{true, false} {
+		store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "suppressed.db"))
+		if err != nil {
+			t.Fatalf("failed to create storage: %v", err)
+		}
+		defer store.Close()
+
+		m := NewManager(store, &config.AlertingConfig{
+			Enabled:              true,
+			Rules:                []config.AlertRule{{Name: "high_usage", Condition: "usage > 80", Severity: "warning"}},
+			FireAfterMaintenance: &fireAfter,
+		})
+		defer m.Stop()
+
+		now := time.Now()
+		window := &models.MaintenanceWindow{Name: "deploy", TargetName: "orders", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+		if err := store.SaveMaintenanceWindow(context.Background(), window); err != nil {
+			t.Fatalf("SaveMaintenanceWindow() error = %v", err)
+		}
+
+		busy := &models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 9, Max: 10, Timestamp: now}
+		m.Check(busy)
+		m.Check(busy)
+		alertsWith := func(status string) []models.Alert {
+			alerts, err := store.GetAlerts(context.Background(), status, 10)
+			if err != nil {
+				t.Fatalf("GetAlerts() error = %v", err)
+			}
+			return alerts
+		}
+		if got := alertsWith(models.AlertStatusSuppressed); len(got) != 1 || got[0].NotifiedAt != nil {
+			t.Fatalf("suppressed alerts = %+v, want one not notified", got)
+		}
+		if got := alertsWith(models.AlertStatusFired); len(got) != 0 {
+			t.Fatalf("fired alerts during maintenance = %+v, want none", got)
+		}
+
+		// The window ends while the condition still holds
+		if err := store.DeleteMaintenanceWindow(context.Background(), window.ID); err != nil {
+			t.Fatalf("DeleteMaintenanceWindow() error = %v", err)
+		}
+		m.Check(busy)
+		fired := alertsWith(models.AlertStatusFired)
+		suppressed := alertsWith(models.AlertStatusSuppressed)[0]
+		if fireAfter {
+			if len(fired) != 1 || suppressed.ResolvedAt == nil {
+				t.Errorf("fire_after_maintenance: fired = %+v, suppressed = %+v, want fired and suppressed closed", fired, suppressed)
+			}
+			continue
+		}
+		if len(fired) != 0 || suppressed.ResolvedAt != nil {
+			t.Errorf("fired = %+v, suppressed = %+v, want still suppressed", fired, suppressed)
+		}
+
+		// The condition clears
+		m.Check(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 1, Max: 10, Timestamp: now})
+		if suppressed := alertsWith(models.AlertStatusSuppressed)[0]; suppressed.ResolvedAt == nil {
+			t.Errorf("suppressed alert should be closed once the condition clears")
+		}
+	}
+}
+
+func TestNewManager_RestoresCooldowns(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "cooldown.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	// Alerts that fired and resolved before the restart, one of them within the cooldown
+	now := time.Now()
+	for _, a := range []struct {
+		instance string
+		firedAt  time.Time
+	}{{"a", now.Add(-time.Minute)}, {"b", now.Add(-10 * time.Minute)}} {
+		resolvedAt := a.firedAt.Add(30 * time.Second)
+		alert := &models.Alert{TargetName: "orders", InstanceName: a.instance, RuleName: "high_usage", Severity: "warning",
+			Status: models.AlertStatusResolved, FiredAt: a.firedAt, ResolvedAt: &resolvedAt}
+		if err := store.SaveAlert(context.Background(), alert); err != nil {
+			t.Fatalf("SaveAlert() error = %v", err)
+		}
+	}
+
+	m := NewManager(store, &config.AlertingConfig{
+		Enabled:  true,
+		Cooldown: 5 * time.Minute,
+		Rules:    []config.AlertRule{{Name: "high_usage", Condition: "usage > 80", Severity: "warning"}},
+	})
+	defer m.Stop()
+
+	for _, instance := range []string{"a", "b"} {
+		m.Check(&models.PoolMetrics{TargetName: "orders", InstanceName: instance, Active: 9, Max: 10, Timestamp: now})
+	}
+	fired, err := store.GetAlerts(context.Background(), models.AlertStatusFired, 10)
+	if err != nil {
+		t.Fatalf("GetAlerts() error = %v", err)
+	}
+	if len(fired) != 1 || fired[0].InstanceName != "b" {
+		t.Errorf("fired alerts = %+v, want only b, whose cooldown passed before the restart", fired)
+	}
+}
+
+func TestCheck_ResolveCondition(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "resolve.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	m := NewManager(store, &config.AlertingConfig{
+		Enabled: true,
+		Rules: []config.AlertRule{{Name: "high_usage", Condition: "usage > 90", ResolveCondition: "usage < 80",
+			Severity: "warning"}},
+	})
+	defer m.Stop()
+
+	active := func() *models.Alert {
+		t.Helper()
+		alert, err := store.GetActiveAlertByRule(context.Background(), "orders", "a", "high_usage")
+		if err != nil {
+			t.Fatalf("GetActiveAlertByRule() error = %v", err)
+		}
+		return alert
+	}
+	check := func(active int) {
+		m.Check(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: active, Max: 100, Timestamp: time.Now()})
+	}
+
+	check(95)
+	if active() == nil {
+		t.Fatal("alert should fire above 90")
+	}
+	// Dipping below the firing threshold keeps the alert active until the resolve condition holds
+	check(85)
+	if active() == nil {
+		t.Error("alert should stay active at 85")
+	}
+	check(75)
+	if alert := active(); alert != nil {
+		t.Errorf("alert = %+v, want resolved below 80", alert)
+	}
+}
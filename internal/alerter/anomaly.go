@@ -0,0 +1,152 @@
+package alerter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/jiin/pondy/internal/analyzer"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// anomalyRiskRank orders the anomaly risk levels; unknown (too little data) never fires
+var anomalyRiskRank = map[string]int{
+	"elevated": 1,
+	"high":     2,
+}
+
+// AnomalyChecker periodically runs the anomaly detector for anomaly rules
+// and fires or resolves their alerts per target
+type AnomalyChecker struct {
+	cfgMgr *config.Manager
+	store  storage.Storage
+	alerts *Manager
+	cancel context.CancelFunc
+}
+
+// NewAnomalyChecker creates an anomaly rule checker
+func NewAnomalyChecker(cfgMgr *config.Manager, store storage.Storage, alerts *Manager) *AnomalyChecker {
+	return &AnomalyChecker{
+		cfgMgr: cfgMgr,
+		store:  store,
+		alerts: alerts,
+	}
+}
+
+// Start begins checking anomaly rules every interval
+func (a *AnomalyChecker) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.check(ctx, time.Now())
+			}
+		}
+	}()
+
+	log.Printf("Anomaly checker started: interval=%v", interval)
+}
+
+// Stop stops the anomaly rule checks
+func (a *AnomalyChecker) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+}
+
+// check evaluates every enabled anomaly rule against every target it applies to
+func (a *AnomalyChecker) check(ctx context.Context, now time.Time) {
+	cfg := a.cfgMgr.Get()
+	loc := cfg.GetLocation()
+
+	for _, rule := range cfg.Alerting.Rules {
+		if !rule.IsAnomaly() || !rule.IsEnabled() {
+			continue
+		}
+		if err := rule.Anomaly.Validate(); err != nil {
+			log.Printf("Alerter: anomaly rule %s: %v", rule.Name, err)
+			continue
+		}
+
+		for _, target := range cfg.Targets {
+			if target.Paused || !rule.Anomaly.AppliesTo(target.Name) || !rule.AppliesToTarget(target.Name, target.Group, target.Labels) {
+				continue
+			}
+			rule := rule
+			if err := a.checkTarget(ctx, &rule, target, now, loc); err != nil {
+				log.Printf("Alerter: failed to check anomaly rule %s for %s: %v", rule.Name, target.Name, err)
+			}
+		}
+	}
+}
+
+// checkTarget runs the detector over the rule's window and reports the result
+func (a *AnomalyChecker) checkTarget(ctx context.Context, rule *config.AlertRule, target config.TargetConfig, now time.Time, loc *time.Location) error {
+	window := rule.Anomaly.GetWindow()
+	metrics, err := a.store.GetHistory(ctx, target.Name, now.Add(-window), now)
+	if err != nil {
+		return err
+	}
+
+	opts := AnomalyRuleOptions(rule, target)
+	result := analyzer.DetectAnomaliesWithOptions(target.Name, metrics, loc, opts)
+	triggered := anomalyRiskRank[result.RiskLevel] >= anomalyRiskRank[rule.Anomaly.GetRiskLevel()]
+
+	// The alert belongs to the target, so the latest sample only fills in the template context
+	latest := &models.PoolMetrics{TargetName: target.Name}
+	if len(metrics) > 0 {
+		latest = &metrics[len(metrics)-1]
+	}
+	ruleCtx := NewRuleContext(latest)
+	ruleCtx.InstanceName = ""
+	ruleCtx.AnomalyRisk = result.RiskLevel
+	ruleCtx.AnomalyCount = len(result.Anomalies)
+	ruleCtx.AnomalyURL = anomalyURL(target.Name, window, opts)
+
+	fired := *rule
+	if fired.Message == "" {
+		fired.Message = fmt.Sprintf("Anomaly risk is %s for %s: %d anomalies in the last %s (%s). Details: %s",
+			result.RiskLevel, target.Name, len(result.Anomalies), window, result.Algorithm, ruleCtx.AnomalyURL)
+	}
+	a.alerts.Report(&fired, ruleCtx, triggered)
+	return nil
+}
+
+// AnomalyRuleOptions returns the detector settings of an anomaly rule for a target:
+// the rule's algorithm and sensitivity, falling back to the target's anomaly config
+func AnomalyRuleOptions(rule *config.AlertRule, target config.TargetConfig) *analyzer.AnomalyOptions {
+	opts := &analyzer.AnomalyOptions{
+		Algorithm:   target.Anomaly.GetAlgorithm(),
+		Sensitivity: target.Anomaly.GetSensitivity(),
+	}
+	if rule.Anomaly != nil {
+		if rule.Anomaly.Algorithm != "" {
+			opts.Algorithm = rule.Anomaly.Algorithm
+		}
+		if rule.Anomaly.Sensitivity != "" {
+			opts.Sensitivity = rule.Anomaly.Sensitivity
+		}
+	}
+	return opts
+}
+
+// anomalyURL returns the API path showing the anomalies an alert fired on
+func anomalyURL(target string, window time.Duration, opts *analyzer.AnomalyOptions) string {
+	query := url.Values{}
+	query.Set("range", window.String())
+	query.Set("algorithm", opts.Algorithm)
+	query.Set("sensitivity", opts.Sensitivity)
+	return fmt.Sprintf("/api/v1/targets/%s/anomalies?%s", url.PathEscape(target), query.Encode())
+}
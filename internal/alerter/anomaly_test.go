@@ -0,0 +1,55 @@
+package alerter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestAnomalyRuleOptions(t *testing.T) {
+	target := config.TargetConfig{Name: "orders", Anomaly: &config.AnomalyConfig{Algorithm: "mad", Sensitivity: "low"}}
+
+	opts := AnomalyRuleOptions(&config.AlertRule{Type: config.AlertRuleTypeAnomaly}, target)
+	if opts.Algorithm != "mad" || opts.Sensitivity != "low" {
+		t.Errorf("options = %s/%s, want the target's mad/low", opts.Algorithm, opts.Sensitivity)
+	}
+
+	rule := &config.AlertRule{Type: config.AlertRuleTypeAnomaly, Anomaly: &config.AnomalyRuleConfig{Algorithm: "iqr"}}
+	opts = AnomalyRuleOptions(rule, target)
+	if opts.Algorithm != "iqr" || opts.Sensitivity != "low" {
+		t.Errorf("options = %s/%s, want the rule's iqr with the target's low", opts.Algorithm, opts.Sensitivity)
+	}
+
+	opts = AnomalyRuleOptions(rule, config.TargetConfig{Name: "billing"})
+	if opts.Sensitivity != "medium" {
+		t.Errorf("Sensitivity = %s, want medium by default", opts.Sensitivity)
+	}
+}
+
+func TestAnomalyURL(t *testing.T) {
+	opts := AnomalyRuleOptions(&config.AlertRule{}, config.TargetConfig{Name: "order service"})
+	got := anomalyURL("order service", 30*time.Minute, opts)
+	want := "/api/v1/targets/order%20service/anomalies?algorithm=zscore&range=30m0s&sensitivity=medium"
+	if got != want {
+		t.Errorf("anomalyURL() = %s, want %s", got, want)
+	}
+}
+
+func TestAnomalyRiskRank(t *testing.T) {
+	tests := []struct {
+		risk, threshold string
+		want            bool
+	}{
+		{"high", "elevated", true},
+		{"elevated", "elevated", true},
+		{"elevated", "high", false},
+		{"normal", "elevated", false},
+		{"unknown", "elevated", false},
+	}
+	for _, tt := range tests {
+		if got := anomalyRiskRank[tt.risk] >= anomalyRiskRank[tt.threshold]; got != tt.want {
+			t.Errorf("risk %s at threshold %s fires = %v, want %v", tt.risk, tt.threshold, got, tt.want)
+		}
+	}
+}
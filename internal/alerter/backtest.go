@@ -0,0 +1,159 @@
+package alerter
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/expr"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// BacktestOptions are the notification settings a backtest replays with
+type BacktestOptions struct {
+	From           time.Time     // Samples before From are only used as history of windowed aggregates
+	Cooldown       time.Duration // Minimum time between fires of the rule on an instance
+	RepeatInterval time.Duration // Re-notify while fired (0 = disabled)
+}
+
+// BacktestResult describes when a rule would have fired and resolved over past samples
+type BacktestResult struct {
+	Samples       int             `json:"samples"` // Samples the rule was evaluated against
+	Fires         int             `json:"fires"`
+	Resolves      int             `json:"resolves"`
+	Repeats       int             `json:"repeats"`
+	Notifications int             `json:"notifications"` // Fired, resolved and repeat notifications
+	FiringSeconds float64         `json:"firing_seconds"`
+	Errors        int             `json:"errors,omitempty"` // Samples the condition could not be evaluated on
+	LastError     string          `json:"last_error,omitempty"`
+	Alerts        []BacktestAlert `json:"alerts"`
+}
+
+// BacktestAlert is an alert a backtest would have fired
+// ResolvedAt is nil when the alert would still be firing at the end of the range
+type BacktestAlert struct {
+	InstanceName string     `json:"instance_name"`
+	FiredAt      time.Time  `json:"fired_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	Repeats      int        `json:"repeats,omitempty"`
+}
+
+// ConditionWindow returns the longest window of the aggregates in a condition, e.g., 5m for "avg(usage, 5m) > 80"
+func ConditionWindow(condition string) time.Duration {
+	var window time.Duration
+	for _, side := range parseCondition(strings.TrimSpace(condition)) {
+		if e, err := expr.Parse(side); err == nil {
+			window = max(window, e.MaxWindow())
+		}
+	}
+	return window
+}
+
+// Backtest replays samples through the rule engine in time order, instance by instance
+// Cooldown and repeat notifications apply as in the alert manager; silences, maintenance
+// windows and grouping are not replayed
+func Backtest(rule *config.AlertRule, samples []models.PoolMetrics, opts BacktestOptions) *BacktestResult {
+	result := &BacktestResult{Alerts: []BacktestAlert{}}
+
+	byInstance := make(map[string][]models.PoolMetrics)
+	for _, s := range samples {
+		byInstance[s.InstanceName] = append(byInstance[s.InstanceName], s)
+	}
+	instances := make([]string, 0, len(byInstance))
+	for name := range byInstance {
+		instances = append(instances, name)
+	}
+	sort.Strings(instances)
+
+	for _, instance := range instances {
+		history := byInstance[instance]
+		slices.SortFunc(history, func(a, b models.PoolMetrics) int {
+			return a.Timestamp.Compare(b.Timestamp)
+		})
+		result.Alerts = append(result.Alerts, backtestInstance(rule, history, opts, result)...)
+	}
+
+	sort.SliceStable(result.Alerts, func(i, j int) bool {
+		return result.Alerts[i].FiredAt.Before(result.Alerts[j].FiredAt)
+	})
+	return result
+}
+
+// backtestInstance replays the samples of one instance, adding to the result's counts
+func backtestInstance(rule *config.AlertRule, history []models.PoolMetrics, opts BacktestOptions, result *BacktestResult) []BacktestAlert {
+	var alerts []BacktestAlert
+	var open *BacktestAlert
+	var lastFired, lastNotified time.Time
+
+	for i := range history {
+		sample := &history[i]
+		if sample.Timestamp.Before(opts.From) {
+			continue
+		}
+
+		ctx := NewRuleContext(sample)
+		ctx.SetHistory(func(from, to time.Time) ([]models.PoolMetrics, error) {
+			return history[:i], nil
+		})
+		if !ctx.appliesTo(rule) {
+			continue
+		}
+		result.Samples++
+
+		triggered, err := EvaluateRule(rule, ctx)
+		if err != nil {
+			result.Errors++
+			result.LastError = err.Error()
+			continue
+		}
+
+		now := sample.Timestamp
+		switch {
+		case triggered:
+			// The cooldown is reserved by every triggered evaluation past it, as in trigger
+			if !lastFired.IsZero() && now.Sub(lastFired) < opts.Cooldown {
+				break
+			}
+			lastFired = now
+			if open == nil {
+				alerts = append(alerts, BacktestAlert{InstanceName: sample.InstanceName, FiredAt: now})
+				open = &alerts[len(alerts)-1]
+				lastNotified = now
+				result.Fires++
+				result.Notifications++
+			}
+		case open != nil:
+			// With a resolve condition, the alert stays open between the two thresholds
+			if rule.ResolveCondition != "" {
+				resolved, err := ResolveRule(rule, ctx)
+				if err != nil {
+					result.Errors++
+					result.LastError = err.Error()
+				}
+				if err != nil || !resolved {
+					break
+				}
+			}
+			resolved := now
+			open.ResolvedAt = &resolved
+			result.FiringSeconds += now.Sub(open.FiredAt).Seconds()
+			result.Resolves++
+			result.Notifications++
+			open = nil
+		}
+
+		if open != nil && opts.RepeatInterval > 0 && now.Sub(lastNotified) >= opts.RepeatInterval {
+			open.Repeats++
+			lastNotified = now
+			result.Repeats++
+			result.Notifications++
+		}
+	}
+
+	if open != nil && len(history) > 0 {
+		result.FiringSeconds += history[len(history)-1].Timestamp.Sub(open.FiredAt).Seconds()
+	}
+	return alerts
+}
@@ -0,0 +1,64 @@
+package alerter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestBacktest(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// One sample a minute; pending spikes at minutes 2-4, 6 and 8-14
+	pending := []int{0, 0, 8, 9, 7, 0, 8, 0, 6, 6, 6, 6, 6, 6, 6, 0}
+	var samples []models.PoolMetrics
+	for i, p := range pending {
+		samples = append(samples, models.PoolMetrics{
+			TargetName:   "orders",
+			InstanceName: "a",
+			Status:       models.StatusHealthy,
+			Pending:      p,
+			Max:          10,
+			Timestamp:    start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	rule := &config.AlertRule{Name: "test", Condition: "pending > 5"}
+
+	got := Backtest(rule, samples, BacktestOptions{From: start, Cooldown: 5 * time.Minute, RepeatInterval: 5 * time.Minute})
+	if got.Samples != len(pending) {
+		t.Errorf("Samples = %d, want %d", got.Samples, len(pending))
+	}
+	// The spike at minute 6 is within the cooldown of the fire at minute 2
+	if got.Fires != 2 || got.Resolves != 2 || len(got.Alerts) != 2 {
+		t.Fatalf("expected 2 fires and resolves, got %+v", got)
+	}
+	first, second := got.Alerts[0], got.Alerts[1]
+	if !first.FiredAt.Equal(start.Add(2*time.Minute)) || !first.ResolvedAt.Equal(start.Add(5*time.Minute)) {
+		t.Errorf("unexpected first alert: %+v", first)
+	}
+	if !second.FiredAt.Equal(start.Add(8*time.Minute)) || second.Repeats != 1 {
+		t.Errorf("unexpected second alert: %+v", second)
+	}
+	if got.Notifications != 5 || got.FiringSeconds != (3+7)*60 {
+		t.Errorf("Notifications = %d, FiringSeconds = %v, want 5 and 600", got.Notifications, got.FiringSeconds)
+	}
+
+	// Samples before From only serve as history of windowed aggregates: at minute 10 the
+	// average still includes the idle minute 7
+	windowed := &config.AlertRule{Name: "test", Condition: "avg(pending, 3m) > 5"}
+	if w := ConditionWindow(windowed.Condition); w != 3*time.Minute {
+		t.Fatalf("ConditionWindow = %v, want 3m", w)
+	}
+	got = Backtest(windowed, samples, BacktestOptions{From: start.Add(10 * time.Minute)})
+	if got.Samples != 6 || got.Fires != 1 || !got.Alerts[0].FiredAt.Equal(start.Add(11*time.Minute)) {
+		t.Errorf("expected a fire at minute 11, got %+v", got)
+	}
+
+	// With a resolve condition, the 7 at minute 4 keeps the alert fired at minute 2 active
+	hysteresis := &config.AlertRule{Name: "test", Condition: "pending > 7", ResolveCondition: "pending < 1"}
+	got = Backtest(hysteresis, samples, BacktestOptions{From: start})
+	if got.Fires != 2 || !got.Alerts[0].ResolvedAt.Equal(start.Add(5*time.Minute)) {
+		t.Errorf("expected the first alert to resolve at minute 5, got %+v", got)
+	}
+}
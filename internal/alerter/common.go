@@ -3,6 +3,7 @@ package alerter
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -98,6 +99,35 @@ func GetSlackColor(severity string) string {
 	}
 }
 
+// DeliveryError carries HTTP details of a failed notification delivery
+type DeliveryError struct {
+	StatusCode int // 0 when no response was received
+	Retries    int // Retries made before giving up
+	Err        error
+}
+
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// statusError returns a DeliveryError for an HTTP error response
+func statusError(format string, statusCode int) error {
+	return &DeliveryError{StatusCode: statusCode, Err: fmt.Errorf(format, statusCode)}
+}
+
+// deliveryDetails extracts the status code and retry count from a send error
+func deliveryDetails(err error) (statusCode, retries int) {
+	var de *DeliveryError
+	if errors.As(err, &de) {
+		return de.StatusCode, de.Retries
+	}
+	return 0, 0
+}
+
 // NewHTTPClient creates a standard HTTP client for alert channels
 func NewHTTPClient() *http.Client {
 	return &http.Client{
@@ -124,7 +154,7 @@ func PostJSON(client *http.Client, url string, payload interface{}) error {
 	}
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("server returned status %d", resp.StatusCode)
+		return statusError("server returned status %d", resp.StatusCode)
 	}
 
 	return nil
@@ -1,6 +1,10 @@
 package alerter
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/jiin/pondy/internal/models"
@@ -167,3 +171,32 @@ func TestConstants(t *testing.T) {
 		t.Errorf("ColorResolved = %s, want #2ECC71", ColorResolved)
 	}
 }
+
+func TestPostJSON_StatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := PostJSON(NewHTTPClient(), srv.URL, map[string]string{"text": "hi"})
+	if err == nil {
+		t.Fatal("PostJSON() expected error")
+	}
+
+	statusCode, retries := deliveryDetails(err)
+	if statusCode != http.StatusBadGateway || retries != 0 {
+		t.Errorf("deliveryDetails() = (%d, %d), want (502, 0)", statusCode, retries)
+	}
+}
+
+func TestDeliveryDetails_Wrapped(t *testing.T) {
+	err := fmt.Errorf("send failed: %w", &DeliveryError{StatusCode: 503, Retries: 2, Err: errors.New("unavailable")})
+
+	statusCode, retries := deliveryDetails(err)
+	if statusCode != 503 || retries != 2 {
+		t.Errorf("deliveryDetails() = (%d, %d), want (503, 2)", statusCode, retries)
+	}
+	if statusCode, _ := deliveryDetails(errors.New("plain")); statusCode != 0 {
+		t.Errorf("plain error status = %d, want 0", statusCode)
+	}
+}
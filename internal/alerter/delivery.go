@@ -0,0 +1,56 @@
+package alerter
+
+import (
+	"log"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// deliver sends through one channel and records the attempt in the notification log
+// A digest delivery is recorded once per alert it contains
+func (m *Manager) deliver(ch Channel, alertIDs []int64, event string, send func() error) error {
+	start := time.Now()
+	err := send()
+	latency := time.Since(start)
+	if err != nil {
+		m.failed.Add(1)
+	} else {
+		m.sent.Add(1)
+	}
+
+	statusCode, retries := deliveryDetails(err)
+	for _, id := range alertIDs {
+		d := &models.NotificationDelivery{
+			AlertID:    id,
+			Channel:    ch.Name(),
+			Event:      event,
+			Status:     models.DeliveryStatusSuccess,
+			StatusCode: statusCode,
+			LatencyMs:  latency.Milliseconds(),
+			Retries:    retries,
+			CreatedAt:  start,
+		}
+		if err != nil {
+			d.Status = models.DeliveryStatusFailed
+			d.Error = err.Error()
+		}
+		if saveErr := m.store.SaveNotificationDelivery(m.ctx, d); saveErr != nil {
+			log.Printf("Alerter: failed to record delivery to %s: %v", ch.Name(), saveErr)
+		}
+	}
+
+	return err
+}
+
+// DeliveryStats counts notification deliveries since the manager started
+// A digest sent to one channel counts once
+type DeliveryStats struct {
+	Sent   int64 `json:"sent"`
+	Failed int64 `json:"failed"`
+}
+
+// DeliveryStats returns the delivery counters
+func (m *Manager) DeliveryStats() DeliveryStats {
+	return DeliveryStats{Sent: m.sent.Load(), Failed: m.failed.Load()}
+}
@@ -0,0 +1,289 @@
+package alerter
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// maxDigestLines limits how many alerts are listed in a digest message
+const maxDigestLines = 20
+
+// DigestChannel is implemented by channels that can render grouped alerts
+// Channels without digest support receive each alert individually
+type DigestChannel interface {
+	Channel
+
+	// SendDigest sends one notification for a group of fired alerts
+	SendDigest(digest *Digest) error
+}
+
+// Digest is a group of alerts fired within the grouping window
+type Digest struct {
+	TargetName string // Set when all alerts share the target
+	RuleName   string // Set when all alerts share the rule
+	Severity   string // Highest severity in the group
+	Alerts     []*models.Alert
+}
+
+// pendingGroup buffers alerts until the grouping window closes
+type pendingGroup struct {
+	alerts   []*models.Alert
+	contexts []*RuleContext
+}
+
+// groupKey builds the grouping key for an alert
+func groupKey(alert *models.Alert, by []string) string {
+	parts := make([]string, 0, len(by))
+	for _, key := range by {
+		switch key {
+		case config.GroupByRule:
+			parts = append(parts, "rule="+alert.RuleName)
+		case config.GroupByTarget:
+			parts = append(parts, "target="+alert.TargetName)
+		case config.GroupBySeverity:
+			parts = append(parts, "severity="+alert.Severity)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// NewDigest builds a digest from a group of alerts
+func NewDigest(alerts []*models.Alert) *Digest {
+	d := &Digest{Alerts: alerts}
+	if len(alerts) == 0 {
+		return d
+	}
+
+	d.TargetName = alerts[0].TargetName
+	d.RuleName = alerts[0].RuleName
+	d.Severity = alerts[0].Severity
+	for _, a := range alerts[1:] {
+		if a.TargetName != d.TargetName {
+			d.TargetName = ""
+		}
+		if a.RuleName != d.RuleName {
+			d.RuleName = ""
+		}
+		if models.SeverityRank(a.Severity) > models.SeverityRank(d.Severity) {
+			d.Severity = a.Severity
+		}
+	}
+	return d
+}
+
+// Summary describes the group, e.g., "5 instances of orders-svc: high_usage"
+func (d *Digest) Summary() string {
+	var subject string
+	if d.TargetName != "" {
+		subject = fmt.Sprintf("%d instances of %s", len(d.Alerts), d.TargetName)
+	} else {
+		subject = fmt.Sprintf("%d alerts", len(d.Alerts))
+	}
+	if d.RuleName != "" {
+		return subject + ": " + d.RuleName
+	}
+	return subject
+}
+
+// Title formats the digest title with emoji
+func (d *Digest) Title() string {
+	return fmt.Sprintf("%s Alert: %s", GetEmoji(d.Severity), d.Summary())
+}
+
+// Targets returns the distinct target names in the group
+func (d *Digest) Targets() []string {
+	seen := make(map[string]bool)
+	var targets []string
+	for _, a := range d.Alerts {
+		if !seen[a.TargetName] {
+			seen[a.TargetName] = true
+			targets = append(targets, a.TargetName)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// Lines returns one line per alert, truncated to maxDigestLines
+func (d *Digest) Lines() []string {
+	lines := make([]string, 0, len(d.Alerts))
+	for i, a := range d.Alerts {
+		if i == maxDigestLines {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(d.Alerts)-maxDigestLines))
+			break
+		}
+
+		label := a.InstanceName
+		if d.TargetName == "" {
+			label = a.TargetName + "/" + a.InstanceName
+		}
+		if d.RuleName == "" {
+			label += " [" + a.RuleName + "]"
+		}
+		lines = append(lines, fmt.Sprintf("• %s: %s", label, a.Message))
+	}
+	return lines
+}
+
+// Text returns the digest body as plain text
+func (d *Digest) Text() string {
+	return strings.Join(d.Lines(), "\n")
+}
+
+// Link returns the dashboard link of the digest's target, or empty when the alerts span
+// several targets or have no links
+func (d *Digest) Link() string {
+	if d.TargetName == "" || len(d.Alerts) == 0 || d.Alerts[0].Links == nil {
+		return ""
+	}
+	return d.Alerts[0].Links.Target
+}
+
+// enqueueGroup buffers a fired alert for a digest notification
+// Returns false when grouping is disabled and the alert should be sent immediately
+func (m *Manager) enqueueGroup(alert *models.Alert, ctx *RuleContext) bool {
+	m.mu.RLock()
+	cfg := m.cfg
+	m.mu.RUnlock()
+
+	if cfg == nil || !cfg.Grouping.Enabled {
+		return false
+	}
+
+	key := groupKey(alert, cfg.Grouping.GetBy())
+
+	m.groupMu.Lock()
+	defer m.groupMu.Unlock()
+
+	group, exists := m.groups[key]
+	if !exists {
+		group = &pendingGroup{}
+		m.groups[key] = group
+		time.AfterFunc(cfg.Grouping.GetWindow(), func() {
+			m.flushGroup(key)
+		})
+	}
+	group.alerts = append(group.alerts, alert)
+	group.contexts = append(group.contexts, ctx)
+	return true
+}
+
+// flushGroup sends the buffered alerts of a group
+func (m *Manager) flushGroup(key string) {
+	m.groupMu.Lock()
+	group, exists := m.groups[key]
+	delete(m.groups, key)
+	m.groupMu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	// Alerts resolved or escalated during the window are sent as they are now, or not at all
+	alerts := group.alerts[:0]
+	contexts := group.contexts[:0]
+	for i, buffered := range group.alerts {
+		alert, err := m.store.GetAlert(m.ctx, buffered.ID)
+		if err != nil {
+			log.Printf("Alerter: error reloading grouped alert %d: %v", buffered.ID, err)
+			continue
+		}
+		if alert == nil || alert.Status != models.AlertStatusFired {
+			continue
+		}
+		alerts = append(alerts, alert)
+		contexts = append(contexts, group.contexts[i])
+	}
+	group.alerts, group.contexts = alerts, contexts
+	if len(group.alerts) == 0 {
+		return
+	}
+
+	if len(group.alerts) == 1 {
+		m.sendNotifications(group.alerts[0], group.contexts[0], models.DeliveryEventFired)
+	} else {
+		m.sendDigest(NewDigest(group.alerts), group.contexts)
+		log.Printf("Alerter: sent digest for %d alerts (%s)", len(group.alerts), key)
+	}
+
+	for _, alert := range group.alerts {
+		m.markNotified(alert)
+	}
+}
+
+// flushAllGroups sends all buffered groups immediately
+func (m *Manager) flushAllGroups() {
+	m.groupMu.Lock()
+	keys := make([]string, 0, len(m.groups))
+	for key := range m.groups {
+		keys = append(keys, key)
+	}
+	m.groupMu.Unlock()
+
+	for _, key := range keys {
+		m.flushGroup(key)
+	}
+}
+
+// sendDigest sends a digest to all enabled channels
+// Each channel only receives the alerts whose rule routes to it
+// Channels without digest support receive each alert individually
+func (m *Manager) sendDigest(digest *Digest, contexts []*RuleContext) {
+	m.mu.RLock()
+	channels := m.channels
+	m.mu.RUnlock()
+
+	routes := make([][]string, len(digest.Alerts))
+	for i, alert := range digest.Alerts {
+		routes[i] = m.ruleRoute(alert.RuleName)
+		m.linkAlert(alert)
+	}
+
+	for _, ch := range channels {
+		if !ch.IsEnabled() {
+			continue
+		}
+
+		var alerts []*models.Alert
+		var ctxs []*RuleContext
+		for i, alert := range digest.Alerts {
+			if routesTo(routes[i], ch) {
+				alerts = append(alerts, alert)
+				ctxs = append(ctxs, contexts[i])
+			}
+		}
+
+		if dc, ok := ch.(DigestChannel); ok && len(alerts) > 1 {
+			d := digest
+			if len(alerts) < len(digest.Alerts) {
+				d = NewDigest(alerts)
+			}
+			ids := make([]int64, 0, len(d.Alerts))
+			for _, alert := range d.Alerts {
+				ids = append(ids, alert.ID)
+			}
+			err := m.deliver(ch, ids, models.DeliveryEventDigest, func() error {
+				return dc.SendDigest(d)
+			})
+			if err != nil {
+				log.Printf("Alerter: failed to send digest to %s: %v", ch.Name(), err)
+			}
+			continue
+		}
+
+		for i, alert := range alerts {
+			err := m.deliver(ch, []int64{alert.ID}, models.DeliveryEventFired, func() error {
+				return sendAlert(ch, alert, ctxs[i])
+			})
+			if err != nil {
+				log.Printf("Alerter: failed to send to %s: %v", ch.Name(), err)
+			}
+		}
+	}
+}
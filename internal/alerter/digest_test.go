@@ -0,0 +1,125 @@
+package alerter
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestGroupKey(t *testing.T) {
+	alert := &models.Alert{TargetName: "orders", InstanceName: "orders-1", RuleName: "high_usage", Severity: "warning"}
+
+	if got := groupKey(alert, []string{config.GroupByRule, config.GroupByTarget}); got != "rule=high_usage,target=orders" {
+		t.Errorf("groupKey() = %q", got)
+	}
+	if got := groupKey(alert, []string{config.GroupBySeverity}); got != "severity=warning" {
+		t.Errorf("groupKey() = %q", got)
+	}
+}
+
+func TestNewDigest(t *testing.T) {
+	var alerts []*models.Alert
+	for i := 1; i <= 5; i++ {
+		alerts = append(alerts, &models.Alert{
+			TargetName:   "orders-svc",
+			InstanceName: fmt.Sprintf("orders-%d", i),
+			RuleName:     "high_usage",
+			Severity:     models.SeverityWarning,
+			Message:      "Pool usage is high",
+		})
+	}
+	alerts[2].Severity = models.SeverityCritical
+
+	d := NewDigest(alerts)
+	if d.Summary() != "5 instances of orders-svc: high_usage" {
+		t.Errorf("Summary() = %q", d.Summary())
+	}
+	if d.Severity != models.SeverityCritical {
+		t.Errorf("Severity = %q, want critical", d.Severity)
+	}
+	if lines := d.Lines(); len(lines) != 5 || lines[0] != "• orders-1: Pool usage is high" {
+		t.Errorf("Lines() = %v", lines)
+	}
+}
+
+func TestNewDigest_MixedTargetsAndRules(t *testing.T) {
+	d := NewDigest([]*models.Alert{
+		{TargetName: "orders", InstanceName: "default", RuleName: "high_usage", Message: "a"},
+		{TargetName: "users", InstanceName: "default", RuleName: "no_idle", Message: "b"},
+	})
+
+	if d.Summary() != "2 alerts" {
+		t.Errorf("Summary() = %q", d.Summary())
+	}
+	if got := d.Lines()[1]; got != "• users/default [no_idle]: b" {
+		t.Errorf("Lines()[1] = %q", got)
+	}
+	if got := strings.Join(d.Targets(), ","); got != "orders,users" {
+		t.Errorf("Targets() = %q", got)
+	}
+}
+
+func TestDigest_LinesTruncated(t *testing.T) {
+	var alerts []*models.Alert
+	for i := 0; i < maxDigestLines+5; i++ {
+		alerts = append(alerts, &models.Alert{TargetName: "orders", InstanceName: fmt.Sprintf("i-%d", i), RuleName: "r"})
+	}
+
+	lines := NewDigest(alerts).Lines()
+	if len(lines) != maxDigestLines+1 {
+		t.Fatalf("len(Lines()) = %d, want %d", len(lines), maxDigestLines+1)
+	}
+	if lines[maxDigestLines] != "... and 5 more" {
+		t.Errorf("last line = %q", lines[maxDigestLines])
+	}
+}
+
+func TestFlushGroup_ReloadsAlerts(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "digest.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	m := NewManager(store, &config.AlertingConfig{
+		Enabled:  true,
+		Grouping: config.GroupingConfig{Enabled: true, Window: time.Hour},
+		Rules: []config.AlertRule{
+			{Name: "high_usage", Condition: "usage > 80", Severity: "warning"},
+			{Name: "pending", Condition: "pending > 5", Severity: "warning"},
+		},
+	})
+	defer m.Stop()
+	ch := &recordingChannel{}
+	m.setChannels(ch)
+
+	ctx := context.Background()
+	m.Check(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 9, Pending: 8, Max: 10, Timestamp: time.Now()})
+
+	// One alert is resolved from the API while the group waits
+	resolved, err := store.GetActiveAlertByRule(ctx, "orders", "a", "high_usage")
+	if err != nil || resolved == nil {
+		t.Fatalf("GetActiveAlertByRule() = %v, %v", resolved, err)
+	}
+	if _, err := store.ApplyAlertAction(ctx, models.AlertActionResolve, models.AlertQuery{RuleName: "high_usage"}, "ops", time.Now()); err != nil {
+		t.Fatalf("ApplyAlertAction() error = %v", err)
+	}
+
+	m.flushAllGroups()
+	if got := ch.sent(); got != "fired" {
+		t.Errorf("notifications = %q, want only the active alert", got)
+	}
+	if alert, err := store.GetAlert(ctx, resolved.ID); err != nil || alert.Status != models.AlertStatusResolved || alert.NotifiedAt != nil {
+		t.Errorf("resolved alert after flush = %+v, %v; want it left resolved and unnotified", alert, err)
+	}
+	if alert, err := store.GetActiveAlertByRule(ctx, "orders", "a", "pending"); err != nil || alert == nil || alert.NotifiedAt == nil {
+		t.Errorf("active alert after flush = %+v, %v; want it marked notified", alert, err)
+	}
+}
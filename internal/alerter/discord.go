@@ -1,7 +1,10 @@
 package alerter
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jiin/pondy/internal/config"
@@ -41,6 +44,7 @@ type DiscordMessage struct {
 // DiscordEmbed is a Discord embed
 type DiscordEmbed struct {
 	Title       string              `json:"title,omitempty"`
+	URL         string              `json:"url,omitempty"` // Makes the title a link
 	Description string              `json:"description,omitempty"`
 	Color       int                 `json:"color,omitempty"`
 	Fields      []DiscordEmbedField `json:"fields,omitempty"`
@@ -62,6 +66,14 @@ type DiscordEmbedFooter struct {
 }
 
 func (d *DiscordChannel) Send(alert *models.Alert) error {
+	return d.SendWithContext(alert, nil)
+}
+
+func (d *DiscordChannel) SendResolved(alert *models.Alert) error {
+	return d.SendResolvedWithContext(alert, nil)
+}
+
+func (d *DiscordChannel) SendWithContext(alert *models.Alert, ctx *RuleContext) error {
 	if !d.IsEnabled() {
 		return nil
 	}
@@ -71,6 +83,7 @@ func (d *DiscordChannel) Send(alert *models.Alert) error {
 		Embeds: []DiscordEmbed{
 			{
 				Title:       FormatAlertTitle(alert),
+				URL:         alertLink(alert),
 				Description: alert.Message,
 				Color:       GetColorInt(alert.Severity),
 				Fields: []DiscordEmbedField{
@@ -84,11 +97,13 @@ func (d *DiscordChannel) Send(alert *models.Alert) error {
 			},
 		},
 	}
+	addDiscordLinks(&msg.Embeds[0], alert)
+	d.applyTemplate(&msg.Embeds[0], NewTemplateData(alert, ctx, false))
 
 	return PostJSON(d.client, d.cfg.WebhookURL, msg)
 }
 
-func (d *DiscordChannel) SendResolved(alert *models.Alert) error {
+func (d *DiscordChannel) SendResolvedWithContext(alert *models.Alert, ctx *RuleContext) error {
 	if !d.IsEnabled() {
 		return nil
 	}
@@ -98,6 +113,7 @@ func (d *DiscordChannel) SendResolved(alert *models.Alert) error {
 		Embeds: []DiscordEmbed{
 			{
 				Title:       FormatResolvedTitle(alert),
+				URL:         alertLink(alert),
 				Description: alert.Message,
 				Color:       ColorResolvedInt,
 				Fields: []DiscordEmbedField{
@@ -110,6 +126,70 @@ func (d *DiscordChannel) SendResolved(alert *models.Alert) error {
 			},
 		},
 	}
+	addDiscordLinks(&msg.Embeds[0], alert)
+	d.applyTemplate(&msg.Embeds[0], NewTemplateData(alert, ctx, true))
+
+	return PostJSON(d.client, d.cfg.WebhookURL, msg)
+}
+
+// addDiscordLinks adds the dashboard and report links of an alert as a field
+func addDiscordLinks(embed *DiscordEmbed, alert *models.Alert) {
+	if links := formatLinks(alert, markdownLink); links != "" {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{Name: "Links", Value: links})
+	}
+}
+
+// applyTemplate overrides embed parts with the custom template
+// On render error the built-in embed is kept
+func (d *DiscordChannel) applyTemplate(embed *DiscordEmbed, data *TemplateData) {
+	if d.cfg.Template.IsEmpty() {
+		return
+	}
+
+	rendered, err := RenderTemplate(d.cfg.Template, data, false)
+	if err != nil {
+		log.Printf("Discord: template render failed, using built-in: %v", err)
+		return
+	}
+
+	if rendered.Title != "" {
+		embed.Title = rendered.Title
+	}
+	if rendered.Body != "" {
+		embed.Description = rendered.Body
+	}
+	if len(rendered.Fields) > 0 {
+		embed.Fields = make([]DiscordEmbedField, 0, len(rendered.Fields))
+		for _, f := range rendered.Fields {
+			embed.Fields = append(embed.Fields, DiscordEmbedField{Name: f.Name, Value: f.Value, Inline: true})
+		}
+	}
+}
+
+func (d *DiscordChannel) SendDigest(digest *Digest) error {
+	if !d.IsEnabled() {
+		return nil
+	}
+
+	msg := DiscordMessage{
+		Username: DefaultUsername,
+		Embeds: []DiscordEmbed{
+			{
+				Title:       digest.Title(),
+				URL:         digest.Link(),
+				Description: digest.Text(),
+				Color:       GetColorInt(digest.Severity),
+				Fields: []DiscordEmbedField{
+					{Name: "Targets", Value: strings.Join(digest.Targets(), ", "), Inline: true},
+					{Name: "Alerts", Value: strconv.Itoa(len(digest.Alerts)), Inline: true},
+					{Name: "Severity", Value: digest.Severity, Inline: true},
+					{Name: "Status", Value: "Fired", Inline: true},
+				},
+				Footer:    &DiscordEmbedFooter{Text: FooterText},
+				Timestamp: time.Now().Format(time.RFC3339),
+			},
+		},
+	}
 
 	return PostJSON(d.client, d.cfg.WebhookURL, msg)
 }
@@ -40,6 +40,14 @@ func (e *EmailChannel) IsEnabled() bool {
 }
 
 func (e *EmailChannel) Send(alert *models.Alert) error {
+	return e.SendWithContext(alert, nil)
+}
+
+func (e *EmailChannel) SendResolved(alert *models.Alert) error {
+	return e.SendResolvedWithContext(alert, nil)
+}
+
+func (e *EmailChannel) SendWithContext(alert *models.Alert, ctx *RuleContext) error {
 	if !e.IsEnabled() {
 		return nil
 	}
@@ -49,11 +57,12 @@ func (e *EmailChannel) Send(alert *models.Alert) error {
 	if err != nil {
 		return err
 	}
+	subject, body = e.applyTemplate(subject, body, NewTemplateData(alert, ctx, false))
 
 	return e.sendEmail(subject, body)
 }
 
-func (e *EmailChannel) SendResolved(alert *models.Alert) error {
+func (e *EmailChannel) SendResolvedWithContext(alert *models.Alert, ctx *RuleContext) error {
 	if !e.IsEnabled() {
 		return nil
 	}
@@ -63,10 +72,34 @@ func (e *EmailChannel) SendResolved(alert *models.Alert) error {
 	if err != nil {
 		return err
 	}
+	subject, body = e.applyTemplate(subject, body, NewTemplateData(alert, ctx, true))
 
 	return e.sendEmail(subject, body)
 }
 
+// applyTemplate overrides the subject and body with the custom template
+// On render error the built-in subject and body are kept
+func (e *EmailChannel) applyTemplate(subject, body string, data *TemplateData) (string, string) {
+	if e.cfg.Template.IsEmpty() {
+		return subject, body
+	}
+
+	rendered, err := RenderTemplate(e.cfg.Template, data, true)
+	if err != nil {
+		log.Printf("Email: template render failed, using built-in: %v", err)
+		return subject, body
+	}
+
+	if rendered.Title != "" {
+		// Header values must stay on a single line
+		subject = strings.Join(strings.Fields(rendered.Title), " ")
+	}
+	if rendered.Body != "" {
+		body = rendered.Body
+	}
+	return subject, body
+}
+
 func (e *EmailChannel) sendEmail(subject, body string) error {
 	addr := fmt.Sprintf("%s:%d", e.cfg.SMTPHost, e.cfg.SMTPPort)
 
@@ -221,6 +254,42 @@ func (e *EmailChannel) sendWithTLS(addr string, auth smtp.Auth, recipients []str
 	return client.Quit()
 }
 
+func (e *EmailChannel) SendDigest(digest *Digest) error {
+	if !e.IsEnabled() {
+		return nil
+	}
+
+	subject := fmt.Sprintf("[Pondy %s] %s", strings.ToUpper(digest.Severity), digest.Summary())
+	body, err := e.renderDigestBody(digest)
+	if err != nil {
+		return err
+	}
+
+	return e.sendEmail(subject, body)
+}
+
+func (e *EmailChannel) renderDigestBody(digest *Digest) (string, error) {
+	tmpl, err := template.New("digest").Parse(emailDigestTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		Digest *Digest
+		Time   time.Time
+	}{
+		Digest: digest,
+		Time:   time.Now(),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
 func (e *EmailChannel) renderAlertBody(alert *models.Alert, resolved bool) (string, error) {
 	tmpl, err := template.New("email").Parse(emailTemplate)
 	if err != nil {
@@ -258,6 +327,8 @@ const emailTemplate = `<!DOCTYPE html>
         .detail-row { display: flex; margin-bottom: 8px; }
         .detail-label { font-weight: 600; width: 120px; color: #666; }
         .detail-value { color: #333; }
+        .links { margin: 16px 0; }
+        .links a { display: inline-block; margin-right: 8px; padding: 8px 16px; border-radius: 4px; background: #3b82f6; color: white; text-decoration: none; font-size: 14px; }
         .footer { margin-top: 24px; padding-top: 16px; border-top: 1px solid #eee; font-size: 12px; color: #999; }
     </style>
 </head>
@@ -295,9 +366,47 @@ const emailTemplate = `<!DOCTYPE html>
             </div>
             {{end}}
         </div>
+        {{with .Alert.Links}}
+        <div class="links">
+            <a href="{{if .Alert}}{{.Alert}}{{else}}{{.Instance}}{{end}}">View in Dashboard</a>
+            <a href="{{.Report}}">Open Report</a>
+        </div>
+        {{end}}
         <div class="footer">
             This alert was sent by Pondy - JVM Connection Pool Monitor
         </div>
     </div>
 </body>
 </html>`
+
+const emailDigestTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; margin: 0; padding: 20px; background: #f5f5f5; }
+        .container { max-width: 600px; margin: 0 auto; background: white; border-radius: 8px; padding: 24px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
+        .header { padding-bottom: 16px; border-bottom: 2px solid {{if eq .Digest.Severity "critical"}}#E74C3C{{else if eq .Digest.Severity "warning"}}#F39C12{{else}}#3498DB{{end}}; }
+        .title { font-size: 20px; font-weight: 600; margin: 0; color: {{if eq .Digest.Severity "critical"}}#E74C3C{{else if eq .Digest.Severity "warning"}}#F39C12{{else}}#3498DB{{end}}; }
+        table { width: 100%; border-collapse: collapse; margin: 16px 0; }
+        th, td { text-align: left; padding: 8px; border-bottom: 1px solid #eee; font-size: 14px; }
+        th { color: #666; background: #f9f9f9; }
+        .footer { margin-top: 24px; padding-top: 16px; border-top: 1px solid #eee; font-size: 12px; color: #999; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1 class="title">{{.Digest.Title}}</h1>
+        </div>
+        <table>
+            <tr><th>Target</th><th>Instance</th><th>Rule</th><th>Severity</th><th>Message</th></tr>
+            {{range .Digest.Alerts}}
+            <tr><td>{{with .Links}}<a href="{{.Target}}">{{end}}{{.TargetName}}{{if .Links}}</a>{{end}}</td><td>{{with .Links}}<a href="{{if .Alert}}{{.Alert}}{{else}}{{.Instance}}{{end}}">{{end}}{{.InstanceName}}{{if .Links}}</a>{{end}}</td><td>{{.RuleName}}</td><td>{{.Severity}}</td><td>{{.Message}}</td></tr>
+            {{end}}
+        </table>
+        <div class="footer">
+            This digest was sent by Pondy at {{.Time.Format "2006-01-02 15:04:05"}} - JVM Connection Pool Monitor
+        </div>
+    </div>
+</body>
+</html>`
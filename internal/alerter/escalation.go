@@ -0,0 +1,94 @@
+package alerter
+
+import (
+	"log"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Severity escalation
+// An active alert is raised to its rule's escalate_severity once the escalate_condition holds,
+// e.g., a warning at "usage > 80" becomes critical at "usage >= 100", and the channels are
+// notified again. The alert keeps its original severity in escalated_from, and each step is
+// recorded in its escalation history
+
+// escalation returns the severity an alert of the rule is raised to on a context, or empty
+// when the rule has no escalate condition or it doesn't hold
+func escalation(rule *config.AlertRule, ctx *RuleContext) string {
+	if rule.EscalateCondition == "" {
+		return ""
+	}
+	escalate := *rule
+	escalate.Condition = rule.EscalateCondition
+	holds, err := EvaluateRule(&escalate, ctx)
+	if err != nil {
+		log.Printf("Alerter: rule %s escalation error: %v", rule.Name, err)
+		return ""
+	}
+	if !holds || models.SeverityRank(rule.GetEscalateSeverity()) <= models.SeverityRank(rule.Severity) {
+		return ""
+	}
+	return rule.GetEscalateSeverity()
+}
+
+// escalate raises the active alert of a rule once its escalate condition holds and
+// notifies the channels of the new severity, unless it is silenced or flapping
+func (m *Manager) escalate(rule *config.AlertRule, ctx *RuleContext, silences []models.Silence) {
+	severity := escalation(rule, ctx)
+	if severity == "" {
+		return
+	}
+
+	alert, err := m.store.GetActiveAlertByRule(m.ctx, ctx.TargetName, ctx.InstanceName, rule.Name)
+	if err != nil {
+		log.Printf("Alerter: error checking alert to escalate: %v", err)
+		return
+	}
+	if alert == nil || models.SeverityRank(alert.Severity) >= models.SeverityRank(severity) {
+		return
+	}
+
+	from := alert.Severity
+	now := time.Now()
+	if alert.EscalatedFrom == "" {
+		alert.EscalatedFrom = from
+	}
+	alert.Severity = severity
+	alert.EscalatedAt = &now
+	alert.Message = RenderMessage(rule.Message, ctx)
+	if err := m.store.UpdateAlert(m.ctx, alert); err != nil {
+		log.Printf("Alerter: failed to update escalated alert: %v", err)
+		return
+	}
+	m.recordEscalation(alert, from, now)
+	log.Printf("Alerter: escalated alert %s for %s/%s from %s to %s",
+		rule.Name, ctx.TargetName, ctx.InstanceName, from, severity)
+
+	if alert.Flapping {
+		return
+	}
+	if silence := findSilence(silences, ctx.TargetName, ctx.InstanceName, rule.Name, severity); silence != nil {
+		log.Printf("Alerter: escalation of %s for %s/%s silenced by silence #%d",
+			rule.Name, ctx.TargetName, ctx.InstanceName, silence.ID)
+		return
+	}
+	m.sendNotifications(escalatedAlert(alert, from), ctx, models.DeliveryEventEscalated)
+	m.markNotified(alert)
+}
+
+// recordEscalation adds a severity change of an alert to its escalation history
+func (m *Manager) recordEscalation(alert *models.Alert, from string, at time.Time) {
+	escalation := &models.AlertEscalation{AlertID: alert.ID, From: from, To: alert.Severity, EscalatedAt: at}
+	if err := m.store.SaveAlertEscalation(m.ctx, escalation); err != nil {
+		log.Printf("Alerter: failed to record escalation of alert %d: %v", alert.ID, err)
+	}
+}
+
+// escalatedAlert returns a copy of the alert with a message naming the severity it was raised from
+func escalatedAlert(alert *models.Alert, from string) *models.Alert {
+	escalated := *alert
+	escalated.Message = "[Escalated from " + from + "] " + alert.Message
+	return &escalated
+}
@@ -0,0 +1,142 @@
+package alerter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestEscalation(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "escalation.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	m := NewManager(store, &config.AlertingConfig{
+		Enabled: true,
+		Rules: []config.AlertRule{{Name: "high_usage", Condition: "usage > 80", EscalateCondition: "usage >= 100",
+			Severity: "warning"}},
+	})
+	defer m.Stop()
+	ch := &recordingChannel{}
+	m.setChannels(ch)
+
+	check := func(active int) *models.Alert {
+		t.Helper()
+		m.Check(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: active, Max: 10, Timestamp: time.Now()})
+		alert, err := store.GetActiveAlertByRule(context.Background(), "orders", "a", "high_usage")
+		if err != nil || alert == nil {
+			t.Fatalf("GetActiveAlertByRule() = %v, %v", alert, err)
+		}
+		return alert
+	}
+
+	if alert := check(9); alert.Severity != models.SeverityWarning || alert.EscalatedAt != nil {
+		t.Fatalf("alert = %+v, want a warning", alert)
+	}
+
+	// A saturated pool raises the open alert and notifies the channels again, once
+	check(10)
+	alert := check(10)
+	if alert.Severity != models.SeverityCritical || alert.EscalatedFrom != models.SeverityWarning || alert.EscalatedAt == nil {
+		t.Errorf("alert = %+v, want critical escalated from warning", alert)
+	}
+	if got := ch.sent(); got != "fired,fired" {
+		t.Errorf("notifications = %s, want the fire and one escalation", got)
+	}
+	deliveries, err := store.GetDeliveriesByAlert(context.Background(), alert.ID)
+	if err != nil {
+		t.Fatalf("GetDeliveriesByAlert() error = %v", err)
+	}
+	var escalated int
+	for _, d := range deliveries {
+		if d.Event == models.DeliveryEventEscalated {
+			escalated++
+		}
+	}
+	if escalated != 1 {
+		t.Errorf("escalated deliveries = %d, want 1", escalated)
+	}
+
+	// Easing off doesn't lower the severity while the alert stays active
+	if alert := check(9); alert.Severity != models.SeverityCritical {
+		t.Errorf("severity = %s, want critical until resolved", alert.Severity)
+	}
+}
+
+func TestEscalation_History(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "escalation.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	rule := config.AlertRule{Name: "high_usage", Condition: "usage > 70", EscalateCondition: "usage >= 90",
+		EscalateSeverity: models.SeverityWarning, Severity: models.SeverityInfo}
+	m := NewManager(store, &config.AlertingConfig{Enabled: true, Rules: []config.AlertRule{rule}})
+	defer m.Stop()
+	m.setChannels(&recordingChannel{})
+
+	check := func(active int) *models.Alert {
+		t.Helper()
+		m.Check(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: active, Max: 10, Timestamp: time.Now()})
+		alert, err := store.GetActiveAlertByRule(context.Background(), "orders", "a", "high_usage")
+		if err != nil || alert == nil {
+			t.Fatalf("GetActiveAlertByRule() = %v, %v", alert, err)
+		}
+		return alert
+	}
+
+	check(8)
+	check(9)
+
+	// A saturated pool raises the alert once more after the rule escalates further
+	rule.EscalateCondition = "usage >= 100"
+	rule.EscalateSeverity = models.SeverityCritical
+	m.UpdateConfig(&config.AlertingConfig{Enabled: true, Rules: []config.AlertRule{rule}})
+	m.setChannels(&recordingChannel{})
+	alert := check(10)
+	if alert.Severity != models.SeverityCritical || alert.EscalatedFrom != models.SeverityInfo {
+		t.Errorf("alert = %+v, want critical escalated from info", alert)
+	}
+
+	escalations, err := store.GetAlertEscalations(context.Background(), alert.ID)
+	if err != nil {
+		t.Fatalf("GetAlertEscalations() error = %v", err)
+	}
+	if len(escalations) != 2 {
+		t.Fatalf("escalations = %+v, want 2 steps", escalations)
+	}
+	steps := []struct{ from, to string }{
+		{models.SeverityInfo, models.SeverityWarning},
+		{models.SeverityWarning, models.SeverityCritical},
+	}
+	for i, step := range steps {
+		if e := escalations[i]; e.From != step.from || e.To != step.to || e.EscalatedAt.IsZero() {
+			t.Errorf("escalations[%d] = %+v, want %s to %s", i, e, step.from, step.to)
+		}
+	}
+}
+
+func TestEscalationSeverity(t *testing.T) {
+	rule := &config.AlertRule{Name: "high_usage", Condition: "usage > 80", EscalateCondition: "usage >= 100", Severity: "warning"}
+	if got := escalation(rule, &RuleContext{Usage: 90}); got != "" {
+		t.Errorf("escalation(usage 90) = %q, want none", got)
+	}
+	if got := escalation(rule, &RuleContext{Usage: 100}); got != models.SeverityCritical {
+		t.Errorf("escalation(usage 100) = %q, want critical", got)
+	}
+
+	// Escalating to a severity that isn't higher does nothing
+	critical := *rule
+	critical.Severity = models.SeverityCritical
+	if got := escalation(&critical, &RuleContext{Usage: 100}); got != "" {
+		t.Errorf("escalation of a critical rule = %q, want none", got)
+	}
+}
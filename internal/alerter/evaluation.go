@@ -0,0 +1,105 @@
+package alerter
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jiin/pondy/internal/collector"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// evaluationOverlap re-reads samples stored before the previous check: a sample carries the
+// time its scrape started, which may run up to the collection timeout, and is saved through
+// the write queue after that
+const evaluationOverlap = collector.CollectionTimeout + 15*time.Second
+
+// evaluationLoop evaluates the samples stored since the previous check every check_interval,
+// so alert latency doesn't depend on how samples arrive; samples already checked inline are skipped
+// With inline checks every sample is evaluated as it arrives, so storage isn't polled
+func (m *Manager) evaluationLoop() {
+	last := time.Now()
+	for {
+		m.mu.RLock()
+		interval, inline := 30*time.Second, false
+		if m.cfg != nil {
+			interval, inline = m.cfg.GetCheckInterval(), m.cfg.IsInlineCheck()
+		}
+		m.mu.RUnlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-m.stop:
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			if inline {
+				m.forgetEvaluated(now.Add(-noDataForgetAfter))
+			} else {
+				m.checkStored(last.Add(-evaluationOverlap), now)
+			}
+			last = now
+		}
+	}
+}
+
+// checkStored evaluates the samples of every target within a time range, in time order,
+// unless a newer sample of their instance has been evaluated
+func (m *Manager) checkStored(from, to time.Time) {
+	m.mu.RLock()
+	cfg := m.cfg
+	targets := make([]string, 0, len(m.intervals))
+	for target := range m.intervals {
+		targets = append(targets, target)
+	}
+	m.mu.RUnlock()
+
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+	sort.Strings(targets)
+
+	silences, err := m.store.GetActiveSilences(m.ctx)
+	if err != nil {
+		log.Printf("Alerter: error loading silences: %v", err)
+	}
+
+	for _, target := range targets {
+		samples, err := m.store.GetHistory(m.ctx, target, from, to)
+		if err != nil {
+			log.Printf("Alerter: failed to load samples of %s: %v", target, err)
+			continue
+		}
+		for i := range samples {
+			if m.markEvaluated(&samples[i]) {
+				m.evaluate(&samples[i], silences)
+			}
+		}
+	}
+	m.forgetEvaluated(to.Add(-noDataForgetAfter))
+}
+
+// markEvaluated records a sample as evaluated and reports whether it is newer than the
+// samples of its instance evaluated before
+func (m *Manager) markEvaluated(metrics *models.PoolMetrics) bool {
+	key := m.alertKey(metrics.TargetName, metrics.InstanceName, "")
+
+	m.evalMu.Lock()
+	defer m.evalMu.Unlock()
+	if last, ok := m.evaluated[key]; ok && !metrics.Timestamp.After(last) {
+		return false
+	}
+	m.evaluated[key] = metrics.Timestamp
+	return true
+}
+
+// forgetEvaluated drops the instances without samples since before, e.g., after a scale-down
+func (m *Manager) forgetEvaluated(before time.Time) {
+	m.evalMu.Lock()
+	defer m.evalMu.Unlock()
+	for key, last := range m.evaluated {
+		if last.Before(before) {
+			delete(m.evaluated, key)
+		}
+	}
+}
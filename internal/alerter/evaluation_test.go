@@ -0,0 +1,122 @@
+package alerter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestCheckStored(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "evaluation.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	inline := false
+	m := NewManager(store, &config.AlertingConfig{
+		Enabled:     true,
+		InlineCheck: &inline,
+		Rules:       []config.AlertRule{{Name: "high_usage", Condition: "usage > 80", Severity: "warning"}},
+	})
+	defer m.Stop()
+	m.SetTargetIntervals(map[string]time.Duration{"orders": 10 * time.Second})
+
+	fired := func() []models.Alert {
+		t.Helper()
+		alerts, err := store.GetAlerts(context.Background(), models.AlertStatusFired, 10)
+		if err != nil {
+			t.Fatalf("GetAlerts() error = %v", err)
+		}
+		return alerts
+	}
+
+	now := time.Now()
+	busy := models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 9, Max: 10, Timestamp: now.Add(-20 * time.Second)}
+	if err := store.Save(context.Background(), &busy); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Without inline checks, samples wait for the evaluation loop
+	m.Check(&busy)
+	if alerts := fired(); len(alerts) != 0 {
+		t.Fatalf("fired alerts = %+v, want none before the check", alerts)
+	}
+
+	m.checkStored(now.Add(-time.Minute), now)
+	if alerts := fired(); len(alerts) != 1 || alerts[0].InstanceName != "a" {
+		t.Fatalf("fired alerts = %+v, want one for instance a", alerts)
+	}
+
+	// A later sample below the threshold resolves the alert on the next check
+	idle := models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 1, Max: 10, Timestamp: now.Add(10 * time.Second)}
+	if err := store.Save(context.Background(), &idle); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	m.checkStored(now.Add(-evaluationOverlap), now.Add(30*time.Second))
+	if alerts := fired(); len(alerts) != 0 {
+		t.Errorf("fired alerts = %+v, want none after the pool calmed down", alerts)
+	}
+}
+
+func TestCheck_FailedScrapeWithoutInlineCheck(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "evaluation.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	inline := false
+	m := NewManager(store, &config.AlertingConfig{
+		Enabled:     true,
+		InlineCheck: &inline,
+		Rules:       []config.AlertRule{{Name: "scrape_down", Condition: "scrape_failures >= 3", Severity: "critical"}},
+	})
+	defer m.Stop()
+
+	// Failed scrapes aren't stored, so they're evaluated as they are reported
+	now := time.Now()
+	m.Check(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Status: models.StatusError, ScrapeFailures: 3, Timestamp: now})
+	alerts, err := store.GetAlerts(context.Background(), models.AlertStatusFired, 10)
+	if err != nil {
+		t.Fatalf("GetAlerts() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].RuleName != "scrape_down" {
+		t.Fatalf("fired alerts = %+v, want scrape_down", alerts)
+	}
+
+	// ...without marking the instance's stored samples evaluated
+	if !m.markEvaluated(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Timestamp: now.Add(-time.Second)}) {
+		t.Error("a stored sample older than the failed scrape should still be evaluated")
+	}
+}
+
+func TestMarkEvaluated(t *testing.T) {
+	m := &Manager{evaluated: make(map[string]time.Time)}
+	now := time.Now()
+	sample := &models.PoolMetrics{TargetName: "orders", InstanceName: "a", Timestamp: now}
+
+	if !m.markEvaluated(sample) {
+		t.Error("first sample should be evaluated")
+	}
+	// A sample checked inline is skipped by the evaluation loop, as are older ones
+	if m.markEvaluated(sample) {
+		t.Error("sample should only be evaluated once")
+	}
+	if m.markEvaluated(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Timestamp: now.Add(-time.Second)}) {
+		t.Error("older sample should be skipped")
+	}
+	if !m.markEvaluated(&models.PoolMetrics{TargetName: "orders", InstanceName: "b", Timestamp: now}) {
+		t.Error("instances are tracked separately")
+	}
+
+	m.forgetEvaluated(now.Add(time.Second))
+	if len(m.evaluated) != 0 {
+		t.Errorf("evaluated = %v, want all forgotten", m.evaluated)
+	}
+}
@@ -0,0 +1,177 @@
+package alerter
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Flapping detection
+// A rule that fires and resolves on an instance at least flapping.changes times within
+// flapping.window is flapping: its alerts are marked and their notifications held back until
+// a window passes without changes, when the channels get the state it settled in
+
+// flapState tracks the state changes of a rule on an instance
+type flapState struct {
+	changes  []time.Time // Fires and resolutions within the window, oldest first
+	flapping bool
+	alert    *models.Alert // Latest alert
+	notified string        // Alert status last sent to the channels, empty if unknown
+}
+
+// recordChange records a fire or resolution of an alert at the given time and reports whether
+// its rule is flapping on the instance, in which case the alert is marked flapping
+func (m *Manager) recordChange(alert *models.Alert, at time.Time) bool {
+	m.mu.RLock()
+	cfg := m.cfg
+	m.mu.RUnlock()
+
+	if cfg == nil || !cfg.Flapping.Enabled {
+		return false
+	}
+	key := m.alertKey(alert.TargetName, alert.InstanceName, alert.RuleName)
+
+	m.flapMu.Lock()
+	defer m.flapMu.Unlock()
+
+	state, ok := m.flaps[key]
+	if !ok {
+		state = &flapState{}
+		m.flaps[key] = state
+	}
+	state.changes = append(changesSince(state.changes, at.Add(-cfg.Flapping.GetWindow())), at)
+	if !state.flapping && len(state.changes) >= cfg.Flapping.GetChanges() {
+		state.flapping = true
+		log.Printf("Alerter: rule %s is flapping on %s/%s (%d changes within %s), holding back notifications",
+			alert.RuleName, alert.TargetName, alert.InstanceName, len(state.changes), cfg.Flapping.GetWindow())
+	}
+
+	state.alert = alert
+	if state.flapping {
+		alert.Flapping = true
+	} else {
+		state.notified = alert.Status
+	}
+	return state.flapping
+}
+
+// changesSince drops the changes before a time
+func changesSince(changes []time.Time, since time.Time) []time.Time {
+	i := sort.Search(len(changes), func(i int) bool { return !changes[i].Before(since) })
+	return changes[i:]
+}
+
+// settleFlapping ends the flapping of rules without changes for a window and forgets
+// the rules that stayed quiet
+func (m *Manager) settleFlapping(now time.Time) {
+	m.mu.RLock()
+	cfg := m.cfg
+	m.mu.RUnlock()
+
+	if cfg == nil {
+		return
+	}
+	window := cfg.Flapping.GetWindow()
+
+	var settled []flapState
+	m.flapMu.Lock()
+	for key, state := range m.flaps {
+		if len(state.changes) > 0 && now.Sub(state.changes[len(state.changes)-1]) < window {
+			continue
+		}
+		delete(m.flaps, key)
+		if state.flapping {
+			settled = append(settled, *state)
+		}
+	}
+	m.flapMu.Unlock()
+
+	for _, state := range settled {
+		m.notifySettled(state)
+	}
+}
+
+// notifySettled sends the state a flapping rule settled in, unless the channels already know it,
+// and clears the flapping mark of its alert while it stays active
+func (m *Manager) notifySettled(state flapState) {
+	// The alert may have been resolved or acknowledged through the API meanwhile
+	alert, err := m.store.GetAlert(m.ctx, state.alert.ID)
+	if err != nil || alert == nil {
+		log.Printf("Alerter: failed to load settled alert %d: %v", state.alert.ID, err)
+		return
+	}
+	log.Printf("Alerter: rule %s stopped flapping on %s/%s, settled %s",
+		alert.RuleName, alert.TargetName, alert.InstanceName, alert.Status)
+
+	switch alert.Status {
+	case models.AlertStatusFired:
+		alert.Flapping = false
+		if err := m.store.UpdateAlert(m.ctx, alert); err != nil {
+			log.Printf("Alerter: failed to update settled alert: %v", err)
+		}
+		if state.notified == models.AlertStatusFired {
+			return
+		}
+		m.sendNotifications(alert, nil, models.DeliveryEventFired)
+		m.markNotified(alert)
+	case models.AlertStatusResolved:
+		if state.notified != models.AlertStatusResolved {
+			m.sendResolutionNotifications(alert, nil)
+		}
+	}
+}
+
+// restoreFlapping rebuilds the state changes of the last window from the alerts, so flapping
+// rules stay dampened across a restart and alerts left flapping get settled
+func (m *Manager) restoreFlapping(now time.Time) {
+	if m.cfg == nil || !m.cfg.Flapping.Enabled {
+		return
+	}
+	since := now.Add(-m.cfg.Flapping.GetWindow())
+
+	recent, _, err := m.store.QueryAlerts(m.ctx, models.AlertQuery{From: since, SortBy: models.AlertSortFiredAt, Ascending: true})
+	if err != nil {
+		log.Printf("Alerter: failed to restore flapping state: %v", err)
+		return
+	}
+	flapping, _, err := m.store.QueryAlerts(m.ctx, models.AlertQuery{Status: models.AlertStatusFired, Flapping: true})
+	if err != nil {
+		log.Printf("Alerter: failed to restore flapping state: %v", err)
+		return
+	}
+
+	alerts := append(flapping, recent...)
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].FiredAt.Before(alerts[j].FiredAt) })
+
+	seen := make(map[int64]bool)
+	m.flapMu.Lock()
+	defer m.flapMu.Unlock()
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.Status == models.AlertStatusSuppressed || seen[alert.ID] {
+			continue
+		}
+		seen[alert.ID] = true
+		key := m.alertKey(alert.TargetName, alert.InstanceName, alert.RuleName)
+		state, ok := m.flaps[key]
+		if !ok {
+			state = &flapState{}
+			m.flaps[key] = state
+		}
+		for _, at := range []*time.Time{&alert.FiredAt, alert.ResolvedAt} {
+			if at != nil && !at.Before(since) {
+				state.changes = append(state.changes, *at)
+			}
+		}
+		state.alert = alert
+		state.flapping = alert.Flapping
+		if !alert.Flapping {
+			state.notified = alert.Status
+		}
+	}
+	for _, state := range m.flaps {
+		sort.Slice(state.changes, func(i, j int) bool { return state.changes[i].Before(state.changes[j]) })
+	}
+}
@@ -0,0 +1,158 @@
+package alerter
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// recordingChannel records the notifications sent to it
+type recordingChannel struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (c *recordingChannel) Name() string    { return "recording" }
+func (c *recordingChannel) IsEnabled() bool { return true }
+
+func (c *recordingChannel) Send(alert *models.Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, "fired")
+	return nil
+}
+
+func (c *recordingChannel) SendResolved(alert *models.Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, "resolved")
+	return nil
+}
+
+func (c *recordingChannel) sent() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return strings.Join(c.events, ",")
+}
+
+// setChannels replaces the notification channels
+func (m *Manager) setChannels(channels ...Channel) {
+	m.mu.Lock()
+	m.channels = channels
+	m.mu.Unlock()
+}
+
+func TestFlappingDampening(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "flapping.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	m := NewManager(store, &config.AlertingConfig{
+		Enabled:  true,
+		Cooldown: time.Nanosecond,
+		Flapping: config.FlappingConfig{Enabled: true, Changes: 4, Window: 15 * time.Minute},
+		Rules:    []config.AlertRule{{Name: "high_usage", Condition: "usage > 80", Severity: "warning"}},
+	})
+	defer m.Stop()
+	ch := &recordingChannel{}
+	m.setChannels(ch)
+
+	busy := &models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 9, Max: 10, Timestamp: time.Now()}
+	idle := &models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 1, Max: 10, Timestamp: time.Now()}
+
+	// The fourth change makes the rule flap: the resolution and the next fire are held back
+	for _, metrics := range []*models.PoolMetrics{busy, idle, busy, idle, busy} {
+		m.Check(metrics)
+	}
+	if got := ch.sent(); got != "fired,resolved,fired" {
+		t.Errorf("notifications = %s, want fired,resolved,fired", got)
+	}
+
+	stats, err := store.GetAlertStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetAlertStats() error = %v", err)
+	}
+	if stats.FlappingAlerts != 1 {
+		t.Errorf("FlappingAlerts = %d, want 1", stats.FlappingAlerts)
+	}
+	flapping, _, err := store.QueryAlerts(context.Background(), models.AlertQuery{Flapping: true})
+	if err != nil {
+		t.Fatalf("QueryAlerts() error = %v", err)
+	}
+	if len(flapping) != 2 {
+		t.Errorf("flapping alerts = %d, want the held back resolution and fire", len(flapping))
+	}
+
+	// Settling before a quiet window passes changes nothing
+	m.Check(idle)
+	m.settleFlapping(time.Now())
+	if got := ch.sent(); got != "fired,resolved,fired" {
+		t.Errorf("notifications while flapping = %s", got)
+	}
+
+	// Once quiet for a window, the channels learn the state the rule settled in
+	m.settleFlapping(time.Now().Add(16 * time.Minute))
+	if got := ch.sent(); got != "fired,resolved,fired,resolved" {
+		t.Errorf("notifications after settling = %s, want the final resolution", got)
+	}
+	if len(m.flaps) != 0 {
+		t.Errorf("flap states = %d, want none after settling", len(m.flaps))
+	}
+}
+
+func TestFlappingSettlesFired(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "flapping.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	cfg := &config.AlertingConfig{
+		Enabled:  true,
+		Cooldown: time.Nanosecond,
+		Flapping: config.FlappingConfig{Enabled: true, Changes: 3},
+		Rules:    []config.AlertRule{{Name: "high_usage", Condition: "usage > 80", Severity: "warning"}},
+	}
+	m := NewManager(store, cfg)
+	ch := &recordingChannel{}
+	m.setChannels(ch)
+
+	busy := &models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 9, Max: 10, Timestamp: time.Now()}
+	idle := &models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 1, Max: 10, Timestamp: time.Now()}
+	for _, metrics := range []*models.PoolMetrics{busy, idle, busy} {
+		m.Check(metrics)
+	}
+	if got := ch.sent(); got != "fired,resolved" {
+		t.Errorf("notifications = %s, want fired,resolved", got)
+	}
+
+	// A restart keeps the rule dampened, and settling it notifies the held back fire once
+	m.Stop()
+	m = NewManager(store, cfg)
+	defer m.Stop()
+	m.setChannels(ch)
+	if state := m.flaps["orders/a/high_usage"]; state == nil || !state.flapping || len(state.changes) != 3 {
+		t.Fatalf("restored flap state = %+v, want flapping with 3 changes", state)
+	}
+
+	m.settleFlapping(time.Now().Add(time.Hour))
+	if got := ch.sent(); got != "fired,resolved,fired" {
+		t.Errorf("notifications after settling = %s, want the held back fire", got)
+	}
+	active, err := store.GetActiveAlertByRule(context.Background(), "orders", "a", "high_usage")
+	if err != nil || active == nil {
+		t.Fatalf("GetActiveAlertByRule() = %v, %v", active, err)
+	}
+	if active.Flapping || active.NotifiedAt == nil {
+		t.Errorf("settled alert = %+v, want notified and no longer flapping", active)
+	}
+}
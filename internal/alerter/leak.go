@@ -0,0 +1,184 @@
+package alerter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/analyzer"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// leakRiskRank orders the leak risk levels; none, low and unknown (too little data) never fire
+var leakRiskRank = map[string]int{
+	"medium": 1,
+	"high":   2,
+}
+
+// LeakChecker periodically runs the leak detector for leak rules
+// and fires or resolves their alerts per target
+type LeakChecker struct {
+	cfgMgr *config.Manager
+	store  storage.Storage
+	alerts *Manager
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	lastRun map[string]time.Time // rule name -> last check
+}
+
+// NewLeakChecker creates a leak rule checker
+func NewLeakChecker(cfgMgr *config.Manager, store storage.Storage, alerts *Manager) *LeakChecker {
+	return &LeakChecker{
+		cfgMgr:  cfgMgr,
+		store:   store,
+		alerts:  alerts,
+		lastRun: make(map[string]time.Time),
+	}
+}
+
+// Start begins looking for due leak rules every tick
+// Each rule is checked at its own interval, so tick should not exceed the shortest one
+func (l *LeakChecker) Start(tick time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.check(ctx, time.Now())
+			}
+		}
+	}()
+
+	log.Printf("Leak checker started: tick=%v", tick)
+}
+
+// Stop stops the leak rule checks
+func (l *LeakChecker) Stop() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+}
+
+// check evaluates every enabled leak rule whose interval elapsed against every target it applies to
+func (l *LeakChecker) check(ctx context.Context, now time.Time) {
+	cfg := l.cfgMgr.Get()
+	loc := cfg.GetLocation()
+
+	for _, rule := range cfg.Alerting.Rules {
+		if !rule.IsLeak() || !rule.IsEnabled() || !l.due(rule.Name, rule.Leak.GetInterval(), now) {
+			continue
+		}
+		if err := rule.Leak.Validate(); err != nil {
+			log.Printf("Alerter: leak rule %s: %v", rule.Name, err)
+			continue
+		}
+
+		for _, target := range cfg.Targets {
+			if target.Paused || !rule.Leak.AppliesTo(target.Name) || !rule.AppliesToTarget(target.Name, target.Group, target.Labels) {
+				continue
+			}
+			rule := rule
+			if err := l.checkTarget(ctx, &rule, target.Name, now, loc); err != nil {
+				log.Printf("Alerter: failed to check leak rule %s for %s: %v", rule.Name, target.Name, err)
+			}
+		}
+	}
+}
+
+// due records a check of the rule if its interval elapsed since the last one
+func (l *LeakChecker) due(rule string, interval time.Duration, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if last, ok := l.lastRun[rule]; ok && now.Sub(last) < interval {
+		return false
+	}
+	l.lastRun[rule] = now
+	return true
+}
+
+// checkTarget runs the leak detector over the rule's window and reports the result
+func (l *LeakChecker) checkTarget(ctx context.Context, rule *config.AlertRule, target string, now time.Time, loc *time.Location) error {
+	window := rule.Leak.GetWindow()
+	metrics, err := l.store.GetHistory(ctx, target, now.Add(-window), now)
+	if err != nil {
+		return err
+	}
+	if len(metrics) == 0 {
+		// No data says nothing about leaks; the stale data rules cover it
+		return nil
+	}
+
+	result := analyzer.DetectLeaks(metrics, loc)
+	triggered := LeakTriggered(result, rule.Leak.GetRiskLevel())
+	suggestions := leakSuggestions(result)
+
+	// The alert belongs to the target, so the latest sample only fills in the template context
+	ruleCtx := NewRuleContext(&metrics[len(metrics)-1])
+	ruleCtx.InstanceName = ""
+	ruleCtx.LeakRisk = result.LeakRisk
+	ruleCtx.LeakHealthScore = result.HealthScore
+	ruleCtx.LeakSuggestions = strings.Join(suggestions, "; ")
+
+	fired := *rule
+	if fired.Message == "" {
+		fired.Message = leakMessage(target, window, result, suggestions)
+	}
+	l.alerts.Report(&fired, ruleCtx, triggered)
+	return nil
+}
+
+// LeakTriggered reports whether a leak analysis fires a rule with the given risk level:
+// a detected leak pattern always fires, otherwise the risk must reach the level
+func LeakTriggered(result *analyzer.LeakAnalysisResult, riskLevel string) bool {
+	if result.HasLeak {
+		return true
+	}
+	rank, ok := leakRiskRank[result.LeakRisk]
+	return ok && rank >= leakRiskRank[riskLevel]
+}
+
+// leakSuggestions returns the distinct suggestions of the detected patterns in order
+func leakSuggestions(result *analyzer.LeakAnalysisResult) []string {
+	seen := make(map[string]bool)
+	var suggestions []string
+	for _, alert := range result.Alerts {
+		for _, s := range alert.Suggestions {
+			if !seen[s] {
+				seen[s] = true
+				suggestions = append(suggestions, s)
+			}
+		}
+	}
+	return suggestions
+}
+
+// leakMessage is the default message of a leak rule
+func leakMessage(target string, window time.Duration, result *analyzer.LeakAnalysisResult, suggestions []string) string {
+	patterns := make([]string, 0, len(result.Alerts))
+	for _, alert := range result.Alerts {
+		patterns = append(patterns, alert.Message)
+	}
+
+	msg := fmt.Sprintf("Connection leak risk is %s for %s (health %d) in the last %s",
+		result.LeakRisk, target, result.HealthScore, window)
+	if len(patterns) > 0 {
+		msg += ": " + strings.Join(patterns, "; ")
+	}
+	if len(suggestions) > 0 {
+		msg += ". Suggestions: " + strings.Join(suggestions, "; ")
+	}
+	return msg
+}
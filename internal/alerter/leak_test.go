@@ -0,0 +1,67 @@
+package alerter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/analyzer"
+)
+
+func TestLeakTriggered(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    analyzer.LeakAnalysisResult
+		riskLevel string
+		want      bool
+	}{
+		{"leak pattern", analyzer.LeakAnalysisResult{HasLeak: true, LeakRisk: "low"}, "high", true},
+		{"medium at medium", analyzer.LeakAnalysisResult{LeakRisk: "medium"}, "medium", true},
+		{"medium at high", analyzer.LeakAnalysisResult{LeakRisk: "medium"}, "high", false},
+		{"low", analyzer.LeakAnalysisResult{LeakRisk: "low"}, "medium", false},
+		{"unknown", analyzer.LeakAnalysisResult{LeakRisk: "unknown"}, "medium", false},
+	}
+	for _, tt := range tests {
+		if got := LeakTriggered(&tt.result, tt.riskLevel); got != tt.want {
+			t.Errorf("%s: LeakTriggered() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLeakMessage(t *testing.T) {
+	result := &analyzer.LeakAnalysisResult{
+		LeakRisk:    "high",
+		HealthScore: 30,
+		Alerts: []analyzer.LeakAlert{
+			{Message: "No idle connections", Suggestions: []string{"Check for unclosed connections", "Enable leak detection"}},
+			{Message: "Pending requests", Suggestions: []string{"Check for unclosed connections"}},
+		},
+	}
+
+	suggestions := leakSuggestions(result)
+	if len(suggestions) != 2 {
+		t.Fatalf("suggestions = %v, want 2 distinct", suggestions)
+	}
+
+	msg := leakMessage("orders", time.Hour, result, suggestions)
+	for _, want := range []string{"risk is high for orders", "No idle connections; Pending requests", "Suggestions: Check for unclosed connections; Enable leak detection"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q does not contain %q", msg, want)
+		}
+	}
+}
+
+func TestLeakCheckerDue(t *testing.T) {
+	l := NewLeakChecker(nil, nil, nil)
+	now := time.Now()
+
+	if !l.due("leaks", 5*time.Minute, now) {
+		t.Error("first check should be due")
+	}
+	if l.due("leaks", 5*time.Minute, now.Add(time.Minute)) {
+		t.Error("check within the interval should not be due")
+	}
+	if !l.due("leaks", 5*time.Minute, now.Add(5*time.Minute)) {
+		t.Error("check after the interval should be due")
+	}
+}
@@ -0,0 +1,77 @@
+package alerter
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Dashboard links
+// With alerting.external_url set, notifications link to the dashboard views of their alert,
+// e.g., "https://pondy.example.com/?target=orders&instance=a&alert=42", and to the HTML report
+// of the target, so responders go from the notification straight to the data
+
+// NewAlertLinks builds the dashboard links of an alert under the external URL
+// Returns nil when the external URL is empty
+func NewAlertLinks(externalURL string, alert *models.Alert) *models.AlertLinks {
+	base := strings.TrimRight(strings.TrimSpace(externalURL), "/")
+	if base == "" {
+		return nil
+	}
+
+	query := url.Values{"target": {alert.TargetName}}
+	links := &models.AlertLinks{
+		Target: base + "/?" + query.Encode(),
+		Report: base + "/api/v1/targets/" + url.PathEscape(alert.TargetName) + "/report",
+	}
+	query.Set("instance", alert.InstanceName)
+	links.Instance = base + "/?" + query.Encode()
+	if alert.ID > 0 {
+		query.Set("alert", strconv.FormatInt(alert.ID, 10))
+		links.Alert = base + "/?" + query.Encode()
+	}
+	return links
+}
+
+// linkAlert sets the dashboard links of an alert about to be sent
+func (m *Manager) linkAlert(alert *models.Alert) {
+	m.mu.RLock()
+	cfg := m.cfg
+	m.mu.RUnlock()
+
+	if cfg != nil {
+		alert.Links = NewAlertLinks(cfg.ExternalURL, alert)
+	}
+}
+
+// alertLink returns the most specific dashboard link of an alert, or empty without links
+func alertLink(alert *models.Alert) string {
+	if alert.Links == nil {
+		return ""
+	}
+	if alert.Links.Alert != "" {
+		return alert.Links.Alert
+	}
+	return alert.Links.Instance
+}
+
+// formatLinks renders the dashboard and report links of an alert with a channel's link markup,
+// or returns empty without links
+func formatLinks(alert *models.Alert, link func(label, href string) string) string {
+	if alert.Links == nil {
+		return ""
+	}
+	return link("Dashboard", alertLink(alert)) + " · " + link("Report", alert.Links.Report)
+}
+
+// slackLink formats a link in Slack and Mattermost markup
+func slackLink(label, href string) string {
+	return "<" + href + "|" + label + ">"
+}
+
+// markdownLink formats a Markdown link
+func markdownLink(label, href string) string {
+	return "[" + label + "](" + href + ")"
+}
@@ -0,0 +1,88 @@
+package alerter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestNewAlertLinks(t *testing.T) {
+	alert := &models.Alert{ID: 42, TargetName: "orders svc", InstanceName: "pod-1"}
+
+	if links := NewAlertLinks("", alert); links != nil {
+		t.Errorf("NewAlertLinks() without external URL = %+v, want nil", links)
+	}
+
+	links := NewAlertLinks(" https://pondy.example.com/ ", alert)
+	want := models.AlertLinks{
+		Target:   "https://pondy.example.com/?target=orders+svc",
+		Instance: "https://pondy.example.com/?instance=pod-1&target=orders+svc",
+		Alert:    "https://pondy.example.com/?alert=42&instance=pod-1&target=orders+svc",
+		Report:   "https://pondy.example.com/api/v1/targets/orders%20svc/report",
+	}
+	if links == nil || *links != want {
+		t.Errorf("NewAlertLinks() = %+v, want %+v", links, want)
+	}
+
+	// Test alerts aren't stored, so they link to the instance
+	alert.ID = 0
+	alert.Links = NewAlertLinks("https://pondy.example.com", alert)
+	if alert.Links.Alert != "" || alertLink(alert) != want.Instance {
+		t.Errorf("alertLink() of unsaved alert = %q, want the instance view", alertLink(alert))
+	}
+}
+
+func TestLinksInPayloads(t *testing.T) {
+	alert := &models.Alert{ID: 7, TargetName: "orders", InstanceName: "a", RuleName: "high_usage", Severity: "warning"}
+	alert.Links = NewAlertLinks("https://pondy.example.com", alert)
+
+	w := NewWebhookChannel(config.WebhookConfig{})
+	body, err := w.builtinPayload("alert_fired", alert)
+	if err != nil {
+		t.Fatalf("builtinPayload() error = %v", err)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload unmarshal error = %v", err)
+	}
+	if payload.Alert.Links == nil || payload.Alert.Links.Alert != alert.Links.Alert {
+		t.Errorf("webhook links = %+v, want %+v", payload.Alert.Links, alert.Links)
+	}
+
+	p := NewPluginChannel(config.PluginConfig{Name: "pager"})
+	if got := p.buildPayload(alert, "alert.fired").Alert.Links; got == nil || *got != *alert.Links {
+		t.Errorf("plugin links = %+v, want %+v", got, alert.Links)
+	}
+
+	e := NewEmailChannel(config.EmailConfig{})
+	html, err := e.renderAlertBody(alert, false)
+	if err != nil {
+		t.Fatalf("renderAlertBody() error = %v", err)
+	}
+	if !strings.Contains(html, `href="https://pondy.example.com/?alert=7&amp;instance=a&amp;target=orders"`) {
+		t.Errorf("email body lacks the alert link:\n%s", html)
+	}
+	digest, err := e.renderDigestBody(NewDigest([]*models.Alert{alert, alert}))
+	if err != nil {
+		t.Fatalf("renderDigestBody() error = %v", err)
+	}
+	if !strings.Contains(digest, `<a href="https://pondy.example.com/?target=orders">orders</a>`) {
+		t.Errorf("digest body lacks the target link:\n%s", digest)
+	}
+
+	if got := formatLinks(alert, markdownLink); !strings.HasPrefix(got, "[Dashboard](https://pondy.example.com/?alert=7") {
+		t.Errorf("formatLinks() = %q", got)
+	}
+
+	// Without an external URL nothing is linked
+	alert.Links = nil
+	if html, _ := e.renderAlertBody(alert, false); strings.Contains(html, "View in Dashboard") {
+		t.Error("email body has links without an external URL")
+	}
+	if got := formatLinks(alert, slackLink); got != "" {
+		t.Errorf("formatLinks() without links = %q, want empty", got)
+	}
+}
@@ -2,6 +2,8 @@ package alerter
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/jiin/pondy/internal/config"
 	"github.com/jiin/pondy/internal/models"
@@ -43,6 +45,7 @@ type MattermostMessage struct {
 type MattermostAttachment struct {
 	Color      string            `json:"color"`
 	Title      string            `json:"title"`
+	TitleLink  string            `json:"title_link,omitempty"`
 	Text       string            `json:"text"`
 	Fields     []MattermostField `json:"fields,omitempty"`
 	Footer     string            `json:"footer,omitempty"`
@@ -67,9 +70,10 @@ func (m *MattermostChannel) Send(alert *models.Alert) error {
 		IconEmoji: ":warning:",
 		Attachments: []MattermostAttachment{
 			{
-				Color: GetColorString(alert.Severity),
-				Title: FormatAlertTitle(alert),
-				Text:  alert.Message,
+				Color:     GetColorString(alert.Severity),
+				Title:     FormatAlertTitle(alert),
+				TitleLink: alertLink(alert),
+				Text:      alert.Message,
 				Fields: []MattermostField{
 					{Title: "Target", Value: alert.TargetName, Short: true},
 					{Title: "Instance", Value: alert.InstanceName, Short: true},
@@ -95,9 +99,10 @@ func (m *MattermostChannel) SendResolved(alert *models.Alert) error {
 		IconEmoji: ":white_check_mark:",
 		Attachments: []MattermostAttachment{
 			{
-				Color: ColorResolved,
-				Title: FormatResolvedTitle(alert),
-				Text:  alert.Message,
+				Color:     ColorResolved,
+				Title:     FormatResolvedTitle(alert),
+				TitleLink: alertLink(alert),
+				Text:      alert.Message,
 				Fields: []MattermostField{
 					{Title: "Target", Value: alert.TargetName, Short: true},
 					{Title: "Instance", Value: alert.InstanceName, Short: true},
@@ -110,3 +115,32 @@ func (m *MattermostChannel) SendResolved(alert *models.Alert) error {
 
 	return PostJSON(m.client, m.cfg.WebhookURL, msg)
 }
+
+func (m *MattermostChannel) SendDigest(digest *Digest) error {
+	if !m.IsEnabled() {
+		return nil
+	}
+
+	msg := MattermostMessage{
+		Channel:   m.cfg.Channel,
+		Username:  GetUsername(m.cfg.Username),
+		IconEmoji: ":warning:",
+		Attachments: []MattermostAttachment{
+			{
+				Color:     GetColorString(digest.Severity),
+				Title:     digest.Title(),
+				TitleLink: digest.Link(),
+				Text:      digest.Text(),
+				Fields: []MattermostField{
+					{Title: "Targets", Value: strings.Join(digest.Targets(), ", "), Short: true},
+					{Title: "Alerts", Value: strconv.Itoa(len(digest.Alerts)), Short: true},
+					{Title: "Severity", Value: digest.Severity, Short: true},
+					{Title: "Status", Value: "Fired", Short: true},
+				},
+				Footer: FooterText,
+			},
+		},
+	}
+
+	return PostJSON(m.client, m.cfg.WebhookURL, msg)
+}
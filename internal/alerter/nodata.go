@@ -0,0 +1,98 @@
+package alerter
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// No data check settings
+const (
+	noDataCheckInterval = 30 * time.Second
+	// noDataForgetAfter is how long an instance may stay silent before it counts as removed
+	// rather than missing, e.g., after a scale-down; its alert is then resolved
+	noDataForgetAfter = 24 * time.Hour
+)
+
+// noDataLoop periodically checks nodata rules
+// Check only runs when samples arrive, so missing samples are detected here instead
+func (m *Manager) noDataLoop() {
+	ticker := time.NewTicker(noDataCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.checkNoData(time.Now())
+		}
+	}
+}
+
+// checkNoData fires or resolves nodata rules for every instance of the targets they apply to
+func (m *Manager) checkNoData(now time.Time) {
+	m.mu.RLock()
+	cfg := m.cfg
+	intervals := m.intervals
+	m.mu.RUnlock()
+
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+
+	for _, rule := range cfg.Rules {
+		if !rule.IsNoData() || !rule.IsEnabled() {
+			continue
+		}
+		for target, interval := range intervals {
+			if !rule.NoData.AppliesTo(target) || !rule.AppliesToTarget(target, m.groupOf(target), m.labelsOf(target)) {
+				continue
+			}
+			rule := rule
+			if err := m.checkTargetNoData(&rule, target, interval, now); err != nil {
+				log.Printf("Alerter: failed to check nodata rule %s for %s: %v", rule.Name, target, err)
+			}
+		}
+	}
+}
+
+// checkTargetNoData reports each instance of the target by the age of its latest sample
+// Targets that never produced a sample are left to the scrape failure rules
+func (m *Manager) checkTargetNoData(rule *config.AlertRule, target string, interval time.Duration, now time.Time) error {
+	latest, err := m.store.GetLatestAllInstances(m.ctx, target)
+	if err != nil {
+		return err
+	}
+
+	threshold := time.Duration(rule.NoData.GetIntervals()) * interval
+	for i := range latest {
+		ctx := NewRuleContext(&latest[i])
+		ctx.DataAge = now.Sub(latest[i].Timestamp).Round(time.Second)
+
+		fired := *rule
+		if fired.Message == "" {
+			fired.Message = noDataMessage(ctx, interval)
+		}
+		m.Report(&fired, ctx, NoDataTriggered(ctx.DataAge, threshold))
+	}
+	return nil
+}
+
+// NoDataTriggered reports whether a sample age fires a nodata rule with the given threshold
+// Instances silent for longer than noDataForgetAfter are considered removed
+func NoDataTriggered(age, threshold time.Duration) bool {
+	return age > threshold && age < noDataForgetAfter
+}
+
+// noDataMessage is the default message of a nodata rule
+func noDataMessage(ctx *RuleContext, interval time.Duration) string {
+	source := ctx.TargetName
+	if ctx.InstanceName != "" && ctx.InstanceName != "default" {
+		source += "/" + ctx.InstanceName
+	}
+	return fmt.Sprintf("No data from %s for %s (collected every %s, last sample at %s)",
+		source, ctx.DataAge, interval, ctx.Timestamp.Format(time.RFC3339))
+}
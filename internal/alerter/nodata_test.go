@@ -0,0 +1,74 @@
+package alerter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestNoDataTriggered(t *testing.T) {
+	threshold := 30 * time.Second
+	if NoDataTriggered(20*time.Second, threshold) {
+		t.Error("fresh sample should not fire")
+	}
+	if !NoDataTriggered(time.Minute, threshold) {
+		t.Error("sample older than the threshold should fire")
+	}
+	if NoDataTriggered(48*time.Hour, threshold) {
+		t.Error("instance silent for days should count as removed")
+	}
+}
+
+func TestCheckNoData(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "nodata.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	for _, m := range []models.PoolMetrics{
+		{TargetName: "orders", InstanceName: "a", Max: 10, Timestamp: now.Add(-5 * time.Second)},
+		{TargetName: "orders", InstanceName: "b", Max: 10, Timestamp: now.Add(-2 * time.Minute)},
+	} {
+		if err := store.Save(context.Background(), &m); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	m := NewManager(store, &config.AlertingConfig{
+		Enabled: true,
+		Rules:   []config.AlertRule{{Name: "no_data", Type: config.AlertRuleTypeNoData, Severity: "critical"}},
+	})
+	defer m.Stop()
+	m.SetTargetIntervals(map[string]time.Duration{"orders": 10 * time.Second})
+
+	m.checkNoData(now)
+
+	alerts, err := store.GetAlerts(context.Background(), models.AlertStatusFired, 10)
+	if err != nil {
+		t.Fatalf("GetAlerts() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].InstanceName != "b" {
+		t.Fatalf("fired alerts = %+v, want one for instance b", alerts)
+	}
+
+	// Samples arriving again resolve the alert
+	if err := store.Save(context.Background(), &models.PoolMetrics{TargetName: "orders", InstanceName: "b", Max: 10, Timestamp: now}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	m.checkNoData(now.Add(time.Second))
+
+	alerts, err = store.GetAlerts(context.Background(), models.AlertStatusFired, 10)
+	if err != nil {
+		t.Fatalf("GetAlerts() error = %v", err)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("fired alerts = %+v, want none after samples resumed", alerts)
+	}
+}
@@ -220,7 +220,7 @@ func (n *NotionChannel) createPage(page NotionPage) error {
 	}
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("notion API returned status %d", resp.StatusCode)
+		return statusError("notion API returned status %d", resp.StatusCode)
 	}
 
 	return nil
@@ -60,6 +60,8 @@ type PluginAlertData struct {
 	Status       string     `json:"status"`
 	FiredAt      time.Time  `json:"fired_at"`
 	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+
+	Links *models.AlertLinks `json:"links,omitempty"` // Set when alerting.external_url is configured
 }
 
 // PluginMetadata contains additional context
@@ -100,6 +102,7 @@ func (p *PluginChannel) buildPayload(alert *models.Alert, event string) PluginPa
 			Status:       alert.Status,
 			FiredAt:      alert.FiredAt,
 			ResolvedAt:   alert.ResolvedAt,
+			Links:        alert.Links,
 		},
 		Metadata: PluginMetadata{
 			Timestamp:  time.Now(),
@@ -134,7 +137,8 @@ func (p *PluginChannel) sendWithRetry(payload PluginPayload) error {
 		lastErr = err
 	}
 
-	return lastErr
+	statusCode, _ := deliveryDetails(lastErr)
+	return &DeliveryError{StatusCode: statusCode, Retries: retryCount - 1, Err: lastErr}
 }
 
 func (p *PluginChannel) send(payload PluginPayload) error {
@@ -175,7 +179,7 @@ func (p *PluginChannel) send(payload PluginPayload) error {
 	}
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("plugin endpoint returned status %d", resp.StatusCode)
+		return statusError("plugin endpoint returned status %d", resp.StatusCode)
 	}
 
 	return nil
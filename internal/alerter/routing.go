@@ -0,0 +1,77 @@
+package alerter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// routesTo checks if a rule's channel list includes a channel
+// An empty list routes to every channel
+func routesTo(route []string, ch Channel) bool {
+	if len(route) == 0 {
+		return true
+	}
+	for _, name := range route {
+		if strings.EqualFold(name, ch.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupRule finds a rule by name, as evaluated: config rules take precedence over
+// database rules with the same name. Returns nil for unknown rules
+func (m *Manager) lookupRule(ruleName string) *config.AlertRule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.cfg != nil {
+		for i := range m.cfg.Rules {
+			if m.cfg.Rules[i].Name == ruleName {
+				return &m.cfg.Rules[i]
+			}
+		}
+	}
+	for i := range m.dbRules {
+		if m.dbRules[i].Name == ruleName {
+			return ruleFromDB(&m.dbRules[i])
+		}
+	}
+	return nil
+}
+
+// ruleRoute returns the channels configured for a rule by name
+func (m *Manager) ruleRoute(ruleName string) []string {
+	if rule := m.lookupRule(ruleName); rule != nil {
+		return rule.Channels
+	}
+	return nil
+}
+
+// repeatInterval returns the repeat interval of a rule by name
+// A rule-level interval overrides the global one; 0 means no repeat
+func (m *Manager) repeatInterval(cfg *config.AlertingConfig, ruleName string) time.Duration {
+	if rule := m.lookupRule(ruleName); rule != nil && rule.RepeatInterval > 0 {
+		return rule.RepeatInterval
+	}
+	return cfg.GetRepeatInterval("")
+}
+
+// routedChannels returns the enabled channels that a rule's alerts are sent to
+func (m *Manager) routedChannels(ruleName string) []Channel {
+	route := m.ruleRoute(ruleName)
+
+	m.mu.RLock()
+	channels := m.channels
+	m.mu.RUnlock()
+
+	var routed []Channel
+	for _, ch := range channels {
+		if ch.IsEnabled() && routesTo(route, ch) {
+			routed = append(routed, ch)
+		}
+	}
+	return routed
+}
@@ -0,0 +1,93 @@
+package alerter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestRoutesTo(t *testing.T) {
+	slack := NewSlackChannel(config.SlackConfig{Enabled: true, WebhookURL: "http://example.com"})
+
+	tests := []struct {
+		route []string
+		want  bool
+	}{
+		{nil, true},
+		{[]string{"slack"}, true},
+		{[]string{"Slack"}, true},
+		{[]string{"discord", "plugin:pagerduty"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := routesTo(tt.route, slack); got != tt.want {
+			t.Errorf("routesTo(%v, slack) = %v, want %v", tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestRoutedChannels(t *testing.T) {
+	cfg := &config.AlertingConfig{
+		Rules: []config.AlertRule{
+			{Name: "pool_exhausted", Channels: []string{"slack", "discord"}},
+			{Name: "high_usage", Channels: []string{"slack"}},
+		},
+	}
+	m := &Manager{
+		cfg: cfg,
+		channels: []Channel{
+			NewSlackChannel(config.SlackConfig{Enabled: true, WebhookURL: "http://example.com/slack"}),
+			NewDiscordChannel(config.DiscordConfig{Enabled: true, WebhookURL: "http://example.com/discord"}),
+			NewMattermostChannel(config.MattermostConfig{Enabled: false}),
+		},
+		dbRules: []models.AlertRule{{Name: "slow_gc", Channels: []string{"discord"}}},
+	}
+
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"pool_exhausted", "slack,discord"},
+		{"high_usage", "slack"},
+		{"slow_gc", "discord"},
+		{"unrouted", "slack,discord"},
+	}
+
+	for _, tt := range tests {
+		if got := m.getRoutedChannelNames(tt.rule); got != tt.want {
+			t.Errorf("getRoutedChannelNames(%q) = %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestRepeatInterval(t *testing.T) {
+	cfg := &config.AlertingConfig{
+		RepeatInterval: time.Hour,
+		Rules:          []config.AlertRule{{Name: "pool_exhausted", RepeatInterval: 10 * time.Minute}},
+	}
+	m := &Manager{
+		cfg: cfg,
+		dbRules: []models.AlertRule{
+			{Name: "slow_gc", RepeatInterval: "30m"},
+			{Name: "high_usage"},
+		},
+	}
+
+	tests := []struct {
+		rule string
+		want time.Duration
+	}{
+		{"pool_exhausted", 10 * time.Minute},
+		{"slow_gc", 30 * time.Minute},
+		{"high_usage", time.Hour},
+		{"unknown", time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := m.repeatInterval(cfg, tt.rule); got != tt.want {
+			t.Errorf("repeatInterval(%q) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
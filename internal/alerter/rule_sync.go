@@ -0,0 +1,128 @@
+package alerter
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// ruleFromDB converts a database rule for evaluation
+func ruleFromDB(r *models.AlertRule) *config.AlertRule {
+	// Validated on create and import; an invalid interval falls back to the global one
+	repeatInterval, _ := time.ParseDuration(r.RepeatInterval)
+	return &config.AlertRule{
+		Name:              r.Name,
+		Condition:         r.Condition,
+		ResolveCondition:  r.ResolveCondition,
+		EscalateCondition: r.EscalateCondition,
+		EscalateSeverity:  r.EscalateSeverity,
+		RepeatInterval:    repeatInterval,
+		Severity:          r.Severity,
+		Message:           r.Message,
+		Enabled:           &r.Enabled,
+		Channels:          r.Channels,
+		Labels:            r.Labels,
+		Targets:           r.Targets,
+		Groups:            r.Groups,
+	}
+}
+
+// syncedRule converts a config condition rule to its config-managed database rule
+func syncedRule(r *config.AlertRule) models.AlertRule {
+	var repeatInterval string
+	if r.RepeatInterval > 0 {
+		repeatInterval = r.RepeatInterval.String()
+	}
+	return models.AlertRule{
+		Name:              r.Name,
+		Condition:         r.Condition,
+		ResolveCondition:  r.ResolveCondition,
+		EscalateCondition: r.EscalateCondition,
+		EscalateSeverity:  r.EscalateSeverity,
+		RepeatInterval:    repeatInterval,
+		Severity:          r.Severity,
+		Message:           r.Message,
+		Enabled:           r.IsEnabled(),
+		Channels:          r.Channels,
+		Labels:            r.Labels,
+		Targets:           r.Targets,
+		Groups:            r.Groups,
+		Origin:            models.RuleOriginConfig,
+	}
+}
+
+// sameSyncedRule reports whether a database rule already matches a synced config rule
+func sameSyncedRule(a, b *models.AlertRule) bool {
+	return a.Origin == b.Origin && a.Condition == b.Condition && a.ResolveCondition == b.ResolveCondition && a.Severity == b.Severity &&
+		a.EscalateCondition == b.EscalateCondition && a.EscalateSeverity == b.EscalateSeverity &&
+		a.RepeatInterval == b.RepeatInterval && a.Message == b.Message && a.Enabled == b.Enabled && a.Project == b.Project &&
+		slices.Equal(a.Channels, b.Channels) && slices.Equal(a.Targets, b.Targets) &&
+		slices.Equal(a.Groups, b.Groups) && maps.Equal(a.Labels, b.Labels)
+}
+
+// SyncConfigRules materializes the condition rules of the alerting config into the database
+// as config-managed rules, deleting the ones no longer configured; with sync_rules off every
+// config-managed rule is deleted. A config rule takes over an API rule of the same name.
+// Anomaly, leak and nodata rules can't be expressed as database rules and stay config-only
+func SyncConfigRules(ctx context.Context, store storage.Storage, cfg *config.AlertingConfig) error {
+	existing, err := store.GetAlertRules(ctx)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*models.AlertRule, len(existing))
+	for i := range existing {
+		byName[existing[i].Name] = &existing[i]
+	}
+
+	configured := make(map[string]bool)
+	if cfg.SyncRules {
+		for i := range cfg.Rules {
+			rule := &cfg.Rules[i]
+			if !rule.IsCondition() || configured[rule.Name] {
+				continue
+			}
+			configured[rule.Name] = true
+
+			synced := syncedRule(rule)
+			prev, ok := byName[rule.Name]
+			switch {
+			case !ok:
+				err = store.SaveAlertRule(ctx, &synced)
+			case sameSyncedRule(prev, &synced):
+				continue
+			default:
+				if !prev.IsConfigManaged() {
+					log.Printf("Alerter: config rule %s replaces the API rule of the same name", rule.Name)
+				}
+				synced.ID = prev.ID
+				err = store.UpdateAlertRule(ctx, &synced)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, r := range existing {
+		if r.IsConfigManaged() && !configured[r.Name] {
+			if err := store.DeleteAlertRule(ctx, r.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// syncConfigRules syncs config rules and reloads the database rules
+func (m *Manager) syncConfigRules(cfg *config.AlertingConfig) {
+	if err := SyncConfigRules(m.ctx, m.store, cfg); err != nil {
+		log.Printf("Alerter: failed to sync config rules: %v", err)
+	}
+	m.loadDBRules()
+}
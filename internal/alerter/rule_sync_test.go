@@ -0,0 +1,78 @@
+package alerter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestSyncConfigRules(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "sync.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	for _, r := range []models.AlertRule{
+		{Name: "pending", Condition: "pending > 1", Severity: models.SeverityInfo, Enabled: true},
+		{Name: "removed", Condition: "idle == 0", Severity: models.SeverityWarning, Enabled: true, Origin: models.RuleOriginConfig},
+		{Name: "manual", Condition: "usage > 99", Severity: models.SeverityCritical, Enabled: true},
+	} {
+		if err := store.SaveAlertRule(context.Background(), &r); err != nil {
+			t.Fatalf("SaveAlertRule() error = %v", err)
+		}
+	}
+
+	cfg := &config.AlertingConfig{
+		Enabled:   true,
+		SyncRules: true,
+		Rules: []config.AlertRule{
+			{Name: "high_usage", Condition: "usage > 80", Severity: models.SeverityWarning, Targets: []string{"orders"}},
+			{Name: "pending", Condition: "pending > 5", Severity: models.SeverityWarning},
+			{Name: "no_data", Type: config.AlertRuleTypeNoData, Severity: models.SeverityCritical},
+		},
+	}
+	m := NewManager(store, cfg)
+	defer m.Stop()
+
+	rules := make(map[string]models.AlertRule)
+	all, _ := store.GetAlertRules(context.Background())
+	for _, r := range all {
+		rules[r.Name] = r
+	}
+	if len(rules) != 3 {
+		t.Fatalf("rules = %+v, want high_usage, pending and manual", all)
+	}
+	if r := rules["high_usage"]; !r.IsConfigManaged() || len(r.Targets) != 1 || r.Targets[0] != "orders" {
+		t.Errorf("high_usage = %+v, want config-managed rule for orders", r)
+	}
+	if r := rules["pending"]; !r.IsConfigManaged() || r.Condition != "pending > 5" {
+		t.Errorf("pending = %+v, want taken over by the config rule", r)
+	}
+	if r := rules["manual"]; r.IsConfigManaged() {
+		t.Errorf("manual = %+v, want API rule kept", r)
+	}
+
+	// Synced rules are evaluated once, through the database rules, with their target scope
+	now := time.Now()
+	m.Check(&models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 9, Max: 10, Timestamp: now})
+	m.Check(&models.PoolMetrics{TargetName: "billing", InstanceName: "a", Active: 9, Max: 10, Timestamp: now})
+	alerts, err := store.GetAlerts(context.Background(), models.AlertStatusFired, 10)
+	if err != nil {
+		t.Fatalf("GetAlerts() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].RuleName != "high_usage" || alerts[0].TargetName != "orders" {
+		t.Errorf("alerts = %+v, want high_usage on orders", alerts)
+	}
+
+	// Turning sync off removes the config-managed rules
+	m.UpdateConfig(&config.AlertingConfig{Enabled: true, Rules: cfg.Rules})
+	if all, _ = store.GetAlertRules(context.Background()); len(all) != 1 || all[0].Name != "manual" {
+		t.Errorf("rules after disabling sync = %+v, want only manual", all)
+	}
+}
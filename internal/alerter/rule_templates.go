@@ -0,0 +1,106 @@
+package alerter
+
+import (
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// RuleTemplate is a recommended alert rule of the built-in catalog
+type RuleTemplate struct {
+	ID          string `json:"id"` // Name of the rules created from the template
+	Description string `json:"description"`
+	Type        string `json:"type"`                // condition or nodata
+	Condition   string `json:"condition,omitempty"` // Empty for nodata rules
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+}
+
+// ruleTemplates is the catalog of recommended rules
+// Windowed conditions keep short spikes from alerting
+var ruleTemplates = []RuleTemplate{
+	{
+		ID:          "high_pool_usage",
+		Description: "Pool usage averaged above 85% for 5 minutes",
+		Type:        config.AlertRuleTypeCondition,
+		Condition:   "avg(usage, 5m) > 85",
+		Severity:    models.SeverityWarning,
+		Message:     `Pool usage is high: {{ printf "%.1f" .Usage }}% ({{ .Active }}/{{ .Max }} active)`,
+	},
+	{
+		ID:          "pool_exhausted",
+		Description: "Every connection of the pool is in use",
+		Type:        config.AlertRuleTypeCondition,
+		Condition:   "usage >= 100",
+		Severity:    models.SeverityCritical,
+		Message:     "Pool exhausted: {{ .Active }}/{{ .Max }} active, {{ .Pending }} waiting",
+	},
+	{
+		ID:          "pending_connections",
+		Description: "Threads waited for a connection for 5 minutes",
+		Type:        config.AlertRuleTypeCondition,
+		Condition:   "min(pending, 5m) > 0",
+		Severity:    models.SeverityWarning,
+		Message:     "{{ .Pending }} threads waiting for a connection",
+	},
+	{
+		ID:          "connection_timeouts",
+		Description: "Connection requests timed out, more than one per minute",
+		Type:        config.AlertRuleTypeCondition,
+		Condition:   "timeout_rate > 1",
+		Severity:    models.SeverityCritical,
+		Message:     `Connection timeouts: {{ printf "%.1f" .TimeoutRate }}/min`,
+	},
+	{
+		ID:          "high_heap_usage",
+		Description: "Heap usage averaged above 90% for 10 minutes",
+		Type:        config.AlertRuleTypeCondition,
+		Condition:   "avg(heap_usage, 10m) > 90",
+		Severity:    models.SeverityWarning,
+		Message:     `Heap usage is high: {{ printf "%.1f" .HeapUsage }}%`,
+	},
+	{
+		ID:          "long_gc_pauses",
+		Description: "GC paused the application over 3s per minute (5%) for 5 minutes",
+		Type:        config.AlertRuleTypeCondition,
+		Condition:   "avg(gc_pause_ms_per_min, 5m) > 3000",
+		Severity:    models.SeverityWarning,
+		Message:     `GC pauses take {{ printf "%.0f" .GcPauseMsPerMin }}ms per minute`,
+	},
+	{
+		ID:          "no_data",
+		Description: "No samples for 3 collection intervals",
+		Type:        config.AlertRuleTypeNoData,
+		Severity:    models.SeverityCritical,
+	},
+}
+
+// RuleTemplates returns the catalog of recommended rules
+func RuleTemplates() []RuleTemplate {
+	return append([]RuleTemplate(nil), ruleTemplates...)
+}
+
+// FindRuleTemplate returns the template with the ID, or nil
+func FindRuleTemplate(id string) *RuleTemplate {
+	for i := range ruleTemplates {
+		if ruleTemplates[i].ID == id {
+			t := ruleTemplates[i]
+			return &t
+		}
+	}
+	return nil
+}
+
+// Rule instantiates the template as a config rule with the given name
+func (t *RuleTemplate) Rule(name string) config.AlertRule {
+	rule := config.AlertRule{
+		Name:      name,
+		Condition: t.Condition,
+		Severity:  t.Severity,
+		Message:   t.Message,
+	}
+	if t.Type == config.AlertRuleTypeNoData {
+		rule.Type = config.AlertRuleTypeNoData
+		rule.NoData = &config.NoDataRuleConfig{}
+	}
+	return rule
+}
@@ -0,0 +1,35 @@
+package alerter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestRuleTemplates(t *testing.T) {
+	ctx := &RuleContext{Usage: 92.5, Active: 9, Max: 10, Pending: 3, TimeoutRate: 2, HeapUsage: 95, GcPauseMsPerMin: 4000}
+
+	for _, tmpl := range RuleTemplates() {
+		rule := tmpl.Rule(tmpl.ID + "_orders")
+		if rule.Name != tmpl.ID+"_orders" || rule.Severity != tmpl.Severity {
+			t.Errorf("%s: unexpected rule %+v", tmpl.ID, rule)
+		}
+		if tmpl.Type == config.AlertRuleTypeNoData {
+			if !rule.IsNoData() {
+				t.Errorf("%s: expected a nodata rule", tmpl.ID)
+			}
+			continue
+		}
+		if err := ValidateCondition(tmpl.Condition); err != nil {
+			t.Errorf("%s: invalid condition %q: %v", tmpl.ID, tmpl.Condition, err)
+		}
+		if msg := RenderMessage(tmpl.Message, ctx); msg == tmpl.Message || strings.Contains(msg, "{{") {
+			t.Errorf("%s: message did not render: %q", tmpl.ID, msg)
+		}
+	}
+
+	if FindRuleTemplate("high_pool_usage") == nil || FindRuleTemplate("unknown") != nil {
+		t.Error("FindRuleTemplate() returned an unexpected template")
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/expr"
 	"github.com/jiin/pondy/internal/models"
 )
 
@@ -23,6 +25,10 @@ type RuleContext struct {
 	Max          int
 	Usage        float64 // (Active/Max) * 100
 	Timeout      int64
+	TimeoutRate  float64 // Timeouts per minute since the previous sample
+	AcquireP95   float64 // Connection acquire time (ms)
+	AcquireP99   float64
+	AcquireMax   float64
 	HeapUsed     int64
 	HeapMax      int64
 	HeapUsage    float64 // (HeapUsed/HeapMax) * 100
@@ -31,6 +37,42 @@ type RuleContext struct {
 	ThreadsLive  int
 	GcCount      int64
 	GcTime       float64
+
+	// GC activity since the previous sample
+	GcPausesPerMin  float64
+	GcPauseMsPerMin float64
+	AvgGcPauseMs    float64
+
+	// Derived holds the derived metrics of the sample, usable by name in conditions
+	Derived map[string]float64
+
+	// ScrapeFailures is the number of consecutive failed scrapes
+	ScrapeFailures int
+
+	// Health is the health endpoint status, e.g., UP or DOWN; empty if the collector doesn't check it
+	Health string
+
+	// Anomaly detection result of anomaly rules
+	AnomalyRisk  string // elevated or high
+	AnomalyCount int
+	AnomalyURL   string // API path of the anomaly details
+
+	// Leak detection result of leak rules
+	LeakRisk        string // medium or high
+	LeakHealthScore int    // 0-100
+	LeakSuggestions string // Suggestions of the detected patterns, joined by "; "
+
+	// DataAge is the time since the last sample, for nodata rules
+	DataAge time.Duration
+
+	// Group and Labels of the target, for rules scoped to groups or labels
+	Group  string
+	Labels map[string]string
+
+	Timestamp time.Time
+
+	scrapeFailed bool           // Metrics are unavailable, only scrape rules apply
+	history      *historyWindow // Recent samples for windowed aggregates
 }
 
 // NewRuleContext creates a RuleContext from PoolMetrics
@@ -43,6 +85,10 @@ func NewRuleContext(m *models.PoolMetrics) *RuleContext {
 		Pending:      m.Pending,
 		Max:          m.Max,
 		Timeout:      m.Timeout,
+		TimeoutRate:  m.TimeoutRate,
+		AcquireP95:   m.AcquireP95,
+		AcquireP99:   m.AcquireP99,
+		AcquireMax:   m.AcquireMax,
 		HeapUsed:     m.HeapUsed,
 		HeapMax:      m.HeapMax,
 		NonHeapUsed:  m.NonHeapUsed,
@@ -50,6 +96,16 @@ func NewRuleContext(m *models.PoolMetrics) *RuleContext {
 		ThreadsLive:  m.ThreadsLive,
 		GcCount:      m.GcCount,
 		GcTime:       m.GcTime,
+
+		GcPausesPerMin:  m.GcPausesPerMin,
+		GcPauseMsPerMin: m.GcPauseMsPerMin,
+		AvgGcPauseMs:    m.AvgGcPauseMs,
+		Derived:         m.Derived,
+
+		ScrapeFailures: m.ScrapeFailures,
+		Health:         m.Health,
+		Timestamp:      m.Timestamp,
+		scrapeFailed:   m.Status == models.StatusError,
 	}
 
 	// Calculate usage percentages
@@ -63,9 +119,89 @@ func NewRuleContext(m *models.PoolMetrics) *RuleContext {
 	return ctx
 }
 
+// appliesTo reports whether a rule can be evaluated against this context
+// When a scrape fails only scrape_failures and health rules are evaluated, so that
+// the zero-valued pool metrics don't trigger or resolve other alerts.
+// Health rules need a collector that checks the health endpoint, and scoped rules
+// a target of their targets, groups and labels
+func (ctx *RuleContext) appliesTo(rule *config.AlertRule) bool {
+	if !rule.AppliesToTarget(ctx.TargetName, ctx.Group, ctx.Labels) {
+		return false
+	}
+	parts := parseCondition(strings.TrimSpace(rule.Condition))
+	if len(parts) == 3 && strings.ToLower(parts[0]) == "health" {
+		return ctx.Health != ""
+	}
+	if !ctx.scrapeFailed {
+		return true
+	}
+	return len(parts) == 3 && isScrapeVariable(strings.ToLower(parts[0]))
+}
+
+// isScrapeVariable checks if a variable describes scrape health
+func isScrapeVariable(varName string) bool {
+	return varName == "scrape_failures" || varName == "scrapefailures"
+}
+
+// Value resolves a condition variable, implementing expr.Env
+func (ctx *RuleContext) Value(name string) (float64, error) {
+	return getContextValue(ctx, name)
+}
+
+// snapshotVariables are the condition variables recorded in alert snapshots, by their canonical names
+var snapshotVariables = []string{
+	"usage", "active", "idle", "pending", "max", "timeout", "timeout_rate",
+	"acquire_p95", "acquire_p99", "acquire_max",
+	"heap_usage", "heap_used", "heap_max", "non_heap_used", "cpu_usage", "threads",
+	"gc_count", "gc_time", "gc_pauses_per_min", "gc_pause_ms_per_min", "avg_gc_pause_ms",
+}
+
+// Snapshot returns the variable values of the context, stored with the alerts it fires
+// so responders see what the pool looked like; a failed scrape only has its scrape variables.
+// NaN and Inf values, which JSON can't encode, are left out
+func (ctx *RuleContext) Snapshot() map[string]float64 {
+	values := map[string]float64{"scrape_failures": float64(ctx.ScrapeFailures)}
+	set := func(name string, v float64) {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			values[name] = v
+		}
+	}
+	if ctx.Health != "" {
+		v, _ := getContextValue(ctx, "health")
+		set("health", v)
+	}
+	if ctx.DataAge > 0 {
+		set("data_age_seconds", ctx.DataAge.Seconds())
+	}
+	if ctx.scrapeFailed {
+		return values
+	}
+	for _, name := range snapshotVariables {
+		v, _ := getContextValue(ctx, name)
+		set(name, v)
+	}
+	for name, v := range ctx.Derived {
+		set(name, v)
+	}
+	return values
+}
+
+// Delta implements expr.Env
+// Conditions see a single sample, so changes over time need a derived metric
+func (ctx *RuleContext) Delta(name string) (float64, error) {
+	return 0, fmt.Errorf("delta(%s) is not supported in conditions: define a derived metric instead", name)
+}
+
+// Config files are checked with the same condition grammar and template checks as the API
+func init() {
+	config.ValidateCondition = ValidateCondition
+	config.ValidateChannelTemplates = ValidateChannelTemplates
+}
+
 // ValidateCondition validates a rule condition syntax without evaluating it
+// derived lists the derived metric names conditions may use
 // Returns nil if valid, error otherwise
-func ValidateCondition(condition string) error {
+func ValidateCondition(condition string, derived ...string) error {
 	condition = strings.TrimSpace(condition)
 	if condition == "" {
 		return fmt.Errorf("condition cannot be empty")
@@ -77,29 +213,45 @@ func ValidateCondition(condition string) error {
 		return fmt.Errorf("invalid condition format: expected 'variable operator value', got '%s'", condition)
 	}
 
-	varName := strings.ToLower(parts[0])
 	operator := parts[1]
-	valueStr := parts[2]
 
-	// Validate variable name
+	// Validate variable names on both sides
 	validVars := []string{
-		"usage", "active", "idle", "pending", "max", "timeout",
+		"usage", "active", "idle", "pending", "max", "timeout", "timeout_rate",
+		"acquire_p95", "acquire_p99", "acquire_max",
 		"heapusage", "heap_usage", "heapused", "heap_used", "heapmax", "heap_max",
 		"nonheapused", "non_heap_used", "nonheap",
 		"cpuusage", "cpu_usage", "cpu",
 		"threads", "threads_live",
 		"gccount", "gc_count", "gctime", "gc_time",
+		"gc_pauses_per_min", "gc_pause_ms_per_min", "avg_gc_pause_ms",
+		"scrape_failures", "scrapefailures",
+		"health",
 	}
+	validVars = append(validVars, derived...)
 
-	validVar := false
-	for _, v := range validVars {
-		if varName == v {
-			validVar = true
-			break
+	left, err := expr.Parse(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid condition: %v", err)
+	}
+	right, err := expr.Parse(parts[2])
+	if err != nil {
+		return fmt.Errorf("invalid value '%s': %v", parts[2], err)
+	}
+	for _, varName := range append(left.Vars(), right.Vars()...) {
+		validVar := false
+		for _, v := range validVars {
+			if varName == v {
+				validVar = true
+				break
+			}
+		}
+		if !validVar {
+			return fmt.Errorf("unknown variable '%s'. Valid variables: usage, active, idle, pending, max, timeout, timeout_rate, acquire_p95, acquire_p99, acquire_max, heapusage, cpuusage, threads, gccount, gctime, gc_pauses_per_min, gc_pause_ms_per_min, avg_gc_pause_ms, scrape_failures, health, and derived metrics", varName)
 		}
 	}
-	if !validVar {
-		return fmt.Errorf("unknown variable '%s'. Valid variables: usage, active, idle, pending, max, timeout, heapusage, cpuusage, threads, gccount, gctime", varName)
+	if left.MaxWindow() > MaxRuleWindow || right.MaxWindow() > MaxRuleWindow {
+		return fmt.Errorf("window too long: maximum is %s", MaxRuleWindow)
 	}
 
 	// Validate operator
@@ -115,16 +267,13 @@ func ValidateCondition(condition string) error {
 		return fmt.Errorf("unknown operator '%s'. Valid operators: >, >=, <, <=, ==, !=", operator)
 	}
 
-	// Validate value is a number
-	if _, err := strconv.ParseFloat(valueStr, 64); err != nil {
-		return fmt.Errorf("invalid value '%s': must be a number", valueStr)
-	}
-
 	return nil
 }
 
 // EvaluateRule evaluates a rule condition against a context
 // Supports simple expressions like: "usage > 80", "pending > 5", "idle == 0"
+// and arithmetic on both sides, e.g., "active / max * 100 > 80", "pending > max / 2"
+// Windowed aggregates, e.g., "avg(usage, 5m) > 80", read history set with SetHistory
 func EvaluateRule(rule *config.AlertRule, ctx *RuleContext) (bool, error) {
 	if !rule.IsEnabled() {
 		return false, nil
@@ -141,26 +290,48 @@ func EvaluateRule(rule *config.AlertRule, ctx *RuleContext) (bool, error) {
 		return false, fmt.Errorf("invalid condition format: %s", condition)
 	}
 
-	varName := strings.ToLower(parts[0])
 	operator := parts[1]
-	valueStr := parts[2]
 
-	// Get the variable value from context
-	varValue, err := getContextValue(ctx, varName)
+	// Evaluate both sides against the context
+	varValue, err := evaluateOperand(parts[0], ctx)
 	if err != nil {
 		return false, err
 	}
-
-	// Parse the comparison value
-	compareValue, err := strconv.ParseFloat(valueStr, 64)
+	compareValue, err := evaluateOperand(parts[2], ctx)
 	if err != nil {
-		return false, fmt.Errorf("invalid value: %s", valueStr)
+		return false, fmt.Errorf("invalid value: %s", parts[2])
 	}
 
 	// Evaluate the condition
 	return evaluateCondition(varValue, operator, compareValue)
 }
 
+// ResolveRule reports whether an active alert of a rule resolves on a context: once its resolve
+// condition holds, e.g., "usage < 80" for "usage > 90", or without one, once the condition
+// no longer holds. Alerts of disabled rules resolve
+func ResolveRule(rule *config.AlertRule, ctx *RuleContext) (bool, error) {
+	if rule.ResolveCondition == "" || !rule.IsEnabled() {
+		triggered, err := EvaluateRule(rule, ctx)
+		return !triggered, err
+	}
+	resolve := *rule
+	resolve.Condition = rule.ResolveCondition
+	return EvaluateRule(&resolve, ctx)
+}
+
+// evaluateOperand evaluates one side of a condition
+// Plain numbers skip the expression parser
+func evaluateOperand(s string, ctx *RuleContext) (float64, error) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	e, err := expr.Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	return e.Eval(ctx)
+}
+
 // parseCondition parses a condition string into parts
 func parseCondition(condition string) []string {
 	// Handle operators with two characters first
@@ -192,6 +363,14 @@ func getContextValue(ctx *RuleContext, varName string) (float64, error) {
 		return float64(ctx.Max), nil
 	case "timeout":
 		return float64(ctx.Timeout), nil
+	case "timeout_rate":
+		return ctx.TimeoutRate, nil
+	case "acquire_p95":
+		return ctx.AcquireP95, nil
+	case "acquire_p99":
+		return ctx.AcquireP99, nil
+	case "acquire_max":
+		return ctx.AcquireMax, nil
 	case "heapusage", "heap_usage":
 		return ctx.HeapUsage, nil
 	case "heapused", "heap_used":
@@ -208,7 +387,24 @@ func getContextValue(ctx *RuleContext, varName string) (float64, error) {
 		return float64(ctx.GcCount), nil
 	case "gctime", "gc_time":
 		return ctx.GcTime, nil
+	case "gc_pauses_per_min":
+		return ctx.GcPausesPerMin, nil
+	case "gc_pause_ms_per_min":
+		return ctx.GcPauseMsPerMin, nil
+	case "avg_gc_pause_ms":
+		return ctx.AvgGcPauseMs, nil
+	case "scrape_failures", "scrapefailures":
+		return float64(ctx.ScrapeFailures), nil
+	case "health":
+		// 1 when UP, 0 for DOWN, OUT_OF_SERVICE and any other status
+		if ctx.Health == models.HealthUp {
+			return 1, nil
+		}
+		return 0, nil
 	default:
+		if v, ok := ctx.Derived[varName]; ok {
+			return v, nil
+		}
 		return 0, fmt.Errorf("unknown variable: %s", varName)
 	}
 }
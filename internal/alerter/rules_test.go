@@ -336,3 +336,204 @@ func TestRenderMessage_InvalidTemplate(t *testing.T) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestRuleContext_AppliesTo(t *testing.T) {
+	scrapeRule := &config.AlertRule{Name: "scrape_broken", Condition: "scrape_failures >= 3"}
+	poolRule := &config.AlertRule{Name: "pool_exhausted", Condition: "idle == 0"}
+
+	ok := NewRuleContext(&models.PoolMetrics{Status: models.StatusHealthy})
+	if !ok.appliesTo(scrapeRule) || !ok.appliesTo(poolRule) {
+		t.Error("all rules should apply to collected metrics")
+	}
+
+	failed := NewRuleContext(&models.PoolMetrics{Status: models.StatusError, ScrapeFailures: 3})
+	if !failed.appliesTo(scrapeRule) {
+		t.Error("scrape rules should apply to failed scrapes")
+	}
+	if failed.appliesTo(poolRule) {
+		t.Error("pool rules should not apply to failed scrapes")
+	}
+
+	triggered, err := EvaluateRule(scrapeRule, failed)
+	if err != nil || !triggered {
+		t.Errorf("EvaluateRule(scrape_failures >= 3) = %v, %v; want true", triggered, err)
+	}
+	if err := ValidateCondition("scrape_failures > 0"); err != nil {
+		t.Errorf("ValidateCondition(scrape_failures) error = %v", err)
+	}
+}
+
+func TestRuleContext_Health(t *testing.T) {
+	healthRule := &config.AlertRule{Name: "instance_down", Condition: "health == 0"}
+
+	// Collectors that don't check the health endpoint leave it empty
+	if NewRuleContext(&models.PoolMetrics{Status: models.StatusHealthy}).appliesTo(healthRule) {
+		t.Error("health rules should not apply without a health status")
+	}
+
+	down := NewRuleContext(&models.PoolMetrics{Status: models.StatusError, ScrapeFailures: 1, Health: models.HealthDown})
+	if !down.appliesTo(healthRule) {
+		t.Error("health rules should apply to failed scrapes with a health status")
+	}
+	triggered, err := EvaluateRule(healthRule, down)
+	if err != nil || !triggered {
+		t.Errorf("EvaluateRule(health == 0) on DOWN = %v, %v; want true", triggered, err)
+	}
+
+	up := NewRuleContext(&models.PoolMetrics{Status: models.StatusHealthy, Health: models.HealthUp})
+	if triggered, _ := EvaluateRule(healthRule, up); triggered {
+		t.Error("EvaluateRule(health == 0) on UP should not trigger")
+	}
+	if err := ValidateCondition("health < 1"); err != nil {
+		t.Errorf("ValidateCondition(health) error = %v", err)
+	}
+}
+
+func TestRuleContext_Labels(t *testing.T) {
+	rule := &config.AlertRule{Name: "payments_pending", Condition: "pending > 5", Labels: map[string]string{"team": "payments"}}
+
+	ctx := NewRuleContext(&models.PoolMetrics{Status: models.StatusHealthy})
+	if ctx.appliesTo(rule) {
+		t.Error("label-scoped rules should not apply to targets without the labels")
+	}
+	ctx.Labels = map[string]string{"team": "payments", "env": "prod"}
+	if !ctx.appliesTo(rule) {
+		t.Error("label-scoped rules should apply to targets with the labels")
+	}
+	ctx.Labels = map[string]string{"team": "search"}
+	if ctx.appliesTo(rule) {
+		t.Error("label-scoped rules should not apply to targets with other label values")
+	}
+}
+
+func TestEvaluateRule_Expressions(t *testing.T) {
+	ctx := NewRuleContext(&models.PoolMetrics{
+		Active:  8,
+		Pending: 6,
+		Max:     10,
+		Derived: map[string]float64{"gc_rate": 2.5},
+	})
+
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{"active / max * 100 > 70", true},
+		{"(active + pending) / max > 1.5", false},
+		{"pending > max / 2", true},
+		{"gc_rate > 2", true},
+		{"GC_RATE * 2 <= 5", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			rule := &config.AlertRule{Name: "test", Condition: tt.condition}
+			result, err := EvaluateRule(rule, ctx)
+			if err != nil {
+				t.Fatalf("EvaluateRule(%s) error: %v", tt.condition, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvaluateRule(%s) = %v, want %v", tt.condition, result, tt.expected)
+			}
+		})
+	}
+
+	// Derived metrics missing from the sample, e.g., delta() on the first sample, can't be evaluated
+	rule := &config.AlertRule{Name: "test", Condition: "heap_rate > 1"}
+	if _, err := EvaluateRule(rule, ctx); err == nil {
+		t.Error("EvaluateRule should fail for a derived metric without a value")
+	}
+
+	// delta() needs a derived metric
+	rule = &config.AlertRule{Name: "test", Condition: "delta(active) > 1"}
+	if _, err := EvaluateRule(rule, ctx); err == nil {
+		t.Error("EvaluateRule should fail for delta() in a condition")
+	}
+}
+
+func TestValidateCondition_Derived(t *testing.T) {
+	if err := ValidateCondition("active / max * 100 > 80"); err != nil {
+		t.Errorf("ValidateCondition error: %v", err)
+	}
+	if err := ValidateCondition("gc_rate > 2"); err == nil {
+		t.Error("ValidateCondition should reject an unknown derived metric")
+	}
+	if err := ValidateCondition("gc_rate > 2", "gc_rate"); err != nil {
+		t.Errorf("ValidateCondition error: %v", err)
+	}
+	if err := ValidateCondition("usage > (80"); err == nil {
+		t.Error("ValidateCondition should reject an invalid expression")
+	}
+}
+
+func TestRuleContext_TimeoutRate(t *testing.T) {
+	rule := &config.AlertRule{Name: "timeout_burst", Condition: "timeout_rate > 5"}
+	if err := ValidateCondition(rule.Condition); err != nil {
+		t.Fatalf("ValidateCondition(timeout_rate) error = %v", err)
+	}
+
+	// A long-running instance with a large counter but no new timeouts
+	quiet := NewRuleContext(&models.PoolMetrics{Status: models.StatusHealthy, Timeout: 10000})
+	if triggered, _ := EvaluateRule(rule, quiet); triggered {
+		t.Error("timeout_rate should not trigger on the cumulative counter")
+	}
+
+	burst := NewRuleContext(&models.PoolMetrics{Status: models.StatusHealthy, Timeout: 10012, TimeoutDelta: 12, TimeoutRate: 8})
+	if triggered, err := EvaluateRule(rule, burst); err != nil || !triggered {
+		t.Errorf("EvaluateRule(timeout_rate > 5) = %v, %v; want true", triggered, err)
+	}
+}
+
+func TestRuleContext_GcPause(t *testing.T) {
+	rule := &config.AlertRule{Name: "gc_pressure", Condition: "gc_pause_ms_per_min > 500"}
+	if err := ValidateCondition(rule.Condition); err != nil {
+		t.Fatalf("ValidateCondition(gc_pause_ms_per_min) error = %v", err)
+	}
+
+	ctx := NewRuleContext(&models.PoolMetrics{Status: models.StatusHealthy, GcTime: 3600, GcPauseMsPerMin: 750, AvgGcPauseMs: 150})
+	if triggered, err := EvaluateRule(rule, ctx); err != nil || !triggered {
+		t.Errorf("EvaluateRule(gc_pause_ms_per_min > 500) = %v, %v; want true", triggered, err)
+	}
+	if v, _ := ctx.Value("avg_gc_pause_ms"); v != 150 {
+		t.Errorf("avg_gc_pause_ms = %v, want 150", v)
+	}
+}
+
+func TestResolveRule(t *testing.T) {
+	rule := &config.AlertRule{Name: "high_usage", Condition: "usage > 90", ResolveCondition: "usage < 80"}
+
+	tests := []struct {
+		usage    float64
+		resolved bool
+	}{
+		{95, false},
+		{85, false}, // Between the thresholds the alert stays active
+		{75, true},
+	}
+	for _, tt := range tests {
+		if resolved, err := ResolveRule(rule, &RuleContext{Usage: tt.usage}); err != nil || resolved != tt.resolved {
+			t.Errorf("ResolveRule(usage %v) = %v, %v; want %v", tt.usage, resolved, err, tt.resolved)
+		}
+	}
+
+	// Without a resolve condition, alerts resolve once the condition no longer holds
+	plain := &config.AlertRule{Name: "high_usage", Condition: "usage > 90"}
+	if resolved, err := ResolveRule(plain, &RuleContext{Usage: 85}); err != nil || !resolved {
+		t.Errorf("ResolveRule(usage 85) without resolve condition = %v, %v; want true", resolved, err)
+	}
+}
+
+func TestRuleContext_Snapshot(t *testing.T) {
+	ctx := NewRuleContext(&models.PoolMetrics{Status: models.StatusHealthy, Active: 9, Idle: 1, Max: 10,
+		Derived: map[string]float64{"gc_rate": 4}})
+	values := ctx.Snapshot()
+	if values["usage"] != 90 || values["active"] != 9 || values["gc_rate"] != 4 {
+		t.Errorf("snapshot = %v, want usage 90, active 9 and gc_rate 4", values)
+	}
+
+	// A failed scrape has no pool metrics to show
+	failed := NewRuleContext(&models.PoolMetrics{Status: models.StatusError, ScrapeFailures: 3})
+	if values := failed.Snapshot(); len(values) != 1 || values["scrape_failures"] != 3 {
+		t.Errorf("snapshot of a failed scrape = %v, want scrape_failures only", values)
+	}
+}
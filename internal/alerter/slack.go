@@ -1,7 +1,10 @@
 package alerter
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jiin/pondy/internal/config"
@@ -42,6 +45,7 @@ type SlackMessage struct {
 type SlackAttachment struct {
 	Color      string       `json:"color"`
 	Title      string       `json:"title"`
+	TitleLink  string       `json:"title_link,omitempty"`
 	Text       string       `json:"text"`
 	Fields     []SlackField `json:"fields,omitempty"`
 	Footer     string       `json:"footer,omitempty"`
@@ -57,6 +61,14 @@ type SlackField struct {
 }
 
 func (s *SlackChannel) Send(alert *models.Alert) error {
+	return s.SendWithContext(alert, nil)
+}
+
+func (s *SlackChannel) SendResolved(alert *models.Alert) error {
+	return s.SendResolvedWithContext(alert, nil)
+}
+
+func (s *SlackChannel) SendWithContext(alert *models.Alert, ctx *RuleContext) error {
 	if !s.IsEnabled() {
 		return nil
 	}
@@ -67,9 +79,10 @@ func (s *SlackChannel) Send(alert *models.Alert) error {
 		IconEmoji: ":warning:",
 		Attachments: []SlackAttachment{
 			{
-				Color: GetSlackColor(alert.Severity),
-				Title: FormatAlertTitle(alert),
-				Text:  alert.Message,
+				Color:     GetSlackColor(alert.Severity),
+				Title:     FormatAlertTitle(alert),
+				TitleLink: alertLink(alert),
+				Text:      alert.Message,
 				Fields: []SlackField{
 					{Title: "Target", Value: alert.TargetName, Short: true},
 					{Title: "Instance", Value: alert.InstanceName, Short: true},
@@ -81,11 +94,13 @@ func (s *SlackChannel) Send(alert *models.Alert) error {
 			},
 		},
 	}
+	addSlackLinks(&msg.Attachments[0], alert)
+	s.applyTemplate(&msg.Attachments[0], NewTemplateData(alert, ctx, false))
 
 	return PostJSON(s.client, s.cfg.WebhookURL, msg)
 }
 
-func (s *SlackChannel) SendResolved(alert *models.Alert) error {
+func (s *SlackChannel) SendResolvedWithContext(alert *models.Alert, ctx *RuleContext) error {
 	if !s.IsEnabled() {
 		return nil
 	}
@@ -96,9 +111,10 @@ func (s *SlackChannel) SendResolved(alert *models.Alert) error {
 		IconEmoji: ":white_check_mark:",
 		Attachments: []SlackAttachment{
 			{
-				Color: "good",
-				Title: FormatResolvedTitle(alert),
-				Text:  alert.Message,
+				Color:     "good",
+				Title:     FormatResolvedTitle(alert),
+				TitleLink: alertLink(alert),
+				Text:      alert.Message,
 				Fields: []SlackField{
 					{Title: "Target", Value: alert.TargetName, Short: true},
 					{Title: "Instance", Value: alert.InstanceName, Short: true},
@@ -109,6 +125,72 @@ func (s *SlackChannel) SendResolved(alert *models.Alert) error {
 			},
 		},
 	}
+	addSlackLinks(&msg.Attachments[0], alert)
+	s.applyTemplate(&msg.Attachments[0], NewTemplateData(alert, ctx, true))
+
+	return PostJSON(s.client, s.cfg.WebhookURL, msg)
+}
+
+// addSlackLinks adds the dashboard and report links of an alert as a field
+func addSlackLinks(att *SlackAttachment, alert *models.Alert) {
+	if links := formatLinks(alert, slackLink); links != "" {
+		att.Fields = append(att.Fields, SlackField{Title: "Links", Value: links})
+	}
+}
+
+// applyTemplate overrides attachment parts with the custom template
+// On render error the built-in attachment is kept
+func (s *SlackChannel) applyTemplate(att *SlackAttachment, data *TemplateData) {
+	if s.cfg.Template.IsEmpty() {
+		return
+	}
+
+	rendered, err := RenderTemplate(s.cfg.Template, data, false)
+	if err != nil {
+		log.Printf("Slack: template render failed, using built-in: %v", err)
+		return
+	}
+
+	if rendered.Title != "" {
+		att.Title = rendered.Title
+	}
+	if rendered.Body != "" {
+		att.Text = rendered.Body
+	}
+	if len(rendered.Fields) > 0 {
+		att.Fields = make([]SlackField, 0, len(rendered.Fields))
+		for _, f := range rendered.Fields {
+			att.Fields = append(att.Fields, SlackField{Title: f.Name, Value: f.Value, Short: true})
+		}
+	}
+}
+
+func (s *SlackChannel) SendDigest(digest *Digest) error {
+	if !s.IsEnabled() {
+		return nil
+	}
+
+	msg := SlackMessage{
+		Channel:   s.cfg.Channel,
+		Username:  GetUsername(s.cfg.Username),
+		IconEmoji: ":warning:",
+		Attachments: []SlackAttachment{
+			{
+				Color:     GetSlackColor(digest.Severity),
+				Title:     digest.Title(),
+				TitleLink: digest.Link(),
+				Text:      digest.Text(),
+				Fields: []SlackField{
+					{Title: "Targets", Value: strings.Join(digest.Targets(), ", "), Short: true},
+					{Title: "Alerts", Value: strconv.Itoa(len(digest.Alerts)), Short: true},
+					{Title: "Severity", Value: digest.Severity, Short: true},
+					{Title: "Status", Value: "Fired", Short: true},
+				},
+				Footer:    FooterText,
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
 
 	return PostJSON(s.client, s.cfg.WebhookURL, msg)
 }
@@ -0,0 +1,249 @@
+package alerter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// TemplatedChannel is implemented by channels that support custom templates
+// The rule context gives templates access to the metrics behind the alert
+type TemplatedChannel interface {
+	Channel
+
+	// SendWithContext sends an alert notification rendered with the rule context
+	SendWithContext(alert *models.Alert, ctx *RuleContext) error
+
+	// SendResolvedWithContext sends a resolution notification rendered with the rule context
+	SendResolvedWithContext(alert *models.Alert, ctx *RuleContext) error
+}
+
+// TemplateData is the data available to notification templates
+type TemplateData struct {
+	Alert    *models.Alert
+	Context  *RuleContext
+	Resolved bool
+	Status   string // "Fired" or "Resolved"
+	Emoji    string
+	Title    string // Built-in title
+}
+
+// RenderedTemplate is the result of rendering a notification template
+type RenderedTemplate struct {
+	Title  string          `json:"title,omitempty"`
+	Body   string          `json:"body,omitempty"`
+	Fields []RenderedField `json:"fields,omitempty"`
+}
+
+// RenderedField is a rendered template field
+type RenderedField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewTemplateData builds template data for an alert
+// A missing context is replaced with one holding only the alert labels
+func NewTemplateData(alert *models.Alert, ctx *RuleContext, resolved bool) *TemplateData {
+	if ctx == nil {
+		ctx = &RuleContext{TargetName: alert.TargetName, InstanceName: alert.InstanceName}
+	}
+
+	data := &TemplateData{
+		Alert:    alert,
+		Context:  ctx,
+		Resolved: resolved,
+		Status:   "Fired",
+		Emoji:    GetEmoji(alert.Severity),
+		Title:    FormatAlertTitle(alert),
+	}
+	if resolved {
+		data.Status = "Resolved"
+		data.Emoji = EmojiResolved
+		data.Title = FormatResolvedTitle(alert)
+	}
+	return data
+}
+
+// templateFuncs are helpers available in notification templates
+var templateFuncs = template.FuncMap{
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"printf": fmt.Sprintf,
+	"formatTime": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+	// json quotes and escapes a value for JSON payloads, e.g., {"text": {{ json .Alert.Message }}}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// RenderTemplate renders all parts of a notification template
+// The body is rendered as HTML (escaped) when html is true
+func RenderTemplate(t *config.NotificationTemplate, data *TemplateData, html bool) (*RenderedTemplate, error) {
+	if t == nil {
+		return nil, fmt.Errorf("template is nil")
+	}
+
+	var result RenderedTemplate
+	var err error
+
+	if result.Title, err = renderText("title", t.Title, data); err != nil {
+		return nil, err
+	}
+	if html {
+		result.Body, err = renderHTML("body", t.Body, data)
+	} else {
+		result.Body, err = renderText("body", t.Body, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range t.Fields {
+		name, err := renderText(fmt.Sprintf("fields[%d].name", i), f.Name, data)
+		if err != nil {
+			return nil, err
+		}
+		value, err := renderText(fmt.Sprintf("fields[%d].value", i), f.Value, data)
+		if err != nil {
+			return nil, err
+		}
+		result.Fields = append(result.Fields, RenderedField{Name: name, Value: value})
+	}
+
+	return &result, nil
+}
+
+func renderText(name, text string, data *TemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func renderHTML(name, text string, data *TemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(templateFuncs)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// SampleTemplateData returns realistic data for validating and previewing templates
+func SampleTemplateData(resolved bool) *TemplateData {
+	now := time.Now()
+	alert := &models.Alert{
+		ID:           1,
+		TargetName:   "order-service",
+		InstanceName: "order-1",
+		RuleName:     "high_usage",
+		Severity:     models.SeverityWarning,
+		Message:      "Pool usage is high: 85.0%",
+		Status:       models.AlertStatusFired,
+		FiredAt:      now.Add(-5 * time.Minute),
+		Channels:     "slack",
+	}
+	if resolved {
+		alert.Status = models.AlertStatusResolved
+		alert.ResolvedAt = &now
+	}
+
+	ctx := &RuleContext{
+		TargetName:   alert.TargetName,
+		InstanceName: alert.InstanceName,
+		Active:       17,
+		Idle:         3,
+		Pending:      2,
+		Max:          20,
+		Usage:        85,
+		HeapUsed:     512 * 1024 * 1024,
+		HeapMax:      1024 * 1024 * 1024,
+		HeapUsage:    50,
+		CpuUsage:     0.35,
+		ThreadsLive:  64,
+	}
+
+	return NewTemplateData(alert, ctx, resolved)
+}
+
+// ValidateTemplate checks that a template parses and renders for both fired and resolved alerts
+func ValidateTemplate(t *config.NotificationTemplate, html bool) error {
+	if t.IsEmpty() {
+		return nil
+	}
+	for _, resolved := range []bool{false, true} {
+		if _, err := RenderTemplate(t, SampleTemplateData(resolved), html); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookSampleMessage holds characters that break a JSON payload unless the template escapes them
+const webhookSampleMessage = "Pool usage is \"high\": 85.0%\nsee C:\\pondy"
+
+// ValidateWebhookTemplate checks that a webhook template renders, and that its body is valid JSON
+// even for alert messages with quotes, backslashes and newlines
+func ValidateWebhookTemplate(t *config.NotificationTemplate) error {
+	if err := ValidateTemplate(t, false); err != nil || t.IsEmpty() || t.Body == "" {
+		return err
+	}
+	for _, resolved := range []bool{false, true} {
+		data := SampleTemplateData(resolved)
+		data.Alert.Message = webhookSampleMessage
+		rendered, err := RenderTemplate(t, data, false)
+		if err != nil {
+			return err
+		}
+		if !json.Valid([]byte(rendered.Body)) {
+			return fmt.Errorf("body is not valid JSON: quote values with json, e.g., {{ json .Alert.Message }}")
+		}
+	}
+	return nil
+}
+
+// ValidateChannelTemplates validates all channel templates in an alerting config
+func ValidateChannelTemplates(channels *config.ChannelsConfig) error {
+	checks := []struct {
+		name string
+		tmpl *config.NotificationTemplate
+		html bool
+	}{
+		{"slack", channels.Slack.Template, false},
+		{"discord", channels.Discord.Template, false},
+		{"email", channels.Email.Template, true},
+	}
+	for _, c := range checks {
+		if err := ValidateTemplate(c.tmpl, c.html); err != nil {
+			return fmt.Errorf("%s template: %w", c.name, err)
+		}
+	}
+	if err := ValidateWebhookTemplate(channels.Webhook.Template); err != nil {
+		return fmt.Errorf("webhook template: %w", err)
+	}
+	return nil
+}
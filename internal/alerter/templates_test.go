@@ -0,0 +1,104 @@
+package alerter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl := &config.NotificationTemplate{
+		Title: "{{ .Emoji }} {{ upper .Alert.Severity }} {{ .Alert.RuleName }}",
+		Body:  "{{ .Alert.TargetName }}: {{ printf \"%.1f\" .Context.Usage }}% ({{ .Status }})",
+		Fields: []config.TemplateField{
+			{Name: "Pending", Value: "{{ .Context.Pending }}"},
+		},
+	}
+
+	rendered, err := RenderTemplate(tmpl, SampleTemplateData(false), false)
+	if err != nil {
+		t.Fatalf("RenderTemplate failed: %v", err)
+	}
+	if rendered.Title != EmojiWarning+" WARNING high_usage" {
+		t.Errorf("Title = %q", rendered.Title)
+	}
+	if rendered.Body != "order-service: 85.0% (Fired)" {
+		t.Errorf("Body = %q", rendered.Body)
+	}
+	if len(rendered.Fields) != 1 || rendered.Fields[0].Value != "2" {
+		t.Errorf("Fields = %+v", rendered.Fields)
+	}
+}
+
+func TestRenderTemplate_HTMLEscapesBody(t *testing.T) {
+	data := SampleTemplateData(false)
+	data.Alert.Message = "<script>alert(1)</script>"
+
+	tmpl := &config.NotificationTemplate{Body: "<p>{{ .Alert.Message }}</p>"}
+	rendered, err := RenderTemplate(tmpl, data, true)
+	if err != nil {
+		t.Fatalf("RenderTemplate failed: %v", err)
+	}
+	if strings.Contains(rendered.Body, "<script>") {
+		t.Errorf("Body should be escaped, got %q", rendered.Body)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    *config.NotificationTemplate
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"valid", &config.NotificationTemplate{Title: "{{ .Alert.RuleName }}"}, false},
+		{"parse error", &config.NotificationTemplate{Body: "{{ .Alert.RuleName "}, true},
+		{"unknown field", &config.NotificationTemplate{Body: "{{ .Alert.Nope }}"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTemplate(tt.tmpl, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    *config.NotificationTemplate
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"no body", &config.NotificationTemplate{Title: "{{ .Alert.RuleName }}"}, false},
+		{"json quoted", &config.NotificationTemplate{Body: `{"text": {{ json .Alert.Message }}, "status": {{ json .Status }}}`}, false},
+		{"hand quoted", &config.NotificationTemplate{Body: `{"text": "{{ .Alert.Message }}"}`}, true},
+		{"not json", &config.NotificationTemplate{Body: "{{ .Alert.RuleName }} fired"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWebhookTemplate(tt.tmpl)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWebhookTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTemplateData_NilContext(t *testing.T) {
+	alert := &models.Alert{TargetName: "api", InstanceName: "default", RuleName: "no_idle"}
+
+	data := NewTemplateData(alert, nil, true)
+	if data.Context == nil || data.Context.TargetName != "api" {
+		t.Fatal("Expected context with alert labels")
+	}
+	if data.Status != "Resolved" || data.Emoji != EmojiResolved {
+		t.Errorf("Status = %q, Emoji = %q", data.Status, data.Emoji)
+	}
+}
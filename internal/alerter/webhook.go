@@ -62,43 +62,126 @@ type AlertData struct {
 	Status       string     `json:"status"`
 	FiredAt      time.Time  `json:"fired_at"`
 	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+
+	Links *models.AlertLinks `json:"links,omitempty"` // Set when alerting.external_url is configured
 }
 
 func (w *WebhookChannel) Send(alert *models.Alert) error {
-	return w.sendPayload("alert_fired", alert)
+	return w.SendWithContext(alert, nil)
 }
 
 func (w *WebhookChannel) SendResolved(alert *models.Alert) error {
-	return w.sendPayload("alert_resolved", alert)
+	return w.SendResolvedWithContext(alert, nil)
+}
+
+func (w *WebhookChannel) SendWithContext(alert *models.Alert, ctx *RuleContext) error {
+	return w.sendPayload("alert_fired", alert, ctx)
+}
+
+func (w *WebhookChannel) SendResolvedWithContext(alert *models.Alert, ctx *RuleContext) error {
+	return w.sendPayload("alert_resolved", alert, ctx)
+}
+
+// renderBody renders the custom payload template
+// Returns nil when no template is set, rendering fails or the body isn't valid JSON, so the
+// built-in payload is used
+func (w *WebhookChannel) renderBody(alert *models.Alert, ctx *RuleContext, resolved bool) []byte {
+	if w.cfg.Template == nil || w.cfg.Template.Body == "" {
+		return nil
+	}
+
+	rendered, err := RenderTemplate(w.cfg.Template, NewTemplateData(alert, ctx, resolved), false)
+	if err != nil {
+		log.Printf("Webhook: template render failed, using built-in payload: %v", err)
+		return nil
+	}
+	if !json.Valid([]byte(rendered.Body)) {
+		log.Printf("Webhook: template rendered invalid JSON for alert %s, using built-in payload", alert.RuleName)
+		return nil
+	}
+	return []byte(rendered.Body)
 }
 
-func (w *WebhookChannel) sendPayload(event string, alert *models.Alert) error {
+func (w *WebhookChannel) sendPayload(event string, alert *models.Alert, ctx *RuleContext) error {
 	if !w.IsEnabled() {
 		return nil
 	}
 
-	payload := WebhookPayload{
-		Event: event,
-		Alert: AlertData{
-			ID:           alert.ID,
-			TargetName:   alert.TargetName,
-			InstanceName: alert.InstanceName,
-			RuleName:     alert.RuleName,
-			Severity:     alert.Severity,
-			Message:      alert.Message,
-			Status:       alert.Status,
-			FiredAt:      alert.FiredAt,
-			ResolvedAt:   alert.ResolvedAt,
-		},
+	body := w.renderBody(alert, ctx, event == "alert_resolved")
+	if body == nil {
+		var err error
+		if body, err = w.builtinPayload(event, alert); err != nil {
+			return err
+		}
+	}
+
+	return w.send(body)
+}
+
+// WebhookDigestPayload is the JSON payload sent for grouped alerts
+type WebhookDigestPayload struct {
+	Event        string      `json:"event"` // "alert_digest"
+	Summary      string      `json:"summary"`
+	Severity     string      `json:"severity"`
+	Alerts       []AlertData `json:"alerts"`
+	Timestamp    time.Time   `json:"timestamp"`
+	PondyVersion string      `json:"pondy_version"`
+}
+
+func (w *WebhookChannel) SendDigest(digest *Digest) error {
+	if !w.IsEnabled() {
+		return nil
+	}
+
+	payload := WebhookDigestPayload{
+		Event:        "alert_digest",
+		Summary:      digest.Summary(),
+		Severity:     digest.Severity,
+		Alerts:       make([]AlertData, 0, len(digest.Alerts)),
 		Timestamp:    time.Now(),
 		PondyVersion: "0.3.0",
 	}
+	for _, alert := range digest.Alerts {
+		payload.Alerts = append(payload.Alerts, newAlertData(alert))
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
+	return w.send(body)
+}
 
+// newAlertData converts an alert to its payload representation
+func newAlertData(alert *models.Alert) AlertData {
+	return AlertData{
+		ID:           alert.ID,
+		TargetName:   alert.TargetName,
+		InstanceName: alert.InstanceName,
+		RuleName:     alert.RuleName,
+		Severity:     alert.Severity,
+		Message:      alert.Message,
+		Status:       alert.Status,
+		FiredAt:      alert.FiredAt,
+		ResolvedAt:   alert.ResolvedAt,
+		Links:        alert.Links,
+	}
+}
+
+// builtinPayload builds the default JSON payload
+func (w *WebhookChannel) builtinPayload(event string, alert *models.Alert) ([]byte, error) {
+	payload := WebhookPayload{
+		Event:        event,
+		Alert:        newAlertData(alert),
+		Timestamp:    time.Now(),
+		PondyVersion: "0.3.0",
+	}
+
+	return json.Marshal(payload)
+}
+
+// send delivers the payload with retries
+func (w *WebhookChannel) send(body []byte) error {
 	method := w.cfg.Method
 	if method == "" {
 		method = "POST"
@@ -106,6 +189,7 @@ func (w *WebhookChannel) sendPayload(event string, alert *models.Alert) error {
 
 	// Retry with exponential backoff
 	var lastErr error
+	var lastStatus int
 	delay := webhookRetryDelay
 
 	for attempt := 1; attempt <= webhookMaxRetries; attempt++ {
@@ -125,6 +209,7 @@ func (w *WebhookChannel) sendPayload(event string, alert *models.Alert) error {
 		resp, err := w.client.Do(req)
 		if err != nil {
 			lastErr = err
+			lastStatus = 0
 			if attempt < webhookMaxRetries {
 				log.Printf("Webhook: attempt %d/%d failed: %v, retrying in %v", attempt, webhookMaxRetries, err, delay)
 				time.Sleep(delay)
@@ -144,6 +229,7 @@ func (w *WebhookChannel) sendPayload(event string, alert *models.Alert) error {
 		if resp.StatusCode >= 500 {
 			// Server error - drain body before retry
 			drainAndClose()
+			lastStatus = resp.StatusCode
 			lastErr = fmt.Errorf("webhook returned status %d", resp.StatusCode)
 			if attempt < webhookMaxRetries {
 				log.Printf("Webhook: attempt %d/%d failed with status %d, retrying in %v", attempt, webhookMaxRetries, resp.StatusCode, delay)
@@ -156,7 +242,11 @@ func (w *WebhookChannel) sendPayload(event string, alert *models.Alert) error {
 		if resp.StatusCode >= 400 {
 			// Client error - don't retry
 			drainAndClose()
-			return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+			return &DeliveryError{
+				StatusCode: resp.StatusCode,
+				Retries:    attempt - 1,
+				Err:        fmt.Errorf("webhook returned status %d", resp.StatusCode),
+			}
 		}
 
 		// Success - drain body for connection reuse
@@ -164,5 +254,9 @@ func (w *WebhookChannel) sendPayload(event string, alert *models.Alert) error {
 		return nil
 	}
 
-	return fmt.Errorf("webhook failed after %d attempts: %w", webhookMaxRetries, lastErr)
+	return &DeliveryError{
+		StatusCode: lastStatus,
+		Retries:    webhookMaxRetries - 1,
+		Err:        fmt.Errorf("webhook failed after %d attempts: %w", webhookMaxRetries, lastErr),
+	}
 }
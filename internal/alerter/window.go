@@ -0,0 +1,92 @@
+package alerter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jiin/pondy/internal/expr"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// MaxRuleWindow bounds windowed aggregates in conditions, e.g., avg(usage, 5m)
+const MaxRuleWindow = 24 * time.Hour
+
+// HistoryFunc loads stored samples of the evaluated instance within a time range
+type HistoryFunc func(from, to time.Time) ([]models.PoolMetrics, error)
+
+// historyWindow lazily loads recent samples, shared by all rules of a check
+type historyWindow struct {
+	load    HistoryFunc
+	from    time.Time
+	samples []models.PoolMetrics
+	loaded  bool
+}
+
+// SetHistory enables windowed aggregates backed by stored samples
+// Without history, aggregates only see the current sample
+func (ctx *RuleContext) SetHistory(load HistoryFunc) {
+	ctx.history = &historyWindow{load: load}
+}
+
+// Window aggregates a variable over the current sample and the stored samples within window,
+// implementing expr.WindowEnv
+func (ctx *RuleContext) Window(fn, name string, window time.Duration) (float64, error) {
+	samples, err := ctx.windowSamples(window)
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]float64, 0, len(samples)+1)
+	for i := range samples {
+		// Samples without the variable, e.g., a derived metric, are skipped
+		if v, err := NewRuleContext(&samples[i]).Value(name); err == nil {
+			values = append(values, v)
+		}
+	}
+	cur, curErr := ctx.Value(name)
+	if curErr == nil {
+		values = append(values, cur)
+	}
+
+	if len(values) == 0 {
+		if curErr != nil {
+			return 0, curErr
+		}
+		return 0, fmt.Errorf("no data for %s over %s", name, window)
+	}
+	return expr.Aggregate(fn, values), nil
+}
+
+// windowSamples returns the stored samples before the current one within window
+func (ctx *RuleContext) windowSamples(window time.Duration) ([]models.PoolMetrics, error) {
+	h := ctx.history
+	if h == nil {
+		return nil, nil
+	}
+
+	to := ctx.Timestamp
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := to.Add(-window)
+
+	// Load once for the longest window requested so far
+	if !h.loaded || from.Before(h.from) {
+		samples, err := h.load(from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load history: %w", err)
+		}
+		h.samples = samples
+		h.from = from
+		h.loaded = true
+	}
+
+	result := make([]models.PoolMetrics, 0, len(h.samples))
+	for _, s := range h.samples {
+		if s.Status == models.StatusError || s.Timestamp.Before(from) || !s.Timestamp.Before(to) {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package alerter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestEvaluateRule_Window(t *testing.T) {
+	now := time.Now()
+	var history []models.PoolMetrics
+	for i, active := range []int{9, 9, 2, 9, 9} {
+		history = append(history, models.PoolMetrics{
+			Active:    active,
+			Pending:   i,
+			Max:       10,
+			Timestamp: now.Add(time.Duration(i-5) * time.Minute),
+		})
+	}
+	// Failed scrapes have zero-valued metrics and are skipped
+	history = append(history, models.PoolMetrics{Status: models.StatusError, Timestamp: now.Add(-30 * time.Second)})
+
+	loads := 0
+	ctx := NewRuleContext(&models.PoolMetrics{Active: 9, Max: 10, Timestamp: now})
+	ctx.SetHistory(func(from, to time.Time) ([]models.PoolMetrics, error) {
+		loads++
+		var result []models.PoolMetrics
+		for _, m := range history {
+			if !m.Timestamp.Before(from) && !m.Timestamp.After(to) {
+				result = append(result, m)
+			}
+		}
+		return result, nil
+	})
+
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{"usage > 80", true},
+		{"avg(usage, 10m) > 80", false}, // (90*5 + 20) / 6 = 78.3
+		{"avg(usage, 150s) > 80", true}, // last 2 stored samples and the current one
+		{"min(usage, 10m) < 50", true},
+		{"max(pending, 10m) >= 4", true},
+		{"max(pending, 90s) >= 4", true},
+		{"max(pending, 30s) > 0", false}, // current sample only
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			rule := &config.AlertRule{Name: "test", Condition: tt.condition}
+			result, err := EvaluateRule(rule, ctx)
+			if err != nil {
+				t.Fatalf("EvaluateRule(%s) error: %v", tt.condition, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvaluateRule(%s) = %v, want %v", tt.condition, result, tt.expected)
+			}
+		})
+	}
+
+	// History is loaded once for the longest window
+	if loads != 1 {
+		t.Errorf("history loaded %d times, want 1", loads)
+	}
+}
+
+func TestEvaluateRule_WindowWithoutHistory(t *testing.T) {
+	ctx := NewRuleContext(&models.PoolMetrics{Active: 9, Max: 10, Timestamp: time.Now()})
+	rule := &config.AlertRule{Name: "test", Condition: "avg(usage, 5m) > 80"}
+
+	result, err := EvaluateRule(rule, ctx)
+	if err != nil || !result {
+		t.Errorf("EvaluateRule() = %v, %v, want true from the current sample", result, err)
+	}
+
+	ctx.SetHistory(func(from, to time.Time) ([]models.PoolMetrics, error) {
+		return nil, errors.New("database is locked")
+	})
+	if _, err := EvaluateRule(rule, ctx); err == nil {
+		t.Error("EvaluateRule should fail when history can't be loaded")
+	}
+}
+
+func TestValidateCondition_Window(t *testing.T) {
+	valid := []string{"avg(usage, 5m) > 80", "max(pending, 10m) > 5", "avg(usage, 5m) > usage", "min(gc_rate, 1h) > 1"}
+	for _, c := range valid {
+		if err := ValidateCondition(c, "gc_rate"); err != nil {
+			t.Errorf("ValidateCondition(%s) error: %v", c, err)
+		}
+	}
+
+	invalid := []string{"avg(usage) > 80", "avg(unknown, 5m) > 80", "avg(usage, 2d) > 80", "avg(usage, 5) > 80"}
+	for _, c := range invalid {
+		if err := ValidateCondition(c); err == nil {
+			t.Errorf("ValidateCondition(%s) should fail", c)
+		}
+	}
+}
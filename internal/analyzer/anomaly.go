@@ -16,6 +16,8 @@ type AnomalyResult struct {
 	Anomalies    []Anomaly       `json:"anomalies"`
 	Statistics   AnomalyStats    `json:"statistics"`
 	RiskLevel    string          `json:"risk_level"` // normal, elevated, high
+	Method       string          `json:"method"`     // global, seasonal
+	Algorithm    string          `json:"algorithm"`  // zscore, mad, ewma, iqr
 }
 
 // Anomaly represents a detected anomaly
@@ -36,11 +38,26 @@ type AnomalyStats struct {
 	Threshold      float64 `json:"threshold"`
 	AnomalyCount   int     `json:"anomaly_count"`
 	AnomalyPercent float64 `json:"anomaly_percent"`
+
+	// Center and Spread are the algorithm's baseline level and scale,
+	// e.g., the median and scaled MAD, or the final moving average and deviation
+	Center float64 `json:"center"`
+	Spread float64 `json:"spread"`
 }
 
+// Anomaly detection methods
+const (
+	AnomalyMethodGlobal   = "global"   // One mean/stddev over the analyzed range
+	AnomalyMethodSeasonal = "seasonal" // Per hour-of-day (and optionally weekday) baselines
+)
+
 // AnomalyOptions configures anomaly detection sensitivity
 type AnomalyOptions struct {
-	Sensitivity string // low, medium, high (affects std deviation threshold)
+	Sensitivity string               // low, medium, high (affects std deviation threshold)
+	Method      string               // global (default), seasonal
+	Algorithm   string               // zscore (default), mad, ewma, iqr; seasonal baselines always use zscore
+	Weekday     bool                 // Seasonal baselines per weekday as well as hour
+	Baseline    []models.PoolMetrics // History for seasonal baselines (default: the analyzed metrics)
 }
 
 // GetThresholds returns detection thresholds based on sensitivity
@@ -69,24 +86,35 @@ func DetectAnomaliesWithOptions(targetName string, metrics []models.PoolMetrics,
 	if loc == nil {
 		loc = time.UTC
 	}
+	method := AnomalyMethodGlobal
+	algorithm := AnomalyAlgorithmZScore
+	if opts.Method == AnomalyMethodSeasonal {
+		method = AnomalyMethodSeasonal
+	} else if IsAnomalyAlgorithm(opts.Algorithm) {
+		algorithm = opts.Algorithm
+	}
 
 	if len(metrics) < 10 {
 		return &AnomalyResult{
 			TargetName: targetName,
 			DataPoints: len(metrics),
 			RiskLevel:  "unknown",
+			Method:     method,
+			Algorithm:  algorithm,
 			Anomalies:  []Anomaly{},
 			Statistics: AnomalyStats{},
 		}
 	}
 
-	// Calculate usage percentages
+	// Calculate usage percentages and timeout rates
 	usages := make([]float64, len(metrics))
+	timeoutRates := make([]float64, len(metrics))
 	var minTime, maxTime time.Time
 	for i, m := range metrics {
 		if m.Max > 0 {
 			usages[i] = float64(m.Active) / float64(m.Max) * 100
 		}
+		timeoutRates[i] = m.TimeoutRate
 		if i == 0 || m.Timestamp.Before(minTime) {
 			minTime = m.Timestamp
 		}
@@ -98,29 +126,53 @@ func DetectAnomaliesWithOptions(targetName string, metrics []models.PoolMetrics,
 	// Calculate mean and standard deviation
 	mean := calculateMean(usages)
 	stdDev := calculateStdDev(usages, mean)
+	timeoutMean := calculateMean(timeoutRates)
+	timeoutStdDev := calculateStdDev(timeoutRates, timeoutMean)
+
+	// Seasonal mode compares each sample against its hour (and weekday) bucket
+	var seasonal *seasonalBaseline
+	normal := "normal"
+	if method == AnomalyMethodSeasonal {
+		baseline := opts.Baseline
+		if len(baseline) == 0 {
+			baseline = metrics
+		}
+		seasonal = buildSeasonalBaseline(baseline, loc, opts.Weekday)
+		normal = "normal for this time of day"
+	}
 
 	// Get thresholds based on sensitivity
 	stdDevThreshold, spikeThreshold, pendingThreshold := opts.GetThresholds()
+	usageThreshold := stdDevThreshold
+	if algorithm == AnomalyAlgorithmIQR {
+		usageThreshold = iqrThreshold(opts.Sensitivity)
+	}
+	scores := scoreUsage(algorithm, usages, mean, stdDev)
 
 	// Detect anomalies
 	var anomalies []Anomaly
 
 	for i, m := range metrics {
 		usage := usages[i]
-		deviation := (usage - mean) / stdDev
+		expected, deviation := scores.expected[i], scores.deviation[i]
+		if seasonal != nil {
+			var sd float64
+			expected, sd = seasonal.expected(m.Timestamp)
+			deviation = (usage - expected) / sd
+		}
 
 		// Check for high usage anomaly
-		if math.Abs(deviation) > stdDevThreshold {
+		if math.Abs(deviation) > usageThreshold {
 			severity := "warning"
-			if math.Abs(deviation) > stdDevThreshold+1 {
+			if math.Abs(deviation) > usageThreshold+1 {
 				severity = "critical"
 			}
 
 			anomalyType := "high_usage"
-			message := "Usage significantly higher than normal"
+			message := "Usage significantly higher than " + normal
 			if deviation < 0 {
 				anomalyType = "low_usage"
-				message = "Usage significantly lower than normal"
+				message = "Usage significantly lower than " + normal
 			}
 
 			anomalies = append(anomalies, Anomaly{
@@ -129,7 +181,7 @@ func DetectAnomaliesWithOptions(targetName string, metrics []models.PoolMetrics,
 				Severity:  severity,
 				Message:   message,
 				Value:     usage,
-				Expected:  mean,
+				Expected:  expected,
 				Deviation: deviation,
 			})
 		}
@@ -161,6 +213,21 @@ func DetectAnomaliesWithOptions(targetName string, metrics []models.PoolMetrics,
 			}
 		}
 
+		// Check for a timeout burst; a steady rate is left to the recommendations
+		if m.TimeoutRate > 0 && timeoutStdDev > 0 {
+			if dev := (m.TimeoutRate - timeoutMean) / timeoutStdDev; dev > stdDevThreshold {
+				anomalies = append(anomalies, Anomaly{
+					Timestamp: m.Timestamp.In(loc),
+					Type:      "timeout_spike",
+					Severity:  "critical",
+					Message:   "Connection timeouts spiked",
+					Value:     m.TimeoutRate,
+					Expected:  timeoutMean,
+					Deviation: dev,
+				})
+			}
+		}
+
 		// Check for sustained high pending
 		if m.Pending > 0 && m.Max > 0 {
 			pendingRatio := float64(m.Pending) / float64(m.Max) * 100
@@ -194,12 +261,16 @@ func DetectAnomaliesWithOptions(targetName string, metrics []models.PoolMetrics,
 		DataPoints:   len(metrics),
 		Anomalies:    anomalies,
 		RiskLevel:    riskLevel,
+		Method:       method,
+		Algorithm:    algorithm,
 		Statistics: AnomalyStats{
 			MeanUsage:      mean,
 			StdDeviation:   stdDev,
-			Threshold:      stdDevThreshold,
+			Threshold:      usageThreshold,
 			AnomalyCount:   len(anomalies),
 			AnomalyPercent: anomalyPercent,
+			Center:         scores.center,
+			Spread:         scores.spread,
 		},
 	}
 }
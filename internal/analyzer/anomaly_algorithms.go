@@ -0,0 +1,147 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+)
+
+// Anomaly detection algorithms for usage levels
+const (
+	AnomalyAlgorithmZScore = "zscore" // Distance from the mean in standard deviations
+	AnomalyAlgorithmMAD    = "mad"    // Distance from the median in scaled median absolute deviations
+	AnomalyAlgorithmEWMA   = "ewma"   // Distance from an exponentially weighted moving average
+	AnomalyAlgorithmIQR    = "iqr"    // Distance beyond the quartiles in interquartile ranges
+)
+
+// IsAnomalyAlgorithm reports whether algorithm is a supported anomaly detection algorithm
+func IsAnomalyAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case AnomalyAlgorithmZScore, AnomalyAlgorithmMAD, AnomalyAlgorithmEWMA, AnomalyAlgorithmIQR:
+		return true
+	}
+	return false
+}
+
+const (
+	// madScale turns a median absolute deviation into a standard deviation estimate for normal data
+	madScale = 1.4826
+	// ewmaLambda is the weight of the newest sample in the moving average and variance
+	ewmaLambda = 0.3
+	// minRobustSpread keeps near-flat series from turning small changes into anomalies (usage points)
+	minRobustSpread = 1.0
+)
+
+// usageScorer scores each usage sample as an expected value and a deviation from it
+// center and spread summarize the baseline for the result statistics
+type usageScorer struct {
+	expected  []float64
+	deviation []float64
+	center    float64
+	spread    float64
+}
+
+// scoreUsage scores usages with the given algorithm; unknown algorithms use the z-score
+func scoreUsage(algorithm string, usages []float64, mean, stdDev float64) usageScorer {
+	switch algorithm {
+	case AnomalyAlgorithmMAD:
+		return scoreMAD(usages)
+	case AnomalyAlgorithmEWMA:
+		return scoreEWMA(usages, stdDev)
+	case AnomalyAlgorithmIQR:
+		return scoreIQR(usages)
+	default:
+		s := usageScorer{center: mean, spread: stdDev}
+		for _, u := range usages {
+			s.expected = append(s.expected, mean)
+			s.deviation = append(s.deviation, (u-mean)/stdDev)
+		}
+		return s
+	}
+}
+
+// scoreMAD measures the distance from the median in robust standard deviations
+// A few extreme samples move neither the median nor the MAD
+func scoreMAD(usages []float64) usageScorer {
+	sorted := sortedCopy(usages)
+	median := percentile(sorted, 50)
+
+	absDev := make([]float64, len(usages))
+	for i, u := range usages {
+		absDev[i] = math.Abs(u - median)
+	}
+	sort.Float64s(absDev)
+	sigma := math.Max(percentile(absDev, 50)*madScale, minRobustSpread)
+
+	s := usageScorer{center: median, spread: sigma}
+	for _, u := range usages {
+		s.expected = append(s.expected, median)
+		s.deviation = append(s.deviation, (u-median)/sigma)
+	}
+	return s
+}
+
+// scoreEWMA compares each sample with the moving average of the samples before it,
+// in standard deviations of the moving residual variance, so the baseline follows slow drifts
+func scoreEWMA(usages []float64, stdDev float64) usageScorer {
+	var s usageScorer
+	if len(usages) == 0 {
+		return s
+	}
+
+	avg := usages[0]
+	variance := stdDev * stdDev
+	for _, u := range usages {
+		sigma := math.Max(math.Sqrt(variance), minRobustSpread)
+		residual := u - avg
+		s.expected = append(s.expected, avg)
+		s.deviation = append(s.deviation, residual/sigma)
+
+		avg += ewmaLambda * residual
+		variance = (1 - ewmaLambda) * (variance + ewmaLambda*residual*residual)
+	}
+	s.center = avg
+	s.spread = math.Max(math.Sqrt(variance), minRobustSpread)
+	return s
+}
+
+// scoreIQR measures how far samples fall outside the quartiles in interquartile ranges
+// Samples between the quartiles have no deviation
+func scoreIQR(usages []float64) usageScorer {
+	sorted := sortedCopy(usages)
+	q1 := percentile(sorted, 25)
+	q3 := percentile(sorted, 75)
+	iqr := math.Max(q3-q1, minRobustSpread)
+
+	s := usageScorer{center: percentile(sorted, 50), spread: iqr}
+	for _, u := range usages {
+		var deviation float64
+		switch {
+		case u > q3:
+			deviation = (u - q3) / iqr
+		case u < q1:
+			deviation = (u - q1) / iqr
+		}
+		s.expected = append(s.expected, s.center)
+		s.deviation = append(s.deviation, deviation)
+	}
+	return s
+}
+
+// iqrThreshold returns the fence multiplier for a sensitivity, 1.5 being Tukey's fences
+func iqrThreshold(sensitivity string) float64 {
+	switch sensitivity {
+	case "low":
+		return 3.0
+	case "high":
+		return 1.0
+	default:
+		return 1.5
+	}
+}
+
+func sortedCopy(values []float64) []float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return sorted
+}
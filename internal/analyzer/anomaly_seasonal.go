@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"math"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+const (
+	// minBucketSamples is the number of samples a seasonal bucket needs before it is trusted
+	minBucketSamples = 5
+	// minSeasonalStdDev keeps flat buckets from flagging every small change (percentage points)
+	minSeasonalStdDev = 1.0
+)
+
+// baselineStats is the usage distribution of one bucket
+type baselineStats struct {
+	mean   float64
+	stdDev float64
+	n      int
+}
+
+// seasonalBaseline holds usage baselines per hour-of-day and weekday
+type seasonalBaseline struct {
+	loc     *time.Location
+	weekday bool
+	hourly  [24]baselineStats
+	weekly  [7 * 24]baselineStats
+	global  baselineStats
+}
+
+// buildSeasonalBaseline groups history usage into hour (and weekday/hour) buckets
+func buildSeasonalBaseline(history []models.PoolMetrics, loc *time.Location, weekday bool) *seasonalBaseline {
+	var hourly [24][]float64
+	var weekly [7 * 24][]float64
+	var all []float64
+
+	for _, m := range history {
+		if m.Max <= 0 {
+			continue
+		}
+		usage := float64(m.Active) / float64(m.Max) * 100
+		t := m.Timestamp.In(loc)
+		hourly[t.Hour()] = append(hourly[t.Hour()], usage)
+		if weekday {
+			weekly[weekBucket(t)] = append(weekly[weekBucket(t)], usage)
+		}
+		all = append(all, usage)
+	}
+
+	b := &seasonalBaseline{loc: loc, weekday: weekday, global: newBaselineStats(all)}
+	for h := range hourly {
+		b.hourly[h] = newBaselineStats(hourly[h])
+	}
+	if weekday {
+		for i := range weekly {
+			b.weekly[i] = newBaselineStats(weekly[i])
+		}
+	}
+	return b
+}
+
+func newBaselineStats(values []float64) baselineStats {
+	mean := calculateMean(values)
+	return baselineStats{
+		mean:   mean,
+		stdDev: calculateStdDev(values, mean),
+		n:      len(values),
+	}
+}
+
+func weekBucket(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// expected returns the baseline mean and stddev for a timestamp
+// Sparse weekday buckets fall back to the hour bucket, sparse hours to the whole history
+func (b *seasonalBaseline) expected(ts time.Time) (mean, stdDev float64) {
+	t := ts.In(b.loc)
+
+	stats := b.global
+	if b.weekday && b.weekly[weekBucket(t)].n >= minBucketSamples {
+		stats = b.weekly[weekBucket(t)]
+	} else if b.hourly[t.Hour()].n >= minBucketSamples {
+		stats = b.hourly[t.Hour()]
+	}
+	return stats.mean, math.Max(stats.stdDev, minSeasonalStdDev)
+}
@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// dailyRampMetrics returns a week of 10-minute samples: 20% at night, 80% from 09:00 to 12:00
+func dailyRampMetrics() []models.PoolMetrics {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var metrics []models.PoolMetrics
+	for ts := start; ts.Before(start.Add(7 * 24 * time.Hour)); ts = ts.Add(10 * time.Minute) {
+		active := 20
+		if h := ts.Hour(); h >= 9 && h < 12 {
+			active = 80
+		}
+		metrics = append(metrics, models.PoolMetrics{Active: active, Max: 100, Timestamp: ts})
+	}
+	return metrics
+}
+
+func countType(anomalies []Anomaly, typ string) int {
+	n := 0
+	for _, a := range anomalies {
+		if a.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDetectAnomalies_SeasonalIgnoresDailyRamp(t *testing.T) {
+	metrics := dailyRampMetrics()
+
+	global := DetectAnomaliesWithOptions("test", metrics, nil, nil)
+	if global.Method != AnomalyMethodGlobal {
+		t.Errorf("Method = %s, want global", global.Method)
+	}
+	if countType(global.Anomalies, "high_usage") == 0 {
+		t.Fatal("global mode should flag the morning ramp-up")
+	}
+
+	seasonal := DetectAnomaliesWithOptions("test", metrics, nil, &AnomalyOptions{Method: AnomalyMethodSeasonal})
+	if seasonal.Method != AnomalyMethodSeasonal {
+		t.Errorf("Method = %s, want seasonal", seasonal.Method)
+	}
+	if n := countType(seasonal.Anomalies, "high_usage"); n != 0 {
+		t.Errorf("seasonal mode flagged %d high_usage anomalies, want 0", n)
+	}
+}
+
+func TestDetectAnomalies_SeasonalFlagsOffHourSpike(t *testing.T) {
+	baseline := dailyRampMetrics()
+
+	// The last day again, with the pool busy at 03:00
+	day := baseline[len(baseline)-144:]
+	metrics := make([]models.PoolMetrics, len(day))
+	copy(metrics, day)
+	metrics[3*6].Active = 80
+
+	result := DetectAnomaliesWithOptions("test", metrics, nil, &AnomalyOptions{
+		Method:   AnomalyMethodSeasonal,
+		Baseline: baseline,
+	})
+
+	found := false
+	for _, a := range result.Anomalies {
+		if a.Type == "high_usage" {
+			if a.Timestamp.Hour() != 3 || a.Expected != 20 {
+				t.Errorf("anomaly at %v expected %.1f, want 03:00 and 20", a.Timestamp, a.Expected)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Error("seasonal mode should flag usage outside the 03:00 baseline")
+	}
+}
+
+func TestSeasonalBaseline_WeekdayFallback(t *testing.T) {
+	// Only Mondays are busy, and Tuesday has too few samples for its own bucket
+	monday := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var history []models.PoolMetrics
+	for week := 0; week < 4; week++ {
+		for i := 0; i < 2; i++ {
+			ts := monday.AddDate(0, 0, 7*week).Add(time.Duration(i) * 10 * time.Minute)
+			history = append(history, models.PoolMetrics{Active: 90, Max: 100, Timestamp: ts})
+			history = append(history, models.PoolMetrics{Active: 30, Max: 100, Timestamp: ts.AddDate(0, 0, 2)})
+		}
+	}
+
+	b := buildSeasonalBaseline(history, time.UTC, true)
+	if mean, _ := b.expected(monday.AddDate(0, 0, 28)); mean != 90 {
+		t.Errorf("Monday baseline = %.1f, want 90", mean)
+	}
+	if mean, _ := b.expected(monday.AddDate(0, 0, 2)); mean != 30 {
+		t.Errorf("Wednesday baseline = %.1f, want 30", mean)
+	}
+	// Tuesday 10:00 has no samples of its own and uses the 10:00 bucket
+	if mean, _ := b.expected(monday.AddDate(0, 0, 1)); mean != 60 {
+		t.Errorf("Tuesday baseline = %.1f, want 60", mean)
+	}
+}
@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestDetectAnomalies_TimeoutSpike(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var metrics []models.PoolMetrics
+	for i := 0; i < 30; i++ {
+		// The counter is high from a long uptime; only one sample has new timeouts
+		m := models.PoolMetrics{Active: 50, Max: 100, Timeout: 900, Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		if i == 20 {
+			m.Timeout, m.TimeoutDelta, m.TimeoutRate = 912, 12, 12
+		}
+		metrics = append(metrics, m)
+	}
+
+	result := DetectAnomalies("orders", metrics, time.UTC)
+	if n := countType(result.Anomalies, "timeout_spike"); n != 1 {
+		t.Fatalf("timeout_spike anomalies = %d, want 1: %+v", n, result.Anomalies)
+	}
+
+	// Counters that never move are not anomalous
+	for i := range metrics {
+		metrics[i].TimeoutDelta, metrics[i].TimeoutRate = 0, 0
+	}
+	if n := countType(DetectAnomalies("orders", metrics, time.UTC).Anomalies, "timeout_spike"); n != 0 {
+		t.Errorf("timeout_spike anomalies without timeouts = %d, want 0", n)
+	}
+}
+
+// spikyMetrics returns a pool idling around 30% with frequent 95% bursts and one 60% sample at index 50
+func spikyMetrics() []models.PoolMetrics {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var metrics []models.PoolMetrics
+	for i := 0; i < 100; i++ {
+		active := 29 + i%3
+		switch {
+		case i == 50:
+			active = 60
+		case i%7 == 3:
+			active = 95
+		}
+		metrics = append(metrics, models.PoolMetrics{Active: active, Max: 100, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	return metrics
+}
+
+// hasUsageAnomalyAt reports whether a high_usage anomaly was found at the given sample
+func hasUsageAnomalyAt(result *AnomalyResult, ts time.Time) bool {
+	for _, a := range result.Anomalies {
+		if a.Type == "high_usage" && a.Timestamp.Equal(ts) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetectAnomalies_RobustAlgorithms(t *testing.T) {
+	metrics := spikyMetrics()
+	target := metrics[50].Timestamp
+
+	// The bursts inflate the standard deviation so the z-score misses the 60% sample
+	if hasUsageAnomalyAt(DetectAnomalies("orders", metrics, time.UTC), target) {
+		t.Error("zscore flagged the 60% sample, the scenario no longer covers a blind z-score")
+	}
+
+	for _, algorithm := range []string{AnomalyAlgorithmMAD, AnomalyAlgorithmIQR} {
+		result := DetectAnomaliesWithOptions("orders", metrics, time.UTC, &AnomalyOptions{Algorithm: algorithm})
+		if result.Algorithm != algorithm {
+			t.Errorf("Algorithm = %s, want %s", result.Algorithm, algorithm)
+		}
+		if !hasUsageAnomalyAt(result, target) {
+			t.Errorf("%s did not flag the 60%% sample", algorithm)
+		}
+		if result.Statistics.Center != 30 {
+			t.Errorf("%s center = %v, want the median 30", algorithm, result.Statistics.Center)
+		}
+	}
+}
+
+func TestDetectAnomalies_EWMAFollowsDrift(t *testing.T) {
+	// Usage climbs steadily from 20% to 80% with one 15 point jump at index 50
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var metrics []models.PoolMetrics
+	for i := 0; i < 100; i++ {
+		active := 20 + i*60/100
+		if i == 50 {
+			active += 15
+		}
+		metrics = append(metrics, models.PoolMetrics{Active: active, Max: 100, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	result := DetectAnomaliesWithOptions("orders", metrics, time.UTC, &AnomalyOptions{Algorithm: AnomalyAlgorithmEWMA})
+	if !hasUsageAnomalyAt(result, metrics[50].Timestamp) {
+		t.Errorf("ewma did not flag the jump: %+v", result.Anomalies)
+	}
+	if n := countType(result.Anomalies, "high_usage") + countType(result.Anomalies, "low_usage"); n > 2 {
+		t.Errorf("ewma flagged %d usage anomalies on a steady drift, want the jump only", n)
+	}
+}
+
+func TestDetectAnomalies_SeasonalIgnoresAlgorithm(t *testing.T) {
+	result := DetectAnomaliesWithOptions("orders", spikyMetrics(), time.UTC,
+		&AnomalyOptions{Method: AnomalyMethodSeasonal, Algorithm: AnomalyAlgorithmMAD})
+	if result.Algorithm != AnomalyAlgorithmZScore {
+		t.Errorf("Algorithm = %s, want zscore for seasonal baselines", result.Algorithm)
+	}
+}
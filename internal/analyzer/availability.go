@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	"math"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// AvailabilityResult is the health endpoint uptime of a target's instances over a time range
+type AvailabilityResult struct {
+	TargetName    string                 `json:"target_name"`
+	From          time.Time              `json:"from"`
+	To            time.Time              `json:"to"`
+	Checks        int                    `json:"checks"`
+	UptimePercent float64                `json:"uptime_percent"` // Share of all checks that were UP
+	Instances     []InstanceAvailability `json:"instances"`
+}
+
+// InstanceAvailability is the health history of one instance
+type InstanceAvailability struct {
+	InstanceName    string               `json:"instance_name"`
+	Status          string               `json:"status"` // Latest status
+	LastCheck       time.Time            `json:"last_check"`
+	Checks          int                  `json:"checks"`
+	ByStatus        map[string]int       `json:"by_status"`
+	UptimePercent   float64              `json:"uptime_percent"`
+	DowntimeSeconds float64              `json:"downtime_seconds"` // Time spent in periods other than UP
+	Periods         []AvailabilityPeriod `json:"periods"`
+}
+
+// AvailabilityPeriod is a run of checks with the same status
+// It ends at the first check with another status, or at the last check for the latest period
+type AvailabilityPeriod struct {
+	Status string    `json:"status"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+// CalculateAvailability summarizes health checks ordered by instance and time, as returned by storage
+// Gaps without checks, e.g., while pondy was stopped, count as neither up nor down
+// loc is the timezone for timestamps (if nil, uses UTC)
+func CalculateAvailability(targetName string, checks []models.HealthCheck, from, to time.Time, loc *time.Location) *AvailabilityResult {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	result := &AvailabilityResult{
+		TargetName: targetName,
+		From:       from.In(loc),
+		To:         to.In(loc),
+		Instances:  []InstanceAvailability{},
+	}
+
+	up := 0
+	var inst *InstanceAvailability
+	for _, check := range checks {
+		ts := check.Timestamp.In(loc)
+		if inst == nil || inst.InstanceName != check.InstanceName {
+			result.Instances = append(result.Instances, InstanceAvailability{
+				InstanceName: check.InstanceName,
+				ByStatus:     make(map[string]int),
+				Periods:      []AvailabilityPeriod{},
+			})
+			inst = &result.Instances[len(result.Instances)-1]
+		}
+
+		inst.Checks++
+		inst.ByStatus[check.Status]++
+		inst.Status = check.Status
+		inst.LastCheck = ts
+
+		if n := len(inst.Periods); n > 0 && inst.Periods[n-1].Status == check.Status {
+			inst.Periods[n-1].To = ts
+		} else {
+			if n > 0 {
+				inst.Periods[n-1].To = ts
+			}
+			inst.Periods = append(inst.Periods, AvailabilityPeriod{Status: check.Status, From: ts, To: ts})
+		}
+	}
+
+	for i := range result.Instances {
+		inst := &result.Instances[i]
+		instUp := inst.ByStatus[models.HealthUp]
+		inst.UptimePercent = percent(instUp, inst.Checks)
+		for _, p := range inst.Periods {
+			if p.Status != models.HealthUp {
+				inst.DowntimeSeconds += p.To.Sub(p.From).Seconds()
+			}
+		}
+
+		up += instUp
+		result.Checks += inst.Checks
+	}
+	result.UptimePercent = percent(up, result.Checks)
+
+	return result
+}
+
+// percent returns n of total as a percentage rounded to two decimals, 0 for no total
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(total)*10000) / 100
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestCalculateAvailability(t *testing.T) {
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return from.Add(time.Duration(min) * time.Minute) }
+	checks := []models.HealthCheck{
+		{InstanceName: "pod-1", Status: models.HealthUp, Timestamp: at(0)},
+		{InstanceName: "pod-1", Status: models.HealthUp, Timestamp: at(1)},
+		{InstanceName: "pod-1", Status: models.HealthDown, Timestamp: at(2)},
+		{InstanceName: "pod-1", Status: models.HealthDown, Timestamp: at(3)},
+		{InstanceName: "pod-1", Status: models.HealthUp, Timestamp: at(5)},
+		{InstanceName: "pod-2", Status: models.HealthUp, Timestamp: at(0)},
+		{InstanceName: "pod-2", Status: models.HealthUp, Timestamp: at(5)},
+	}
+
+	result := CalculateAvailability("orders", checks, from, at(10), nil)
+
+	if result.Checks != 7 || len(result.Instances) != 2 {
+		t.Fatalf("checks = %d, instances = %d; want 7, 2", result.Checks, len(result.Instances))
+	}
+	if result.UptimePercent != 71.43 {
+		t.Errorf("UptimePercent = %v, want 71.43", result.UptimePercent)
+	}
+
+	pod1 := result.Instances[0]
+	if pod1.Status != models.HealthUp || !pod1.LastCheck.Equal(at(5)) {
+		t.Errorf("pod-1 latest = %s at %v", pod1.Status, pod1.LastCheck)
+	}
+	if pod1.UptimePercent != 60 || pod1.ByStatus[models.HealthDown] != 2 {
+		t.Errorf("pod-1 uptime = %v, by status = %v", pod1.UptimePercent, pod1.ByStatus)
+	}
+	// DOWN from the first failed check until the next UP check
+	if len(pod1.Periods) != 3 || !pod1.Periods[1].From.Equal(at(2)) || !pod1.Periods[1].To.Equal(at(5)) {
+		t.Fatalf("pod-1 periods = %+v", pod1.Periods)
+	}
+	if pod1.DowntimeSeconds != 180 {
+		t.Errorf("pod-1 DowntimeSeconds = %v, want 180", pod1.DowntimeSeconds)
+	}
+
+	pod2 := result.Instances[1]
+	if pod2.UptimePercent != 100 || pod2.DowntimeSeconds != 0 || len(pod2.Periods) != 1 {
+		t.Errorf("pod-2 = %+v", pod2)
+	}
+}
+
+func TestCalculateAvailability_NoChecks(t *testing.T) {
+	result := CalculateAvailability("orders", nil, time.Now().Add(-time.Hour), time.Now(), nil)
+	if result.Checks != 0 || result.UptimePercent != 0 || result.Instances == nil {
+		t.Errorf("result = %+v, want empty instances and 0%% uptime", result)
+	}
+}
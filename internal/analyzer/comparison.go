@@ -96,7 +96,7 @@ func calculatePeriodStats(metrics []models.PoolMetrics, loc *time.Location) Peri
 			maxPending = m.Pending
 		}
 
-		stats.TimeoutSum += m.Timeout
+		stats.TimeoutSum += m.TimeoutDelta
 	}
 
 	n := float64(len(metrics))
@@ -0,0 +1,377 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Forecast methods
+const (
+	ForecastMethodLinear      = "linear"
+	ForecastMethodHoltWinters = "holt_winters"
+)
+
+const (
+	forecastMinSamples = 10
+	seasonLength       = 24 // Hourly buckets per daily season
+	stableSlopePerDay  = 0.5
+
+	// Holt-Winters smoothing factors for level, trend and season
+	hwAlpha = 0.3
+	hwBeta  = 0.05
+	hwGamma = 0.2
+)
+
+// ForecastOptions configures capacity forecasting
+type ForecastOptions struct {
+	PoolThreshold float64       // Pool usage % treated as saturation (default: 90)
+	HeapThreshold float64       // Heap usage % treated as exhaustion (default: 90)
+	Horizon       time.Duration // How far ahead to predict (default: 30 days)
+}
+
+// GetThresholds returns the pool and heap thresholds with defaults
+func (o *ForecastOptions) GetThresholds() (pool, heap float64) {
+	pool, heap = 90, 90
+	if o != nil && o.PoolThreshold > 0 {
+		pool = o.PoolThreshold
+	}
+	if o != nil && o.HeapThreshold > 0 {
+		heap = o.HeapThreshold
+	}
+	return pool, heap
+}
+
+// GetHorizon returns the forecast horizon with default
+func (o *ForecastOptions) GetHorizon() time.Duration {
+	if o == nil || o.Horizon <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return o.Horizon
+}
+
+// ForecastResult contains capacity forecasts for a target
+type ForecastResult struct {
+	TargetName   string          `json:"target_name"`
+	AnalyzedFrom time.Time       `json:"analyzed_from"`
+	AnalyzedTo   time.Time       `json:"analyzed_to"`
+	DataPoints   int             `json:"data_points"`
+	Horizon      string          `json:"horizon"`
+	Pool         *MetricForecast `json:"pool,omitempty"`
+	Heap         *MetricForecast `json:"heap,omitempty"`
+	Summary      string          `json:"summary"`
+}
+
+// MetricForecast is the predicted trend of one usage metric
+type MetricForecast struct {
+	Method      string          `json:"method"`                // linear, holt_winters
+	Current     float64         `json:"current"`               // Fitted usage % at the last sample
+	Threshold   float64         `json:"threshold"`             // Usage % the forecast is checked against
+	SlopePerDay float64         `json:"slope_per_day"`         // Trend in percentage points per day
+	Trend       string          `json:"trend"`                 // rising, falling, stable
+	ExpectedAt  *time.Time      `json:"expected_at,omitempty"` // When usage is predicted to reach the threshold
+	DaysUntil   *float64        `json:"days_until,omitempty"`
+	Message     string          `json:"message"`
+	Points      []ForecastPoint `json:"points"`
+}
+
+// ForecastPoint is a predicted value
+type ForecastPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// usageSample is a usage percentage at a point in time
+type usageSample struct {
+	t time.Time
+	v float64
+}
+
+// Forecast fits a trend over pool and heap usage and predicts when they reach the thresholds
+// Holt-Winters with daily seasonality is used once two days of data are available, linear regression otherwise.
+// loc is the timezone for timestamps (if nil, uses UTC)
+func Forecast(targetName string, metrics []models.PoolMetrics, loc *time.Location, opts *ForecastOptions) *ForecastResult {
+	if loc == nil {
+		loc = time.UTC
+	}
+	horizon := opts.GetHorizon()
+	poolThreshold, heapThreshold := opts.GetThresholds()
+
+	result := &ForecastResult{
+		TargetName: targetName,
+		DataPoints: len(metrics),
+		Horizon:    formatHorizon(horizon),
+	}
+	if len(metrics) == 0 {
+		result.Summary = "No data available for forecasting"
+		return result
+	}
+	result.AnalyzedFrom = metrics[0].Timestamp.In(loc)
+	result.AnalyzedTo = metrics[len(metrics)-1].Timestamp.In(loc)
+
+	var pool, heap []usageSample
+	for _, m := range metrics {
+		if m.Max > 0 {
+			pool = append(pool, usageSample{m.Timestamp, float64(m.Active) / float64(m.Max) * 100})
+		}
+		if m.HeapMax > 0 {
+			heap = append(heap, usageSample{m.Timestamp, float64(m.HeapUsed) / float64(m.HeapMax) * 100})
+		}
+	}
+
+	result.Pool = forecastUsage(pool, poolThreshold, horizon, "Pool usage", "Pool saturation", loc)
+	result.Heap = forecastUsage(heap, heapThreshold, horizon, "Heap usage", "Heap exhaustion", loc)
+	result.Summary = forecastSummary(result)
+	return result
+}
+
+// forecastUsage fits a model over samples and checks it against the threshold
+func forecastUsage(samples []usageSample, threshold float64, horizon time.Duration, subject, event string, loc *time.Location) *MetricForecast {
+	if len(samples) < forecastMinSamples || samples[len(samples)-1].t.Sub(samples[0].t) < time.Hour {
+		return nil
+	}
+
+	last := samples[len(samples)-1].t
+	fc := &MetricForecast{Threshold: threshold}
+
+	// predict returns the usage % at d after the last sample
+	var predict func(d time.Duration) float64
+	var observed float64
+
+	if hourly := hourlySeries(samples); hourly != nil {
+		hw := fitHoltWinters(hourly)
+		fc.Method = ForecastMethodHoltWinters
+		fc.Current = hw.level + hw.season[(len(hourly)-1)%seasonLength]
+		fc.SlopePerDay = hw.trend * 24
+		observed = hourly[len(hourly)-1]
+		predict = func(d time.Duration) float64 {
+			return hw.predict(len(hourly), int(math.Ceil(d.Hours())))
+		}
+	} else {
+		xs := make([]float64, len(samples))
+		ys := make([]float64, len(samples))
+		for i, s := range samples {
+			xs[i] = s.t.Sub(samples[0].t).Hours() / 24
+			ys[i] = s.v
+		}
+		slope, intercept := linearRegression(xs, ys)
+		current := intercept + slope*xs[len(xs)-1]
+		fc.Method = ForecastMethodLinear
+		fc.Current = current
+		fc.SlopePerDay = slope
+		observed = current
+		predict = func(d time.Duration) float64 {
+			return current + slope*d.Hours()/24
+		}
+	}
+	fc.Current = clampUsage(fc.Current)
+
+	switch {
+	case fc.SlopePerDay >= stableSlopePerDay:
+		fc.Trend = "rising"
+	case fc.SlopePerDay <= -stableSlopePerDay:
+		fc.Trend = "falling"
+	default:
+		fc.Trend = "stable"
+	}
+
+	// Step hourly through the horizon to find the first crossing
+	var until time.Duration = -1
+	if observed >= threshold {
+		until = 0
+	} else {
+		for d := time.Hour; d <= horizon; d += time.Hour {
+			if predict(d) >= threshold {
+				until = d
+				break
+			}
+		}
+	}
+
+	if until >= 0 {
+		at := last.Add(until).In(loc)
+		days := math.Round(until.Hours()/24*10) / 10
+		fc.ExpectedAt = &at
+		fc.DaysUntil = &days
+		if until == 0 {
+			fc.Message = fmt.Sprintf("%s is already above the %.0f%% threshold", subject, threshold)
+		} else {
+			fc.Message = fmt.Sprintf("%s expected in %s", event, humanizeDuration(until))
+		}
+	} else if fc.Trend == "rising" {
+		fc.Message = fmt.Sprintf("%s rising %.1f%%/day, %.0f%% not reached within %s", subject, fc.SlopePerDay, threshold, formatHorizon(horizon))
+	} else {
+		fc.Message = fmt.Sprintf("%s is %s, %.0f%% not reached within %s", subject, fc.Trend, threshold, formatHorizon(horizon))
+	}
+
+	// Keep the series small enough to chart: about 120 points per horizon
+	step := horizon / 120
+	if step < time.Hour {
+		step = time.Hour
+	}
+	step = step.Round(time.Hour)
+	for d := step; d <= horizon; d += step {
+		fc.Points = append(fc.Points, ForecastPoint{
+			Timestamp: last.Add(d).In(loc),
+			Value:     math.Round(clampUsage(predict(d))*10) / 10,
+		})
+	}
+
+	return fc
+}
+
+// hourlySeries averages samples into contiguous hourly buckets, interpolating gaps
+// Returns nil when there are fewer than two seasons of data or too many gaps
+func hourlySeries(samples []usageSample) []float64 {
+	start := samples[0].t.Truncate(time.Hour)
+	n := int(samples[len(samples)-1].t.Truncate(time.Hour).Sub(start)/time.Hour) + 1
+	if n < 2*seasonLength {
+		return nil
+	}
+
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	for _, s := range samples {
+		i := int(s.t.Truncate(time.Hour).Sub(start) / time.Hour)
+		if i < 0 || i >= n {
+			continue
+		}
+		sums[i] += s.v
+		counts[i]++
+	}
+
+	series := make([]float64, n)
+	known := 0
+	for i := range series {
+		if counts[i] > 0 {
+			series[i] = sums[i] / float64(counts[i])
+			known++
+		} else {
+			series[i] = math.NaN()
+		}
+	}
+	if known < n/2 {
+		return nil
+	}
+
+	// Linear interpolation between known neighbours (ends are always known)
+	for i := 0; i < n; i++ {
+		if !math.IsNaN(series[i]) {
+			continue
+		}
+		j := i
+		for math.IsNaN(series[j]) {
+			j++
+		}
+		prev, next := series[i-1], series[j]
+		for k := i; k < j; k++ {
+			series[k] = prev + (next-prev)*float64(k-i+1)/float64(j-i+1)
+		}
+		i = j
+	}
+	return series
+}
+
+// holtWinters holds the fitted state of an additive Holt-Winters model
+type holtWinters struct {
+	level  float64
+	trend  float64
+	season []float64
+}
+
+// fitHoltWinters fits an additive model with a daily season over an hourly series
+func fitHoltWinters(y []float64) *holtWinters {
+	first := calculateMean(y[:seasonLength])
+	second := calculateMean(y[seasonLength : 2*seasonLength])
+
+	hw := &holtWinters{
+		level:  first,
+		trend:  (second - first) / seasonLength,
+		season: make([]float64, seasonLength),
+	}
+	for i := 0; i < seasonLength; i++ {
+		hw.season[i] = y[i] - first
+	}
+
+	for t := seasonLength; t < len(y); t++ {
+		s := hw.season[t%seasonLength]
+		prevLevel := hw.level
+		hw.level = hwAlpha*(y[t]-s) + (1-hwAlpha)*(hw.level+hw.trend)
+		hw.trend = hwBeta*(hw.level-prevLevel) + (1-hwBeta)*hw.trend
+		hw.season[t%seasonLength] = hwGamma*(y[t]-hw.level) + (1-hwGamma)*s
+	}
+	return hw
+}
+
+// predict returns the value h hours after a series of length n
+func (hw *holtWinters) predict(n, h int) float64 {
+	return hw.level + float64(h)*hw.trend + hw.season[(n-1+h)%seasonLength]
+}
+
+// linearRegression returns the least squares slope and intercept
+func linearRegression(xs, ys []float64) (slope, intercept float64) {
+	meanX := calculateMean(xs)
+	meanY := calculateMean(ys)
+
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - meanX) * (ys[i] - meanY)
+		den += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if den == 0 {
+		return 0, meanY
+	}
+	slope = num / den
+	return slope, meanY - slope*meanX
+}
+
+func forecastSummary(result *ForecastResult) string {
+	var soonest *MetricForecast
+	for _, fc := range []*MetricForecast{result.Pool, result.Heap} {
+		if fc == nil || fc.ExpectedAt == nil {
+			continue
+		}
+		if soonest == nil || fc.ExpectedAt.Before(*soonest.ExpectedAt) {
+			soonest = fc
+		}
+	}
+
+	switch {
+	case soonest != nil:
+		return soonest.Message
+	case result.Pool == nil && result.Heap == nil:
+		return "Not enough data to forecast (need at least 1 hour of metrics)"
+	default:
+		return fmt.Sprintf("No capacity issues expected within %s", result.Horizon)
+	}
+}
+
+func clampUsage(v float64) float64 {
+	return math.Max(0, math.Min(100, v))
+}
+
+// humanizeDuration formats a duration as "~N hours" or "~N days"
+func humanizeDuration(d time.Duration) string {
+	if d < 24*time.Hour {
+		hours := int(math.Max(1, math.Round(d.Hours())))
+		if hours == 1 {
+			return "~1 hour"
+		}
+		return fmt.Sprintf("~%d hours", hours)
+	}
+	days := int(math.Round(d.Hours() / 24))
+	if days == 1 {
+		return "~1 day"
+	}
+	return fmt.Sprintf("~%d days", days)
+}
+
+// formatHorizon formats whole days as "30d"
+func formatHorizon(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return d.String()
+}
@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// forecastMetrics builds hourly samples with pool usage from usage(hour)
+func forecastMetrics(hours int, usage func(h int) float64) []models.PoolMetrics {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	metrics := make([]models.PoolMetrics, hours)
+	for h := 0; h < hours; h++ {
+		metrics[h] = models.PoolMetrics{
+			TargetName: "test",
+			Active:     int(math.Round(usage(h))),
+			Max:        100,
+			Timestamp:  start.Add(time.Duration(h) * time.Hour),
+		}
+	}
+	return metrics
+}
+
+func TestForecast_EmptyMetrics(t *testing.T) {
+	result := Forecast("test", nil, nil, nil)
+	if result.Pool != nil || result.Heap != nil {
+		t.Error("Forecast(nil) should not produce forecasts")
+	}
+	if result.Horizon != "30d" {
+		t.Errorf("Horizon = %s, want 30d", result.Horizon)
+	}
+}
+
+func TestForecast_LinearTrend(t *testing.T) {
+	// 1 percentage point per hour from 20%, 24 hours of data
+	metrics := forecastMetrics(24, func(h int) float64 { return 20 + float64(h) })
+
+	result := Forecast("test", metrics, nil, &ForecastOptions{PoolThreshold: 90})
+	if result.Pool == nil {
+		t.Fatal("Pool forecast is nil")
+	}
+	if result.Heap != nil {
+		t.Error("Heap forecast should be nil without heap metrics")
+	}
+
+	fc := result.Pool
+	if fc.Method != ForecastMethodLinear {
+		t.Errorf("Method = %s, want %s", fc.Method, ForecastMethodLinear)
+	}
+	if math.Abs(fc.SlopePerDay-24) > 0.5 {
+		t.Errorf("SlopePerDay = %.2f, want ~24", fc.SlopePerDay)
+	}
+	if fc.Trend != "rising" {
+		t.Errorf("Trend = %s, want rising", fc.Trend)
+	}
+	// 43% at the last sample, 90% is reached ~47 hours later
+	if fc.DaysUntil == nil || math.Abs(*fc.DaysUntil-2) > 0.1 {
+		t.Fatalf("DaysUntil = %v, want ~2", fc.DaysUntil)
+	}
+	if fc.Message != "Pool saturation expected in ~2 days" {
+		t.Errorf("Message = %q", fc.Message)
+	}
+	if result.Summary != fc.Message {
+		t.Errorf("Summary = %q, want %q", result.Summary, fc.Message)
+	}
+}
+
+func TestForecast_Stable(t *testing.T) {
+	metrics := forecastMetrics(24, func(h int) float64 { return 40 })
+
+	result := Forecast("test", metrics, nil, nil)
+	fc := result.Pool
+	if fc == nil {
+		t.Fatal("Pool forecast is nil")
+	}
+	if fc.Trend != "stable" || fc.ExpectedAt != nil {
+		t.Errorf("Trend = %s, ExpectedAt = %v, want stable and nil", fc.Trend, fc.ExpectedAt)
+	}
+	if result.Summary != "No capacity issues expected within 30d" {
+		t.Errorf("Summary = %q", result.Summary)
+	}
+}
+
+func TestForecast_AlreadyAboveThreshold(t *testing.T) {
+	metrics := forecastMetrics(24, func(h int) float64 { return 95 })
+
+	result := Forecast("test", metrics, nil, nil)
+	if result.Pool == nil || result.Pool.DaysUntil == nil || *result.Pool.DaysUntil != 0 {
+		t.Fatalf("Pool forecast = %+v, want DaysUntil 0", result.Pool)
+	}
+}
+
+func TestForecast_HoltWintersSeasonality(t *testing.T) {
+	// Flat 50% with a daily peak of +30% at noon, a week of data
+	metrics := forecastMetrics(7*24, func(h int) float64 {
+		return 50 + 30*math.Max(0, math.Cos(float64(h%24-12)/24*2*math.Pi))
+	})
+
+	result := Forecast("test", metrics, nil, &ForecastOptions{PoolThreshold: 75, Horizon: 7 * 24 * time.Hour})
+	fc := result.Pool
+	if fc == nil {
+		t.Fatal("Pool forecast is nil")
+	}
+	if fc.Method != ForecastMethodHoltWinters {
+		t.Errorf("Method = %s, want %s", fc.Method, ForecastMethodHoltWinters)
+	}
+	if fc.Trend != "stable" {
+		t.Errorf("Trend = %s, want stable", fc.Trend)
+	}
+	// The last sample is at 23:00, so the next daily peak crosses 75% around noon
+	if fc.ExpectedAt == nil {
+		t.Fatal("ExpectedAt is nil, want next daily peak")
+	}
+	if hour := fc.ExpectedAt.Hour(); hour < 9 || hour > 12 {
+		t.Errorf("ExpectedAt hour = %d, want late morning", hour)
+	}
+	if len(fc.Points) == 0 {
+		t.Error("Points should not be empty")
+	}
+}
+
+func TestHourlySeries_InterpolatesGaps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var samples []usageSample
+	for h := 0; h < 48; h++ {
+		if h == 10 || h == 11 {
+			continue
+		}
+		samples = append(samples, usageSample{start.Add(time.Duration(h) * time.Hour), float64(h)})
+	}
+
+	series := hourlySeries(samples)
+	if len(series) != 48 {
+		t.Fatalf("len(series) = %d, want 48", len(series))
+	}
+	if series[10] != 10 || series[11] != 11 {
+		t.Errorf("interpolated = %.1f, %.1f, want 10, 11", series[10], series[11])
+	}
+
+	if hourlySeries(samples[:24]) != nil {
+		t.Error("hourlySeries() should need two days of data")
+	}
+}
@@ -0,0 +1,213 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// HealthResult is a composite 0-100 health score for a target
+// Like the leak detector's HealthScore, it starts at 100 and loses points per problem
+type HealthResult struct {
+	TargetName string         `json:"target_name"`
+	AnalyzedAt time.Time      `json:"analyzed_at"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	DataPoints int            `json:"data_points"`
+	Score      int            `json:"score"`  // 0-100, -1 when there isn't enough data
+	Status     string         `json:"status"` // healthy, warning, degraded, critical, unknown
+	Factors    []HealthFactor `json:"factors"`
+	Summary    string         `json:"summary"`
+}
+
+// HealthFactor is one input of the health score
+type HealthFactor struct {
+	Name       string  `json:"name"`
+	Label      string  `json:"label"`
+	Value      float64 `json:"value"`
+	Unit       string  `json:"unit,omitempty"`
+	Score      int     `json:"score"`       // 0-100 for this factor alone
+	Penalty    int     `json:"penalty"`     // points deducted from the total
+	MaxPenalty int     `json:"max_penalty"` // weight of the factor
+}
+
+// healthFactor scores a value linearly between good (no penalty) and bad (full penalty)
+type healthFactor struct {
+	name       string
+	label      string
+	unit       string
+	maxPenalty int
+	good, bad  float64
+}
+
+// healthFactors are listed in this order, weights add up to 100
+var healthFactors = []healthFactor{
+	{"pool_usage", "average pool usage", "%", 20, 70, 95},
+	{"pending", "time with pending threads", "%", 15, 0, 30},
+	{"timeouts", "connection timeouts", "/h", 15, 0, 10},
+	{"heap_usage", "average heap usage", "%", 15, 70, 95},
+	{"gc", "time spent in GC", "%", 10, 2, 10},
+	{"cpu_usage", "average CPU usage", "%", 10, 70, 95},
+	{"alerts", "weighted recent alerts", "", 15, 0, 5},
+}
+
+// score returns the penalty for a value
+func (f healthFactor) score(value float64) HealthFactor {
+	ratio := 0.0
+	if f.bad > f.good {
+		ratio = (value - f.good) / (f.bad - f.good)
+	}
+	ratio = math.Max(0, math.Min(1, ratio))
+	penalty := int(math.Round(ratio * float64(f.maxPenalty)))
+
+	return HealthFactor{
+		Name:       f.name,
+		Label:      f.label,
+		Value:      math.Round(value*100) / 100,
+		Unit:       f.unit,
+		Score:      int(math.Round((1 - ratio) * 100)),
+		Penalty:    penalty,
+		MaxPenalty: f.maxPenalty,
+	}
+}
+
+// CalculateHealth combines pool, JVM and alert signals into a single score
+// Critical alerts count twice. Factors without data, e.g., heap for a pool-only target, are skipped
+// loc is the timezone for timestamps (if nil, uses UTC)
+func CalculateHealth(targetName string, metrics []models.PoolMetrics, alerts []models.Alert, loc *time.Location) *HealthResult {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	result := &HealthResult{
+		TargetName: targetName,
+		AnalyzedAt: time.Now().In(loc),
+		Factors:    []HealthFactor{},
+	}
+
+	var totalUsage, totalHeap, totalCpu, gcTime, timeouts float64
+	var n, usageCount, heapCount, pendingCount int
+	prev := make(map[string]*models.PoolMetrics)
+
+	for i := range metrics {
+		m := &metrics[i]
+		if m.Status == models.StatusError {
+			continue
+		}
+		if n == 0 {
+			result.From = m.Timestamp.In(loc)
+		}
+		result.To = m.Timestamp.In(loc)
+		n++
+
+		if m.Max > 0 {
+			totalUsage += float64(m.Active) / float64(m.Max) * 100
+			usageCount++
+		}
+		if m.Pending > 0 {
+			pendingCount++
+		}
+		if m.HeapMax > 0 {
+			totalHeap += float64(m.HeapUsed) / float64(m.HeapMax) * 100
+			heapCount++
+		}
+		totalCpu += m.CpuUsage * 100
+
+		// Counters are cumulative per instance
+		if p, ok := prev[m.InstanceName]; ok {
+			gcTime += counterIncrease(p.GcTime, m.GcTime)
+			timeouts += counterIncrease(float64(p.Timeout), float64(m.Timeout))
+		}
+		prev[m.InstanceName] = m
+	}
+	result.DataPoints = n
+
+	// Same minimum as the leak detector: 1 minute of data at 10s intervals
+	if n < 6 {
+		result.Score = -1
+		result.Status = "unknown"
+		result.Summary = "Not enough data to calculate a health score"
+		return result
+	}
+
+	values := map[string]float64{
+		"pending":   float64(pendingCount) / float64(n) * 100,
+		"cpu_usage": totalCpu / float64(n),
+	}
+	if usageCount > 0 {
+		values["pool_usage"] = totalUsage / float64(usageCount)
+	}
+	if heapCount > 0 {
+		values["heap_usage"] = totalHeap / float64(heapCount)
+	}
+	if seconds := result.To.Sub(result.From).Seconds(); seconds > 0 {
+		// Each instance spends its own wall time, so GC time is per instance
+		values["timeouts"] = timeouts / (seconds / 3600)
+		values["gc"] = gcTime / (seconds * float64(len(prev))) * 100
+	}
+
+	var weightedAlerts float64
+	for _, a := range alerts {
+		if a.Severity == models.SeverityCritical {
+			weightedAlerts += 2
+		} else {
+			weightedAlerts++
+		}
+	}
+	values["alerts"] = weightedAlerts
+
+	result.Score = 100
+	for _, f := range healthFactors {
+		value, ok := values[f.name]
+		if !ok {
+			continue
+		}
+		factor := f.score(value)
+		result.Score -= factor.Penalty
+		result.Factors = append(result.Factors, factor)
+	}
+	if result.Score < 0 {
+		result.Score = 0
+	}
+
+	result.Status = healthStatus(result.Score)
+	result.Summary = healthSummary(result)
+	return result
+}
+
+// healthStatus uses the leak detector's risk bands
+func healthStatus(score int) string {
+	switch {
+	case score >= 80:
+		return "healthy"
+	case score >= 60:
+		return "warning"
+	case score >= 40:
+		return "degraded"
+	default:
+		return "critical"
+	}
+}
+
+// healthSummary names the factors costing the most points
+func healthSummary(result *HealthResult) string {
+	worst := make([]HealthFactor, 0, len(result.Factors))
+	for _, f := range result.Factors {
+		if f.Penalty > 0 {
+			worst = append(worst, f)
+		}
+	}
+	if len(worst) == 0 {
+		return fmt.Sprintf("Health score %d (%s)", result.Score, result.Status)
+	}
+	sort.SliceStable(worst, func(i, j int) bool { return worst[i].Penalty > worst[j].Penalty })
+
+	summary := fmt.Sprintf("Health score %d (%s), mostly from %s", result.Score, result.Status, worst[0].Label)
+	if len(worst) > 1 {
+		summary += fmt.Sprintf(" and %s", worst[1].Label)
+	}
+	return summary
+}
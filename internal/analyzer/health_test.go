@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// healthSamples builds minutely samples for an hour
+func healthSamples(active, pending int, heapUsed int64, cpu float64) []models.PoolMetrics {
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	metrics := make([]models.PoolMetrics, 61)
+	for i := range metrics {
+		metrics[i] = models.PoolMetrics{
+			TargetName: "test",
+			Active:     active,
+			Pending:    pending,
+			Max:        10,
+			HeapUsed:   heapUsed,
+			HeapMax:    100,
+			CpuUsage:   cpu,
+			Timestamp:  from.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return metrics
+}
+
+func findFactor(factors []HealthFactor, name string) *HealthFactor {
+	for i := range factors {
+		if factors[i].Name == name {
+			return &factors[i]
+		}
+	}
+	return nil
+}
+
+func TestCalculateHealth_Healthy(t *testing.T) {
+	result := CalculateHealth("test", healthSamples(3, 0, 40, 0.2), nil, nil)
+
+	if result.Score != 100 {
+		t.Errorf("Score = %d, want 100", result.Score)
+	}
+	if result.Status != "healthy" {
+		t.Errorf("Status = %s, want healthy", result.Status)
+	}
+	if len(result.Factors) != len(healthFactors) {
+		t.Errorf("len(Factors) = %d, want %d", len(result.Factors), len(healthFactors))
+	}
+}
+
+func TestCalculateHealth_Penalties(t *testing.T) {
+	metrics := healthSamples(10, 2, 95, 0.5)
+	// 60 timeouts in an hour, 6 minutes of GC
+	for i := range metrics {
+		metrics[i].Timeout = int64(i)
+		metrics[i].GcTime = float64(i) * 6
+	}
+	alerts := []models.Alert{
+		{Severity: models.SeverityCritical},
+		{Severity: models.SeverityWarning},
+	}
+
+	result := CalculateHealth("test", metrics, alerts, nil)
+
+	// Full penalties for pool, pending, timeouts, heap and GC, 3/5 of the alert penalty
+	if result.Score != 100-20-15-15-15-10-9 {
+		t.Errorf("Score = %d, want %d", result.Score, 100-20-15-15-15-10-9)
+	}
+	if result.Status != "critical" {
+		t.Errorf("Status = %s, want critical", result.Status)
+	}
+
+	gc := findFactor(result.Factors, "gc")
+	if gc == nil || gc.Value != 10 || gc.Score != 0 {
+		t.Errorf("gc factor = %+v, want 10%% with score 0", gc)
+	}
+	cpu := findFactor(result.Factors, "cpu_usage")
+	if cpu == nil || cpu.Penalty != 0 || cpu.Score != 100 {
+		t.Errorf("cpu factor = %+v, want no penalty", cpu)
+	}
+	if !strings.Contains(result.Summary, "average pool usage") {
+		t.Errorf("Summary = %s, want the worst factor", result.Summary)
+	}
+}
+
+func TestCalculateHealth_SkipsMissingFactors(t *testing.T) {
+	metrics := healthSamples(3, 0, 0, 0.2)
+	for i := range metrics {
+		metrics[i].HeapMax = 0
+	}
+
+	result := CalculateHealth("test", metrics, nil, nil)
+	if findFactor(result.Factors, "heap_usage") != nil {
+		t.Error("heap_usage should be skipped without heap metrics")
+	}
+	if result.Score != 100 {
+		t.Errorf("Score = %d, want 100", result.Score)
+	}
+}
+
+func TestCalculateHealth_NotEnoughData(t *testing.T) {
+	result := CalculateHealth("test", healthSamples(3, 0, 40, 0.2)[:5], nil, nil)
+	if result.Score != -1 || result.Status != "unknown" {
+		t.Errorf("Score = %d, Status = %s, want -1 and unknown", result.Score, result.Status)
+	}
+}
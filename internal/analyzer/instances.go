@@ -0,0 +1,224 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// InstanceComparisonResult compares the instances of a target side by side
+type InstanceComparisonResult struct {
+	TargetName   string            `json:"target_name"`
+	AnalyzedFrom time.Time         `json:"analyzed_from"`
+	AnalyzedTo   time.Time         `json:"analyzed_to"`
+	DataPoints   int               `json:"data_points"`
+	Instances    []InstanceStats   `json:"instances"` // Ranked by outlier score, worst first
+	Outliers     []InstanceOutlier `json:"outliers"`
+	Summary      string            `json:"summary"`
+}
+
+// InstanceStats contains statistics for one instance
+// GC and timeout values are counter increases over the analyzed range
+type InstanceStats struct {
+	InstanceName string  `json:"instance_name"`
+	DataPoints   int     `json:"data_points"`
+	AvgUsage     float64 `json:"avg_usage"`
+	PeakUsage    float64 `json:"peak_usage"`
+	AvgPending   float64 `json:"avg_pending"`
+	MaxPending   int     `json:"max_pending"`
+	GcCount      int64   `json:"gc_count"`
+	GcTime       float64 `json:"gc_time"` // seconds
+	Timeouts     int64   `json:"timeouts"`
+	OutlierScore float64 `json:"outlier_score"` // Largest relative excess over the other instances' median
+	Outlier      bool    `json:"outlier"`
+	Rank         int     `json:"rank"`
+}
+
+// InstanceOutlier is an instance that stands out from the others on one metric
+type InstanceOutlier struct {
+	InstanceName string  `json:"instance_name"`
+	Metric       string  `json:"metric"`
+	Value        float64 `json:"value"`
+	Median       float64 `json:"median"` // Median of the other instances
+	Score        float64 `json:"score"`
+	Message      string  `json:"message"`
+}
+
+// outlierScoreThreshold flags values at least twice the others' median (plus the metric floor)
+const outlierScoreThreshold = 1.0
+
+// instanceMetric describes a compared metric
+// floor keeps small absolute differences, e.g., 2 vs 1 pending, from counting as outliers
+type instanceMetric struct {
+	name   string
+	label  string
+	format string
+	floor  float64
+	value  func(s *InstanceStats) float64
+}
+
+var instanceMetrics = []instanceMetric{
+	{"avg_usage", "avg usage", "%.1f%%", 10, func(s *InstanceStats) float64 { return s.AvgUsage }},
+	{"peak_usage", "peak usage", "%.1f%%", 10, func(s *InstanceStats) float64 { return s.PeakUsage }},
+	{"avg_pending", "avg pending", "%.1f", 1, func(s *InstanceStats) float64 { return s.AvgPending }},
+	{"gc_time", "GC time", "%.1fs", 1, func(s *InstanceStats) float64 { return s.GcTime }},
+	{"timeouts", "timeouts", "%.0f", 1, func(s *InstanceStats) float64 { return float64(s.Timeouts) }},
+}
+
+// CompareInstances computes per-instance statistics and ranks instances that stand out from the rest
+// loc is the timezone for timestamps (if nil, uses UTC)
+func CompareInstances(targetName string, metrics []models.PoolMetrics, loc *time.Location) *InstanceComparisonResult {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	result := &InstanceComparisonResult{
+		TargetName: targetName,
+		DataPoints: len(metrics),
+		Instances:  []InstanceStats{},
+		Outliers:   []InstanceOutlier{},
+	}
+	if len(metrics) == 0 {
+		result.Summary = "No data available"
+		return result
+	}
+	result.AnalyzedFrom = metrics[0].Timestamp.In(loc)
+	result.AnalyzedTo = metrics[len(metrics)-1].Timestamp.In(loc)
+
+	// Group samples by instance, keeping first-seen order
+	byInstance := make(map[string][]models.PoolMetrics)
+	var names []string
+	for _, m := range metrics {
+		if m.Status == models.StatusError {
+			continue
+		}
+		if _, ok := byInstance[m.InstanceName]; !ok {
+			names = append(names, m.InstanceName)
+		}
+		byInstance[m.InstanceName] = append(byInstance[m.InstanceName], m)
+	}
+	for _, name := range names {
+		result.Instances = append(result.Instances, instanceStats(name, byInstance[name]))
+	}
+
+	if len(result.Instances) < 2 {
+		result.Summary = "Only one instance reported metrics in this range"
+		if len(result.Instances) == 1 {
+			result.Instances[0].Rank = 1
+		}
+		return result
+	}
+
+	// Score each instance against the median of the others
+	for i := range result.Instances {
+		inst := &result.Instances[i]
+		for _, metric := range instanceMetrics {
+			others := make([]float64, 0, len(result.Instances)-1)
+			for j := range result.Instances {
+				if j != i {
+					others = append(others, metric.value(&result.Instances[j]))
+				}
+			}
+			median := calculateMedian(others)
+			value := metric.value(inst)
+			score := (value - median) / maxFloat(median, metric.floor)
+			if score > inst.OutlierScore {
+				inst.OutlierScore = score
+			}
+			if score >= outlierScoreThreshold {
+				inst.Outlier = true
+				result.Outliers = append(result.Outliers, InstanceOutlier{
+					InstanceName: inst.InstanceName,
+					Metric:       metric.name,
+					Value:        value,
+					Median:       median,
+					Score:        score,
+					Message: fmt.Sprintf("%s %s "+metric.format+" vs "+metric.format+" median of other instances",
+						inst.InstanceName, metric.label, value, median),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(result.Instances, func(i, j int) bool {
+		return result.Instances[i].OutlierScore > result.Instances[j].OutlierScore
+	})
+	for i := range result.Instances {
+		result.Instances[i].Rank = i + 1
+	}
+	sort.SliceStable(result.Outliers, func(i, j int) bool {
+		return result.Outliers[i].Score > result.Outliers[j].Score
+	})
+
+	if len(result.Outliers) == 0 {
+		result.Summary = fmt.Sprintf("All %d instances behave alike", len(result.Instances))
+	} else {
+		result.Summary = fmt.Sprintf("%s stands out: %s", result.Outliers[0].InstanceName, result.Outliers[0].Message)
+	}
+	return result
+}
+
+// instanceStats aggregates the samples of one instance in time order
+func instanceStats(name string, metrics []models.PoolMetrics) InstanceStats {
+	stats := InstanceStats{InstanceName: name, DataPoints: len(metrics)}
+
+	var usageSum, pendingSum float64
+	usageCount := 0
+	for i, m := range metrics {
+		if m.Max > 0 {
+			usage := float64(m.Active) / float64(m.Max) * 100
+			usageSum += usage
+			usageCount++
+			if usage > stats.PeakUsage {
+				stats.PeakUsage = usage
+			}
+		}
+		pendingSum += float64(m.Pending)
+		if m.Pending > stats.MaxPending {
+			stats.MaxPending = m.Pending
+		}
+
+		if i > 0 {
+			prev := metrics[i-1]
+			stats.GcCount += int64(counterIncrease(float64(prev.GcCount), float64(m.GcCount)))
+			stats.GcTime += counterIncrease(prev.GcTime, m.GcTime)
+			stats.Timeouts += int64(counterIncrease(float64(prev.Timeout), float64(m.Timeout)))
+		}
+	}
+
+	if usageCount > 0 {
+		stats.AvgUsage = usageSum / float64(usageCount)
+	}
+	stats.AvgPending = pendingSum / float64(len(metrics))
+	return stats
+}
+
+// counterIncrease returns the increase of a cumulative counter, treating a drop as a restart
+func counterIncrease(prev, cur float64) float64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+func calculateMedian(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// instanceSamples builds hourly samples for each instance with active(instance, hour) connections of 100
+func instanceSamples(instances []string, hours int, active func(instance string, h int) int) []models.PoolMetrics {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var metrics []models.PoolMetrics
+	for h := 0; h < hours; h++ {
+		for _, inst := range instances {
+			metrics = append(metrics, models.PoolMetrics{
+				TargetName:   "test",
+				InstanceName: inst,
+				Active:       active(inst, h),
+				Max:          100,
+				Timestamp:    start.Add(time.Duration(h) * time.Hour),
+			})
+		}
+	}
+	return metrics
+}
+
+func TestCompareInstances_Empty(t *testing.T) {
+	result := CompareInstances("test", nil, nil)
+	if len(result.Instances) != 0 || len(result.Outliers) != 0 {
+		t.Error("CompareInstances(nil) should not produce instances")
+	}
+	if result.Summary != "No data available" {
+		t.Errorf("Summary = %q", result.Summary)
+	}
+}
+
+func TestCompareInstances_SingleInstance(t *testing.T) {
+	metrics := instanceSamples([]string{"pod-1"}, 24, func(string, int) int { return 90 })
+
+	result := CompareInstances("test", metrics, nil)
+	if len(result.Instances) != 1 || result.Instances[0].Rank != 1 {
+		t.Fatalf("Instances = %+v, want one ranked instance", result.Instances)
+	}
+	if len(result.Outliers) != 0 {
+		t.Error("a single instance can't be an outlier")
+	}
+}
+
+func TestCompareInstances_RanksOutlier(t *testing.T) {
+	instances := []string{"pod-1", "pod-2", "pod-3"}
+	metrics := instanceSamples(instances, 24, func(inst string, h int) int {
+		if inst == "pod-2" {
+			return 80
+		}
+		return 30 + h%5
+	})
+	// pod-3 times out a little, pod-2 a lot and restarts halfway
+	for i := range metrics {
+		m := &metrics[i]
+		h := int(m.Timestamp.Sub(metrics[0].Timestamp) / time.Hour)
+		switch m.InstanceName {
+		case "pod-2":
+			m.Timeout = int64(h % 12 * 2)
+			m.Pending = 4
+		case "pod-3":
+			m.Timeout = int64(h / 12)
+		}
+	}
+
+	result := CompareInstances("test", metrics, nil)
+	if len(result.Instances) != 3 {
+		t.Fatalf("len(Instances) = %d, want 3", len(result.Instances))
+	}
+
+	worst := result.Instances[0]
+	if worst.InstanceName != "pod-2" || worst.Rank != 1 || !worst.Outlier {
+		t.Fatalf("worst = %+v, want outlier pod-2 ranked first", worst)
+	}
+	if worst.AvgUsage != 80 || worst.PeakUsage != 80 {
+		t.Errorf("pod-2 usage avg/peak = %.1f/%.1f, want 80/80", worst.AvgUsage, worst.PeakUsage)
+	}
+	// 11 increments of 2 before and after the restart at hour 12
+	if worst.Timeouts != 44 {
+		t.Errorf("pod-2 Timeouts = %d, want 44", worst.Timeouts)
+	}
+	for _, inst := range result.Instances[1:] {
+		if inst.Outlier {
+			t.Errorf("%s flagged as outlier", inst.InstanceName)
+		}
+	}
+
+	metricsFlagged := make(map[string]bool)
+	for _, o := range result.Outliers {
+		if o.InstanceName != "pod-2" {
+			t.Errorf("unexpected outlier %+v", o)
+		}
+		metricsFlagged[o.Metric] = true
+	}
+	for _, m := range []string{"avg_usage", "avg_pending", "timeouts"} {
+		if !metricsFlagged[m] {
+			t.Errorf("pod-2 %s not flagged", m)
+		}
+	}
+}
+
+func TestCompareInstances_AlikeInstances(t *testing.T) {
+	metrics := instanceSamples([]string{"pod-1", "pod-2"}, 24, func(inst string, h int) int {
+		if inst == "pod-1" {
+			return 40
+		}
+		return 45
+	})
+
+	result := CompareInstances("test", metrics, nil)
+	if len(result.Outliers) != 0 {
+		t.Errorf("Outliers = %+v, want none", result.Outliers)
+	}
+	if result.Summary != "All 2 instances behave alike" {
+		t.Errorf("Summary = %q", result.Summary)
+	}
+}
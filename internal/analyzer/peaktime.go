@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"fmt"
 	"sort"
 	"time"
 
@@ -17,6 +18,11 @@ type PeakTimeResult struct {
 	QuietHours   []HourlyStats   `json:"quiet_hours"`
 	DailyPattern []HourlyStats   `json:"daily_pattern"`
 	Summary      PeakTimeSummary `json:"summary"`
+
+	// WeeklyPattern has one bucket per weekday and hour, indexed weekday*24+hour (Sunday first)
+	WeeklyPattern    []WeekdayHourStats `json:"weekly_pattern"`
+	WeekdayVsWeekend WeekdayComparison  `json:"weekday_vs_weekend"`
+	WeeklySpikes     []WeeklySpike      `json:"weekly_spikes"` // Busiest first
 }
 
 // HourlyStats contains statistics for a specific hour
@@ -28,6 +34,43 @@ type HourlyStats struct {
 	SampleSize int     `json:"sample_size"`
 }
 
+// WeekdayHourStats contains statistics for an hour of a day of the week
+type WeekdayHourStats struct {
+	Weekday    int     `json:"weekday"` // 0 = Sunday
+	Hour       int     `json:"hour"`
+	AvgUsage   float64 `json:"avg_usage"`
+	MaxUsage   float64 `json:"max_usage"`
+	SampleSize int     `json:"sample_size"`
+}
+
+// WeekdayComparison compares usage on weekdays (Monday to Friday) with weekends
+type WeekdayComparison struct {
+	WeekdayAvgUsage  float64 `json:"weekday_avg_usage"`
+	WeekendAvgUsage  float64 `json:"weekend_avg_usage"`
+	WeekdayPeakUsage float64 `json:"weekday_peak_usage"`
+	WeekendPeakUsage float64 `json:"weekend_peak_usage"`
+	WeekdaySamples   int     `json:"weekday_samples"`
+	WeekendSamples   int     `json:"weekend_samples"`
+}
+
+// WeeklySpike is a weekday hour that is much busier than the same hour on other days,
+// e.g., a weekly batch job
+type WeeklySpike struct {
+	Weekday      int     `json:"weekday"`
+	WeekdayName  string  `json:"weekday_name"`
+	Hour         int     `json:"hour"`
+	AvgUsage     float64 `json:"avg_usage"`
+	HourAvgUsage float64 `json:"hour_avg_usage"` // Same hour on the other days
+	Excess       float64 `json:"excess"`
+}
+
+// Weekly spike detection settings
+const (
+	weeklySpikeMargin  = 20.0 // Usage points above the same hour on other days
+	weeklySpikeMinDays = 2    // Other days with data needed to compare against
+	maxWeeklySpikes    = 5
+)
+
 // PeakTimeSummary provides a summary of peak time analysis
 type PeakTimeSummary struct {
 	BusiestHour      int     `json:"busiest_hour"`
@@ -35,6 +78,7 @@ type PeakTimeSummary struct {
 	QuietestHour     int     `json:"quietest_hour"`
 	QuietestUsage    float64 `json:"quietest_hour_usage"`
 	AvgDailyPeak     float64 `json:"avg_daily_peak"`
+	BusiestWeekday   string  `json:"busiest_weekday,omitempty"`
 	Recommendation   string  `json:"recommendation"`
 }
 
@@ -60,15 +104,20 @@ func AnalyzePeakTime(targetName string, metrics []models.PoolMetrics, loc *time.
 		}
 	}
 
+	weeklyData := make([]hourlyBucket, 7*24)
+
 	// Collect data by hour (using configured timezone)
 	var minTime, maxTime time.Time
 	for i, m := range metrics {
-		hour := m.Timestamp.In(loc).Hour()
+		local := m.Timestamp.In(loc)
+		hour := local.Hour()
 		usage := float64(0)
 		if m.Max > 0 {
 			usage = float64(m.Active) / float64(m.Max) * 100
 		}
 		hourlyData[hour].usages = append(hourlyData[hour].usages, usage)
+		weekly := &weeklyData[int(local.Weekday())*24+hour]
+		weekly.usages = append(weekly.usages, usage)
 
 		if i == 0 || m.Timestamp.Before(minTime) {
 			minTime = m.Timestamp
@@ -138,18 +187,34 @@ func AnalyzePeakTime(targetName string, metrics []models.PoolMetrics, loc *time.
 	}
 	summary.AvgDailyPeak = peakSum / float64(len(peakHours))
 
+	weeklyPattern := make([]WeekdayHourStats, len(weeklyData))
+	for i, bucket := range weeklyData {
+		weeklyPattern[i] = WeekdayHourStats{Weekday: i / 24, Hour: i % 24, SampleSize: len(bucket.usages)}
+		weeklyPattern[i].AvgUsage, weeklyPattern[i].MaxUsage = bucket.avgMax()
+	}
+	spikes := findWeeklySpikes(weeklyPattern)
+	summary.BusiestWeekday = busiestWeekday(weeklyPattern)
+
 	// Generate recommendation
 	summary.Recommendation = generatePeakTimeRecommendation(summary, peakHours)
+	if len(spikes) > 0 {
+		s := spikes[0]
+		summary.Recommendation += fmt.Sprintf(" %s at %02d:00 averages %.0f%% usage, %.0f points above the same hour on other days, which suggests a weekly job.",
+			s.WeekdayName, s.Hour, s.AvgUsage, s.Excess)
+	}
 
 	return &PeakTimeResult{
-		TargetName:   targetName,
-		AnalyzedFrom: minTime,
-		AnalyzedTo:   maxTime,
-		DataPoints:   len(metrics),
-		PeakHours:    peakHours,
-		QuietHours:   quietHours,
-		DailyPattern: dailyPattern,
-		Summary:      summary,
+		TargetName:       targetName,
+		AnalyzedFrom:     minTime,
+		AnalyzedTo:       maxTime,
+		DataPoints:       len(metrics),
+		PeakHours:        peakHours,
+		QuietHours:       quietHours,
+		DailyPattern:     dailyPattern,
+		Summary:          summary,
+		WeeklyPattern:    weeklyPattern,
+		WeekdayVsWeekend: compareWeekdays(weeklyData),
+		WeeklySpikes:     spikes,
 	}
 }
 
@@ -158,6 +223,113 @@ type hourlyBucket struct {
 	usages []float64
 }
 
+// avgMax returns the average and maximum usage of the bucket
+func (b hourlyBucket) avgMax() (avg, max float64) {
+	if len(b.usages) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, u := range b.usages {
+		sum += u
+		if u > max {
+			max = u
+		}
+	}
+	return sum / float64(len(b.usages)), max
+}
+
+// compareWeekdays aggregates the weekly buckets into weekdays and weekends
+func compareWeekdays(weeklyData []hourlyBucket) WeekdayComparison {
+	var cmp WeekdayComparison
+	var weekdaySum, weekendSum float64
+	for i, bucket := range weeklyData {
+		weekday := time.Weekday(i / 24)
+		weekend := weekday == time.Saturday || weekday == time.Sunday
+		for _, u := range bucket.usages {
+			if weekend {
+				weekendSum += u
+				cmp.WeekendSamples++
+				if u > cmp.WeekendPeakUsage {
+					cmp.WeekendPeakUsage = u
+				}
+			} else {
+				weekdaySum += u
+				cmp.WeekdaySamples++
+				if u > cmp.WeekdayPeakUsage {
+					cmp.WeekdayPeakUsage = u
+				}
+			}
+		}
+	}
+	if cmp.WeekdaySamples > 0 {
+		cmp.WeekdayAvgUsage = weekdaySum / float64(cmp.WeekdaySamples)
+	}
+	if cmp.WeekendSamples > 0 {
+		cmp.WeekendAvgUsage = weekendSum / float64(cmp.WeekendSamples)
+	}
+	return cmp
+}
+
+// findWeeklySpikes returns weekday hours well above the average of the same hour on the other days
+func findWeeklySpikes(weeklyPattern []WeekdayHourStats) []WeeklySpike {
+	spikes := []WeeklySpike{}
+	for _, b := range weeklyPattern {
+		if b.SampleSize == 0 {
+			continue
+		}
+
+		var otherSum float64
+		var otherDays int
+		for weekday := 0; weekday < 7; weekday++ {
+			other := weeklyPattern[weekday*24+b.Hour]
+			if weekday != b.Weekday && other.SampleSize > 0 {
+				otherSum += other.AvgUsage
+				otherDays++
+			}
+		}
+		if otherDays < weeklySpikeMinDays {
+			continue
+		}
+
+		hourAvg := otherSum / float64(otherDays)
+		if excess := b.AvgUsage - hourAvg; excess >= weeklySpikeMargin {
+			spikes = append(spikes, WeeklySpike{
+				Weekday:      b.Weekday,
+				WeekdayName:  time.Weekday(b.Weekday).String(),
+				Hour:         b.Hour,
+				AvgUsage:     b.AvgUsage,
+				HourAvgUsage: hourAvg,
+				Excess:       excess,
+			})
+		}
+	}
+
+	sort.Slice(spikes, func(i, j int) bool { return spikes[i].Excess > spikes[j].Excess })
+	if len(spikes) > maxWeeklySpikes {
+		spikes = spikes[:maxWeeklySpikes]
+	}
+	return spikes
+}
+
+// busiestWeekday returns the day of the week with the highest average usage
+func busiestWeekday(weeklyPattern []WeekdayHourStats) string {
+	var busiest string
+	var busiestAvg float64
+	for weekday := 0; weekday < 7; weekday++ {
+		var sum float64
+		var samples int
+		for _, b := range weeklyPattern[weekday*24 : (weekday+1)*24] {
+			sum += b.AvgUsage * float64(b.SampleSize)
+			samples += b.SampleSize
+		}
+		if samples > 0 && (busiest == "" || sum/float64(samples) > busiestAvg) {
+			busiest = time.Weekday(weekday).String()
+			busiestAvg = sum / float64(samples)
+		}
+	}
+	return busiest
+}
+
 func generatePeakTimeRecommendation(summary PeakTimeSummary, peakHours []HourlyStats) string {
 	if summary.BusiestHourUsage > 80 {
 		return "Critical: Peak usage exceeds 80%. Consider increasing pool size or scheduling heavy tasks during off-peak hours."
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestAnalyzePeakTime_WeeklyPattern(t *testing.T) {
+	// Two weeks of hourly samples at 20%, with a 90% batch job on Mondays at 02:00
+	// and 10% usage on weekends
+	start := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC) // Sunday
+	var metrics []models.PoolMetrics
+	for ts := start; ts.Before(start.AddDate(0, 0, 14)); ts = ts.Add(time.Hour) {
+		active := 20
+		switch {
+		case ts.Weekday() == time.Monday && ts.Hour() == 2:
+			active = 90
+		case ts.Weekday() == time.Saturday || ts.Weekday() == time.Sunday:
+			active = 10
+		}
+		metrics = append(metrics, models.PoolMetrics{Active: active, Max: 100, Timestamp: ts})
+	}
+
+	result := AnalyzePeakTime("test", metrics, nil)
+
+	if len(result.WeeklyPattern) != 7*24 {
+		t.Fatalf("WeeklyPattern has %d buckets, want 168", len(result.WeeklyPattern))
+	}
+	monday := result.WeeklyPattern[int(time.Monday)*24+2]
+	if monday.Weekday != int(time.Monday) || monday.Hour != 2 || monday.AvgUsage != 90 || monday.SampleSize != 2 {
+		t.Errorf("Monday 02:00 = %+v, want avg 90 over 2 samples", monday)
+	}
+
+	if len(result.WeeklySpikes) != 1 {
+		t.Fatalf("WeeklySpikes = %+v, want only Monday 02:00", result.WeeklySpikes)
+	}
+	if s := result.WeeklySpikes[0]; s.WeekdayName != "Monday" || s.Hour != 2 {
+		t.Errorf("spike = %s %d:00, want Monday 2:00", s.WeekdayName, s.Hour)
+	}
+
+	cmp := result.WeekdayVsWeekend
+	if cmp.WeekendAvgUsage != 10 || cmp.WeekendPeakUsage != 10 {
+		t.Errorf("weekend avg/peak = %.1f/%.1f, want 10/10", cmp.WeekendAvgUsage, cmp.WeekendPeakUsage)
+	}
+	if cmp.WeekdayPeakUsage != 90 || cmp.WeekdayAvgUsage <= 20 {
+		t.Errorf("weekday avg/peak = %.1f/%.1f, want >20/90", cmp.WeekdayAvgUsage, cmp.WeekdayPeakUsage)
+	}
+	if result.Summary.BusiestWeekday != "Monday" {
+		t.Errorf("BusiestWeekday = %s, want Monday", result.Summary.BusiestWeekday)
+	}
+}
@@ -14,6 +14,10 @@ type Recommendation struct {
 	Recommended string `json:"recommended"`
 	Reason      string `json:"reason"`
 	Severity    string `json:"severity"` // info, warning, critical
+
+	// Tracking record, set when the recommendation is persisted
+	ID     int64  `json:"id,omitempty"`
+	Status string `json:"status,omitempty"` // open, applied, dismissed, snoozed
 }
 
 type AnalysisResult struct {
@@ -21,6 +25,7 @@ type AnalysisResult struct {
 	AnalyzedAt      time.Time        `json:"analyzed_at"`
 	DataPoints      int              `json:"data_points"`
 	Recommendations []Recommendation `json:"recommendations"`
+	Suppressed      []Recommendation `json:"suppressed,omitempty"` // Applied, dismissed or snoozed
 	Stats           PoolStats        `json:"stats"`
 }
 
@@ -32,8 +37,14 @@ type PoolStats struct {
 	MaxPending   int     `json:"max_pending"`
 	AvgUsage     float64 `json:"avg_usage"`
 	PeakUsage    float64 `json:"peak_usage"`
+	P50Usage     float64 `json:"p50_usage"`
+	P90Usage     float64 `json:"p90_usage"`
+	P99Usage     float64 `json:"p99_usage"`
 	CurrentMax   int     `json:"current_max"`
-	TimeoutCount int64   `json:"timeout_count"`
+	TimeoutCount int64   `json:"timeout_count"` // Timeouts within the analyzed range
+	TimeoutRate  float64 `json:"timeout_rate"`  // Timeouts per minute over the analyzed range
+
+	TimeAbove []ThresholdTime `json:"time_above"` // Time spent above each of UsageThresholds
 }
 
 // Analyze analyzes pool metrics and generates recommendations
@@ -68,14 +79,12 @@ func calculateStats(metrics []models.PoolMetrics) PoolStats {
 	var totalActive, totalIdle, totalPending float64
 	var maxActive, maxPending int
 	var peakUsage float64
-	var lastTimeout int64
+	var timeouts int64
 
 	currentMax := metrics[0].Max
-	if len(metrics) > 0 {
-		lastTimeout = metrics[len(metrics)-1].Timeout
-	}
 
 	for _, m := range metrics {
+		timeouts += m.TimeoutDelta
 		totalActive += float64(m.Active)
 		totalIdle += float64(m.Idle)
 		totalPending += float64(m.Pending)
@@ -97,10 +106,15 @@ func calculateStats(metrics []models.PoolMetrics) PoolStats {
 
 	n := float64(len(metrics))
 	avgActive := totalActive / n
+	var timeoutRate float64
+	if span := metrics[len(metrics)-1].Timestamp.Sub(metrics[0].Timestamp).Minutes(); span > 0 {
+		timeoutRate = float64(timeouts) / span
+	}
 	avgUsage := 0.0
 	if currentMax > 0 {
 		avgUsage = avgActive / float64(currentMax) * 100
 	}
+	dist := CalculateUsageDistribution(metrics)
 
 	return PoolStats{
 		AvgActive:    math.Round(avgActive*10) / 10,
@@ -110,31 +124,47 @@ func calculateStats(metrics []models.PoolMetrics) PoolStats {
 		MaxPending:   maxPending,
 		AvgUsage:     math.Round(avgUsage*10) / 10,
 		PeakUsage:    math.Round(peakUsage*10) / 10,
+		P50Usage:     dist.P50,
+		P90Usage:     dist.P90,
+		P99Usage:     dist.P99,
 		CurrentMax:   currentMax,
-		TimeoutCount: lastTimeout,
+		TimeoutCount: timeouts,
+		TimeoutRate:  math.Round(timeoutRate*100) / 100,
+		TimeAbove:    dist.TimeAbove,
+	}
+}
+
+// timeAboveSuffix describes the time spent above threshold, e.g., ", 47 minutes above 80%"
+func (s PoolStats) timeAboveSuffix(threshold float64) string {
+	for _, t := range s.TimeAbove {
+		if t.Threshold == threshold && t.Minutes > 0 {
+			return fmt.Sprintf(", %.0f minutes above %.0f%%", t.Minutes, t.Threshold)
+		}
 	}
+	return ""
 }
 
 func generateRecommendations(stats PoolStats) []Recommendation {
 	var recs []Recommendation
 
-	// Pool size recommendations
-	if stats.PeakUsage > 90 {
+	// Pool size increases follow p99 usage so a single outlier peak does not drive them,
+	// decreases still require the peak itself to be low
+	if stats.P99Usage > 90 {
 		newSize := int(float64(stats.CurrentMax) * 1.5)
 		recs = append(recs, Recommendation{
 			Type:        "maximumPoolSize",
 			Current:     fmt.Sprintf("%d", stats.CurrentMax),
 			Recommended: fmt.Sprintf("%d", newSize),
-			Reason:      fmt.Sprintf("Peak usage reached %.1f%%. Increase pool size to prevent connection starvation.", stats.PeakUsage),
+			Reason:      fmt.Sprintf("p99 usage reached %.1f%% (peak %.1f%%)%s. Increase pool size to prevent connection starvation.", stats.P99Usage, stats.PeakUsage, stats.timeAboveSuffix(90)),
 			Severity:    "critical",
 		})
-	} else if stats.PeakUsage > 70 {
+	} else if stats.P99Usage > 70 {
 		newSize := int(float64(stats.CurrentMax) * 1.25)
 		recs = append(recs, Recommendation{
 			Type:        "maximumPoolSize",
 			Current:     fmt.Sprintf("%d", stats.CurrentMax),
 			Recommended: fmt.Sprintf("%d", newSize),
-			Reason:      fmt.Sprintf("Peak usage reached %.1f%%. Consider increasing pool size for safety margin.", stats.PeakUsage),
+			Reason:      fmt.Sprintf("p99 usage reached %.1f%% (peak %.1f%%)%s. Consider increasing pool size for safety margin.", stats.P99Usage, stats.PeakUsage, stats.timeAboveSuffix(70)),
 			Severity:    "warning",
 		})
 	} else if stats.PeakUsage < 30 && stats.CurrentMax > 10 {
@@ -167,7 +197,7 @@ func generateRecommendations(stats PoolStats) []Recommendation {
 			Type:        "connectionTimeout",
 			Current:     "30000ms (default)",
 			Recommended: "45000ms",
-			Reason:      fmt.Sprintf("Detected %d timeout(s). Consider increasing connectionTimeout or pool size.", stats.TimeoutCount),
+			Reason:      fmt.Sprintf("Detected %d timeout(s) (%.2f/min). Consider increasing connectionTimeout or pool size.", stats.TimeoutCount, stats.TimeoutRate),
 			Severity:    "critical",
 		})
 	}
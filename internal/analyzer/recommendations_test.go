@@ -68,10 +68,12 @@ func TestAnalyze_WithTimezone(t *testing.T) {
 }
 
 func TestCalculateStats(t *testing.T) {
+	// The cumulative counter includes timeouts from before the range
+	base := time.Now()
 	metrics := []models.PoolMetrics{
-		{Active: 4, Idle: 6, Pending: 0, Max: 10, Timeout: 0},
-		{Active: 6, Idle: 4, Pending: 1, Max: 10, Timeout: 0},
-		{Active: 8, Idle: 2, Pending: 2, Max: 10, Timeout: 1},
+		{Active: 4, Idle: 6, Pending: 0, Max: 10, Timeout: 500, Timestamp: base},
+		{Active: 6, Idle: 4, Pending: 1, Max: 10, Timeout: 500, Timestamp: base.Add(time.Minute)},
+		{Active: 8, Idle: 2, Pending: 2, Max: 10, Timeout: 501, TimeoutDelta: 1, Timestamp: base.Add(2 * time.Minute)},
 	}
 
 	stats := calculateStats(metrics)
@@ -96,16 +98,17 @@ func TestCalculateStats(t *testing.T) {
 		t.Errorf("PeakUsage = %f, want 80", stats.PeakUsage)
 	}
 
-	// TimeoutCount = 1 (last value)
-	if stats.TimeoutCount != 1 {
-		t.Errorf("TimeoutCount = %d, want 1", stats.TimeoutCount)
+	// TimeoutCount = 1 (sum of deltas), 0.5 per minute over 2 minutes
+	if stats.TimeoutCount != 1 || stats.TimeoutRate != 0.5 {
+		t.Errorf("TimeoutCount = %d, TimeoutRate = %f, want 1, 0.5", stats.TimeoutCount, stats.TimeoutRate)
 	}
 }
 
 func TestGenerateRecommendations_Critical(t *testing.T) {
-	// Peak usage > 90%
+	// p99 usage > 90%
 	stats := PoolStats{
 		PeakUsage:  95,
+		P99Usage:   92,
 		CurrentMax: 10,
 	}
 
@@ -129,9 +132,10 @@ func TestGenerateRecommendations_Critical(t *testing.T) {
 }
 
 func TestGenerateRecommendations_Warning(t *testing.T) {
-	// Peak usage > 70% but < 90%
+	// p99 usage > 70% but < 90%
 	stats := PoolStats{
 		PeakUsage:  75,
+		P99Usage:   72,
 		CurrentMax: 10,
 	}
 
@@ -150,6 +154,21 @@ func TestGenerateRecommendations_Warning(t *testing.T) {
 	}
 }
 
+func TestGenerateRecommendations_OutlierPeak(t *testing.T) {
+	// A single 100% sample must not trigger a pool size increase
+	stats := PoolStats{
+		PeakUsage:  100,
+		P99Usage:   45,
+		CurrentMax: 10,
+	}
+
+	for _, rec := range generateRecommendations(stats) {
+		if rec.Type == "maximumPoolSize" {
+			t.Errorf("unexpected maximumPoolSize recommendation: %s", rec.Reason)
+		}
+	}
+}
+
 func TestGenerateRecommendations_Oversized(t *testing.T) {
 	// Peak usage < 30% with large pool
 	stats := PoolStats{
@@ -0,0 +1,208 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// DefaultRegressionThreshold is the % increase flagged as a regression
+const DefaultRegressionThreshold = 20.0
+
+// RegressionOptions configures event regression detection
+type RegressionOptions struct {
+	Threshold float64 // % increase flagged as a regression (default: 20)
+}
+
+// GetThreshold returns the regression threshold with default
+func (o *RegressionOptions) GetThreshold() float64 {
+	if o == nil || o.Threshold <= 0 {
+		return DefaultRegressionThreshold
+	}
+	return o.Threshold
+}
+
+// RegressionResult compares pool/JVM behavior before and after an event
+type RegressionResult struct {
+	TargetName  string          `json:"target_name"`
+	Event       models.Event    `json:"event"`
+	Threshold   float64         `json:"threshold"`
+	Before      RegressionStats `json:"before"`
+	After       RegressionStats `json:"after"`
+	Changes     []MetricChange  `json:"changes"`
+	Regressions []MetricChange  `json:"regressions"`
+	Summary     string          `json:"summary"`
+}
+
+// RegressionStats contains averages for one side of an event
+// GC time and timeouts are counter increases per hour
+type RegressionStats struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	DataPoints  int       `json:"data_points"`
+	AvgActive   float64   `json:"avg_active"`
+	AvgUsage    float64   `json:"avg_usage"`
+	MaxUsage    float64   `json:"max_usage"`
+	AvgPending  float64   `json:"avg_pending"`
+	AcquireP99  float64   `json:"acquire_p99"` // average
+	HeapUsage   float64   `json:"heap_usage"`
+	CpuUsage    float64   `json:"cpu_usage"`
+	GcTimeRate  float64   `json:"gc_time_rate"` // seconds per hour
+	TimeoutRate float64   `json:"timeout_rate"` // timeouts per hour
+}
+
+// MetricChange is the change of one metric across an event
+type MetricChange struct {
+	Metric        string  `json:"metric"`
+	Before        float64 `json:"before"`
+	After         float64 `json:"after"`
+	ChangePercent float64 `json:"change_percent"`
+	Regression    bool    `json:"regression"`
+	Message       string  `json:"message,omitempty"`
+}
+
+// regressionMetric describes a compared metric, higher values are worse
+// floor is the smallest absolute increase worth flagging
+type regressionMetric struct {
+	name  string
+	label string
+	floor float64
+	value func(s *RegressionStats) float64
+}
+
+var regressionMetrics = []regressionMetric{
+	{"avg_active", "average active connections", 1, func(s *RegressionStats) float64 { return s.AvgActive }},
+	{"avg_usage", "average pool usage", 5, func(s *RegressionStats) float64 { return s.AvgUsage }},
+	{"max_usage", "peak pool usage", 5, func(s *RegressionStats) float64 { return s.MaxUsage }},
+	{"avg_pending", "average pending threads", 0.5, func(s *RegressionStats) float64 { return s.AvgPending }},
+	{"acquire_p99", "connection acquire p99", 0.01, func(s *RegressionStats) float64 { return s.AcquireP99 }},
+	{"heap_usage", "heap usage", 5, func(s *RegressionStats) float64 { return s.HeapUsage }},
+	{"cpu_usage", "CPU usage", 0.05, func(s *RegressionStats) float64 { return s.CpuUsage }},
+	{"gc_time_rate", "GC time", 1, func(s *RegressionStats) float64 { return s.GcTimeRate }},
+	{"timeout_rate", "connection timeouts", 1, func(s *RegressionStats) float64 { return s.TimeoutRate }},
+}
+
+// DetectRegression compares metrics before and after an event and flags regressions
+// loc is the timezone for timestamps (if nil, uses UTC)
+func DetectRegression(event models.Event, before, after []models.PoolMetrics, loc *time.Location, opts *RegressionOptions) *RegressionResult {
+	if loc == nil {
+		loc = time.UTC
+	}
+	event.Timestamp = event.Timestamp.In(loc)
+
+	result := &RegressionResult{
+		TargetName:  event.TargetName,
+		Event:       event,
+		Threshold:   opts.GetThreshold(),
+		Before:      calculateRegressionStats(before, loc),
+		After:       calculateRegressionStats(after, loc),
+		Changes:     []MetricChange{},
+		Regressions: []MetricChange{},
+	}
+
+	if result.Before.DataPoints == 0 || result.After.DataPoints == 0 {
+		result.Summary = "Not enough data around the event to compare"
+		return result
+	}
+
+	since := event.Version
+	if since == "" {
+		since = fmt.Sprintf("%s at %s", event.Type, event.Timestamp.Format("2006-01-02 15:04"))
+	}
+
+	for _, metric := range regressionMetrics {
+		b, a := metric.value(&result.Before), metric.value(&result.After)
+		change := MetricChange{Metric: metric.name, Before: b, After: a}
+		if b > 0 {
+			change.ChangePercent = (a - b) / b * 100
+		}
+
+		// A metric that was zero before regresses once it clears the floor
+		increased := a-b >= metric.floor
+		if increased && (b == 0 || change.ChangePercent >= result.Threshold) {
+			change.Regression = true
+			if b == 0 {
+				change.Message = fmt.Sprintf("%s rose from 0 to %.1f since %s", metric.label, a, since)
+			} else {
+				change.Message = fmt.Sprintf("%+.0f%% %s since %s", change.ChangePercent, metric.label, since)
+			}
+			result.Regressions = append(result.Regressions, change)
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	switch len(result.Regressions) {
+	case 0:
+		result.Summary = fmt.Sprintf("No regressions since %s", since)
+	case 1:
+		result.Summary = result.Regressions[0].Message
+	default:
+		result.Summary = fmt.Sprintf("%s (and %d more regressions)", result.Regressions[0].Message, len(result.Regressions)-1)
+	}
+	return result
+}
+
+func calculateRegressionStats(metrics []models.PoolMetrics, loc *time.Location) RegressionStats {
+	stats := RegressionStats{}
+
+	var totalActive, totalUsage, totalPending, totalP99, totalHeap, totalCpu float64
+	var n, usageCount, heapCount int
+	var gcTime, timeouts float64
+	prev := make(map[string]*models.PoolMetrics)
+
+	for i := range metrics {
+		m := &metrics[i]
+		if m.Status == models.StatusError {
+			continue
+		}
+		if n == 0 {
+			stats.From = m.Timestamp.In(loc)
+		}
+		stats.To = m.Timestamp.In(loc)
+		n++
+
+		totalActive += float64(m.Active)
+		totalPending += float64(m.Pending)
+		totalP99 += m.AcquireP99
+		totalCpu += m.CpuUsage
+		if m.Max > 0 {
+			usage := float64(m.Active) / float64(m.Max) * 100
+			totalUsage += usage
+			usageCount++
+			stats.MaxUsage = math.Max(stats.MaxUsage, usage)
+		}
+		if m.HeapMax > 0 {
+			totalHeap += float64(m.HeapUsed) / float64(m.HeapMax) * 100
+			heapCount++
+		}
+
+		// Counters are cumulative per instance
+		if p, ok := prev[m.InstanceName]; ok {
+			gcTime += counterIncrease(p.GcTime, m.GcTime)
+			timeouts += counterIncrease(float64(p.Timeout), float64(m.Timeout))
+		}
+		prev[m.InstanceName] = m
+	}
+
+	stats.DataPoints = n
+	if n == 0 {
+		return stats
+	}
+	stats.AvgActive = totalActive / float64(n)
+	stats.AvgPending = totalPending / float64(n)
+	stats.AcquireP99 = totalP99 / float64(n)
+	stats.CpuUsage = totalCpu / float64(n)
+	if usageCount > 0 {
+		stats.AvgUsage = totalUsage / float64(usageCount)
+	}
+	if heapCount > 0 {
+		stats.HeapUsage = totalHeap / float64(heapCount)
+	}
+	if hours := stats.To.Sub(stats.From).Hours(); hours > 0 {
+		stats.GcTimeRate = gcTime / hours
+		stats.TimeoutRate = timeouts / hours
+	}
+	return stats
+}
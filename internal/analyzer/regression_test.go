@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+var regressionEvent = models.Event{
+	TargetName: "test",
+	Type:       models.EventTypeDeploy,
+	Version:    "v2.3.1",
+	Timestamp:  time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+}
+
+// regressionSamples builds minutely samples for an hour starting at from
+func regressionSamples(from time.Time, active int, timeoutsPerMinute int64) []models.PoolMetrics {
+	metrics := make([]models.PoolMetrics, 60)
+	for i := range metrics {
+		metrics[i] = models.PoolMetrics{
+			TargetName: "test",
+			Active:     active,
+			Max:        50,
+			Timeout:    int64(i) * timeoutsPerMinute,
+			Timestamp:  from.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return metrics
+}
+
+func findChange(changes []MetricChange, metric string) *MetricChange {
+	for i := range changes {
+		if changes[i].Metric == metric {
+			return &changes[i]
+		}
+	}
+	return nil
+}
+
+func TestDetectRegression_Flags(t *testing.T) {
+	before := regressionSamples(regressionEvent.Timestamp.Add(-time.Hour), 10, 0)
+	after := regressionSamples(regressionEvent.Timestamp, 14, 1)
+
+	result := DetectRegression(regressionEvent, before, after, nil, nil)
+	if result.Threshold != DefaultRegressionThreshold {
+		t.Errorf("Threshold = %.0f, want %.0f", result.Threshold, DefaultRegressionThreshold)
+	}
+
+	active := findChange(result.Changes, "avg_active")
+	if active == nil || !active.Regression || math.Abs(active.ChangePercent-40) > 0.01 {
+		t.Fatalf("avg_active = %+v, want +40%% regression", active)
+	}
+	if active.Message != "+40% average active connections since v2.3.1" {
+		t.Errorf("Message = %q", active.Message)
+	}
+	if result.Summary != active.Message+" (and 3 more regressions)" {
+		t.Errorf("Summary = %q", result.Summary)
+	}
+
+	// 59 timeouts over 59 minutes, none before
+	timeouts := findChange(result.Changes, "timeout_rate")
+	if timeouts == nil || !timeouts.Regression || math.Abs(timeouts.After-60) > 0.01 {
+		t.Errorf("timeout_rate = %+v, want 60/h regression", timeouts)
+	}
+
+	if pending := findChange(result.Changes, "avg_pending"); pending == nil || pending.Regression {
+		t.Errorf("avg_pending = %+v, want no regression", pending)
+	}
+}
+
+func TestDetectRegression_BelowThreshold(t *testing.T) {
+	before := regressionSamples(regressionEvent.Timestamp.Add(-time.Hour), 10, 0)
+	after := regressionSamples(regressionEvent.Timestamp, 11, 0)
+
+	result := DetectRegression(regressionEvent, before, after, nil, &RegressionOptions{Threshold: 50})
+	if len(result.Regressions) != 0 {
+		t.Errorf("Regressions = %+v, want none", result.Regressions)
+	}
+	if result.Summary != "No regressions since v2.3.1" {
+		t.Errorf("Summary = %q", result.Summary)
+	}
+}
+
+func TestDetectRegression_NoData(t *testing.T) {
+	before := regressionSamples(regressionEvent.Timestamp.Add(-time.Hour), 10, 0)
+
+	result := DetectRegression(regressionEvent, before, nil, nil, nil)
+	if len(result.Changes) != 0 {
+		t.Errorf("Changes = %+v, want none without data after the event", result.Changes)
+	}
+	if result.Summary != "Not enough data around the event to compare" {
+		t.Errorf("Summary = %q", result.Summary)
+	}
+}
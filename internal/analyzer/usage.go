@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// UsageThresholds are the pool usage levels tracked by time-above-threshold statistics
+var UsageThresholds = []float64{70, 80, 90}
+
+// maxSampleGap caps the time a single sample accounts for
+// Longer gaps are missing data rather than time spent at the sample's usage
+const maxSampleGap = 5 * time.Minute
+
+// UsageDistribution summarizes how pool usage is spread over a range
+type UsageDistribution struct {
+	P50       float64         `json:"p50_usage"`
+	P90       float64         `json:"p90_usage"`
+	P99       float64         `json:"p99_usage"`
+	TimeAbove []ThresholdTime `json:"time_above"`
+}
+
+// ThresholdTime is the time spent above a usage threshold
+// Multi-instance targets report their worst instance
+type ThresholdTime struct {
+	Threshold float64 `json:"threshold"`
+	Minutes   float64 `json:"minutes"`
+	Percent   float64 `json:"percent"` // Share of the observed time
+}
+
+// CalculateUsageDistribution computes usage percentiles and time above UsageThresholds
+func CalculateUsageDistribution(metrics []models.PoolMetrics) UsageDistribution {
+	var usages []float64
+	byInstance := make(map[string][]models.PoolMetrics)
+	for _, m := range metrics {
+		if m.Max <= 0 {
+			continue
+		}
+		usages = append(usages, float64(m.Active)/float64(m.Max)*100)
+		byInstance[m.InstanceName] = append(byInstance[m.InstanceName], m)
+	}
+	sort.Float64s(usages)
+
+	dist := UsageDistribution{
+		P50:       math.Round(percentile(usages, 50)*10) / 10,
+		P90:       math.Round(percentile(usages, 90)*10) / 10,
+		P99:       math.Round(percentile(usages, 99)*10) / 10,
+		TimeAbove: make([]ThresholdTime, len(UsageThresholds)),
+	}
+
+	for i, threshold := range UsageThresholds {
+		dist.TimeAbove[i].Threshold = threshold
+	}
+	for _, samples := range byInstance {
+		sort.Slice(samples, func(i, j int) bool { return samples[i].Timestamp.Before(samples[j].Timestamp) })
+
+		var observed time.Duration
+		above := make([]time.Duration, len(UsageThresholds))
+		for i := 0; i < len(samples)-1; i++ {
+			gap := samples[i+1].Timestamp.Sub(samples[i].Timestamp)
+			if gap > maxSampleGap {
+				gap = maxSampleGap
+			}
+			observed += gap
+			usage := float64(samples[i].Active) / float64(samples[i].Max) * 100
+			for t, threshold := range UsageThresholds {
+				if usage > threshold {
+					above[t] += gap
+				}
+			}
+		}
+		if observed <= 0 {
+			continue
+		}
+
+		for t := range UsageThresholds {
+			if minutes := above[t].Minutes(); minutes > dist.TimeAbove[t].Minutes {
+				dist.TimeAbove[t].Minutes = math.Round(minutes*10) / 10
+				dist.TimeAbove[t].Percent = math.Round(float64(above[t])/float64(observed)*1000) / 10
+			}
+		}
+	}
+
+	return dist
+}
+
+// percentile returns the p-th percentile of sorted values using linear interpolation
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestCalculateUsageDistribution(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var metrics []models.PoolMetrics
+	// pod-1: 100 minutes at 50% with 10 minutes at 85% in the middle
+	for i := 0; i <= 100; i++ {
+		active := 5
+		if i >= 40 && i < 50 {
+			active = 85
+		}
+		metrics = append(metrics, models.PoolMetrics{
+			InstanceName: "pod-1", Active: active, Max: 100, Timestamp: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	// pod-2: always 95% but only 2 minutes observed
+	for i := 0; i <= 2; i++ {
+		metrics = append(metrics, models.PoolMetrics{
+			InstanceName: "pod-2", Active: 95, Max: 100, Timestamp: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	dist := CalculateUsageDistribution(metrics)
+
+	if dist.P50 != 5 {
+		t.Errorf("P50 = %v, want 5", dist.P50)
+	}
+	if dist.P99 != 95 {
+		t.Errorf("P99 = %v, want 95", dist.P99)
+	}
+
+	want := map[float64]float64{70: 10, 80: 10, 90: 2}
+	for _, ta := range dist.TimeAbove {
+		if ta.Minutes != want[ta.Threshold] {
+			t.Errorf("minutes above %.0f%% = %v, want %v", ta.Threshold, ta.Minutes, want[ta.Threshold])
+		}
+	}
+	if dist.TimeAbove[2].Percent != 100 {
+		t.Errorf("percent above 90%% = %v, want 100", dist.TimeAbove[2].Percent)
+	}
+}
+
+func TestCalculateUsageDistribution_CapsGaps(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	metrics := []models.PoolMetrics{
+		{Active: 9, Max: 10, Timestamp: base},
+		{Active: 9, Max: 10, Timestamp: base.Add(time.Hour)},
+	}
+
+	dist := CalculateUsageDistribution(metrics)
+	if got := dist.TimeAbove[0].Minutes; got != maxSampleGap.Minutes() {
+		t.Errorf("minutes above 70%% = %v, want %v", got, maxSampleGap.Minutes())
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// bulkMaxIDs limits the number of alert IDs in a single bulk request
+const bulkMaxIDs = 1000
+
+// BulkAlertRequest applies an action to alerts selected by ID or by filter
+type BulkAlertRequest struct {
+	Action string           `json:"action" binding:"required"` // resolve, acknowledge, delete
+	IDs    []int64          `json:"ids,omitempty"`
+	Filter *BulkAlertFilter `json:"filter,omitempty"`
+	By     string           `json:"by,omitempty"` // Recorded on acknowledge, defaults to the API key name
+}
+
+// BulkAlertFilter selects alerts by target and rule; target or rule is required
+type BulkAlertFilter struct {
+	Target   string   `json:"target,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Severity []string `json:"severity,omitempty"`
+	Status   string   `json:"status,omitempty"` // fired, resolved
+}
+
+// BulkAlertResponse lists the alerts changed by a bulk action
+type BulkAlertResponse struct {
+	Action string  `json:"action"`
+	Count  int     `json:"count"`
+	IDs    []int64 `json:"ids"`
+}
+
+// alertQuery validates the request selection and converts it to a storage query
+func (r *BulkAlertRequest) alertQuery() (models.AlertQuery, error) {
+	switch r.Action {
+	case models.AlertActionResolve, models.AlertActionAcknowledge, models.AlertActionDelete:
+	default:
+		return models.AlertQuery{}, fmt.Errorf("invalid action '%s': use resolve, acknowledge or delete", r.Action)
+	}
+
+	if (len(r.IDs) > 0) == (r.Filter != nil) {
+		return models.AlertQuery{}, fmt.Errorf("specify either ids or filter")
+	}
+	if len(r.IDs) > 0 {
+		if len(r.IDs) > bulkMaxIDs {
+			return models.AlertQuery{}, fmt.Errorf("too many ids (max %d)", bulkMaxIDs)
+		}
+		return models.AlertQuery{IDs: r.IDs}, nil
+	}
+
+	f := r.Filter
+	if f.Target == "" && f.Rule == "" {
+		return models.AlertQuery{}, fmt.Errorf("filter requires target or rule")
+	}
+	if f.Status != "" && f.Status != models.AlertStatusFired && f.Status != models.AlertStatusResolved && f.Status != models.AlertStatusSuppressed {
+		return models.AlertQuery{}, fmt.Errorf("invalid status '%s': use fired, resolved or suppressed", f.Status)
+	}
+	for _, sev := range f.Severity {
+		if sev != models.SeverityInfo && sev != models.SeverityWarning && sev != models.SeverityCritical {
+			return models.AlertQuery{}, fmt.Errorf("invalid severity '%s': use info, warning or critical", sev)
+		}
+	}
+	return models.AlertQuery{TargetName: f.Target, RuleName: f.Rule, Severities: f.Severity, Status: f.Status}, nil
+}
+
+// BulkAlerts resolves, acknowledges or deletes many alerts in one transaction
+func (h *Handler) BulkAlerts(c *gin.Context) {
+	var req BulkAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	q, err := req.alertQuery()
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	q.TargetIn = h.visibleTargetNames(c)
+
+	by := req.By
+	if by == "" {
+		by = c.GetString(ContextKeyAPIKey)
+	}
+
+	ids, err := h.store.ApplyAlertAction(c.Request.Context(), req.Action, q, by, time.Now())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if ids == nil {
+		ids = []int64{}
+	}
+
+	resp := BulkAlertResponse{Action: req.Action, Count: len(ids), IDs: ids}
+	auditAfter(c, resp)
+	c.JSON(http.StatusOK, resp)
+}
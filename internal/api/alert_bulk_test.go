@@ -0,0 +1,34 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestBulkAlertRequest_AlertQuery(t *testing.T) {
+	valid := []BulkAlertRequest{
+		{Action: models.AlertActionResolve, IDs: []int64{1, 2}},
+		{Action: models.AlertActionAcknowledge, Filter: &BulkAlertFilter{Target: "orders", Rule: "high_usage"}},
+		{Action: models.AlertActionDelete, Filter: &BulkAlertFilter{Rule: "pending", Status: models.AlertStatusResolved}},
+	}
+	for _, req := range valid {
+		if _, err := req.alertQuery(); err != nil {
+			t.Errorf("alertQuery(%+v) = %v, want valid", req, err)
+		}
+	}
+
+	invalid := []BulkAlertRequest{
+		{Action: "mute", IDs: []int64{1}},
+		{Action: models.AlertActionResolve},
+		{Action: models.AlertActionResolve, IDs: []int64{1}, Filter: &BulkAlertFilter{Target: "orders"}},
+		{Action: models.AlertActionResolve, Filter: &BulkAlertFilter{Status: models.AlertStatusFired}}, // Would match every target
+		{Action: models.AlertActionResolve, Filter: &BulkAlertFilter{Target: "orders", Severity: []string{"fatal"}}},
+		{Action: models.AlertActionDelete, IDs: make([]int64, bulkMaxIDs+1)},
+	}
+	for _, req := range invalid {
+		if _, err := req.alertQuery(); err == nil {
+			t.Errorf("alertQuery(%+v) = nil, want error", req)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// alertContextWindow is the history shown before and after an alert fired
+const alertContextWindow = 15 * time.Minute
+
+// AlertContextResponse shows what the pool looked like when an alert fired
+type AlertContextResponse struct {
+	Alert   *models.Alert        `json:"alert"`
+	Values  map[string]float64   `json:"values"` // Metric values the alert fired on, empty for alerts from before snapshots
+	From    time.Time            `json:"from"`
+	To      time.Time            `json:"to"`
+	History []models.PoolMetrics `json:"history"` // Samples of the instance around the firing time
+
+	Escalations []models.AlertEscalation `json:"escalations"` // Severity changes of the alert, oldest first
+}
+
+// GetAlertContext returns the metric values an alert fired on and the samples of its
+// instance within 15 minutes before and after, so responders don't reconstruct them
+func (h *Handler) GetAlertContext(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid alert ID")
+		return
+	}
+
+	alert, err := h.store.GetAlert(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if alert == nil || !h.targetVisible(c, alert.TargetName) {
+		RespondNotFound(c, "alert not found")
+		return
+	}
+
+	values, err := h.store.GetAlertSnapshot(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if values == nil {
+		values = map[string]float64{}
+	}
+
+	from := alert.FiredAt.Add(-alertContextWindow)
+	to := alert.FiredAt.Add(alertContextWindow)
+	history, err := h.store.GetHistoryByInstance(c.Request.Context(), alert.TargetName, alert.InstanceName, from, to)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if history == nil {
+		history = []models.PoolMetrics{}
+	}
+
+	escalations, err := h.store.GetAlertEscalations(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if escalations == nil {
+		escalations = []models.AlertEscalation{}
+	}
+
+	c.JSON(http.StatusOK, AlertContextResponse{
+		Alert:   alert,
+		Values:  values,
+		From:    from,
+		To:      to,
+		History: history,
+
+		Escalations: escalations,
+	})
+}
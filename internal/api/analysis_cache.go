@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cache of analysis results
+// Dashboards and combined reports request the peak times, forecasts, anomalies and reports of
+// the same window together; the cache serves repeats until the target gets a newer sample
+
+const (
+	analysisCacheTTL  = time.Minute // Also bounds how far a relative range slides for an idle target
+	analysisCacheSize = 256
+)
+
+// errNoAnalysisData is returned by an analysis without samples in its range, which isn't cached
+var errNoAnalysisData = errors.New("no data available for analysis")
+
+// analysisCache holds analysis results by target and key; a nil cache stores nothing
+type analysisCache struct {
+	mu      sync.Mutex
+	entries map[string]analysisEntry
+}
+
+type analysisEntry struct {
+	value   interface{}
+	latest  time.Time // Newest sample of the target the value was computed from
+	expires time.Time
+}
+
+func newAnalysisCache() *analysisCache {
+	return &analysisCache{entries: make(map[string]analysisEntry)}
+}
+
+// get returns the value stored under key if it was computed from the newest sample, latest,
+// and hasn't expired
+func (a *analysisCache) get(key string, latest, now time.Time) (interface{}, bool) {
+	if a == nil {
+		return nil, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[key]
+	if !ok || !entry.latest.Equal(latest) || now.After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// put stores a value, making room by dropping expired entries, then the one expiring first
+func (a *analysisCache) put(key string, value interface{}, latest, now time.Time) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, exists := a.entries[key]; !exists && len(a.entries) >= analysisCacheSize {
+		var oldest string
+		for k, entry := range a.entries {
+			if now.After(entry.expires) {
+				delete(a.entries, k)
+			} else if oldest == "" || entry.expires.Before(a.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(a.entries) >= analysisCacheSize {
+			delete(a.entries, oldest)
+		}
+	}
+	a.entries[key] = analysisEntry{value: value, latest: latest, expires: now.Add(analysisCacheTTL)}
+}
+
+// clear drops all results, e.g., after a config change or a data import
+func (a *analysisCache) clear() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.entries = make(map[string]analysisEntry)
+	a.mu.Unlock()
+}
+
+// cachedAnalysis returns the result of an analysis of a target from the cache, or computes
+// and caches it; key names the analysis and its parameters. Errors aren't cached
+func cachedAnalysis[T any](ctx context.Context, h *Handler, target, key string, compute func() (T, error)) (T, error) {
+	latest, err := h.store.GetLatestTimestamps(ctx, target)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	var newest time.Time
+	for _, ts := range latest {
+		if ts.After(newest) {
+			newest = ts
+		}
+	}
+
+	key = target + "\x00" + key
+	if value, ok := h.analyses.get(key, newest, time.Now()); ok {
+		if result, ok := value.(T); ok {
+			return result, nil
+		}
+	}
+
+	result, err := compute()
+	if err != nil {
+		return result, err
+	}
+	h.analyses.put(key, result, newest, time.Now())
+	return result, nil
+}
+
+// analysisKey names an analysis by its type and the request parameters, in a fixed order
+func analysisKey(c *gin.Context, kind string) string {
+	return kind + "?" + c.Request.URL.Query().Encode()
+}
+
+// respondAnalysisError answers a failed analysis: 404 without data, otherwise an internal error
+func respondAnalysisError(c *gin.Context, err error) {
+	if errors.Is(err, errNoAnalysisData) {
+		RespondNoData(c)
+		return
+	}
+	RespondInternalError(c, err)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestAnalysisCache(t *testing.T) {
+	a := newAnalysisCache()
+	now := time.Now()
+	latest := now.Add(-10 * time.Second)
+
+	a.put("orders\x00peaktime", "result", latest, now)
+	if v, ok := a.get("orders\x00peaktime", latest, now.Add(time.Second)); !ok || v != "result" {
+		t.Errorf("get() = %v, %v; want the stored result", v, ok)
+	}
+	if _, ok := a.get("orders\x00peaktime", now, now.Add(time.Second)); ok {
+		t.Error("get() after a newer sample should miss")
+	}
+	if _, ok := a.get("orders\x00peaktime", latest, now.Add(analysisCacheTTL+time.Second)); ok {
+		t.Error("get() after the TTL should miss")
+	}
+
+	// A full cache drops the entry expiring first
+	for i := 0; i < analysisCacheSize; i++ {
+		a.put(fmt.Sprintf("key-%d", i), i, latest, now.Add(time.Duration(i+1)*time.Millisecond))
+	}
+	if len(a.entries) != analysisCacheSize {
+		t.Errorf("cache holds %d entries, want %d", len(a.entries), analysisCacheSize)
+	}
+	if _, ok := a.entries["orders\x00peaktime"]; ok {
+		t.Error("oldest entry was not evicted")
+	}
+
+	a.clear()
+	if len(a.entries) != 0 {
+		t.Errorf("clear() left %d entries", len(a.entries))
+	}
+}
+
+func TestCachedAnalysis(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "analysis.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+	h := &Handler{store: store, analyses: newAnalysisCache()}
+	ctx := context.Background()
+
+	save := func(ts time.Time) {
+		m := &models.PoolMetrics{TargetName: "orders", InstanceName: "default", Status: models.StatusHealthy, Max: 10, Timestamp: ts}
+		if err := store.Save(ctx, m); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	computed := 0
+	analyze := func() (int, error) {
+		computed++
+		return computed, nil
+	}
+
+	save(time.Now().Add(-time.Minute))
+	first, _ := cachedAnalysis(ctx, h, "orders", "peaktime?range=24h", analyze)
+	second, _ := cachedAnalysis(ctx, h, "orders", "peaktime?range=24h", analyze)
+	if first != 1 || second != 1 {
+		t.Errorf("results = %d, %d; want the first result twice", first, second)
+	}
+	if other, _ := cachedAnalysis(ctx, h, "orders", "peaktime?range=1h", analyze); other != 2 {
+		t.Errorf("other range = %d, want a new computation", other)
+	}
+
+	// A new sample of the target invalidates its results
+	save(time.Now())
+	if third, _ := cachedAnalysis(ctx, h, "orders", "peaktime?range=24h", analyze); third != 3 {
+		t.Errorf("after a new sample = %d, want a new computation", third)
+	}
+
+	// Errors aren't cached
+	if _, err := cachedAnalysis(ctx, h, "billing", "peaktime", func() (int, error) { return 0, errNoAnalysisData }); err != errNoAnalysisData {
+		t.Errorf("error = %v, want errNoAnalysisData", err)
+	}
+	if v, _ := cachedAnalysis(ctx, h, "billing", "peaktime", analyze); v != 4 {
+		t.Errorf("after an error = %d, want a new computation", v)
+	}
+}
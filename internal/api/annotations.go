@@ -0,0 +1,213 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Annotation handlers
+
+type AnnotationsResponse struct {
+	Annotations []models.Annotation `json:"annotations"`
+	Total       int                 `json:"total"`
+}
+
+// buildAnnotation validates input and creates an annotation
+func buildAnnotation(input *models.AnnotationInput) (*models.Annotation, error) {
+	text := strings.TrimSpace(input.Text)
+	if text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+	if len(text) > 5000 {
+		return nil, fmt.Errorf("text must be less than 5000 characters")
+	}
+
+	var tags []string
+	for _, tag := range input.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if strings.Contains(tag, ",") {
+			return nil, fmt.Errorf("tags must not contain commas")
+		}
+		tags = append(tags, tag)
+	}
+
+	t := time.Now()
+	if input.Time != "" {
+		parsed, err := time.Parse(time.RFC3339, input.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time format, use RFC3339 (e.g., 2024-01-15T10:00:00Z)")
+		}
+		t = parsed
+	}
+
+	annotation := &models.Annotation{
+		TargetName: input.TargetName,
+		Time:       t,
+		Tags:       tags,
+		Text:       text,
+		CreatedBy:  input.CreatedBy,
+	}
+
+	if input.EndTime != "" {
+		end, err := time.Parse(time.RFC3339, input.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_time format, use RFC3339 (e.g., 2024-01-15T12:00:00Z)")
+		}
+		if !end.After(t) {
+			return nil, fmt.Errorf("end_time must be after time")
+		}
+		annotation.EndTime = &end
+	}
+	return annotation, nil
+}
+
+// filterAnnotationsByTag keeps annotations having all of the comma-separated tags
+func filterAnnotationsByTag(annotations []models.Annotation, tagsParam string) []models.Annotation {
+	if tagsParam == "" {
+		return annotations
+	}
+	tags := strings.Split(tagsParam, ",")
+
+	var filtered []models.Annotation
+	for _, a := range annotations {
+		matched := true
+		for _, tag := range tags {
+			if !a.HasTag(strings.TrimSpace(tag)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// annotationVisible returns whether the request may see an annotation
+// Annotations without a target apply to all projects and are visible to everyone
+func (h *Handler) annotationVisible(c *gin.Context, a *models.Annotation) bool {
+	return a.TargetName == "" || h.targetVisible(c, a.TargetName)
+}
+
+// annotationWritable returns whether the request may create or delete an annotation of a target
+// Annotations without a target can only be changed without a project scope
+func (h *Handler) annotationWritable(c *gin.Context, target string) bool {
+	if target == "" {
+		return scopeOf(c) == nil
+	}
+	return h.targetVisible(c, target)
+}
+
+func (h *Handler) GetAnnotations(c *gin.Context) {
+	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
+
+	annotations, err := h.store.GetAnnotations(c.Request.Context(), c.Query("target"), tr.From, tr.To)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	annotations = slices.DeleteFunc(annotations, func(a models.Annotation) bool {
+		return !h.annotationVisible(c, &a)
+	})
+	annotations = filterAnnotationsByTag(annotations, c.Query("tags"))
+	if annotations == nil {
+		annotations = []models.Annotation{}
+	}
+
+	c.JSON(http.StatusOK, AnnotationsResponse{
+		Annotations: annotations,
+		Total:       len(annotations),
+	})
+}
+
+func (h *Handler) GetAnnotation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid annotation ID")
+		return
+	}
+
+	annotation, err := h.store.GetAnnotation(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if annotation == nil || !h.annotationVisible(c, annotation) {
+		RespondNotFound(c, "annotation not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, annotation)
+}
+
+func (h *Handler) CreateAnnotation(c *gin.Context) {
+	var input models.AnnotationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid input: "+err.Error())
+		return
+	}
+
+	annotation, err := buildAnnotation(&input)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if !h.annotationWritable(c, annotation.TargetName) {
+		if annotation.TargetName == "" {
+			respondNotWritable(c, "an annotation without a target")
+		} else {
+			RespondNotFound(c, fmt.Sprintf("target '%s' not found", annotation.TargetName))
+		}
+		return
+	}
+
+	if err := h.store.SaveAnnotation(c.Request.Context(), annotation); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	auditAfter(c, annotation)
+	c.JSON(http.StatusCreated, annotation)
+}
+
+func (h *Handler) DeleteAnnotation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid annotation ID")
+		return
+	}
+
+	existing, err := h.store.GetAnnotation(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if existing == nil || !h.annotationVisible(c, existing) {
+		RespondNotFound(c, "annotation not found")
+		return
+	}
+	if !h.annotationWritable(c, existing.TargetName) {
+		respondNotWritable(c, "an annotation without a target")
+		return
+	}
+	auditBefore(c, existing)
+
+	if err := h.store.DeleteAnnotation(c.Request.Context(), id); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "annotation deleted"})
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// Context keys of the state recorded by handlers for the audit log
+const (
+	contextKeyAuditBefore = "audit_before"
+	contextKeyAuditAfter  = "audit_after"
+)
+
+// auditActions names the audited operations by "METHOD route" without the API prefix
+// Mutating routes missing here are recorded under their method and route
+var auditActions = map[string]string{
+	"POST /targets/:name/events":                           "event.create",
+	"DELETE /targets/:name/events/:id":                     "event.delete",
+	"POST /targets/:name/recommendations/apply":            "target.reconfigure",
+	"POST /recommendations/:id/status":                     "recommendation.status",
+	"POST /targets/:name/instances/:id/actions/threaddump": "instance.threaddump",
+	"POST /targets/:name/instances/:id/actions/heapdump":   "instance.heapdump",
+	"POST /alerts/bulk":                                    "alert.bulk",
+	"POST /alerts/:id/resolve":                             "alert.resolve",
+	"POST /rules":                                          "rule.create",
+	"PUT /rules/:id":                                       "rule.update",
+	"DELETE /rules/:id":                                    "rule.delete",
+	"PATCH /rules/:id/toggle":                              "rule.toggle",
+	"POST /rules/templates/apply":                          "rule.template_apply",
+	"POST /rules/import":                                   "rule.import",
+	"POST /rules/:id/adopt":                                "rule.adopt",
+	"POST /backup":                                         "backup.create",
+	"POST /backup/restore":                                 "backup.restore",
+	"POST /backup/remote/restore":                          "backup.restore",
+	"POST /storage/vacuum":                                 "storage.vacuum",
+	"DELETE /storage/targets/:name":                        "storage.purge",
+	"POST /config/targets":                                 "target.create",
+	"POST /config/targets/import":                          "target.import",
+	"PUT /config/targets/:name":                            "target.update",
+	"DELETE /config/targets/:name":                         "target.delete",
+	"POST /config/targets/:name/backfill":                  "target.backfill",
+	"POST /config/targets/:name/pause":                     "target.pause",
+	"POST /config/targets/:name/resume":                    "target.resume",
+	"POST /config/derived-metrics":                         "derived_metric.create",
+	"DELETE /config/derived-metrics/:name":                 "derived_metric.delete",
+	"PUT /config/alerting":                                 "alerting.update",
+	"POST /maintenance":                                    "maintenance.create",
+	"PUT /maintenance/:id":                                 "maintenance.update",
+	"DELETE /maintenance/:id":                              "maintenance.delete",
+	"POST /annotations":                                    "annotation.create",
+	"DELETE /annotations/:id":                              "annotation.delete",
+	"POST /silences":                                       "silence.create",
+	"PUT /silences/:id":                                    "silence.update",
+	"DELETE /silences/:id":                                 "silence.delete",
+}
+
+// auditSkipped lists POST routes that don't change anything pondy keeps
+var auditSkipped = map[string]bool{
+	"POST /alerts/templates/validate": true,
+	"POST /alerts/test":               true,
+	"POST /rules/test":                true,
+	"POST /ingest/metrics":            true, // Metric samples, not changes
+}
+
+// auditRoute returns the route of a request without the versioned, project or legacy API prefix
+func auditRoute(fullPath string) string {
+	if strings.HasPrefix(fullPath, APIPrefix+"/") {
+		route := strings.TrimPrefix(fullPath, APIPrefix)
+		if strings.HasPrefix(route, projectRoutePrefix+"/") {
+			return strings.TrimPrefix(route, projectRoutePrefix)
+		}
+		return route
+	}
+	return strings.TrimPrefix(fullPath, "/api")
+}
+
+// auditBefore records the state of the changed resource before the change
+// The value is marshaled right away, so it may be modified afterwards
+func auditBefore(c *gin.Context, v interface{}) {
+	setAuditState(c, contextKeyAuditBefore, v)
+}
+
+// auditAfter records the state of the changed resource after the change
+func auditAfter(c *gin.Context, v interface{}) {
+	setAuditState(c, contextKeyAuditAfter, v)
+}
+
+func setAuditState(c *gin.Context, key string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Audit: failed to marshal state of %s: %v", c.FullPath(), err)
+		return
+	}
+	c.Set(key, json.RawMessage(data))
+}
+
+func auditState(c *gin.Context, key string) json.RawMessage {
+	if v, ok := c.Get(key); ok {
+		if data, ok := v.(json.RawMessage); ok {
+			return data
+		}
+	}
+	return nil
+}
+
+// AuditMiddleware records successful mutating requests in the audit log
+// The actor is the API key name, or the client IP when authentication is disabled
+func AuditMiddleware(store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			c.Next()
+			return
+		}
+		route := method + " " + auditRoute(c.FullPath())
+		if auditSkipped[route] {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		// Failed requests change nothing
+		status := c.Writer.Status()
+		if status >= http.StatusBadRequest || c.FullPath() == "" {
+			return
+		}
+
+		action, ok := auditActions[route]
+		if !ok {
+			action = route
+		}
+		actor := c.GetString(ContextKeyAPIKey)
+		if actor == "" || actor == AnonymousKeyName {
+			actor = c.ClientIP()
+		}
+		resource := c.Param("name")
+		if id := c.Param("id"); id != "" {
+			resource = id
+		}
+
+		entry := &models.AuditEntry{
+			Timestamp: time.Now(),
+			Actor:     actor,
+			IP:        c.ClientIP(),
+			Action:    action,
+			Method:    method,
+			Path:      c.Request.URL.Path,
+			Resource:  resource,
+			Status:    status,
+			Before:    auditState(c, contextKeyAuditBefore),
+			After:     auditState(c, contextKeyAuditAfter),
+		}
+		// The entry is recorded even if the client has disconnected meanwhile
+		if err := store.SaveAuditEntry(context.WithoutCancel(c.Request.Context()), entry); err != nil {
+			log.Printf("Audit: failed to record %s by %s: %v", action, actor, err)
+		}
+	}
+}
+
+// AuditChange is a field that differs between the before and after state
+type AuditChange struct {
+	Path   string      `json:"path"` // Dotted field path, empty for the whole state
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntryResponse is an audit entry with the changed fields
+type AuditEntryResponse struct {
+	models.AuditEntry
+	Changes []AuditChange `json:"changes"`
+}
+
+// AuditResponse is the response for the audit log endpoint
+type AuditResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	Total   int                  `json:"total"`
+	Limit   int                  `json:"limit"`
+	Offset  int                  `json:"offset"`
+}
+
+// diffAuditState lists the fields that differ between two JSON states
+// Objects are compared field by field; arrays and values are compared as a whole
+func diffAuditState(before, after json.RawMessage) []AuditChange {
+	var b, a interface{}
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil
+		}
+	}
+
+	changes := []AuditChange{}
+	var walk func(path string, b, a interface{})
+	walk = func(path string, b, a interface{}) {
+		bm, bok := b.(map[string]interface{})
+		am, aok := a.(map[string]interface{})
+		if !bok || !aok {
+			if !reflect.DeepEqual(b, a) {
+				changes = append(changes, AuditChange{Path: path, Before: b, After: a})
+			}
+			return
+		}
+
+		keys := make([]string, 0, len(bm)+len(am))
+		for k := range bm {
+			keys = append(keys, k)
+		}
+		for k := range am {
+			if _, ok := bm[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			walk(child, bm[k], am[k])
+		}
+	}
+	if b != nil && a != nil {
+		walk("", b, a)
+	} else if b != nil || a != nil {
+		changes = append(changes, AuditChange{Before: b, After: a})
+	}
+	return changes
+}
+
+// parseAuditQuery reads the audit log filters from query parameters
+func parseAuditQuery(c *gin.Context) (models.AuditQuery, error) {
+	q := models.AuditQuery{
+		Actor:    c.Query("actor"),
+		Action:   c.Query("action"),
+		Resource: c.Query("resource"),
+		Limit:    100,
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return q, fmt.Errorf("invalid limit")
+		}
+		q.Limit = limit
+	}
+	if q.Limit > 10000 {
+		q.Limit = 10000
+	}
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return q, fmt.Errorf("invalid offset")
+		}
+		q.Offset = offset
+	}
+
+	// Time range: range relative to now, or explicit from/to
+	if c.Query("range") != "" {
+		tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
+		q.From, q.To = tr.From, tr.To
+	}
+	bounds := []struct {
+		param string
+		t     *time.Time
+	}{{"from", &q.From}, {"to", &q.To}}
+	for _, b := range bounds {
+		if v := c.Query(b.param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s, use RFC3339 (e.g., 2024-01-15T10:00:00Z)", b.param)
+			}
+			*b.t = parsed
+		}
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return q, fmt.Errorf("to must be after from")
+	}
+
+	return q, nil
+}
+
+// GetAuditLog returns audit entries newest first, as JSON or as a CSV download with format=csv
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	q, err := parseAuditQuery(c)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		RespondBadRequest(c, "format must be json or csv")
+		return
+	}
+
+	entries, total, err := h.store.QueryAuditLog(c.Request.Context(), q)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	resp := AuditResponse{
+		Entries: make([]AuditEntryResponse, 0, len(entries)),
+		Total:   total,
+		Limit:   q.Limit,
+		Offset:  q.Offset,
+	}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, AuditEntryResponse{AuditEntry: e, Changes: diffAuditState(e.Before, e.After)})
+	}
+
+	if format == "csv" {
+		writeAuditCSV(c, resp.Entries)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// writeAuditCSV writes audit entries as a CSV download, one row per entry
+func writeAuditCSV(c *gin.Context, entries []AuditEntryResponse) {
+	filename := fmt.Sprintf("pondy_audit_%s.csv", time.Now().Format("20060102_150405"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"id", "timestamp", "actor", "ip", "action", "method", "path", "resource", "status", "changes", "before", "after"})
+	for _, e := range entries {
+		changes := make([]string, 0, len(e.Changes))
+		for _, ch := range e.Changes {
+			before, _ := json.Marshal(ch.Before)
+			after, _ := json.Marshal(ch.After)
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", ch.Path, before, after))
+		}
+		writer.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.Timestamp.Format(time.RFC3339),
+			e.Actor,
+			e.IP,
+			e.Action,
+			e.Method,
+			e.Path,
+			e.Resource,
+			strconv.Itoa(e.Status),
+			strings.Join(changes, "; "),
+			string(e.Before),
+			string(e.After),
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestAuditRoute(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/rules/:id":                   "/rules/:id",
+		"/api/v1/projects/:project/rules/:id": "/rules/:id",
+		"/api/rules/:id":                      "/rules/:id",
+		"/api/v1":                             "/v1",
+	}
+	for fullPath, want := range tests {
+		if got := auditRoute(fullPath); got != want {
+			t.Errorf("auditRoute(%q) = %q, want %q", fullPath, got, want)
+		}
+	}
+}
+
+func TestDiffAuditState(t *testing.T) {
+	before := json.RawMessage(`{"name":"high_usage","enabled":true,"channels":["slack"],"template":{"title":"a"}}`)
+	after := json.RawMessage(`{"name":"high_usage","enabled":false,"channels":["slack","email"],"template":{"title":"b"},"message":"m"}`)
+
+	got := diffAuditState(before, after)
+	want := []AuditChange{
+		{Path: "channels", Before: []interface{}{"slack"}, After: []interface{}{"slack", "email"}},
+		{Path: "enabled", Before: true, After: false},
+		{Path: "message", Before: nil, After: "m"},
+		{Path: "template.title", Before: "a", After: "b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffAuditState() = %+v, want %+v", got, want)
+	}
+
+	if got := diffAuditState(before, before); len(got) != 0 {
+		t.Errorf("identical states should have no changes, got %+v", got)
+	}
+
+	created := diffAuditState(nil, json.RawMessage(`{"id":1}`))
+	if len(created) != 1 || created[0].Path != "" || created[0].Before != nil {
+		t.Errorf("creation should be one whole-state change, got %+v", created)
+	}
+	if got := diffAuditState(nil, nil); len(got) != 0 {
+		t.Errorf("no states should have no changes, got %+v", got)
+	}
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	r := gin.New()
+	api := r.Group(APIPrefix)
+	api.Use(func(c *gin.Context) { c.Set(ContextKeyAPIKey, "ci") })
+	api.Use(AuditMiddleware(store))
+	api.PUT("/rules/:id", func(c *gin.Context) {
+		auditBefore(c, gin.H{"enabled": true})
+		auditAfter(c, gin.H{"enabled": false})
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	api.POST("/rules", func(c *gin.Context) { RespondBadRequest(c, "invalid") })
+	api.GET("/rules", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	api.POST("/ingest/metrics", func(c *gin.Context) { c.JSON(http.StatusAccepted, gin.H{}) })
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodPut, "/api/v1/rules/7"},
+		{http.MethodPost, "/api/v1/rules"},          // Failed
+		{http.MethodGet, "/api/v1/rules"},           // Read only
+		{http.MethodPost, "/api/v1/ingest/metrics"}, // Skipped
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	entries, total, err := store.QueryAuditLog(context.Background(), models.AuditQuery{})
+	if err != nil {
+		t.Fatalf("QueryAuditLog failed: %v", err)
+	}
+	if total != 1 {
+		t.Fatalf("recorded %d entries, want 1: %+v", total, entries)
+	}
+	e := entries[0]
+	if e.Actor != "ci" || e.Action != "rule.update" || e.Resource != "7" || e.Status != http.StatusOK {
+		t.Errorf("entry = %+v", e)
+	}
+	if string(e.Before) != `{"enabled":true}` || string(e.After) != `{"enabled":false}` {
+		t.Errorf("states = %s -> %s", e.Before, e.After)
+	}
+}
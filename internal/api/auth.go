@@ -0,0 +1,134 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+)
+
+// Context key and fallback name for the authenticated API key
+const (
+	ContextKeyAPIKey = "api_key_name"
+	AnonymousKeyName = "anonymous"
+)
+
+// extractAPIKey reads the API key from X-API-Key or a Bearer Authorization header
+func extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	auth := c.GetHeader("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return ""
+}
+
+// matchAPIKey returns the name of the configured key matching the given value
+func matchAPIKey(keys []config.APIKeyConfig, value string) (string, bool) {
+	if k := findAPIKey(keys, value); k != nil {
+		return k.Name, true
+	}
+	return "", false
+}
+
+// findAPIKey returns the configured key matching the given value, or nil
+func findAPIKey(keys []config.APIKeyConfig, value string) *config.APIKeyConfig {
+	if value == "" {
+		return nil
+	}
+	for i := range keys {
+		if keys[i].Key == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(keys[i].Key), []byte(value)) == 1 {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// APIKeyMiddleware authenticates requests against the configured API keys
+// When no keys are configured, requests pass through as anonymous
+func APIKeyMiddleware(cfgMgr *config.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server := cfgMgr.Get().Server
+		if !server.AuthEnabled() {
+			c.Set(ContextKeyAPIKey, AnonymousKeyName)
+			c.Next()
+			return
+		}
+
+		key := findAPIKey(server.APIKeys, extractAPIKey(c))
+		if key == nil {
+			RespondError(c, http.StatusUnauthorized, "missing or invalid API key")
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextKeyAPIKey, key.Name)
+		if len(key.Projects) > 0 {
+			c.Set(ContextKeyProjects, projectScope(key.Projects))
+		}
+		c.Next()
+	}
+}
+
+// AuthRateLimitMiddleware limits failed authentications per client IP, so API keys can't be
+// guessed at the general rate. It runs before APIKeyMiddleware: each 401 takes a token, and a
+// client without tokens left gets 429 before its key is checked
+func AuthRateLimitMiddleware(rl *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if rl.Exhausted(ip) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "too many failed authentication attempts",
+				"retry_after": "1m",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() == http.StatusUnauthorized {
+			rl.Allow(ip)
+		}
+	}
+}
+
+// AdminMiddleware restricts instance-wide reports to admin API keys
+// Without admin keys, keys without a project scope are admins; without authentication, everyone is
+func AdminMiddleware(cfgMgr *config.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(&cfgMgr.Get().Server, c.GetString(ContextKeyAPIKey), scopeOf(c)) {
+			RespondError(c, http.StatusForbidden, "this endpoint requires an admin API key")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// isAdmin returns whether a request authenticated with the named key may use admin endpoints
+func isAdmin(server *config.ServerConfig, keyName string, scope projectScope) bool {
+	if !server.AuthEnabled() {
+		return true
+	}
+	if server.HasAdminKeys() {
+		key := server.APIKeyByName(keyName)
+		return key != nil && key.Admin
+	}
+	return scope == nil
+}
+
+// apiKeyName returns the API key name attached to the request
+func apiKeyName(c *gin.Context) string {
+	if name := c.GetString(ContextKeyAPIKey); name != "" {
+		return name
+	}
+	return AnonymousKeyName
+}
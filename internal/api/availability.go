@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/analyzer"
+)
+
+// GetTargetAvailability returns the health endpoint uptime of a target's instances
+func (h *Handler) GetTargetAvailability(c *gin.Context) {
+	name := c.Param("name")
+	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
+
+	checks, err := h.store.GetHealthChecks(c.Request.Context(), name, tr.From, tr.To)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analyzer.CalculateAvailability(name, checks, tr.From, tr.To, h.cfg().GetLocation()))
+}
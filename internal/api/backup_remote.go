@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/backup"
+)
+
+// RemoteRestoreRequest selects a backup object to restore
+type RemoteRestoreRequest struct {
+	Key string `json:"key" binding:"required"`
+}
+
+// remoteBackups returns a backup manager for the configured bucket, responding with an error if unavailable
+func (h *Handler) remoteBackups(c *gin.Context) *backup.Manager {
+	cfg := h.cfg().Backup.S3
+	if !cfg.Enabled {
+		RespondError(c, http.StatusServiceUnavailable, "remote backups are not configured (backup.s3)")
+		return nil
+	}
+	mgr, err := backup.NewManager(h.store, cfg)
+	if err != nil {
+		RespondError(c, http.StatusServiceUnavailable, err.Error())
+		return nil
+	}
+	return mgr
+}
+
+// GetRemoteBackups lists backups in object storage, newest first
+func (h *Handler) GetRemoteBackups(c *gin.Context) {
+	mgr := h.remoteBackups(c)
+	if mgr == nil {
+		return
+	}
+
+	backups, err := mgr.Remote().List(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	if backups == nil {
+		backups = []backup.RemoteBackup{}
+	}
+	c.JSON(http.StatusOK, gin.H{"backups": backups})
+}
+
+// RestoreRemoteBackup downloads a backup from object storage and restores it
+func (h *Handler) RestoreRemoteBackup(c *gin.Context) {
+	var req RemoteRestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBadRequest(c, "key is required")
+		return
+	}
+
+	mgr := h.remoteBackups(c)
+	if mgr == nil {
+		return
+	}
+
+	if err := mgr.ValidateKey(req.Key); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if err := mgr.Restore(c.Request.Context(), req.Key); err != nil {
+		RespondError(c, http.StatusBadGateway, "restore failed: "+err.Error())
+		return
+	}
+	h.InvalidateCache()
+
+	auditAfter(c, gin.H{"key": req.Key})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "backup restored successfully",
+		"key":     req.Key,
+	})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/importer"
+)
+
+// Bootstrap handlers
+
+// backfillTimeout bounds a single target backfill run
+const backfillTimeout = 10 * time.Minute
+
+// newImporter creates an importer from the current bootstrap config
+func (h *Handler) newImporter() *importer.PrometheusImporter {
+	cfg := h.cfgMgr.Get()
+	return importer.NewPrometheusImporter(cfg.Bootstrap.Prometheus, h.store)
+}
+
+// startBackfill imports Prometheus history for a newly added target in the background
+func (h *Handler) startBackfill(target config.TargetConfig) {
+	imp := h.newImporter()
+	if !imp.IsEnabled() {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), backfillTimeout)
+		defer cancel()
+
+		result, err := imp.Backfill(ctx, target)
+		if err != nil {
+			log.Printf("Prometheus bootstrap failed for %s: %v", target.Name, err)
+			return
+		}
+		for _, e := range result.Errors {
+			log.Printf("Prometheus bootstrap error for %s: %s", target.Name, e)
+		}
+	}()
+}
+
+// BackfillTarget imports Prometheus history for an existing target
+// Instances that already have collected data are skipped
+func (h *Handler) BackfillTarget(c *gin.Context) {
+	imp := h.newImporter()
+	if !imp.IsEnabled() {
+		RespondBadRequest(c, "prometheus bootstrap is not enabled")
+		return
+	}
+
+	target, err := h.cfgMgr.GetTarget(c.Param("name"))
+	if err != nil {
+		RespondNotFound(c, "target not found")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), backfillTimeout)
+	defer cancel()
+
+	result, err := imp.Backfill(ctx, *target)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
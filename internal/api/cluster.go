@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/cluster"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// ClusterResponse shows the replicas sharing collection and which one owns each target
+type ClusterResponse struct {
+	Enabled     bool                `json:"enabled"`
+	NodeID      string              `json:"node_id,omitempty"` // Replica serving this request
+	Nodes       []ClusterNodeStatus `json:"nodes"`
+	Assignments map[string]string   `json:"assignments"` // Target name -> owning node ID
+}
+
+// ClusterNodeStatus is a live replica and the targets it collects
+type ClusterNodeStatus struct {
+	models.ClusterNode
+	Targets []string `json:"targets"`
+}
+
+// GetCluster returns the live replicas and the target assignment computed from the shared ring
+func (h *Handler) GetCluster(c *gin.Context) {
+	cfg := h.cfg().Cluster
+	resp := ClusterResponse{
+		Enabled:     cfg.Enabled,
+		Nodes:       []ClusterNodeStatus{},
+		Assignments: map[string]string{},
+	}
+	if !cfg.Enabled {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.NodeID = cfg.GetNodeID()
+
+	nodes, err := h.store.GetClusterNodes(c.Request.Context(), time.Now().Add(-cfg.GetNodeTTL()))
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	ids := make([]string, 0, len(nodes))
+	byID := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		ids = append(ids, n.ID)
+		byID[n.ID] = i
+		resp.Nodes = append(resp.Nodes, ClusterNodeStatus{ClusterNode: n, Targets: []string{}})
+	}
+
+	ring := cluster.NewRing(ids)
+	for _, name := range h.shardedTargets() {
+		owner := ring.Owner(name)
+		if owner == "" {
+			continue
+		}
+		resp.Assignments[name] = owner
+		node := &resp.Nodes[byID[owner]]
+		node.Targets = append(node.Targets, name)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// shardedTargets returns the names of the scraped targets, which are split across replicas
+// Push targets are stored by whichever replica receives them and paused targets aren't collected
+func (h *Handler) shardedTargets() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(t config.TargetConfig) {
+		if t.Paused || t.Type == config.TargetTypePush || seen[t.Name] {
+			return
+		}
+		seen[t.Name] = true
+		names = append(names, t.Name)
+	}
+	for _, t := range h.cfg().Targets {
+		add(t)
+	}
+	if h.collectors != nil {
+		for _, t := range h.collectors.DiscoveredTargets() {
+			add(t)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// CollectorsResponse lists the scrape health of active collectors
+type CollectorsResponse struct {
+	Collectors []models.CollectorHealth `json:"collectors"`
+	Total      int                      `json:"total"`
+	Failing    int                      `json:"failing"`
+	Scheduler  *models.SchedulerStats   `json:"scheduler,omitempty"`
+}
+
+// GetCollectors returns per-collector scrape health
+func (h *Handler) GetCollectors(c *gin.Context) {
+	collectors := []models.CollectorHealth{}
+	if h.collectors != nil {
+		collectors = slices.DeleteFunc(h.collectors.Health(), func(col models.CollectorHealth) bool {
+			return !h.targetVisible(c, col.TargetName)
+		})
+	}
+
+	failing := 0
+	for i, col := range collectors {
+		collectors[i].Endpoint = redactURL(col.Endpoint)
+		if col.State == models.CollectorFailing || col.State == models.CollectorDown {
+			failing++
+		}
+	}
+
+	resp := CollectorsResponse{
+		Collectors: collectors,
+		Total:      len(collectors),
+		Failing:    failing,
+	}
+	if h.collectors != nil {
+		stats := h.collectors.SchedulerStats()
+		resp.Scheduler = &stats
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// collectorHealthByKey indexes collector health by "target/instance"
+func (h *Handler) collectorHealthByKey() map[string]models.CollectorHealth {
+	result := make(map[string]models.CollectorHealth)
+	if h.collectors == nil {
+		return result
+	}
+	for _, col := range h.collectors.Health() {
+		result[col.TargetName+"/"+col.InstanceName] = col
+	}
+	return result
+}
+
+// applyBreakerState marks instances whose circuit breaker is open as down
+// The target is down when the breaker is open for all of its collectors
+func applyBreakerState(status *models.TargetStatus, health map[string]models.CollectorHealth) {
+	for i := range status.Instances {
+		inst := &status.Instances[i]
+		if col, ok := health[status.Name+"/"+inst.InstanceName]; ok && col.BreakerOpen {
+			inst.Status = models.CollectorDown
+			inst.Collector = &col
+		}
+	}
+
+	total, down := 0, 0
+	for _, col := range health {
+		if col.TargetName != status.Name {
+			continue
+		}
+		total++
+		if col.BreakerOpen {
+			down++
+		}
+	}
+	if total > 0 && down == total {
+		status.Status = models.CollectorDown
+		status.Current = nil
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestApplyBreakerState(t *testing.T) {
+	health := map[string]models.CollectorHealth{
+		"orders/a": {TargetName: "orders", InstanceName: "a", BreakerOpen: true, ConsecutiveFailures: 6},
+		"orders/b": {TargetName: "orders", InstanceName: "b"},
+	}
+
+	status := models.TargetStatus{
+		Name:   "orders",
+		Status: "healthy",
+		Instances: []models.InstanceStatus{
+			{InstanceName: "a", Status: "healthy"},
+			{InstanceName: "b", Status: "healthy"},
+		},
+	}
+	applyBreakerState(&status, health)
+
+	if status.Instances[0].Status != models.CollectorDown || status.Instances[0].Collector == nil {
+		t.Errorf("instance a = %+v, want down with collector health", status.Instances[0])
+	}
+	if status.Instances[1].Status != "healthy" || status.Instances[1].Collector != nil {
+		t.Errorf("instance b = %+v, want unchanged", status.Instances[1])
+	}
+	if status.Status != "healthy" {
+		t.Errorf("target status = %s, want healthy while one instance is up", status.Status)
+	}
+
+	// All collectors down marks the whole target down
+	health["orders/b"] = models.CollectorHealth{TargetName: "orders", InstanceName: "b", BreakerOpen: true}
+	applyBreakerState(&status, health)
+	if status.Status != models.CollectorDown {
+		t.Errorf("target status = %s, want down", status.Status)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jiin/pondy/internal/report"
+)
+
+// Combined report analysis
+// Targets are analyzed by a bounded pool of workers, so a report over many targets isn't the
+// sum of their analyses; targets that fail or run out of time are listed instead of failing it
+
+// reportRenderShare is the share of the time left before the request deadline kept for
+// rendering: the analyses stop 1/reportRenderShare of it early, so the finished ones get reported
+const reportRenderShare = 5
+
+// combinedReports analyzes the targets, at most workers at a time, and returns the reports in
+// target order and the targets that failed or weren't analyzed before ctx ended
+// Targets without data are left out of both
+func combinedReports(ctx context.Context, names []string, workers int, analyze func(ctx context.Context, name string) (report.ReportData, error)) ([]report.ReportData, []report.ReportFailure) {
+	results := make([]report.ReportData, len(names))
+	errs := make([]error, len(names))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), len(names)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i], errs[i] = analyze(ctx, names[i])
+			}
+		}()
+	}
+	for i := range names {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var reports []report.ReportData
+	var failures []report.ReportFailure
+	for i, name := range names {
+		switch err := errs[i]; {
+		case err == nil:
+			reports = append(reports, results[i])
+		case errors.Is(err, errNoAnalysisData):
+		case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) || ctx.Err() != nil:
+			failures = append(failures, report.ReportFailure{TargetName: name, Error: "analysis timed out"})
+		default:
+			failures = append(failures, report.ReportFailure{TargetName: name, Error: err.Error()})
+		}
+	}
+	return reports, failures
+}
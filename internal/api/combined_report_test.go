@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/report"
+)
+
+func TestCombinedReports(t *testing.T) {
+	names := []string{"a", "b", "empty", "broken", "c", "d", "e"}
+	var running, peak atomic.Int32
+
+	reports, failures := combinedReports(context.Background(), names, 2, func(ctx context.Context, name string) (report.ReportData, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		switch name {
+		case "empty":
+			return report.ReportData{}, errNoAnalysisData
+		case "broken":
+			return report.ReportData{}, errors.New("database is locked")
+		}
+		return report.ReportData{TargetName: name}, nil
+	})
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d analyses ran at once, want at most 2", p)
+	}
+	var got []string
+	for _, r := range reports {
+		got = append(got, r.TargetName)
+	}
+	if strings.Join(got, ",") != "a,b,c,d,e" {
+		t.Errorf("reports = %v, want a,b,c,d,e in order", got)
+	}
+	if len(failures) != 1 || failures[0].TargetName != "broken" || failures[0].Error != "database is locked" {
+		t.Errorf("failures = %+v, want broken only", failures)
+	}
+}
+
+func TestCombinedReports_Deadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	reports, failures := combinedReports(ctx, []string{"fast", "slow", "queued"}, 2, func(ctx context.Context, name string) (report.ReportData, error) {
+		if name == "slow" {
+			<-ctx.Done()
+			return report.ReportData{}, ctx.Err()
+		}
+		// Finishes after the deadline, so that no worker is free to start queued before it
+		time.Sleep(30 * time.Millisecond)
+		return report.ReportData{TargetName: name}, nil
+	})
+
+	if len(reports) != 1 || reports[0].TargetName != "fast" {
+		t.Errorf("reports = %+v, want fast only", reports)
+	}
+	if len(failures) != 2 || failures[0].TargetName != "slow" || failures[1].TargetName != "queued" ||
+		failures[0].Error != "analysis timed out" {
+		t.Errorf("failures = %+v, want slow and queued timed out", failures)
+	}
+
+	html, err := report.GenerateCombinedHTMLReport(reports, failures, "24h", nil)
+	if err != nil {
+		t.Fatalf("GenerateCombinedHTMLReport() error = %v", err)
+	}
+	if !strings.Contains(string(html), "slow</span>: <span class=\"rec-reason\">analysis timed out") {
+		t.Error("combined report should list the timed out target in its errors section")
+	}
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Conditional requests of the polled read APIs
+// Handlers build a weak ETag from cheap validators, e.g., the newest sample of each instance or
+// the alert version, and answer 304 Not Modified before running the queries of the full response
+
+// analysisMaxAge is how long clients may reuse an analysis over a time range, which barely
+// changes between two requests a few seconds apart
+const analysisMaxAge = time.Minute
+
+// etag accumulates everything a response depends on into a weak entity tag
+type etag struct {
+	h hash.Hash
+}
+
+// newETag starts the ETag of a request with its path, query and the targets visible to it,
+// so that responses of other parameters or project scopes never validate each other
+func (h *Handler) newETag(c *gin.Context) *etag {
+	e := &etag{h: sha256.New()}
+	e.add(c.Request.URL.Path, c.Request.URL.RawQuery)
+	if visible := h.visibleTargetNames(c); visible != nil {
+		e.add(visible)
+	}
+	return e
+}
+
+// add hashes values by their default format; times go through addTime, as their format
+// carries the location and monotonic clock reading
+func (e *etag) add(values ...interface{}) *etag {
+	for _, v := range values {
+		fmt.Fprintf(e.h, "%v\x00", v)
+	}
+	return e
+}
+
+// addJSON hashes values by their JSON encoding, for structs with pointer fields
+func (e *etag) addJSON(values ...interface{}) *etag {
+	for _, v := range values {
+		data, _ := json.Marshal(v)
+		e.h.Write(append(data, 0))
+	}
+	return e
+}
+
+// addTime hashes a time by its Unix nanoseconds, the same for all equal times
+func (e *etag) addTime(t time.Time) *etag {
+	return e.add(t.UnixNano())
+}
+
+func (e *etag) String() string {
+	return `W/"` + hex.EncodeToString(e.h.Sum(nil)[:16]) + `"`
+}
+
+// targetsVersion digests what the targets response depends on besides the request: the newest
+// sample of each instance and whether it is stale, the target configuration, open circuit
+// breakers and, unless includeInactive, the hidden inactive instances
+// Returns the digest and the time of the newest sample
+func (h *Handler) targetsVersion(ctx context.Context, includeInactive bool) (string, time.Time, error) {
+	cfg := h.cfg()
+	e := &etag{h: sha256.New()}
+	var lastModified time.Time
+
+	for _, t := range cfg.Targets {
+		latest, err := h.store.GetLatestTimestamps(ctx, t.Name)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		e.add(t.Name)
+		if newest := e.addLatest(latest); newest.After(lastModified) {
+			lastModified = newest
+		}
+		// Instances turn stale without new samples; with the sample times fixed, the number
+		// of stale instances tells which ones are
+		staleThreshold := h.calculateStaleThreshold(t.Interval)
+		stale := 0
+		for _, ts := range latest {
+			if time.Since(ts) > staleThreshold {
+				stale++
+			}
+		}
+		e.add(stale)
+	}
+
+	e.addJSON(cfg.Targets)
+
+	// Instances with an open breaker show their collector health, which changes without samples
+	health := h.collectorHealthByKey()
+	keys := make([]string, 0, len(health))
+	for key := range health {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		col := health[key]
+		e.add(key, col.BreakerOpen)
+		if col.BreakerOpen {
+			e.addJSON(col)
+		}
+	}
+
+	if !includeInactive {
+		e.add(h.inactiveInstances(ctx))
+	}
+	return hex.EncodeToString(e.h.Sum(nil)), lastModified, nil
+}
+
+// addLatest hashes the newest sample time of each instance and returns the newest of all
+func (e *etag) addLatest(latest map[string]time.Time) time.Time {
+	instances := make([]string, 0, len(latest))
+	for instance := range latest {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+
+	var newest time.Time
+	for _, instance := range instances {
+		e.add(instance).addTime(latest[instance])
+		if latest[instance].After(newest) {
+			newest = latest[instance]
+		}
+	}
+	return newest
+}
+
+// alertsNotModified validates an alert response by the alert version, plus the times of a
+// relative range, and reports whether the request has been answered, with 304 or an error
+func (h *Handler) alertsNotModified(c *gin.Context, times ...time.Time) bool {
+	version, updated, err := h.store.GetAlertVersion(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return true
+	}
+	tag := h.newETag(c).add(version)
+	for _, t := range times {
+		tag.addTime(t)
+	}
+	return notModified(c, tag.String(), updated)
+}
+
+// notModified sets the validators of a response and reports whether the client's copy is
+// still current, in which case 304 Not Modified has been answered
+// If-None-Match takes precedence over If-Modified-Since, which only compares lastModified;
+// a zero lastModified leaves Last-Modified unset
+func notModified(c *gin.Context, tag string, lastModified time.Time) bool {
+	// Clients keep the response but revalidate it on every poll
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("ETag", tag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if !etagMatches(match, tag) {
+			return false
+		}
+	} else if since := c.GetHeader("If-Modified-Since"); since != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(since)
+		if err != nil || lastModified.Truncate(time.Second).After(t) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match list has the tag, using weak comparison
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheControlMiddleware lets clients reuse successful responses for maxAge, for analysis
+// endpoints whose result over a time range changes slowly
+func CacheControlMiddleware(maxAge time.Duration) gin.HandlerFunc {
+	value := fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds()))
+	return func(c *gin.Context) {
+		c.Writer = &cacheControlWriter{ResponseWriter: c.Writer, value: value}
+		c.Next()
+	}
+}
+
+// cacheControlWriter sets Cache-Control on 200 responses only, so errors aren't reused
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	value string
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	if code == http.StatusOK {
+		w.Header().Set("Cache-Control", w.value)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modified := time.Date(2024, 1, 15, 10, 0, 0, 500, time.UTC)
+	tag := `W/"abc"`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"unconditional", nil, false},
+		{"matching etag", map[string]string{"If-None-Match": `"other", W/"abc"`}, true},
+		{"strong form of the weak etag", map[string]string{"If-None-Match": `"abc"`}, true},
+		{"wildcard", map[string]string{"If-None-Match": "*"}, true},
+		{"changed etag", map[string]string{"If-None-Match": `W/"old"`}, false},
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, false},
+		{"invalid date", map[string]string{"If-Modified-Since": "yesterday"}, false},
+		// If-None-Match takes precedence over If-Modified-Since
+		{"etag over date", map[string]string{"If-None-Match": `W/"old"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/targets", nil)
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+
+			if got := notModified(c, tag, modified); got != tt.want {
+				t.Errorf("notModified() = %v, want %v", got, tt.want)
+			}
+			if got := w.Header().Get("ETag"); got != tag {
+				t.Errorf("ETag = %q, want %q", got, tag)
+			}
+			if got := w.Header().Get("Last-Modified"); got != modified.Format(http.TimeFormat) {
+				t.Errorf("Last-Modified = %q", got)
+			}
+			if tt.want && c.Writer.Status() != http.StatusNotModified {
+				t.Errorf("status = %d, want 304", c.Writer.Status())
+			}
+		})
+	}
+
+	// Without a modification time, If-Modified-Since alone never matches
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/alerts", nil)
+	c.Request.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+	if notModified(c, tag, time.Time{}) {
+		t.Error("notModified() without last modified = true, want false")
+	}
+}
+
+func TestCacheControlMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ok", CacheControlMiddleware(time.Minute), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	r.GET("/missing", CacheControlMiddleware(time.Minute), func(c *gin.Context) {
+		RespondNotFound(c, "no data")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=60" {
+		t.Errorf("Cache-Control = %q, want private, max-age=60", got)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if got := w.Header().Get("Cache-Control"); got != "" {
+		t.Errorf("Cache-Control of an error = %q, want none", got)
+	}
+}
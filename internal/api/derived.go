@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/derived"
+)
+
+// Derived metric config handlers
+
+// GetDerivedMetrics returns the derived metric definitions and the variables expressions can read
+func (h *Handler) GetDerivedMetrics(c *gin.Context) {
+	metrics := h.cfg().DerivedMetrics
+	if metrics == nil {
+		metrics = []config.DerivedMetricConfig{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":     metrics,
+		"total":     len(metrics),
+		"variables": derived.Variables(),
+	})
+}
+
+// AddDerivedMetric adds a derived metric definition
+func (h *Handler) AddDerivedMetric(c *gin.Context) {
+	var input config.DerivedMetricConfig
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	input.Expr = strings.TrimSpace(input.Expr)
+
+	// Compile with the existing definitions so the new metric can read them
+	defs := append(append([]config.DerivedMetricConfig{}, h.cfg().DerivedMetrics...), input)
+	if _, err := derived.Compile(defs); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.cfgMgr.AddDerivedMetric(input); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.cfgMgr.SaveConfig(); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	auditAfter(c, input)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "derived metric added successfully",
+		"metric":  input,
+	})
+}
+
+// DeleteDerivedMetric removes a derived metric definition
+// Metrics still used by other derived metrics can't be removed
+func (h *Handler) DeleteDerivedMetric(c *gin.Context) {
+	name := c.Param("name")
+
+	var remaining []config.DerivedMetricConfig
+	found := false
+	for _, d := range h.cfg().DerivedMetrics {
+		if d.Name == name {
+			found = true
+			auditBefore(c, d)
+			continue
+		}
+		remaining = append(remaining, d)
+	}
+	if !found {
+		RespondNotFound(c, "derived metric not found")
+		return
+	}
+	if _, err := derived.Compile(remaining); err != nil {
+		RespondBadRequest(c, "derived metric is still in use: "+err.Error())
+		return
+	}
+
+	if err := h.cfgMgr.DeleteDerivedMetric(name); err != nil {
+		RespondNotFound(c, err.Error())
+		return
+	}
+
+	if err := h.cfgMgr.SaveConfig(); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "derived metric deleted successfully",
+	})
+}
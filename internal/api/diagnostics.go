@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/collector"
+)
+
+// DumpCapture describes a captured dump in the audit log
+type DumpCapture struct {
+	Target     string `json:"target"`
+	Instance   string `json:"instance"`
+	Dump       string `json:"dump"`
+	Bytes      int64  `json:"bytes"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"` // Set when the transfer broke off after it started
+}
+
+// CaptureThreadDump streams a thread dump of an actuator instance to the client
+// The Accept header is passed on, so text/plain returns the plain-text format
+func (h *Handler) CaptureThreadDump(c *gin.Context) {
+	h.captureDump(c, collector.DumpThread, c.GetHeader("Accept"))
+}
+
+// CaptureHeapDump streams a heap dump (.hprof) of an actuator instance to the client
+func (h *Handler) CaptureHeapDump(c *gin.Context) {
+	h.captureDump(c, collector.DumpHeap, "")
+}
+
+func (h *Handler) captureDump(c *gin.Context, kind, accept string) {
+	// Dumps can pause the JVM and expose heap contents, so only authenticated clients
+	// may capture them from targets that allow it
+	if server := h.cfg().Server; !server.AuthEnabled() {
+		RespondError(c, http.StatusForbidden, "dump capture requires server.api_keys to be configured")
+		return
+	}
+	if h.collectors == nil {
+		RespondError(c, http.StatusServiceUnavailable, "collectors not initialized")
+		return
+	}
+
+	name := c.Param("name")
+	instance := c.Param("id")
+	target, err := h.cfgMgr.GetTarget(name)
+	if err != nil {
+		RespondNotFound(c, err.Error())
+		return
+	}
+	if !target.DiagnosticsEnabled() {
+		RespondError(c, http.StatusForbidden, fmt.Sprintf("dump capture is not enabled for target %s", name))
+		return
+	}
+	start := time.Now()
+
+	dump, err := h.collectors.Dump(c.Request.Context(), name, instance, kind, accept)
+	switch {
+	case errors.Is(err, collector.ErrInstanceNotFound):
+		RespondNotFound(c, err.Error())
+		return
+	case errors.Is(err, collector.ErrNotActuator):
+		RespondBadRequest(c, err.Error())
+		return
+	case err != nil:
+		RespondError(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	defer dump.Close()
+
+	contentType := dump.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ext := "hprof"
+	if kind == collector.DumpThread {
+		ext = "txt"
+		if strings.Contains(contentType, "json") {
+			ext = "json"
+		}
+	}
+	filename := fmt.Sprintf("%s_%s_%s_%s.%s", name, instance, kind, start.In(h.cfg().GetLocation()).Format("20060102_150405"), ext)
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if dump.Size >= 0 {
+		c.Header("Content-Length", strconv.FormatInt(dump.Size, 10))
+	}
+	c.Status(http.StatusOK)
+
+	n, err := io.Copy(c.Writer, dump.Body)
+	capture := DumpCapture{
+		Target:     name,
+		Instance:   instance,
+		Dump:       kind,
+		Bytes:      n,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		capture.Error = err.Error()
+		log.Printf("Failed to stream %s of %s/%s after %d bytes: %v", kind, name, instance, n, err)
+	}
+	auditAfter(c, capture)
+}
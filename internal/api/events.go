@@ -0,0 +1,188 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/analyzer"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Event handlers
+
+// defaultRegressionWindow is how much data on each side of an event is compared
+const defaultRegressionWindow = time.Hour
+
+// maxRegressionWindow limits the data loaded for a regression check
+const maxRegressionWindow = 7 * 24 * time.Hour
+
+type EventsResponse struct {
+	TargetName string         `json:"target_name"`
+	Events     []models.Event `json:"events"`
+	Total      int            `json:"total"`
+}
+
+// buildEvent validates input and creates an event for the target
+func buildEvent(target string, input *models.EventInput) (*models.Event, error) {
+	if input.Type == "" {
+		input.Type = models.EventTypeDeploy
+	}
+	if !models.IsValidEventType(input.Type) {
+		return nil, fmt.Errorf("type must be deploy, rollback, or config")
+	}
+	if len(input.Version) > 100 {
+		return nil, fmt.Errorf("version must be less than 100 characters")
+	}
+	if len(input.Description) > 5000 {
+		return nil, fmt.Errorf("description must be less than 5000 characters")
+	}
+
+	timestamp := time.Now()
+	if input.Timestamp != "" {
+		t, err := time.Parse(time.RFC3339, input.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp format, use RFC3339 (e.g., 2024-01-15T10:00:00Z)")
+		}
+		timestamp = t
+	}
+
+	return &models.Event{
+		TargetName:  target,
+		Type:        input.Type,
+		Version:     input.Version,
+		Description: input.Description,
+		Timestamp:   timestamp,
+	}, nil
+}
+
+func (h *Handler) GetEvents(c *gin.Context) {
+	name := c.Param("name")
+	tr := ParseTimeRange(c.DefaultQuery("range", "168h"), 7*24*time.Hour)
+
+	events, err := h.store.GetEvents(c.Request.Context(), name, tr.From, tr.To)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	if events == nil {
+		events = []models.Event{}
+	}
+
+	c.JSON(http.StatusOK, EventsResponse{
+		TargetName: name,
+		Events:     events,
+		Total:      len(events),
+	})
+}
+
+func (h *Handler) CreateEvent(c *gin.Context) {
+	var input models.EventInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid input: "+err.Error())
+		return
+	}
+
+	event, err := buildEvent(c.Param("name"), &input)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.store.SaveEvent(c.Request.Context(), event); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	auditAfter(c, event)
+	c.JSON(http.StatusCreated, event)
+}
+
+// targetEvent loads an event by ID and checks it belongs to the target in the path
+func (h *Handler) targetEvent(c *gin.Context) (*models.Event, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid event ID")
+		return nil, false
+	}
+
+	event, err := h.store.GetEvent(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return nil, false
+	}
+	if event == nil || event.TargetName != c.Param("name") {
+		RespondNotFound(c, "event not found")
+		return nil, false
+	}
+	return event, true
+}
+
+func (h *Handler) DeleteEvent(c *gin.Context) {
+	event, ok := h.targetEvent(c)
+	if !ok {
+		return
+	}
+	auditBefore(c, event)
+
+	if err := h.store.DeleteEvent(c.Request.Context(), event.ID); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "event deleted"})
+}
+
+// GetEventRegression compares metrics in equal windows before and after an event
+func (h *Handler) GetEventRegression(c *gin.Context) {
+	event, ok := h.targetEvent(c)
+	if !ok {
+		return
+	}
+
+	window := defaultRegressionWindow
+	if w := c.Query("window"); w != "" {
+		d, err := time.ParseDuration(w)
+		if err != nil || d <= 0 || d > maxRegressionWindow {
+			RespondBadRequest(c, "invalid window, use a duration up to 168h (e.g., 1h, 24h)")
+			return
+		}
+		window = d
+	}
+
+	var threshold float64
+	if t := c.Query("threshold"); t != "" {
+		v, err := strconv.ParseFloat(t, 64)
+		if err != nil || v <= 0 {
+			RespondBadRequest(c, "invalid threshold, must be a positive percentage")
+			return
+		}
+		threshold = v
+	}
+
+	before, err := h.store.GetHistory(c.Request.Context(), event.TargetName, event.Timestamp.Add(-window), event.Timestamp)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	afterTo := event.Timestamp.Add(window)
+	if now := time.Now(); afterTo.After(now) {
+		afterTo = now
+	}
+	var after []models.PoolMetrics
+	if afterTo.After(event.Timestamp) {
+		after, err = h.store.GetHistory(c.Request.Context(), event.TargetName, event.Timestamp, afterTo)
+		if err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+	}
+
+	result := analyzer.DetectRegression(*event, before, after, h.cfg().GetLocation(),
+		&analyzer.RegressionOptions{Threshold: threshold})
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,319 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Metrics export formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json" // Newline-delimited JSON, one object per sample
+)
+
+// exportFlushRows is how many rows are written between flushes to the client
+const exportFlushRows = 500
+
+// errExportQuota stops an export at the daily row quota of the API key
+var errExportQuota = errors.New("daily export row quota reached")
+
+// exportColumn is an exported metrics field
+// CSV cells are formatted with format, JSON values keep their type
+type exportColumn struct {
+	name   string
+	format string
+	value  func(d *models.PoolMetrics, loc *time.Location) interface{}
+}
+
+// exportColumns are the exportable fields in their default order
+var exportColumns = []exportColumn{
+	{"target_name", "%s", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.TargetName }},
+	{"timestamp", "%s", func(d *models.PoolMetrics, loc *time.Location) interface{} {
+		return d.Timestamp.In(loc).Format(time.RFC3339)
+	}},
+	{"instance_name", "%s", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.InstanceName }},
+	{"status", "%s", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.Status }},
+	{"active", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.Active }},
+	{"idle", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.Idle }},
+	{"pending", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.Pending }},
+	{"max", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.Max }},
+	{"timeout", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.Timeout }},
+	{"timeout_delta", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.TimeoutDelta }},
+	{"acquire_p50", "%.2f", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.AcquireP50 }},
+	{"acquire_p95", "%.2f", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.AcquireP95 }},
+	{"acquire_p99", "%.2f", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.AcquireP99 }},
+	{"acquire_max", "%.2f", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.AcquireMax }},
+	{"heap_used", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.HeapUsed }},
+	{"heap_max", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.HeapMax }},
+	{"non_heap_used", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.NonHeapUsed }},
+	{"threads_live", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.ThreadsLive }},
+	{"cpu_usage", "%.4f", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.CpuUsage }},
+	{"gc_count", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.GcCount }},
+	{"gc_time", "%.4f", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.GcTime }},
+	{"young_gc_count", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.YoungGcCount }},
+	{"old_gc_count", "%d", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.OldGcCount }},
+	{"gc_pauses_per_min", "%.2f", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.GcPausesPerMin }},
+	{"gc_pause_ms_per_min", "%.2f", func(d *models.PoolMetrics, _ *time.Location) interface{} { return d.GcPauseMsPerMin }},
+}
+
+// parseExportFields returns the columns selected by a comma-separated field list in column order,
+// or all columns if empty; target_name is only included by default in multi-target exports
+func parseExportFields(fields string, multiTarget bool) ([]exportColumn, error) {
+	if strings.TrimSpace(fields) == "" {
+		if multiTarget {
+			return exportColumns, nil
+		}
+		return exportColumns[1:], nil
+	}
+
+	selected := make(map[string]bool)
+	for _, f := range strings.Split(fields, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		known := false
+		for _, col := range exportColumns {
+			if col.name == f {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown field '%s'", f)
+		}
+		selected[f] = true
+	}
+
+	var columns []exportColumn
+	for _, col := range exportColumns {
+		if selected[col.name] {
+			columns = append(columns, col)
+		}
+	}
+	return columns, nil
+}
+
+// metricsExporter streams metrics rows as CSV or NDJSON, gzip-compressed when the client accepts it
+type metricsExporter struct {
+	c       *gin.Context
+	format  string
+	columns []exportColumn
+	loc     *time.Location
+
+	gz   *gzip.Writer
+	csv  *csv.Writer
+	json *json.Encoder
+	rows int
+
+	// Rows the API key may still export today (0: unlimited), counted on Close
+	rowLimit int64
+	onClose  func(rows int64)
+}
+
+// newMetricsExporter validates the format and fields query parameters and writes the response headers
+// Returns nil after responding with an error
+func (h *Handler) newMetricsExporter(c *gin.Context, filenameBase string, multiTarget bool) *metricsExporter {
+	format := c.DefaultQuery("format", ExportFormatCSV)
+	switch format {
+	case ExportFormatCSV, ExportFormatJSON:
+	case "parquet":
+		RespondBadRequest(c, "parquet export is not supported; use csv or json")
+		return nil
+	default:
+		RespondBadRequest(c, "format must be csv or json")
+		return nil
+	}
+	columns, err := parseExportFields(c.Query("fields"), multiTarget)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return nil
+	}
+
+	rowsLeft := h.exportRowsLeft(c)
+	if rowsLeft == 0 {
+		RespondError(c, http.StatusTooManyRequests, "daily export row quota of this API key is used up")
+		return nil
+	}
+
+	loc := h.cfg().GetLocation()
+	e := &metricsExporter{c: c, format: format, columns: columns, loc: loc}
+	if rowsLeft > 0 {
+		e.rowLimit = rowsLeft
+		// Exports longer than the quota are cut off at the remaining rows
+		c.Header("X-Export-Rows-Remaining", strconv.FormatInt(rowsLeft, 10))
+		key := apiKeyName(c)
+		e.onClose = func(rows int64) { h.quotas.AddExportRows(key, rows, loc) }
+	}
+
+	ext, contentType := "csv", "text/csv"
+	if format == ExportFormatJSON {
+		ext, contentType = "ndjson", "application/x-ndjson"
+	}
+	filename := fmt.Sprintf("%s_%s.%s", filenameBase, time.Now().In(loc).Format("20060102_150405"), ext)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Vary", "Accept-Encoding")
+	c.Status(http.StatusOK)
+
+	var w io.Writer = c.Writer
+	if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+		c.Header("Content-Encoding", "gzip")
+		e.gz = gzip.NewWriter(c.Writer)
+		w = e.gz
+	}
+
+	if format == ExportFormatJSON {
+		e.json = json.NewEncoder(w)
+	} else {
+		e.csv = csv.NewWriter(w)
+		header := make([]string, len(columns))
+		for i, col := range columns {
+			header[i] = col.name
+		}
+		e.csv.Write(header)
+	}
+	return e
+}
+
+// Write writes the rows of datapoints, flushing to the client periodically
+func (e *metricsExporter) Write(datapoints []models.PoolMetrics) error {
+	for i := range datapoints {
+		if e.rowLimit > 0 && int64(e.rows) >= e.rowLimit {
+			return errExportQuota
+		}
+		d := &datapoints[i]
+		if e.json != nil {
+			row := make(map[string]interface{}, len(e.columns))
+			for _, col := range e.columns {
+				row[col.name] = col.value(d, e.loc)
+			}
+			if err := e.json.Encode(row); err != nil {
+				return err
+			}
+		} else {
+			record := make([]string, len(e.columns))
+			for j, col := range e.columns {
+				record[j] = fmt.Sprintf(col.format, col.value(d, e.loc))
+			}
+			if err := e.csv.Write(record); err != nil {
+				return err
+			}
+		}
+
+		e.rows++
+		if e.rows%exportFlushRows == 0 {
+			e.flush()
+		}
+	}
+	return nil
+}
+
+func (e *metricsExporter) flush() {
+	if e.csv != nil {
+		e.csv.Flush()
+	}
+	if e.gz != nil {
+		e.gz.Flush()
+	}
+	e.c.Writer.Flush()
+}
+
+// Close writes the remaining rows and the gzip footer
+func (e *metricsExporter) Close() {
+	if e.onClose != nil {
+		e.onClose(int64(e.rows))
+	}
+	if e.csv != nil {
+		e.csv.Flush()
+	}
+	if e.gz != nil {
+		if err := e.gz.Close(); err != nil {
+			log.Printf("Export: failed to finish gzip stream: %v", err)
+		}
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding := strings.TrimSpace(part)
+		name, params, _ := strings.Cut(coding, ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// ExportCSV exports a target's metrics as CSV or NDJSON (?format=json), optionally limited to ?fields=
+func (h *Handler) ExportCSV(c *gin.Context) {
+	name := c.Param("name")
+	instance := c.Query("instance")
+	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
+
+	var datapoints []models.PoolMetrics
+	var err error
+	if instance != "" {
+		datapoints, err = h.store.GetHistoryByInstance(c.Request.Context(), name, instance, tr.From, tr.To)
+	} else {
+		datapoints, err = h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
+	}
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	filenameBase := name
+	if instance != "" {
+		filenameBase = name + "_" + instance
+	}
+	e := h.newMetricsExporter(c, filenameBase, false)
+	if e == nil {
+		return
+	}
+	defer e.Close()
+
+	if err := e.Write(datapoints); err != nil {
+		log.Printf("Export of %s failed: %v", name, err)
+	}
+}
+
+// ExportAllCSV exports the metrics of all configured targets, one target loaded at a time
+func (h *Handler) ExportAllCSV(c *gin.Context) {
+	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
+
+	e := h.newMetricsExporter(c, "all_targets", true)
+	if e == nil {
+		return
+	}
+	defer e.Close()
+
+	scope := scopeOf(c)
+	for _, target := range h.cfg().Targets {
+		if !scope.allows(target.GetProject()) {
+			continue
+		}
+		datapoints, err := h.store.GetHistory(c.Request.Context(), target.Name, tr.From, tr.To)
+		if err != nil {
+			continue
+		}
+		if err := e.Write(datapoints); err != nil {
+			log.Printf("Export of all targets failed at %s: %v", target.Name, err)
+			return
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package api
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/jiin/pondy/internal/models"
 )
 
@@ -90,6 +95,44 @@ func TestDownsampleMetrics_Aggregation(t *testing.T) {
 	}
 }
 
+func TestDownsampleMetrics_KeepsExtremes(t *testing.T) {
+	// A short saturation spike survives averaging as the bucket maximum
+	data := []models.PoolMetrics{
+		{TargetName: "test", Active: 2, Max: 10, Timestamp: time.Now()},
+		{TargetName: "test", Active: 10, Pending: 5, Max: 10, Timestamp: time.Now()},
+		{TargetName: "test", Active: 3, Max: 10, Timestamp: time.Now()},
+		{TargetName: "test", Active: 1, Max: 10, Timestamp: time.Now()},
+	}
+
+	result := downsampleMetrics(data, 1)
+
+	if len(result) != 1 || result[0].Samples != 4 {
+		t.Fatalf("Expected 1 bucket of 4 samples, got %+v", result)
+	}
+	m := result[0]
+	if m.MinValues["active"] != 1 || m.MaxValues["active"] != 10 || m.MaxValues["pending"] != 5 || m.MaxValues["usage"] != 100 {
+		t.Errorf("Expected active 1..10, pending max 5, usage max 100, got min %v max %v", m.MinValues, m.MaxValues)
+	}
+
+	bands := historyBands(result)
+	if len(bands) != 1 || bands[0].Active != (models.Band{Min: 1, Avg: 4, Max: 10}) || bands[0].Usage.Max != 100 {
+		t.Errorf("Expected active band 1/4/10 and usage max 100, got %+v", bands)
+	}
+}
+
+func TestHistoryBands_SingleSample(t *testing.T) {
+	bands := historyBands([]models.PoolMetrics{{Active: 5, Pending: 1, Max: 20}})
+
+	want := models.HistoryBand{
+		Active:  models.Band{Min: 5, Avg: 5, Max: 5},
+		Pending: models.Band{Min: 1, Avg: 1, Max: 1},
+		Usage:   models.Band{Min: 25, Avg: 25, Max: 25},
+	}
+	if len(bands) != 1 || bands[0] != want {
+		t.Errorf("Expected the values as bands, got %+v", bands)
+	}
+}
+
 func TestDownsampleMetrics_EmptyData(t *testing.T) {
 	var data []models.PoolMetrics
 
@@ -120,3 +163,70 @@ func TestDownsampleMetrics_PreservesTargetName(t *testing.T) {
 		t.Errorf("Expected InstanceName='instance-1', got '%s'", result[0].InstanceName)
 	}
 }
+
+func TestParseExportFields(t *testing.T) {
+	all, _ := parseExportFields("", false)
+	if all[0].name != "timestamp" || len(all) != len(exportColumns)-1 {
+		t.Errorf("default single-target columns start with %s, count %d", all[0].name, len(all))
+	}
+	all, _ = parseExportFields("", true)
+	if all[0].name != "target_name" {
+		t.Errorf("default multi-target columns start with %s, want target_name", all[0].name)
+	}
+
+	// Selected fields keep the column order
+	cols, err := parseExportFields("active, timestamp", false)
+	if err != nil || len(cols) != 2 || cols[0].name != "timestamp" || cols[1].name != "active" {
+		t.Errorf("parseExportFields() = %v, %v", cols, err)
+	}
+	if _, err := parseExportFields("active,bogus", false); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                     false,
+		"gzip":                 true,
+		"deflate, gzip;q=0.8":  true,
+		"br, gzip; q=0":        false,
+		"identity":             false,
+		"gzip-custom, deflate": false,
+	}
+	for header, want := range tests {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestMetricsExporter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := []models.PoolMetrics{{TargetName: "orders", InstanceName: "a", Active: 3, CpuUsage: 0.25, Timestamp: ts}}
+	cols, _ := parseExportFields("timestamp,active,cpu_usage", false)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	e := &metricsExporter{c: c, columns: cols, loc: time.UTC, csv: csv.NewWriter(c.Writer)}
+	if err := e.Write(data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	e.Close()
+	if got := strings.TrimSpace(w.Body.String()); got != "2026-01-02T03:04:05Z,3,0.2500" {
+		t.Errorf("csv row = %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	e = &metricsExporter{c: c, columns: cols, loc: time.UTC, json: json.NewEncoder(c.Writer)}
+	e.Write(data)
+	e.Close()
+	var row map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &row); err != nil {
+		t.Fatalf("ndjson row %q: %v", w.Body.String(), err)
+	}
+	if row["active"] != float64(3) || row["timestamp"] != "2026-01-02T03:04:05Z" || len(row) != 3 {
+		t.Errorf("ndjson row = %v", row)
+	}
+}
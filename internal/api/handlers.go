@@ -1,13 +1,16 @@
 package api
 
 import (
-	"encoding/csv"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,7 +19,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jiin/pondy/internal/alerter"
 	"github.com/jiin/pondy/internal/analyzer"
+	"github.com/jiin/pondy/internal/backup"
+	"github.com/jiin/pondy/internal/collector"
 	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/derived"
+	"github.com/jiin/pondy/internal/hooks"
 	"github.com/jiin/pondy/internal/models"
 	"github.com/jiin/pondy/internal/report"
 	"github.com/jiin/pondy/internal/storage"
@@ -32,29 +39,61 @@ const (
 
 // Cache entry for targets response
 type cacheEntry struct {
-	data      TargetsResponse
-	timestamp time.Time
+	data         TargetsResponse
+	timestamp    time.Time
+	version      string    // targetsVersion the response was built at
+	lastModified time.Time // Newest sample of the response
 }
 
 type Handler struct {
-	cfgMgr   *config.Manager
-	store    storage.Storage
-	alertMgr *alerter.Manager
+	cfgMgr     *config.Manager
+	store      storage.Storage
+	alertMgr   *alerter.Manager
+	collectors *collector.Manager
+	usage      *UsageTracker
+	quotas     *QuotaTracker
+	events     *hooks.Publisher
 	cache    *cacheEntry
 	cacheMu  sync.RWMutex
 	cacheTTL time.Duration
+	analyses *analysisCache
+	startedAt    time.Time
+	rateLimiters map[string]*RateLimiter // By name, for the rejection counts in the system status
 }
 
-func NewHandler(cfgMgr *config.Manager, store storage.Storage, alertMgr *alerter.Manager) *Handler {
+func NewHandler(cfgMgr *config.Manager, store storage.Storage, alertMgr *alerter.Manager, collectors *collector.Manager) *Handler {
 	h := &Handler{
-		cfgMgr:   cfgMgr,
-		store:    store,
-		alertMgr: alertMgr,
-		cacheTTL: 2 * time.Second,
-	}
-
-	cfgMgr.OnReload(func(*config.Config) {
+		cfgMgr:     cfgMgr,
+		store:      store,
+		alertMgr:   alertMgr,
+		collectors: collectors,
+		usage:      NewUsageTracker(),
+		quotas:     NewQuotaTracker(),
+		cacheTTL:   2 * time.Second,
+		analyses:   newAnalysisCache(),
+		startedAt:  time.Now(),
+	}
+
+	// Alerts of paused targets are suppressed; nodata rules follow the collection intervals
+	// Project rules and maintenance windows only apply to targets of their project
+	if alertMgr != nil {
+		alertMgr.SetPausedTargets(cfgMgr.Get().PausedTargets())
+		alertMgr.SetTargetIntervals(cfgMgr.Get().TargetIntervals())
+		alertMgr.SetTargetProjects(cfgMgr.Get().TargetProjects())
+		alertMgr.SetTargetGroups(cfgMgr.Get().TargetGroups())
+		alertMgr.SetTargetLabels(cfgMgr.Get().TargetLabels())
+		alertMgr.SetLocation(cfgMgr.Get().GetLocation())
+	}
+	cfgMgr.OnReload(func(cfg *config.Config) {
 		h.InvalidateCache()
+		if alertMgr != nil {
+			alertMgr.SetPausedTargets(cfg.PausedTargets())
+			alertMgr.SetTargetIntervals(cfg.TargetIntervals())
+			alertMgr.SetTargetProjects(cfg.TargetProjects())
+			alertMgr.SetTargetGroups(cfg.TargetGroups())
+			alertMgr.SetTargetLabels(cfg.TargetLabels())
+			alertMgr.SetLocation(cfg.GetLocation())
+		}
 	})
 
 	return h
@@ -68,6 +107,7 @@ func (h *Handler) InvalidateCache() {
 	h.cacheMu.Lock()
 	h.cache = nil
 	h.cacheMu.Unlock()
+	h.analyses.clear()
 }
 
 type TargetsResponse struct {
@@ -80,13 +120,27 @@ func (h *Handler) GetSettings(c *gin.Context) {
 	if timezone == "" {
 		timezone = "Local"
 	}
-	c.JSON(http.StatusOK, gin.H{"timezone": timezone})
+	c.JSON(http.StatusOK, gin.H{"timezone": timezone, "read_only": h.cfg().Server.ReadOnly})
 }
 
 func (h *Handler) GetTargets(c *gin.Context) {
+	// Inactive instances are hidden unless requested; only the default response is cached
+	includeInactive := c.Query("include_inactive") == "true"
+	selector, err := labelSelector(c)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
 	// Check cache with proper locking - copy data while holding lock to avoid race
 	h.cacheMu.RLock()
-	if h.cache != nil && time.Since(h.cache.timestamp) < h.cacheTTL {
+	if !includeInactive && h.cache != nil && time.Since(h.cache.timestamp) < h.cacheTTL {
+		// The cached response is answered with the version it was built at
+		if notModified(c, h.newETag(c).add(h.cache.version).String(), h.cache.lastModified) {
+			h.cacheMu.RUnlock()
+			return
+		}
+
 		// Deep copy the response while holding the lock
 		response := TargetsResponse{
 			Targets: make([]models.TargetStatus, len(h.cache.data.Targets)),
@@ -95,12 +149,27 @@ func (h *Handler) GetTargets(c *gin.Context) {
 		copy(response.Targets, h.cache.data.Targets)
 		copy(response.Groups, h.cache.data.Groups)
 		h.cacheMu.RUnlock()
-		c.JSON(http.StatusOK, response)
+		c.JSON(http.StatusOK, filterTargetsByLabels(scopeTargets(c, response), selector))
 		return
 	}
 	h.cacheMu.RUnlock()
 
+	// The version is read before the metrics, so a response is never older than its ETag
+	version, lastModified, err := h.targetsVersion(c.Request.Context(), includeInactive)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if notModified(c, h.newETag(c).add(version).String(), lastModified) {
+		return
+	}
+
 	var targets []models.TargetStatus
+	collectorHealth := h.collectorHealthByKey()
+	var inactive map[string]map[string]bool
+	if !includeInactive {
+		inactive = h.inactiveInstances(c.Request.Context())
+	}
 
 	for _, t := range h.cfg().Targets {
 		status := models.TargetStatus{
@@ -110,19 +179,21 @@ func (h *Handler) GetTargets(c *gin.Context) {
 		}
 
 		// Build set of valid instance IDs from config
+		// Push targets accept whatever instances their agents report
 		validInstances := make(map[string]bool)
 		for _, inst := range t.GetInstances() {
 			validInstances[inst.ID] = true
 		}
+		isPush := t.Type == config.TargetTypePush
 
 		staleThreshold := h.calculateStaleThreshold(t.Interval)
-		instanceMetrics, err := h.store.GetLatestAllInstances(t.Name)
+		instanceMetrics, err := h.store.GetLatestAllInstances(c.Request.Context(), t.Name)
 
 		// Filter to only include instances that are in current config
 		if err == nil && len(instanceMetrics) > 0 {
 			var filteredMetrics []models.PoolMetrics
 			for _, m := range instanceMetrics {
-				if validInstances[m.InstanceName] {
+				if (isPush || validInstances[m.InstanceName]) && !inactive[t.Name][m.InstanceName] {
 					filteredMetrics = append(filteredMetrics, m)
 				}
 			}
@@ -133,7 +204,7 @@ func (h *Handler) GetTargets(c *gin.Context) {
 			status = h.buildTargetStatus(t.Name, instanceMetrics, staleThreshold)
 			status.Group = t.Group
 		} else {
-			metrics, err := h.store.GetLatest(t.Name)
+			metrics, err := h.store.GetLatest(c.Request.Context(), t.Name)
 			if err == nil && metrics != nil {
 				if time.Since(metrics.Timestamp) > staleThreshold {
 					status.Status = "unknown"
@@ -143,6 +214,14 @@ func (h *Handler) GetTargets(c *gin.Context) {
 				}
 			}
 		}
+		applyBreakerState(&status, collectorHealth)
+		if t.Paused {
+			status.Status = models.TargetStatusPaused
+			status.Paused = true
+			status.PauseReason = t.PauseReason
+		}
+		status.Project = t.GetProject()
+		status.Labels = t.Labels
 
 		targets = append(targets, status)
 	}
@@ -150,11 +229,56 @@ func (h *Handler) GetTargets(c *gin.Context) {
 	groups := h.collectGroups()
 	response := TargetsResponse{Targets: targets, Groups: groups}
 
-	h.cacheMu.Lock()
-	h.cache = &cacheEntry{data: response, timestamp: time.Now()}
-	h.cacheMu.Unlock()
+	if !includeInactive {
+		h.cacheMu.Lock()
+		h.cache = &cacheEntry{data: response, timestamp: time.Now(), version: version, lastModified: lastModified}
+		h.cacheMu.Unlock()
+	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, filterTargetsByLabels(scopeTargets(c, response), selector))
+}
+
+// filterTargetsByLabels keeps the targets having all labels of the selector, and their groups
+func filterTargetsByLabels(response TargetsResponse, selector map[string]string) TargetsResponse {
+	if len(selector) == 0 {
+		return response
+	}
+	filtered := TargetsResponse{Targets: []models.TargetStatus{}}
+	groups := make(map[string]bool)
+	for _, t := range response.Targets {
+		if config.MatchLabels(selector, t.Labels) {
+			filtered.Targets = append(filtered.Targets, t)
+			groups[t.Group] = true
+		}
+	}
+	for _, g := range response.Groups {
+		if groups[g] {
+			filtered.Groups = append(filtered.Groups, g)
+		}
+	}
+	return filtered
+}
+
+// scopeTargets drops the targets outside the request's projects, and groups left without targets
+func scopeTargets(c *gin.Context, response TargetsResponse) TargetsResponse {
+	scope := scopeOf(c)
+	if scope == nil {
+		return response
+	}
+	scoped := TargetsResponse{Targets: []models.TargetStatus{}}
+	groups := make(map[string]bool)
+	for _, t := range response.Targets {
+		if scope.allows(t.Project) {
+			scoped.Targets = append(scoped.Targets, t)
+			groups[t.Group] = true
+		}
+	}
+	for _, g := range response.Groups {
+		if groups[g] {
+			scoped.Groups = append(scoped.Groups, g)
+		}
+	}
+	return scoped
 }
 
 func (h *Handler) calculateStaleThreshold(interval time.Duration) time.Duration {
@@ -174,6 +298,8 @@ func (h *Handler) buildTargetStatus(name string, instanceMetrics []models.PoolMe
 	var totalCpuUsage float64
 	var totalGcCount, totalYoungGcCount, totalOldGcCount int64
 	var totalGcTime float64
+	var totalTimeoutRate, totalGcPausesPerMin, totalGcPauseMsPerMin float64
+	var worstAcquireP50, worstAcquireP95, worstAcquireP99, worstAcquireMax float64
 	var activeInstanceCount int
 	worstStatus := "healthy"
 	allStale := true
@@ -209,6 +335,14 @@ func (h *Handler) buildTargetStatus(name string, instanceMetrics []models.PoolMe
 			totalGcTime += m.GcTime
 			totalYoungGcCount += m.YoungGcCount
 			totalOldGcCount += m.OldGcCount
+			totalTimeoutRate += m.TimeoutRate
+			totalGcPausesPerMin += m.GcPausesPerMin
+			totalGcPauseMsPerMin += m.GcPauseMsPerMin
+			// Acquire times (worst instance)
+			worstAcquireP50 = math.Max(worstAcquireP50, m.AcquireP50)
+			worstAcquireP95 = math.Max(worstAcquireP95, m.AcquireP95)
+			worstAcquireP99 = math.Max(worstAcquireP99, m.AcquireP99)
+			worstAcquireMax = math.Max(worstAcquireMax, m.AcquireMax)
 		}
 
 		instances = append(instances, models.InstanceStatus{
@@ -258,6 +392,15 @@ func (h *Handler) buildTargetStatus(name string, instanceMetrics []models.PoolMe
 				GcTime:       totalGcTime,
 				YoungGcCount: totalYoungGcCount,
 				OldGcCount:   totalOldGcCount,
+				// Rates (sum)
+				TimeoutRate:     totalTimeoutRate,
+				GcPausesPerMin:  totalGcPausesPerMin,
+				GcPauseMsPerMin: totalGcPauseMsPerMin,
+				// Acquire times (max)
+				AcquireP50: worstAcquireP50,
+				AcquireP95: worstAcquireP95,
+				AcquireP99: worstAcquireP99,
+				AcquireMax: worstAcquireMax,
 			}
 		}
 	}
@@ -281,17 +424,27 @@ func (h *Handler) collectGroups() []string {
 
 func (h *Handler) GetInstances(c *gin.Context) {
 	name := c.Param("name")
-	instances, err := h.store.GetInstances(name)
+	instances, err := h.store.GetInstances(c.Request.Context(), name)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
+	if c.Query("include_inactive") != "true" {
+		inactive := h.inactiveInstances(c.Request.Context())[name]
+		active := make([]string, 0, len(instances))
+		for _, inst := range instances {
+			if !inactive[inst] {
+				active = append(active, inst)
+			}
+		}
+		instances = active
+	}
 	c.JSON(http.StatusOK, gin.H{"target_name": name, "instances": instances})
 }
 
 func (h *Handler) GetTargetMetrics(c *gin.Context) {
 	name := c.Param("name")
-	metrics, err := h.store.GetLatest(name)
+	metrics, err := h.store.GetLatest(c.Request.Context(), name)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
@@ -318,11 +471,62 @@ func (h *Handler) GetTargetHistory(c *gin.Context) {
 		limit = 10000
 	}
 
-	var datapoints []models.PoolMetrics
+	// Optional bucket interval, e.g., 5m; combined with limit, the coarser of the two wins
+	var interval time.Duration
+	if s := c.Query("interval"); s != "" {
+		if interval, err = time.ParseDuration(s); err != nil || interval < time.Second {
+			RespondBadRequest(c, "interval must be a duration of at least 1s")
+			return
+		}
+	}
+
+	bucket := historyBucket(tr, limit, interval)
+
+	// Events are annotations on the chart; annotations are opt-in for chart overlays
+	events, err := h.store.GetEvents(c.Request.Context(), name, tr.From, tr.To)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	var annotations []models.Annotation
+	if c.Query("annotations") == "true" {
+		annotations, err = h.store.GetAnnotations(c.Request.Context(), name, tr.From, tr.To)
+		if err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+	}
+
+	// The response changes with new samples, the events and annotations, derived metrics and
+	// the window, which slides by whole buckets or, for raw samples, by the second
+	latest, err := h.store.GetLatestTimestamps(c.Request.Context(), name)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
 	if instance != "" {
-		datapoints, err = h.store.GetHistoryByInstance(name, instance, tr.From, tr.To)
+		latest = map[string]time.Time{instance: latest[instance]}
+	}
+	tag := h.newETag(c)
+	lastModified := tag.addLatest(latest)
+	tag.addJSON(events, annotations, h.cfg().DerivedMetrics)
+	if bucket > 0 {
+		tag.addTime(tr.From.Truncate(bucket))
+	} else {
+		tag.addTime(tr.From.Truncate(time.Second))
+	}
+	if notModified(c, tag.String(), lastModified) {
+		return
+	}
+
+	// Downsampled history is bucketed by the database; limit=0 without interval returns raw samples
+	var datapoints []models.PoolMetrics
+	if bucket > 0 {
+		datapoints, err = h.store.GetHistoryAggregated(c.Request.Context(), name, instance, tr.From, tr.To, bucket)
+	} else if instance != "" {
+		datapoints, err = h.store.GetHistoryByInstance(c.Request.Context(), name, instance, tr.From, tr.To)
 	} else {
-		datapoints, err = h.store.GetHistory(name, tr.From, tr.To)
+		datapoints, err = h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
 	}
 
 	if err != nil {
@@ -330,40 +534,79 @@ func (h *Handler) GetTargetHistory(c *gin.Context) {
 		return
 	}
 
-	// Downsample if limit > 0
-	if limit > 0 {
-		datapoints = downsampleMetrics(datapoints, limit)
+	// Rollups and buckets carry no derived values, compute them from the aggregated fields
+	if metrics, err := derived.Compile(h.cfg().DerivedMetrics); err == nil {
+		derived.Fill(metrics, datapoints)
 	}
 
-	c.JSON(http.StatusOK, models.HistoryResponse{
-		TargetName: name,
-		Datapoints: datapoints,
-	})
+	response := models.HistoryResponse{
+		TargetName:  name,
+		Datapoints:  datapoints,
+		Events:      events,
+		Annotations: annotations,
+	}
+	if bucket > 0 {
+		response.Bands = historyBands(datapoints)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *Handler) GetRecommendations(c *gin.Context) {
 	name := c.Param("name")
 	tr := ParseTimeRangeFromContext(c, DefaultRangeShort)
 
-	datapoints, err := h.store.GetHistory(name, tr.From, tr.To)
+	// Recommendations are tracked when computed, not for every cached response,
+	// and not at all in read-only mode
+	track := !h.cfg().Server.ReadOnly
+	result, err := cachedAnalysis(c.Request.Context(), h, name, analysisKey(c, "recommendations"), func() (*analyzer.AnalysisResult, error) {
+		datapoints, err := h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
+		if err != nil {
+			return nil, err
+		}
+		if len(datapoints) == 0 {
+			return nil, errNoAnalysisData
+		}
+		result := analyzer.Analyze(datapoints, h.cfg().GetLocation())
+		if track {
+			h.trackRecommendations(c.Request.Context(), result, time.Now())
+		}
+		return result, nil
+	})
 	if err != nil {
-		RespondInternalError(c, err)
+		respondAnalysisError(c, err)
 		return
 	}
-	if len(datapoints) == 0 {
-		RespondNoData(c)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) DetectLeaks(c *gin.Context) {
+	name := c.Param("name")
+	tr := ParseTimeRangeFromContext(c, DefaultRangeShort)
+
+	result, err := cachedAnalysis(c.Request.Context(), h, name, analysisKey(c, "leaks"), func() (*analyzer.LeakAnalysisResult, error) {
+		datapoints, err := h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
+		if err != nil {
+			return nil, err
+		}
+		if len(datapoints) == 0 {
+			return nil, errNoAnalysisData
+		}
+		return analyzer.DetectLeaks(datapoints, h.cfg().GetLocation()), nil
+	})
+	if err != nil {
+		respondAnalysisError(c, err)
 		return
 	}
-
-	result := analyzer.Analyze(datapoints, h.cfg().GetLocation())
 	c.JSON(http.StatusOK, result)
 }
 
-func (h *Handler) DetectLeaks(c *gin.Context) {
+// GetTargetHealth returns a composite 0-100 health score with a per-factor breakdown
+func (h *Handler) GetTargetHealth(c *gin.Context) {
 	name := c.Param("name")
 	tr := ParseTimeRangeFromContext(c, DefaultRangeShort)
 
-	datapoints, err := h.store.GetHistory(name, tr.From, tr.To)
+	datapoints, err := h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
@@ -373,127 +616,63 @@ func (h *Handler) DetectLeaks(c *gin.Context) {
 		return
 	}
 
-	result := analyzer.DetectLeaks(datapoints, h.cfg().GetLocation())
-	c.JSON(http.StatusOK, result)
-}
-
-func (h *Handler) ExportCSV(c *gin.Context) {
-	name := c.Param("name")
-	instance := c.Query("instance")
-	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
-
-	var datapoints []models.PoolMetrics
-	var err error
-	if instance != "" {
-		datapoints, err = h.store.GetHistoryByInstance(name, instance, tr.From, tr.To)
-	} else {
-		datapoints, err = h.store.GetHistory(name, tr.From, tr.To)
-	}
+	alerts, err := h.store.GetAlertsByTarget(c.Request.Context(), name, tr.From, tr.To)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
-	loc := h.cfg().GetLocation()
-	filename := fmt.Sprintf("%s_%s.csv", name, time.Now().In(loc).Format("20060102_150405"))
-	if instance != "" {
-		filename = fmt.Sprintf("%s_%s_%s.csv", name, instance, time.Now().In(loc).Format("20060102_150405"))
-	}
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	result := analyzer.CalculateHealth(name, datapoints, alerts, h.cfg().GetLocation())
+	c.JSON(http.StatusOK, result)
+}
 
-	writer := csv.NewWriter(c.Writer)
-	defer writer.Flush()
+func (h *Handler) GetPeakTime(c *gin.Context) {
+	name := c.Param("name")
+	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
 
-	// Header with all fields including GC metrics
-	writer.Write([]string{
-		"timestamp", "instance_name", "status",
-		"active", "idle", "pending", "max", "timeout", "acquire_p99",
-		"heap_used", "heap_max", "non_heap_used", "threads_live", "cpu_usage",
-		"gc_count", "gc_time", "young_gc_count", "old_gc_count",
+	result, err := cachedAnalysis(c.Request.Context(), h, name, analysisKey(c, "peaktime"), func() (*analyzer.PeakTimeResult, error) {
+		datapoints, err := h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
+		if err != nil {
+			return nil, err
+		}
+		if len(datapoints) == 0 {
+			return nil, errNoAnalysisData
+		}
+		return analyzer.AnalyzePeakTime(name, datapoints, h.cfg().GetLocation()), nil
 	})
-
-	for _, d := range datapoints {
-		writer.Write([]string{
-			d.Timestamp.In(loc).Format(time.RFC3339),
-			d.InstanceName,
-			d.Status,
-			fmt.Sprintf("%d", d.Active),
-			fmt.Sprintf("%d", d.Idle),
-			fmt.Sprintf("%d", d.Pending),
-			fmt.Sprintf("%d", d.Max),
-			fmt.Sprintf("%d", d.Timeout),
-			fmt.Sprintf("%.2f", d.AcquireP99),
-			fmt.Sprintf("%d", d.HeapUsed),
-			fmt.Sprintf("%d", d.HeapMax),
-			fmt.Sprintf("%d", d.NonHeapUsed),
-			fmt.Sprintf("%d", d.ThreadsLive),
-			fmt.Sprintf("%.4f", d.CpuUsage),
-			fmt.Sprintf("%d", d.GcCount),
-			fmt.Sprintf("%.4f", d.GcTime),
-			fmt.Sprintf("%d", d.YoungGcCount),
-			fmt.Sprintf("%d", d.OldGcCount),
-		})
+	if err != nil {
+		respondAnalysisError(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, result)
 }
 
-func (h *Handler) ExportAllCSV(c *gin.Context) {
-	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
-	loc := h.cfg().GetLocation()
-
-	filename := fmt.Sprintf("all_targets_%s.csv", time.Now().In(loc).Format("20060102_150405"))
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-
-	writer := csv.NewWriter(c.Writer)
-	defer writer.Flush()
-
-	// Header with all fields including target_name
-	writer.Write([]string{
-		"target_name", "timestamp", "instance_name", "status",
-		"active", "idle", "pending", "max", "timeout", "acquire_p99",
-		"heap_used", "heap_max", "non_heap_used", "threads_live", "cpu_usage",
-		"gc_count", "gc_time", "young_gc_count", "old_gc_count",
-	})
+func (h *Handler) GetForecast(c *gin.Context) {
+	name := c.Param("name")
+	tr := ParseTimeRange(c.DefaultQuery("range", "168h"), 7*24*time.Hour)
 
-	// Export data for all configured targets
-	for _, target := range h.cfg().Targets {
-		datapoints, err := h.store.GetHistory(target.Name, tr.From, tr.To)
+	result, err := cachedAnalysis(c.Request.Context(), h, name, analysisKey(c, "forecast"), func() (*analyzer.ForecastResult, error) {
+		datapoints, err := h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
 		if err != nil {
-			continue
+			return nil, err
 		}
-
-		for _, d := range datapoints {
-			writer.Write([]string{
-				d.TargetName,
-				d.Timestamp.In(loc).Format(time.RFC3339),
-				d.InstanceName,
-				d.Status,
-				fmt.Sprintf("%d", d.Active),
-				fmt.Sprintf("%d", d.Idle),
-				fmt.Sprintf("%d", d.Pending),
-				fmt.Sprintf("%d", d.Max),
-				fmt.Sprintf("%d", d.Timeout),
-				fmt.Sprintf("%.2f", d.AcquireP99),
-				fmt.Sprintf("%d", d.HeapUsed),
-				fmt.Sprintf("%d", d.HeapMax),
-				fmt.Sprintf("%d", d.NonHeapUsed),
-				fmt.Sprintf("%d", d.ThreadsLive),
-				fmt.Sprintf("%.4f", d.CpuUsage),
-				fmt.Sprintf("%d", d.GcCount),
-				fmt.Sprintf("%.4f", d.GcTime),
-				fmt.Sprintf("%d", d.YoungGcCount),
-				fmt.Sprintf("%d", d.OldGcCount),
-			})
+		if len(datapoints) == 0 {
+			return nil, errNoAnalysisData
 		}
+		return analyzer.Forecast(name, datapoints, h.cfg().GetLocation(), h.forecastOptions()), nil
+	})
+	if err != nil {
+		respondAnalysisError(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, result)
 }
 
-func (h *Handler) GetPeakTime(c *gin.Context) {
+func (h *Handler) CompareInstances(c *gin.Context) {
 	name := c.Param("name")
 	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
 
-	datapoints, err := h.store.GetHistory(name, tr.From, tr.To)
+	datapoints, err := h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
@@ -503,27 +682,89 @@ func (h *Handler) GetPeakTime(c *gin.Context) {
 		return
 	}
 
-	result := analyzer.AnalyzePeakTime(name, datapoints, h.cfg().GetLocation())
+	result := analyzer.CompareInstances(name, datapoints, h.cfg().GetLocation())
 	c.JSON(http.StatusOK, result)
 }
 
+// forecastOptions builds forecast settings from the forecast config
+func (h *Handler) forecastOptions() *analyzer.ForecastOptions {
+	fc := h.cfg().Forecast
+	return &analyzer.ForecastOptions{
+		PoolThreshold: fc.GetPoolThreshold(),
+		HeapThreshold: fc.GetHeapThreshold(),
+		Horizon:       fc.GetHorizon(),
+	}
+}
+
+// anomalyOptions builds anomaly detection settings from the target's anomaly config
+func (h *Handler) anomalyOptions(name string) *analyzer.AnomalyOptions {
+	var ac *config.AnomalyConfig
+	if target, err := h.cfgMgr.GetTarget(name); err == nil {
+		ac = target.Anomaly
+	}
+	return &analyzer.AnomalyOptions{
+		Sensitivity: ac.GetSensitivity(),
+		Algorithm:   ac.GetAlgorithm(),
+	}
+}
+
 func (h *Handler) DetectAnomalies(c *gin.Context) {
 	name := c.Param("name")
 	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
-	sensitivity := c.DefaultQuery("sensitivity", "medium")
-
-	datapoints, err := h.store.GetHistory(name, tr.From, tr.To)
-	if err != nil {
-		RespondInternalError(c, err)
+	opts := h.anomalyOptions(name)
+	opts.Sensitivity = c.DefaultQuery("sensitivity", opts.Sensitivity)
+	opts.Algorithm = c.DefaultQuery("algorithm", opts.Algorithm)
+	if !analyzer.IsAnomalyAlgorithm(opts.Algorithm) {
+		RespondBadRequest(c, "algorithm must be zscore, mad, ewma or iqr")
 		return
 	}
-	if len(datapoints) == 0 {
-		RespondNoData(c)
+
+	// Baselines come from the history preceding the analyzed range
+	var baselineRange time.Duration
+	switch method := c.DefaultQuery("method", analyzer.AnomalyMethodGlobal); method {
+	case analyzer.AnomalyMethodGlobal:
+	case analyzer.AnomalyMethodSeasonal:
+		if c.Query("algorithm") != "" && opts.Algorithm != analyzer.AnomalyAlgorithmZScore {
+			RespondBadRequest(c, "seasonal baselines only support the zscore algorithm")
+			return
+		}
+		opts.Method = method
+		opts.Weekday = c.Query("weekday") == "true"
+
+		baselineRange = 7 * 24 * time.Hour
+		if opts.Weekday {
+			baselineRange = 28 * 24 * time.Hour
+		}
+		if d, err := time.ParseDuration(c.Query("baseline")); err == nil && d > 0 {
+			baselineRange = d
+		}
+	default:
+		RespondBadRequest(c, "method must be global or seasonal")
 		return
 	}
 
-	opts := &analyzer.AnomalyOptions{Sensitivity: sensitivity}
-	result := analyzer.DetectAnomaliesWithOptions(name, datapoints, h.cfg().GetLocation(), opts)
+	result, err := cachedAnalysis(c.Request.Context(), h, name, analysisKey(c, "anomalies"), func() (*analyzer.AnomalyResult, error) {
+		datapoints, err := h.store.GetHistory(c.Request.Context(), name, tr.From, tr.To)
+		if err != nil {
+			return nil, err
+		}
+		if len(datapoints) == 0 {
+			return nil, errNoAnalysisData
+		}
+		if baselineRange > 0 {
+			if opts.Baseline, err = h.store.GetHistory(c.Request.Context(), name, tr.From.Add(-baselineRange), tr.From); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.Request.Context().Err(); err != nil {
+			return nil, err
+		}
+		return analyzer.DetectAnomaliesWithOptions(name, datapoints, h.cfg().GetLocation(), opts), nil
+	})
+	if err != nil {
+		respondAnalysisError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, result)
 }
 
@@ -546,13 +787,13 @@ func (h *Handler) ComparePeriods(c *gin.Context) {
 	previousTo := currentFrom
 	previousFrom := previousTo.Add(-duration)
 
-	currentMetrics, err := h.store.GetHistory(name, currentFrom, currentTo)
+	currentMetrics, err := h.store.GetHistory(c.Request.Context(), name, currentFrom, currentTo)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
-	previousMetrics, err := h.store.GetHistory(name, previousFrom, previousTo)
+	previousMetrics, err := h.store.GetHistory(c.Request.Context(), name, previousFrom, previousTo)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
@@ -579,7 +820,7 @@ func (h *Handler) determineStatus(m *models.PoolMetrics) string {
 	if usage > WarningUsageThreshold {
 		return "warning"
 	}
-	if m.Pending > 0 {
+	if m.Pending > 0 || m.TimeoutDelta > 0 {
 		return "warning"
 	}
 	return "healthy"
@@ -590,42 +831,57 @@ func (h *Handler) GenerateReport(c *gin.Context) {
 	rangeParam := c.DefaultQuery("range", "24h")
 	tr := ParseTimeRange(rangeParam, DefaultRangeLong)
 
-	datapoints, err := h.store.GetHistory(name, tr.From, tr.To)
-	if err != nil {
-		RespondInternalError(c, err)
+	reportData, err := h.targetReport(c.Request.Context(), name, rangeParam, tr, nil, true)
+	if errors.Is(err, errNoAnalysisData) {
+		RespondNotFound(c, "no data available for report")
 		return
 	}
-	if len(datapoints) == 0 {
-		RespondNotFound(c, "no data available for report")
+	if err != nil {
+		RespondInternalError(c, err)
 		return
 	}
 
-	loc := h.cfg().GetLocation()
-	recs := analyzer.Analyze(datapoints, loc)
-	leaks := analyzer.DetectLeaks(datapoints, loc)
-	anomalies := analyzer.DetectAnomalies(name, datapoints, loc)
-	peakTime := analyzer.AnalyzePeakTime(name, datapoints, loc)
-
-	reportData := report.BuildReportData(name, rangeParam, datapoints, recs, leaks, anomalies, peakTime, loc)
-
 	htmlBytes, err := report.GenerateHTMLReport(&reportData)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.Data(http.StatusOK, "text/html", htmlBytes)
+	h.respondReport(c, htmlBytes, fmt.Sprintf("pondy_report_%s_%s", name, time.Now().Format("20060102")))
 }
 
+// GenerateCombinedReport reports on ?targets=, the targets of a saved ?view= or all targets,
+// narrowed to those having the ?label= labels
 func (h *Handler) GenerateCombinedReport(c *gin.Context) {
 	targetsParam := c.Query("targets")
 	rangeParam := c.DefaultQuery("range", "24h")
+	selector, err := labelSelector(c)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	// A view scopes the report to its targets and instances, over its range unless one is given
+	var view *models.View
+	if viewParam := c.Query("view"); viewParam != "" {
+		id, err := strconv.ParseInt(viewParam, 10, 64)
+		if err != nil {
+			RespondBadRequest(c, "invalid view ID")
+			return
+		}
+		var ok bool
+		if view, ok = h.visibleView(c, id); !ok {
+			return
+		}
+		rangeParam = viewRange(view, c.Query("range"))
+	}
 
 	tr := ParseTimeRange(rangeParam, DefaultRangeLong)
 
 	var targetNames []string
-	if targetsParam == "" {
+	if view != nil {
+		targetNames = view.TargetNames()
+	} else if targetsParam == "" {
 		// Default to all configured targets
 		for _, t := range h.cfg().Targets {
 			targetNames = append(targetNames, t.Name)
@@ -633,6 +889,10 @@ func (h *Handler) GenerateCombinedReport(c *gin.Context) {
 	} else {
 		targetNames = parseTargetNames(targetsParam)
 	}
+	labels := h.cfg().TargetLabels()
+	targetNames = slices.DeleteFunc(targetNames, func(name string) bool {
+		return !h.targetVisible(c, name) || !config.MatchLabels(selector, labels[name])
+	})
 
 	if len(targetNames) == 0 {
 		RespondBadRequest(c, "no targets configured")
@@ -640,36 +900,98 @@ func (h *Handler) GenerateCombinedReport(c *gin.Context) {
 	}
 
 	loc := h.cfg().GetLocation()
-	var allReports []report.ReportData
 
-	for _, name := range targetNames {
-		datapoints, err := h.store.GetHistory(name, tr.From, tr.To)
-		if err != nil || len(datapoints) == 0 {
-			continue
-		}
-
-		recs := analyzer.Analyze(datapoints, loc)
-		leaks := analyzer.DetectLeaks(datapoints, loc)
-		anomalies := analyzer.DetectAnomalies(name, datapoints, loc)
-		peakTime := analyzer.AnalyzePeakTime(name, datapoints, loc)
-
-		reportData := report.BuildReportData(name, rangeParam, datapoints, recs, leaks, anomalies, peakTime, loc)
-		allReports = append(allReports, reportData)
+	// The analyses stop early enough to render the targets finished before the request deadline
+	ctx := c.Request.Context()
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Until(deadline)/reportRenderShare))
+		defer cancel()
+	}
+	allReports, failures := combinedReports(ctx, targetNames, h.cfg().Report.GetWorkers(),
+		func(ctx context.Context, name string) (report.ReportData, error) {
+			return h.targetReport(ctx, name, rangeParam, tr, view.Target(name), false)
+		})
+	if requestDone(c) {
+		return
 	}
 
-	if len(allReports) == 0 {
+	if len(allReports) == 0 && len(failures) == 0 {
 		RespondNotFound(c, "no data available for any target")
 		return
 	}
 
-	htmlBytes, err := report.GenerateCombinedHTMLReport(allReports, rangeParam, loc)
+	htmlBytes, err := report.GenerateCombinedHTMLReport(allReports, failures, rangeParam, loc)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.Data(http.StatusOK, "text/html", htmlBytes)
+	h.respondReport(c, htmlBytes, fmt.Sprintf("pondy_report_combined_%s", time.Now().Format("20060102")))
+}
+
+// targetReport returns the report data of a target over a range, cached like other analyses
+// A view's selection narrows the samples to its instances; withInstances adds the instance comparison
+func (h *Handler) targetReport(ctx context.Context, name, rangeParam string, tr TimeRange, selection *models.ViewTarget, withInstances bool) (report.ReportData, error) {
+	key := fmt.Sprintf("report?range=%s&instances=%t", rangeParam, withInstances)
+	if selection != nil {
+		key += "&view=" + strings.Join(selection.Instances, ",")
+	}
+
+	return cachedAnalysis(ctx, h, name, key, func() (report.ReportData, error) {
+		datapoints, err := h.store.GetHistory(ctx, name, tr.From, tr.To)
+		if err != nil {
+			return report.ReportData{}, err
+		}
+		if selection != nil {
+			datapoints = viewDatapoints(selection, datapoints)
+		}
+		if len(datapoints) == 0 {
+			return report.ReportData{}, errNoAnalysisData
+		}
+
+		loc := h.cfg().GetLocation()
+		recs := analyzer.Analyze(datapoints, loc)
+		leaks := analyzer.DetectLeaks(datapoints, loc)
+		if err := ctx.Err(); err != nil {
+			return report.ReportData{}, err
+		}
+		anomalies := analyzer.DetectAnomaliesWithOptions(name, datapoints, loc, h.anomalyOptions(name))
+		peakTime := analyzer.AnalyzePeakTime(name, datapoints, loc)
+		forecast := analyzer.Forecast(name, datapoints, loc, h.forecastOptions())
+		if err := ctx.Err(); err != nil {
+			return report.ReportData{}, err
+		}
+		var instances *analyzer.InstanceComparisonResult
+		if withInstances {
+			instances = analyzer.CompareInstances(name, datapoints, loc)
+		}
+
+		return report.BuildReportData(name, rangeParam, datapoints, recs, leaks, anomalies, peakTime, forecast, instances, loc), nil
+	})
+}
+
+// respondReport sends a report as HTML, or as a PDF attachment with ?format=pdf
+func (h *Handler) respondReport(c *gin.Context, htmlBytes []byte, filename string) {
+	switch c.DefaultQuery("format", "html") {
+	case "html":
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Data(http.StatusOK, "text/html", htmlBytes)
+	case "pdf":
+		pdf, err := report.RenderPDF(c.Request.Context(), htmlBytes, h.cfg().Report.ChromePath)
+		if errors.Is(err, report.ErrPDFUnavailable) {
+			RespondError(c, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		if err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", filename))
+		c.Data(http.StatusOK, "application/pdf", pdf)
+	default:
+		RespondBadRequest(c, "format must be html or pdf")
+	}
 }
 
 func parseTargetNames(param string) []string {
@@ -685,28 +1007,144 @@ func parseTargetNames(param string) []string {
 
 // Alert handlers
 
+// AlertsResponse is a page of alerts
+type AlertsResponse struct {
+	Alerts     []models.Alert `json:"alerts"`
+	Total      int            `json:"total"`                 // Alerts matching the filters, across all pages
+	Limit      int            `json:"limit"`
+	Offset     int            `json:"offset"`
+	NextCursor string         `json:"next_cursor,omitempty"` // Pass as cursor to get the next page, empty on the last page
+}
+
+// GetAlerts lists alerts with filtering, sorting and offset or cursor pagination
 func (h *Handler) GetAlerts(c *gin.Context) {
-	status := c.Query("status")
-	limitStr := c.DefaultQuery("limit", "100")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 100
+	q, err := parseAlertQuery(c)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
 	}
-	if limit > 10000 {
-		limit = 10000
+	q.TargetIn = h.visibleTargetNames(c)
+
+	// A relative range slides with the clock; it is validated by the minute
+	var window []time.Time
+	if c.Query("range") != "" {
+		window = append(window, q.From.Truncate(time.Minute))
+	}
+	if h.alertsNotModified(c, window...) {
+		return
 	}
 
-	alerts, err := h.store.GetAlerts(status, limit)
+	// Fetch one extra alert to know whether there is a next page
+	limit := q.Limit
+	q.Limit++
+	alerts, total, err := h.store.QueryAlerts(c.Request.Context(), q)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+	resp := AlertsResponse{Alerts: alerts, Total: total, Limit: limit, Offset: q.Offset}
+	if len(alerts) > limit {
+		resp.Alerts = alerts[:limit]
+		resp.NextCursor = strconv.FormatInt(resp.Alerts[limit-1].ID, 10)
+	}
+	if resp.Alerts == nil {
+		resp.Alerts = []models.Alert{}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseAlertQuery reads alert filters, sorting and pagination from query parameters
+func parseAlertQuery(c *gin.Context) (models.AlertQuery, error) {
+	q := models.AlertQuery{
+		Status:     c.Query("status"),
+		TargetName: c.Query("target"),
+		RuleName:   c.Query("rule"),
+		Flapping:   c.Query("flapping") == "true",
+		Severities: parseTargetNames(c.Query("severity")),
+		SortBy:     c.DefaultQuery("sort", models.AlertSortFiredAt),
+		Limit:      100,
+	}
+
+	if q.Status != "" && q.Status != models.AlertStatusFired && q.Status != models.AlertStatusResolved && q.Status != models.AlertStatusSuppressed {
+		return q, fmt.Errorf("invalid status '%s': use fired, resolved or suppressed", q.Status)
+	}
+	for _, sev := range q.Severities {
+		if sev != models.SeverityInfo && sev != models.SeverityWarning && sev != models.SeverityCritical {
+			return q, fmt.Errorf("invalid severity '%s': use info, warning or critical", sev)
+		}
+	}
+	switch q.SortBy {
+	case models.AlertSortFiredAt, models.AlertSortSeverity, models.AlertSortTarget, models.AlertSortRule:
+	default:
+		return q, fmt.Errorf("invalid sort '%s': use fired_at, severity, target_name or rule_name", q.SortBy)
+	}
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "asc":
+		q.Ascending = true
+	case "desc":
+	default:
+		return q, fmt.Errorf("invalid order '%s': use asc or desc", order)
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err == nil && limit > 0 {
+			q.Limit = limit
+		}
+	}
+	if q.Limit > 10000 {
+		q.Limit = 10000
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		q.AfterID = id
+	} else if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return q, fmt.Errorf("invalid offset")
+		}
+		q.Offset = offset
+	}
+
+	// Time range: range relative to now, or explicit from/to
+	if c.Query("range") != "" {
+		tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
+		q.From, q.To = tr.From, tr.To
+	}
+	bounds := []struct {
+		param string
+		t     *time.Time
+	}{{"from", &q.From}, {"to", &q.To}}
+	for _, b := range bounds {
+		if v := c.Query(b.param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s, use RFC3339 (e.g., 2024-01-15T10:00:00Z)", b.param)
+			}
+			*b.t = parsed
+		}
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return q, fmt.Errorf("to must be after from")
+	}
+
+	return q, nil
 }
 
 func (h *Handler) GetActiveAlerts(c *gin.Context) {
-	alerts, err := h.store.GetAlerts(models.AlertStatusFired, 100)
+	if h.alertsNotModified(c) {
+		return
+	}
+	alerts, _, err := h.store.QueryAlerts(c.Request.Context(), models.AlertQuery{
+		Status:   models.AlertStatusFired,
+		TargetIn: h.visibleTargetNames(c),
+		Limit:    100,
+	})
 	if err != nil {
 		RespondInternalError(c, err)
 		return
@@ -721,12 +1159,12 @@ func (h *Handler) GetAlert(c *gin.Context) {
 		return
 	}
 
-	alert, err := h.store.GetAlert(id)
+	alert, err := h.store.GetAlert(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if alert == nil {
+	if alert == nil || !h.targetVisible(c, alert.TargetName) {
 		RespondNotFound(c, "alert not found")
 		return
 	}
@@ -741,12 +1179,12 @@ func (h *Handler) ResolveAlert(c *gin.Context) {
 		return
 	}
 
-	alert, err := h.store.GetAlert(id)
+	alert, err := h.store.GetAlert(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if alert == nil {
+	if alert == nil || !h.targetVisible(c, alert.TargetName) {
 		RespondNotFound(c, "alert not found")
 		return
 	}
@@ -754,21 +1192,32 @@ func (h *Handler) ResolveAlert(c *gin.Context) {
 		RespondBadRequest(c, "alert already resolved")
 		return
 	}
+	auditBefore(c, alert)
 
 	now := time.Now()
 	alert.Status = models.AlertStatusResolved
 	alert.ResolvedAt = &now
 
-	if err := h.store.UpdateAlert(alert); err != nil {
+	if err := h.store.UpdateAlert(c.Request.Context(), alert); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
+	auditAfter(c, alert)
 	c.JSON(http.StatusOK, alert)
 }
 
 func (h *Handler) GetAlertStats(c *gin.Context) {
-	stats, err := h.store.GetAlertStats()
+	if h.alertsNotModified(c) {
+		return
+	}
+	var stats *models.AlertStats
+	var err error
+	if targets := h.visibleTargetNames(c); targets != nil {
+		stats, err = h.scopedAlertStats(c.Request.Context(), targets)
+	} else {
+		stats, err = h.store.GetAlertStats(c.Request.Context())
+	}
 	if err != nil {
 		RespondInternalError(c, err)
 		return
@@ -776,6 +1225,48 @@ func (h *Handler) GetAlertStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// Alert timeseries defaults
+const (
+	alertTimeseriesDefaultRange = 30 * 24 * time.Hour
+	alertTimeseriesDefaultLimit = 10
+	alertTimeseriesMaxLimit     = 100
+	alertDefaultFlapThreshold   = 3
+)
+
+// GetAlertTimeseries returns alerts fired and resolved per day over ?range= (default: 720h),
+// mean time to resolve by rule and target, the ?limit= noisiest rules and alerts that fired
+// at least ?flap_threshold= times on the same instance
+func (h *Handler) GetAlertTimeseries(c *gin.Context) {
+	tr := ParseTimeRange(c.Query("range"), alertTimeseriesDefaultRange)
+
+	limit := alertTimeseriesDefaultLimit
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		limit = min(v, alertTimeseriesMaxLimit)
+	}
+	threshold := alertDefaultFlapThreshold
+	if v := c.Query("flap_threshold"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 2 {
+			RespondBadRequest(c, "flap_threshold must be an integer of at least 2")
+			return
+		}
+		threshold = n
+	}
+
+	series, err := h.store.GetAlertTimeseries(c.Request.Context(), models.AlertTimeseriesQuery{
+		From:          tr.From,
+		To:            tr.To,
+		TargetIn:      h.visibleTargetNames(c),
+		Limit:         limit,
+		FlapThreshold: threshold,
+	})
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
 func (h *Handler) TestAlert(c *gin.Context) {
 	if h.alertMgr == nil {
 		RespondError(c, http.StatusServiceUnavailable, "alert manager not initialized")
@@ -823,14 +1314,22 @@ func (h *Handler) GetAlertChannels(c *gin.Context) {
 // Alert Rule handlers
 
 func (h *Handler) GetAlertRules(c *gin.Context) {
-	rules, err := h.store.GetAlertRules()
+	rules, err := h.store.GetAlertRules(c.Request.Context())
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
+	rules = slices.DeleteFunc(rules, func(r models.AlertRule) bool {
+		return !scopedVisible(c, r.Project)
+	})
 
-	// Also include config-based rules for reference
-	configRules := h.cfg().Alerting.Rules
+	// Also include config-based rules for reference; with sync_rules, condition rules
+	// are among the database rules with origin config
+	alerting := h.cfg().Alerting
+	configRules := alerting.Rules
+	if alerting.SyncRules {
+		configRules = slices.DeleteFunc(slices.Clone(configRules), func(r config.AlertRule) bool { return r.IsCondition() })
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"rules":        rules,
@@ -845,12 +1344,12 @@ func (h *Handler) GetAlertRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := h.store.GetAlertRule(id)
+	rule, err := h.store.GetAlertRule(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if rule == nil {
+	if rule == nil || !scopedVisible(c, rule.Project) {
 		RespondNotFound(c, "rule not found")
 		return
 	}
@@ -858,6 +1357,38 @@ func (h *Handler) GetAlertRule(c *gin.Context) {
 	c.JSON(http.StatusOK, rule)
 }
 
+// validateEscalation checks a rule's escalate condition and that it raises alerts above the rule's severity
+func validateEscalation(severity, condition, escalateSeverity string, derived []string) error {
+	if condition == "" {
+		if escalateSeverity != "" {
+			return fmt.Errorf("escalate_severity requires an escalate_condition")
+		}
+		return nil
+	}
+	if err := alerter.ValidateCondition(condition, derived...); err != nil {
+		return fmt.Errorf("invalid escalate_condition: %v", err)
+	}
+	rule := config.AlertRule{EscalateSeverity: escalateSeverity}
+	if escalateSeverity != "" && models.SeverityRank(escalateSeverity) == 0 {
+		return fmt.Errorf("escalate_severity must be warning or critical")
+	}
+	if models.SeverityRank(rule.GetEscalateSeverity()) <= models.SeverityRank(severity) {
+		return fmt.Errorf("escalate_severity must be more severe than severity")
+	}
+	return nil
+}
+
+// validateRepeatInterval checks the repeat_interval of a rule (empty = alerting.repeat_interval)
+func validateRepeatInterval(interval string) error {
+	if interval == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(interval); err != nil || d < 0 {
+		return fmt.Errorf("invalid repeat_interval: use Go duration format (e.g., 30m, 1h)")
+	}
+	return nil
+}
+
 func (h *Handler) CreateAlertRule(c *gin.Context) {
 	var input models.AlertRuleInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -883,14 +1414,43 @@ func (h *Handler) CreateAlertRule(c *gin.Context) {
 		return
 	}
 
-	// Validate condition syntax
-	if err := alerter.ValidateCondition(input.Condition); err != nil {
-		RespondBadRequest(c, "invalid condition: "+err.Error())
+	// Validate condition syntax
+	if err := alerter.ValidateCondition(input.Condition, h.cfg().DerivedMetricNames()...); err != nil {
+		RespondBadRequest(c, "invalid condition: "+err.Error())
+		return
+	}
+	if input.ResolveCondition != "" {
+		if err := alerter.ValidateCondition(input.ResolveCondition, h.cfg().DerivedMetricNames()...); err != nil {
+			RespondBadRequest(c, "invalid resolve_condition: "+err.Error())
+			return
+		}
+	}
+	if err := validateEscalation(input.Severity, input.EscalateCondition, input.EscalateSeverity, h.cfg().DerivedMetricNames()); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if err := validateRepeatInterval(input.RepeatInterval); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	// Validate channel routing against configured channels
+	if err := h.cfg().Alerting.Channels.ValidateRuleChannels(input.Channels); err != nil {
+		RespondBadRequest(c, "invalid channels: "+err.Error())
+		return
+	}
+	if err := config.ValidateLabels(input.Labels); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	project, ok := projectForWrite(c, input.Project)
+	if !ok {
 		return
 	}
 
 	// Check if rule with same name exists
-	existing, err := h.store.GetAlertRuleByName(input.Name)
+	existing, err := h.store.GetAlertRuleByName(c.Request.Context(), input.Name)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
@@ -906,14 +1466,23 @@ func (h *Handler) CreateAlertRule(c *gin.Context) {
 	}
 
 	rule := &models.AlertRule{
-		Name:      input.Name,
-		Condition: input.Condition,
-		Severity:  input.Severity,
-		Message:   input.Message,
-		Enabled:   enabled,
-	}
-
-	if err := h.store.SaveAlertRule(rule); err != nil {
+		Name:              input.Name,
+		Condition:         input.Condition,
+		ResolveCondition:  input.ResolveCondition,
+		EscalateCondition: input.EscalateCondition,
+		EscalateSeverity:  input.EscalateSeverity,
+		RepeatInterval:    input.RepeatInterval,
+		Severity:          input.Severity,
+		Message:           input.Message,
+		Enabled:           enabled,
+		Channels:          input.Channels,
+		Project:           project,
+		Labels:            input.Labels,
+		Targets:           input.Targets,
+		Groups:            input.Groups,
+	}
+
+	if err := h.store.SaveAlertRule(c.Request.Context(), rule); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
@@ -923,6 +1492,7 @@ func (h *Handler) CreateAlertRule(c *gin.Context) {
 		h.alertMgr.ReloadRules()
 	}
 
+	auditAfter(c, rule)
 	c.JSON(http.StatusCreated, rule)
 }
 
@@ -958,24 +1528,60 @@ func (h *Handler) UpdateAlertRule(c *gin.Context) {
 	}
 
 	// Validate condition syntax
-	if err := alerter.ValidateCondition(input.Condition); err != nil {
+	if err := alerter.ValidateCondition(input.Condition, h.cfg().DerivedMetricNames()...); err != nil {
 		RespondBadRequest(c, "invalid condition: "+err.Error())
 		return
 	}
+	if input.ResolveCondition != "" {
+		if err := alerter.ValidateCondition(input.ResolveCondition, h.cfg().DerivedMetricNames()...); err != nil {
+			RespondBadRequest(c, "invalid resolve_condition: "+err.Error())
+			return
+		}
+	}
+	if err := validateEscalation(input.Severity, input.EscalateCondition, input.EscalateSeverity, h.cfg().DerivedMetricNames()); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if err := validateRepeatInterval(input.RepeatInterval); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	// Validate channel routing against configured channels
+	if err := h.cfg().Alerting.Channels.ValidateRuleChannels(input.Channels); err != nil {
+		RespondBadRequest(c, "invalid channels: "+err.Error())
+		return
+	}
+	if err := config.ValidateLabels(input.Labels); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
 
-	rule, err := h.store.GetAlertRule(id)
+	rule, err := h.store.GetAlertRule(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if rule == nil {
+	if rule == nil || !scopedVisible(c, rule.Project) {
 		RespondNotFound(c, "rule not found")
 		return
 	}
+	if !scopedWritable(c, rule.Project) {
+		respondNotWritable(c, "rule")
+		return
+	}
+	if rule.IsConfigManaged() {
+		respondConfigManaged(c, rule)
+		return
+	}
+	project, ok := projectForWrite(c, input.Project)
+	if !ok {
+		return
+	}
 
 	// Check if name is being changed to an existing name
 	if input.Name != rule.Name {
-		existing, err := h.store.GetAlertRuleByName(input.Name)
+		existing, err := h.store.GetAlertRuleByName(c.Request.Context(), input.Name)
 		if err != nil {
 			RespondInternalError(c, err)
 			return
@@ -986,15 +1592,25 @@ func (h *Handler) UpdateAlertRule(c *gin.Context) {
 		}
 	}
 
+	auditBefore(c, rule)
 	rule.Name = input.Name
 	rule.Condition = input.Condition
+	rule.ResolveCondition = input.ResolveCondition
+	rule.EscalateCondition = input.EscalateCondition
+	rule.EscalateSeverity = input.EscalateSeverity
+	rule.RepeatInterval = input.RepeatInterval
 	rule.Severity = input.Severity
 	rule.Message = input.Message
+	rule.Channels = input.Channels
+	rule.Project = project
+	rule.Labels = input.Labels
+	rule.Targets = input.Targets
+	rule.Groups = input.Groups
 	if input.Enabled != nil {
 		rule.Enabled = *input.Enabled
 	}
 
-	if err := h.store.UpdateAlertRule(rule); err != nil {
+	if err := h.store.UpdateAlertRule(c.Request.Context(), rule); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
@@ -1004,6 +1620,7 @@ func (h *Handler) UpdateAlertRule(c *gin.Context) {
 		h.alertMgr.ReloadRules()
 	}
 
+	auditAfter(c, rule)
 	c.JSON(http.StatusOK, rule)
 }
 
@@ -1014,17 +1631,26 @@ func (h *Handler) DeleteAlertRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := h.store.GetAlertRule(id)
+	rule, err := h.store.GetAlertRule(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if rule == nil {
+	if rule == nil || !scopedVisible(c, rule.Project) {
 		RespondNotFound(c, "rule not found")
 		return
 	}
+	if !scopedWritable(c, rule.Project) {
+		respondNotWritable(c, "rule")
+		return
+	}
+	if rule.IsConfigManaged() {
+		respondConfigManaged(c, rule)
+		return
+	}
+	auditBefore(c, rule)
 
-	if err := h.store.DeleteAlertRule(id); err != nil {
+	if err := h.store.DeleteAlertRule(c.Request.Context(), id); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
@@ -1044,19 +1670,28 @@ func (h *Handler) ToggleAlertRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := h.store.GetAlertRule(id)
+	rule, err := h.store.GetAlertRule(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if rule == nil {
+	if rule == nil || !scopedVisible(c, rule.Project) {
 		RespondNotFound(c, "rule not found")
 		return
 	}
+	if !scopedWritable(c, rule.Project) {
+		respondNotWritable(c, "rule")
+		return
+	}
+	if rule.IsConfigManaged() {
+		respondConfigManaged(c, rule)
+		return
+	}
 
+	auditBefore(c, rule)
 	rule.Enabled = !rule.Enabled
 
-	if err := h.store.UpdateAlertRule(rule); err != nil {
+	if err := h.store.UpdateAlertRule(c.Request.Context(), rule); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
@@ -1066,6 +1701,7 @@ func (h *Handler) ToggleAlertRule(c *gin.Context) {
 		h.alertMgr.ReloadRules()
 	}
 
+	auditAfter(c, rule)
 	c.JSON(http.StatusOK, rule)
 }
 
@@ -1076,15 +1712,35 @@ func (h *Handler) CreateBackup(c *gin.Context) {
 	timestamp := time.Now().Format("20060102_150405")
 	backupPath := fmt.Sprintf("./data/backups/pondy_backup_%s.db", timestamp)
 
-	if err := h.store.CreateBackup(backupPath); err != nil {
+	if err := h.store.CreateBackup(c.Request.Context(), backupPath); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"message": "backup created",
 		"path":    backupPath,
-	})
+	}
+
+	// Copy to object storage when configured
+	var remoteKey string
+	if h.cfg().Backup.S3.Enabled {
+		mgr, err := backup.NewManager(h.store, h.cfg().Backup.S3)
+		if err != nil {
+			RespondError(c, http.StatusBadGateway, fmt.Sprintf("backup created at %s but upload is misconfigured: %v", backupPath, err))
+			return
+		}
+		key, err := mgr.Upload(c.Request.Context(), backupPath)
+		if err != nil {
+			RespondError(c, http.StatusBadGateway, fmt.Sprintf("backup created at %s but %v", backupPath, err))
+			return
+		}
+		resp["remote_key"] = key
+		remoteKey = key
+	}
+
+	h.events.BackupCompleted("manual", backupPath, remoteKey)
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *Handler) DownloadBackup(c *gin.Context) {
@@ -1092,7 +1748,7 @@ func (h *Handler) DownloadBackup(c *gin.Context) {
 	timestamp := time.Now().Format("20060102_150405")
 	backupPath := fmt.Sprintf("./data/backups/pondy_backup_%s.db", timestamp)
 
-	if err := h.store.CreateBackup(backupPath); err != nil {
+	if err := h.store.CreateBackup(c.Request.Context(), backupPath); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
@@ -1123,7 +1779,7 @@ func (h *Handler) RestoreBackup(c *gin.Context) {
 	}
 
 	// Restore from the uploaded file
-	if err := h.store.RestoreBackup(tempPath); err != nil {
+	if err := h.store.RestoreBackup(c.Request.Context(), tempPath); err != nil {
 		if removeErr := os.Remove(tempPath); removeErr != nil {
 			log.Printf("Warning: failed to remove temp backup file %s: %v", tempPath, removeErr)
 		}
@@ -1136,6 +1792,7 @@ func (h *Handler) RestoreBackup(c *gin.Context) {
 		log.Printf("Warning: failed to remove temp backup file %s: %v", tempPath, err)
 	}
 
+	auditAfter(c, gin.H{"file": file.Filename, "size": file.Size})
 	c.JSON(http.StatusOK, gin.H{
 		"message": "backup restored successfully",
 	})
@@ -1194,6 +1851,35 @@ type TargetConfigRequest struct {
 	Interval  string                   `json:"interval"` // e.g., "10s", "1m"
 	Group     string                   `json:"group,omitempty"`
 	Instances []InstanceConfigRequest  `json:"instances,omitempty"`
+
+	// Project is the team owning the target; defaults to the request's project scope
+	Project string `json:"project,omitempty"`
+
+	// PrometheusSelector overrides the label selector used for Prometheus bootstrap
+	PrometheusSelector string `json:"prometheus_selector,omitempty"`
+
+	// PoolType selects pool metric names: auto, hikari, tomcat, dbcp2, druid, r2dbc, jdbc
+	PoolType string `json:"pool_type,omitempty"`
+
+	// ScrapeMode selects how actuator metrics are read: metrics, prometheus, auto
+	ScrapeMode string `json:"scrape_mode,omitempty"`
+
+	// Discovery resolves instances from Eureka, Consul or DNS instead of endpoint/instances
+	Discovery *config.TargetDiscoveryConfig `json:"discovery,omitempty"`
+
+	// Request settings for flaky networks
+	Timeout      string `json:"timeout,omitempty"`       // e.g., "5s"
+	Retries      int    `json:"retries,omitempty"`       // Retries per failed request
+	RetryBackoff string `json:"retry_backoff,omitempty"` // e.g., "500ms", doubled per retry
+
+	// Anomaly sets the default anomaly detection algorithm and sensitivity
+	Anomaly *config.AnomalyConfig `json:"anomaly,omitempty"`
+
+	// Retention overrides how long raw metrics are kept, e.g., "7d"
+	Retention string `json:"retention,omitempty"`
+
+	// Labels are key/value tags, e.g., {"team": "payments", "env": "prod"}
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 type InstanceConfigRequest struct {
@@ -1215,6 +1901,34 @@ func (r *TargetConfigRequest) ToConfig() (config.TargetConfig, error) {
 		})
 	}
 
+	var timeout, retryBackoff time.Duration
+	if r.Timeout != "" {
+		if timeout, err = time.ParseDuration(r.Timeout); err != nil {
+			return config.TargetConfig{}, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	if r.RetryBackoff != "" {
+		if retryBackoff, err = time.ParseDuration(r.RetryBackoff); err != nil {
+			return config.TargetConfig{}, fmt.Errorf("invalid retry_backoff: %w", err)
+		}
+	}
+	if r.Retries < 0 {
+		return config.TargetConfig{}, fmt.Errorf("retries must not be negative")
+	}
+	if r.Anomaly != nil {
+		if err := r.Anomaly.Validate(); err != nil {
+			return config.TargetConfig{}, err
+		}
+	}
+	if r.Retention != "" {
+		if _, err := config.ParseMaxAge(r.Retention); err != nil {
+			return config.TargetConfig{}, err
+		}
+	}
+	if err := config.ValidateLabels(r.Labels); err != nil {
+		return config.TargetConfig{}, err
+	}
+
 	return config.TargetConfig{
 		Name:      r.Name,
 		Type:      r.Type,
@@ -1222,10 +1936,26 @@ func (r *TargetConfigRequest) ToConfig() (config.TargetConfig, error) {
 		Interval:  interval,
 		Group:     r.Group,
 		Instances: instances,
+		Project:   r.Project,
+
+		PrometheusSelector: r.PrometheusSelector,
+		PoolType:           r.PoolType,
+		ScrapeMode:         r.ScrapeMode,
+		Discovery:          r.Discovery,
+
+		Timeout:      timeout,
+		Retries:      r.Retries,
+		RetryBackoff: retryBackoff,
+
+		Anomaly:   r.Anomaly,
+		Retention: r.Retention,
+		Labels:    r.Labels,
 	}, nil
 }
 
+// targetConfigToResponse converts a target for API responses, masking endpoint credentials
 func targetConfigToResponse(t config.TargetConfig) map[string]interface{} {
+	t = redactTargetURLs(t)
 	instances := make([]map[string]string, 0)
 	for _, inst := range t.Instances {
 		instances = append(instances, map[string]string{
@@ -1241,16 +1971,38 @@ func targetConfigToResponse(t config.TargetConfig) map[string]interface{} {
 		"interval":  t.Interval.String(),
 		"group":     t.Group,
 		"instances": instances,
+		"project":   t.GetProject(),
+
+		"prometheus_selector": t.PrometheusSelector,
+		"pool_type":           t.PoolType,
+		"scrape_mode":         t.ScrapeMode,
+		"discovery":           t.Discovery,
+		"timeout":             t.GetTimeout().String(),
+		"retries":             t.Retries,
+		"retry_backoff":       t.GetRetryBackoff().String(),
+		"paused":              t.Paused,
+		"pause_reason":        t.PauseReason,
+		"reconfigure":         t.Reconfigure != nil && t.Reconfigure.Enabled,
+		"diagnostics":         t.DiagnosticsEnabled(),
+		"anomaly":             t.Anomaly,
+		"retention":           t.Retention,
+		"labels":              t.Labels,
 	}
 }
 
 // GetConfigTargets returns all configured targets
 func (h *Handler) GetConfigTargets(c *gin.Context) {
 	targets := h.cfgMgr.GetAllTargets()
+	cfg := h.cfg()
 
 	result := make([]map[string]interface{}, 0, len(targets))
 	for _, t := range targets {
-		result = append(result, targetConfigToResponse(t))
+		if !scopeOf(c).allows(t.GetProject()) {
+			continue
+		}
+		resp := targetConfigToResponse(t)
+		resp["effective_retention"] = formatRetention(cfg.GetTargetRetention(&t))
+		result = append(result, resp)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"targets": result})
@@ -1269,12 +2021,30 @@ func (h *Handler) AddConfigTarget(c *gin.Context) {
 		return
 	}
 	if req.Type == "" {
-		req.Type = "actuator"
+		req.Type = config.TargetTypeActuator
+	}
+	if req.Type != config.TargetTypeActuator && req.Type != config.TargetTypeJolokia && req.Type != config.TargetTypePush {
+		RespondBadRequest(c, "type must be actuator, jolokia, or push")
+		return
+	}
+	if err := config.ValidatePoolType(req.PoolType); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if err := config.ValidateScrapeMode(req.ScrapeMode); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
 	}
-	if req.Endpoint == "" && len(req.Instances) == 0 {
-		RespondBadRequest(c, "endpoint or instances is required")
+	if req.Type != config.TargetTypePush && req.Endpoint == "" && len(req.Instances) == 0 && req.Discovery == nil {
+		RespondBadRequest(c, "endpoint, instances, or discovery is required")
 		return
 	}
+	if req.Discovery != nil {
+		if err := req.Discovery.Validate(); err != nil {
+			RespondBadRequest(c, err.Error())
+			return
+		}
+	}
 
 	// Validate endpoint URL format (http:// or https://)
 	if req.Endpoint != "" {
@@ -1308,6 +2078,12 @@ func (h *Handler) AddConfigTarget(c *gin.Context) {
 		}
 	}
 
+	project, ok := projectForWrite(c, req.Project)
+	if !ok {
+		return
+	}
+	req.Project = project
+
 	targetCfg, err := req.ToConfig()
 	if err != nil {
 		RespondBadRequest(c, "invalid configuration: "+err.Error())
@@ -1324,6 +2100,10 @@ func (h *Handler) AddConfigTarget(c *gin.Context) {
 		return
 	}
 
+	// Backfill recent history so analyses are useful immediately
+	h.startBackfill(targetCfg)
+
+	auditAfter(c, targetConfigToResponse(targetCfg))
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "target added successfully",
 		"target":  targetConfigToResponse(targetCfg),
@@ -1344,12 +2124,30 @@ func (h *Handler) UpdateConfigTarget(c *gin.Context) {
 		req.Name = name
 	}
 	if req.Type == "" {
-		req.Type = "actuator"
+		req.Type = config.TargetTypeActuator
+	}
+	if req.Type != config.TargetTypeActuator && req.Type != config.TargetTypeJolokia && req.Type != config.TargetTypePush {
+		RespondBadRequest(c, "type must be actuator, jolokia, or push")
+		return
+	}
+	if err := config.ValidatePoolType(req.PoolType); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if err := config.ValidateScrapeMode(req.ScrapeMode); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
 	}
-	if req.Endpoint == "" && len(req.Instances) == 0 {
-		RespondBadRequest(c, "endpoint or instances is required")
+	if req.Type != config.TargetTypePush && req.Endpoint == "" && len(req.Instances) == 0 && req.Discovery == nil {
+		RespondBadRequest(c, "endpoint, instances, or discovery is required")
 		return
 	}
+	if req.Discovery != nil {
+		if err := req.Discovery.Validate(); err != nil {
+			RespondBadRequest(c, err.Error())
+			return
+		}
+	}
 
 	// Validate endpoint URL format (http:// or https://)
 	if req.Endpoint != "" {
@@ -1367,12 +2165,28 @@ func (h *Handler) UpdateConfigTarget(c *gin.Context) {
 		}
 	}
 
+	project, ok := projectForWrite(c, req.Project)
+	if !ok {
+		return
+	}
+	req.Project = project
+
 	targetCfg, err := req.ToConfig()
 	if err != nil {
 		RespondBadRequest(c, "invalid configuration: "+err.Error())
 		return
 	}
 
+	// Endpoints sent back masked keep their credentials, pausing is done through pause/resume,
+	// and reconfiguration and dump capture are only enabled in config.yaml
+	if current, err := h.cfgMgr.GetTarget(name); err == nil {
+		restoreTargetURLs(&targetCfg, *current)
+		targetCfg.Paused, targetCfg.PauseReason = current.Paused, current.PauseReason
+		targetCfg.Reconfigure = current.Reconfigure
+		targetCfg.Diagnostics = current.Diagnostics
+		auditBefore(c, targetConfigToResponse(*current))
+	}
+
 	if err := h.cfgMgr.UpdateTarget(name, targetCfg); err != nil {
 		RespondBadRequest(c, err.Error())
 		return
@@ -1383,6 +2197,7 @@ func (h *Handler) UpdateConfigTarget(c *gin.Context) {
 		return
 	}
 
+	auditAfter(c, targetConfigToResponse(targetCfg))
 	c.JSON(http.StatusOK, gin.H{
 		"message": "target updated successfully",
 		"target":  targetConfigToResponse(targetCfg),
@@ -1393,6 +2208,9 @@ func (h *Handler) UpdateConfigTarget(c *gin.Context) {
 func (h *Handler) DeleteConfigTarget(c *gin.Context) {
 	name := c.Param("name")
 
+	if current, err := h.cfgMgr.GetTarget(name); err == nil {
+		auditBefore(c, targetConfigToResponse(*current))
+	}
 	if err := h.cfgMgr.DeleteTarget(name); err != nil {
 		RespondNotFound(c, err.Error())
 		return
@@ -1409,99 +2227,131 @@ func (h *Handler) DeleteConfigTarget(c *gin.Context) {
 }
 
 // GetAlertingConfig returns the current alerting configuration
+// Webhook URLs, credentials and tokens are masked; see SecretValue for updating them
 func (h *Handler) GetAlertingConfig(c *gin.Context) {
-	cfg := h.cfg()
-	alerting := cfg.Alerting
+	c.JSON(http.StatusOK, alertingConfigResponse(h.cfg().Alerting))
+}
 
+// alertingConfigResponse converts the alerting configuration for API responses, masking secrets
+func alertingConfigResponse(alerting config.AlertingConfig) gin.H {
 	channels := gin.H{
 		"slack": gin.H{
 			"enabled":     alerting.Channels.Slack.Enabled,
-			"webhook_url": alerting.Channels.Slack.WebhookURL,
+			"webhook_url": redactSecret(alerting.Channels.Slack.WebhookURL),
 			"channel":     alerting.Channels.Slack.Channel,
 			"username":    alerting.Channels.Slack.Username,
+			"template":    alerting.Channels.Slack.Template,
 		},
 		"discord": gin.H{
 			"enabled":     alerting.Channels.Discord.Enabled,
-			"webhook_url": alerting.Channels.Discord.WebhookURL,
+			"webhook_url": redactSecret(alerting.Channels.Discord.WebhookURL),
+			"template":    alerting.Channels.Discord.Template,
 		},
 		"mattermost": gin.H{
 			"enabled":     alerting.Channels.Mattermost.Enabled,
-			"webhook_url": alerting.Channels.Mattermost.WebhookURL,
+			"webhook_url": redactSecret(alerting.Channels.Mattermost.WebhookURL),
 			"channel":     alerting.Channels.Mattermost.Channel,
 			"username":    alerting.Channels.Mattermost.Username,
 		},
 		"webhook": gin.H{
-			"enabled": alerting.Channels.Webhook.Enabled,
-			"url":     alerting.Channels.Webhook.URL,
-			"method":  alerting.Channels.Webhook.Method,
-			"headers": alerting.Channels.Webhook.Headers,
+			"enabled":  alerting.Channels.Webhook.Enabled,
+			"url":      redactSecret(alerting.Channels.Webhook.URL),
+			"method":   alerting.Channels.Webhook.Method,
+			"headers":  redactHeaders(alerting.Channels.Webhook.Headers),
+			"template": alerting.Channels.Webhook.Template,
 		},
 		"email": gin.H{
 			"enabled":   alerting.Channels.Email.Enabled,
 			"smtp_host": alerting.Channels.Email.SMTPHost,
 			"smtp_port": alerting.Channels.Email.SMTPPort,
-			"username":  alerting.Channels.Email.Username,
+			"username":  redactSecret(alerting.Channels.Email.Username),
+			"password":  redactSecret(alerting.Channels.Email.Password),
 			"from":      alerting.Channels.Email.From,
 			"to":        alerting.Channels.Email.To,
 			"use_tls":   alerting.Channels.Email.UseTLS,
+			"template":  alerting.Channels.Email.Template,
 		},
 		"notion": gin.H{
 			"enabled":     alerting.Channels.Notion.Enabled,
+			"token":       redactSecret(alerting.Channels.Notion.Token),
 			"database_id": alerting.Channels.Notion.DatabaseID,
 		},
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"enabled":        alerting.Enabled,
-		"check_interval": alerting.CheckInterval.String(),
-		"cooldown":       alerting.Cooldown.String(),
-		"channels":       channels,
-	})
+	return gin.H{
+		"enabled":         alerting.Enabled,
+		"check_interval":  alerting.CheckInterval.String(),
+		"cooldown":        alerting.Cooldown.String(),
+		"repeat_interval": alerting.RepeatInterval.String(),
+		"external_url":    alerting.ExternalURL,
+		"grouping": gin.H{
+			"enabled": alerting.Grouping.Enabled,
+			"window":  alerting.Grouping.GetWindow().String(),
+			"by":      alerting.Grouping.GetBy(),
+		},
+		"channels": channels,
+	}
 }
 
 // UpdateAlertingConfig updates the alerting configuration
+// Omitted fields are left unchanged; secrets are write-only SecretValue fields
 func (h *Handler) UpdateAlertingConfig(c *gin.Context) {
 	var req struct {
-		Enabled       *bool  `json:"enabled"`
-		CheckInterval string `json:"check_interval"`
-		Cooldown      string `json:"cooldown"`
-		Channels      struct {
+		Enabled        *bool   `json:"enabled"`
+		CheckInterval  string  `json:"check_interval"`
+		Cooldown       string  `json:"cooldown"`
+		RepeatInterval string  `json:"repeat_interval"` // "0" disables repeat notifications
+		ExternalURL    *string `json:"external_url"`    // Empty string removes the dashboard links
+		Grouping       struct {
+			Enabled *bool    `json:"enabled"`
+			Window  string   `json:"window"`
+			By      []string `json:"by"`
+		} `json:"grouping"`
+		Channels struct {
 			Slack struct {
-				Enabled    *bool  `json:"enabled"`
-				WebhookURL string `json:"webhook_url"`
-				Channel    string `json:"channel"`
-				Username   string `json:"username"`
+				Enabled    *bool       `json:"enabled"`
+				WebhookURL SecretValue `json:"webhook_url"`
+				Channel    string      `json:"channel"`
+				Username   string      `json:"username"`
+
+				Template *config.NotificationTemplate `json:"template"`
 			} `json:"slack"`
 			Discord struct {
-				Enabled    *bool  `json:"enabled"`
-				WebhookURL string `json:"webhook_url"`
+				Enabled    *bool       `json:"enabled"`
+				WebhookURL SecretValue `json:"webhook_url"`
+
+				Template *config.NotificationTemplate `json:"template"`
 			} `json:"discord"`
 			Mattermost struct {
-				Enabled    *bool  `json:"enabled"`
-				WebhookURL string `json:"webhook_url"`
-				Channel    string `json:"channel"`
-				Username   string `json:"username"`
+				Enabled    *bool       `json:"enabled"`
+				WebhookURL SecretValue `json:"webhook_url"`
+				Channel    string      `json:"channel"`
+				Username   string      `json:"username"`
 			} `json:"mattermost"`
 			Webhook struct {
 				Enabled *bool             `json:"enabled"`
-				URL     string            `json:"url"`
+				URL     SecretValue       `json:"url"`
 				Method  string            `json:"method"`
-				Headers map[string]string `json:"headers"`
+				Headers map[string]string `json:"headers"` // Masked values keep the current header value
+
+				Template *config.NotificationTemplate `json:"template"`
 			} `json:"webhook"`
 			Email struct {
-				Enabled  *bool    `json:"enabled"`
-				SMTPHost string   `json:"smtp_host"`
-				SMTPPort int      `json:"smtp_port"`
-				Username string   `json:"username"`
-				Password string   `json:"password"`
-				From     string   `json:"from"`
-				To       []string `json:"to"`
-				UseTLS   *bool    `json:"use_tls"`
+				Enabled  *bool       `json:"enabled"`
+				SMTPHost string      `json:"smtp_host"`
+				SMTPPort int         `json:"smtp_port"`
+				Username SecretValue `json:"username"`
+				Password SecretValue `json:"password"`
+				From     string      `json:"from"`
+				To       []string    `json:"to"`
+				UseTLS   *bool       `json:"use_tls"`
+
+				Template *config.NotificationTemplate `json:"template"`
 			} `json:"email"`
 			Notion struct {
-				Enabled    *bool  `json:"enabled"`
-				Token      string `json:"token"`
-				DatabaseID string `json:"database_id"`
+				Enabled    *bool       `json:"enabled"`
+				Token      SecretValue `json:"token"`
+				DatabaseID string      `json:"database_id"`
 			} `json:"notion"`
 		} `json:"channels"`
 	}
@@ -1510,104 +2360,144 @@ func (h *Handler) UpdateAlertingConfig(c *gin.Context) {
 		RespondBadRequest(c, "invalid request body: "+err.Error())
 		return
 	}
+	auditBefore(c, alertingConfigResponse(h.cfg().Alerting))
 
-	// Update config in memory
-	cfg := h.cfg()
+	// Apply changes to a copy of the alerting config and swap it in atomically
+	alerting, err := h.cfgMgr.UpdateAlerting(func(a *config.AlertingConfig) error {
+		if req.Enabled != nil {
+			a.Enabled = *req.Enabled
+		}
+		if req.CheckInterval != "" {
+			d, err := time.ParseDuration(req.CheckInterval)
+			if err != nil {
+				return fmt.Errorf("invalid check_interval: %v", err)
+			}
+			a.CheckInterval = d
+		}
+		if req.Cooldown != "" {
+			d, err := time.ParseDuration(req.Cooldown)
+			if err != nil {
+				return fmt.Errorf("invalid cooldown: %v", err)
+			}
+			a.Cooldown = d
+		}
+		if req.RepeatInterval != "" {
+			d, err := time.ParseDuration(req.RepeatInterval)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid repeat_interval: use Go duration format (e.g., 30m, 1h)")
+			}
+			a.RepeatInterval = d
+		}
+		if req.ExternalURL != nil {
+			a.ExternalURL = strings.TrimSpace(*req.ExternalURL)
+			if err := a.ValidateExternalURL(); err != nil {
+				return err
+			}
+		}
 
-	if req.Enabled != nil {
-		cfg.Alerting.Enabled = *req.Enabled
-	}
-	if req.CheckInterval != "" {
-		if d, err := time.ParseDuration(req.CheckInterval); err == nil {
-			cfg.Alerting.CheckInterval = d
+		if req.Grouping.Enabled != nil {
+			a.Grouping.Enabled = *req.Grouping.Enabled
 		}
-	}
-	if req.Cooldown != "" {
-		if d, err := time.ParseDuration(req.Cooldown); err == nil {
-			cfg.Alerting.Cooldown = d
+		if req.Grouping.Window != "" {
+			d, err := time.ParseDuration(req.Grouping.Window)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid grouping window: use Go duration format (e.g., 30s, 1m)")
+			}
+			a.Grouping.Window = d
+		}
+		if req.Grouping.By != nil {
+			a.Grouping.By = req.Grouping.By
+		}
+		if err := a.Grouping.Validate(); err != nil {
+			return err
 		}
-	}
 
-	// Update channels
-	if req.Channels.Slack.Enabled != nil {
-		cfg.Alerting.Channels.Slack.Enabled = *req.Channels.Slack.Enabled
-	}
-	if req.Channels.Slack.WebhookURL != "" {
-		cfg.Alerting.Channels.Slack.WebhookURL = req.Channels.Slack.WebhookURL
-	}
-	if req.Channels.Slack.Channel != "" {
-		cfg.Alerting.Channels.Slack.Channel = req.Channels.Slack.Channel
-	}
-	if req.Channels.Slack.Username != "" {
-		cfg.Alerting.Channels.Slack.Username = req.Channels.Slack.Username
-	}
+		// Update channels
+		if req.Channels.Slack.Enabled != nil {
+			a.Channels.Slack.Enabled = *req.Channels.Slack.Enabled
+		}
+		a.Channels.Slack.WebhookURL = req.Channels.Slack.WebhookURL.Apply(a.Channels.Slack.WebhookURL)
+		if req.Channels.Slack.Channel != "" {
+			a.Channels.Slack.Channel = req.Channels.Slack.Channel
+		}
+		if req.Channels.Slack.Username != "" {
+			a.Channels.Slack.Username = req.Channels.Slack.Username
+		}
 
-	if req.Channels.Discord.Enabled != nil {
-		cfg.Alerting.Channels.Discord.Enabled = *req.Channels.Discord.Enabled
-	}
-	if req.Channels.Discord.WebhookURL != "" {
-		cfg.Alerting.Channels.Discord.WebhookURL = req.Channels.Discord.WebhookURL
-	}
+		if req.Channels.Discord.Enabled != nil {
+			a.Channels.Discord.Enabled = *req.Channels.Discord.Enabled
+		}
+		a.Channels.Discord.WebhookURL = req.Channels.Discord.WebhookURL.Apply(a.Channels.Discord.WebhookURL)
 
-	if req.Channels.Mattermost.Enabled != nil {
-		cfg.Alerting.Channels.Mattermost.Enabled = *req.Channels.Mattermost.Enabled
-	}
-	if req.Channels.Mattermost.WebhookURL != "" {
-		cfg.Alerting.Channels.Mattermost.WebhookURL = req.Channels.Mattermost.WebhookURL
-	}
-	if req.Channels.Mattermost.Channel != "" {
-		cfg.Alerting.Channels.Mattermost.Channel = req.Channels.Mattermost.Channel
-	}
-	if req.Channels.Mattermost.Username != "" {
-		cfg.Alerting.Channels.Mattermost.Username = req.Channels.Mattermost.Username
-	}
+		if req.Channels.Mattermost.Enabled != nil {
+			a.Channels.Mattermost.Enabled = *req.Channels.Mattermost.Enabled
+		}
+		a.Channels.Mattermost.WebhookURL = req.Channels.Mattermost.WebhookURL.Apply(a.Channels.Mattermost.WebhookURL)
+		if req.Channels.Mattermost.Channel != "" {
+			a.Channels.Mattermost.Channel = req.Channels.Mattermost.Channel
+		}
+		if req.Channels.Mattermost.Username != "" {
+			a.Channels.Mattermost.Username = req.Channels.Mattermost.Username
+		}
 
-	if req.Channels.Webhook.Enabled != nil {
-		cfg.Alerting.Channels.Webhook.Enabled = *req.Channels.Webhook.Enabled
-	}
-	if req.Channels.Webhook.URL != "" {
-		cfg.Alerting.Channels.Webhook.URL = req.Channels.Webhook.URL
-	}
-	if req.Channels.Webhook.Method != "" {
-		cfg.Alerting.Channels.Webhook.Method = req.Channels.Webhook.Method
-	}
-	if req.Channels.Webhook.Headers != nil {
-		cfg.Alerting.Channels.Webhook.Headers = req.Channels.Webhook.Headers
-	}
+		if req.Channels.Webhook.Enabled != nil {
+			a.Channels.Webhook.Enabled = *req.Channels.Webhook.Enabled
+		}
+		a.Channels.Webhook.URL = req.Channels.Webhook.URL.Apply(a.Channels.Webhook.URL)
+		if req.Channels.Webhook.Method != "" {
+			a.Channels.Webhook.Method = req.Channels.Webhook.Method
+		}
+		if req.Channels.Webhook.Headers != nil {
+			a.Channels.Webhook.Headers = restoreHeaders(req.Channels.Webhook.Headers, a.Channels.Webhook.Headers)
+		}
 
-	if req.Channels.Email.Enabled != nil {
-		cfg.Alerting.Channels.Email.Enabled = *req.Channels.Email.Enabled
-	}
-	if req.Channels.Email.SMTPHost != "" {
-		cfg.Alerting.Channels.Email.SMTPHost = req.Channels.Email.SMTPHost
-	}
-	if req.Channels.Email.SMTPPort > 0 {
-		cfg.Alerting.Channels.Email.SMTPPort = req.Channels.Email.SMTPPort
-	}
-	if req.Channels.Email.Username != "" {
-		cfg.Alerting.Channels.Email.Username = req.Channels.Email.Username
-	}
-	if req.Channels.Email.Password != "" {
-		cfg.Alerting.Channels.Email.Password = req.Channels.Email.Password
-	}
-	if req.Channels.Email.From != "" {
-		cfg.Alerting.Channels.Email.From = req.Channels.Email.From
-	}
-	if req.Channels.Email.To != nil {
-		cfg.Alerting.Channels.Email.To = req.Channels.Email.To
-	}
-	if req.Channels.Email.UseTLS != nil {
-		cfg.Alerting.Channels.Email.UseTLS = *req.Channels.Email.UseTLS
-	}
+		if req.Channels.Email.Enabled != nil {
+			a.Channels.Email.Enabled = *req.Channels.Email.Enabled
+		}
+		if req.Channels.Email.SMTPHost != "" {
+			a.Channels.Email.SMTPHost = req.Channels.Email.SMTPHost
+		}
+		if req.Channels.Email.SMTPPort > 0 {
+			a.Channels.Email.SMTPPort = req.Channels.Email.SMTPPort
+		}
+		a.Channels.Email.Username = req.Channels.Email.Username.Apply(a.Channels.Email.Username)
+		a.Channels.Email.Password = req.Channels.Email.Password.Apply(a.Channels.Email.Password)
+		if req.Channels.Email.From != "" {
+			a.Channels.Email.From = req.Channels.Email.From
+		}
+		if req.Channels.Email.To != nil {
+			a.Channels.Email.To = req.Channels.Email.To
+		}
+		if req.Channels.Email.UseTLS != nil {
+			a.Channels.Email.UseTLS = *req.Channels.Email.UseTLS
+		}
 
-	if req.Channels.Notion.Enabled != nil {
-		cfg.Alerting.Channels.Notion.Enabled = *req.Channels.Notion.Enabled
-	}
-	if req.Channels.Notion.Token != "" {
-		cfg.Alerting.Channels.Notion.Token = req.Channels.Notion.Token
-	}
-	if req.Channels.Notion.DatabaseID != "" {
-		cfg.Alerting.Channels.Notion.DatabaseID = req.Channels.Notion.DatabaseID
+		if req.Channels.Notion.Enabled != nil {
+			a.Channels.Notion.Enabled = *req.Channels.Notion.Enabled
+		}
+		a.Channels.Notion.Token = req.Channels.Notion.Token.Apply(a.Channels.Notion.Token)
+		if req.Channels.Notion.DatabaseID != "" {
+			a.Channels.Notion.DatabaseID = req.Channels.Notion.DatabaseID
+		}
+
+		// Templates: an empty object resets the channel to the built-in template
+		if req.Channels.Slack.Template != nil {
+			a.Channels.Slack.Template = templateOrNil(req.Channels.Slack.Template)
+		}
+		if req.Channels.Discord.Template != nil {
+			a.Channels.Discord.Template = templateOrNil(req.Channels.Discord.Template)
+		}
+		if req.Channels.Webhook.Template != nil {
+			a.Channels.Webhook.Template = templateOrNil(req.Channels.Webhook.Template)
+		}
+		if req.Channels.Email.Template != nil {
+			a.Channels.Email.Template = templateOrNil(req.Channels.Email.Template)
+		}
+		return alerter.ValidateChannelTemplates(&a.Channels)
+	})
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
 	}
 
 	// Save to file
@@ -1616,6 +2506,12 @@ func (h *Handler) UpdateAlertingConfig(c *gin.Context) {
 		return
 	}
 
+	// Propagate the new configuration to the alert manager
+	if h.alertMgr != nil {
+		h.alertMgr.UpdateConfig(&alerting)
+	}
+	auditAfter(c, alertingConfigResponse(alerting))
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "alerting configuration updated successfully",
 	})
@@ -1628,12 +2524,77 @@ type MaintenanceWindowsResponse struct {
 	Total   int                        `json:"total"`
 }
 
+// maxCronWindowMinutes limits the length of each cron-scheduled window to a week
+const maxCronWindowMinutes = 7 * 24 * 60
+
+// maintenanceTimes validates the schedule of a maintenance window and parses its start and end time
+// Cron windows may leave both empty to apply from now on without end
+func maintenanceTimes(input *models.MaintenanceWindowInput) (time.Time, time.Time, error) {
+	var start, end time.Time
+	if input.Cron != "" {
+		if err := models.ValidateCron(input.Cron); err != nil {
+			return start, end, fmt.Errorf("invalid cron: %v", err)
+		}
+		if input.DurationMinutes <= 0 || input.DurationMinutes > maxCronWindowMinutes {
+			return start, end, fmt.Errorf("duration_minutes must be between 1 and %d with cron", maxCronWindowMinutes)
+		}
+	}
+
+	var err error
+	if input.StartTime != "" || input.Cron == "" {
+		if start, err = time.Parse(time.RFC3339, input.StartTime); err != nil {
+			return start, end, fmt.Errorf("invalid start_time format, use RFC3339 (e.g., 2024-01-15T10:00:00Z)")
+		}
+	}
+	if input.EndTime != "" || input.Cron == "" {
+		if end, err = time.Parse(time.RFC3339, input.EndTime); err != nil {
+			return start, end, fmt.Errorf("invalid end_time format, use RFC3339 (e.g., 2024-01-15T12:00:00Z)")
+		}
+	}
+
+	// Weekly windows only use the time of day
+	if (!input.Recurring || input.Cron != "") && !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return start, end, fmt.Errorf("end_time must be after start_time")
+	}
+	return start, end, nil
+}
+
+// validWindowTargets checks the target selectors of a maintenance window input
+// Plain target names must be visible to the request; patterns and groups only match visible targets
+// through the window's project. On failure an error response is sent and false returned
+func (h *Handler) validWindowTargets(c *gin.Context, input *models.MaintenanceWindowInput) bool {
+	patterns := input.Targets
+	if input.TargetName != "" {
+		patterns = append([]string{input.TargetName}, patterns...)
+	}
+	for _, p := range patterns {
+		if err := models.ValidateTargetPattern(p); err != nil {
+			RespondBadRequest(c, err.Error())
+			return false
+		}
+		if !models.IsTargetPattern(p) && !h.targetVisible(c, p) {
+			RespondNotFound(c, fmt.Sprintf("target '%s' not found", p))
+			return false
+		}
+	}
+	for _, g := range input.Groups {
+		if g == "" || strings.Contains(g, ",") {
+			RespondBadRequest(c, fmt.Sprintf("invalid group '%s'", g))
+			return false
+		}
+	}
+	return true
+}
+
 func (h *Handler) GetMaintenanceWindows(c *gin.Context) {
-	windows, err := h.store.GetAllMaintenanceWindows()
+	windows, err := h.store.GetAllMaintenanceWindows(c.Request.Context())
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
+	windows = slices.DeleteFunc(windows, func(w models.MaintenanceWindow) bool {
+		return !scopedVisible(c, w.Project)
+	})
 
 	if windows == nil {
 		windows = []models.MaintenanceWindow{}
@@ -1646,11 +2607,14 @@ func (h *Handler) GetMaintenanceWindows(c *gin.Context) {
 }
 
 func (h *Handler) GetActiveMaintenanceWindows(c *gin.Context) {
-	windows, err := h.store.GetActiveMaintenanceWindows()
+	windows, err := h.store.GetActiveMaintenanceWindows(c.Request.Context(), time.Now().In(h.cfg().GetLocation()))
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
+	windows = slices.DeleteFunc(windows, func(w models.MaintenanceWindow) bool {
+		return !scopedVisible(c, w.Project)
+	})
 
 	if windows == nil {
 		windows = []models.MaintenanceWindow{}
@@ -1669,12 +2633,12 @@ func (h *Handler) GetMaintenanceWindow(c *gin.Context) {
 		return
 	}
 
-	window, err := h.store.GetMaintenanceWindow(id)
+	window, err := h.store.GetMaintenanceWindow(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if window == nil {
+	if window == nil || !scopedVisible(c, window.Project) {
 		RespondNotFound(c, "maintenance window not found")
 		return
 	}
@@ -1689,22 +2653,17 @@ func (h *Handler) CreateMaintenanceWindow(c *gin.Context) {
 		return
 	}
 
-	// Parse times
-	startTime, err := time.Parse(time.RFC3339, input.StartTime)
+	startTime, endTime, err := maintenanceTimes(&input)
 	if err != nil {
-		RespondBadRequest(c, "invalid start_time format, use RFC3339 (e.g., 2024-01-15T10:00:00Z)")
+		RespondBadRequest(c, err.Error())
 		return
 	}
 
-	endTime, err := time.Parse(time.RFC3339, input.EndTime)
-	if err != nil {
-		RespondBadRequest(c, "invalid end_time format, use RFC3339 (e.g., 2024-01-15T12:00:00Z)")
+	project, ok := projectForWrite(c, input.Project)
+	if !ok {
 		return
 	}
-
-	// Validate time range
-	if !input.Recurring && endTime.Before(startTime) {
-		RespondBadRequest(c, "end_time must be after start_time")
+	if !h.validWindowTargets(c, &input) {
 		return
 	}
 
@@ -1712,17 +2671,83 @@ func (h *Handler) CreateMaintenanceWindow(c *gin.Context) {
 		Name:        input.Name,
 		Description: input.Description,
 		TargetName:  input.TargetName,
+		Targets:     input.Targets,
+		Groups:      input.Groups,
 		StartTime:   startTime,
 		EndTime:     endTime,
-		Recurring:   input.Recurring,
+		Recurring:   input.Recurring || input.Cron != "",
 		DaysOfWeek:  input.DaysOfWeek,
+		Project:     project,
+
+		Cron:            input.Cron,
+		DurationMinutes: input.DurationMinutes,
+	}
+
+	if err := h.store.SaveMaintenanceWindow(c.Request.Context(), window); err != nil {
+		RespondInternalError(c, err)
+		return
 	}
 
-	if err := h.store.SaveMaintenanceWindow(window); err != nil {
+	auditAfter(c, window)
+	c.JSON(http.StatusCreated, window)
+}
+
+// maxQuickMaintenance limits how long a quick maintenance window silences a target
+const maxQuickMaintenance = 24 * time.Hour
+
+// parseQuickDuration parses minutes ("30") or a Go duration ("2h")
+func parseQuickDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if minutes, convErr := strconv.Atoi(s); convErr == nil {
+		d = time.Duration(minutes) * time.Minute
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 || d > maxQuickMaintenance {
+		return 0, fmt.Errorf("duration must be minutes or a Go duration (e.g., 30m, 2h) of at most %v", maxQuickMaintenance)
+	}
+	return d, nil
+}
+
+// CreateQuickMaintenance starts a maintenance window for a target right away, for firefighting
+func (h *Handler) CreateQuickMaintenance(c *gin.Context) {
+	var input models.QuickMaintenanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid input: "+err.Error())
+		return
+	}
+	if _, err := h.cfgMgr.GetTarget(input.Target); err != nil || !h.targetVisible(c, input.Target) {
+		RespondNotFound(c, fmt.Sprintf("target '%s' not found", input.Target))
+		return
+	}
+	duration, err := parseQuickDuration(input.Duration)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	// Scoped keys create the window in the target's project so they can end it early
+	var project string
+	if scopeOf(c) != nil {
+		project = projectOf(h.cfg(), input.Target)
+	}
+
+	now := time.Now()
+	window := &models.MaintenanceWindow{
+		Name:        fmt.Sprintf("Silence %s for %v", input.Target, duration),
+		Description: input.Reason,
+		TargetName:  input.Target,
+		StartTime:   now,
+		EndTime:     now.Add(duration),
+		Project:     project,
+	}
+	if err := h.store.SaveMaintenanceWindow(c.Request.Context(), window); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
+	auditAfter(c, window)
 	c.JSON(http.StatusCreated, window)
 }
 
@@ -1733,15 +2758,19 @@ func (h *Handler) UpdateMaintenanceWindow(c *gin.Context) {
 		return
 	}
 
-	existing, err := h.store.GetMaintenanceWindow(id)
+	existing, err := h.store.GetMaintenanceWindow(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if existing == nil {
+	if existing == nil || !scopedVisible(c, existing.Project) {
 		RespondNotFound(c, "maintenance window not found")
 		return
 	}
+	if !scopedWritable(c, existing.Project) {
+		respondNotWritable(c, "maintenance window")
+		return
+	}
 
 	var input models.MaintenanceWindowInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -1749,38 +2778,40 @@ func (h *Handler) UpdateMaintenanceWindow(c *gin.Context) {
 		return
 	}
 
-	// Parse times
-	startTime, err := time.Parse(time.RFC3339, input.StartTime)
+	startTime, endTime, err := maintenanceTimes(&input)
 	if err != nil {
-		RespondBadRequest(c, "invalid start_time format, use RFC3339")
+		RespondBadRequest(c, err.Error())
 		return
 	}
 
-	endTime, err := time.Parse(time.RFC3339, input.EndTime)
-	if err != nil {
-		RespondBadRequest(c, "invalid end_time format, use RFC3339")
+	project, ok := projectForWrite(c, input.Project)
+	if !ok {
 		return
 	}
-
-	// Validate time range
-	if !input.Recurring && endTime.Before(startTime) {
-		RespondBadRequest(c, "end_time must be after start_time")
+	if !h.validWindowTargets(c, &input) {
 		return
 	}
 
+	auditBefore(c, existing)
 	existing.Name = input.Name
 	existing.Description = input.Description
 	existing.TargetName = input.TargetName
+	existing.Targets = input.Targets
+	existing.Groups = input.Groups
 	existing.StartTime = startTime
 	existing.EndTime = endTime
-	existing.Recurring = input.Recurring
+	existing.Recurring = input.Recurring || input.Cron != ""
 	existing.DaysOfWeek = input.DaysOfWeek
+	existing.Project = project
+	existing.Cron = input.Cron
+	existing.DurationMinutes = input.DurationMinutes
 
-	if err := h.store.UpdateMaintenanceWindow(existing); err != nil {
+	if err := h.store.UpdateMaintenanceWindow(c.Request.Context(), existing); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
 
+	auditAfter(c, existing)
 	c.JSON(http.StatusOK, existing)
 }
 
@@ -1791,17 +2822,22 @@ func (h *Handler) DeleteMaintenanceWindow(c *gin.Context) {
 		return
 	}
 
-	existing, err := h.store.GetMaintenanceWindow(id)
+	existing, err := h.store.GetMaintenanceWindow(c.Request.Context(), id)
 	if err != nil {
 		RespondInternalError(c, err)
 		return
 	}
-	if existing == nil {
+	if existing == nil || !scopedVisible(c, existing.Project) {
 		RespondNotFound(c, "maintenance window not found")
 		return
 	}
+	if !scopedWritable(c, existing.Project) {
+		respondNotWritable(c, "maintenance window")
+		return
+	}
+	auditBefore(c, existing)
 
-	if err := h.store.DeleteMaintenanceWindow(id); err != nil {
+	if err := h.store.DeleteMaintenanceWindow(c.Request.Context(), id); err != nil {
 		RespondInternalError(c, err)
 		return
 	}
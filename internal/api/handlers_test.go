@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestMaintenanceTimes(t *testing.T) {
+	valid := []models.MaintenanceWindowInput{
+		{StartTime: "2024-01-15T02:00:00Z", EndTime: "2024-01-15T04:00:00Z"},
+		{StartTime: "2024-01-15T22:00:00Z", EndTime: "2024-01-15T02:00:00Z", Recurring: true},
+		{Cron: "0 2 * * 0#1", DurationMinutes: 120},
+		{Cron: "0 2 * * 0#1", DurationMinutes: 120, StartTime: "2024-01-01T00:00:00Z"},
+	}
+	for _, input := range valid {
+		if _, _, err := maintenanceTimes(&input); err != nil {
+			t.Errorf("maintenanceTimes(%+v) error = %v", input, err)
+		}
+	}
+
+	invalid := []models.MaintenanceWindowInput{
+		{StartTime: "2024-01-15T02:00:00Z"},
+		{StartTime: "2024-01-15T04:00:00Z", EndTime: "2024-01-15T02:00:00Z"},
+		{Cron: "0 2 * *", DurationMinutes: 120},
+		{Cron: "0 2 * * 0", DurationMinutes: 0},
+		{Cron: "0 2 * * 0", DurationMinutes: 120, StartTime: "2024-02-01T00:00:00Z", EndTime: "2024-01-01T00:00:00Z"},
+	}
+	for _, input := range invalid {
+		if _, _, err := maintenanceTimes(&input); err == nil {
+			t.Errorf("maintenanceTimes(%+v) = nil, want error", input)
+		}
+	}
+}
+
+func TestParseQuickDuration(t *testing.T) {
+	tests := map[string]time.Duration{"30": 30 * time.Minute, "2h": 2 * time.Hour, "90m": 90 * time.Minute}
+	for s, want := range tests {
+		if got, err := parseQuickDuration(s); err != nil || got != want {
+			t.Errorf("parseQuickDuration(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "0", "-5", "soon", "48h"} {
+		if _, err := parseQuickDuration(s); err == nil {
+			t.Errorf("parseQuickDuration(%q) = nil error, want error", s)
+		}
+	}
+}
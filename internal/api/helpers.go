@@ -1,10 +1,15 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
 	"github.com/jiin/pondy/internal/models"
 )
 
@@ -48,6 +53,34 @@ func formatDuration(d time.Duration) string {
 	return "1h"
 }
 
+// formatRetention formats a retention age in days when it is a whole number of days
+func formatRetention(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// labelSelector merges the ?label= parameters, e.g., label=team:payments&label=env:prod,
+// into the labels a target must have; nil without any
+func labelSelector(c *gin.Context) (map[string]string, error) {
+	params := c.QueryArray("label")
+	if len(params) == 0 {
+		return nil, nil
+	}
+	selector := make(map[string]string)
+	for _, param := range params {
+		labels, err := config.ParseLabelSelector(param)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range labels {
+			selector[key] = value
+		}
+	}
+	return selector, nil
+}
+
 // ErrorResponse represents a structured error response
 type ErrorResponse struct {
 	Error      string `json:"error"`
@@ -64,8 +97,12 @@ func RespondError(c *gin.Context, statusCode int, message string) {
 	})
 }
 
-// RespondInternalError sends a 500 error response
+// RespondInternalError sends a 500 error response, or 504 when the request or the query ran out of time
 func RespondInternalError(c *gin.Context, err error) {
+	if errors.Is(err, context.DeadlineExceeded) || (c.Request != nil && errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)) {
+		RespondError(c, http.StatusGatewayTimeout, "request timed out: "+err.Error())
+		return
+	}
 	c.JSON(http.StatusInternalServerError, ErrorResponse{
 		Error:      err.Error(),
 		StatusCode: http.StatusInternalServerError,
@@ -91,12 +128,64 @@ func RespondBadRequest(c *gin.Context, message string) {
 	})
 }
 
+// requestDone reports whether the request was cancelled or ran out of time, after answering it,
+// for handlers to check between expensive steps
+func requestDone(c *gin.Context) bool {
+	if err := c.Request.Context().Err(); err != nil {
+		RespondInternalError(c, err)
+		return true
+	}
+	return false
+}
+
 // RespondNoData sends a standard "no data available" response
 func RespondNoData(c *gin.Context) {
 	RespondNotFound(c, "no data available for analysis")
 }
 
+// historyBucket returns the bucket size of downsampled history: interval, widened so that
+// the range fits in limit buckets; 0 means neither was requested
+func historyBucket(tr TimeRange, limit int, interval time.Duration) time.Duration {
+	if limit > 0 {
+		perPoint := time.Duration(math.Ceil(tr.To.Sub(tr.From).Seconds()/float64(limit))) * time.Second
+		interval = max(interval, perPoint, time.Second)
+	}
+	return interval
+}
+
+// historyBands returns the active, pending and usage band of each downsampled point
+// Points of a single sample have no extremes, their band is the value itself
+func historyBands(points []models.PoolMetrics) []models.HistoryBand {
+	bands := make([]models.HistoryBand, 0, len(points))
+	for i := range points {
+		m := &points[i]
+		var usage float64
+		if m.Max > 0 {
+			usage = float64(m.Active) / float64(m.Max) * 100
+		}
+		bands = append(bands, models.HistoryBand{
+			Timestamp: m.Timestamp,
+			Active:    pointBand(m, "active", float64(m.Active)),
+			Pending:   pointBand(m, "pending", float64(m.Pending)),
+			Usage:     pointBand(m, "usage", usage),
+		})
+	}
+	return bands
+}
+
+// pointBand returns the band of a field around its average; the average is rounded for
+// integer fields, so it is kept within the extremes
+func pointBand(m *models.PoolMetrics, field string, avg float64) models.Band {
+	lo, okMin := m.MinValues[field]
+	hi, okMax := m.MaxValues[field]
+	if !okMin || !okMax {
+		return models.Band{Min: avg, Avg: avg, Max: avg}
+	}
+	return models.Band{Min: lo, Avg: math.Max(lo, math.Min(avg, hi)), Max: hi}
+}
+
 // downsampleMetrics reduces data points to maxPoints using time-bucket averaging
+// Like the database downsampling, buckets keep the extremes of active, pending and usage
 func downsampleMetrics(data []models.PoolMetrics, maxPoints int) []models.PoolMetrics {
 	if maxPoints <= 0 || len(data) <= maxPoints {
 		return data
@@ -126,8 +215,26 @@ func downsampleMetrics(data []models.PoolMetrics, maxPoints int) []models.PoolMe
 		var sumThreadsLive int
 		var sumGcCount, sumYoungGcCount, sumOldGcCount int64
 		var sumCpuUsage, sumGcTime float64
+		// Deltas are summed so the bucket keeps the totals, rates are averaged
+		var sumTimeoutDelta, sumGcCountDelta, sumYoungGcCountDelta, sumOldGcCountDelta int64
+		var sumGcTimeDelta, sumTimeoutRate, sumGcPausesPerMin, sumGcPauseMsPerMin float64
+		// Acquire percentiles are averaged, the maximum is kept
+		var sumAcquireP50, sumAcquireP95, sumAcquireP99, maxAcquire float64
+		var sumDerived map[string]float64
+		derivedCount := make(map[string]int)
+		minValues := map[string]float64{"active": math.Inf(1), "pending": math.Inf(1), "usage": math.Inf(1)}
+		maxValues := map[string]float64{"active": math.Inf(-1), "pending": math.Inf(-1), "usage": math.Inf(-1)}
 
 		for _, m := range bucket {
+			values := map[string]float64{"active": float64(m.Active), "pending": float64(m.Pending)}
+			if m.Max > 0 {
+				values["usage"] = float64(m.Active) / float64(m.Max) * 100
+			}
+			for k, v := range values {
+				minValues[k] = math.Min(minValues[k], v)
+				maxValues[k] = math.Max(maxValues[k], v)
+			}
+
 			sumActive += m.Active
 			sumIdle += m.Idle
 			sumPending += m.Pending
@@ -141,6 +248,30 @@ func downsampleMetrics(data []models.PoolMetrics, maxPoints int) []models.PoolMe
 			sumGcTime += m.GcTime
 			sumYoungGcCount += m.YoungGcCount
 			sumOldGcCount += m.OldGcCount
+			sumTimeoutDelta += m.TimeoutDelta
+			sumTimeoutRate += m.TimeoutRate
+			sumGcCountDelta += m.GcCountDelta
+			sumGcTimeDelta += m.GcTimeDelta
+			sumYoungGcCountDelta += m.YoungGcCountDelta
+			sumOldGcCountDelta += m.OldGcCountDelta
+			sumGcPausesPerMin += m.GcPausesPerMin
+			sumGcPauseMsPerMin += m.GcPauseMsPerMin
+			sumAcquireP50 += m.AcquireP50
+			sumAcquireP95 += m.AcquireP95
+			sumAcquireP99 += m.AcquireP99
+			maxAcquire = math.Max(maxAcquire, m.AcquireMax)
+			for k, v := range m.Derived {
+				if sumDerived == nil {
+					sumDerived = make(map[string]float64)
+				}
+				sumDerived[k] += v
+				derivedCount[k]++
+			}
+		}
+
+		// Derived metrics are averaged over the samples that have them
+		for k := range sumDerived {
+			sumDerived[k] /= float64(derivedCount[k])
 		}
 
 		n := len(bucket)
@@ -162,7 +293,31 @@ func downsampleMetrics(data []models.PoolMetrics, maxPoints int) []models.PoolMe
 			GcTime:       sumGcTime / float64(n),
 			YoungGcCount: sumYoungGcCount / n64,
 			OldGcCount:   sumOldGcCount / n64,
+			Derived:      sumDerived,
 			Timestamp:    bucket[n/2].Timestamp, // Use middle point timestamp
+
+			TimeoutDelta:      sumTimeoutDelta,
+			TimeoutRate:       sumTimeoutRate / float64(n),
+			GcCountDelta:      sumGcCountDelta,
+			GcTimeDelta:       sumGcTimeDelta,
+			YoungGcCountDelta: sumYoungGcCountDelta,
+			OldGcCountDelta:   sumOldGcCountDelta,
+			GcPausesPerMin:    sumGcPausesPerMin / float64(n),
+			GcPauseMsPerMin:   sumGcPauseMsPerMin / float64(n),
+
+			AcquireP50: sumAcquireP50 / float64(n),
+			AcquireP95: sumAcquireP95 / float64(n),
+			AcquireP99: sumAcquireP99 / float64(n),
+			AcquireMax: maxAcquire,
+		}
+		aggregated.SetAvgGcPause()
+		if n > 1 {
+			// Buckets without a pool size have no usage extremes
+			if math.IsInf(minValues["usage"], 1) {
+				delete(minValues, "usage")
+				delete(maxValues, "usage")
+			}
+			aggregated.Samples, aggregated.MinValues, aggregated.MaxValues = n, minValues, maxValues
 		}
 
 		result = append(result, aggregated)
@@ -98,6 +98,32 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestHistoryBucket(t *testing.T) {
+	now := time.Now()
+	day := TimeRange{From: now.Add(-24 * time.Hour), To: now}
+
+	tests := []struct {
+		name     string
+		limit    int
+		interval time.Duration
+		want     time.Duration
+	}{
+		{"raw", 0, 0, 0},
+		{"limit", 500, 0, 173 * time.Second},
+		{"interval", 0, 5 * time.Minute, 5 * time.Minute},
+		{"finer interval than limit", 500, time.Minute, 173 * time.Second},
+		{"coarser interval than limit", 500, time.Hour, time.Hour},
+		{"at least a second", 10000000, 0, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := historyBucket(day, tt.limit, tt.interval); got != tt.want {
+				t.Errorf("historyBucket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestConstants(t *testing.T) {
 	if DefaultRangeShort != time.Hour {
 		t.Errorf("DefaultRangeShort = %v, want 1h", DefaultRangeShort)
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Push ingestion limits
+const (
+	MaxIngestBatch     = 1000            // Maximum metrics per request
+	MaxIngestClockSkew = 5 * time.Minute // Maximum timestamp drift into the future
+)
+
+// IngestRequest is a batch of metrics pushed by an agent
+type IngestRequest struct {
+	Metrics []models.PoolMetrics `json:"metrics"`
+}
+
+// validateIngestMetrics checks a pushed sample and fills defaults
+// Only targets configured with type push accept pushed metrics
+func validateIngestMetrics(m *models.PoolMetrics, targets map[string]config.TargetConfig, now time.Time) error {
+	if m.TargetName == "" {
+		return fmt.Errorf("target_name is required")
+	}
+	target, ok := targets[m.TargetName]
+	if !ok {
+		return fmt.Errorf("unknown target '%s'", m.TargetName)
+	}
+	if target.Type != config.TargetTypePush {
+		return fmt.Errorf("target '%s' is not a push target", m.TargetName)
+	}
+
+	if m.InstanceName == "" {
+		m.InstanceName = "default"
+	}
+	if len(m.InstanceName) > 255 || strings.ContainsAny(m.InstanceName, "/\n") {
+		return fmt.Errorf("invalid instance_name")
+	}
+
+	switch m.Status {
+	case "":
+		m.Status = models.StatusHealthy
+	case models.StatusHealthy, models.StatusNoPool, models.StatusError:
+	default:
+		return fmt.Errorf("status must be healthy, no_pool, or error")
+	}
+
+	if m.Active < 0 || m.Idle < 0 || m.Pending < 0 || m.Max < 0 || m.Timeout < 0 ||
+		m.HeapUsed < 0 || m.HeapMax < 0 || m.NonHeapUsed < 0 || m.NonHeapMax < 0 ||
+		m.ThreadsLive < 0 || m.GcCount < 0 || m.GcTime < 0 || m.YoungGcCount < 0 || m.OldGcCount < 0 ||
+		m.AcquireP50 < 0 || m.AcquireP95 < 0 || m.AcquireP99 < 0 || m.AcquireMax < 0 {
+		return fmt.Errorf("metric values must not be negative")
+	}
+	if m.CpuUsage < 0 || m.CpuUsage > 1 {
+		return fmt.Errorf("cpu_usage must be between 0 and 1")
+	}
+
+	if m.Timestamp.IsZero() {
+		m.Timestamp = now
+	} else if m.Timestamp.After(now.Add(MaxIngestClockSkew)) {
+		return fmt.Errorf("timestamp is in the future")
+	}
+
+	m.ID = 0
+	m.ScrapeFailures = 0
+	return nil
+}
+
+// IngestMetrics stores a batch of metrics pushed by agents that pondy can't scrape
+// The batch is rejected as a whole when any sample is invalid
+func (h *Handler) IngestMetrics(c *gin.Context) {
+	// Pushed data is only accepted from authenticated clients
+	if server := h.cfg().Server; !server.AuthEnabled() {
+		RespondError(c, http.StatusForbidden, "push ingestion requires server.api_keys to be configured")
+		return
+	}
+
+	var req IngestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if len(req.Metrics) == 0 {
+		RespondBadRequest(c, "metrics is required")
+		return
+	}
+	if len(req.Metrics) > MaxIngestBatch {
+		RespondBadRequest(c, fmt.Sprintf("too many metrics: maximum %d per request", MaxIngestBatch))
+		return
+	}
+
+	// Keys limited to projects can only push to the targets of their projects
+	targets := make(map[string]config.TargetConfig)
+	scope := scopeOf(c)
+	for _, t := range h.cfg().Targets {
+		if scope.allows(t.GetProject()) {
+			targets[t.Name] = t
+		}
+	}
+
+	now := time.Now()
+	for i := range req.Metrics {
+		if err := validateIngestMetrics(&req.Metrics[i], targets, now); err != nil {
+			RespondBadRequest(c, fmt.Sprintf("metrics[%d]: %v", i, err))
+			return
+		}
+	}
+
+	for i := range req.Metrics {
+		m := &req.Metrics[i]
+		if h.collectors != nil {
+			h.collectors.ApplyDerived(m)
+		}
+		if err := h.store.Save(c.Request.Context(), m); err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		if h.alertMgr != nil {
+			h.alertMgr.Check(m)
+		}
+	}
+	h.InvalidateCache()
+
+	c.JSON(http.StatusAccepted, gin.H{"accepted": len(req.Metrics)})
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestValidateIngestMetrics(t *testing.T) {
+	targets := map[string]config.TargetConfig{
+		"edge-agent": {Name: "edge-agent", Type: config.TargetTypePush},
+		"orders":     {Name: "orders", Type: config.TargetTypeActuator},
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		m       models.PoolMetrics
+		wantErr bool
+	}{
+		{"valid", models.PoolMetrics{TargetName: "edge-agent", Active: 3, Idle: 7, Max: 10}, false},
+		{"missing target", models.PoolMetrics{Active: 3}, true},
+		{"unknown target", models.PoolMetrics{TargetName: "billing"}, true},
+		{"scraped target", models.PoolMetrics{TargetName: "orders"}, true},
+		{"negative value", models.PoolMetrics{TargetName: "edge-agent", Active: -1}, true},
+		{"cpu out of range", models.PoolMetrics{TargetName: "edge-agent", CpuUsage: 42}, true},
+		{"bad status", models.PoolMetrics{TargetName: "edge-agent", Status: "great"}, true},
+		{"bad instance", models.PoolMetrics{TargetName: "edge-agent", InstanceName: "a/b"}, true},
+		{"future timestamp", models.PoolMetrics{TargetName: "edge-agent", Timestamp: now.Add(time.Hour)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.m
+			err := validateIngestMetrics(&m, targets, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateIngestMetrics() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateIngestMetrics_Defaults(t *testing.T) {
+	targets := map[string]config.TargetConfig{
+		"edge-agent": {Name: "edge-agent", Type: config.TargetTypePush},
+	}
+	now := time.Now()
+
+	m := models.PoolMetrics{ID: 99, TargetName: "edge-agent"}
+	if err := validateIngestMetrics(&m, targets, now); err != nil {
+		t.Fatalf("validateIngestMetrics() error = %v", err)
+	}
+	if m.InstanceName != "default" || m.Status != models.StatusHealthy || !m.Timestamp.Equal(now) || m.ID != 0 {
+		t.Errorf("defaults = %+v, want default instance, healthy status, current time and no ID", m)
+	}
+}
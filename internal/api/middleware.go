@@ -1,11 +1,16 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
 )
 
 // RateLimiter implements a token bucket rate limiter
@@ -17,6 +22,8 @@ type RateLimiter struct {
 	burst    int           // max burst size
 	cleanup  time.Duration // cleanup interval for expired entries
 	stopCh   chan struct{} // channel to signal shutdown
+	stopOnce sync.Once
+	rejected int64         // requests denied since start
 }
 
 type clientBucket struct {
@@ -44,9 +51,25 @@ func NewRateLimiter(rate int, interval time.Duration, burst int) *RateLimiter {
 	return rl
 }
 
+// SetLimits changes the rate, interval and burst, keeping the tokens clients have left
+// up to the new burst
+func (rl *RateLimiter) SetLimits(rate int, interval time.Duration, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.rate = rate
+	rl.interval = interval
+	rl.burst = burst
+	for _, bucket := range rl.clients {
+		if bucket.tokens > burst {
+			bucket.tokens = burst
+		}
+	}
+}
+
 // Stop stops the rate limiter cleanup goroutine
 func (rl *RateLimiter) Stop() {
-	close(rl.stopCh)
+	rl.stopOnce.Do(func() { close(rl.stopCh) })
 }
 
 func (rl *RateLimiter) cleanupLoop() {
@@ -85,8 +108,38 @@ func (rl *RateLimiter) Allow(clientIP string) bool {
 		}
 		return true
 	}
+	rl.refill(bucket, now)
+
+	// Check if we have tokens available
+	if bucket.tokens > 0 {
+		bucket.tokens--
+		return true
+	}
+
+	rl.rejected++
+	return false
+}
+
+// Exhausted returns whether a client has no tokens left, without taking one
+// A client found exhausted is counted as rejected
+func (rl *RateLimiter) Exhausted(clientIP string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	bucket, exists := rl.clients[clientIP]
+	if !exists {
+		return false
+	}
+	rl.refill(bucket, time.Now())
+	if bucket.tokens > 0 {
+		return false
+	}
+	rl.rejected++
+	return true
+}
 
-	// Refill tokens based on elapsed time
+// refill adds the tokens earned since the last refill, up to the burst; rl.mu must be held
+func (rl *RateLimiter) refill(bucket *clientBucket, now time.Time) {
 	elapsed := now.Sub(bucket.lastRefill)
 	tokensToAdd := int(elapsed / rl.interval) * rl.rate
 	if tokensToAdd > 0 {
@@ -96,22 +149,25 @@ func (rl *RateLimiter) Allow(clientIP string) bool {
 		}
 		bucket.lastRefill = now
 	}
+}
 
-	// Check if we have tokens available
-	if bucket.tokens > 0 {
-		bucket.tokens--
-		return true
-	}
-
-	return false
+// Rejected returns the number of requests denied since the limiter was created
+func (rl *RateLimiter) Rejected() int64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.rejected
 }
 
 // RateLimitMiddleware returns a Gin middleware for rate limiting
+// Clients are told apart by API key, or by IP without one; keys with their own quota are skipped
 func RateLimitMiddleware(rl *RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
+		if c.GetBool(ContextKeyOwnRateLimit) {
+			c.Next()
+			return
+		}
 
-		if !rl.Allow(clientIP) {
+		if !rl.Allow(rateLimitKey(c)) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":       "rate limit exceeded",
 				"retry_after": "1s",
@@ -127,9 +183,7 @@ func RateLimitMiddleware(rl *RateLimiter) gin.HandlerFunc {
 // StrictRateLimitMiddleware is a stricter rate limiter for sensitive endpoints
 func StrictRateLimitMiddleware(rl *RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-
-		if !rl.Allow(clientIP) {
+		if !rl.Allow(rateLimitKey(c)) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":       "rate limit exceeded for this endpoint",
 				"retry_after": "10s",
@@ -142,9 +196,10 @@ func StrictRateLimitMiddleware(rl *RateLimiter) gin.HandlerFunc {
 	}
 }
 
-// MaxBodySizeMiddleware limits the maximum request body size
-func MaxBodySizeMiddleware(maxBytes int64) gin.HandlerFunc {
+// MaxBodySizeMiddleware limits the maximum request body size to server.max_body_bytes
+func MaxBodySizeMiddleware(cfgMgr *config.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		maxBytes := cfgMgr.Get().Server.GetMaxBodyBytes()
 		if c.Request.ContentLength > maxBytes {
 			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
 				"error":     "request body too large",
@@ -160,6 +215,24 @@ func MaxBodySizeMiddleware(maxBytes int64) gin.HandlerFunc {
 	}
 }
 
+// TimeoutMiddleware bounds an expensive request to server.request_timeout
+// The request context is cancelled at the deadline, stopping storage queries and the analysis
+// between its steps; a request that ran out of time is answered with 504
+func TimeoutMiddleware(cfgMgr *config.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := cfgMgr.Get().Server.GetRequestTimeout()
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			RespondError(c, http.StatusGatewayTimeout, fmt.Sprintf("request timed out after %s", timeout))
+		}
+	}
+}
+
 // ConnectionLimiter limits concurrent connections
 type ConnectionLimiter struct {
 	mu          sync.Mutex
@@ -250,6 +323,11 @@ func SecurityHeadersMiddleware() gin.HandlerFunc {
 		// Permissions Policy (disable unnecessary features)
 		c.Header("Permissions-Policy", "geolocation=(), microphone=(), camera=()")
 
+		// Keep browsers on HTTPS once pondy serves it (server.tls)
+		if c.Request.TLS != nil {
+			c.Header("Strict-Transport-Security", "max-age=31536000")
+		}
+
 		// Content Security Policy for API responses
 		// Note: Frontend serves its own CSP via meta tag or separate config
 		if len(c.Request.URL.Path) >= 4 && c.Request.URL.Path[:4] == "/api" {
@@ -260,31 +338,84 @@ func SecurityHeadersMiddleware() gin.HandlerFunc {
 	}
 }
 
+// readOnlyExempt are routes allowed in read-only mode despite their method
+var readOnlyExempt = []string{
+	"/alerts/templates/validate", // Only renders a template
+	"/rules/test",                // Only replays stored metrics
+	"/ingest/metrics",            // Collection continues in read-only mode
+}
+
+// readOnlyBlocked are route prefixes rejected in read-only mode whatever the method:
+// downloading a backup writes a backup file on the server
+var readOnlyBlocked = []string{
+	"/backup",
+}
+
+// ReadOnlyMiddleware rejects requests that change state with 403 when server.read_only is set
+// Read on every request, so reloads apply immediately
+func ReadOnlyMiddleware(cfgMgr *config.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfgMgr.Get().Server.ReadOnly && changesState(c.Request.Method, c.FullPath()) {
+			RespondError(c, http.StatusForbidden, "pondy is in read-only mode: changes are disabled on this instance")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// changesState returns whether a request to a route may change config or data
+func changesState(method, route string) bool {
+	for _, blocked := range readOnlyBlocked {
+		if r := auditRoute(route); r == blocked || strings.HasPrefix(r, blocked+"/") {
+			return true
+		}
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	for _, exempt := range readOnlyExempt {
+		if strings.HasSuffix(route, exempt) {
+			return false
+		}
+	}
+	return true
+}
+
+// DeprecationMiddleware marks responses of deprecated routes and links to their successor
+// prefix is replaced by successor in the request path, e.g., /api/targets -> /api/v1/targets
+func DeprecationMiddleware(prefix, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		if path := c.Request.URL.Path; strings.HasPrefix(path, prefix) {
+			c.Header("Link", "<"+successor+strings.TrimPrefix(path, prefix)+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
+
 // CORSMiddleware handles Cross-Origin Resource Sharing
 // allowedOrigins: list of allowed origins, or ["*"] for all (not recommended for production)
-func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
-	allowAll := len(allowedOrigins) == 1 && allowedOrigins[0] == "*"
-
+// Origins are read from server.cors.allowed_origins on every request, so reloads apply immediately
+func CORSMiddleware(cfgMgr *config.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		origin := c.GetHeader("Origin")
 
 		// Check if origin is allowed
 		allowed := false
-		if allowAll {
-			allowed = true
-		} else {
-			for _, o := range allowedOrigins {
-				if o == origin {
-					allowed = true
-					break
-				}
+		for _, o := range cfgMgr.Get().Server.CORS.GetAllowedOrigins() {
+			if o == "*" || o == origin {
+				allowed = true
+				break
 			}
 		}
 
 		if allowed && origin != "" {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
+			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-API-Key, "+ProjectHeader)
+			c.Header("Access-Control-Expose-Headers", "Deprecation, Link, Retry-After, X-Export-Rows-Remaining")
 			c.Header("Access-Control-Max-Age", "86400")
 			c.Header("Access-Control-Allow-Credentials", "true")
 		}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET(APIPrefix+"/targets/:name", ok)
+	r.Group("/api", DeprecationMiddleware("/api", APIPrefix)).GET("/targets/:name", ok)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/targets/orders", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("legacy status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation = %q, want true", got)
+	}
+	if got, want := w.Header().Get("Link"), `</api/v1/targets/orders>; rel="successor-version"`; got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/targets/orders", nil))
+	if w.Header().Get("Deprecation") != "" || w.Header().Get("Link") != "" {
+		t.Errorf("versioned route has deprecation headers: %v", w.Header())
+	}
+}
+
+func TestChangesState(t *testing.T) {
+	tests := []struct {
+		method string
+		route  string
+		want   bool
+	}{
+		{http.MethodGet, APIPrefix + "/targets/:name/history", false},
+		{http.MethodHead, APIPrefix + "/targets/:name/history", false},
+		{http.MethodGet, APIPrefix + "/backup/download", true},
+		{http.MethodGet, APIPrefix + "/projects/:project/backup/remote", true},
+		{http.MethodGet, "/api/backup/download", true},
+		{http.MethodPost, APIPrefix + "/backup/restore", true},
+		{http.MethodPut, APIPrefix + "/config/targets/:name", true},
+		{http.MethodPost, APIPrefix + "/alerts/:id/resolve", true},
+		{http.MethodDelete, "/api/rules/:id", true},
+		{http.MethodPost, APIPrefix + "/alerts/templates/validate", false},
+		{http.MethodPost, APIPrefix + "/projects/:project/ingest/metrics", false},
+	}
+	for _, tt := range tests {
+		if got := changesState(tt.method, tt.route); got != tt.want {
+			t.Errorf("changesState(%s %s) = %v, want %v", tt.method, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiter_SetLimits(t *testing.T) {
+	rl := NewRateLimiter(1, time.Hour, 5)
+	defer rl.Stop()
+
+	rl.Allow("client") // 4 tokens left
+	rl.SetLimits(1, time.Hour, 2)
+
+	// Remaining tokens are capped at the new burst
+	if !rl.Allow("client") || !rl.Allow("client") {
+		t.Fatal("expected 2 requests within the new burst to be allowed")
+	}
+	if rl.Allow("client") {
+		t.Error("expected request beyond the new burst to be rejected")
+	}
+}
+
+func TestAuthRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rl := NewRateLimiter(1, time.Hour, 2)
+	defer rl.Stop()
+
+	r := gin.New()
+	r.Use(AuthRateLimitMiddleware(rl))
+	r.GET("/targets", func(c *gin.Context) {
+		if c.GetHeader("X-API-Key") != "secret" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	request := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/targets", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Successful requests don't count
+	for i := 0; i < 5; i++ {
+		if code := request("secret"); code != http.StatusOK {
+			t.Fatalf("request with a valid key = %d, want 200", code)
+		}
+	}
+
+	// Failures use up the burst, then the client is blocked even with a valid key
+	for _, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if code := request("guess"); code != want {
+			t.Errorf("request with a wrong key = %d, want %d", code, want)
+		}
+	}
+	if code := request("secret"); code != http.StatusTooManyRequests {
+		t.Errorf("request from a blocked client = %d, want 429", code)
+	}
+}
+
+func TestRespondInternalError_Timeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+
+	// A request past its deadline is answered with 504, also for driver errors not wrapping it
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/targets/orders/report", nil).WithContext(ctx)
+	if !requestDone(c) {
+		t.Fatal("requestDone() = false after the deadline")
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/targets/orders/report", nil).WithContext(ctx)
+	RespondInternalError(c, errors.New("interrupted"))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", w.Code)
+	}
+
+	// Other errors stay 500
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/targets/orders/report", nil)
+	if requestDone(c) {
+		t.Fatal("requestDone() = true before the deadline")
+	}
+	RespondInternalError(c, errors.New("disk I/O error"))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Notification delivery handlers
+
+type DeliveriesResponse struct {
+	Deliveries []models.NotificationDelivery `json:"deliveries"`
+	Total      int                           `json:"total"`
+}
+
+// GetAlertDeliveries returns every notification attempt made for an alert
+func (h *Handler) GetAlertDeliveries(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid alert ID")
+		return
+	}
+
+	alert, err := h.store.GetAlert(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if alert == nil || !h.targetVisible(c, alert.TargetName) {
+		RespondNotFound(c, "alert not found")
+		return
+	}
+
+	deliveries, err := h.store.GetDeliveriesByAlert(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []models.NotificationDelivery{}
+	}
+
+	c.JSON(http.StatusOK, DeliveriesResponse{
+		Deliveries: deliveries,
+		Total:      len(deliveries),
+	})
+}
+
+// GetFailedNotifications returns failed notification attempts within a time range
+func (h *Handler) GetFailedNotifications(c *gin.Context) {
+	tr := ParseTimeRangeFromContext(c, DefaultRangeLong)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	if limit > 10000 {
+		limit = 10000
+	}
+
+	deliveries, err := h.store.GetFailedDeliveries(c.Request.Context(), tr.From, limit)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []models.NotificationDelivery{}
+	}
+
+	c.JSON(http.StatusOK, DeliveriesResponse{
+		Deliveries: deliveries,
+		Total:      len(deliveries),
+	})
+}
@@ -0,0 +1,696 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/analyzer"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/importer"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// openAPIVersion is the API version reported in the OpenAPI document
+const openAPIVersion = "0.3.0"
+
+// OpenAPISpec is an OpenAPI 3.0 document, limited to the parts pondy uses
+type OpenAPISpec struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"` // path -> lowercase method -> operation
+	Components OpenAPIComponents                       `json:"components"`
+	Security   []map[string][]string                   `json:"security"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIOperation is a single route
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a path or query parameter
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"` // path, query
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody is a JSON request body
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds the schema of a body
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIComponents holds shared schemas and security schemes
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes"`
+}
+
+// OpenAPISecurityScheme describes an authentication method
+type OpenAPISecurityScheme struct {
+	Type   string `json:"type"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// OpenAPISchema is a JSON schema
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// routeDoc adds what can't be read from the route table: query parameters and body types
+// Routes without a doc get a summary from the handler name and a generic JSON response
+type routeDoc struct {
+	summary     string
+	query       []queryParam
+	request     interface{} // zero value of the request body type
+	response    interface{} // zero value of the response body type
+	contentType string      // non-JSON response, e.g., text/csv
+}
+
+type queryParam struct {
+	name        string
+	typ         string // string, integer, boolean
+	description string
+}
+
+// Common query parameters
+var (
+	rangeQuery = func(def string) queryParam {
+		return queryParam{"range", "string", "Time range, e.g., 1h, 24h, 7d (default: " + def + ")"}
+	}
+	instanceQuery = queryParam{"instance", "string", "Instance filter"}
+	limitQuery    = queryParam{"limit", "integer", "Maximum number of items"}
+
+	includeInactiveQuery = queryParam{"include_inactive", "boolean", "Include instances that stopped reporting"}
+	exportFormatQuery    = queryParam{"format", "string", "csv (default) or json (newline-delimited)"}
+	exportFieldsQuery    = queryParam{"fields", "string", "Comma-separated columns, e.g., timestamp,active,max (default: all)"}
+	labelQuery           = queryParam{"label", "string", "Only targets with these labels, e.g., team:payments (comma-separated, all must match)"}
+)
+
+// routeDocs documents routes by "METHOD path", with paths without the version prefix
+var routeDocs = map[string]routeDoc{
+	"GET /api/settings":              {summary: "Get UI settings"},
+	"GET /api/targets":               {summary: "List targets with their current status", query: []queryParam{includeInactiveQuery, labelQuery}, response: TargetsResponse{}},
+	"GET /api/summary":               {summary: "Fleet-wide summary with 24h trends", query: []queryParam{labelQuery, {"group_by", "string", "Aggregate by group or by a label key"}}, response: SummaryResponse{}},
+	"GET /api/targets/:name/metrics": {summary: "Get the latest metrics of a target", response: models.PoolMetrics{}},
+	"GET /api/targets/:name/history": {
+		summary:  "Get historical metrics",
+		query:    []queryParam{rangeQuery("1h"), instanceQuery, {"limit", "integer", "Maximum datapoints, downsampled (default: 500, 0 = no limit)"}, {"annotations", "boolean", "Include annotations"}},
+		response: models.HistoryResponse{},
+	},
+	"GET /api/targets/:name/recommendations": {summary: "Pool size recommendations", query: []queryParam{rangeQuery("1h")}, response: analyzer.AnalysisResult{}},
+	"GET /api/targets/:name/recommendations/apply": {
+		summary:  "Preview applying a pool setting to the running instances",
+		query:    []queryParam{{"setting", "string", "maximumPoolSize, minimumIdle or connectionTimeout"}, {"value", "string", "New value, e.g., 30"}},
+		response: ApplyRecommendationResponse{},
+	},
+	"POST /api/targets/:name/recommendations/apply": {summary: "Apply a pool setting to the running instances", request: ApplyRecommendationRequest{}, response: ApplyRecommendationResponse{}},
+	"GET /api/recommendations": {
+		summary:  "Tracked recommendations and their status",
+		query:    []queryParam{{"target", "string", "Target filter"}, {"status", "string", "open, applied, dismissed or snoozed"}},
+		response: RecommendationsResponse{},
+	},
+	"POST /api/recommendations/:id/status": {summary: "Mark a recommendation applied, dismissed, snoozed or open", request: models.RecommendationStatusInput{}, response: models.RecommendationRecord{}},
+	"GET /api/targets/:name/leaks":         {summary: "Detect connection leaks", query: []queryParam{rangeQuery("1h")}, response: analyzer.LeakAnalysisResult{}},
+	"GET /api/targets/:name/health":        {summary: "Composite 0-100 health score", query: []queryParam{rangeQuery("1h")}, response: analyzer.HealthResult{}},
+	"GET /api/targets/:name/availability":  {summary: "Health endpoint uptime and status periods per instance", query: []queryParam{rangeQuery("24h")}, response: analyzer.AvailabilityResult{}},
+	"GET /api/targets/:name/peaktime":      {summary: "Peak time analysis", query: []queryParam{rangeQuery("24h")}, response: analyzer.PeakTimeResult{}},
+	"GET /api/targets/:name/forecast":      {summary: "Capacity forecast", query: []queryParam{rangeQuery("168h")}, response: analyzer.ForecastResult{}},
+	"GET /api/targets/:name/events":        {summary: "List deployment and other events", query: []queryParam{rangeQuery("168h")}, response: EventsResponse{}},
+	"POST /api/targets/:name/events":       {summary: "Record an event", request: models.EventInput{}, response: models.Event{}},
+	"GET /api/targets/:name/export": {
+		summary:     "Export metrics as CSV or NDJSON, gzip-compressed if accepted",
+		query:       []queryParam{rangeQuery("24h"), instanceQuery, exportFormatQuery, exportFieldsQuery},
+		contentType: "text/csv",
+	},
+	"GET /api/targets/:name/anomalies": {
+		summary: "Detect anomalies",
+		query: []queryParam{
+			rangeQuery("24h"),
+			{"sensitivity", "string", "low, medium or high (default: target config or medium)"},
+			{"algorithm", "string", "zscore, mad, ewma or iqr (default: target config or zscore)"},
+			{"method", "string", "global or seasonal"},
+			{"weekday", "boolean", "Seasonal baseline per weekday and hour"},
+			{"baseline", "string", "Seasonal baseline period"},
+		},
+		response: analyzer.AnomalyResult{},
+	},
+	"GET /api/targets/:name/compare":           {summary: "Compare with the previous period", query: []queryParam{{"period", "string", "day or week"}}, response: analyzer.PeriodComparisonResult{}},
+	"GET /api/targets/:name/instances/compare": {summary: "Compare instances and rank outliers", query: []queryParam{rangeQuery("24h")}, response: analyzer.InstanceComparisonResult{}},
+	"GET /api/targets/:name/events/:id/regression": {
+		summary:  "Compare metrics before and after an event",
+		query:    []queryParam{{"window", "string", "Window on each side of the event (default: 1h)"}, {"threshold", "number", "% increase flagged as a regression (default: 20)"}},
+		response: analyzer.RegressionResult{},
+	},
+	"GET /api/targets/:name/slo": {summary: "SLO compliance, error budget and burn rates", response: SLOResponse{}},
+	"GET /api/targets/:name/report": {
+		summary:     "Generate an HTML or PDF report",
+		query:       []queryParam{rangeQuery("24h"), {"format", "string", "html or pdf"}},
+		contentType: "text/html",
+	},
+	"POST /api/targets/:name/instances/:id/actions/threaddump": {
+		summary:     "Capture a thread dump of an actuator instance",
+		contentType: "application/json",
+	},
+	"POST /api/targets/:name/instances/:id/actions/heapdump": {
+		summary:     "Capture a heap dump (.hprof) of an actuator instance",
+		contentType: "application/octet-stream",
+	},
+	"GET /api/report/combined": {
+		summary:     "Generate a report for several targets",
+		query:       []queryParam{rangeQuery("24h"), {"targets", "string", "Comma-separated target names"}, {"view", "integer", "Saved view whose targets and instances to report on"}, labelQuery, {"format", "string", "html or pdf"}},
+		contentType: "text/html",
+	},
+	"GET /api/export/all": {
+		summary:     "Export metrics of all targets as CSV or NDJSON, gzip-compressed if accepted",
+		query:       []queryParam{rangeQuery("24h"), exportFormatQuery, exportFieldsQuery},
+		contentType: "text/csv",
+	},
+	"GET /api/search": {
+		summary:  "Search targets, instances, alert rules and alerts",
+		query:    []queryParam{{"q", "string", "Search terms, all must match"}, {"limit", "integer", "Maximum matches per category (default: 10, max: 50)"}},
+		response: SearchResponse{},
+	},
+	"GET /api/collectors": {summary: "Scrape health of all collectors", response: CollectorsResponse{}},
+	"GET /api/cluster":    {summary: "Live replicas and the targets each one collects when collection is sharded", response: ClusterResponse{}},
+
+	"GET /api/alerts": {
+		summary: "List alerts with filtering, sorting and pagination",
+		query: []queryParam{
+			{"status", "string", "fired or resolved"},
+			{"target", "string", "Target filter"},
+			{"rule", "string", "Rule filter"},
+			{"flapping", "boolean", "true: only alerts marked flapping"},
+			{"severity", "string", "Comma-separated severities: info, warning, critical"},
+			{"range", "string", "Fired within this duration before now, e.g., 24h"},
+			{"from", "string", "Fired at or after, RFC3339"},
+			{"to", "string", "Fired at or before, RFC3339"},
+			{"sort", "string", "fired_at (default), severity, target_name or rule_name"},
+			{"order", "string", "desc (default) or asc"},
+			{"limit", "integer", "Page size (default: 100, max: 10000)"},
+			{"offset", "integer", "Alerts to skip"},
+			{"cursor", "string", "next_cursor of the previous page, replaces offset"},
+		},
+		response: AlertsResponse{},
+	},
+	"GET /api/alerts/stats/timeseries": {
+		summary:  "Alerts per day, mean time to resolve, noisy rules and flapping alerts",
+		query:    []queryParam{rangeQuery("720h"), {"limit", "integer", "Maximum noisy rules and flapping alerts (default: 10, max: 100)"}, {"flap_threshold", "integer", "Fires on the same instance that count as flapping (default: 3)"}},
+		response: models.AlertTimeseries{},
+	},
+	"GET /api/alerts/stats":               {summary: "Alert statistics", response: models.AlertStats{}},
+	"POST /api/alerts/templates/validate": {summary: "Validate a notification template", request: ValidateTemplateRequest{}, response: ValidateTemplateResponse{}},
+	"POST /api/alerts/bulk":               {summary: "Resolve, acknowledge or delete many alerts", request: BulkAlertRequest{}, response: BulkAlertResponse{}},
+	"GET /api/alerts/:id":                 {summary: "Get an alert", response: models.Alert{}},
+	"POST /api/alerts/:id/resolve":        {summary: "Resolve an alert", response: models.Alert{}},
+	"GET /api/alerts/:id/deliveries":      {summary: "Notification deliveries of an alert", response: DeliveriesResponse{}},
+	"GET /api/alerts/:id/context":         {summary: "Metric values an alert fired on and the instance's history around it", response: AlertContextResponse{}},
+	"POST /api/alerts/test":               {summary: "Send a test alert", request: alerter.TestAlertOptions{}},
+
+	"GET /api/rules/:id":          {summary: "Get an alert rule", response: models.AlertRule{}},
+	"POST /api/rules":             {summary: "Create an alert rule", request: models.AlertRuleInput{}, response: models.AlertRule{}},
+	"POST /api/rules/test":        {summary: "Replay a target's metrics through a rule condition", request: RuleTestRequest{}, response: RuleTestResponse{}},
+	"PUT /api/rules/:id":          {summary: "Update an alert rule", request: models.AlertRuleInput{}, response: models.AlertRule{}},
+	"PATCH /api/rules/:id/toggle": {summary: "Enable or disable an alert rule", response: models.AlertRule{}},
+	"POST /api/rules/:id/adopt":   {summary: "Move a config-managed rule from the configuration file to the API", response: models.AlertRule{}},
+
+	"GET /api/rules/templates":        {summary: "Recommended alert rules", response: RuleTemplatesResponse{}},
+	"POST /api/rules/templates/apply": {summary: "Create config rules from recommended rules", request: ApplyRuleTemplatesRequest{}, response: ApplyRuleTemplatesResponse{}},
+	"GET /api/rules/export": {
+		summary:     "Export DB rules and alerting settings as YAML or JSON",
+		query:       []queryParam{{"format", "string", "yaml (default) or json"}},
+		contentType: "application/yaml",
+	},
+	"POST /api/rules/import": {
+		summary: "Import DB rules and alerting settings from YAML or JSON",
+		query: []queryParam{
+			{"format", "string", "yaml or json, default from Content-Type"},
+			{"on_conflict", "string", "skip (default), overwrite or rename rules whose name exists"},
+			{"dry_run", "boolean", "Validate and report changes without applying"},
+		},
+		response: RuleImportResponse{},
+	},
+
+	"GET /api/backup/download":        {summary: "Download a database backup", contentType: "application/octet-stream"},
+	"POST /api/backup/remote/restore": {summary: "Restore a remote backup", request: RemoteRestoreRequest{}},
+
+	"GET /api/storage/stats":                            {summary: "Database size and row counts", response: StorageStatsResponse{}},
+	"GET /api/storage/targets/:name/inactive-instances": {summary: "List instances that stopped reporting"},
+	"DELETE /api/storage/targets/:name/inactive-instances": {
+		summary: "Delete stored data of inactive instances",
+		query:   []queryParam{{"instance", "string", "Only purge this instance"}},
+	},
+
+	"POST /api/config/targets": {summary: "Add a target", request: TargetConfigRequest{}},
+	"GET /api/config/targets/export": {
+		summary:     "Export all targets as YAML or JSON",
+		query:       []queryParam{{"format", "string", "yaml (default) or json"}},
+		contentType: "application/yaml",
+	},
+	"POST /api/config/targets/import": {
+		summary:  "Replace all targets with a YAML or JSON list",
+		query:    []queryParam{{"format", "string", "yaml or json, default from Content-Type"}, {"dry_run", "boolean", "Validate and report changes without applying"}},
+		response: TargetImportResponse{},
+	},
+	"PUT /api/config/targets/:name":           {summary: "Update a target", request: TargetConfigRequest{}},
+	"POST /api/config/targets/:name/backfill": {summary: "Import Prometheus history for a target", response: importer.BackfillResult{}},
+	"POST /api/config/targets/:name/pause":    {summary: "Stop collecting and alerting on a target, keeping its config and history", request: PauseTargetRequest{}},
+	"POST /api/config/targets/:name/resume":   {summary: "Resume collecting and alerting on a paused target"},
+	"POST /api/config/targets/:name/rename":   {summary: "Rename a target, moving its history to the new name", request: RenameTargetRequest{}},
+	"POST /api/config/derived-metrics":        {summary: "Add a derived metric", request: config.DerivedMetricConfig{}},
+
+	"GET /api/maintenance":        {summary: "List maintenance windows", response: MaintenanceWindowsResponse{}},
+	"GET /api/maintenance/active": {summary: "List active maintenance windows", response: MaintenanceWindowsResponse{}},
+	"GET /api/maintenance/:id":    {summary: "Get a maintenance window", response: models.MaintenanceWindow{}},
+	"POST /api/maintenance":       {summary: "Create a maintenance window", request: models.MaintenanceWindowInput{}, response: models.MaintenanceWindow{}},
+	"POST /api/maintenance/quick": {summary: "Silence a target for a while, starting now", request: models.QuickMaintenanceInput{}, response: models.MaintenanceWindow{}},
+	"PUT /api/maintenance/:id":    {summary: "Update a maintenance window", request: models.MaintenanceWindowInput{}, response: models.MaintenanceWindow{}},
+
+	"GET /api/views":     {summary: "List saved views", response: ViewsResponse{}},
+	"GET /api/views/:id": {summary: "Get a saved view", response: models.View{}},
+	"GET /api/views/:id/data": {
+		summary:  "History of a view's targets and instances for rendering its panels",
+		query:    []queryParam{{"range", "string", "Time range (default: the view's range, or 24h)"}, {"limit", "integer", "Maximum datapoints per target, downsampled (default: 500)"}},
+		response: ViewDataResponse{},
+	},
+	"POST /api/views":    {summary: "Create a saved view", request: models.ViewInput{}, response: models.View{}},
+	"PUT /api/views/:id": {summary: "Update a saved view", request: models.ViewInput{}, response: models.View{}},
+
+	"GET /api/annotations": {
+		summary:  "List annotations",
+		query:    []queryParam{rangeQuery("24h"), {"target", "string", "Target filter, global annotations are included"}, {"tags", "string", "Comma-separated tags, all must match"}},
+		response: AnnotationsResponse{},
+	},
+	"GET /api/annotations/:id": {summary: "Get an annotation", response: models.Annotation{}},
+	"POST /api/annotations":    {summary: "Create an annotation", request: models.AnnotationInput{}, response: models.Annotation{}},
+
+	"GET /api/silences":        {summary: "List silences", response: SilencesResponse{}},
+	"GET /api/silences/active": {summary: "List active silences", response: SilencesResponse{}},
+	"GET /api/silences/:id":    {summary: "Get a silence", response: models.Silence{}},
+	"POST /api/silences":       {summary: "Create a silence", request: models.SilenceInput{}, response: models.Silence{}},
+	"PUT /api/silences/:id":    {summary: "Update a silence", request: models.SilenceInput{}, response: models.Silence{}},
+
+	"POST /api/ingest/metrics":      {summary: "Push metrics from an agent", request: IngestRequest{}},
+	"GET /api/notifications/failed": {summary: "List failed notification deliveries", query: []queryParam{limitQuery}, response: DeliveriesResponse{}},
+	"GET /api/projects":             {summary: "Projects visible to the API key, with target counts", response: ProjectsResponse{}},
+	"GET /api/admin/usage":          {summary: "API usage per key", response: UsageResponse{}},
+	"GET /api/keys/:id/usage":       {summary: "Usage and quota of one API key, by name", response: KeyUsageResponse{}},
+	"GET /api/audit": {
+		summary: "Audit log of changes made through the API, newest first",
+		query: []queryParam{
+			{"actor", "string", "API key name, or client IP without authentication"},
+			{"action", "string", "Action, e.g., rule.update, or a prefix ending in a dot, e.g., rule."},
+			{"resource", "string", "Target name or resource ID from the path"},
+			{"range", "string", "Within this duration before now, e.g., 24h"},
+			{"from", "string", "At or after, RFC3339"},
+			{"to", "string", "At or before, RFC3339"},
+			{"limit", "integer", "Page size (default: 100, max: 10000)"},
+			{"offset", "integer", "Entries to skip"},
+			{"format", "string", "json (default) or csv"},
+		},
+		response: AuditResponse{},
+	},
+	"GET /api/system/status":  {summary: "Operational state of pondy itself: runtime, write queue, collectors, rate limiting, notifications", response: SystemStatusResponse{}},
+	"GET /api/system/metrics": {summary: "Operational state of pondy itself in the Prometheus text format", contentType: "text/plain"},
+	"GET /health":             {summary: "Health check"},
+}
+
+// BuildOpenAPISpec assembles an OpenAPI document from the registered routes
+// Only current API version routes and the health check are included, deprecated and project prefixed aliases are left out
+func BuildOpenAPISpec(routes gin.RoutesInfo) *OpenAPISpec {
+	spec := &OpenAPISpec{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:       "pondy API",
+			Description: "Connection pool and JVM monitoring API. When API keys are configured, send one in the X-API-Key header or as a Bearer token. Every route is also served under /api/v1/projects/{project}, which scopes it to one project like the X-Pondy-Project header.",
+			Version:     openAPIVersion,
+		},
+		Paths: make(map[string]map[string]*OpenAPIOperation),
+		Components: OpenAPIComponents{
+			Schemas: make(map[string]*OpenAPISchema),
+			SecuritySchemes: map[string]*OpenAPISecurityScheme{
+				"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+				"bearer": {Type: "http", Scheme: "bearer"},
+			},
+		},
+		// Authentication is optional when no API keys are configured
+		Security: []map[string][]string{{"apiKey": {}}, {"bearer": {}}, {}},
+	}
+
+	gen := &schemaGenerator{schemas: spec.Components.Schemas, types: make(map[string]reflect.Type)}
+	errorSchema := gen.schemaFor(reflect.TypeOf(ErrorResponse{}))
+
+	sorted := append(gin.RoutesInfo{}, routes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	for _, route := range sorted {
+		if !strings.HasPrefix(route.Path, APIPrefix+"/") && route.Path != "/health" {
+			continue
+		}
+		// Project prefixed routes repeat the API under /projects/:project
+		if strings.HasPrefix(route.Path, APIPrefix+"/projects/:project/") {
+			continue
+		}
+		unversioned := strings.Replace(route.Path, APIPrefix, "/api", 1)
+		doc := routeDocs[route.Method+" "+unversioned]
+
+		op := &OpenAPIOperation{
+			OperationID: handlerName(route.Handler),
+			Summary:     doc.summary,
+			Tags:        []string{routeTag(unversioned)},
+			Responses:   make(map[string]*OpenAPIResponse),
+		}
+		if route.Path == "/health" {
+			op.OperationID = "health"
+		}
+		if op.Summary == "" {
+			op.Summary = humanize(op.OperationID)
+		}
+
+		for _, name := range pathParams(route.Path) {
+			schema := &OpenAPISchema{Type: "string"}
+			if name == "id" && !strings.HasPrefix(unversioned, "/api/keys/") { // API keys are identified by name
+				schema = &OpenAPISchema{Type: "integer", Format: "int64"}
+			}
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: name, In: "path", Required: true, Schema: schema})
+		}
+		for _, q := range doc.query {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name: q.name, In: "query", Description: q.description, Schema: &OpenAPISchema{Type: q.typ},
+			})
+		}
+
+		if doc.request != nil {
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]OpenAPIMediaType{"application/json": {Schema: gen.schemaFor(reflect.TypeOf(doc.request))}},
+			}
+		} else if route.Method == http.MethodPost || route.Method == http.MethodPut {
+			// Handlers without a documented body still read JSON
+			op.RequestBody = &OpenAPIRequestBody{
+				Content: map[string]OpenAPIMediaType{"application/json": {Schema: &OpenAPISchema{Type: "object"}}},
+			}
+		}
+
+		success := &OpenAPIResponse{Description: "Success"}
+		switch {
+		case doc.contentType != "":
+			success.Content = map[string]OpenAPIMediaType{doc.contentType: {Schema: &OpenAPISchema{Type: "string", Format: "binary"}}}
+		case doc.response != nil:
+			success.Content = map[string]OpenAPIMediaType{"application/json": {Schema: gen.schemaFor(reflect.TypeOf(doc.response))}}
+		default:
+			success.Content = map[string]OpenAPIMediaType{"application/json": {Schema: &OpenAPISchema{Type: "object"}}}
+		}
+		op.Responses[successStatus(route.Method, unversioned)] = success
+
+		errorContent := map[string]OpenAPIMediaType{"application/json": {Schema: errorSchema}}
+		op.Responses["400"] = &OpenAPIResponse{Description: "Invalid request", Content: errorContent}
+		op.Responses["404"] = &OpenAPIResponse{Description: "Not found or no data", Content: errorContent}
+		op.Responses["500"] = &OpenAPIResponse{Description: "Internal error", Content: errorContent}
+		if route.Path != "/health" {
+			op.Responses["401"] = &OpenAPIResponse{Description: "Missing or invalid API key", Content: errorContent}
+			op.Responses["429"] = &OpenAPIResponse{Description: "Rate limit exceeded"}
+		}
+
+		path := openAPIPath(route.Path)
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		spec.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	return spec
+}
+
+// successStatus returns the status code of a successful response
+func successStatus(method, path string) string {
+	switch {
+	case path == "/api/ingest/metrics":
+		return "202"
+	case method == http.MethodPost && creates(path):
+		return "201"
+	default:
+		return "200"
+	}
+}
+
+// creates reports whether a POST route creates a resource
+func creates(path string) bool {
+	for _, p := range []string{"/api/targets/:name/events", "/api/rules", "/api/config/targets", "/api/config/derived-metrics",
+		"/api/maintenance", "/api/views", "/api/annotations", "/api/silences"} {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerName extracts the method name from a gin handler name,
+// e.g., "github.com/jiin/pondy/internal/api.(*Handler).GetTargets-fm" -> "GetTargets"
+func handlerName(handler string) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// humanize turns a handler name into a summary, e.g., "GetAlertDeliveries" -> "Get alert deliveries"
+func humanize(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte(' ')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// routeTag groups routes by their first segment after /api
+func routeTag(path string) string {
+	return strings.Split(strings.TrimPrefix(path, "/api/"), "/")[0]
+}
+
+// pathParams returns the parameter names of a gin path
+func pathParams(path string) []string {
+	var params []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			params = append(params, seg[1:])
+		}
+	}
+	return params
+}
+
+// openAPIPath converts gin parameters to OpenAPI templates, e.g., /targets/:name -> /targets/{name}
+func openAPIPath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			segs[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// schemaGenerator builds JSON schemas from Go types
+// Named structs become shared component schemas
+type schemaGenerator struct {
+	schemas map[string]*OpenAPISchema
+	types   map[string]reflect.Type // component name -> Go type
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) *OpenAPISchema {
+	if t.Kind() == reflect.Ptr {
+		s := g.schemaFor(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		s.Nullable = true
+		return s
+	}
+
+	switch {
+	case t == timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case t == reflect.TypeOf(time.Duration(0)):
+		return &OpenAPISchema{Type: "integer", Format: "int64", Description: "nanoseconds"}
+	case t == reflect.TypeOf(json.RawMessage(nil)):
+		return &OpenAPISchema{} // Embedded JSON of any shape
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + g.register(t)}
+	default:
+		// interface{} and anything else accepts any value
+		return &OpenAPISchema{}
+	}
+}
+
+// register adds a named struct to the components and returns its name
+// Types with the same name in different packages are prefixed with the package name
+func (g *schemaGenerator) register(t reflect.Type) string {
+	name := t.Name()
+	if existing, ok := g.types[name]; ok && existing != t {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	if _, ok := g.types[name]; ok {
+		return name
+	}
+
+	// Reserve the name first so recursive types terminate
+	g.types[name] = t
+	g.schemas[name] = &OpenAPISchema{Type: "object"}
+	g.schemas[name] = g.structSchema(t)
+	return name
+}
+
+// structSchema builds an object schema from exported fields and their json tags
+// Embedded structs are flattened like encoding/json does
+func (g *schemaGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := g.structSchema(ft)
+				for k, v := range embedded.Properties {
+					s.Properties[k] = v
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schemaFor(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at the generated spec
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>pondy API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/v1/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>`
+
+// serveOpenAPI registers the spec and Swagger UI routes, under both API prefixes like other routes
+// Both are public so the UI can load the spec; the spec contains no configuration
+func serveOpenAPI(r *gin.Engine) {
+	spec := BuildOpenAPISpec(r.Routes())
+
+	serveSpec := func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	}
+	serveUI := func(c *gin.Context) {
+		// API responses deny everything, the UI needs its scripts and styles
+		c.Header("Content-Security-Policy", fmt.Sprintf(
+			"default-src 'none'; script-src %[1]s 'unsafe-inline'; style-src %[1]s; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'",
+			"https://unpkg.com"))
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+	}
+
+	r.GET(APIPrefix+"/openapi.json", serveSpec)
+	r.GET(APIPrefix+"/docs", serveUI)
+
+	legacy := r.Group("/api", DeprecationMiddleware("/api", APIPrefix))
+	legacy.GET("/openapi.json", serveSpec)
+	legacy.GET("/docs", serveUI)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestBuildOpenAPISpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	r := gin.New()
+	r.GET("/api/v1/targets/:name/history", h.GetTargetHistory)
+	r.POST("/api/v1/annotations", h.CreateAnnotation)
+	r.DELETE("/api/v1/silences/:id", h.DeleteSilence)
+	r.GET("/api/targets/:name/history", h.GetTargetHistory)
+	r.GET("/assets/*filepath", func(c *gin.Context) {})
+
+	spec := BuildOpenAPISpec(r.Routes())
+
+	if _, ok := spec.Paths["/assets/{filepath}"]; ok {
+		t.Error("static routes should not be documented")
+	}
+	if _, ok := spec.Paths["/api/targets/{name}/history"]; ok {
+		t.Error("deprecated unversioned routes should not be documented")
+	}
+
+	history := spec.Paths["/api/v1/targets/{name}/history"]["get"]
+	if history == nil {
+		t.Fatalf("history route missing, paths = %v", spec.Paths)
+	}
+	if history.OperationID != "GetTargetHistory" || history.Tags[0] != "targets" {
+		t.Errorf("operation = %s tags = %v", history.OperationID, history.Tags)
+	}
+	params := make(map[string]string)
+	for _, p := range history.Parameters {
+		params[p.Name] = p.In
+	}
+	for name, in := range map[string]string{"name": "path", "range": "query", "limit": "query", "annotations": "query"} {
+		if params[name] != in {
+			t.Errorf("parameter %s in %q, want %q", name, params[name], in)
+		}
+	}
+	if ref := history.Responses["200"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/HistoryResponse" {
+		t.Errorf("history response ref = %s", ref)
+	}
+
+	create := spec.Paths["/api/v1/annotations"]["post"]
+	if create == nil || create.RequestBody == nil || create.Responses["201"] == nil {
+		t.Fatalf("create annotation = %+v, want request body and 201", create)
+	}
+
+	del := spec.Paths["/api/v1/silences/{id}"]["delete"]
+	if del == nil || del.Summary != "Delete silence" || del.Parameters[0].Schema.Type != "integer" {
+		t.Errorf("delete silence = %+v", del)
+	}
+
+	// Referenced schemas are generated, nested types included
+	for _, name := range []string{"HistoryResponse", "PoolMetrics", "Annotation", "AnnotationInput", "ErrorResponse"} {
+		if spec.Components.Schemas[name] == nil {
+			t.Errorf("schema %s missing", name)
+		}
+	}
+	metrics := spec.Components.Schemas["PoolMetrics"]
+	if metrics.Properties["timestamp"].Format != "date-time" {
+		t.Errorf("timestamp = %+v, want date-time", metrics.Properties["timestamp"])
+	}
+	if metrics.Properties["derived"].AdditionalProperties.Type != "number" {
+		t.Errorf("derived = %+v, want map of numbers", metrics.Properties["derived"])
+	}
+
+	if _, err := json.Marshal(spec); err != nil {
+		t.Fatalf("marshal spec: %v", err)
+	}
+}
+
+func TestSchemaGenerator_NameCollision(t *testing.T) {
+	gen := &schemaGenerator{schemas: make(map[string]*OpenAPISchema), types: make(map[string]reflect.Type)}
+
+	a := gen.schemaFor(reflect.TypeOf(models.AlertRule{}))
+	b := gen.schemaFor(reflect.TypeOf(config.AlertRule{}))
+	if a.Ref == b.Ref {
+		t.Fatalf("both types share %s", a.Ref)
+	}
+	if b.Ref != "#/components/schemas/ConfigAlertRule" {
+		t.Errorf("config rule ref = %s", b.Ref)
+	}
+	// Registering again reuses the name
+	if again := gen.schemaFor(reflect.TypeOf(config.AlertRule{})); again.Ref != b.Ref {
+		t.Errorf("second registration = %s, want %s", again.Ref, b.Ref)
+	}
+}
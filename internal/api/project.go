@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Project scoping of API requests
+const (
+	ProjectHeader      = "X-Pondy-Project" // Selects a project when the path has no /projects/:project prefix
+	ContextKeyProjects = "projects"        // projectScope of the request
+
+	projectRoutePrefix = "/projects/:project" // Route prefix scoping a request to one project
+)
+
+// projectScope lists the projects a request may see; nil allows all
+type projectScope []string
+
+// allows returns whether the scope includes a project
+func (s projectScope) allows(project string) bool {
+	return s == nil || slices.Contains(s, project)
+}
+
+// scopeOf returns the project scope of the request
+func scopeOf(c *gin.Context) projectScope {
+	if v, ok := c.Get(ContextKeyProjects); ok {
+		if scope, ok := v.(projectScope); ok {
+			return scope
+		}
+	}
+	return nil
+}
+
+// ProjectMiddleware narrows the request to the project named by the /projects/:project prefix
+// or the X-Pondy-Project header, within the projects the API key was granted
+// Requests for a target outside the scope get 404, as if the target didn't exist
+func ProjectMiddleware(cfgMgr *config.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := scopeOf(c)
+
+		requested := c.Param("project")
+		if requested == "" {
+			requested = strings.TrimSpace(c.GetHeader(ProjectHeader))
+		}
+		if requested != "" {
+			if !scope.allows(requested) {
+				RespondError(c, http.StatusForbidden, fmt.Sprintf("API key has no access to project '%s'", requested))
+				c.Abort()
+				return
+			}
+			scope = projectScope{requested}
+			c.Set(ContextKeyProjects, scope)
+		}
+
+		if name := c.Param("name"); name != "" && strings.Contains(c.FullPath(), "/targets/:name") {
+			if !scope.allows(projectOf(cfgMgr.Get(), name)) {
+				RespondNotFound(c, fmt.Sprintf("target '%s' not found", name))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// projectOf returns the project of a target; targets not in the config belong to the default project
+func projectOf(cfg *config.Config, target string) string {
+	for i := range cfg.Targets {
+		if cfg.Targets[i].Name == target {
+			return cfg.Targets[i].GetProject()
+		}
+	}
+	return config.DefaultProject
+}
+
+// targetVisible returns whether the request may see a target
+func (h *Handler) targetVisible(c *gin.Context, target string) bool {
+	scope := scopeOf(c)
+	return scope == nil || scope.allows(projectOf(h.cfg(), target))
+}
+
+// visibleTargetNames returns the config targets the request may see, or nil when unrestricted
+func (h *Handler) visibleTargetNames(c *gin.Context) []string {
+	scope := scopeOf(c)
+	if scope == nil {
+		return nil
+	}
+	names := []string{}
+	for _, t := range h.cfg().Targets {
+		if scope.allows(t.GetProject()) {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// scopedVisible returns whether the request may see a rule or maintenance window of a project
+// Items of all projects (empty project) are visible to everyone
+func scopedVisible(c *gin.Context, project string) bool {
+	return project == "" || scopeOf(c).allows(project)
+}
+
+// scopedWritable returns whether the request may change a rule or maintenance window of a project
+// Items of all projects can only be changed without a project scope
+func scopedWritable(c *gin.Context, project string) bool {
+	scope := scopeOf(c)
+	if project == "" {
+		return scope == nil
+	}
+	return scope.allows(project)
+}
+
+// respondNotWritable rejects changing an item of all projects from a project scoped request
+func respondNotWritable(c *gin.Context, kind string) {
+	RespondError(c, http.StatusForbidden, kind+" applies to all projects and can only be changed without a project scope")
+}
+
+// UnscopedMiddleware rejects project scoped requests to endpoints whose data spans every
+// project, e.g., backups, storage maintenance and the audit log
+func UnscopedMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopeOf(c) != nil {
+			RespondError(c, http.StatusForbidden, "this endpoint applies to all projects and requires a request without a project scope")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// projectForWrite resolves the project of a created or updated item from the request body
+// A scoped request defaults to its only project and must name one when it has several
+// On failure an error response is sent and false returned
+func projectForWrite(c *gin.Context, requested string) (string, bool) {
+	scope := scopeOf(c)
+	if requested == "" {
+		switch {
+		case scope == nil:
+			return "", true
+		case len(scope) == 1:
+			return scope[0], true
+		default:
+			RespondBadRequest(c, "project is required: the API key has access to several projects")
+			return "", false
+		}
+	}
+	if !scope.allows(requested) {
+		RespondError(c, http.StatusForbidden, fmt.Sprintf("API key has no access to project '%s'", requested))
+		return "", false
+	}
+	return requested, true
+}
+
+// ProjectSummary is a project and how many targets it has
+type ProjectSummary struct {
+	Name    string `json:"name"`
+	Targets int    `json:"targets"`
+}
+
+// ProjectsResponse lists projects
+type ProjectsResponse struct {
+	Projects []ProjectSummary `json:"projects"`
+}
+
+// GetProjects lists the projects the request may see
+func (h *Handler) GetProjects(c *gin.Context) {
+	scope := scopeOf(c)
+	counts := make(map[string]int)
+	for _, t := range h.cfg().Targets {
+		counts[t.GetProject()]++
+	}
+
+	projects := []ProjectSummary{}
+	for _, name := range h.cfg().Projects() {
+		if scope.allows(name) {
+			projects = append(projects, ProjectSummary{Name: name, Targets: counts[name]})
+		}
+	}
+	c.JSON(http.StatusOK, ProjectsResponse{Projects: projects})
+}
+
+// scopedAlertStats computes alert statistics over the alerts of the given targets
+func (h *Handler) scopedAlertStats(ctx context.Context, targets []string) (*models.AlertStats, error) {
+	alerts, _, err := h.store.QueryAlerts(ctx, models.AlertQuery{TargetIn: targets})
+	if err != nil {
+		return nil, err
+	}
+	stats := &models.AlertStats{
+		BySeverity: make(map[string]int),
+		ByTarget:   make(map[string]int),
+		ByRule:     make(map[string]int),
+	}
+	for _, a := range alerts {
+		stats.TotalAlerts++
+		if a.Status != models.AlertStatusFired {
+			stats.ResolvedAlerts++
+			continue
+		}
+		stats.ActiveAlerts++
+		stats.BySeverity[a.Severity]++
+		stats.ByTarget[a.TargetName]++
+		stats.ByRule[a.RuleName]++
+	}
+	return stats, nil
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func scopedContext(scope projectScope) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	if scope != nil {
+		c.Set(ContextKeyProjects, scope)
+	}
+	return c, w
+}
+
+func TestProjectForWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     projectScope
+		requested string
+		want      string
+		status    int // 0 when accepted
+	}{
+		{"unrestricted keeps all projects", nil, "", "", 0},
+		{"unrestricted picks any project", nil, "payments", "payments", 0},
+		{"single project is the default", projectScope{"payments"}, "", "payments", 0},
+		{"several projects need a choice", projectScope{"payments", "search"}, "", "", http.StatusBadRequest},
+		{"allowed project", projectScope{"payments", "search"}, "search", "search", 0},
+		{"other project is forbidden", projectScope{"payments"}, "search", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := scopedContext(tt.scope)
+			got, ok := projectForWrite(c, tt.requested)
+			if tt.status != 0 {
+				if ok || w.Code != tt.status {
+					t.Errorf("projectForWrite = %q, %v (status %d), want rejected with %d", got, ok, w.Code, tt.status)
+				}
+				return
+			}
+			if !ok || got != tt.want {
+				t.Errorf("projectForWrite = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopedVisibleAndWritable(t *testing.T) {
+	scoped, _ := scopedContext(projectScope{"payments"})
+	if !scopedVisible(scoped, "") || !scopedVisible(scoped, "payments") || scopedVisible(scoped, "search") {
+		t.Error("scoped request should see items of all projects and of its own project only")
+	}
+	if scopedWritable(scoped, "") || !scopedWritable(scoped, "payments") || scopedWritable(scoped, "search") {
+		t.Error("scoped request should only change items of its own project")
+	}
+
+	unrestricted, _ := scopedContext(nil)
+	if !scopedWritable(unrestricted, "") || !scopedWritable(unrestricted, "search") {
+		t.Error("unrestricted request should change items of any project")
+	}
+}
+
+func TestScopeTargets(t *testing.T) {
+	response := TargetsResponse{
+		Targets: []models.TargetStatus{
+			{Name: "orders", Group: "prod", Project: "payments"},
+			{Name: "billing", Group: "dev", Project: "payments"},
+			{Name: "search-api", Group: "staging", Project: "search"},
+		},
+		Groups: []string{"dev", "prod", "staging"},
+	}
+
+	c, _ := scopedContext(projectScope{"payments"})
+	scoped := scopeTargets(c, response)
+	if len(scoped.Targets) != 2 || scoped.Targets[0].Name != "orders" || scoped.Targets[1].Name != "billing" {
+		t.Errorf("targets = %+v, want orders and billing", scoped.Targets)
+	}
+	if len(scoped.Groups) != 2 || scoped.Groups[0] != "dev" || scoped.Groups[1] != "prod" {
+		t.Errorf("groups = %v, want [dev prod]", scoped.Groups)
+	}
+
+	c, _ = scopedContext(nil)
+	if all := scopeTargets(c, response); len(all.Targets) != 3 || len(all.Groups) != 3 {
+		t.Errorf("unrestricted response changed: %+v", all)
+	}
+}
+
+func TestUnscopedMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if project := c.GetHeader(ProjectHeader); project != "" {
+			c.Set(ContextKeyProjects, projectScope{project})
+		}
+	})
+	r.GET("/audit", UnscopedMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		project string
+		want    int
+	}{
+		{"", http.StatusOK},
+		{"payments", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/audit", nil)
+		if tt.project != "" {
+			req.Header.Set(ProjectHeader, tt.project)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("GET /audit with project %q = %d, want %d", tt.project, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+)
+
+// ContextKeyOwnRateLimit marks requests limited by their API key's quota instead of the general rate limit
+const ContextKeyOwnRateLimit = "own_rate_limit"
+
+// QuotaTracker enforces the per-API-key quotas of server.api_keys in memory
+// Requests are counted per calendar minute and exported rows per day; counts reset on restart
+type QuotaTracker struct {
+	mu   sync.Mutex
+	keys map[string]*keyQuotaState
+	now  func() time.Time
+}
+
+type keyQuotaState struct {
+	minute         time.Time // Start of the minute requests are counted in
+	minuteRequests int
+	day            string // Date export rows are counted on
+	exportRows     int64
+}
+
+// NewQuotaTracker creates an empty quota tracker
+func NewQuotaTracker() *QuotaTracker {
+	return &QuotaTracker{keys: make(map[string]*keyQuotaState), now: time.Now}
+}
+
+func (q *QuotaTracker) state(key string) *keyQuotaState {
+	s, ok := q.keys[key]
+	if !ok {
+		s = &keyQuotaState{}
+		q.keys[key] = s
+	}
+	return s
+}
+
+// AllowRequest counts a request of the key against its per-minute quota
+// Returns false and the time until the next minute when the quota is used up
+func (q *QuotaTracker) AllowRequest(key string, perMinute int) (bool, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	minute := now.Truncate(time.Minute)
+	s := q.state(key)
+	if !s.minute.Equal(minute) {
+		s.minute, s.minuteRequests = minute, 0
+	}
+	if s.minuteRequests >= perMinute {
+		return false, minute.Add(time.Minute).Sub(now)
+	}
+	s.minuteRequests++
+	return true, 0
+}
+
+// ExportRowsLeft returns how many rows the key may still export today, or -1 without a limit
+func (q *QuotaTracker) ExportRowsLeft(key string, perDay int64, loc *time.Location) int64 {
+	if perDay <= 0 {
+		return -1
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s := q.state(key)
+	if day := q.now().In(loc).Format("2006-01-02"); s.day != day {
+		s.day, s.exportRows = day, 0
+	}
+	return max(perDay-s.exportRows, 0)
+}
+
+// AddExportRows counts rows exported by the key today
+func (q *QuotaTracker) AddExportRows(key string, rows int64, loc *time.Location) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s := q.state(key)
+	if day := q.now().In(loc).Format("2006-01-02"); s.day != day {
+		s.day, s.exportRows = day, 0
+	}
+	s.exportRows += rows
+}
+
+// KeyQuotaStatus is the quota use of an API key; zero limits are unlimited
+type KeyQuotaStatus struct {
+	RequestsPerMinute  int       `json:"requests_per_minute"`
+	RequestsThisMinute int       `json:"requests_this_minute"`
+	ExportRowsPerDay   int64     `json:"export_rows_per_day"`
+	ExportRowsToday    int64     `json:"export_rows_today"`
+	ExportRowsResetAt  time.Time `json:"export_rows_reset_at"` // Next midnight in the configured timezone
+}
+
+// Status returns the current quota use of a key
+func (q *QuotaTracker) Status(key string, quota config.APIKeyQuota, loc *time.Location) KeyQuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	local := now.In(loc)
+	status := KeyQuotaStatus{
+		RequestsPerMinute: quota.RequestsPerMinute,
+		ExportRowsPerDay:  quota.ExportRowsPerDay,
+		ExportRowsResetAt: time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc),
+	}
+	if s, ok := q.keys[key]; ok {
+		if s.minute.Equal(now.Truncate(time.Minute)) {
+			status.RequestsThisMinute = s.minuteRequests
+		}
+		if s.day == local.Format("2006-01-02") {
+			status.ExportRowsToday = s.exportRows
+		}
+	}
+	return status
+}
+
+// QuotaMiddleware applies the per-minute request quota of the authenticated key
+// Keys with a quota skip the general rate limit, so automation clients can be given more room than anonymous traffic
+func QuotaMiddleware(cfgMgr *config.Manager, q *QuotaTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.GetString(ContextKeyAPIKey)
+		if name == "" {
+			c.Next()
+			return
+		}
+		server := cfgMgr.Get().Server
+		key := server.APIKeyByName(name)
+		if key == nil || key.Quota.RequestsPerMinute <= 0 {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter := q.AllowRequest(name, key.Quota.RequestsPerMinute)
+		if !allowed {
+			seconds := int(retryAfter.Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       fmt.Sprintf("quota of %d requests per minute exceeded for API key '%s'", key.Quota.RequestsPerMinute, name),
+				"retry_after": fmt.Sprintf("%ds", seconds),
+			})
+			c.Abort()
+			return
+		}
+		c.Set(ContextKeyOwnRateLimit, true)
+		c.Next()
+	}
+}
+
+// rateLimitKey identifies the client of a request for rate limiting: its API key, or its IP without one
+func rateLimitKey(c *gin.Context) string {
+	if name := c.GetString(ContextKeyAPIKey); name != "" {
+		return "key:" + name
+	}
+	return c.ClientIP()
+}
+
+// exportRowsLeft returns the rows the request's key may still export today, or -1 without a limit
+func (h *Handler) exportRowsLeft(c *gin.Context) int64 {
+	key := h.cfg().Server.APIKeyByName(c.GetString(ContextKeyAPIKey))
+	if key == nil || h.quotas == nil {
+		return -1
+	}
+	return h.quotas.ExportRowsLeft(key.Name, key.Quota.ExportRowsPerDay, h.cfg().GetLocation())
+}
+
+// KeyUsageResponse is the usage and quota of one API key
+type KeyUsageResponse struct {
+	Since time.Time      `json:"since"`
+	Usage KeyUsage       `json:"usage"`
+	Quota KeyQuotaStatus `json:"quota"`
+}
+
+// GetKeyUsage returns the usage counters and quota use of an API key by name
+func (h *Handler) GetKeyUsage(c *gin.Context) {
+	name := c.Param("id")
+	key := h.cfg().Server.APIKeyByName(name)
+	if key == nil && name != AnonymousKeyName {
+		RespondNotFound(c, fmt.Sprintf("API key '%s' not found", name))
+		return
+	}
+
+	var quota config.APIKeyQuota
+	if key != nil {
+		quota = key.Quota
+	}
+	usage, ok := h.usage.Get(name)
+	if !ok {
+		usage = KeyUsage{Name: name, Endpoints: map[string]int64{}}
+	}
+	c.JSON(http.StatusOK, KeyUsageResponse{
+		Since: h.usage.Since(),
+		Usage: usage,
+		Quota: h.quotas.Status(name, quota, h.cfg().GetLocation()),
+	})
+}
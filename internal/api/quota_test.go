@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestQuotaTracker_AllowRequest(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 50, 0, time.UTC)
+	q := NewQuotaTracker()
+	q.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := q.AllowRequest("ci", 3); !ok {
+			t.Fatalf("request %d rejected within quota", i+1)
+		}
+	}
+	ok, retry := q.AllowRequest("ci", 3)
+	if ok || retry != 10*time.Second {
+		t.Errorf("AllowRequest over quota = %v, %v, want false, 10s", ok, retry)
+	}
+	if ok, _ := q.AllowRequest("grafana", 3); !ok {
+		t.Error("quota of one key should not limit another")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, _ := q.AllowRequest("ci", 3); !ok {
+		t.Error("quota should reset in the next minute")
+	}
+}
+
+func TestQuotaTracker_ExportRows(t *testing.T) {
+	loc := time.UTC
+	now := time.Date(2024, 1, 15, 23, 0, 0, 0, loc)
+	q := NewQuotaTracker()
+	q.now = func() time.Time { return now }
+
+	if left := q.ExportRowsLeft("ci", 0, loc); left != -1 {
+		t.Errorf("ExportRowsLeft without limit = %d, want -1", left)
+	}
+	q.AddExportRows("ci", 700, loc)
+	if left := q.ExportRowsLeft("ci", 1000, loc); left != 300 {
+		t.Errorf("ExportRowsLeft = %d, want 300", left)
+	}
+	q.AddExportRows("ci", 500, loc)
+	if left := q.ExportRowsLeft("ci", 1000, loc); left != 0 {
+		t.Errorf("ExportRowsLeft over quota = %d, want 0", left)
+	}
+
+	status := q.Status("ci", config.APIKeyQuota{ExportRowsPerDay: 1000}, loc)
+	if status.ExportRowsToday != 1200 || !status.ExportRowsResetAt.Equal(time.Date(2024, 1, 16, 0, 0, 0, 0, loc)) {
+		t.Errorf("Status = %+v", status)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if left := q.ExportRowsLeft("ci", 1000, loc); left != 1000 {
+		t.Errorf("ExportRowsLeft on the next day = %d, want 1000", left)
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "10.0.0.1:1234"
+
+	if got := rateLimitKey(c); got != "10.0.0.1" {
+		t.Errorf("anonymous rateLimitKey = %q, want client IP", got)
+	}
+	c.Set(ContextKeyAPIKey, "ci")
+	if got := rateLimitKey(c); got != "key:ci" {
+		t.Errorf("rateLimitKey = %q, want key:ci", got)
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/analyzer"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// defaultSnooze is how long a recommendation is snoozed without a duration
+const defaultSnooze = 24 * time.Hour
+
+// RecommendationsResponse is the response for the recommendation tracking endpoint
+type RecommendationsResponse struct {
+	Recommendations []models.RecommendationRecord `json:"recommendations"`
+	Total           int                           `json:"total"`
+}
+
+// trackRecommendations persists the recommendations of an analysis and moves the ones
+// acted upon to Suppressed. Recommendations sharing a type share one record, holding
+// the most severe advice. Tracking errors are logged, the analysis is still returned.
+func (h *Handler) trackRecommendations(ctx context.Context, result *analyzer.AnalysisResult, now time.Time) {
+	records, err := h.store.GetRecommendations(ctx, result.TargetName, "")
+	if err != nil {
+		log.Printf("Failed to load recommendations of %s: %v", result.TargetName, err)
+		return
+	}
+	byType := make(map[string]*models.RecommendationRecord, len(records))
+	for i := range records {
+		byType[records[i].Type] = &records[i]
+	}
+
+	// Most severe advice per type, in analysis order
+	var types []string
+	advice := make(map[string]analyzer.Recommendation)
+	for _, rec := range result.Recommendations {
+		if rec.Type == "status" {
+			continue
+		}
+		prev, seen := advice[rec.Type]
+		if !seen {
+			types = append(types, rec.Type)
+		}
+		if !seen || models.SeverityRank(rec.Severity) > models.SeverityRank(prev.Severity) {
+			advice[rec.Type] = rec
+		}
+	}
+
+	for _, typ := range types {
+		rec := advice[typ]
+		record, exists := byType[typ]
+		if !exists {
+			record = &models.RecommendationRecord{
+				TargetName:  result.TargetName,
+				Type:        typ,
+				Status:      models.RecommendationOpen,
+				FirstSeenAt: now,
+			}
+		} else if record.Status != models.RecommendationOpen && record.ShouldReraise(rec.Current, rec.Severity, now) {
+			record.SetStatus(models.RecommendationOpen, "", nil, now)
+		}
+		record.Current = rec.Current
+		record.Recommended = rec.Recommended
+		record.Reason = rec.Reason
+		record.Severity = rec.Severity
+		record.LastSeenAt = now
+
+		if exists {
+			err = h.store.UpdateRecommendation(ctx, record)
+		} else {
+			err = h.store.SaveRecommendation(ctx, record)
+		}
+		if err != nil {
+			log.Printf("Failed to save recommendation %s of %s: %v", typ, result.TargetName, err)
+			continue
+		}
+		byType[typ] = record
+	}
+
+	visible := make([]analyzer.Recommendation, 0, len(result.Recommendations))
+	for _, rec := range result.Recommendations {
+		if record, ok := byType[rec.Type]; ok && rec.Type != "status" {
+			rec.ID = record.ID
+			rec.Status = record.Status
+			if record.Status != models.RecommendationOpen {
+				result.Suppressed = append(result.Suppressed, rec)
+				continue
+			}
+		}
+		visible = append(visible, rec)
+	}
+	result.Recommendations = visible
+}
+
+// GetRecommendationRecords lists tracked recommendations, optionally by target and status
+func (h *Handler) GetRecommendationRecords(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !models.IsValidRecommendationStatus(status) {
+		RespondBadRequest(c, "status must be open, applied, dismissed, or snoozed")
+		return
+	}
+
+	records, err := h.store.GetRecommendations(c.Request.Context(), c.Query("target"), status)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	records = slices.DeleteFunc(records, func(r models.RecommendationRecord) bool {
+		return !h.targetVisible(c, r.TargetName)
+	})
+
+	c.JSON(http.StatusOK, RecommendationsResponse{
+		Recommendations: records,
+		Total:           len(records),
+	})
+}
+
+// UpdateRecommendationStatus marks a recommendation applied, dismissed, snoozed or open again
+func (h *Handler) UpdateRecommendationStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid recommendation ID")
+		return
+	}
+
+	var input models.RecommendationStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid input: "+err.Error())
+		return
+	}
+	if !models.IsValidRecommendationStatus(input.Status) {
+		RespondBadRequest(c, "status must be open, applied, dismissed, or snoozed")
+		return
+	}
+	if len(input.Note) > 5000 {
+		RespondBadRequest(c, "note must be less than 5000 characters")
+		return
+	}
+
+	now := time.Now()
+	var snoozeUntil *time.Time
+	if input.Status == models.RecommendationSnoozed {
+		d := defaultSnooze
+		if input.Duration != "" {
+			d, err = time.ParseDuration(input.Duration)
+			if err != nil || d <= 0 {
+				RespondBadRequest(c, "invalid duration, use Go duration format (e.g., 24h, 168h)")
+				return
+			}
+		}
+		until := now.Add(d)
+		snoozeUntil = &until
+	}
+
+	record, err := h.store.GetRecommendation(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if record == nil || !h.targetVisible(c, record.TargetName) {
+		RespondNotFound(c, "recommendation not found")
+		return
+	}
+
+	auditBefore(c, record)
+	record.SetStatus(input.Status, input.Note, snoozeUntil, now)
+	if err := h.store.UpdateRecommendation(c.Request.Context(), record); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	auditAfter(c, record)
+	c.JSON(http.StatusOK, record)
+}
+
+// markRecommendationApplied marks the open recommendation of a type applied, if there is one
+func (h *Handler) markRecommendationApplied(ctx context.Context, target, typ, note string) {
+	records, err := h.store.GetRecommendations(ctx, target, models.RecommendationOpen)
+	if err != nil {
+		log.Printf("Failed to load recommendations of %s: %v", target, err)
+		return
+	}
+	for i := range records {
+		if records[i].Type != typ {
+			continue
+		}
+		records[i].SetStatus(models.RecommendationApplied, note, nil, time.Now())
+		if err := h.store.UpdateRecommendation(ctx, &records[i]); err != nil {
+			log.Printf("Failed to mark recommendation %d applied: %v", records[i].ID, err)
+		}
+	}
+}
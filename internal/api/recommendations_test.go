@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/analyzer"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestTrackRecommendations(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "recommendations.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+	h := &Handler{store: store}
+
+	analyze := func(current, severity string, now time.Time) *analyzer.AnalysisResult {
+		result := &analyzer.AnalysisResult{
+			TargetName: "orders",
+			Recommendations: []analyzer.Recommendation{
+				{Type: "maximumPoolSize", Current: current, Recommended: "30", Severity: models.SeverityInfo},
+				{Type: "maximumPoolSize", Current: current, Recommended: "40", Severity: severity},
+				{Type: "status", Severity: models.SeverityInfo},
+			},
+		}
+		h.trackRecommendations(context.Background(), result, now)
+		return result
+	}
+
+	now := time.Now()
+	result := analyze("20", models.SeverityWarning, now)
+	if len(result.Recommendations) != 3 || result.Recommendations[0].ID == 0 || result.Recommendations[0].Status != models.RecommendationOpen {
+		t.Fatalf("first analysis = %+v", result.Recommendations)
+	}
+	if result.Recommendations[2].ID != 0 {
+		t.Error("status recommendations should not be tracked")
+	}
+
+	// One record per type, holding the most severe advice
+	records, _ := store.GetRecommendations(context.Background(), "orders", "")
+	if len(records) != 1 || records[0].Recommended != "40" {
+		t.Fatalf("records = %+v", records)
+	}
+
+	record := records[0]
+	record.SetStatus(models.RecommendationDismissed, "capacity is fixed", nil, now)
+	if err := store.UpdateRecommendation(context.Background(), &record); err != nil {
+		t.Fatalf("UpdateRecommendation() error = %v", err)
+	}
+
+	result = analyze("20", models.SeverityWarning, now.Add(time.Minute))
+	if len(result.Recommendations) != 1 || len(result.Suppressed) != 2 {
+		t.Fatalf("dismissed analysis = %+v / %+v", result.Recommendations, result.Suppressed)
+	}
+
+	// Rising severity re-raises a dismissal
+	result = analyze("20", models.SeverityCritical, now.Add(2*time.Minute))
+	if len(result.Suppressed) != 0 || result.Recommendations[0].Status != models.RecommendationOpen {
+		t.Fatalf("re-raised analysis = %+v / %+v", result.Recommendations, result.Suppressed)
+	}
+	got, _ := store.GetRecommendation(context.Background(), record.ID)
+	if got.Note != "" || got.FirstSeenAt.Unix() != now.Unix() {
+		t.Errorf("re-raised record = %+v", got)
+	}
+}
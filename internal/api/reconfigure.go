@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/collector"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// ApplyRecommendationRequest applies a recommended pool setting to a target's instances
+type ApplyRecommendationRequest struct {
+	Setting string `json:"setting"` // Recommendation type: maximumPoolSize, minimumIdle or connectionTimeout
+	Value   string `json:"value"`   // Recommended value, e.g., "30" or "45000ms"
+	Confirm bool   `json:"confirm"` // Must be true; GET the same path to preview
+}
+
+// ApplyRecommendationResponse is the preview or result of applying a pool setting
+type ApplyRecommendationResponse struct {
+	TargetName string                        `json:"target_name"`
+	Setting    string                        `json:"setting"`
+	Property   string                        `json:"property"`
+	Mode       string                        `json:"mode"`
+	Value      string                        `json:"value"`
+	Applied    int                           `json:"applied"` // Instances changed, 0 for a preview
+	Changes    []collector.PoolSettingChange `json:"changes"`
+
+	// Rollback restores the previous value, when all instances had the same known value
+	Rollback *ApplyRecommendationRequest `json:"rollback,omitempty"`
+}
+
+// PreviewRecommendation shows the current values a pool setting change would replace
+func (h *Handler) PreviewRecommendation(c *gin.Context) {
+	req := ApplyRecommendationRequest{Setting: c.Query("setting"), Value: c.Query("value")}
+	h.applyRecommendation(c, req, true)
+}
+
+// ApplyRecommendation applies a recommended pool setting to the running instances of a target
+func (h *Handler) ApplyRecommendation(c *gin.Context) {
+	var req ApplyRecommendationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	if !req.Confirm {
+		RespondBadRequest(c, "confirm must be true to apply; GET the same path to preview the change")
+		return
+	}
+	h.applyRecommendation(c, req, false)
+}
+
+func (h *Handler) applyRecommendation(c *gin.Context, req ApplyRecommendationRequest, dryRun bool) {
+	if h.collectors == nil {
+		RespondError(c, http.StatusServiceUnavailable, "collectors not initialized")
+		return
+	}
+
+	name := c.Param("name")
+	target, err := h.cfgMgr.GetTarget(name)
+	if err != nil {
+		RespondNotFound(c, err.Error())
+		return
+	}
+	rc := target.Reconfigure
+	if rc == nil || !rc.Enabled {
+		RespondBadRequest(c, fmt.Sprintf("reconfiguration is not enabled for target %s", name))
+		return
+	}
+	if err := rc.Validate(); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	if !collector.IsPoolSetting(req.Setting) {
+		RespondBadRequest(c, "setting must be maximumPoolSize, minimumIdle or connectionTimeout")
+		return
+	}
+	value, err := parsePoolSettingValue(req.Value)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	changes, err := h.collectors.ApplyPoolSetting(c.Request.Context(), name, rc, req.Setting, value, dryRun)
+	switch {
+	case errors.Is(err, collector.ErrInstanceNotFound):
+		RespondNotFound(c, err.Error())
+		return
+	case err != nil:
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	resp := ApplyRecommendationResponse{
+		TargetName: name,
+		Setting:    req.Setting,
+		Property:   collector.PoolSettingProperty(rc, req.Setting),
+		Mode:       rc.GetMode(),
+		Value:      value,
+		Changes:    changes,
+	}
+	previous := make(map[string]bool)
+	for _, ch := range changes {
+		if ch.Applied {
+			resp.Applied++
+		}
+		previous[ch.Previous] = true
+	}
+	if len(previous) == 1 && !previous[""] {
+		resp.Rollback = &ApplyRecommendationRequest{Setting: req.Setting, Value: changes[0].Previous, Confirm: true}
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if resp.Applied == 0 {
+		RespondError(c, http.StatusBadGateway, fmt.Sprintf("%s was not applied to any instance: %s", req.Setting, changes[0].Error))
+		return
+	}
+
+	// A config event lets regression analysis compare metrics before and after the change
+	event := &models.Event{
+		TargetName:  name,
+		Type:        models.EventTypeConfig,
+		Version:     fmt.Sprintf("%s=%s", req.Setting, value),
+		Description: fmt.Sprintf("Set %s to %s on %d of %d instances", resp.Property, value, resp.Applied, len(changes)),
+		Timestamp:   time.Now(),
+	}
+	if err := h.store.SaveEvent(c.Request.Context(), event); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	h.markRecommendationApplied(c.Request.Context(), name, req.Setting, event.Description)
+
+	auditBefore(c, previousValues(changes))
+	auditAfter(c, resp)
+	c.JSON(http.StatusOK, resp)
+}
+
+// parsePoolSettingValue accepts a positive integer, with an optional ms suffix as in recommendations
+func parsePoolSettingValue(value string) (string, error) {
+	v := strings.TrimSuffix(strings.TrimSpace(value), "ms")
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return "", fmt.Errorf("value must be a positive integer, got %q", value)
+	}
+	return strconv.Itoa(n), nil
+}
+
+// previousValues maps instances to their value before a change
+func previousValues(changes []collector.PoolSettingChange) map[string]string {
+	values := make(map[string]string, len(changes))
+	for _, ch := range changes {
+		values[ch.Instance] = ch.Previous
+	}
+	return values
+}
@@ -0,0 +1,25 @@
+package api
+
+import "testing"
+
+func TestParsePoolSettingValue(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"30", "30", false},
+		{"45000ms", "45000", false},
+		{" 10 ", "10", false},
+		{"0", "", true},
+		{"-5", "", true},
+		{"No changes needed", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePoolSettingValue(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePoolSettingValue(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
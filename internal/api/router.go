@@ -3,106 +3,266 @@ package api
 import (
 	"embed"
 	"io/fs"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/collector"
 	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/hooks"
+	"github.com/jiin/pondy/internal/lifecycle"
 	"github.com/jiin/pondy/internal/storage"
 )
 
-func NewRouter(cfgMgr *config.Manager, store storage.Storage, alertMgr *alerter.Manager, webFS embed.FS) *gin.Engine {
+// APIPrefix is the prefix of the current API version
+const APIPrefix = "/api/v1"
+
+// NewRouter builds the HTTP router
+// The rate limiters it creates are registered with lc to be stopped on shutdown
+func NewRouter(cfgMgr *config.Manager, store storage.Storage, alertMgr *alerter.Manager, collectors *collector.Manager, webFS embed.FS, lc *lifecycle.Manager) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
 
-	// Rate limiters
-	// General API: 100 requests per second, burst of 200
-	generalRL := NewRateLimiter(100, time.Second, 200)
-	// Strict: 10 requests per second, burst of 20 (for expensive endpoints)
-	strictRL := NewRateLimiter(10, time.Second, 20)
-	// Test alert: 1 request per 10 seconds, burst of 3
-	testAlertRL := NewRateLimiter(1, 10*time.Second, 3)
+	// Per-client rate limits from server.rate_limits, updated on reload
+	serverCfg := cfgMgr.Get().Server
+	limits := serverCfg.RateLimits.Limits()
+	rateLimiters := make(map[string]*RateLimiter, len(limits))
+	for name, l := range limits {
+		rateLimiters[name] = NewRateLimiter(l.Rate, l.Interval, l.Burst)
+	}
+	generalRL := rateLimiters[config.RateLimitGeneral]
+	strictRL := rateLimiters[config.RateLimitStrict]
+	testAlertRL := rateLimiters[config.RateLimitTestAlert]
+	ingestRL := rateLimiters[config.RateLimitIngest]
+	authRL := rateLimiters[config.RateLimitAuth]
+
+	if len(serverCfg.TrustedProxies) > 0 {
+		if err := r.SetTrustedProxies(serverCfg.TrustedProxies); err != nil {
+			log.Printf("Invalid server.trusted_proxies, trusting all proxies: %v", err)
+		}
+	}
+
+	cfgMgr.OnReload(func(cfg *config.Config) {
+		for name, l := range cfg.Server.RateLimits.Limits() {
+			rateLimiters[name].SetLimits(l.Rate, l.Interval, l.Burst)
+		}
+		if cfg.Server.Port != serverCfg.Port || !slices.Equal(cfg.Server.TrustedProxies, serverCfg.TrustedProxies) || cfg.Server.TLS != serverCfg.TLS {
+			log.Printf("server.port, server.trusted_proxies and server.tls changes take effect after a restart")
+		}
+	})
 
 	// Connection limiter: max 50 per IP, 500 total
 	connLimiter := NewConnectionLimiter(50, 500)
 
 	// Global middlewares
 	r.Use(SecurityHeadersMiddleware())
-	r.Use(CORSMiddleware([]string{"*"})) // Allow all origins; configure for production
+	r.Use(CORSMiddleware(cfgMgr)) // All origins unless server.cors.allowed_origins is set
 	r.Use(ConnectionLimitMiddleware(connLimiter))
-	r.Use(MaxBodySizeMiddleware(10 * 1024 * 1024)) // 10MB max body size
+	r.Use(MaxBodySizeMiddleware(cfgMgr)) // server.max_body_bytes, 10MB by default
+
+	handler := NewHandler(cfgMgr, store, alertMgr, collectors)
+	handler.rateLimiters = rateLimiters
+	if lc != nil {
+		for name, rl := range handler.rateLimiters {
+			lc.RegisterFunc(name+" rate limiter", rl.Stop)
+		}
+	}
+
+	// Lifecycle events for event_webhooks: target and config changes, backups, collectors going down
+	handler.events = hooks.NewPublisher(cfgMgr.Get())
+	cfgMgr.OnReload(handler.events.Reload)
+	if collectors != nil {
+		collectors.SetStateCallback(handler.events.CollectorChanged)
+	}
+	if lc != nil {
+		lc.RegisterFunc("event webhooks", handler.events.Stop)
+	}
+
+	// registerAPI adds the API routes to a group, shared by the versioned and legacy prefixes
+	registerAPI := func(api *gin.RouterGroup) {
+		api.Use(AuthRateLimitMiddleware(authRL))
+		api.Use(APIKeyMiddleware(cfgMgr))
+		api.Use(ProjectMiddleware(cfgMgr))
+		api.Use(ReadOnlyMiddleware(cfgMgr))
+		api.Use(UsageMiddleware(handler.usage))
+		api.Use(AuditMiddleware(store))
+		api.Use(QuotaMiddleware(cfgMgr, handler.quotas))
+		api.Use(RateLimitMiddleware(generalRL))
 
-	handler := NewHandler(cfgMgr, store, alertMgr)
+		// Analyses over a time range may be reused by clients for a short while
+		analysisCache := CacheControlMiddleware(analysisMaxAge)
+		// Long analyses and reports are cancelled at server.request_timeout
+		timeout := TimeoutMiddleware(cfgMgr)
+		// Instance-wide data and settings are off limits to project scoped requests
+		unscoped := UnscopedMiddleware()
 
-	api := r.Group("/api")
-	api.Use(RateLimitMiddleware(generalRL))
-	{
 		api.GET("/settings", handler.GetSettings)
+		api.GET("/projects", handler.GetProjects)
 		api.GET("/targets", handler.GetTargets)
+		api.GET("/summary", handler.GetSummary)
 		api.GET("/targets/:name/instances", handler.GetInstances)
 		api.GET("/targets/:name/metrics", handler.GetTargetMetrics)
 		api.GET("/targets/:name/history", handler.GetTargetHistory)
-		api.GET("/targets/:name/recommendations", handler.GetRecommendations)
-		api.GET("/targets/:name/leaks", handler.DetectLeaks)
-		api.GET("/targets/:name/peaktime", handler.GetPeakTime)
+		api.GET("/targets/:name/recommendations", analysisCache, handler.GetRecommendations)
+		api.GET("/targets/:name/recommendations/apply", handler.PreviewRecommendation)
+		api.POST("/targets/:name/recommendations/apply", handler.ApplyRecommendation)
+		api.GET("/recommendations", handler.GetRecommendationRecords)
+		api.POST("/recommendations/:id/status", handler.UpdateRecommendationStatus)
+		api.GET("/targets/:name/leaks", analysisCache, handler.DetectLeaks)
+		api.GET("/targets/:name/health", handler.GetTargetHealth)
+		api.GET("/targets/:name/availability", handler.GetTargetAvailability)
+		api.GET("/targets/:name/peaktime", analysisCache, handler.GetPeakTime)
+		api.GET("/targets/:name/forecast", analysisCache, handler.GetForecast)
+		api.GET("/targets/:name/events", handler.GetEvents)
+		api.POST("/targets/:name/events", handler.CreateEvent)
+		api.DELETE("/targets/:name/events/:id", handler.DeleteEvent)
+		api.GET("/collectors", handler.GetCollectors)
+		api.GET("/cluster", unscoped, handler.GetCluster)
+		api.GET("/search", handler.Search)
 
 		// CPU/Memory intensive endpoints - stricter rate limiting
 		api.GET("/targets/:name/export", StrictRateLimitMiddleware(strictRL), handler.ExportCSV)
-		api.GET("/targets/:name/anomalies", StrictRateLimitMiddleware(strictRL), handler.DetectAnomalies)
-		api.GET("/targets/:name/compare", StrictRateLimitMiddleware(strictRL), handler.ComparePeriods)
-		api.GET("/targets/:name/report", StrictRateLimitMiddleware(strictRL), handler.GenerateReport)
-		api.GET("/report/combined", StrictRateLimitMiddleware(strictRL), handler.GenerateCombinedReport)
+		api.GET("/targets/:name/anomalies", StrictRateLimitMiddleware(strictRL), timeout, analysisCache, handler.DetectAnomalies)
+		api.GET("/targets/:name/compare", StrictRateLimitMiddleware(strictRL), timeout, analysisCache, handler.ComparePeriods)
+		api.GET("/targets/:name/instances/compare", StrictRateLimitMiddleware(strictRL), timeout, analysisCache, handler.CompareInstances)
+		api.GET("/targets/:name/events/:id/regression", StrictRateLimitMiddleware(strictRL), timeout, analysisCache, handler.GetEventRegression)
+		api.GET("/targets/:name/slo", StrictRateLimitMiddleware(strictRL), timeout, handler.GetTargetSLOs)
+		api.GET("/targets/:name/report", StrictRateLimitMiddleware(strictRL), timeout, handler.GenerateReport)
+		api.GET("/report/combined", StrictRateLimitMiddleware(strictRL), timeout, handler.GenerateCombinedReport)
+		api.POST("/targets/:name/instances/:id/actions/threaddump", StrictRateLimitMiddleware(strictRL), handler.CaptureThreadDump)
+		api.POST("/targets/:name/instances/:id/actions/heapdump", StrictRateLimitMiddleware(strictRL), handler.CaptureHeapDump)
 		api.GET("/export/all", StrictRateLimitMiddleware(strictRL), handler.ExportAllCSV)
 
 		// Alert endpoints
 		api.GET("/alerts", handler.GetAlerts)
 		api.GET("/alerts/active", handler.GetActiveAlerts)
 		api.GET("/alerts/stats", handler.GetAlertStats)
+		api.GET("/alerts/stats/timeseries", handler.GetAlertTimeseries)
 		api.GET("/alerts/channels", handler.GetAlertChannels)
+		api.POST("/alerts/templates/validate", handler.ValidateTemplate)
+		api.POST("/alerts/bulk", handler.BulkAlerts)
 		api.GET("/alerts/:id", handler.GetAlert)
 		api.POST("/alerts/:id/resolve", handler.ResolveAlert)
+		api.GET("/alerts/:id/deliveries", handler.GetAlertDeliveries)
+		api.GET("/alerts/:id/context", handler.GetAlertContext)
 		// Test alert has very strict rate limiting to prevent external service abuse
 		api.POST("/alerts/test", StrictRateLimitMiddleware(testAlertRL), handler.TestAlert)
 
 		// Alert Rule endpoints
 		api.GET("/rules", handler.GetAlertRules)
+		api.GET("/rules/templates", handler.GetRuleTemplates)
+		api.GET("/rules/export", handler.ExportAlertRules)
 		api.GET("/rules/:id", handler.GetAlertRule)
 		api.POST("/rules", handler.CreateAlertRule)
+		api.POST("/rules/test", StrictRateLimitMiddleware(strictRL), timeout, handler.TestAlertRule)
+		api.POST("/rules/templates/apply", handler.ApplyRuleTemplates)
+		api.POST("/rules/import", StrictRateLimitMiddleware(strictRL), handler.ImportAlertRules)
 		api.PUT("/rules/:id", handler.UpdateAlertRule)
 		api.DELETE("/rules/:id", handler.DeleteAlertRule)
 		api.PATCH("/rules/:id/toggle", handler.ToggleAlertRule)
+		api.POST("/rules/:id/adopt", handler.AdoptAlertRule)
 
 		// Backup endpoints - stricter rate limiting
-		api.POST("/backup", StrictRateLimitMiddleware(strictRL), handler.CreateBackup)
-		api.GET("/backup/download", StrictRateLimitMiddleware(strictRL), handler.DownloadBackup)
-		api.POST("/backup/restore", StrictRateLimitMiddleware(strictRL), handler.RestoreBackup)
+		api.POST("/backup", unscoped, StrictRateLimitMiddleware(strictRL), handler.CreateBackup)
+		api.GET("/backup/download", unscoped, StrictRateLimitMiddleware(strictRL), handler.DownloadBackup)
+		api.POST("/backup/restore", unscoped, StrictRateLimitMiddleware(strictRL), handler.RestoreBackup)
+		api.GET("/backup/remote", unscoped, StrictRateLimitMiddleware(strictRL), handler.GetRemoteBackups)
+		api.POST("/backup/remote/restore", unscoped, StrictRateLimitMiddleware(strictRL), handler.RestoreRemoteBackup)
+
+		// Storage management endpoints
+		api.GET("/storage/stats", unscoped, handler.GetStorageStats)
+		api.POST("/storage/vacuum", unscoped, StrictRateLimitMiddleware(strictRL), handler.VacuumStorage)
+		api.DELETE("/storage/targets/:name", StrictRateLimitMiddleware(strictRL), handler.PurgeTargetData)
+		api.GET("/storage/targets/:name/inactive-instances", handler.GetInactiveInstances)
+		api.DELETE("/storage/targets/:name/inactive-instances", StrictRateLimitMiddleware(strictRL), handler.PurgeInactiveInstances)
 
 		// Target config CRUD endpoints
 		api.GET("/config/targets", handler.GetConfigTargets)
 		api.POST("/config/targets", handler.AddConfigTarget)
+		api.GET("/config/targets/export", unscoped, handler.ExportConfigTargets)
+		api.POST("/config/targets/import", unscoped, StrictRateLimitMiddleware(strictRL), handler.ImportConfigTargets)
 		api.PUT("/config/targets/:name", handler.UpdateConfigTarget)
 		api.DELETE("/config/targets/:name", handler.DeleteConfigTarget)
+		api.POST("/config/targets/:name/backfill", StrictRateLimitMiddleware(strictRL), handler.BackfillTarget)
+		api.POST("/config/targets/:name/pause", handler.PauseTarget)
+		api.POST("/config/targets/:name/resume", handler.ResumeTarget)
+		api.POST("/config/targets/:name/rename", StrictRateLimitMiddleware(strictRL), handler.RenameConfigTarget)
+
+		// Derived metric config endpoints
+		api.GET("/config/derived-metrics", unscoped, handler.GetDerivedMetrics)
+		api.POST("/config/derived-metrics", unscoped, handler.AddDerivedMetric)
+		api.DELETE("/config/derived-metrics/:name", unscoped, handler.DeleteDerivedMetric)
 
 		// Alerting config endpoints
 		api.GET("/config/alerting", handler.GetAlertingConfig)
-		api.PUT("/config/alerting", handler.UpdateAlertingConfig)
+		api.PUT("/config/alerting", unscoped, handler.UpdateAlertingConfig)
 
 		// Maintenance Window endpoints
 		api.GET("/maintenance", handler.GetMaintenanceWindows)
 		api.GET("/maintenance/active", handler.GetActiveMaintenanceWindows)
 		api.GET("/maintenance/:id", handler.GetMaintenanceWindow)
 		api.POST("/maintenance", handler.CreateMaintenanceWindow)
+		api.POST("/maintenance/quick", handler.CreateQuickMaintenance)
 		api.PUT("/maintenance/:id", handler.UpdateMaintenanceWindow)
 		api.DELETE("/maintenance/:id", handler.DeleteMaintenanceWindow)
+
+		// Saved view endpoints
+		api.GET("/views", handler.GetViews)
+		api.GET("/views/:id", handler.GetView)
+		api.GET("/views/:id/data", handler.GetViewData)
+		api.POST("/views", handler.CreateView)
+		api.PUT("/views/:id", handler.UpdateView)
+		api.DELETE("/views/:id", handler.DeleteView)
+
+		// Annotation endpoints
+		api.GET("/annotations", handler.GetAnnotations)
+		api.GET("/annotations/:id", handler.GetAnnotation)
+		api.POST("/annotations", handler.CreateAnnotation)
+		api.DELETE("/annotations/:id", handler.DeleteAnnotation)
+
+		// Silence endpoints
+		api.GET("/silences", unscoped, handler.GetSilences)
+		api.GET("/silences/active", unscoped, handler.GetActiveSilences)
+		api.GET("/silences/:id", unscoped, handler.GetSilence)
+		api.POST("/silences", unscoped, handler.CreateSilence)
+		api.PUT("/silences/:id", unscoped, handler.UpdateSilence)
+		api.DELETE("/silences/:id", unscoped, handler.DeleteSilence)
+
+		// Push ingestion for agents pondy can't reach
+		api.POST("/ingest/metrics", RateLimitMiddleware(ingestRL), handler.IngestMetrics)
+
+		// Notification delivery endpoints
+		api.GET("/notifications/failed", unscoped, handler.GetFailedNotifications)
+
+		// Admin endpoints
+		api.GET("/admin/usage", unscoped, AdminMiddleware(cfgMgr), handler.GetUsage)
+		api.GET("/keys/:id/usage", unscoped, handler.GetKeyUsage)
+		api.GET("/audit", unscoped, handler.GetAuditLog)
+		api.GET("/system/status", unscoped, handler.GetSystemStatus)
+		api.GET("/system/metrics", unscoped, handler.GetSystemMetrics)
 	}
 
+	registerAPI(r.Group(APIPrefix))
+
+	// The same routes scoped to one project, an alternative to the X-Pondy-Project header
+	registerAPI(r.Group(APIPrefix + projectRoutePrefix))
+
+	// Unversioned paths are deprecated aliases of v1, kept for existing dashboards and scripts
+	legacy := r.Group("/api")
+	legacy.Use(DeprecationMiddleware("/api", APIPrefix))
+	registerAPI(legacy)
+
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// OpenAPI document generated from the routes above, and Swagger UI
+	serveOpenAPI(r)
+
 	// Serve static files from embedded filesystem
 	distFS, err := fs.Sub(webFS, "web/dist")
 	if err != nil {
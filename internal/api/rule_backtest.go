@@ -0,0 +1,133 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Rule backtest handlers
+
+// maxBacktestRange bounds the history a rule test replays
+const maxBacktestRange = 30 * 24 * time.Hour
+
+// RuleTestRequest is the request body for testing a rule condition against past metrics
+type RuleTestRequest struct {
+	Condition        string `json:"condition" binding:"required"`
+	ResolveCondition string `json:"resolve_condition"` // Default: resolve once the condition no longer holds
+	Target           string `json:"target" binding:"required"`
+	Instance         string `json:"instance"`        // Only this instance (default: all)
+	Range            string `json:"range"`           // Replayed time range before now (default: 24h)
+	Cooldown         string `json:"cooldown"`        // Default: alerting cooldown
+	RepeatInterval   string `json:"repeat_interval"` // Default: alerting repeat_interval, "0" disables
+}
+
+// RuleTestResponse reports when the condition would have fired and resolved
+type RuleTestResponse struct {
+	Condition        string    `json:"condition"`
+	ResolveCondition string    `json:"resolve_condition,omitempty"`
+	Target           string    `json:"target"`
+	Instance         string    `json:"instance,omitempty"`
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	Cooldown         string    `json:"cooldown"`
+	RepeatInterval   string    `json:"repeat_interval"`
+	*alerter.BacktestResult
+}
+
+// parseOptionalDuration parses a duration field, returning def when it is empty
+func parseOptionalDuration(field, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s '%s', use a duration such as 5m", field, value)
+	}
+	return d, nil
+}
+
+// TestAlertRule replays a target's stored metrics through a rule condition
+// It reports when the rule would have fired and resolved and the notifications it would have sent,
+// for tuning thresholds before a rule is enabled
+func (h *Handler) TestAlertRule(c *gin.Context) {
+	var req RuleTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	cfg := h.cfg()
+	if err := alerter.ValidateCondition(req.Condition, cfg.DerivedMetricNames()...); err != nil {
+		RespondBadRequest(c, "invalid condition: "+err.Error())
+		return
+	}
+	if req.ResolveCondition != "" {
+		if err := alerter.ValidateCondition(req.ResolveCondition, cfg.DerivedMetricNames()...); err != nil {
+			RespondBadRequest(c, "invalid resolve_condition: "+err.Error())
+			return
+		}
+	}
+	if _, err := h.cfgMgr.GetTarget(req.Target); err != nil || !h.targetVisible(c, req.Target) {
+		RespondNotFound(c, fmt.Sprintf("target '%s' not found", req.Target))
+		return
+	}
+
+	rng, err := parseOptionalDuration("range", req.Range, DefaultRangeLong)
+	if err == nil && (rng == 0 || rng > maxBacktestRange) {
+		err = fmt.Errorf("range must be between 1s and %s", maxBacktestRange)
+	}
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	cooldown, err := parseOptionalDuration("cooldown", req.Cooldown, cfg.Alerting.GetCooldown())
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	repeat, err := parseOptionalDuration("repeat_interval", req.RepeatInterval, cfg.Alerting.GetRepeatInterval(""))
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	// Windowed aggregates of the first samples need the history before the range
+	to := time.Now()
+	from := to.Add(-rng)
+	loadFrom := from.Add(-max(alerter.ConditionWindow(req.Condition), alerter.ConditionWindow(req.ResolveCondition)))
+	var samples []models.PoolMetrics
+	if req.Instance != "" {
+		samples, err = h.store.GetHistoryByInstance(c.Request.Context(), req.Target, req.Instance, loadFrom, to)
+	} else {
+		samples, err = h.store.GetHistory(c.Request.Context(), req.Target, loadFrom, to)
+	}
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	rule := &config.AlertRule{Name: "test", Condition: req.Condition, ResolveCondition: req.ResolveCondition}
+	result := alerter.Backtest(rule, samples, alerter.BacktestOptions{
+		From:           from,
+		Cooldown:       cooldown,
+		RepeatInterval: repeat,
+	})
+
+	c.JSON(http.StatusOK, RuleTestResponse{
+		Condition:        req.Condition,
+		ResolveCondition: req.ResolveCondition,
+		Target:           req.Target,
+		Instance:         req.Instance,
+		From:             from,
+		To:               to,
+		Cooldown:         cooldown.String(),
+		RepeatInterval:   repeat.String(),
+		BacktestResult:   result,
+	})
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Rule export and import handlers
+
+// Conflict resolutions of a rule import, for imported rules whose name already exists
+const (
+	RuleConflictSkip      = "skip"      // Keep the existing rule
+	RuleConflictOverwrite = "overwrite" // Replace the existing rule
+	RuleConflictRename    = "rename"    // Create the imported rule under a free name, e.g., high_usage_2
+)
+
+// ruleDocument is the document format of rule export and import: DB rules with their
+// channel routing and the alerting settings, without channel credentials
+type ruleDocument struct {
+	Rules    []exportedRule    `yaml:"rules"`
+	Alerting *alertingSettings `yaml:"alerting,omitempty"` // Omitted settings are left unchanged on import
+}
+
+// exportedRule is a DB rule without its ID and timestamps
+type exportedRule struct {
+	Name              string            `yaml:"name"`
+	Condition         string            `yaml:"condition"`
+	ResolveCondition  string            `yaml:"resolve_condition,omitempty"`
+	EscalateCondition string            `yaml:"escalate_condition,omitempty"`
+	EscalateSeverity  string            `yaml:"escalate_severity,omitempty"`
+	RepeatInterval    string            `yaml:"repeat_interval,omitempty"`
+	Severity          string            `yaml:"severity"`
+	Message           string            `yaml:"message,omitempty"`
+	Enabled           *bool             `yaml:"enabled,omitempty"` // Default: true
+	Channels          []string          `yaml:"channels,omitempty"`
+	Project           string            `yaml:"project,omitempty"`
+	Labels            map[string]string `yaml:"labels,omitempty"`
+	Targets           []string          `yaml:"targets,omitempty"`
+	Groups            []string          `yaml:"groups,omitempty"`
+}
+
+// alertingSettings are the instance-independent alerting settings
+type alertingSettings struct {
+	Cooldown       *time.Duration         `yaml:"cooldown,omitempty"`
+	RepeatInterval *time.Duration         `yaml:"repeat_interval,omitempty"`
+	Grouping       *config.GroupingConfig `yaml:"grouping,omitempty"`
+}
+
+// RuleImportResponse summarizes the changes of a rule import
+type RuleImportResponse struct {
+	DryRun   bool              `json:"dry_run"`
+	Created  []string          `json:"created"`
+	Updated  []string          `json:"updated"`
+	Renamed  map[string]string `json:"renamed"` // Imported name -> name the rule was created as
+	Skipped  []string          `json:"skipped"`
+	Alerting bool              `json:"alerting"` // Alerting settings were imported
+}
+
+// ExportAlertRules returns the DB rules and alerting settings as YAML or JSON
+// Project scoped requests only get the rules they can see and no alerting settings;
+// config-managed rules are left out, as they are kept in the configuration file
+func (h *Handler) ExportAlertRules(c *gin.Context) {
+	format, err := requestFormat(c, c.GetHeader("Accept"))
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	rules, err := h.store.GetAlertRules(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	doc := ruleDocument{Rules: []exportedRule{}}
+	for _, r := range rules {
+		if !scopedVisible(c, r.Project) || r.IsConfigManaged() {
+			continue
+		}
+		enabled := r.Enabled
+		doc.Rules = append(doc.Rules, exportedRule{
+			Name:              r.Name,
+			Condition:         r.Condition,
+			ResolveCondition:  r.ResolveCondition,
+			EscalateCondition: r.EscalateCondition,
+			EscalateSeverity:  r.EscalateSeverity,
+			RepeatInterval:    r.RepeatInterval,
+			Severity:          r.Severity,
+			Message:           r.Message,
+			Enabled:           &enabled,
+			Channels:          r.Channels,
+			Project:           r.Project,
+			Labels:            r.Labels,
+			Targets:           r.Targets,
+			Groups:            r.Groups,
+		})
+	}
+	if scopeOf(c) == nil {
+		alerting := h.cfg().Alerting
+		doc.Alerting = &alertingSettings{
+			Cooldown:       &alerting.Cooldown,
+			RepeatInterval: &alerting.RepeatInterval,
+			Grouping:       &alerting.Grouping,
+		}
+	}
+
+	respondDocument(c, format, "pondy-rules", doc)
+}
+
+// validateImportedRules checks every rule and the alerting settings and returns all problems found
+func validateImportedRules(doc *ruleDocument, cfg *config.Config) []string {
+	var problems []string
+	names := make(map[string]int)
+
+	for i := range doc.Rules {
+		r := &doc.Rules[i]
+		label := fmt.Sprintf("rules[%d]", i)
+		if r.Name != "" {
+			label = fmt.Sprintf("rule '%s'", r.Name)
+		}
+		fail := func(format string, args ...interface{}) {
+			problems = append(problems, label+": "+fmt.Sprintf(format, args...))
+		}
+
+		switch {
+		case r.Name == "":
+			fail("name is required")
+		case len(r.Name) > 255:
+			fail("name must be less than 255 characters")
+		default:
+			if prev, ok := names[r.Name]; ok {
+				fail("duplicate name, also used by rules[%d]", prev)
+			} else {
+				names[r.Name] = i
+			}
+		}
+		if len(r.Message) > 5000 {
+			fail("message must be less than 5000 characters")
+		}
+		if r.Severity != models.SeverityInfo && r.Severity != models.SeverityWarning && r.Severity != models.SeverityCritical {
+			fail("severity must be info, warning, or critical")
+		}
+		if err := alerter.ValidateCondition(r.Condition, cfg.DerivedMetricNames()...); err != nil {
+			fail("invalid condition: %v", err)
+		}
+		if r.ResolveCondition != "" {
+			if err := alerter.ValidateCondition(r.ResolveCondition, cfg.DerivedMetricNames()...); err != nil {
+				fail("invalid resolve_condition: %v", err)
+			}
+		}
+		if err := validateEscalation(r.Severity, r.EscalateCondition, r.EscalateSeverity, cfg.DerivedMetricNames()); err != nil {
+			fail("%v", err)
+		}
+		if err := validateRepeatInterval(r.RepeatInterval); err != nil {
+			fail("%v", err)
+		}
+		if err := cfg.Alerting.Channels.ValidateRuleChannels(r.Channels); err != nil {
+			fail("invalid channels: %v", err)
+		}
+		if err := config.ValidateLabels(r.Labels); err != nil {
+			fail("%v", err)
+		}
+	}
+
+	if a := doc.Alerting; a != nil {
+		if a.Cooldown != nil && *a.Cooldown < 0 {
+			problems = append(problems, "alerting: cooldown must not be negative")
+		}
+		if a.RepeatInterval != nil && *a.RepeatInterval < 0 {
+			problems = append(problems, "alerting: repeat_interval must not be negative")
+		}
+		if a.Grouping != nil {
+			if err := a.Grouping.Validate(); err != nil {
+				problems = append(problems, "alerting: "+err.Error())
+			}
+		}
+	}
+	return problems
+}
+
+// freeRuleName returns name with the lowest numeric suffix not in taken, e.g., high_usage_2
+func freeRuleName(name string, taken map[string]bool) string {
+	for i := 2; ; i++ {
+		if candidate := fmt.Sprintf("%s_%d", name, i); !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// ImportAlertRules creates or updates DB rules from a YAML or JSON document, as exported by
+// ExportAlertRules; on_conflict decides what happens to rules whose name already exists
+// The whole document is validated before anything is applied; dry_run=true only reports the changes
+func (h *Handler) ImportAlertRules(c *gin.Context) {
+	format, err := requestFormat(c, c.ContentType())
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	onConflict := c.DefaultQuery("on_conflict", RuleConflictSkip)
+	if onConflict != RuleConflictSkip && onConflict != RuleConflictOverwrite && onConflict != RuleConflictRename {
+		RespondBadRequest(c, "on_conflict must be skip, overwrite, or rename")
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		RespondBadRequest(c, "failed to read request body: "+err.Error())
+		return
+	}
+	var doc ruleDocument
+	if err := decodeDocument(data, &doc); err != nil {
+		RespondBadRequest(c, fmt.Sprintf("invalid %s: %v", format, err))
+		return
+	}
+	if problems := validateImportedRules(&doc, h.cfg()); len(problems) > 0 {
+		RespondBadRequest(c, fmt.Sprintf("%d validation errors: %s", len(problems), strings.Join(problems, "; ")))
+		return
+	}
+	if doc.Alerting != nil && scopeOf(c) != nil {
+		respondNotWritable(c, "alerting config")
+		return
+	}
+
+	existing, err := h.store.GetAlertRules(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	byName := make(map[string]models.AlertRule)
+	taken := make(map[string]bool)
+	for _, r := range existing {
+		byName[r.Name] = r
+		taken[r.Name] = true
+	}
+
+	resp := RuleImportResponse{
+		DryRun:   c.Query("dry_run") == "true",
+		Created:  []string{},
+		Updated:  []string{},
+		Renamed:  map[string]string{},
+		Skipped:  []string{},
+		Alerting: doc.Alerting != nil,
+	}
+
+	// Plan every change first, so a rule of another project fails the import before anything is applied
+	var creates, updates []models.AlertRule
+	for _, r := range doc.Rules {
+		project, ok := projectForWrite(c, r.Project)
+		if !ok {
+			return
+		}
+		rule := models.AlertRule{
+			Name:              r.Name,
+			Condition:         r.Condition,
+			ResolveCondition:  r.ResolveCondition,
+			EscalateCondition: r.EscalateCondition,
+			EscalateSeverity:  r.EscalateSeverity,
+			RepeatInterval:    r.RepeatInterval,
+			Severity:          r.Severity,
+			Message:           r.Message,
+			Enabled:           r.Enabled == nil || *r.Enabled,
+			Channels:          r.Channels,
+			Project:           project,
+			Labels:            r.Labels,
+			Targets:           r.Targets,
+			Groups:            r.Groups,
+		}
+
+		prev, exists := byName[r.Name]
+		switch {
+		case !exists:
+			resp.Created = append(resp.Created, rule.Name)
+			creates = append(creates, rule)
+		case onConflict == RuleConflictSkip:
+			resp.Skipped = append(resp.Skipped, rule.Name)
+		case onConflict == RuleConflictRename:
+			rule.Name = freeRuleName(r.Name, taken)
+			resp.Renamed[r.Name] = rule.Name
+			resp.Created = append(resp.Created, rule.Name)
+			creates = append(creates, rule)
+		default:
+			if !scopedVisible(c, prev.Project) || !scopedWritable(c, prev.Project) {
+				respondNotWritable(c, fmt.Sprintf("rule '%s'", prev.Name))
+				return
+			}
+			if prev.IsConfigManaged() {
+				respondConfigManaged(c, &prev)
+				return
+			}
+			rule.ID, rule.CreatedAt = prev.ID, prev.CreatedAt
+			resp.Updated = append(resp.Updated, rule.Name)
+			updates = append(updates, rule)
+		}
+		taken[rule.Name] = true
+	}
+
+	auditAfter(c, resp)
+	if resp.DryRun {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	for i := range creates {
+		if err := h.store.SaveAlertRule(c.Request.Context(), &creates[i]); err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+	}
+	for i := range updates {
+		if err := h.store.UpdateAlertRule(c.Request.Context(), &updates[i]); err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+	}
+	if h.alertMgr != nil && len(creates)+len(updates) > 0 {
+		h.alertMgr.ReloadRules()
+	}
+
+	if a := doc.Alerting; a != nil {
+		alerting, err := h.cfgMgr.UpdateAlerting(func(cfg *config.AlertingConfig) error {
+			if a.Cooldown != nil {
+				cfg.Cooldown = *a.Cooldown
+			}
+			if a.RepeatInterval != nil {
+				cfg.RepeatInterval = *a.RepeatInterval
+			}
+			if a.Grouping != nil {
+				cfg.Grouping = *a.Grouping
+			}
+			return nil
+		})
+		if err != nil {
+			RespondBadRequest(c, err.Error())
+			return
+		}
+		if err := h.cfgMgr.SaveConfig(); err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		if h.alertMgr != nil {
+			h.alertMgr.UpdateConfig(&alerting)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRuleDocument_RoundTrip(t *testing.T) {
+	enabled := false
+	cooldown := 10 * time.Minute
+	doc := ruleDocument{
+		Rules: []exportedRule{
+			{Name: "high_usage", Condition: "usage > 80", Severity: "warning", Enabled: &enabled, Channels: []string{"slack"}, Labels: map[string]string{"team": "payments"}},
+		},
+		Alerting: &alertingSettings{Cooldown: &cooldown, Grouping: &config.GroupingConfig{Enabled: true, By: []string{"rule"}}},
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed ruleDocument
+	if err := decodeDocument(data, &parsed); err != nil {
+		t.Fatalf("decodeDocument failed: %v", err)
+	}
+	r := parsed.Rules[0]
+	if r.Name != "high_usage" || *r.Enabled || r.Channels[0] != "slack" || r.Labels["team"] != "payments" {
+		t.Errorf("parsed rule = %+v", r)
+	}
+	if *parsed.Alerting.Cooldown != cooldown || parsed.Alerting.RepeatInterval != nil || !parsed.Alerting.Grouping.Enabled {
+		t.Errorf("parsed alerting = %+v", parsed.Alerting)
+	}
+
+	// JSON with the same field names; settings that are left out stay nil
+	if err := decodeDocument([]byte(`{"rules": [{"name": "pending", "condition": "pending > 5", "severity": "critical"}]}`), &parsed); err != nil {
+		t.Fatalf("decodeDocument(json) failed: %v", err)
+	}
+	if len(parsed.Rules) != 1 || parsed.Rules[0].Enabled != nil {
+		t.Errorf("parsed json = %+v", parsed.Rules)
+	}
+	if err := decodeDocument([]byte("rules:\n  - name: x\n    conditon: usage > 1\n"), &parsed); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
+
+func TestValidateImportedRules(t *testing.T) {
+	negative := -time.Minute
+	doc := ruleDocument{
+		Rules: []exportedRule{
+			{Name: "high_usage", Condition: "usage > 80", Severity: "warning"},
+			{Name: "high_usage", Condition: "usage > 90", Severity: "critical"},
+			{Name: "typo", Condition: "usgae > 80", Severity: "warning"},
+			{Name: "loud", Condition: "usage > 80", Severity: "page"},
+			{Name: "routed", Condition: "usage > 80", Severity: "info", Channels: []string{"slack"}},
+			{Name: "downgrade", Condition: "usage > 80", EscalateCondition: "usage >= 100", EscalateSeverity: "warning", Severity: "critical"},
+		},
+		Alerting: &alertingSettings{RepeatInterval: &negative},
+	}
+
+	problems := validateImportedRules(&doc, &config.Config{})
+	want := []string{
+		"rule 'high_usage': duplicate name",
+		"rule 'typo': invalid condition",
+		"rule 'loud': severity must be",
+		"rule 'routed': invalid channels",
+		"rule 'downgrade': escalate_severity must be more severe",
+		"alerting: repeat_interval",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems = %v, want %d", problems, len(want))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(problems[i], w) {
+			t.Errorf("problems[%d] = %q, want prefix %q", i, problems[i], w)
+		}
+	}
+}
+
+func TestFreeRuleName(t *testing.T) {
+	taken := map[string]bool{"high_usage": true, "high_usage_2": true}
+	if got := freeRuleName("high_usage", taken); got != "high_usage_3" {
+		t.Errorf("freeRuleName() = %q, want high_usage_3", got)
+	}
+}
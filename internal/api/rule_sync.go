@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Config-managed rule handlers
+
+// respondConfigManaged rejects changing a rule synced from the configuration file
+func respondConfigManaged(c *gin.Context, rule *models.AlertRule) {
+	RespondError(c, http.StatusConflict, fmt.Sprintf("rule '%s' is managed by the configuration file; change it there or adopt it first", rule.Name))
+}
+
+// AdoptAlertRule turns a config-managed rule into an API rule: the rule is removed from the
+// configuration file and kept in the database, where the API can edit it from then on
+func (h *Handler) AdoptAlertRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid rule ID")
+		return
+	}
+
+	rule, err := h.store.GetAlertRule(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if rule == nil || !scopedVisible(c, rule.Project) {
+		RespondNotFound(c, "rule not found")
+		return
+	}
+	if !rule.IsConfigManaged() {
+		RespondBadRequest(c, fmt.Sprintf("rule '%s' is not managed by the configuration file", rule.Name))
+		return
+	}
+	if !scopedWritable(c, rule.Project) {
+		respondNotWritable(c, "rule")
+		return
+	}
+	auditBefore(c, rule)
+
+	// Mark the rule first, so the sync triggered by the config change keeps it
+	rule.Origin = models.RuleOriginAPI
+	if err := h.store.UpdateAlertRule(c.Request.Context(), rule); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	alerting, err := h.cfgMgr.UpdateAlerting(func(a *config.AlertingConfig) error {
+		a.Rules = slices.DeleteFunc(a.Rules, func(r config.AlertRule) bool {
+			return r.Name == rule.Name && r.IsCondition()
+		})
+		return nil
+	})
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if err := h.cfgMgr.SaveConfig(); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if h.alertMgr != nil {
+		h.alertMgr.UpdateConfig(&alerting)
+	}
+
+	auditAfter(c, rule)
+	c.JSON(http.StatusOK, rule)
+}
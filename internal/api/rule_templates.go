@@ -0,0 +1,149 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/config"
+)
+
+// Rule template handlers
+
+// RuleTemplatesResponse lists the recommended rules and which are already configured
+type RuleTemplatesResponse struct {
+	Templates []RuleTemplateStatus `json:"templates"`
+}
+
+// RuleTemplateStatus is a template with the config rules created from it
+type RuleTemplateStatus struct {
+	alerter.RuleTemplate
+	Applied bool `json:"applied"` // A config rule is named after the template
+}
+
+// ApplyRuleTemplatesRequest selects templates to create config rules from
+type ApplyRuleTemplatesRequest struct {
+	Templates  []string `json:"templates"`   // Template IDs (default: all)
+	Targets    []string `json:"targets"`     // Limit the rules to these targets
+	Group      string   `json:"group"`       // Limit the rules to the targets of this group
+	NameSuffix string   `json:"name_suffix"` // Appended to rule names, default: the group or single target of the scope
+	Channels   []string `json:"channels"`    // Channels to notify (default: all)
+}
+
+// ApplyRuleTemplatesResponse reports the created rules and the ones that already existed
+type ApplyRuleTemplatesResponse struct {
+	Created []string `json:"created"`
+	Skipped []string `json:"skipped"` // A rule with the name already exists
+}
+
+// GetRuleTemplates returns the catalog of recommended rules
+func (h *Handler) GetRuleTemplates(c *gin.Context) {
+	rules := h.cfg().Alerting.Rules
+	templates := []RuleTemplateStatus{}
+	for _, t := range alerter.RuleTemplates() {
+		applied := slices.ContainsFunc(rules, func(r config.AlertRule) bool {
+			return r.Name == t.ID || strings.HasPrefix(r.Name, t.ID+"_")
+		})
+		templates = append(templates, RuleTemplateStatus{RuleTemplate: t, Applied: applied})
+	}
+	c.JSON(http.StatusOK, RuleTemplatesResponse{Templates: templates})
+}
+
+// ruleTemplateSuffix returns the suffix of the names of scoped rules, e.g., high_pool_usage_payments
+func ruleTemplateSuffix(req *ApplyRuleTemplatesRequest) (string, error) {
+	switch {
+	case req.NameSuffix != "":
+		return "_" + req.NameSuffix, nil
+	case req.Group != "" && len(req.Targets) == 0:
+		return "_" + req.Group, nil
+	case req.Group == "" && len(req.Targets) == 1:
+		return "_" + req.Targets[0], nil
+	case req.Group == "" && len(req.Targets) == 0:
+		return "", nil
+	default:
+		return "", fmt.Errorf("name_suffix is required when scoping to several targets")
+	}
+}
+
+// ApplyRuleTemplates adds config rules from templates, optionally scoped to targets or a group
+// Existing rules of the same name are left unchanged, so applying twice is harmless
+func (h *Handler) ApplyRuleTemplates(c *gin.Context) {
+	var req ApplyRuleTemplatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	templates := alerter.RuleTemplates()
+	if len(req.Templates) > 0 {
+		templates = templates[:0]
+		for _, id := range req.Templates {
+			t := alerter.FindRuleTemplate(id)
+			if t == nil {
+				RespondBadRequest(c, fmt.Sprintf("unknown rule template '%s'", id))
+				return
+			}
+			templates = append(templates, *t)
+		}
+	}
+
+	cfg := h.cfg()
+	for _, name := range req.Targets {
+		if _, err := h.cfgMgr.GetTarget(name); err != nil || !h.targetVisible(c, name) {
+			RespondNotFound(c, fmt.Sprintf("target '%s' not found", name))
+			return
+		}
+	}
+	if req.Group != "" && !slices.ContainsFunc(cfg.Targets, func(t config.TargetConfig) bool { return t.Group == req.Group }) {
+		RespondNotFound(c, fmt.Sprintf("group '%s' not found", req.Group))
+		return
+	}
+	if err := cfg.Alerting.Channels.ValidateRuleChannels(req.Channels); err != nil {
+		RespondBadRequest(c, "invalid channels: "+err.Error())
+		return
+	}
+	suffix, err := ruleTemplateSuffix(&req)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	resp := ApplyRuleTemplatesResponse{Created: []string{}, Skipped: []string{}}
+	alerting, err := h.cfgMgr.UpdateAlerting(func(a *config.AlertingConfig) error {
+		for _, t := range templates {
+			name := t.ID + suffix
+			if slices.ContainsFunc(a.Rules, func(r config.AlertRule) bool { return r.Name == name }) {
+				resp.Skipped = append(resp.Skipped, name)
+				continue
+			}
+			rule := t.Rule(name)
+			rule.Targets = req.Targets
+			if req.Group != "" {
+				rule.Groups = []string{req.Group}
+			}
+			rule.Channels = req.Channels
+			a.Rules = append(a.Rules, rule)
+			resp.Created = append(resp.Created, name)
+		}
+		return nil
+	})
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	if len(resp.Created) > 0 {
+		if err := h.cfgMgr.SaveConfig(); err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		if h.alertMgr != nil {
+			h.alertMgr.UpdateConfig(&alerting)
+		}
+	}
+	auditAfter(c, resp)
+	c.JSON(http.StatusOK, resp)
+}
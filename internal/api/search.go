@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Search settings
+const (
+	searchDefaultLimit = 10
+	searchMaxLimit     = 50
+	searchMaxQuery     = 200
+)
+
+// Alert rule sources
+const (
+	ruleSourceConfig = "config" // Defined in the configuration file
+	ruleSourceAPI    = "api"    // Created through the rules API
+)
+
+// SearchResponse holds matches of a global search, each category limited separately
+type SearchResponse struct {
+	Query     string           `json:"query"`
+	Targets   []SearchTarget   `json:"targets"`
+	Instances []SearchInstance `json:"instances"`
+	Rules     []SearchRule     `json:"rules"`
+	Alerts    []models.Alert   `json:"alerts"` // Newest first
+}
+
+// SearchTarget is a matching target
+type SearchTarget struct {
+	Name  string `json:"name"`
+	Group string `json:"group,omitempty"`
+	Type  string `json:"type"`
+}
+
+// SearchInstance is a matching target instance
+type SearchInstance struct {
+	TargetName   string `json:"target_name"`
+	InstanceName string `json:"instance_name"`
+	Endpoint     string `json:"endpoint,omitempty"`
+}
+
+// SearchRule is a matching alert rule
+type SearchRule struct {
+	ID        int64  `json:"id,omitempty"` // Only for rules created through the API
+	Name      string `json:"name"`
+	Condition string `json:"condition"`
+	Severity  string `json:"severity"`
+	Enabled   bool   `json:"enabled"`
+	Source    string `json:"source"` // config, api
+}
+
+// matchesAll reports whether every term is contained in one of the fields, ignoring case
+// terms must be lowercase
+func matchesAll(terms []string, fields ...string) bool {
+	for _, term := range terms {
+		found := false
+		for _, f := range fields {
+			if strings.Contains(strings.ToLower(f), term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Search finds targets, instances, alert rules and alerts matching all query terms
+func (h *Handler) Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		RespondBadRequest(c, "q is required")
+		return
+	}
+	if len(query) > searchMaxQuery {
+		RespondBadRequest(c, "q is too long (max 200 characters)")
+		return
+	}
+
+	limit := searchDefaultLimit
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	cfg := h.cfg()
+	resp := SearchResponse{
+		Query:     query,
+		Targets:   []SearchTarget{},
+		Instances: []SearchInstance{},
+		Rules:     []SearchRule{},
+	}
+
+	// Instances come from the configuration and from collectors, which include discovered instances
+	seen := make(map[string]bool)
+	addInstance := func(inst SearchInstance) {
+		key := inst.TargetName + "/" + inst.InstanceName
+		if seen[key] || len(resp.Instances) >= limit {
+			return
+		}
+		seen[key] = true
+		inst.Endpoint = redactURL(inst.Endpoint)
+		if matchesAll(terms, inst.TargetName, inst.InstanceName, inst.Endpoint) {
+			resp.Instances = append(resp.Instances, inst)
+		}
+	}
+
+	scope := scopeOf(c)
+	for _, t := range cfg.Targets {
+		if !scope.allows(t.GetProject()) {
+			continue
+		}
+		if len(resp.Targets) < limit && matchesAll(terms, t.Name, t.Group, t.Type) {
+			resp.Targets = append(resp.Targets, SearchTarget{Name: t.Name, Group: t.Group, Type: t.Type})
+		}
+		for _, inst := range t.GetInstances() {
+			addInstance(SearchInstance{TargetName: t.Name, InstanceName: inst.ID, Endpoint: inst.Endpoint})
+		}
+	}
+	if h.collectors != nil {
+		for _, col := range h.collectors.Health() {
+			if !h.targetVisible(c, col.TargetName) {
+				continue
+			}
+			addInstance(SearchInstance{TargetName: col.TargetName, InstanceName: col.InstanceName, Endpoint: col.Endpoint})
+		}
+	}
+
+	for _, r := range cfg.Alerting.Rules {
+		if len(resp.Rules) < limit && matchesAll(terms, r.Name, r.Condition, r.Message) {
+			resp.Rules = append(resp.Rules, SearchRule{
+				Name: r.Name, Condition: r.Condition, Severity: r.Severity, Enabled: r.IsEnabled(), Source: ruleSourceConfig,
+			})
+		}
+	}
+	rules, err := h.store.GetAlertRules(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	for _, r := range rules {
+		// Config-managed rules are found among the config rules
+		if !scopedVisible(c, r.Project) || r.IsConfigManaged() {
+			continue
+		}
+		if len(resp.Rules) < limit && matchesAll(terms, r.Name, r.Condition, r.Message) {
+			resp.Rules = append(resp.Rules, SearchRule{
+				ID: r.ID, Name: r.Name, Condition: r.Condition, Severity: r.Severity, Enabled: r.Enabled, Source: ruleSourceAPI,
+			})
+		}
+	}
+
+	alerts, err := h.store.SearchAlerts(c.Request.Context(), query, limit)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	resp.Alerts = slices.DeleteFunc(alerts, func(a models.Alert) bool {
+		return !h.targetVisible(c, a.TargetName)
+	})
+	if resp.Alerts == nil {
+		resp.Alerts = []models.Alert{}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Silence handlers
+
+type SilencesResponse struct {
+	Silences []models.Silence `json:"silences"`
+	Total    int              `json:"total"`
+}
+
+// buildSilence validates input and applies it to the given silence
+func buildSilence(input *models.SilenceInput, silence *models.Silence) error {
+	if input.Severity != "" &&
+		input.Severity != models.SeverityInfo &&
+		input.Severity != models.SeverityWarning &&
+		input.Severity != models.SeverityCritical {
+		return fmt.Errorf("severity must be info, warning, or critical")
+	}
+	if len(input.Comment) > 5000 {
+		return fmt.Errorf("comment must be less than 5000 characters")
+	}
+
+	startsAt := time.Now()
+	if input.StartsAt != "" {
+		t, err := time.Parse(time.RFC3339, input.StartsAt)
+		if err != nil {
+			return fmt.Errorf("invalid starts_at format, use RFC3339 (e.g., 2024-01-15T10:00:00Z)")
+		}
+		startsAt = t
+	}
+
+	var endsAt time.Time
+	switch {
+	case input.EndsAt != "":
+		t, err := time.Parse(time.RFC3339, input.EndsAt)
+		if err != nil {
+			return fmt.Errorf("invalid ends_at format, use RFC3339 (e.g., 2024-01-15T12:00:00Z)")
+		}
+		endsAt = t
+	case input.Duration != "":
+		d, err := time.ParseDuration(input.Duration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration, use Go duration format (e.g., 30m, 2h)")
+		}
+		endsAt = startsAt.Add(d)
+	default:
+		return fmt.Errorf("ends_at or duration is required")
+	}
+
+	if !endsAt.After(startsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+
+	silence.TargetName = input.TargetName
+	silence.InstanceName = input.InstanceName
+	silence.RuleName = input.RuleName
+	silence.Severity = input.Severity
+	silence.Comment = input.Comment
+	silence.CreatedBy = input.CreatedBy
+	silence.StartsAt = startsAt
+	silence.EndsAt = endsAt
+
+	if !silence.HasMatchers() {
+		return fmt.Errorf("at least one matcher (target_name, instance_name, rule_name, severity) is required")
+	}
+	return nil
+}
+
+func (h *Handler) GetSilences(c *gin.Context) {
+	silences, err := h.store.GetAllSilences(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	if silences == nil {
+		silences = []models.Silence{}
+	}
+
+	c.JSON(http.StatusOK, SilencesResponse{
+		Silences: silences,
+		Total:    len(silences),
+	})
+}
+
+func (h *Handler) GetActiveSilences(c *gin.Context) {
+	silences, err := h.store.GetActiveSilences(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	if silences == nil {
+		silences = []models.Silence{}
+	}
+
+	c.JSON(http.StatusOK, SilencesResponse{
+		Silences: silences,
+		Total:    len(silences),
+	})
+}
+
+func (h *Handler) GetSilence(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid silence ID")
+		return
+	}
+
+	silence, err := h.store.GetSilence(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if silence == nil {
+		RespondNotFound(c, "silence not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, silence)
+}
+
+func (h *Handler) CreateSilence(c *gin.Context) {
+	var input models.SilenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid input: "+err.Error())
+		return
+	}
+
+	silence := &models.Silence{}
+	if err := buildSilence(&input, silence); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.store.SaveSilence(c.Request.Context(), silence); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	auditAfter(c, silence)
+	c.JSON(http.StatusCreated, silence)
+}
+
+func (h *Handler) UpdateSilence(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid silence ID")
+		return
+	}
+
+	existing, err := h.store.GetSilence(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if existing == nil {
+		RespondNotFound(c, "silence not found")
+		return
+	}
+
+	var input models.SilenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid input: "+err.Error())
+		return
+	}
+
+	auditBefore(c, existing)
+	if err := buildSilence(&input, existing); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.store.UpdateSilence(c.Request.Context(), existing); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	auditAfter(c, existing)
+	c.JSON(http.StatusOK, existing)
+}
+
+func (h *Handler) DeleteSilence(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid silence ID")
+		return
+	}
+
+	existing, err := h.store.GetSilence(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if existing == nil {
+		RespondNotFound(c, "silence not found")
+		return
+	}
+	auditBefore(c, existing)
+
+	if err := h.store.DeleteSilence(c.Request.Context(), id); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "silence deleted"})
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/slo"
+)
+
+// SLOResponse reports the SLOs of a target
+type SLOResponse struct {
+	TargetName string       `json:"target_name"`
+	SLOs       []slo.Status `json:"slos"`
+}
+
+// GetTargetSLOs evaluates the compliance, error budget and burn rates of a target's SLOs
+func (h *Handler) GetTargetSLOs(c *gin.Context) {
+	target, err := h.cfgMgr.GetTarget(c.Param("name"))
+	if err != nil {
+		RespondNotFound(c, "target not found")
+		return
+	}
+
+	slos := slo.EvaluateTarget(c.Request.Context(), h.store, *target, time.Now(), h.cfg().GetLocation())
+	if requestDone(c) {
+		return
+	}
+	c.JSON(http.StatusOK, SLOResponse{TargetName: target.Name, SLOs: slos})
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// StorageStatsResponse reports database size and the state of the storage layer
+type StorageStatsResponse struct {
+	*models.StorageStats
+	WriteQueue *storage.WriteQueueStats `json:"write_queue"` // nil when writes are synchronous
+}
+
+// GetStorageStats returns database size, row counts and the write queue state
+func (h *Handler) GetStorageStats(c *gin.Context) {
+	stats, err := h.store.GetStorageStats(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	resp := StorageStatsResponse{StorageStats: stats}
+	if q, ok := h.store.(*storage.WriteQueue); ok {
+		queue := q.Stats()
+		resp.WriteQueue = &queue
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// VacuumStorage rebuilds the database file and reports the reclaimed space
+func (h *Handler) VacuumStorage(c *gin.Context) {
+	before, err := h.store.GetStorageStats(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	if err := h.store.Vacuum(c.Request.Context()); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	after, err := h.store.GetStorageStats(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "vacuum completed",
+		"db_size":         after.DBSize,
+		"reclaimed_bytes": (before.DBSize + before.WALSize) - (after.DBSize + after.WALSize),
+	})
+}
+
+// PurgeTargetData deletes stored data of a target that is no longer configured
+func (h *Handler) PurgeTargetData(c *gin.Context) {
+	name := c.Param("name")
+
+	for _, t := range h.cfg().Targets {
+		if t.Name == name {
+			RespondError(c, http.StatusConflict, fmt.Sprintf("target '%s' is still configured; delete it first", name))
+			return
+		}
+	}
+
+	deleted, err := h.store.PurgeTarget(c.Request.Context(), name)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	h.InvalidateCache()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "target data purged",
+		"deleted": deleted,
+	})
+}
+
+// GetInactiveInstances lists the instances of a target that stopped reporting
+func (h *Handler) GetInactiveInstances(c *gin.Context) {
+	name := c.Param("name")
+	instances, err := h.store.GetInactiveInstances(c.Request.Context(), name)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"target_name": name, "instances": instances})
+}
+
+// PurgeInactiveInstances deletes stored data of a target's inactive instances,
+// or of a single one with ?instance=
+func (h *Handler) PurgeInactiveInstances(c *gin.Context) {
+	name := c.Param("name")
+	only := c.Query("instance")
+
+	inactive, err := h.store.GetInactiveInstances(c.Request.Context(), name)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	purged := make([]string, 0, len(inactive))
+	var deleted int64
+	for _, inst := range inactive {
+		if only != "" && inst.InstanceName != only {
+			continue
+		}
+		n, err := h.store.PurgeInstance(c.Request.Context(), name, inst.InstanceName)
+		if err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		purged = append(purged, inst.InstanceName)
+		deleted += n
+	}
+	if only != "" && len(purged) == 0 {
+		RespondNotFound(c, fmt.Sprintf("instance '%s' of target '%s' is not inactive", only, name))
+		return
+	}
+	h.InvalidateCache()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "inactive instances purged",
+		"instances": purged,
+		"deleted":   deleted,
+	})
+}
+
+// inactiveInstances returns the inactive instance names by target
+func (h *Handler) inactiveInstances(ctx context.Context) map[string]map[string]bool {
+	inactive, err := h.store.GetInactiveInstances(ctx, "")
+	if err != nil {
+		return nil
+	}
+	byTarget := make(map[string]map[string]bool)
+	for _, inst := range inactive {
+		if byTarget[inst.TargetName] == nil {
+			byTarget[inst.TargetName] = make(map[string]bool)
+		}
+		byTarget[inst.TargetName][inst.InstanceName] = true
+	}
+	return byTarget
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Summary settings
+const (
+	summaryTopTargets = 5
+	summaryTrendAge   = 24 * time.Hour
+	summaryLookback   = time.Hour // Instances without a sample this recent count as unknown
+)
+
+// SummaryResponse is the fleet-wide overview for the landing page
+type SummaryResponse struct {
+	GeneratedAt      time.Time      `json:"generated_at"`
+	TotalTargets     int            `json:"total_targets"`
+	TargetsByStatus  map[string]int `json:"targets_by_status"` // healthy, warning, critical, unknown, down
+	ActiveAlerts     int            `json:"active_alerts"`
+	AlertsBySeverity map[string]int `json:"alerts_by_severity"`
+	TotalActive      int            `json:"total_active"`
+	TotalMax         int            `json:"total_max"`
+	TotalPending     int            `json:"total_pending"`
+	Usage            float64        `json:"usage"` // total_active / total_max * 100
+	TopTargets       []TargetUsage  `json:"top_targets"`
+	Trend            *SummaryTrend  `json:"trend,omitempty"` // nil without data from 24h ago
+
+	// Groups aggregates targets by ?group_by=, set only when requested
+	GroupBy string         `json:"group_by,omitempty"`
+	Groups  []GroupSummary `json:"groups,omitempty"`
+}
+
+// GroupSummary aggregates the targets sharing a group or label value
+// Value is empty for targets without the label
+type GroupSummary struct {
+	Value           string         `json:"value"`
+	Targets         []string       `json:"targets"`
+	TargetsByStatus map[string]int `json:"targets_by_status"`
+	TotalActive     int            `json:"total_active"`
+	TotalMax        int            `json:"total_max"`
+	TotalPending    int            `json:"total_pending"`
+	Usage           float64        `json:"usage"`
+}
+
+// groupKeyOf returns the value a target is grouped by: its group for "group", otherwise the label
+func groupKeyOf(t config.TargetConfig, groupBy string) string {
+	if groupBy == "group" {
+		return t.Group
+	}
+	return t.Labels[groupBy]
+}
+
+// TargetUsage is a target ranked by pool usage
+type TargetUsage struct {
+	Name        string   `json:"name"`
+	Group       string   `json:"group,omitempty"`
+	Status      string   `json:"status"`
+	Active      int      `json:"active"`
+	Max         int      `json:"max"`
+	Usage       float64  `json:"usage"`
+	UsageChange *float64 `json:"usage_change,omitempty"` // percentage points vs 24h ago
+}
+
+// SummaryTrend compares fleet totals with 24h ago
+type SummaryTrend struct {
+	Since         time.Time `json:"since"`
+	ActiveChange  int       `json:"active_change"`
+	MaxChange     int       `json:"max_change"`
+	PendingChange int       `json:"pending_change"`
+	UsageChange   float64   `json:"usage_change"` // percentage points
+}
+
+// poolTotals sums pool metrics of a set of samples
+type poolTotals struct {
+	active, max, pending int
+}
+
+func (t poolTotals) usage() float64 {
+	if t.max == 0 {
+		return 0
+	}
+	return float64(t.active) / float64(t.max) * 100
+}
+
+// GetSummary returns fleet-wide counts, top targets by usage and 24h trends
+// ?label= narrows it to targets with the labels and ?group_by= (group or a label key) adds per-group totals
+// Metrics of all targets come from a single storage query
+func (h *Handler) GetSummary(c *gin.Context) {
+	now := time.Now()
+	baseline := now.Add(-summaryTrendAge)
+
+	selector, err := labelSelector(c)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	groupBy := strings.TrimSpace(c.Query("group_by"))
+
+	current, previous, err := h.store.GetFleetSnapshot(c.Request.Context(), now, baseline, summaryLookback)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	// A project or label scoped summary only counts the targets and alerts in scope
+	targets := h.cfg().Targets
+	var alertStats *models.AlertStats
+	visible := h.visibleTargetNames(c)
+	if visible != nil || selector != nil {
+		targets = slices.DeleteFunc(slices.Clone(targets), func(t config.TargetConfig) bool {
+			return (visible != nil && !slices.Contains(visible, t.Name)) || !t.HasLabels(selector)
+		})
+		names := make([]string, len(targets))
+		for i, t := range targets {
+			names[i] = t.Name
+		}
+		alertStats, err = h.scopedAlertStats(c.Request.Context(), names)
+	} else {
+		alertStats, err = h.store.GetAlertStats(c.Request.Context())
+	}
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	currentByTarget := groupByTarget(current)
+	previousByTarget := groupByTarget(previous)
+	collectorHealth := h.collectorHealthByKey()
+
+	resp := SummaryResponse{
+		GeneratedAt:      now.In(h.cfg().GetLocation()),
+		TotalTargets:     len(targets),
+		TargetsByStatus:  map[string]int{"healthy": 0, "warning": 0, "critical": 0, "unknown": 0},
+		ActiveAlerts:     alertStats.ActiveAlerts,
+		AlertsBySeverity: alertStats.BySeverity,
+		TopTargets:       []TargetUsage{},
+	}
+
+	var prevTotals poolTotals
+	hasPrevious := false
+	groups := make(map[string]*GroupSummary)
+
+	for _, t := range targets {
+		instances := configuredInstances(t, currentByTarget[t.Name])
+		status := models.TargetStatus{Name: t.Name, Status: "unknown"}
+		if len(instances) > 0 {
+			status = h.buildTargetStatus(t.Name, instances, h.calculateStaleThreshold(t.Interval))
+		}
+		applyBreakerState(&status, collectorHealth)
+		if t.Paused {
+			status.Status = models.TargetStatusPaused
+		}
+		resp.TargetsByStatus[status.Status]++
+
+		var group *GroupSummary
+		if groupBy != "" {
+			value := groupKeyOf(t, groupBy)
+			if group = groups[value]; group == nil {
+				group = &GroupSummary{Value: value, TargetsByStatus: make(map[string]int)}
+				groups[value] = group
+			}
+			group.Targets = append(group.Targets, t.Name)
+			group.TargetsByStatus[status.Status]++
+		}
+
+		prev := configuredInstances(t, previousByTarget[t.Name])
+		var prevTarget poolTotals
+		for _, m := range prev {
+			prevTarget.active += m.Active
+			prevTarget.max += m.Max
+			prevTarget.pending += m.Pending
+		}
+		if len(prev) > 0 {
+			hasPrevious = true
+			prevTotals.active += prevTarget.active
+			prevTotals.max += prevTarget.max
+			prevTotals.pending += prevTarget.pending
+		}
+
+		if status.Current == nil {
+			continue
+		}
+		resp.TotalActive += status.Current.Active
+		resp.TotalMax += status.Current.Max
+		resp.TotalPending += status.Current.Pending
+		if group != nil {
+			group.TotalActive += status.Current.Active
+			group.TotalMax += status.Current.Max
+			group.TotalPending += status.Current.Pending
+		}
+
+		if status.Current.Max > 0 {
+			usage := TargetUsage{
+				Name:   t.Name,
+				Group:  t.Group,
+				Status: status.Status,
+				Active: status.Current.Active,
+				Max:    status.Current.Max,
+				Usage:  poolTotals{active: status.Current.Active, max: status.Current.Max}.usage(),
+			}
+			if prevTarget.max > 0 {
+				change := usage.Usage - prevTarget.usage()
+				usage.UsageChange = &change
+			}
+			resp.TopTargets = append(resp.TopTargets, usage)
+		}
+	}
+
+	totals := poolTotals{active: resp.TotalActive, max: resp.TotalMax, pending: resp.TotalPending}
+	resp.Usage = totals.usage()
+
+	sort.SliceStable(resp.TopTargets, func(i, j int) bool {
+		return resp.TopTargets[i].Usage > resp.TopTargets[j].Usage
+	})
+	if len(resp.TopTargets) > summaryTopTargets {
+		resp.TopTargets = resp.TopTargets[:summaryTopTargets]
+	}
+
+	if groupBy != "" {
+		resp.GroupBy = groupBy
+		resp.Groups = []GroupSummary{}
+		for _, g := range groups {
+			g.Usage = poolTotals{active: g.TotalActive, max: g.TotalMax}.usage()
+			resp.Groups = append(resp.Groups, *g)
+		}
+		sort.Slice(resp.Groups, func(i, j int) bool {
+			return resp.Groups[i].Value < resp.Groups[j].Value
+		})
+	}
+
+	if hasPrevious {
+		resp.Trend = &SummaryTrend{
+			Since:         baseline.In(h.cfg().GetLocation()),
+			ActiveChange:  totals.active - prevTotals.active,
+			MaxChange:     totals.max - prevTotals.max,
+			PendingChange: totals.pending - prevTotals.pending,
+			UsageChange:   totals.usage() - prevTotals.usage(),
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// groupByTarget groups samples by target name
+func groupByTarget(metrics []models.PoolMetrics) map[string][]models.PoolMetrics {
+	result := make(map[string][]models.PoolMetrics)
+	for _, m := range metrics {
+		result[m.TargetName] = append(result[m.TargetName], m)
+	}
+	return result
+}
+
+// configuredInstances keeps samples of instances in the target config
+// Push targets accept whatever instances their agents report
+func configuredInstances(t config.TargetConfig, metrics []models.PoolMetrics) []models.PoolMetrics {
+	if t.Type == config.TargetTypePush {
+		return metrics
+	}
+	valid := make(map[string]bool)
+	for _, inst := range t.GetInstances() {
+		valid[inst.ID] = true
+	}
+	var result []models.PoolMetrics
+	for _, m := range metrics {
+		if valid[m.InstanceName] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/export"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// SystemStatusResponse reports pondy's own operational state
+type SystemStatusResponse struct {
+	StartedAt     time.Time                `json:"started_at"`
+	UptimeSeconds float64                  `json:"uptime_seconds"`
+	Runtime       RuntimeStatus            `json:"runtime"`
+	WriteQueue    *storage.WriteQueueStats `json:"write_queue"`   // nil when writes are synchronous
+	InfluxDB      *export.InfluxSinkStats  `json:"influxdb"`      // nil when the InfluxDB mirror is disabled
+	Collectors    map[string]int           `json:"collectors"`    // Collector count by state
+	RateLimited   map[string]int64         `json:"rate_limited"`  // Rejected requests by limiter
+	Notifications *alerter.DeliveryStats   `json:"notifications"` // nil when alerting is not running
+}
+
+// RuntimeStatus holds Go runtime figures of the pondy process
+type RuntimeStatus struct {
+	GoVersion      string     `json:"go_version"`
+	Goroutines     int        `json:"goroutines"`
+	HeapAllocBytes uint64     `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64     `json:"heap_inuse_bytes"`
+	SysBytes       uint64     `json:"sys_bytes"` // Memory obtained from the OS
+	GCCycles       uint32     `json:"gc_cycles"`
+	GCPauseSeconds float64    `json:"gc_pause_seconds"` // Total stop-the-world pause time
+	LastGC         *time.Time `json:"last_gc,omitempty"`
+}
+
+// systemStatus collects the current operational state
+func (h *Handler) systemStatus() SystemStatusResponse {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	status := SystemStatusResponse{
+		StartedAt:     h.startedAt,
+		UptimeSeconds: time.Since(h.startedAt).Seconds(),
+		Runtime: RuntimeStatus{
+			GoVersion:      runtime.Version(),
+			Goroutines:     runtime.NumGoroutine(),
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapInuseBytes: mem.HeapInuse,
+			SysBytes:       mem.Sys,
+			GCCycles:       mem.NumGC,
+			GCPauseSeconds: time.Duration(mem.PauseTotalNs).Seconds(),
+		},
+		Collectors:  make(map[string]int),
+		RateLimited: make(map[string]int64),
+	}
+	if mem.LastGC > 0 {
+		t := time.Unix(0, int64(mem.LastGC))
+		status.Runtime.LastGC = &t
+	}
+
+	store := h.store
+	if q, ok := store.(*storage.WriteQueue); ok {
+		queue := q.Stats()
+		status.WriteQueue = &queue
+		store = q.Storage
+	}
+	if sink, ok := store.(*export.InfluxSink); ok {
+		mirror := sink.Stats()
+		status.InfluxDB = &mirror
+	}
+	if h.collectors != nil {
+		for _, col := range h.collectors.Health() {
+			status.Collectors[col.State]++
+		}
+	}
+	for name, rl := range h.rateLimiters {
+		status.RateLimited[name] = rl.Rejected()
+	}
+	if h.alertMgr != nil {
+		stats := h.alertMgr.DeliveryStats()
+		status.Notifications = &stats
+	}
+	return status
+}
+
+// GetSystemStatus returns pondy's own operational state
+func (h *Handler) GetSystemStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.systemStatus())
+}
+
+// GetSystemMetrics returns the operational state in the Prometheus text format
+func (h *Handler) GetSystemMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	writeSystemMetrics(c.Writer, h.systemStatus())
+}
+
+// metricSample is one value of a metric, with labels already formatted as {name="value"}
+type metricSample struct {
+	labels string
+	value  float64
+}
+
+// writeMetric writes a metric family in the Prometheus text format
+func writeMetric(w io.Writer, name, typ, help string, samples ...metricSample) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", name, s.labels, strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+}
+
+// labeledSamples turns a map into samples labeled by key, sorted for stable output
+func labeledSamples[V int | int64](label string, values map[string]V) []metricSample {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	samples := make([]metricSample, 0, len(keys))
+	for _, k := range keys {
+		samples = append(samples, metricSample{labels: fmt.Sprintf("{%s=%q}", label, k), value: float64(values[k])})
+	}
+	return samples
+}
+
+func writeSystemMetrics(w io.Writer, s SystemStatusResponse) {
+	single := func(v float64) metricSample { return metricSample{value: v} }
+
+	writeMetric(w, "pondy_start_time_seconds", "gauge", "Start time of the process since the Unix epoch in seconds.", single(float64(s.StartedAt.Unix())))
+	writeMetric(w, "pondy_uptime_seconds", "gauge", "Time since the process started in seconds.", single(s.UptimeSeconds))
+
+	writeMetric(w, "pondy_goroutines", "gauge", "Number of goroutines.", single(float64(s.Runtime.Goroutines)))
+	writeMetric(w, "pondy_heap_alloc_bytes", "gauge", "Bytes of allocated heap objects.", single(float64(s.Runtime.HeapAllocBytes)))
+	writeMetric(w, "pondy_heap_inuse_bytes", "gauge", "Bytes in in-use heap spans.", single(float64(s.Runtime.HeapInuseBytes)))
+	writeMetric(w, "pondy_sys_bytes", "gauge", "Bytes of memory obtained from the OS.", single(float64(s.Runtime.SysBytes)))
+	writeMetric(w, "pondy_gc_cycles_total", "counter", "Completed GC cycles.", single(float64(s.Runtime.GCCycles)))
+	writeMetric(w, "pondy_gc_pause_seconds_total", "counter", "Total GC stop-the-world pause time in seconds.", single(s.Runtime.GCPauseSeconds))
+
+	if q := s.WriteQueue; q != nil {
+		writeMetric(w, "pondy_write_queue_depth", "gauge", "Metrics waiting to be written to the database.", single(float64(q.Depth)))
+		writeMetric(w, "pondy_write_queue_capacity", "gauge", "Maximum queued metrics before writes become synchronous.", single(float64(q.Capacity)))
+		writeMetric(w, "pondy_write_queue_overflows_total", "counter", "Metrics written synchronously because the queue was full.", single(float64(q.Overflows)))
+		writeMetric(w, "pondy_db_written_metrics_total", "counter", "Metrics written to the database by the write queue.", single(float64(q.Written)))
+		writeMetric(w, "pondy_db_write_failures_total", "counter", "Metrics the write queue failed to write.", single(float64(q.Failed)))
+		writeMetric(w, "pondy_db_write_batches_total", "counter", "Batches written to the database.", single(float64(q.Batches)))
+		writeMetric(w, "pondy_db_write_seconds_total", "counter", "Time spent writing batches to the database in seconds.", single(q.WriteSeconds))
+		writeMetric(w, "pondy_db_last_write_seconds", "gauge", "Time spent writing the last batch in seconds.", single(q.LastWriteSeconds))
+	}
+
+	if m := s.InfluxDB; m != nil {
+		writeMetric(w, "pondy_influxdb_buffered", "gauge", "Metrics waiting to be mirrored to InfluxDB.", single(float64(m.Buffered)))
+		writeMetric(w, "pondy_influxdb_written_metrics_total", "counter", "Metrics mirrored to InfluxDB.", single(float64(m.Written)))
+		writeMetric(w, "pondy_influxdb_dropped_metrics_total", "counter", "Metrics dropped because the InfluxDB buffer was full.", single(float64(m.Dropped)))
+		writeMetric(w, "pondy_influxdb_write_failures_total", "counter", "Failed InfluxDB write requests.", single(float64(m.Failures)))
+	}
+
+	writeMetric(w, "pondy_collectors", "gauge", "Collectors by scrape state.", labeledSamples("state", s.Collectors)...)
+	writeMetric(w, "pondy_rate_limited_requests_total", "counter", "Requests rejected by rate limiting.", labeledSamples("limiter", s.RateLimited)...)
+
+	if n := s.Notifications; n != nil {
+		writeMetric(w, "pondy_notifications_total", "counter", "Notification deliveries by result.",
+			metricSample{labels: `{result="sent"}`, value: float64(n.Sent)},
+			metricSample{labels: `{result="failed"}`, value: float64(n.Failed)})
+	}
+}
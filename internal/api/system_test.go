@@ -0,0 +1,62 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestRateLimiter_Rejected(t *testing.T) {
+	rl := NewRateLimiter(1, time.Minute, 2)
+
+	for i := 0; i < 5; i++ {
+		rl.Allow("client")
+	}
+	if got := rl.Rejected(); got != 3 {
+		t.Errorf("Rejected() = %d, want 3", got)
+	}
+}
+
+func TestWriteSystemMetrics(t *testing.T) {
+	status := SystemStatusResponse{
+		StartedAt:     time.Unix(1700000000, 0),
+		UptimeSeconds: 90.5,
+		Runtime:       RuntimeStatus{Goroutines: 12},
+		WriteQueue:    &storage.WriteQueueStats{Depth: 3, Capacity: 1000, Written: 42, WriteSeconds: 0.25},
+		Collectors:    map[string]int{"up": 2, "down": 1},
+		RateLimited:   map[string]int64{"general": 7, "ingest": 0},
+		Notifications: &alerter.DeliveryStats{Sent: 5, Failed: 1},
+	}
+
+	var sb strings.Builder
+	writeSystemMetrics(&sb, status)
+	out := sb.String()
+
+	for _, want := range []string{
+		"# TYPE pondy_uptime_seconds gauge\npondy_uptime_seconds 90.5\n",
+		"pondy_start_time_seconds 1.7e+09\n",
+		"pondy_goroutines 12\n",
+		"pondy_write_queue_depth 3\n",
+		"pondy_db_written_metrics_total 42\n",
+		"pondy_db_write_seconds_total 0.25\n",
+		"pondy_collectors{state=\"down\"} 1\npondy_collectors{state=\"up\"} 2\n",
+		"pondy_rate_limited_requests_total{limiter=\"general\"} 7\n",
+		"pondy_notifications_total{result=\"failed\"} 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Metrics missing %q\n%s", want, out)
+		}
+	}
+
+	// Without a write queue or alerting, their metrics are left out
+	status.WriteQueue = nil
+	status.Notifications = nil
+	sb.Reset()
+	writeSystemMetrics(&sb, status)
+	if out := sb.String(); strings.Contains(out, "pondy_write_queue_depth") || strings.Contains(out, "pondy_notifications_total") {
+		t.Errorf("Unexpected write queue or notification metrics:\n%s", out)
+	}
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// targetList is the document format of target export and import, the same as the
+// targets section of the configuration file; JSON uses the same field names
+type targetList struct {
+	Targets []config.TargetConfig `yaml:"targets"`
+}
+
+// TargetImportResponse summarizes the changes of an import
+type TargetImportResponse struct {
+	DryRun    bool     `json:"dry_run"`
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// requestFormat returns yaml or json from the format parameter or the content type
+func requestFormat(c *gin.Context, contentType string) (string, error) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = "yaml"
+		if strings.Contains(contentType, "json") {
+			format = "json"
+		}
+	}
+	if format != "yaml" && format != "json" {
+		return "", fmt.Errorf("format must be yaml or json")
+	}
+	return format, nil
+}
+
+// ExportConfigTargets returns all configured targets as YAML or JSON
+// Endpoint credentials are masked; importing the masked endpoints keeps them
+func (h *Handler) ExportConfigTargets(c *gin.Context) {
+	format, err := requestFormat(c, c.GetHeader("Accept"))
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	targets := h.cfgMgr.GetAllTargets()
+	for i := range targets {
+		targets[i] = redactTargetURLs(targets[i])
+	}
+
+	respondDocument(c, format, "pondy-targets", targetList{Targets: targets})
+}
+
+// respondDocument sends an export document as a YAML or JSON attachment named base.yaml or base.json
+func respondDocument(c *gin.Context, format, base string, doc interface{}) {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	filename := base + ".yaml"
+	contentType := "application/yaml"
+
+	if format == "json" {
+		// Convert through YAML so JSON keeps the configuration field names and duration strings
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		if data, err = json.MarshalIndent(v, "", "  "); err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		filename = base + ".json"
+		contentType = "application/json"
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// parseTargetList decodes a target list, rejecting unknown fields
+// JSON is decoded as YAML, which it is a subset of
+func parseTargetList(data []byte) ([]config.TargetConfig, error) {
+	var list targetList
+	if err := decodeDocument(data, &list); err != nil {
+		return nil, err
+	}
+	return list.Targets, nil
+}
+
+// decodeDocument decodes an imported YAML or JSON document, rejecting unknown fields
+func decodeDocument(data []byte, doc interface{}) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("empty document")
+		}
+		return err
+	}
+	return nil
+}
+
+// validateImportedTargets checks every target and returns all problems found
+// Endpoints are not contacted, so an import works for targets that are not reachable yet
+func validateImportedTargets(targets []config.TargetConfig) []string {
+	var problems []string
+	names := make(map[string]int)
+
+	for i := range targets {
+		t := &targets[i]
+		label := fmt.Sprintf("targets[%d]", i)
+		if t.Name != "" {
+			label = fmt.Sprintf("target '%s'", t.Name)
+		}
+		fail := func(format string, args ...interface{}) {
+			problems = append(problems, label+": "+fmt.Sprintf(format, args...))
+		}
+
+		if t.Name == "" {
+			fail("name is required")
+		} else if prev, ok := names[t.Name]; ok {
+			fail("duplicate name, also used by targets[%d]", prev)
+		} else {
+			names[t.Name] = i
+		}
+
+		if t.Type == "" {
+			t.Type = config.TargetTypeActuator
+		}
+		if t.Type != config.TargetTypeActuator && t.Type != config.TargetTypeJolokia && t.Type != config.TargetTypePush {
+			fail("type must be actuator, jolokia, or push")
+		}
+		if t.Interval == 0 {
+			t.Interval = 10 * time.Second
+		} else if t.Interval < 0 {
+			fail("interval must be positive")
+		}
+		if err := config.ValidatePoolType(t.PoolType); err != nil {
+			fail("%v", err)
+		}
+		if err := config.ValidateScrapeMode(t.ScrapeMode); err != nil {
+			fail("%v", err)
+		}
+		if t.Retries < 0 {
+			fail("retries must not be negative")
+		}
+
+		if t.Type != config.TargetTypePush && t.Endpoint == "" && len(t.Instances) == 0 && t.Discovery == nil {
+			fail("endpoint, instances, or discovery is required")
+		}
+		if t.Discovery != nil {
+			if err := t.Discovery.Validate(); err != nil {
+				fail("%v", err)
+			}
+		}
+		if err := validateEndpointURL(t.Endpoint); err != nil {
+			fail("%v", err)
+		}
+
+		instanceIDs := make(map[string]bool)
+		for j, inst := range t.Instances {
+			if inst.ID == "" {
+				fail("instances[%d]: id is required", j)
+			} else if instanceIDs[inst.ID] {
+				fail("duplicate instance id '%s'", inst.ID)
+			}
+			instanceIDs[inst.ID] = true
+			if inst.Endpoint == "" {
+				fail("instance %s: endpoint is required", inst.ID)
+			} else if err := validateEndpointURL(inst.Endpoint); err != nil {
+				fail("instance %s: %v", inst.ID, err)
+			}
+		}
+
+		for j := range t.SLOs {
+			if err := t.SLOs[j].Validate(); err != nil {
+				fail("%v", err)
+			}
+		}
+		if t.Retention != "" {
+			if _, err := config.ParseMaxAge(t.Retention); err != nil {
+				fail("%v", err)
+			}
+		}
+		if t.Anomaly != nil {
+			if err := t.Anomaly.Validate(); err != nil {
+				fail("%v", err)
+			}
+		}
+		if err := config.ValidateLabels(t.Labels); err != nil {
+			fail("%v", err)
+		}
+	}
+	return problems
+}
+
+// sameTarget compares targets by their configuration file representation,
+// so nil and empty lists or defaulted fields don't count as changes
+func sameTarget(a, b config.TargetConfig) bool {
+	ya, errA := yaml.Marshal(a)
+	yb, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ya, yb)
+}
+
+// ImportConfigTargets replaces the configured targets with a YAML or JSON list
+// The whole list is validated before anything is applied; dry_run=true only reports the changes
+func (h *Handler) ImportConfigTargets(c *gin.Context) {
+	format, err := requestFormat(c, c.ContentType())
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		RespondBadRequest(c, "failed to read request body: "+err.Error())
+		return
+	}
+	targets, err := parseTargetList(data)
+	if err != nil {
+		RespondBadRequest(c, fmt.Sprintf("invalid %s: %v", format, err))
+		return
+	}
+	if problems := validateImportedTargets(targets); len(problems) > 0 {
+		RespondBadRequest(c, fmt.Sprintf("%d validation errors: %s", len(problems), strings.Join(problems, "; ")))
+		return
+	}
+
+	resp := TargetImportResponse{
+		DryRun:    c.Query("dry_run") == "true",
+		Added:     []string{},
+		Updated:   []string{},
+		Removed:   []string{},
+		Unchanged: []string{},
+	}
+	current := make(map[string]config.TargetConfig)
+	for _, t := range h.cfgMgr.GetAllTargets() {
+		current[t.Name] = t
+	}
+	var added []config.TargetConfig
+	for i := range targets {
+		prev, ok := current[targets[i].Name]
+		if ok {
+			restoreTargetURLs(&targets[i], prev)
+		}
+		t := targets[i]
+		switch {
+		case !ok:
+			resp.Added = append(resp.Added, t.Name)
+			added = append(added, t)
+		case sameTarget(prev, t):
+			resp.Unchanged = append(resp.Unchanged, t.Name)
+		default:
+			resp.Updated = append(resp.Updated, t.Name)
+		}
+		delete(current, t.Name)
+	}
+	for _, t := range h.cfgMgr.GetAllTargets() {
+		if _, ok := current[t.Name]; ok {
+			resp.Removed = append(resp.Removed, t.Name)
+		}
+	}
+
+	auditAfter(c, resp)
+	if resp.DryRun {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	if err := h.cfgMgr.ReplaceTargets(targets); err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if err := h.cfgMgr.SaveConfig(); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	// Backfill recent history of new targets, as when adding them one by one
+	for _, t := range added {
+		h.startBackfill(t)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
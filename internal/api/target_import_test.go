@@ -0,0 +1,81 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseTargetList_RoundTrip(t *testing.T) {
+	targets := []config.TargetConfig{
+		{Name: "orders", Type: config.TargetTypeActuator, Endpoint: "http://orders:8080/actuator", Interval: 15 * time.Second, Group: "prod"},
+		{Name: "billing", Type: config.TargetTypeJolokia, Interval: time.Minute, Instances: []config.InstanceConfig{
+			{ID: "a", Endpoint: "http://billing-a:8778/jolokia"},
+			{ID: "b", Endpoint: "http://billing-b:8778/jolokia"},
+		}},
+	}
+	data, err := yaml.Marshal(targetList{Targets: targets})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseTargetList(data)
+	if err != nil {
+		t.Fatalf("parseTargetList failed: %v", err)
+	}
+	if len(parsed) != 2 || !sameTarget(parsed[0], targets[0]) || !sameTarget(parsed[1], targets[1]) {
+		t.Errorf("parsed = %+v, want %+v", parsed, targets)
+	}
+
+	// JSON with the same field names
+	parsed, err = parseTargetList([]byte(`{"targets": [{"name": "orders", "endpoint": "http://orders:8080/actuator", "interval": "30s"}]}`))
+	if err != nil {
+		t.Fatalf("parseTargetList(json) failed: %v", err)
+	}
+	if len(parsed) != 1 || parsed[0].Interval != 30*time.Second {
+		t.Errorf("parsed json = %+v", parsed)
+	}
+
+	// Typos are rejected instead of silently ignored
+	if _, err := parseTargetList([]byte("targets:\n  - name: orders\n    endpiont: http://orders:8080\n")); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err := parseTargetList(nil); err == nil {
+		t.Error("empty document accepted")
+	}
+}
+
+func TestValidateImportedTargets(t *testing.T) {
+	targets := []config.TargetConfig{
+		{Name: "orders", Endpoint: "http://orders:8080/actuator"},
+		{Name: "orders", Endpoint: "http://orders-2:8080/actuator"},
+		{Name: "billing", Endpoint: "ftp://billing"},
+		{Name: "payments", Type: "snmp", Endpoint: "http://payments:8080"},
+		{Name: "search", Instances: []config.InstanceConfig{{ID: "a", Endpoint: "http://a:8080"}, {ID: "a", Endpoint: "http://b:8080"}}},
+		{Name: "ingest", Type: config.TargetTypePush},
+	}
+
+	problems := validateImportedTargets(targets)
+	want := []string{
+		"target 'orders': duplicate name",
+		"target 'billing': endpoint must start with http",
+		"target 'payments': type must be",
+		"target 'search': duplicate instance id 'a'",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems = %v, want %d", problems, len(want))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(problems[i], w) {
+			t.Errorf("problems[%d] = %q, want prefix %q", i, problems[i], w)
+		}
+	}
+
+	// Defaults are applied to valid targets
+	if targets[0].Type != config.TargetTypeActuator || targets[0].Interval != 10*time.Second {
+		t.Errorf("defaults not applied: %+v", targets[0])
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PauseTargetRequest is the optional body of the pause endpoint
+type PauseTargetRequest struct {
+	Reason string `json:"reason"` // Shown with the paused status, e.g., "DB migration until Friday"
+}
+
+// PauseTarget stops collecting and alerting on a target, keeping its config and history
+func (h *Handler) PauseTarget(c *gin.Context) {
+	var req PauseTargetRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondBadRequest(c, "invalid request body: "+err.Error())
+			return
+		}
+	}
+	h.setTargetPaused(c, true, req.Reason)
+}
+
+// ResumeTarget restarts collecting and alerting on a paused target
+func (h *Handler) ResumeTarget(c *gin.Context) {
+	h.setTargetPaused(c, false, "")
+}
+
+func (h *Handler) setTargetPaused(c *gin.Context, paused bool, reason string) {
+	name := c.Param("name")
+
+	current, err := h.cfgMgr.GetTarget(name)
+	if err != nil {
+		RespondNotFound(c, err.Error())
+		return
+	}
+	auditBefore(c, targetConfigToResponse(*current))
+
+	target, err := h.cfgMgr.SetTargetPaused(name, paused, reason)
+	if err != nil {
+		RespondNotFound(c, err.Error())
+		return
+	}
+
+	// Saving notifies the collectors and the alerter
+	if err := h.cfgMgr.SaveConfig(); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	message := "target resumed"
+	if paused {
+		message = "target paused"
+	}
+	auditAfter(c, targetConfigToResponse(target))
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"target":  targetConfigToResponse(target),
+	})
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RenameTargetRequest is the body of the rename endpoint
+type RenameTargetRequest struct {
+	NewName string `json:"new_name"`
+
+	// MigrateHistory moves metrics, rollups, alerts, maintenance windows and silences
+	// to the new name (default: true); otherwise they stay under the old name
+	MigrateHistory *bool `json:"migrate_history,omitempty"`
+}
+
+// RenameConfigTarget renames a target, moving its history along unless disabled
+func (h *Handler) RenameConfigTarget(c *gin.Context) {
+	name := c.Param("name")
+
+	var req RenameTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+	req.NewName = strings.TrimSpace(req.NewName)
+	if req.NewName == "" {
+		RespondBadRequest(c, "new_name is required")
+		return
+	}
+	if req.NewName == name {
+		RespondBadRequest(c, "new_name is the current name")
+		return
+	}
+	migrate := req.MigrateHistory == nil || *req.MigrateHistory
+
+	current, err := h.cfgMgr.GetTarget(name)
+	if err != nil {
+		RespondNotFound(c, err.Error())
+		return
+	}
+
+	// Migrated rows would collide with leftover data of a removed target of the same name
+	if migrate {
+		stored, err := h.store.GetTargets(c.Request.Context())
+		if err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		for _, t := range stored {
+			if t == req.NewName {
+				RespondError(c, http.StatusConflict, fmt.Sprintf("data for '%s' already exists; purge it first", req.NewName))
+				return
+			}
+		}
+	}
+	auditBefore(c, targetConfigToResponse(*current))
+
+	// The config is renamed first so collectors write under the new name,
+	// then the history, including samples written meanwhile, follows
+	target, err := h.cfgMgr.RenameTarget(name, req.NewName)
+	if err != nil {
+		RespondBadRequest(c, err.Error())
+		return
+	}
+	if err := h.cfgMgr.SaveConfig(); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	var moved int64
+	if migrate {
+		moved, err = h.store.RenameTarget(c.Request.Context(), name, req.NewName)
+		if err != nil {
+			// Keep config and history together under the old name
+			if _, revertErr := h.cfgMgr.RenameTarget(req.NewName, name); revertErr == nil {
+				if saveErr := h.cfgMgr.SaveConfig(); saveErr != nil {
+					log.Printf("Failed to save config after reverting rename of %s: %v", name, saveErr)
+				}
+			}
+			RespondInternalError(c, fmt.Errorf("history migration failed, rename reverted: %w", err))
+			return
+		}
+	}
+	h.InvalidateCache()
+
+	auditAfter(c, targetConfigToResponse(target))
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "target renamed",
+		"target":     targetConfigToResponse(target),
+		"rows_moved": moved,
+	})
+}
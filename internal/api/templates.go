@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/config"
+)
+
+// Notification template handlers
+
+// templateOrNil returns nil for an empty template so the channel uses its built-in layout
+func templateOrNil(t *config.NotificationTemplate) *config.NotificationTemplate {
+	if t.IsEmpty() {
+		return nil
+	}
+	return t
+}
+
+// ValidateTemplateRequest is the request body for template validation
+type ValidateTemplateRequest struct {
+	Channel  string                      `json:"channel"` // slack, discord, webhook, email
+	Template config.NotificationTemplate `json:"template"`
+}
+
+// ValidateTemplateResponse contains the validation result and sample renders
+type ValidateTemplateResponse struct {
+	Valid    bool                      `json:"valid"`
+	Error    string                    `json:"error,omitempty"`
+	Fired    *alerter.RenderedTemplate `json:"fired,omitempty"`
+	Resolved *alerter.RenderedTemplate `json:"resolved,omitempty"`
+}
+
+// ValidateTemplate checks a notification template and previews it with sample data
+func (h *Handler) ValidateTemplate(c *gin.Context) {
+	var req ValidateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBadRequest(c, "invalid request body: "+err.Error())
+		return
+	}
+
+	switch req.Channel {
+	case "", "slack", "discord", "webhook", "email":
+	default:
+		RespondBadRequest(c, "channel must be slack, discord, webhook, or email")
+		return
+	}
+	if req.Template.IsEmpty() {
+		RespondBadRequest(c, "template must set title, body, or fields")
+		return
+	}
+
+	// Email bodies are HTML and rendered with escaping
+	html := req.Channel == "email"
+
+	fired, err := alerter.RenderTemplate(&req.Template, alerter.SampleTemplateData(false), html)
+	if err != nil {
+		c.JSON(http.StatusOK, ValidateTemplateResponse{Valid: false, Error: err.Error()})
+		return
+	}
+	resolved, err := alerter.RenderTemplate(&req.Template, alerter.SampleTemplateData(true), html)
+	if err != nil {
+		c.JSON(http.StatusOK, ValidateTemplateResponse{Valid: false, Error: err.Error()})
+		return
+	}
+	// Webhook bodies are sent as the raw JSON payload
+	if req.Channel == "webhook" {
+		if err := alerter.ValidateWebhookTemplate(&req.Template); err != nil {
+			c.JSON(http.StatusOK, ValidateTemplateResponse{Valid: false, Error: err.Error(), Fired: fired, Resolved: resolved})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, ValidateTemplateResponse{
+		Valid:    true,
+		Fired:    fired,
+		Resolved: resolved,
+	})
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/lifecycle"
+)
+
+// CertReloader serves the configured certificate and client CAs
+// Files are checked every reload interval; a renewal that fails to load keeps the previous certificate
+type CertReloader struct {
+	cfg config.TLSConfig
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamp     string // Sizes and modification times of the loaded files
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewCertReloader loads the certificate files and starts watching them
+func NewCertReloader(cfg config.TLSConfig) (*CertReloader, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled() {
+		return nil, fmt.Errorf("server.tls is not configured")
+	}
+
+	r := &CertReloader{cfg: cfg, stop: make(chan struct{}), done: make(chan struct{})}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	go r.run()
+	return r, nil
+}
+
+// files returns the files the reloader watches
+func (r *CertReloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// fileStamp summarizes the sizes and modification times of the watched files
+func (r *CertReloader) fileStamp() (string, error) {
+	var parts []string
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano()))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// reload loads the files when they changed since the last load, returning whether they did
+func (r *CertReloader) reload() (bool, error) {
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	unchanged := stamp == r.stamp
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("no certificates found in client CA file %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCAs, r.stamp = &cert, clientCAs, stamp
+	r.mu.Unlock()
+	return true, nil
+}
+
+func (r *CertReloader) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.cfg.GetReloadInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := r.reload()
+			if err != nil {
+				log.Printf("TLS: keeping the current certificate: %v", err)
+			} else if changed {
+				log.Printf("TLS: reloaded certificate from %s", r.cfg.CertFile)
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Stop stops watching the files
+func (r *CertReloader) Stop() {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	<-r.done
+}
+
+// TLSConfig returns a server TLS config using the latest loaded certificate for each handshake
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.minVersion(),
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current(), nil
+		},
+	}
+}
+
+// current builds the TLS config of a handshake from the loaded files
+func (r *CertReloader) current() *tls.Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cfg := &tls.Config{
+		MinVersion:   r.minVersion(),
+		Certificates: []tls.Certificate{*r.cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	switch r.cfg.GetClientAuth() {
+	case config.ClientAuthRequire:
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = r.clientCAs
+	case config.ClientAuthOptional:
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		cfg.ClientCAs = r.clientCAs
+	}
+	return cfg
+}
+
+func (r *CertReloader) minVersion() uint16 {
+	if r.cfg.MinVersion == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+// ListenAndServe serves srv over HTTPS when server.tls is configured, plain HTTP otherwise
+// The certificate reloader is registered with lc, so it stops on shutdown
+func ListenAndServe(srv *http.Server, cfg config.TLSConfig, lc *lifecycle.Manager) error {
+	if !cfg.Enabled() {
+		return srv.ListenAndServe()
+	}
+
+	reloader, err := NewCertReloader(cfg)
+	if err != nil {
+		return err
+	}
+	if lc != nil {
+		lc.RegisterFunc("TLS certificate reloader", reloader.Stop)
+	}
+	srv.TLSConfig = reloader.TLSConfig()
+	log.Printf("Serving HTTPS on %s (client certificates: %s)", srv.Addr, clientAuthLabel(cfg))
+	return srv.ListenAndServeTLS("", "")
+}
+
+func clientAuthLabel(cfg config.TLSConfig) string {
+	if mode := cfg.GetClientAuth(); mode != "" {
+		return mode
+	}
+	return "not verified"
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// writeCert writes a certificate for localhost, signed by parent or self-signed, and returns it with its key
+func writeCert(t *testing.T, dir, name string, serial int64, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func startTLSServer(t *testing.T, r *CertReloader) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	srv.TLS = r.TLSConfig()
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// servedSerial returns the serial number of the certificate the server presents
+func servedSerial(t *testing.T, addr string, clientCert *tls.Certificate) (int64, error) {
+	t.Helper()
+	cfg := &tls.Config{InsecureSkipVerify: true}
+	if clientCert != nil {
+		// Sent even when not issued by a CA the server asks for
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return clientCert, nil }
+	}
+	conn, err := tls.Dial("tcp", addr, cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// TLS 1.3 reports client certificate rejections on the first read
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !isTimeout(err) {
+		return 0, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func isTimeout(err error) bool {
+	ne, ok := err.(interface{ Timeout() bool })
+	return ok && ne.Timeout()
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	writeCert(t, dir, "server", 1, false, nil, nil)
+
+	r, err := NewCertReloader(config.TLSConfig{
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	})
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+	defer r.Stop()
+	srv := startTLSServer(t, r)
+
+	if serial, err := servedSerial(t, srv.Listener.Addr().String(), nil); err != nil || serial != 1 {
+		t.Fatalf("served serial = %d, %v, want 1", serial, err)
+	}
+
+	// A broken renewal keeps the current certificate
+	if err := os.WriteFile(filepath.Join(dir, "server.crt"), []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.reload(); err == nil {
+		t.Error("reload() of an invalid certificate should fail")
+	}
+	if serial, err := servedSerial(t, srv.Listener.Addr().String(), nil); err != nil || serial != 1 {
+		t.Errorf("served serial after failed reload = %d, %v, want 1", serial, err)
+	}
+
+	writeCert(t, dir, "server", 2, false, nil, nil)
+	if changed, err := r.reload(); err != nil || !changed {
+		t.Fatalf("reload() = %v, %v, want changed", changed, err)
+	}
+	if serial, err := servedSerial(t, srv.Listener.Addr().String(), nil); err != nil || serial != 2 {
+		t.Errorf("served serial after renewal = %d, %v, want 2", serial, err)
+	}
+}
+
+func TestCertReloader_ClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", 10, true, nil, nil)
+	writeCert(t, dir, "server", 11, false, ca, caKey)
+	writeCert(t, dir, "client", 12, false, ca, caKey)
+	writeCert(t, dir, "stranger", 13, false, nil, nil)
+
+	load := func(name string) *tls.Certificate {
+		cert, err := tls.LoadX509KeyPair(filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &cert
+	}
+
+	for _, mode := range []string{config.ClientAuthRequire, config.ClientAuthOptional} {
+		t.Run(mode, func(t *testing.T) {
+			r, err := NewCertReloader(config.TLSConfig{
+				CertFile:     filepath.Join(dir, "server.crt"),
+				KeyFile:      filepath.Join(dir, "server.key"),
+				ClientCAFile: filepath.Join(dir, "ca.crt"),
+				ClientAuth:   mode,
+			})
+			if err != nil {
+				t.Fatalf("NewCertReloader() error = %v", err)
+			}
+			defer r.Stop()
+			addr := startTLSServer(t, r).Listener.Addr().String()
+
+			if _, err := servedSerial(t, addr, load("client")); err != nil {
+				t.Errorf("client signed by the CA rejected: %v", err)
+			}
+			if _, err := servedSerial(t, addr, load("stranger")); err == nil {
+				t.Error("client certificate from another CA accepted")
+			}
+			_, err = servedSerial(t, addr, nil)
+			if mode == config.ClientAuthRequire && err == nil {
+				t.Error("connection without a client certificate accepted")
+			}
+			if mode == config.ClientAuthOptional && err != nil {
+				t.Errorf("connection without a client certificate rejected: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyUsage holds usage counters for a single API key
+type KeyUsage struct {
+	Name          string           `json:"name"`
+	Requests      int64            `json:"requests"`
+	Errors        int64            `json:"errors"`       // responses with status >= 400
+	RateLimited   int64            `json:"rate_limited"` // responses with status 429
+	BytesSent     int64            `json:"bytes_sent"`
+	ExportedBytes int64            `json:"exported_bytes"` // bytes sent by export/report/backup endpoints
+	Endpoints     map[string]int64 `json:"endpoints"`      // "METHOD /route" -> count
+	FirstSeen     time.Time        `json:"first_seen"`
+	LastSeen      time.Time        `json:"last_seen"`
+}
+
+// UsageTracker records per-API-key request metering in memory
+type UsageTracker struct {
+	mu      sync.Mutex
+	usage   map[string]*KeyUsage
+	started time.Time
+}
+
+// NewUsageTracker creates a new usage tracker
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		usage:   make(map[string]*KeyUsage),
+		started: time.Now(),
+	}
+}
+
+// isExportRoute reports whether a route returns bulk data to the client
+func isExportRoute(route string) bool {
+	return strings.Contains(route, "/export") ||
+		strings.Contains(route, "/report") ||
+		strings.Contains(route, "/backup/download") ||
+		strings.Contains(route, "/actions/")
+}
+
+// Record records a single request for the given key
+func (u *UsageTracker) Record(keyName, method, route string, status int, bytes int64) {
+	if route == "" {
+		route = "unmatched"
+	}
+	if bytes < 0 {
+		bytes = 0
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	ku, exists := u.usage[keyName]
+	if !exists {
+		ku = &KeyUsage{
+			Name:      keyName,
+			Endpoints: make(map[string]int64),
+			FirstSeen: now,
+		}
+		u.usage[keyName] = ku
+	}
+
+	ku.Requests++
+	ku.BytesSent += bytes
+	ku.Endpoints[method+" "+route]++
+	ku.LastSeen = now
+	if status >= 400 {
+		ku.Errors++
+	}
+	if status == http.StatusTooManyRequests {
+		ku.RateLimited++
+	}
+	if isExportRoute(route) {
+		ku.ExportedBytes += bytes
+	}
+}
+
+// Snapshot returns a copy of all usage records, busiest keys first
+func (u *UsageTracker) Snapshot() []KeyUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	result := make([]KeyUsage, 0, len(u.usage))
+	for _, ku := range u.usage {
+		cp := *ku
+		cp.Endpoints = make(map[string]int64, len(ku.Endpoints))
+		for k, v := range ku.Endpoints {
+			cp.Endpoints[k] = v
+		}
+		result = append(result, cp)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Get returns a copy of the usage record of a key
+func (u *UsageTracker) Get(keyName string) (KeyUsage, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	ku, ok := u.usage[keyName]
+	if !ok {
+		return KeyUsage{}, false
+	}
+	cp := *ku
+	cp.Endpoints = make(map[string]int64, len(ku.Endpoints))
+	for k, v := range ku.Endpoints {
+		cp.Endpoints[k] = v
+	}
+	return cp, true
+}
+
+// Since returns when usage tracking started
+func (u *UsageTracker) Since() time.Time {
+	return u.started
+}
+
+// UsageMiddleware records request metering for the authenticated API key
+func UsageMiddleware(u *UsageTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		u.Record(apiKeyName(c), c.Request.Method, c.FullPath(), c.Writer.Status(), int64(c.Writer.Size()))
+	}
+}
+
+// UsageResponse is the response for the usage endpoint
+type UsageResponse struct {
+	Since time.Time  `json:"since"`
+	Keys  []KeyUsage `json:"keys"`
+}
+
+// GetUsage returns per-API-key usage statistics
+func (h *Handler) GetUsage(c *gin.Context) {
+	if h.usage == nil {
+		RespondError(c, http.StatusServiceUnavailable, "usage tracking not initialized")
+		return
+	}
+
+	c.JSON(http.StatusOK, UsageResponse{
+		Since: h.usage.Since(),
+		Keys:  h.usage.Snapshot(),
+	})
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestUsageTracker_Record(t *testing.T) {
+	u := NewUsageTracker()
+
+	u.Record("ci", "GET", "/api/targets", http.StatusOK, 100)
+	u.Record("ci", "GET", "/api/targets", http.StatusOK, 50)
+	u.Record("ci", "GET", "/api/targets/:name/export", http.StatusOK, 1000)
+	u.Record("ci", "GET", "/api/targets", http.StatusTooManyRequests, 10)
+
+	snapshot := u.Snapshot()
+	if len(snapshot) != 1 {
+		t.Fatalf("Expected 1 key, got %d", len(snapshot))
+	}
+
+	ku := snapshot[0]
+	if ku.Requests != 4 {
+		t.Errorf("Requests = %d, want 4", ku.Requests)
+	}
+	if ku.BytesSent != 1160 {
+		t.Errorf("BytesSent = %d, want 1160", ku.BytesSent)
+	}
+	if ku.ExportedBytes != 1000 {
+		t.Errorf("ExportedBytes = %d, want 1000", ku.ExportedBytes)
+	}
+	if ku.Errors != 1 || ku.RateLimited != 1 {
+		t.Errorf("Errors = %d, RateLimited = %d, want 1 and 1", ku.Errors, ku.RateLimited)
+	}
+	if ku.Endpoints["GET /api/targets"] != 3 {
+		t.Errorf("Endpoint count = %d, want 3", ku.Endpoints["GET /api/targets"])
+	}
+}
+
+func TestUsageTracker_SnapshotOrder(t *testing.T) {
+	u := NewUsageTracker()
+
+	u.Record("quiet", "GET", "/api/targets", http.StatusOK, 0)
+	for i := 0; i < 5; i++ {
+		u.Record("busy", "GET", "/api/targets", http.StatusOK, 0)
+	}
+
+	snapshot := u.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(snapshot))
+	}
+	if snapshot[0].Name != "busy" {
+		t.Errorf("Expected busiest key first, got %s", snapshot[0].Name)
+	}
+
+	// Snapshot must not share maps with the tracker
+	snapshot[0].Endpoints["GET /api/targets"] = 0
+	if u.Snapshot()[0].Endpoints["GET /api/targets"] != 5 {
+		t.Error("Snapshot should return a copy of endpoint counters")
+	}
+}
+
+func TestMatchAPIKey(t *testing.T) {
+	keys := []config.APIKeyConfig{
+		{Name: "grafana", Key: "secret-1"},
+		{Name: "empty", Key: ""},
+		{Name: "ci", Key: "secret-2"},
+	}
+
+	tests := []struct {
+		value    string
+		wantName string
+		wantOK   bool
+	}{
+		{"secret-1", "grafana", true},
+		{"secret-2", "ci", true},
+		{"wrong", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			name, ok := matchAPIKey(keys, tt.value)
+			if name != tt.wantName || ok != tt.wantOK {
+				t.Errorf("matchAPIKey(%q) = (%q, %v), want (%q, %v)", tt.value, name, ok, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	open := &config.ServerConfig{}
+	if !isAdmin(open, AnonymousKeyName, nil) {
+		t.Error("isAdmin() without authentication = false, want true")
+	}
+
+	// Without admin keys, keys without a project scope are admins
+	keys := &config.ServerConfig{APIKeys: []config.APIKeyConfig{{Name: "ops", Key: "k1"}, {Name: "payments", Key: "k2", Projects: []string{"payments"}}}}
+	if !isAdmin(keys, "ops", nil) || isAdmin(keys, "payments", projectScope{"payments"}) {
+		t.Error("isAdmin() without admin keys should only allow unscoped keys")
+	}
+
+	keys.APIKeys = append(keys.APIKeys, config.APIKeyConfig{Name: "root", Key: "k3", Admin: true})
+	if isAdmin(keys, "ops", nil) || !isAdmin(keys, "root", nil) {
+		t.Error("isAdmin() with admin keys should only allow them")
+	}
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// View handlers
+
+type ViewsResponse struct {
+	Views []models.View `json:"views"`
+	Total int           `json:"total"`
+}
+
+// ViewDataResponse holds the metrics of a view's targets for rendering its panels
+type ViewDataResponse struct {
+	View   *models.View `json:"view"`
+	Range  string       `json:"range"`
+	Series []ViewSeries `json:"series"`
+}
+
+// ViewSeries is the history of one target of a view, limited to the view's instances
+type ViewSeries struct {
+	TargetName string               `json:"target_name"`
+	Datapoints []models.PoolMetrics `json:"datapoints"`
+}
+
+// defaultViewRange is the time range of a view without one
+const defaultViewRange = "24h"
+
+// validViewInput checks a view input and that its targets are visible to the request
+// On failure an error response is sent and false returned
+func (h *Handler) validViewInput(c *gin.Context, input *models.ViewInput) bool {
+	input.Name = strings.TrimSpace(input.Name)
+	if err := input.Validate(); err != nil {
+		RespondBadRequest(c, err.Error())
+		return false
+	}
+	if input.Range != "" {
+		if d, err := time.ParseDuration(input.Range); err != nil || d <= 0 {
+			RespondBadRequest(c, fmt.Sprintf("invalid range '%s', use a duration such as 24h", input.Range))
+			return false
+		}
+	}
+	for _, t := range input.Targets {
+		if !h.targetVisible(c, t.Target) {
+			RespondNotFound(c, fmt.Sprintf("target '%s' not found", t.Target))
+			return false
+		}
+	}
+	return true
+}
+
+// viewFromParam loads the view of the :id parameter, responding with an error when it is not visible
+func (h *Handler) viewFromParam(c *gin.Context) (*models.View, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid view ID")
+		return nil, false
+	}
+	return h.visibleView(c, id)
+}
+
+// visibleView loads a view, responding with an error when it is missing or not visible
+func (h *Handler) visibleView(c *gin.Context, id int64) (*models.View, bool) {
+	view, err := h.store.GetView(c.Request.Context(), id)
+	if err != nil {
+		RespondInternalError(c, err)
+		return nil, false
+	}
+	if view == nil || !scopedVisible(c, view.Project) {
+		RespondNotFound(c, "view not found")
+		return nil, false
+	}
+	return view, true
+}
+
+func (h *Handler) GetViews(c *gin.Context) {
+	views, err := h.store.GetViews(c.Request.Context())
+	if err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+	views = slices.DeleteFunc(views, func(v models.View) bool {
+		return !scopedVisible(c, v.Project)
+	})
+	if views == nil {
+		views = []models.View{}
+	}
+
+	c.JSON(http.StatusOK, ViewsResponse{Views: views, Total: len(views)})
+}
+
+func (h *Handler) GetView(c *gin.Context) {
+	view, ok := h.viewFromParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+func (h *Handler) CreateView(c *gin.Context) {
+	var input models.ViewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid input: "+err.Error())
+		return
+	}
+	if !h.validViewInput(c, &input) {
+		return
+	}
+	project, ok := projectForWrite(c, input.Project)
+	if !ok {
+		return
+	}
+
+	view := &models.View{
+		Name:        input.Name,
+		Description: input.Description,
+		Targets:     input.Targets,
+		Panels:      input.Panels,
+		Range:       input.Range,
+		Project:     project,
+		CreatedBy:   c.GetString(ContextKeyAPIKey),
+	}
+	if err := h.store.SaveView(c.Request.Context(), view); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	auditAfter(c, view)
+	c.JSON(http.StatusCreated, view)
+}
+
+func (h *Handler) UpdateView(c *gin.Context) {
+	view, ok := h.viewFromParam(c)
+	if !ok {
+		return
+	}
+	if !scopedWritable(c, view.Project) {
+		respondNotWritable(c, "view")
+		return
+	}
+
+	var input models.ViewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		RespondBadRequest(c, "invalid input: "+err.Error())
+		return
+	}
+	if !h.validViewInput(c, &input) {
+		return
+	}
+	project, ok := projectForWrite(c, input.Project)
+	if !ok {
+		return
+	}
+	auditBefore(c, view)
+
+	view.Name = input.Name
+	view.Description = input.Description
+	view.Targets = input.Targets
+	view.Panels = input.Panels
+	view.Range = input.Range
+	view.Project = project
+	if err := h.store.UpdateView(c.Request.Context(), view); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	auditAfter(c, view)
+	c.JSON(http.StatusOK, view)
+}
+
+func (h *Handler) DeleteView(c *gin.Context) {
+	view, ok := h.viewFromParam(c)
+	if !ok {
+		return
+	}
+	if !scopedWritable(c, view.Project) {
+		respondNotWritable(c, "view")
+		return
+	}
+	auditBefore(c, view)
+
+	if err := h.store.DeleteView(c.Request.Context(), view.ID); err != nil {
+		RespondInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "view deleted"})
+}
+
+// GetViewData returns the history of the view's targets over ?range= (default: the view's range)
+// Each target is downsampled to ?limit= points (default: 500, max: 10000)
+func (h *Handler) GetViewData(c *gin.Context) {
+	view, ok := h.viewFromParam(c)
+	if !ok {
+		return
+	}
+
+	rangeParam := viewRange(view, c.Query("range"))
+	tr := ParseTimeRange(rangeParam, DefaultRangeLong)
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "500"))
+	if err != nil || limit <= 0 {
+		limit = 500
+	}
+	if limit > 10000 {
+		limit = 10000
+	}
+
+	resp := ViewDataResponse{View: view, Range: rangeParam, Series: []ViewSeries{}}
+	for _, t := range view.Targets {
+		// Targets moved out of the request's projects are left out
+		if !h.targetVisible(c, t.Target) {
+			continue
+		}
+		// Targets of all instances are bucketed by the database, instance subsets after filtering
+		var datapoints []models.PoolMetrics
+		if len(t.Instances) == 0 {
+			datapoints, err = h.store.GetHistoryAggregated(c.Request.Context(), t.Target, "", tr.From, tr.To, historyBucket(tr, limit, 0))
+		} else {
+			datapoints, err = h.store.GetHistory(c.Request.Context(), t.Target, tr.From, tr.To)
+			datapoints = downsampleMetrics(viewDatapoints(&t, datapoints), limit)
+		}
+		if err != nil {
+			RespondInternalError(c, err)
+			return
+		}
+		if datapoints == nil {
+			datapoints = []models.PoolMetrics{}
+		}
+		resp.Series = append(resp.Series, ViewSeries{
+			TargetName: t.Target,
+			Datapoints: datapoints,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// viewRange returns the requested range, or the view's own range and then the default
+func viewRange(view *models.View, requested string) string {
+	switch {
+	case requested != "":
+		return requested
+	case view.Range != "":
+		return view.Range
+	default:
+		return defaultViewRange
+	}
+}
+
+// viewDatapoints keeps the datapoints of the instances a view target selects
+func viewDatapoints(t *models.ViewTarget, datapoints []models.PoolMetrics) []models.PoolMetrics {
+	if len(t.Instances) == 0 {
+		return datapoints
+	}
+	return slices.DeleteFunc(datapoints, func(m models.PoolMetrics) bool {
+		return !t.IncludesInstance(m.InstanceName)
+	})
+}
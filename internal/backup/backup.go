@@ -0,0 +1,162 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// LocalDir is where backups are written before upload
+const LocalDir = "./data/backups"
+
+// Manager creates backups and copies them to object storage
+type Manager struct {
+	store  storage.Storage
+	s3     *S3Client
+	keep   int
+	cancel context.CancelFunc
+
+	onCompleted func(key string) // Called after each scheduled backup is uploaded
+}
+
+// NewManager creates a backup manager uploading to the configured bucket
+func NewManager(store storage.Storage, cfg config.S3BackupConfig) (*Manager, error) {
+	client, err := NewS3Client(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{store: store, s3: client, keep: cfg.Keep}, nil
+}
+
+// Remote returns the object storage client
+func (m *Manager) Remote() *S3Client {
+	return m.s3
+}
+
+// Create writes a local backup and uploads it, returning the local path and object key
+func (m *Manager) Create(ctx context.Context) (string, string, error) {
+	name := fmt.Sprintf("pondy_backup_%s.db", time.Now().Format("20060102_150405"))
+	path := filepath.Join(LocalDir, name)
+	if err := m.store.CreateBackup(ctx, path); err != nil {
+		return "", "", err
+	}
+
+	key, err := m.Upload(ctx, path)
+	return path, key, err
+}
+
+// Upload copies a local backup file to object storage and returns its key
+func (m *Manager) Upload(ctx context.Context, path string) (string, error) {
+	key := m.s3.Prefix() + filepath.Base(path)
+	if err := m.s3.Upload(ctx, key, path); err != nil {
+		return "", fmt.Errorf("upload to s3 failed: %w", err)
+	}
+	return key, nil
+}
+
+// ValidateKey checks that key names a backup under the configured prefix
+func (m *Manager) ValidateKey(key string) error {
+	if !strings.HasPrefix(key, m.s3.Prefix()) || !strings.HasSuffix(key, ".db") || strings.Contains(key, "..") {
+		return fmt.Errorf("invalid backup key '%s'", key)
+	}
+	return nil
+}
+
+// Restore downloads a remote backup and restores the database from it
+func (m *Manager) Restore(ctx context.Context, key string) error {
+	if err := m.ValidateKey(key); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(LocalDir, 0755); err != nil {
+		return err
+	}
+	tempPath := filepath.Join(LocalDir, fmt.Sprintf("restore_remote_%d.db", time.Now().UnixNano()))
+	if err := m.s3.Download(ctx, key, tempPath); err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(tempPath); err != nil {
+			log.Printf("Warning: failed to remove temp backup file %s: %v", tempPath, err)
+		}
+	}()
+
+	return m.store.RestoreBackup(ctx, tempPath)
+}
+
+// SetCompletedCallback sets the function called with the object key after each scheduled backup
+func (m *Manager) SetCompletedCallback(callback func(key string)) {
+	m.onCompleted = callback
+}
+
+// Start begins scheduled backups
+func (m *Manager) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.runScheduled(ctx)
+			}
+		}
+	}()
+
+	log.Printf("Backup manager started: interval=%v, keep=%d", interval, m.keep)
+}
+
+func (m *Manager) runScheduled(ctx context.Context) {
+	path, key, err := m.Create(ctx)
+	if path != "" {
+		// The remote copy is the one that survives disk loss
+		defer os.Remove(path)
+	}
+	if err != nil {
+		log.Printf("Scheduled backup failed: %v", err)
+		return
+	}
+	log.Printf("Scheduled backup uploaded: %s", key)
+	if m.onCompleted != nil {
+		m.onCompleted(key)
+	}
+
+	if m.keep > 0 {
+		m.prune(ctx)
+	}
+}
+
+// prune deletes remote backups beyond the newest keep
+func (m *Manager) prune(ctx context.Context) {
+	backups, err := m.s3.List(ctx)
+	if err != nil {
+		log.Printf("Backup prune failed: %v", err)
+		return
+	}
+	for i := m.keep; i < len(backups); i++ {
+		if err := m.s3.Delete(ctx, backups[i].Key); err != nil {
+			log.Printf("Backup prune failed to delete %s: %v", backups[i].Key, err)
+			continue
+		}
+		log.Printf("Backup prune: deleted %s", backups[i].Key)
+	}
+}
+
+// Stop stops scheduled backups
+func (m *Manager) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestRing(t *testing.T) {
+	if owner := NewRing(nil).Owner("orders"); owner != "" {
+		t.Errorf("empty ring owner = %q", owner)
+	}
+
+	ring := NewRing([]string{"c", "a", "b", "a"})
+	if got := ring.Nodes(); len(got) != 3 || got[0] != "a" {
+		t.Fatalf("Nodes() = %v", got)
+	}
+
+	// Same membership in any order gives the same assignment
+	other := NewRing([]string{"b", "c", "a"})
+	counts := map[string]int{}
+	before := map[string]string{}
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("target-%d", i)
+		owner := ring.Owner(key)
+		if owner != other.Owner(key) {
+			t.Fatalf("owner of %s differs between rings", key)
+		}
+		counts[owner]++
+		before[key] = owner
+	}
+	for node, n := range counts {
+		if n < 600 || n > 1400 {
+			t.Errorf("node %s owns %d of 3000 keys, want an even spread", node, n)
+		}
+	}
+
+	// Removing a node only moves its own keys
+	shrunk := NewRing([]string{"a", "b"})
+	for key, owner := range before {
+		if owner != "c" && shrunk.Owner(key) != owner {
+			t.Fatalf("%s moved from %s to %s", key, owner, shrunk.Owner(key))
+		}
+	}
+}
+
+func TestMembership(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "cluster.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	var mu sync.Mutex
+	var rings []*Ring
+	onChange := func(r *Ring) {
+		mu.Lock()
+		rings = append(rings, r)
+		mu.Unlock()
+	}
+	cfg := config.ClusterConfig{Enabled: true, NodeID: "a", HeartbeatInterval: time.Hour}
+	a := NewMembership(store, cfg, onChange)
+	if !a.Owns("orders") {
+		t.Error("a replica owns everything before its first heartbeat")
+	}
+
+	a.Heartbeat()
+	if len(rings) != 1 || !a.Owns("orders") {
+		t.Fatalf("single node rings = %d, owns = %v", len(rings), a.Owns("orders"))
+	}
+
+	// A second replica joins; both agree on ownership
+	cfg.NodeID = "b"
+	b := NewMembership(store, cfg, nil)
+	b.Heartbeat()
+	a.Heartbeat()
+	if len(rings) != 2 || len(rings[1].Nodes()) != 2 {
+		t.Fatalf("rings after join = %d", len(rings))
+	}
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("target-%d", i)
+		if a.Owns(name) == b.Owns(name) {
+			t.Fatalf("%s should be owned by exactly one replica", name)
+		}
+	}
+
+	// Unchanged membership doesn't notify
+	a.Heartbeat()
+	if len(rings) != 2 {
+		t.Errorf("rings after unchanged heartbeat = %d", len(rings))
+	}
+
+	// b leaves and a takes over everything
+	b.Start()
+	b.Stop()
+	a.Heartbeat()
+	if len(rings) != 3 || !a.Owns("target-1") || !a.Owns("target-2") {
+		t.Errorf("after leave rings = %d", len(rings))
+	}
+}
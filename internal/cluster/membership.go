@@ -0,0 +1,137 @@
+package cluster
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// Membership keeps this replica registered in the shared database and tracks the live replicas
+// Every heartbeat rebuilds the ring, and onChange is called when the set of replicas changes
+type Membership struct {
+	store    storage.Storage
+	self     models.ClusterNode
+	interval time.Duration
+	ttl      time.Duration
+	onChange func(*Ring)
+
+	mu   sync.RWMutex
+	ring *Ring
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMembership creates the membership of this replica
+// onChange receives the new ring, e.g., to start and stop collectors
+func NewMembership(store storage.Storage, cfg config.ClusterConfig, onChange func(*Ring)) *Membership {
+	now := time.Now()
+	return &Membership{
+		store: store,
+		self: models.ClusterNode{
+			ID:        cfg.GetNodeID(),
+			Address:   cfg.Address,
+			StartedAt: now,
+		},
+		interval: cfg.GetHeartbeatInterval(),
+		ttl:      cfg.GetNodeTTL(),
+		onChange: onChange,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start joins the cluster and keeps sending heartbeats
+// The first heartbeat runs before Start returns, so ownership is known when collectors start
+func (m *Membership) Start() {
+	m.Heartbeat()
+	go m.run()
+	log.Printf("Cluster membership started: node=%s, heartbeat=%v, ttl=%v", m.self.ID, m.interval, m.ttl)
+}
+
+// Stop leaves the cluster so the other replicas take over its targets right away
+func (m *Membership) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+		<-m.done
+		if err := m.store.DeleteClusterNode(context.Background(), m.self.ID); err != nil {
+			log.Printf("Cluster: failed to leave: %v", err)
+		}
+	})
+}
+
+func (m *Membership) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.Heartbeat()
+		}
+	}
+}
+
+// Heartbeat records this replica as alive and rebuilds the ring from the live replicas
+// When the database is unreachable the previous ring is kept
+func (m *Membership) Heartbeat() {
+	ctx := context.Background()
+	now := time.Now()
+	m.self.LastSeen = now
+	if err := m.store.HeartbeatClusterNode(ctx, &m.self); err != nil {
+		log.Printf("Cluster: heartbeat failed: %v", err)
+		return
+	}
+	nodes, err := m.store.GetClusterNodes(ctx, now.Add(-m.ttl))
+	if err != nil {
+		log.Printf("Cluster: failed to list nodes: %v", err)
+		return
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	ring := NewRing(ids)
+
+	m.mu.Lock()
+	changed := m.ring == nil || !slices.Equal(m.ring.Nodes(), ring.Nodes())
+	if changed {
+		m.ring = ring
+	}
+	m.mu.Unlock()
+
+	if changed {
+		log.Printf("Cluster: %d node(s) %v", len(ring.Nodes()), ring.Nodes())
+		if m.onChange != nil {
+			m.onChange(ring)
+		}
+	}
+}
+
+// NodeID returns the ID of this replica
+func (m *Membership) NodeID() string {
+	return m.self.ID
+}
+
+// Owns reports whether this replica collects a target
+// Everything is owned until the first heartbeat succeeds
+func (m *Membership) Owns(target string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.ring == nil {
+		return true
+	}
+	return m.ring.Owner(target) == m.self.ID
+}
@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// virtualNodes is the number of ring positions per replica, spreading targets evenly
+const virtualNodes = 128
+
+// Ring assigns keys to nodes by consistent hashing
+// Adding or removing a node only moves the keys it gains or loses
+type Ring struct {
+	nodes  []string
+	hashes []uint64
+	owners map[uint64]string
+}
+
+// NewRing builds a ring of the given node IDs
+func NewRing(nodes []string) *Ring {
+	r := &Ring{owners: make(map[uint64]string, len(nodes)*virtualNodes)}
+	seen := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if node == "" || seen[node] {
+			continue
+		}
+		seen[node] = true
+		r.nodes = append(r.nodes, node)
+		for i := 0; i < virtualNodes; i++ {
+			h := hashKey(node + "#" + strconv.Itoa(i))
+			// On the rare collision the smaller ID wins, so every replica builds the same ring
+			if owner, ok := r.owners[h]; !ok {
+				r.hashes = append(r.hashes, h)
+			} else if owner < node {
+				continue
+			}
+			r.owners[h] = node
+		}
+	}
+	sort.Strings(r.nodes)
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// Nodes returns the node IDs on the ring, sorted
+func (r *Ring) Nodes() []string {
+	return r.nodes
+}
+
+// Owner returns the node responsible for a key, or "" for an empty ring
+func (r *Ring) Owner(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := hashKey(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+// hashKey hashes with FNV-1a followed by a 64-bit finalizer, since FNV alone
+// clusters the positions of similar keys such as "node#1", "node#2"
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -3,14 +3,18 @@ package collector
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/jiin/pondy/internal/config"
 	"github.com/jiin/pondy/internal/models"
 )
 
@@ -42,8 +46,21 @@ type ActuatorCollector struct {
 	instanceName string
 	endpoint     string
 	client       *http.Client
+	retry        retryPolicy
+
+	mu         sync.Mutex
+	poolType   string               // Configured pool type, empty for auto-detection
+	pool       *PoolDriver          // Resolved pool driver
+	detectWait time.Duration        // Backoff after a failed pool detection, doubled each failure
+	detectNext time.Time            // Pool detection is skipped until the time
+	scrapeMode string               // Configured scrape mode, empty for metrics
+	promRetry  time.Time            // auto mode: /actuator/prometheus is not exposed, retried after
+	missing    map[string]time.Time // Optional metric URLs answering 404, skipped until the time
 }
 
+// optionalRetry is how long an optional metric that isn't exported is skipped before asking again
+const optionalRetry = 10 * time.Minute
+
 // ActuatorMetricResponse represents Spring Actuator metric response
 type ActuatorMetricResponse struct {
 	Name          string                `json:"name"`
@@ -78,6 +95,11 @@ func NewActuatorCollector(name, instanceName, endpoint string) *ActuatorCollecto
 	}
 }
 
+// do sends a request using the collector's retry policy
+func (c *ActuatorCollector) do(req *http.Request) (*http.Response, error) {
+	return c.retry.do(c.client, req)
+}
+
 func (c *ActuatorCollector) Name() string {
 	return c.name
 }
@@ -93,16 +115,44 @@ func (c *ActuatorCollector) Collect() (*models.PoolMetrics, error) {
 	return c.CollectWithContext(context.Background())
 }
 
+// jvmMetric is a JVM metric read along with the pool metrics
+type jvmMetric struct {
+	name    string
+	tag     string // Tag to filter by, empty for all measurements
+	tagVal  string
+	handler func(float64)
+}
+
+// jvmMetrics returns the JVM metrics stored into metrics
+func jvmMetrics(metrics *models.PoolMetrics) []jvmMetric {
+	return []jvmMetric{
+		{"jvm.memory.used", "area", "heap", func(v float64) { metrics.HeapUsed = int64(v) }},
+		{"jvm.memory.max", "area", "heap", func(v float64) { metrics.HeapMax = int64(v) }},
+		{"jvm.memory.used", "area", "nonheap", func(v float64) { metrics.NonHeapUsed = int64(v) }},
+		{"jvm.memory.max", "area", "nonheap", func(v float64) { metrics.NonHeapMax = int64(v) }},
+		{"jvm.threads.live", "", "", func(v float64) { metrics.ThreadsLive = int(v) }},
+		{"process.cpu.usage", "", "", func(v float64) { metrics.CpuUsage = v }},
+	}
+}
+
 // CollectWithContext collects metrics with context for timeout/cancellation
+// In prometheus and auto scrape mode every metric is read from /actuator/prometheus in one request
 func (c *ActuatorCollector) CollectWithContext(ctx context.Context) (*models.PoolMetrics, error) {
+	if c.usePrometheus() {
+		metrics, err := c.collectPrometheusWithContext(ctx)
+		if !errors.Is(err, errPrometheusUnavailable) {
+			return metrics, err
+		}
+	}
+
 	metrics := &models.PoolMetrics{
 		TargetName:   c.name,
 		InstanceName: c.instanceName,
 		Timestamp:    time.Now(),
 	}
 
-	// Use WaitGroup for parallel metric collection
-	var wg sync.WaitGroup
+	// Fetch in parallel, bounded by the request limit of the scheduler
+	g := newTaskGroup(requestLimit(ctx))
 	var mu sync.Mutex
 
 	// Results storage
@@ -113,84 +163,65 @@ func (c *ActuatorCollector) CollectWithContext(ctx context.Context) (*models.Poo
 	}
 	results := make(map[string]metricResult)
 
-	// Define metrics to fetch in parallel
-	hikariMetrics := []string{
-		"hikaricp.connections.active",
-		"hikaricp.connections.idle",
-		"hikaricp.connections.pending",
-		"hikaricp.connections.max",
-		"hikaricp.connections.timeout",
-		"hikaricp.connections.acquire",
-	}
+	// Resolve pool metric names before fetching
+	pool := c.poolDriver(ctx)
+	poolMetrics := []string{pool.Active, pool.Idle, pool.Pending, pool.Max, pool.Timeout}
 
 	// Fetch health check
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	g.Go(func() {
 		status := c.checkHealthWithContext(ctx)
 		mu.Lock()
+		metrics.Health = status
 		results["health"] = metricResult{name: "health", value: 0, err: nil}
 		if status == "UP" {
 			results["health"] = metricResult{name: "health", value: 1, err: nil}
 		}
 		mu.Unlock()
-	}()
-
-	// Fetch HikariCP metrics in parallel
-	for _, metricName := range hikariMetrics {
-		wg.Add(1)
-		go func(name string) {
-			defer wg.Done()
-			val, err := c.fetchMetricWithContext(ctx, name)
+	})
+
+	// Fetch pool metrics in parallel
+	for _, metricName := range poolMetrics {
+		if metricName == "" {
+			continue
+		}
+		g.Go(func() {
+			val, err := c.fetchMetricWithContext(ctx, metricName)
 			mu.Lock()
-			results[name] = metricResult{name: name, value: val, err: err}
+			results[metricName] = metricResult{name: metricName, value: val, err: err}
 			mu.Unlock()
-		}(metricName)
+		})
 	}
 
 	// Fetch JVM metrics in parallel
-	jvmMetrics := []struct {
-		name    string
-		tag     string
-		tagVal  string
-		handler func(float64)
-	}{
-		{"jvm.memory.used", "area", "heap", func(v float64) { metrics.HeapUsed = int64(v) }},
-		{"jvm.memory.max", "area", "heap", func(v float64) { metrics.HeapMax = int64(v) }},
-		{"jvm.memory.used", "area", "nonheap", func(v float64) { metrics.NonHeapUsed = int64(v) }},
-		{"jvm.memory.max", "area", "nonheap", func(v float64) { metrics.NonHeapMax = int64(v) }},
-		{"jvm.threads.live", "", "", func(v float64) { metrics.ThreadsLive = int(v) }},
-		{"process.cpu.usage", "", "", func(v float64) { metrics.CpuUsage = v }},
-	}
-
-	for _, jm := range jvmMetrics {
-		wg.Add(1)
-		go func(m struct {
-			name    string
-			tag     string
-			tagVal  string
-			handler func(float64)
-		}) {
-			defer wg.Done()
-			var val float64
-			var err error
-			if m.tag != "" {
-				val, err = c.fetchMetricWithTagAndContext(ctx, m.name, m.tag, m.tagVal)
-			} else {
-				val, err = c.fetchMetricWithContext(ctx, m.name)
+	for _, jm := range jvmMetrics(metrics) {
+		g.Go(func() {
+			url := fmt.Sprintf("%s/%s", c.endpoint, jm.name)
+			if jm.tag != "" {
+				url += fmt.Sprintf("?tag=%s:%s", jm.tag, jm.tagVal)
 			}
-			if err == nil {
+			if val, err := c.fetchOptionalValueWithContext(ctx, url); err == nil {
 				mu.Lock()
-				m.handler(val)
+				jm.handler(val)
 				mu.Unlock()
 			}
-		}(jm)
+		})
+	}
+
+	// Fetch connection acquire times in parallel
+	if pool.Acquire != "" {
+		g.Go(func() {
+			p50, p95, p99, maxMs := c.fetchAcquireTimesWithContext(ctx, pool)
+			mu.Lock()
+			metrics.AcquireP50 = p50
+			metrics.AcquireP95 = p95
+			metrics.AcquireP99 = p99
+			metrics.AcquireMax = maxMs
+			mu.Unlock()
+		})
 	}
 
 	// Fetch GC metrics in parallel
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	g.Go(func() {
 		count, gcTime, youngCount, oldCount := c.fetchGcMetricsWithContext(ctx)
 		mu.Lock()
 		metrics.GcCount = count
@@ -198,12 +229,12 @@ func (c *ActuatorCollector) CollectWithContext(ctx context.Context) (*models.Poo
 		metrics.YoungGcCount = youngCount
 		metrics.OldGcCount = oldCount
 		mu.Unlock()
-	}()
+	})
 
-	wg.Wait()
+	g.Wait()
 
-	// Process HikariCP results
-	activeRes := results["hikaricp.connections.active"]
+	// Process pool results
+	activeRes := results[pool.Active]
 	if activeRes.err != nil {
 		if strings.Contains(activeRes.err.Error(), "404") {
 			healthRes := results["health"]
@@ -218,33 +249,36 @@ func (c *ActuatorCollector) CollectWithContext(ctx context.Context) (*models.Poo
 	metrics.Active = int(activeRes.value)
 
 	// Check required metrics
-	idleRes := results["hikaricp.connections.idle"]
+	idleRes := results[pool.Idle]
 	if idleRes.err != nil {
 		metrics.Status = models.StatusError
 		return metrics, fmt.Errorf("failed to fetch idle: %w", idleRes.err)
 	}
 	metrics.Idle = int(idleRes.value)
 
-	pendingRes := results["hikaricp.connections.pending"]
-	if pendingRes.err != nil {
-		metrics.Status = models.StatusError
-		return metrics, fmt.Errorf("failed to fetch pending: %w", pendingRes.err)
-	}
-	metrics.Pending = int(pendingRes.value)
-
-	maxRes := results["hikaricp.connections.max"]
+	maxRes := results[pool.Max]
 	if maxRes.err != nil {
 		metrics.Status = models.StatusError
 		return metrics, fmt.Errorf("failed to fetch max: %w", maxRes.err)
 	}
 	metrics.Max = int(maxRes.value)
 
-	// Optional metrics (ignore errors)
-	if timeoutRes := results["hikaricp.connections.timeout"]; timeoutRes.err == nil {
-		metrics.Timeout = int64(timeoutRes.value)
+	// Pending is required for HikariCP, optional for pools that may not export it
+	if pool.Pending != "" {
+		pendingRes := results[pool.Pending]
+		if pendingRes.err == nil {
+			metrics.Pending = int(pendingRes.value)
+		} else if pool.Type == config.PoolTypeHikari {
+			metrics.Status = models.StatusError
+			return metrics, fmt.Errorf("failed to fetch pending: %w", pendingRes.err)
+		}
 	}
-	if acquireRes := results["hikaricp.connections.acquire"]; acquireRes.err == nil {
-		metrics.AcquireP99 = acquireRes.value
+
+	// Optional metrics (ignore errors)
+	if pool.Timeout != "" {
+		if timeoutRes := results[pool.Timeout]; timeoutRes.err == nil {
+			metrics.Timeout = int64(timeoutRes.value)
+		}
 	}
 
 	metrics.Status = models.StatusHealthy
@@ -256,31 +290,33 @@ func (c *ActuatorCollector) checkHealth() string {
 }
 
 func (c *ActuatorCollector) checkHealthWithContext(ctx context.Context) string {
-	// Derive health endpoint from metrics endpoint
-	// e.g., http://host:port/actuator/metrics -> http://host:port/actuator/health
-	healthURL := strings.Replace(c.endpoint, "/metrics", "/health", 1)
+	healthURL := actuatorURL(c.endpoint, "health")
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
 	if err != nil {
-		return "DOWN"
+		return models.HealthDown
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return "DOWN"
+		return models.HealthDown
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "DOWN"
+	// Spring Boot answers DOWN and OUT_OF_SERVICE with 503 and the status in the body
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return models.HealthDown
 	}
 
 	var health HealthResponse
-	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
-		return "UNKNOWN"
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil || health.Status == "" {
+		if resp.StatusCode != http.StatusOK {
+			return models.HealthDown
+		}
+		return models.HealthUnknown
 	}
 
-	return health.Status
+	return strings.ToUpper(health.Status)
 }
 
 func (c *ActuatorCollector) fetchMetric(metricName string) (float64, error) {
@@ -305,69 +341,144 @@ func (c *ActuatorCollector) fetchMetricURL(url string) (float64, error) {
 	return c.fetchMetricURLWithContext(context.Background(), url)
 }
 
-func (c *ActuatorCollector) fetchMetricURLWithContext(ctx context.Context, url string) (float64, error) {
+// fetchMeasurementsWithContext returns all measurements of a metric URL
+func (c *ActuatorCollector) fetchMeasurementsWithContext(ctx context.Context, url string) ([]ActuatorMeasurement, error) {
+	result, err := c.fetchMetricResponseWithContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return result.Measurements, nil
+}
+
+// fetchMetricResponseWithContext returns the measurements and available tags of a metric URL
+func (c *ActuatorCollector) fetchMetricResponseWithContext(ctx context.Context, url string) (*ActuatorMetricResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	var result ActuatorMetricResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// fetchOptionalWithContext fetches a metric that instances may not export, e.g., acquire percentiles
+// A metric answering 404 is skipped for optionalRetry instead of being requested every scrape
+func (c *ActuatorCollector) fetchOptionalWithContext(ctx context.Context, url string) (*ActuatorMetricResponse, error) {
+	c.mu.Lock()
+	until, missing := c.missing[url]
+	c.mu.Unlock()
+	if missing && time.Now().Before(until) {
+		return nil, fmt.Errorf("metric not exported: %s", url)
+	}
+
+	result, err := c.fetchMetricResponseWithContext(ctx, url)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case err != nil && strings.Contains(err.Error(), "404"):
+		if c.missing == nil {
+			c.missing = make(map[string]time.Time)
+		}
+		c.missing[url] = time.Now().Add(optionalRetry)
+	case missing:
+		delete(c.missing, url)
+	}
+	return result, err
+}
+
+// fetchOptionalValueWithContext returns the value of an optional metric
+func (c *ActuatorCollector) fetchOptionalValueWithContext(ctx context.Context, url string) (float64, error) {
+	result, err := c.fetchOptionalWithContext(ctx, url)
+	if err != nil {
 		return 0, err
 	}
+	return measurementValue(result.Measurements)
+}
+
+func (c *ActuatorCollector) fetchMetricURLWithContext(ctx context.Context, url string) (float64, error) {
+	measurements, err := c.fetchMeasurementsWithContext(ctx, url)
+	if err != nil {
+		return 0, err
+	}
+	return measurementValue(measurements)
+}
 
+// measurementValue returns the VALUE or COUNT measurement, or the first one without either
+func measurementValue(measurements []ActuatorMeasurement) (float64, error) {
 	// Find VALUE measurement
-	for _, m := range result.Measurements {
+	for _, m := range measurements {
 		if m.Statistic == "VALUE" || m.Statistic == "COUNT" {
 			return m.Value, nil
 		}
 	}
 
 	// If no VALUE found, return first measurement
-	if len(result.Measurements) > 0 {
-		return result.Measurements[0].Value, nil
+	if len(measurements) > 0 {
+		return measurements[0].Value, nil
 	}
 
 	return 0, fmt.Errorf("no measurements found")
 }
 
+// fetchAcquireTimesWithContext returns the connection acquire time percentiles and maximum
+// in milliseconds. Percentiles are only published when the application enables them
+// (management.metrics.distribution.percentiles.hikaricp.connections.acquire); missing
+// values are left at 0
+func (c *ActuatorCollector) fetchAcquireTimesWithContext(ctx context.Context, pool *PoolDriver) (p50, p95, p99, maxMs float64) {
+	url := fmt.Sprintf("%s/%s", c.endpoint, pool.Acquire)
+	if result, err := c.fetchOptionalWithContext(ctx, url); err == nil {
+		for _, m := range result.Measurements {
+			if m.Statistic == "MAX" {
+				maxMs = m.Value * 1000
+			}
+		}
+	}
+	if pool.AcquirePercentile == "" {
+		return p50, p95, p99, maxMs
+	}
+
+	percentiles := []struct {
+		phi   string
+		value *float64
+	}{
+		{"0.5", &p50},
+		{"0.95", &p95},
+		{"0.99", &p99},
+	}
+	for _, p := range percentiles {
+		url := fmt.Sprintf("%s/%s?tag=phi:%s", c.endpoint, pool.AcquirePercentile, p.phi)
+		if v, err := c.fetchOptionalValueWithContext(ctx, url); err == nil {
+			*p.value = v * 1000
+		}
+	}
+	return p50, p95, p99, maxMs
+}
+
 func (c *ActuatorCollector) fetchGcMetrics() (gcCount int64, gcTime float64, youngGcCount int64, oldGcCount int64) {
 	return c.fetchGcMetricsWithContext(context.Background())
 }
 
 func (c *ActuatorCollector) fetchGcMetricsWithContext(ctx context.Context) (gcCount int64, gcTime float64, youngGcCount int64, oldGcCount int64) {
 	// Fetch jvm.gc.pause which contains COUNT and TOTAL_TIME statistics
-	url := fmt.Sprintf("%s/jvm.gc.pause", c.endpoint)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	result, err := c.fetchOptionalWithContext(ctx, fmt.Sprintf("%s/jvm.gc.pause", c.endpoint))
 	if err != nil {
 		return 0, 0, 0, 0
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return 0, 0, 0, 0
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, 0, 0, 0
-	}
-
-	var result ActuatorMetricResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, 0, 0, 0
-	}
-
 	// Extract COUNT and TOTAL_TIME from measurements
 	for _, m := range result.Measurements {
 		switch m.Statistic {
@@ -378,45 +489,41 @@ func (c *ActuatorCollector) fetchGcMetricsWithContext(ctx context.Context) (gcCo
 		}
 	}
 
-	// Try to get young/minor GC count
-	youngUrl := fmt.Sprintf("%s/jvm.gc.pause?tag=action:end of minor GC", c.endpoint)
-	youngReq, err := http.NewRequestWithContext(ctx, http.MethodGet, youngUrl, nil)
-	if err == nil {
-		if youngResp, err := c.client.Do(youngReq); err == nil {
-			defer youngResp.Body.Close()
-			if youngResp.StatusCode == http.StatusOK {
-				var youngResult ActuatorMetricResponse
-				if json.NewDecoder(youngResp.Body).Decode(&youngResult) == nil {
-					for _, m := range youngResult.Measurements {
-						if m.Statistic == "COUNT" {
-							youngGcCount = int64(m.Value)
-							break
-						}
-					}
-				}
-			}
+	// Young/minor and old/major GC counts are tagged by action; the available tags of the
+	// response tell which actions the JVM reports, so collectors without one skip its request
+	actions := tagValues(result.AvailableTags, "action")
+	youngGcCount = c.fetchGcActionCountWithContext(ctx, "end of minor GC", actions)
+	oldGcCount = c.fetchGcActionCountWithContext(ctx, "end of major GC", actions)
+
+	return gcCount, gcTime, youngGcCount, oldGcCount
+}
+
+// fetchGcActionCountWithContext returns the pause count of a GC action
+// actions lists the reported actions, nil when unknown
+func (c *ActuatorCollector) fetchGcActionCountWithContext(ctx context.Context, action string, actions []string) int64 {
+	if actions != nil && !slices.Contains(actions, action) {
+		return 0
+	}
+	// Actions contain spaces, e.g., "end of minor GC"
+	tag := url.QueryEscape("action:" + action)
+	result, err := c.fetchOptionalWithContext(ctx, fmt.Sprintf("%s/jvm.gc.pause?tag=%s", c.endpoint, tag))
+	if err != nil {
+		return 0
+	}
+	for _, m := range result.Measurements {
+		if m.Statistic == "COUNT" {
+			return int64(m.Value)
 		}
 	}
+	return 0
+}
 
-	// Try to get old/major GC count
-	oldUrl := fmt.Sprintf("%s/jvm.gc.pause?tag=action:end of major GC", c.endpoint)
-	oldReq, err := http.NewRequestWithContext(ctx, http.MethodGet, oldUrl, nil)
-	if err == nil {
-		if oldResp, err := c.client.Do(oldReq); err == nil {
-			defer oldResp.Body.Close()
-			if oldResp.StatusCode == http.StatusOK {
-				var oldResult ActuatorMetricResponse
-				if json.NewDecoder(oldResp.Body).Decode(&oldResult) == nil {
-					for _, m := range oldResult.Measurements {
-						if m.Statistic == "COUNT" {
-							oldGcCount = int64(m.Value)
-							break
-						}
-					}
-				}
-			}
+// tagValues returns the values of an available tag, nil when the tag isn't listed
+func tagValues(tags []ActuatorTag, tag string) []string {
+	for _, t := range tags {
+		if t.Tag == tag {
+			return t.Values
 		}
 	}
-
-	return gcCount, gcTime, youngGcCount, oldGcCount
-}
\ No newline at end of file
+	return nil
+}
@@ -0,0 +1,31 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// breakerInterval returns the delay until the next scrape and whether the breaker is open
+// Once consecutive failures reach the threshold, the interval doubles with every
+// further failure up to the configured maximum
+func breakerInterval(cfg config.CircuitBreakerConfig, interval time.Duration, failures int) (time.Duration, bool) {
+	threshold := cfg.GetFailureThreshold()
+	if !cfg.IsEnabled() || failures < threshold {
+		return interval, false
+	}
+
+	max := cfg.GetMaxInterval()
+	if max < interval {
+		max = interval
+	}
+
+	delay := interval
+	for i := threshold; i <= failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay, true
+}
@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestBreakerInterval(t *testing.T) {
+	disabled := false
+	interval := 10 * time.Second
+
+	tests := []struct {
+		name     string
+		cfg      config.CircuitBreakerConfig
+		failures int
+		want     time.Duration
+		wantOpen bool
+	}{
+		{"healthy", config.CircuitBreakerConfig{}, 0, interval, false},
+		{"below threshold", config.CircuitBreakerConfig{}, 4, interval, false},
+		{"opens at threshold", config.CircuitBreakerConfig{}, 5, 20 * time.Second, true},
+		{"doubles per failure", config.CircuitBreakerConfig{}, 7, 80 * time.Second, true},
+		{"capped at max", config.CircuitBreakerConfig{}, 50, 5 * time.Minute, true},
+		{"custom threshold", config.CircuitBreakerConfig{FailureThreshold: 2, MaxInterval: time.Minute}, 4, time.Minute, true},
+		{"disabled", config.CircuitBreakerConfig{Enabled: &disabled}, 50, interval, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, open := breakerInterval(tt.cfg, interval, tt.failures)
+			if got != tt.want || open != tt.wantOpen {
+				t.Errorf("breakerInterval(%d failures) = %v, %v; want %v, %v", tt.failures, got, open, tt.want, tt.wantOpen)
+			}
+		})
+	}
+}
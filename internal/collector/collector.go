@@ -1,6 +1,10 @@
 package collector
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/jiin/pondy/internal/config"
 	"github.com/jiin/pondy/internal/models"
 )
 
@@ -9,6 +13,38 @@ type Collector interface {
 	// Collect fetches current metrics from the target
 	Collect() (*models.PoolMetrics, error)
 
+	// CollectWithContext fetches current metrics with timeout/cancellation support
+	CollectWithContext(ctx context.Context) (*models.PoolMetrics, error)
+
 	// Name returns the target name
 	Name() string
+
+	// InstanceName returns the instance ID
+	InstanceName() string
+}
+
+// NewCollector creates a collector for a target instance based on the target type
+func NewCollector(target config.TargetConfig, inst config.InstanceConfig) (Collector, error) {
+	switch target.Type {
+	case "", config.TargetTypeActuator:
+		if err := config.ValidatePoolType(target.PoolType); err != nil {
+			return nil, err
+		}
+		if err := config.ValidateScrapeMode(target.ScrapeMode); err != nil {
+			return nil, err
+		}
+		c := NewActuatorCollector(target.Name, inst.ID, inst.Endpoint)
+		c.poolType = target.PoolType
+		c.scrapeMode = target.ScrapeMode
+		c.client.Timeout = target.GetTimeout()
+		c.retry = retryPolicy{retries: target.Retries, backoff: target.GetRetryBackoff()}
+		return c, nil
+	case config.TargetTypeJolokia:
+		c := NewJolokiaCollector(target.Name, inst.ID, inst.Endpoint)
+		c.client.Timeout = target.GetTimeout()
+		c.retry = retryPolicy{retries: target.Retries, backoff: target.GetRetryBackoff()}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unsupported target type '%s'", target.Type)
+	}
 }
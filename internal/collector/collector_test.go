@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestNewCollector(t *testing.T) {
+	inst := config.InstanceConfig{ID: "default", Endpoint: "http://localhost"}
+
+	c, err := NewCollector(config.TargetConfig{Name: "t"}, inst)
+	if _, ok := c.(*ActuatorCollector); !ok || err != nil {
+		t.Errorf("empty type should create an actuator collector, got %T (err: %v)", c, err)
+	}
+	c, err = NewCollector(config.TargetConfig{Name: "t", Type: config.TargetTypeJolokia}, inst)
+	if _, ok := c.(*JolokiaCollector); !ok || err != nil {
+		t.Errorf("jolokia type should create a jolokia collector, got %T (err: %v)", c, err)
+	}
+	if _, err := NewCollector(config.TargetConfig{Name: "t", Type: "jmx"}, inst); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+	if _, err := NewCollector(config.TargetConfig{Name: "t", PoolType: "c3p0"}, inst); err == nil {
+		t.Error("expected error for unsupported pool type")
+	}
+	if _, err := NewCollector(config.TargetConfig{Name: "t", ScrapeMode: "otlp"}, inst); err == nil {
+		t.Error("expected error for unsupported scrape mode")
+	}
+}
+
+func TestManager_SetDiscoveredTargets(t *testing.T) {
+	m := NewManager(nil)
+	defer m.Stop()
+
+	static := config.TargetConfig{Name: "static", Interval: time.Hour, Endpoint: "http://127.0.0.1:1/actuator/metrics"}
+	discovered := config.TargetConfig{
+		Name:     "orders",
+		Interval: time.Hour,
+		Instances: []config.InstanceConfig{
+			{ID: "orders-abc", Endpoint: "http://127.0.0.1:1/actuator/metrics"},
+			{ID: "orders-def", Endpoint: "http://127.0.0.1:1/actuator/metrics"},
+		},
+	}
+
+	registry := config.TargetConfig{
+		Name:      "payments",
+		Interval:  time.Hour,
+		Endpoint:  "http://127.0.0.1:1/actuator/metrics",
+		Discovery: &config.TargetDiscoveryConfig{Type: config.RegistryEureka, Service: "payments"},
+	}
+
+	// Registry-backed config targets wait for resolved instances
+	m.UpdateFromConfig(&config.Config{Targets: []config.TargetConfig{static, registry}})
+	if m.Count() != 1 {
+		t.Errorf("Count() = %d, want 1", m.Count())
+	}
+
+	m.SetDiscoveredTargets("kubernetes", []config.TargetConfig{discovered})
+	if m.Count() != 3 {
+		t.Errorf("Count() = %d, want 3", m.Count())
+	}
+
+	// Config reloads keep discovered targets
+	m.UpdateFromConfig(&config.Config{Targets: []config.TargetConfig{static, registry}})
+	if m.Count() != 3 {
+		t.Errorf("Count() after reload = %d, want 3", m.Count())
+	}
+
+	// Pods going away remove their collectors
+	discovered.Instances = discovered.Instances[:1]
+	m.SetDiscoveredTargets("kubernetes", []config.TargetConfig{discovered})
+	if m.Count() != 2 {
+		t.Errorf("Count() after pod removal = %d, want 2", m.Count())
+	}
+	if got := m.DiscoveredTargets(); len(got) != 1 || len(got[0].Instances) != 1 {
+		t.Errorf("DiscoveredTargets() = %+v", got)
+	}
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	m := NewManager(nil)
+	m.UpdateFromConfig(&config.Config{Targets: []config.TargetConfig{
+		{Name: "orders", Interval: time.Hour, Endpoint: "http://127.0.0.1:1/actuator/metrics"},
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if m.Count() != 0 {
+		t.Errorf("Count() = %d after Shutdown, want 0", m.Count())
+	}
+}
+
+func TestManager_PausedTargets(t *testing.T) {
+	m := NewManager(nil)
+	defer m.Stop()
+
+	endpoint := "http://127.0.0.1:1/actuator/metrics"
+	m.UpdateFromConfig(&config.Config{Targets: []config.TargetConfig{
+		{Name: "orders", Interval: time.Hour, Endpoint: endpoint, Paused: true},
+		{Name: "payments", Interval: time.Hour, Endpoint: endpoint},
+		{Name: "billing", Interval: time.Hour, Paused: true, Discovery: &config.TargetDiscoveryConfig{Type: config.RegistryEureka, Service: "billing"}},
+	}})
+	if m.Count() != 1 {
+		t.Errorf("Count() = %d, want 1 without the paused target", m.Count())
+	}
+
+	// Instances discovered before the pause are not collected either
+	m.SetDiscoveredTargets("registry", []config.TargetConfig{
+		{Name: "billing", Interval: time.Hour, Instances: []config.InstanceConfig{{ID: "billing-1", Endpoint: endpoint}}},
+	})
+	if m.Count() != 1 {
+		t.Errorf("Count() = %d, want 1 without the paused registry target", m.Count())
+	}
+}
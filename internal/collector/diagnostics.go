@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// Diagnostic dumps that actuator instances can capture on demand
+const (
+	DumpThread = "threaddump"
+	DumpHeap   = "heapdump"
+)
+
+// Dump timeouts; a heap dump pauses the JVM and can be several GB
+const (
+	threadDumpTimeout = 30 * time.Second
+	heapDumpTimeout   = 10 * time.Minute
+)
+
+var (
+	// ErrInstanceNotFound is returned when no collector runs for the target instance
+	ErrInstanceNotFound = errors.New("instance not found")
+	// ErrNotActuator is returned for management operations on targets not scraped from an actuator
+	ErrNotActuator = errors.New("operation requires an actuator target")
+)
+
+// managementClient calls actuator management endpoints
+// It has no overall timeout, requests are bounded by their context instead
+var managementClient = &http.Client{Transport: getSharedTransport()}
+
+// actuatorURL derives another actuator endpoint from the metrics endpoint
+// e.g., http://host:port/actuator/metrics -> http://host:port/actuator/health
+func actuatorURL(metricsEndpoint, name string) string {
+	return strings.Replace(metricsEndpoint, "/metrics", "/"+name, 1)
+}
+
+// Dump holds a dump being read from an instance; the caller must close Body
+type Dump struct {
+	ContentType string
+	Size        int64 // -1 when the instance doesn't send a length
+	Body        io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the request
+func (d *Dump) Close() error {
+	err := d.Body.Close()
+	d.cancel()
+	return err
+}
+
+// Dump requests a thread or heap dump from an actuator instance
+// accept is passed on to the instance, e.g., text/plain for a plain-text thread dump
+func (m *Manager) Dump(ctx context.Context, target, instance, kind, accept string) (*Dump, error) {
+	timeout := threadDumpTimeout
+	switch kind {
+	case DumpThread:
+	case DumpHeap:
+		timeout = heapDumpTimeout
+	default:
+		return nil, fmt.Errorf("unknown dump %q", kind)
+	}
+
+	m.mu.RLock()
+	info, ok := m.collectors[target+"/"+instance]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s", ErrInstanceNotFound, target, instance)
+	}
+	if info.Type != "" && info.Type != config.TargetTypeActuator {
+		return nil, ErrNotActuator
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actuatorURL(info.Endpoint, kind), nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := managementClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%s returned HTTP %d", kind, resp.StatusCode)
+	}
+
+	return &Dump{
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+		Body:        resp.Body,
+		cancel:      cancel,
+	}, nil
+}
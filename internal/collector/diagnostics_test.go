@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestManager_Dump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/actuator/threaddump":
+			w.Header().Set("Content-Type", r.Header.Get("Accept"))
+			io.WriteString(w, `"main" #1 prio=5`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	m := NewManager(nil)
+	m.collectors["orders/pod-1"] = &CollectorInfo{Endpoint: server.URL + "/actuator/metrics", Type: config.TargetTypeActuator}
+	m.collectors["legacy/default"] = &CollectorInfo{Endpoint: server.URL + "/jolokia", Type: config.TargetTypeJolokia}
+
+	dump, err := m.Dump(context.Background(), "orders", "pod-1", DumpThread, "text/plain")
+	if err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	body, _ := io.ReadAll(dump.Body)
+	dump.Close()
+	if string(body) != `"main" #1 prio=5` || dump.ContentType != "text/plain" {
+		t.Errorf("Dump() = %q (%s)", body, dump.ContentType)
+	}
+
+	// Heap dump endpoint not exposed by the instance
+	if _, err := m.Dump(context.Background(), "orders", "pod-1", DumpHeap, ""); err == nil {
+		t.Error("expected error when the instance answers 404")
+	}
+	if _, err := m.Dump(context.Background(), "orders", "pod-2", DumpThread, ""); !errors.Is(err, ErrInstanceNotFound) {
+		t.Errorf("unknown instance error = %v, want ErrInstanceNotFound", err)
+	}
+	if _, err := m.Dump(context.Background(), "legacy", "default", DumpThread, ""); !errors.Is(err, ErrNotActuator) {
+		t.Errorf("jolokia target error = %v, want ErrNotActuator", err)
+	}
+}
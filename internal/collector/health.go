@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// collectorHealth tracks scrape results of a single collector
+type collectorHealth struct {
+	mu                  sync.Mutex
+	lastScrape          time.Time
+	lastSuccess         time.Time
+	lastDuration        time.Duration
+	consecutiveFailures int
+	totalScrapes        int64
+	totalFailures       int64
+	lastError           string
+	breakerOpen         bool
+	nextScrape          time.Time
+}
+
+// record stores a scrape result and returns the consecutive failure count
+func (h *collectorHealth) record(start time.Time, duration time.Duration, err error) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastScrape = start
+	h.lastDuration = duration
+	h.totalScrapes++
+
+	if err != nil {
+		h.consecutiveFailures++
+		h.totalFailures++
+		h.lastError = err.Error()
+	} else {
+		h.consecutiveFailures = 0
+		h.lastSuccess = start
+	}
+	return h.consecutiveFailures
+}
+
+// setBreaker stores the circuit breaker state and reports whether it changed
+func (h *collectorHealth) setBreaker(open bool, nextScrape time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	changed := h.breakerOpen != open
+	h.breakerOpen = open
+	h.nextScrape = nextScrape
+	return changed
+}
+
+// snapshot fills the scrape fields of a health report
+func (h *collectorHealth) snapshot(out *models.CollectorHealth) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out.State = models.CollectorPending
+	if !h.lastScrape.IsZero() {
+		t := h.lastScrape
+		out.LastScrape = &t
+		out.State = models.CollectorUp
+		if h.consecutiveFailures > 0 {
+			out.State = models.CollectorFailing
+		}
+		if h.breakerOpen {
+			out.State = models.CollectorDown
+		}
+	}
+	if !h.nextScrape.IsZero() {
+		t := h.nextScrape
+		out.NextScrape = &t
+	}
+	out.BreakerOpen = h.breakerOpen
+	if !h.lastSuccess.IsZero() {
+		t := h.lastSuccess
+		out.LastSuccess = &t
+	}
+	out.LastDurationMs = float64(h.lastDuration.Microseconds()) / 1000
+	out.ConsecutiveFailures = h.consecutiveFailures
+	out.TotalScrapes = h.totalScrapes
+	out.TotalFailures = h.totalFailures
+	out.LastError = h.lastError
+}
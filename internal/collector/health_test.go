@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// failingCollector always fails to scrape
+type failingCollector struct{}
+
+func (failingCollector) Collect() (*models.PoolMetrics, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingCollector) CollectWithContext(ctx context.Context) (*models.PoolMetrics, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingCollector) Name() string         { return "orders" }
+func (failingCollector) InstanceName() string { return "default" }
+
+func TestManager_CollectRecordsFailures(t *testing.T) {
+	m := NewManager(nil)
+
+	var got []*models.PoolMetrics
+	m.SetAlertCallback(func(pm *models.PoolMetrics) { got = append(got, pm) })
+
+	health := &collectorHealth{}
+	m.collect(failingCollector{}, health)
+	m.collect(failingCollector{}, health)
+
+	if len(got) != 2 {
+		t.Fatalf("callback called %d times, want 2", len(got))
+	}
+	if got[1].Status != models.StatusError || got[1].ScrapeFailures != 2 {
+		t.Errorf("callback metrics = %+v, want error status with 2 scrape failures", got[1])
+	}
+
+	var h models.CollectorHealth
+	health.snapshot(&h)
+	if h.State != models.CollectorFailing || h.ConsecutiveFailures != 2 || h.TotalScrapes != 2 {
+		t.Errorf("health = %+v, want failing with 2 consecutive failures", h)
+	}
+	if h.LastError != "connection refused" || h.LastSuccess != nil {
+		t.Errorf("health = %+v, want last error and no success", h)
+	}
+}
+
+func TestCollectorHealth_Recovers(t *testing.T) {
+	health := &collectorHealth{}
+
+	var h models.CollectorHealth
+	health.snapshot(&h)
+	if h.State != models.CollectorPending {
+		t.Errorf("State = %s, want pending before the first scrape", h.State)
+	}
+
+	health.record(time.Now(), time.Millisecond, errors.New("timeout"))
+	if n := health.record(time.Now(), 2*time.Millisecond, nil); n != 0 {
+		t.Errorf("consecutive failures after success = %d, want 0", n)
+	}
+
+	health.snapshot(&h)
+	if h.State != models.CollectorUp || h.LastSuccess == nil || h.TotalFailures != 1 || h.LastDurationMs != 2 {
+		t.Errorf("health = %+v, want up with 1 total failure", h)
+	}
+}
@@ -0,0 +1,250 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// JMX MBeans read through Jolokia
+const (
+	jolokiaHikariPool   = "com.zaxxer.hikari:type=Pool (*)"
+	jolokiaHikariConfig = "com.zaxxer.hikari:type=PoolConfig (*)"
+	jolokiaMemory       = "java.lang:type=Memory"
+	jolokiaThreading    = "java.lang:type=Threading"
+	jolokiaOS           = "java.lang:type=OperatingSystem"
+	jolokiaGC           = "java.lang:type=GarbageCollector,name=*"
+)
+
+// Garbage collector MBean names by generation
+var (
+	youngGCNames = []string{"Young", "Scavenge", "ParNew", "Copy", "Minor"}
+	oldGCNames   = []string{"Old", "MarkSweep", "Major"}
+)
+
+// JolokiaCollector collects HikariCP and JVM metrics over Jolokia's JMX HTTP API
+// It is used for applications that don't expose Spring Boot Actuator
+type JolokiaCollector struct {
+	name         string
+	instanceName string
+	endpoint     string // Jolokia agent URL, e.g., http://host:8778/jolokia
+	client       *http.Client
+	retry        retryPolicy
+}
+
+// JolokiaRequest is a single read operation in a Jolokia bulk request
+type JolokiaRequest struct {
+	Type      string   `json:"type"`
+	MBean     string   `json:"mbean"`
+	Attribute []string `json:"attribute,omitempty"`
+}
+
+// JolokiaResponse is a single result in a Jolokia bulk response
+type JolokiaResponse struct {
+	Status int             `json:"status"`
+	Value  json.RawMessage `json:"value"`
+	Error  string          `json:"error"`
+}
+
+// jolokiaMemoryUsage is the java.lang.management.MemoryUsage composite
+type jolokiaMemoryUsage struct {
+	Used int64 `json:"used"`
+	Max  int64 `json:"max"`
+}
+
+func NewJolokiaCollector(name, instanceName, endpoint string) *JolokiaCollector {
+	return &JolokiaCollector{
+		name:         name,
+		instanceName: instanceName,
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: getSharedTransport(),
+		},
+	}
+}
+
+// do sends a request using the collector's retry policy
+func (c *JolokiaCollector) do(req *http.Request) (*http.Response, error) {
+	return c.retry.do(c.client, req)
+}
+
+func (c *JolokiaCollector) Name() string {
+	return c.name
+}
+
+func (c *JolokiaCollector) InstanceName() string {
+	return c.instanceName
+}
+
+// Collect collects metrics without context (legacy, uses background context)
+// Deprecated: Use CollectWithContext instead for proper timeout/cancellation support
+func (c *JolokiaCollector) Collect() (*models.PoolMetrics, error) {
+	log.Printf("Warning: Collect() called without context, use CollectWithContext() for timeout support")
+	return c.CollectWithContext(context.Background())
+}
+
+// CollectWithContext reads all MBeans in one bulk request and maps them into PoolMetrics
+// Pool values are summed when the application runs several HikariCP pools
+func (c *JolokiaCollector) CollectWithContext(ctx context.Context) (*models.PoolMetrics, error) {
+	metrics := &models.PoolMetrics{
+		TargetName:   c.name,
+		InstanceName: c.instanceName,
+		Timestamp:    time.Now(),
+	}
+
+	requests := []JolokiaRequest{
+		{Type: "read", MBean: jolokiaHikariPool, Attribute: []string{"ActiveConnections", "IdleConnections", "ThreadsAwaitingConnection", "TotalConnections"}},
+		{Type: "read", MBean: jolokiaHikariConfig, Attribute: []string{"MaximumPoolSize"}},
+		{Type: "read", MBean: jolokiaMemory, Attribute: []string{"HeapMemoryUsage", "NonHeapMemoryUsage"}},
+		{Type: "read", MBean: jolokiaThreading, Attribute: []string{"ThreadCount"}},
+		{Type: "read", MBean: jolokiaOS, Attribute: []string{"ProcessCpuLoad"}},
+		{Type: "read", MBean: jolokiaGC, Attribute: []string{"CollectionCount", "CollectionTime"}},
+	}
+
+	responses, err := c.bulkRead(ctx, requests)
+	if err != nil {
+		metrics.Status = models.StatusError
+		return metrics, err
+	}
+
+	// JVM metrics are optional (ignore errors)
+	c.applyJVMMetrics(metrics, responses)
+
+	// Process HikariCP results
+	poolRes := responses[0]
+	if poolRes.Status != http.StatusOK {
+		if poolRes.Status == http.StatusNotFound && responses[2].Status == http.StatusOK {
+			// JVM is reachable but has no HikariCP pool registered
+			metrics.Status = models.StatusNoPool
+			return metrics, nil
+		}
+		metrics.Status = models.StatusError
+		return metrics, fmt.Errorf("failed to read %s: %s", jolokiaHikariPool, poolRes.Error)
+	}
+
+	var pools map[string]map[string]float64
+	if err := json.Unmarshal(poolRes.Value, &pools); err != nil {
+		metrics.Status = models.StatusError
+		return metrics, fmt.Errorf("failed to decode pool metrics: %w", err)
+	}
+	if len(pools) == 0 {
+		metrics.Status = models.StatusNoPool
+		return metrics, nil
+	}
+	total := 0
+	for _, attrs := range pools {
+		metrics.Active += int(attrs["ActiveConnections"])
+		metrics.Idle += int(attrs["IdleConnections"])
+		metrics.Pending += int(attrs["ThreadsAwaitingConnection"])
+		total += int(attrs["TotalConnections"])
+	}
+
+	// Max pool size lives on the PoolConfig MBean; fall back to current total
+	metrics.Max = total
+	if cfgRes := responses[1]; cfgRes.Status == http.StatusOK {
+		var configs map[string]map[string]float64
+		if err := json.Unmarshal(cfgRes.Value, &configs); err == nil && len(configs) > 0 {
+			metrics.Max = 0
+			for _, attrs := range configs {
+				metrics.Max += int(attrs["MaximumPoolSize"])
+			}
+		}
+	}
+
+	metrics.Status = models.StatusHealthy
+	return metrics, nil
+}
+
+// applyJVMMetrics maps memory, thread, CPU and GC MBeans into metrics
+func (c *JolokiaCollector) applyJVMMetrics(metrics *models.PoolMetrics, responses []JolokiaResponse) {
+	if res := responses[2]; res.Status == http.StatusOK {
+		var memory map[string]jolokiaMemoryUsage
+		if json.Unmarshal(res.Value, &memory) == nil {
+			metrics.HeapUsed = memory["HeapMemoryUsage"].Used
+			metrics.HeapMax = memory["HeapMemoryUsage"].Max
+			metrics.NonHeapUsed = memory["NonHeapMemoryUsage"].Used
+			metrics.NonHeapMax = memory["NonHeapMemoryUsage"].Max
+		}
+	}
+
+	if res := responses[3]; res.Status == http.StatusOK {
+		var threading map[string]float64
+		if json.Unmarshal(res.Value, &threading) == nil {
+			metrics.ThreadsLive = int(threading["ThreadCount"])
+		}
+	}
+
+	if res := responses[4]; res.Status == http.StatusOK {
+		var osAttrs map[string]float64
+		if json.Unmarshal(res.Value, &osAttrs) == nil && osAttrs["ProcessCpuLoad"] >= 0 {
+			metrics.CpuUsage = osAttrs["ProcessCpuLoad"]
+		}
+	}
+
+	if res := responses[5]; res.Status == http.StatusOK {
+		var collectors map[string]map[string]float64
+		if json.Unmarshal(res.Value, &collectors) == nil {
+			for mbean, attrs := range collectors {
+				count := int64(attrs["CollectionCount"])
+				metrics.GcCount += count
+				metrics.GcTime += attrs["CollectionTime"] / 1000 // ms -> seconds, as reported by Actuator
+				switch {
+				case containsAny(mbean, youngGCNames):
+					metrics.YoungGcCount += count
+				case containsAny(mbean, oldGCNames):
+					metrics.OldGcCount += count
+				}
+			}
+		}
+	}
+}
+
+// bulkRead posts read requests to the Jolokia agent and returns responses in request order
+func (c *JolokiaCollector) bulkRead(ctx context.Context, requests []JolokiaRequest) ([]JolokiaResponse, error) {
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var responses []JolokiaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return nil, err
+	}
+	if len(responses) != len(requests) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(requests), len(responses))
+	}
+	return responses, nil
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestJolokiaCollector_CollectWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []JolokiaRequest
+		if err := json.NewDecoder(r.Body).Decode(&requests); err != nil || len(requests) != 6 {
+			t.Errorf("unexpected bulk request: %v (%d requests)", err, len(requests))
+		}
+		w.Write([]byte(`[
+			{"status":200,"value":{"com.zaxxer.hikari:type=Pool (HikariPool-1)":{"ActiveConnections":7,"IdleConnections":3,"ThreadsAwaitingConnection":2,"TotalConnections":10}}},
+			{"status":200,"value":{"com.zaxxer.hikari:type=PoolConfig (HikariPool-1)":{"MaximumPoolSize":20}}},
+			{"status":200,"value":{"HeapMemoryUsage":{"used":512,"max":1024},"NonHeapMemoryUsage":{"used":64,"max":-1}}},
+			{"status":200,"value":{"ThreadCount":42}},
+			{"status":200,"value":{"ProcessCpuLoad":0.25}},
+			{"status":200,"value":{
+				"java.lang:name=G1 Young Generation,type=GarbageCollector":{"CollectionCount":10,"CollectionTime":1500},
+				"java.lang:name=G1 Old Generation,type=GarbageCollector":{"CollectionCount":2,"CollectionTime":500}}}
+		]`))
+	}))
+	defer srv.Close()
+
+	c := NewJolokiaCollector("legacy", "default", srv.URL+"/jolokia/")
+	metrics, err := c.CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+
+	if metrics.Status != models.StatusHealthy {
+		t.Errorf("Status = %s, want healthy", metrics.Status)
+	}
+	if metrics.Active != 7 || metrics.Idle != 3 || metrics.Pending != 2 || metrics.Max != 20 {
+		t.Errorf("pool = active %d idle %d pending %d max %d, want 7/3/2/20",
+			metrics.Active, metrics.Idle, metrics.Pending, metrics.Max)
+	}
+	if metrics.HeapUsed != 512 || metrics.HeapMax != 1024 || metrics.NonHeapMax != -1 {
+		t.Errorf("memory = heap %d/%d nonheap max %d", metrics.HeapUsed, metrics.HeapMax, metrics.NonHeapMax)
+	}
+	if metrics.ThreadsLive != 42 || metrics.CpuUsage != 0.25 {
+		t.Errorf("threads = %d cpu = %f, want 42 and 0.25", metrics.ThreadsLive, metrics.CpuUsage)
+	}
+	if metrics.GcCount != 12 || metrics.YoungGcCount != 10 || metrics.OldGcCount != 2 || metrics.GcTime != 2 {
+		t.Errorf("gc = count %d young %d old %d time %f", metrics.GcCount, metrics.YoungGcCount, metrics.OldGcCount, metrics.GcTime)
+	}
+}
+
+func TestJolokiaCollector_NoPool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"status":404,"error":"javax.management.InstanceNotFoundException"},
+			{"status":404,"error":"javax.management.InstanceNotFoundException"},
+			{"status":200,"value":{"HeapMemoryUsage":{"used":512,"max":1024}}},
+			{"status":200,"value":{"ThreadCount":42}},
+			{"status":200,"value":{"ProcessCpuLoad":-1}},
+			{"status":200,"value":{}}
+		]`))
+	}))
+	defer srv.Close()
+
+	metrics, err := NewJolokiaCollector("legacy", "default", srv.URL).CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+	if metrics.Status != models.StatusNoPool {
+		t.Errorf("Status = %s, want no_pool", metrics.Status)
+	}
+	if metrics.HeapUsed != 512 || metrics.CpuUsage != 0 {
+		t.Errorf("expected JVM metrics without a pool, got heap %d cpu %f", metrics.HeapUsed, metrics.CpuUsage)
+	}
+}
@@ -2,21 +2,36 @@ package collector
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/derived"
 	"github.com/jiin/pondy/internal/models"
 	"github.com/jiin/pondy/internal/storage"
 )
 
 // CollectorInfo holds collector and its cancel function
 type CollectorInfo struct {
-	Collector *ActuatorCollector
+	Collector Collector
 	Cancel    context.CancelFunc
 	Interval  time.Duration
 	Endpoint  string
+	Type      string
+	PoolType  string
+	Scrape    string // Scrape mode of actuator targets
+
+	Timeout      time.Duration
+	Retries      int
+	RetryBackoff time.Duration
+
+	health *collectorHealth
+	phase  atomic.Int64 // Offset of the scrapes within the interval, see assignPhases
 }
 
 // Manager manages multiple collectors with hot reload support
@@ -25,6 +40,17 @@ type Manager struct {
 	collectors    map[string]*CollectorInfo // key: "targetName/instanceID"
 	store         storage.Storage
 	alertCallback func(*models.PoolMetrics)
+	stateCallback func(StateChange)
+	derived       *derived.Evaluator
+
+	static     []config.TargetConfig            // targets from config.yaml
+	discovered map[string][]config.TargetConfig // targets from service discovery, by source
+	breaker    config.CircuitBreakerConfig
+	owns       func(target string) bool // Sharding filter; nil collects every target
+	sched      *scheduler
+	spread     bool // Spread collectors evenly over their interval instead of a random jitter
+
+	running sync.WaitGroup // Collector goroutines, including stopped ones finishing a scrape
 }
 
 // NewManager creates a new collector manager
@@ -32,6 +58,10 @@ func NewManager(store storage.Storage) *Manager {
 	return &Manager{
 		collectors: make(map[string]*CollectorInfo),
 		store:      store,
+		derived:    derived.NewEvaluator(),
+		discovered: make(map[string][]config.TargetConfig),
+		sched:      newScheduler(),
+		spread:     true,
 	}
 }
 
@@ -40,9 +70,91 @@ func (m *Manager) UpdateFromConfig(cfg *config.Config) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.static = cfg.Targets
+	m.breaker = cfg.CircuitBreaker
+	m.sched.configure(cfg.Scheduler)
+	m.spread = cfg.Scheduler.IsSpread()
+	m.reconcile()
+
+	// Invalid definitions keep the previous derived metrics running
+	if metrics, err := derived.Compile(cfg.DerivedMetrics); err != nil {
+		log.Printf("Invalid derived metrics, keeping previous definitions: %v", err)
+	} else {
+		m.derived.SetMetrics(metrics)
+	}
+}
+
+// ApplyDerived computes the derived metrics of a sample before it is stored
+func (m *Manager) ApplyDerived(metrics *models.PoolMetrics) {
+	m.derived.Process(metrics)
+}
+
+// SetDiscoveredTargets replaces the targets found by a service discovery source
+// Discovered targets are collected alongside config targets but not persisted
+func (m *Manager) SetDiscoveredTargets(source string, targets []config.TargetConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.discovered[source] = targets
+	m.reconcile()
+}
+
+// SetOwnership restricts collection to the targets for which owns returns true
+// Used when collection is sharded across replicas; nil collects every target
+func (m *Manager) SetOwnership(owns func(target string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.owns = owns
+	m.reconcile()
+}
+
+// DiscoveredTargets returns the targets currently found by service discovery
+func (m *Manager) DiscoveredTargets() []config.TargetConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var targets []config.TargetConfig
+	for _, t := range m.discovered {
+		targets = append(targets, t...)
+	}
+	return targets
+}
+
+// reconcile starts and stops collectors to match config and discovered targets
+// Caller must hold m.mu
+func (m *Manager) reconcile() {
+	// Paused targets keep their config but aren't collected; checked by name so a
+	// registry-backed target stops before discovery next refreshes it
+	paused := make(map[string]bool)
+	var targets []config.TargetConfig
+	for _, t := range m.static {
+		if t.Paused {
+			paused[t.Name] = true
+			continue
+		}
+		// Registry-backed targets are collected from their resolved instances
+		// and push targets send their own metrics
+		if t.Discovery == nil && t.Type != config.TargetTypePush {
+			targets = append(targets, t)
+		}
+	}
+	for _, discovered := range m.discovered {
+		for _, t := range discovered {
+			if !paused[t.Name] && !t.Paused {
+				targets = append(targets, t)
+			}
+		}
+	}
+
+	// Targets owned by other replicas are collected there
+	if m.owns != nil {
+		targets = slices.DeleteFunc(targets, func(t config.TargetConfig) bool { return !m.owns(t.Name) })
+	}
+
 	// Build desired state from config
 	desired := make(map[string]config.TargetConfig)
-	for _, target := range cfg.Targets {
+	for _, target := range targets {
 		instances := target.GetInstances()
 		for _, inst := range instances {
 			key := target.Name + "/" + inst.ID
@@ -60,98 +172,193 @@ func (m *Manager) UpdateFromConfig(cfg *config.Config) {
 	}
 
 	// Start new collectors or update existing ones
-	for _, target := range cfg.Targets {
+	for _, target := range targets {
 		instances := target.GetInstances()
 		for _, inst := range instances {
 			key := target.Name + "/" + inst.ID
 
 			if existing, exists := m.collectors[key]; exists {
-				// Check if interval or endpoint changed
-				if existing.Interval != target.Interval || existing.Endpoint != inst.Endpoint {
+				// Check if interval, endpoint, collector type or request settings changed
+				if existing.Interval != target.Interval || existing.Endpoint != inst.Endpoint ||
+					existing.Type != target.Type || existing.PoolType != target.PoolType ||
+					existing.Scrape != target.ScrapeMode ||
+					existing.Timeout != target.Timeout || existing.Retries != target.Retries ||
+					existing.RetryBackoff != target.RetryBackoff {
 					log.Printf("Restarting collector (config changed): %s -> %s (interval: %v)", key, inst.Endpoint, target.Interval)
 					existing.Cancel()
-					m.startCollector(target.Name, inst.ID, inst.Endpoint, target.Interval)
+					delete(m.collectors, key)
+					m.startCollector(target, inst)
 				}
 				// Note: group changes don't require collector restart
 				// as group is read from config at API response time
 			} else {
 				// New collector
 				log.Printf("Starting collector: %s -> %s (interval: %v)", key, inst.Endpoint, target.Interval)
-				m.startCollector(target.Name, inst.ID, inst.Endpoint, target.Interval)
+				m.startCollector(target, inst)
 			}
 		}
 	}
 
+	m.assignPhases()
 	log.Printf("Collector manager updated: %d active collectors", len(m.collectors))
 }
 
 // startCollector starts a new collector goroutine
-func (m *Manager) startCollector(name, instanceID, endpoint string, interval time.Duration) {
-	key := name + "/" + instanceID
-	ctx, cancel := context.WithCancel(context.Background())
+func (m *Manager) startCollector(target config.TargetConfig, inst config.InstanceConfig) {
+	key := target.Name + "/" + inst.ID
 
-	collector := NewActuatorCollector(name, instanceID, endpoint)
-	m.collectors[key] = &CollectorInfo{
+	collector, err := NewCollector(target, inst)
+	if err != nil {
+		log.Printf("Cannot start collector %s: %v", key, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	info := &CollectorInfo{
 		Collector: collector,
 		Cancel:    cancel,
-		Interval:  interval,
-		Endpoint:  endpoint,
+		Interval:  target.Interval,
+		Endpoint:  inst.Endpoint,
+		Type:      target.Type,
+		PoolType:  target.PoolType,
+		Scrape:    target.ScrapeMode,
+
+		Timeout:      target.Timeout,
+		Retries:      target.Retries,
+		RetryBackoff: target.RetryBackoff,
+
+		health: &collectorHealth{},
 	}
+	m.collectors[key] = info
 
-	go m.runCollector(ctx, collector, interval)
+	m.running.Add(1)
+	go func() {
+		defer m.running.Done()
+		m.runCollector(ctx, info)
+	}()
 }
 
 // runCollector runs the collector loop
-func (m *Manager) runCollector(ctx context.Context, c *ActuatorCollector, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	// Collect immediately on start
-	m.collect(c)
-
+// Scrapes are spread over the interval (see nextScrape) and wait for a slot of the scheduler,
+// and endpoints that keep failing are scraped less often while the circuit breaker is open
+// The schedule advances from a base time without jitter, so jitter doesn't accumulate
+func (m *Manager) runCollector(ctx context.Context, info *CollectorInfo) {
+	c, interval, health := info.Collector, info.Interval, info.health
+	base := time.Now()
+	due := m.nextScrape(info, base)
 	for {
-		select {
-		case <-ctx.Done():
+		if !sleepContext(ctx, time.Until(due)) {
 			return
-		case <-ticker.C:
-			m.collect(c)
 		}
+		release, ok := m.sched.acquire(ctx)
+		if !ok {
+			return
+		}
+
+		failures := m.collect(c, health)
+		release()
+
+		m.mu.RLock()
+		breaker := m.breaker
+		m.mu.RUnlock()
+
+		next, open := breakerInterval(breaker, interval, failures)
+		base = nextBase(base, next, time.Now())
+		due = m.nextScrape(info, base)
+		if health.setBreaker(open, due) {
+			if open {
+				log.Printf("Circuit breaker opened for %s/%s after %d failures, backing off to %v",
+					c.Name(), c.InstanceName(), failures, next)
+			} else {
+				log.Printf("Circuit breaker closed for %s/%s", c.Name(), c.InstanceName())
+			}
+			m.notifyState(c, health, open, failures)
+		}
+	}
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
 	}
 }
 
 // CollectionTimeout is the maximum time allowed for a single metric collection
 const CollectionTimeout = 30 * time.Second
 
-// collect performs a single collection with timeout
-func (m *Manager) collect(c *ActuatorCollector) {
+// collect performs a single collection with timeout and returns the consecutive failures
+// Failed scrapes are reported to the alert callback with ScrapeFailures set
+func (m *Manager) collect(c Collector, health *collectorHealth) int {
 	// Create a context with timeout to prevent goroutine leaks
 	ctx, cancel := context.WithTimeout(context.Background(), CollectionTimeout)
 	defer cancel()
+	ctx = withRequestLimit(ctx, m.sched.requestLimit())
 
+	start := time.Now()
 	metrics, err := c.CollectWithContext(ctx)
-	if err != nil {
+
+	// A service without a pool is still a successful scrape
+	var scrapeErr error
+	if err != nil && (metrics == nil || metrics.Status != models.StatusNoPool) {
+		scrapeErr = err
 		if ctx.Err() == context.DeadlineExceeded {
-			log.Printf("Collection timeout for %s/%s after %v", c.Name(), c.InstanceName(), CollectionTimeout)
-			return
-		}
-		if metrics == nil || metrics.Status != "no_pool" {
-			log.Printf("Failed to collect from %s/%s: %v", c.Name(), c.InstanceName(), err)
-			return
+			scrapeErr = fmt.Errorf("collection timeout after %v", CollectionTimeout)
 		}
 	}
+	failures := health.record(start, time.Since(start), scrapeErr)
 
-	if err := m.store.Save(metrics); err != nil {
-		log.Printf("Failed to save metrics for %s/%s: %v", c.Name(), c.InstanceName(), err)
+	// Health is recorded even when the pool metrics can't be read, so an instance
+	// going DOWN shows up in its availability
+	// Storage calls don't share the scrape timeout, so a slow scrape still gets recorded
+	saveCtx := context.Background()
+	var healthStatus string
+	if metrics != nil && metrics.Health != "" {
+		healthStatus = metrics.Health
+		check := &models.HealthCheck{TargetName: c.Name(), InstanceName: c.InstanceName(), Status: healthStatus, Timestamp: start}
+		if err := m.store.SaveHealthCheck(saveCtx, check); err != nil {
+			log.Printf("Failed to save health check for %s/%s: %v", c.Name(), c.InstanceName(), err)
+		}
 	}
 
-	// Alert check hook
 	m.mu.RLock()
 	callback := m.alertCallback
 	m.mu.RUnlock()
 
+	if scrapeErr != nil {
+		log.Printf("Failed to collect from %s/%s: %v", c.Name(), c.InstanceName(), scrapeErr)
+		if callback != nil {
+			callback(&models.PoolMetrics{
+				TargetName:     c.Name(),
+				InstanceName:   c.InstanceName(),
+				Status:         models.StatusError,
+				ScrapeFailures: failures,
+				Health:         healthStatus,
+				Timestamp:      start,
+			})
+		}
+		return failures
+	}
+
+	m.derived.Process(metrics)
+	if err := m.store.Save(saveCtx, metrics); err != nil {
+		log.Printf("Failed to save metrics for %s/%s: %v", c.Name(), c.InstanceName(), err)
+	}
+
+	// Alert check hook
 	if callback != nil && metrics != nil {
 		callback(metrics)
 	}
+	return failures
 }
 
 // Stop stops all collectors
@@ -166,6 +373,55 @@ func (m *Manager) Stop() {
 	m.collectors = make(map[string]*CollectorInfo)
 }
 
+// Shutdown stops all collectors and waits for scrapes in progress to be saved
+// Returns ctx.Err() if they are still running when ctx is done
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Health returns the scrape health of all active collectors, sorted by target and instance
+func (m *Manager) Health() []models.CollectorHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]models.CollectorHealth, 0, len(m.collectors))
+	for _, info := range m.collectors {
+		h := models.CollectorHealth{
+			TargetName:   info.Collector.Name(),
+			InstanceName: info.Collector.InstanceName(),
+			Type:         info.Type,
+			Endpoint:     info.Endpoint,
+			Interval:     info.Interval.String(),
+		}
+		if h.Type == "" {
+			h.Type = config.TargetTypeActuator
+		}
+		info.health.snapshot(&h)
+		result = append(result, h)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TargetName != result[j].TargetName {
+			return result[i].TargetName < result[j].TargetName
+		}
+		return result[i].InstanceName < result[j].InstanceName
+	})
+	return result
+}
+
 // Count returns the number of active collectors
 func (m *Manager) Count() int {
 	m.mu.RLock()
@@ -179,3 +435,39 @@ func (m *Manager) SetAlertCallback(callback func(*models.PoolMetrics)) {
 	defer m.mu.Unlock()
 	m.alertCallback = callback
 }
+
+// StateChange reports a collector going down when its circuit breaker opens, or recovering when it closes
+type StateChange struct {
+	TargetName   string
+	InstanceName string
+	Down         bool
+	Failures     int    // Consecutive failed scrapes
+	LastError    string // Error of the last failed scrape
+}
+
+// SetStateCallback sets the function called when a collector goes down or recovers
+func (m *Manager) SetStateCallback(callback func(StateChange)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stateCallback = callback
+}
+
+func (m *Manager) notifyState(c Collector, health *collectorHealth, down bool, failures int) {
+	m.mu.RLock()
+	callback := m.stateCallback
+	m.mu.RUnlock()
+	if callback == nil {
+		return
+	}
+
+	health.mu.Lock()
+	lastError := health.lastError
+	health.mu.Unlock()
+	callback(StateChange{
+		TargetName:   c.Name(),
+		InstanceName: c.InstanceName(),
+		Down:         down,
+		Failures:     failures,
+		LastError:    lastError,
+	})
+}
@@ -0,0 +1,185 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// PoolDriver maps a connection pool implementation to its actuator metric names
+// Empty names mark metrics the pool does not expose
+type PoolDriver struct {
+	Type    string
+	Prefix  string // Metric name prefix used for auto-detection
+	Active  string
+	Idle    string
+	Pending string
+	Max     string
+	Timeout string
+	Acquire string
+	// AcquirePercentile is the percentile gauge of the Acquire timer, tagged by phi
+	AcquirePercentile string
+}
+
+// Supported pool drivers, in auto-detection priority order
+var poolDrivers = []*PoolDriver{
+	{
+		Type:    config.PoolTypeHikari,
+		Prefix:  "hikaricp.",
+		Active:  "hikaricp.connections.active",
+		Idle:    "hikaricp.connections.idle",
+		Pending: "hikaricp.connections.pending",
+		Max:     "hikaricp.connections.max",
+		Timeout: "hikaricp.connections.timeout",
+		Acquire: "hikaricp.connections.acquire",
+
+		AcquirePercentile: "hikaricp.connections.acquire.percentile",
+	},
+	{
+		Type:    config.PoolTypeDBCP2,
+		Prefix:  "dbcp2.",
+		Active:  "dbcp2.numActive",
+		Idle:    "dbcp2.numIdle",
+		Pending: "dbcp2.numWaiters",
+		Max:     "dbcp2.maxTotal",
+	},
+	{
+		Type:    config.PoolTypeDruid,
+		Prefix:  "druid.",
+		Active:  "druid.connections.active",
+		Idle:    "druid.connections.pooling",
+		Pending: "druid.connections.wait",
+		Max:     "druid.connections.max",
+	},
+	{
+		Type:    config.PoolTypeTomcat,
+		Prefix:  "tomcat.jdbc.",
+		Active:  "tomcat.jdbc.numActive",
+		Idle:    "tomcat.jdbc.numIdle",
+		Pending: "tomcat.jdbc.waitCount",
+		Max:     "tomcat.jdbc.maxActive",
+	},
+	{
+		Type:    config.PoolTypeR2DBC,
+		Prefix:  "r2dbc.pool.",
+		Active:  "r2dbc.pool.acquired",
+		Idle:    "r2dbc.pool.idle",
+		Pending: "r2dbc.pool.pending",
+		Max:     "r2dbc.pool.max.allocated",
+	},
+	{
+		Type:   config.PoolTypeJDBC,
+		Prefix: "jdbc.connections.",
+		Active: "jdbc.connections.active",
+		Idle:   "jdbc.connections.idle",
+		Max:    "jdbc.connections.max",
+	},
+}
+
+// GetPoolDriver returns the driver for a pool type, or nil for auto-detection
+func GetPoolDriver(poolType string) *PoolDriver {
+	for _, d := range poolDrivers {
+		if d.Type == poolType {
+			return d
+		}
+	}
+	return nil
+}
+
+// DetectPoolDriver picks a driver from the actuator metric names
+// Returns nil when no known pool metrics are registered
+func DetectPoolDriver(names []string) *PoolDriver {
+	for _, d := range poolDrivers {
+		for _, name := range names {
+			if strings.HasPrefix(name, d.Prefix) {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+// Pool detection backoff: while no pool metrics are found, the metric list is fetched again
+// after detectBackoffMin, doubling up to detectBackoffMax, instead of on every scrape
+const (
+	detectBackoffMin = time.Minute
+	detectBackoffMax = 10 * time.Minute
+)
+
+// errDetectBackoff skips pool detection until the backoff elapses
+var errDetectBackoff = errors.New("pool detection backed off")
+
+// ActuatorMetricsList represents the /actuator/metrics names list
+type ActuatorMetricsList struct {
+	Names []string `json:"names"`
+}
+
+// poolDriver returns the configured driver or detects it from the metric list
+// Detection results are cached; pools often register metrics on first use,
+// so the HikariCP default is used until another pool is detected, backing off between
+// fetches of the metric list while none is found.
+func (c *ActuatorCollector) poolDriver(ctx context.Context) *PoolDriver {
+	return c.resolvePoolDriver(func() ([]string, error) {
+		if time.Now().Before(c.detectNext) {
+			return nil, errDetectBackoff
+		}
+		return c.fetchMetricNamesWithContext(ctx)
+	})
+}
+
+// resolvePoolDriver returns the cached or configured driver, detecting it from the names
+// returned by listNames otherwise; listNames is called with c.mu held
+func (c *ActuatorCollector) resolvePoolDriver(listNames func() ([]string, error)) *PoolDriver {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pool != nil {
+		return c.pool
+	}
+	if d := GetPoolDriver(c.poolType); d != nil {
+		c.pool = d
+		return d
+	}
+
+	names, err := listNames()
+	if err == nil {
+		if d := DetectPoolDriver(names); d != nil {
+			c.pool = d
+			return d
+		}
+	}
+	if err != errDetectBackoff {
+		c.detectWait = min(max(c.detectWait*2, detectBackoffMin), detectBackoffMax)
+		c.detectNext = time.Now().Add(c.detectWait)
+	}
+	return GetPoolDriver(config.PoolTypeHikari)
+}
+
+func (c *ActuatorCollector) fetchMetricNamesWithContext(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var list ActuatorMetricsList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.Names, nil
+}
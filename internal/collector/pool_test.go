@@ -0,0 +1,248 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestDetectPoolDriver(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"jvm.memory.used", "hikaricp.connections.active", "jdbc.connections.active"}, config.PoolTypeHikari},
+		{[]string{"dbcp2.numActive", "jdbc.connections.active"}, config.PoolTypeDBCP2},
+		{[]string{"tomcat.jdbc.numActive"}, config.PoolTypeTomcat},
+		{[]string{"druid.connections.active", "jdbc.connections.active"}, config.PoolTypeDruid},
+		{[]string{"r2dbc.pool.acquired", "r2dbc.pool.allocated"}, config.PoolTypeR2DBC},
+		{[]string{"jdbc.connections.active"}, config.PoolTypeJDBC},
+	}
+
+	for _, tt := range tests {
+		d := DetectPoolDriver(tt.names)
+		if d == nil || d.Type != tt.want {
+			t.Errorf("DetectPoolDriver(%v) = %v, want %s", tt.names, d, tt.want)
+		}
+	}
+
+	if d := DetectPoolDriver([]string{"jvm.memory.used"}); d != nil {
+		t.Errorf("expected no driver without pool metrics, got %s", d.Type)
+	}
+}
+
+// newActuatorServer serves a metric list and VALUE measurements from a map
+func newActuatorServer(values map[string]float64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/actuator/health":
+			fmt.Fprint(w, `{"status":"UP"}`)
+		case r.URL.Path == "/actuator/metrics":
+			names := make([]string, 0, len(values))
+			for name := range values {
+				names = append(names, `"`+name+`"`)
+			}
+			fmt.Fprintf(w, `{"names":[%s]}`, strings.Join(names, ","))
+		default:
+			name := strings.TrimPrefix(r.URL.Path, "/actuator/metrics/")
+			value, ok := values[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, `{"name":"%s","measurements":[{"statistic":"VALUE","value":%v}]}`, name, value)
+		}
+	}))
+}
+
+func TestActuatorCollector_DetectsDBCP2(t *testing.T) {
+	srv := newActuatorServer(map[string]float64{
+		"dbcp2.numActive":  6,
+		"dbcp2.numIdle":    2,
+		"dbcp2.numWaiters": 1,
+		"dbcp2.maxTotal":   8,
+	})
+	defer srv.Close()
+
+	c := NewActuatorCollector("legacy", "default", srv.URL+"/actuator/metrics")
+	metrics, err := c.CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+	if metrics.Status != models.StatusHealthy {
+		t.Errorf("Status = %s, want healthy", metrics.Status)
+	}
+	if metrics.Active != 6 || metrics.Idle != 2 || metrics.Pending != 1 || metrics.Max != 8 {
+		t.Errorf("pool = active %d idle %d pending %d max %d, want 6/2/1/8",
+			metrics.Active, metrics.Idle, metrics.Pending, metrics.Max)
+	}
+	if c.pool == nil || c.pool.Type != config.PoolTypeDBCP2 {
+		t.Errorf("expected dbcp2 driver to be cached, got %v", c.pool)
+	}
+}
+
+func TestResolvePoolDriver_DetectionBackoff(t *testing.T) {
+	c := NewActuatorCollector("app", "default", "http://localhost/actuator/metrics")
+	fetches := 0
+	listNames := func() ([]string, error) {
+		fetches++
+		return []string{"jvm.memory.used"}, nil
+	}
+	if d := c.resolvePoolDriver(listNames); d.Type != config.PoolTypeHikari || c.pool != nil {
+		t.Fatalf("resolvePoolDriver() = %s, cached %v; want the uncached hikari default", d.Type, c.pool)
+	}
+	if c.detectWait != detectBackoffMin {
+		t.Errorf("detectWait = %v, want %v", c.detectWait, detectBackoffMin)
+	}
+
+	// Backed off: the metric list isn't fetched again
+	if d := c.poolDriver(context.Background()); d.Type != config.PoolTypeHikari {
+		t.Errorf("poolDriver() = %s, want hikari", d.Type)
+	}
+	if c.detectWait != detectBackoffMin {
+		t.Errorf("detectWait after skipped detection = %v, want %v", c.detectWait, detectBackoffMin)
+	}
+
+	// Each failure doubles the backoff up to the maximum
+	for range 5 {
+		c.detectNext = time.Time{}
+		c.resolvePoolDriver(listNames)
+	}
+	if fetches != 6 || c.detectWait != detectBackoffMax {
+		t.Errorf("fetches = %d, detectWait = %v; want 6, %v", fetches, c.detectWait, detectBackoffMax)
+	}
+}
+
+func TestActuatorCollector_GenericJDBCWithoutPending(t *testing.T) {
+	srv := newActuatorServer(map[string]float64{
+		"jdbc.connections.active": 3,
+		"jdbc.connections.idle":   7,
+		"jdbc.connections.max":    10,
+	})
+	defer srv.Close()
+
+	c, err := NewCollector(config.TargetConfig{Name: "legacy", PoolType: config.PoolTypeJDBC},
+		config.InstanceConfig{ID: "default", Endpoint: srv.URL + "/actuator/metrics"})
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+	metrics, err := c.(*ActuatorCollector).CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+	if metrics.Active != 3 || metrics.Idle != 7 || metrics.Max != 10 || metrics.Pending != 0 {
+		t.Errorf("pool = active %d idle %d pending %d max %d, want 3/7/0/10",
+			metrics.Active, metrics.Idle, metrics.Pending, metrics.Max)
+	}
+}
+
+func TestActuatorCollector_DruidOverride(t *testing.T) {
+	srv := newActuatorServer(map[string]float64{
+		"druid.connections.active":  4,
+		"druid.connections.pooling": 16,
+		"druid.connections.wait":    2,
+		"druid.connections.max":     20,
+		"jdbc.connections.active":   99,
+	})
+	defer srv.Close()
+
+	c, err := NewCollector(config.TargetConfig{Name: "orders", PoolType: config.PoolTypeDruid},
+		config.InstanceConfig{ID: "default", Endpoint: srv.URL + "/actuator/metrics"})
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+	metrics, err := c.(*ActuatorCollector).CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+	if metrics.Active != 4 || metrics.Idle != 16 || metrics.Pending != 2 || metrics.Max != 20 {
+		t.Errorf("pool = active %d idle %d pending %d max %d, want 4/16/2/20",
+			metrics.Active, metrics.Idle, metrics.Pending, metrics.Max)
+	}
+}
+
+func TestActuatorCollector_DetectsR2DBC(t *testing.T) {
+	srv := newActuatorServer(map[string]float64{
+		"r2dbc.pool.acquired":      5,
+		"r2dbc.pool.allocated":     8,
+		"r2dbc.pool.idle":          3,
+		"r2dbc.pool.pending":       4,
+		"r2dbc.pool.max.allocated": 10,
+	})
+	defer srv.Close()
+
+	c := NewActuatorCollector("reactive", "default", srv.URL+"/actuator/metrics")
+	metrics, err := c.CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+	if metrics.Active != 5 || metrics.Idle != 3 || metrics.Pending != 4 || metrics.Max != 10 {
+		t.Errorf("pool = active %d idle %d pending %d max %d, want 5/3/4/10",
+			metrics.Active, metrics.Idle, metrics.Pending, metrics.Max)
+	}
+}
+
+func TestActuatorCollector_AcquireTimes(t *testing.T) {
+	values := newActuatorServer(map[string]float64{
+		"hikaricp.connections.active":  4,
+		"hikaricp.connections.idle":    6,
+		"hikaricp.connections.pending": 0,
+		"hikaricp.connections.max":     10,
+	})
+	defer values.Close()
+
+	// Timer statistics and percentile gauges are reported in seconds
+	quantiles := map[string]float64{"0.5": 0.002, "0.95": 0.015, "0.99": 0.04}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/actuator/metrics/hikaricp.connections.acquire":
+			fmt.Fprint(w, `{"name":"hikaricp.connections.acquire","measurements":[`+
+				`{"statistic":"COUNT","value":120},{"statistic":"TOTAL_TIME","value":0.6},{"statistic":"MAX","value":0.25}]}`)
+		case "/actuator/metrics/hikaricp.connections.acquire.percentile":
+			phi := strings.TrimPrefix(r.URL.Query().Get("tag"), "phi:")
+			v, ok := quantiles[phi]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, `{"name":"hikaricp.connections.acquire.percentile","measurements":[{"statistic":"VALUE","value":%v}]}`, v)
+		default:
+			resp, err := http.Get(values.URL + r.URL.Path)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			defer resp.Body.Close()
+			w.WriteHeader(resp.StatusCode)
+			io.Copy(w, resp.Body)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewActuatorCollector("orders", "default", srv.URL+"/actuator/metrics")
+	metrics, err := c.CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+
+	want := map[string][2]float64{
+		"p50": {metrics.AcquireP50, 2},
+		"p95": {metrics.AcquireP95, 15},
+		"p99": {metrics.AcquireP99, 40},
+		"max": {metrics.AcquireMax, 250},
+	}
+	for name, v := range want {
+		if math.Abs(v[0]-v[1]) > 1e-9 {
+			t.Errorf("acquire %s = %v ms, want %v", name, v[0], v[1])
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package collector
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Prometheus scraping of actuator targets
+// One request to /actuator/prometheus replaces the per-metric requests to /actuator/metrics,
+// cutting a scrape from about 20 requests to 2 (the other one is the health check)
+
+const (
+	// promRetry is how long auto mode falls back to /actuator/metrics before probing /actuator/prometheus again
+	promRetry = 10 * time.Minute
+
+	// maxPromBody bounds the exposition read per scrape
+	maxPromBody = 16 << 20
+)
+
+// errPrometheusUnavailable is returned in auto mode when the instance doesn't expose /actuator/prometheus
+var errPrometheusUnavailable = errors.New("prometheus endpoint not exposed")
+
+// promUnitSuffixes are appended by Micrometer's Prometheus naming to metrics with a base unit
+var promUnitSuffixes = []string{"", "_total", "_bytes", "_seconds", "_threads", "_connections"}
+
+// promSample is one sample of the text exposition format
+type promSample struct {
+	labels map[string]string
+	value  float64
+}
+
+// promSamples holds the samples of a scrape by sample name
+type promSamples map[string][]promSample
+
+// usePrometheus reports whether the next scrape reads /actuator/prometheus
+func (c *ActuatorCollector) usePrometheus() bool {
+	switch c.scrapeMode {
+	case config.ScrapeModePrometheus:
+		return true
+	case config.ScrapeModeAuto:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return time.Now().After(c.promRetry)
+	default:
+		return false
+	}
+}
+
+// collectPrometheusWithContext collects metrics from /actuator/prometheus and the health endpoint
+// Returns errPrometheusUnavailable in auto mode when the instance doesn't expose the endpoint
+func (c *ActuatorCollector) collectPrometheusWithContext(ctx context.Context) (*models.PoolMetrics, error) {
+	metrics := &models.PoolMetrics{
+		TargetName:   c.name,
+		InstanceName: c.instanceName,
+		Timestamp:    time.Now(),
+	}
+
+	var samples promSamples
+	var err error
+	g := newTaskGroup(requestLimit(ctx))
+	g.Go(func() {
+		metrics.Health = c.checkHealthWithContext(ctx)
+	})
+	g.Go(func() {
+		samples, err = c.fetchPrometheusWithContext(ctx)
+	})
+	g.Wait()
+
+	if err != nil {
+		if c.scrapeMode == config.ScrapeModeAuto && strings.Contains(err.Error(), "404") {
+			log.Printf("%s/%s does not expose /actuator/prometheus, scraping /actuator/metrics", c.name, c.instanceName)
+			c.mu.Lock()
+			c.promRetry = time.Now().Add(promRetry)
+			c.mu.Unlock()
+			return nil, errPrometheusUnavailable
+		}
+		metrics.Status = models.StatusError
+		return metrics, err
+	}
+
+	pool := c.resolvePoolDriver(func() ([]string, error) {
+		return samples.actuatorNames(), nil
+	})
+
+	active, ok := samples.gauge(pool.Active)
+	if !ok {
+		if metrics.Health == models.HealthUp {
+			metrics.Status = models.StatusNoPool
+			return metrics, nil
+		}
+		metrics.Status = models.StatusError
+		return metrics, fmt.Errorf("metric %s not found", pool.Active)
+	}
+	metrics.Active = int(active)
+
+	idle, ok := samples.gauge(pool.Idle)
+	if !ok {
+		metrics.Status = models.StatusError
+		return metrics, fmt.Errorf("failed to fetch idle: metric %s not found", pool.Idle)
+	}
+	metrics.Idle = int(idle)
+
+	maxConns, ok := samples.gauge(pool.Max)
+	if !ok {
+		metrics.Status = models.StatusError
+		return metrics, fmt.Errorf("failed to fetch max: metric %s not found", pool.Max)
+	}
+	metrics.Max = int(maxConns)
+
+	// Pending is required for HikariCP, optional for pools that may not export it
+	if pool.Pending != "" {
+		if pending, ok := samples.gauge(pool.Pending); ok {
+			metrics.Pending = int(pending)
+		} else if pool.Type == config.PoolTypeHikari {
+			metrics.Status = models.StatusError
+			return metrics, fmt.Errorf("failed to fetch pending: metric %s not found", pool.Pending)
+		}
+	}
+	if pool.Timeout != "" {
+		if timeout, ok := samples.gauge(pool.Timeout); ok {
+			metrics.Timeout = int64(timeout)
+		}
+	}
+
+	// Timers are exported in seconds; percentiles are quantiles of the timer summary
+	if pool.Acquire != "" {
+		timer := promName(pool.Acquire) + "_seconds"
+		if v, ok := samples.sum([]string{timer + "_max"}); ok {
+			metrics.AcquireMax = v * 1000
+		}
+		if pool.AcquirePercentile != "" {
+			quantiles := []struct {
+				phi   string
+				value *float64
+			}{
+				{"0.5", &metrics.AcquireP50},
+				{"0.95", &metrics.AcquireP95},
+				{"0.99", &metrics.AcquireP99},
+			}
+			for _, q := range quantiles {
+				if v, ok := samples.sum([]string{timer}, "quantile", q.phi); ok {
+					*q.value = v * 1000
+				}
+			}
+		}
+	}
+
+	for _, jm := range jvmMetrics(metrics) {
+		var labels []string
+		if jm.tag != "" {
+			labels = []string{jm.tag, jm.tagVal}
+		}
+		if v, ok := samples.gauge(jm.name, labels...); ok {
+			jm.handler(v)
+		}
+	}
+
+	if v, ok := samples.sum([]string{"jvm_gc_pause_seconds_count"}); ok {
+		metrics.GcCount = int64(v)
+	}
+	if v, ok := samples.sum([]string{"jvm_gc_pause_seconds_sum"}); ok {
+		metrics.GcTime = v
+	}
+	if v, ok := samples.sum([]string{"jvm_gc_pause_seconds_count"}, "action", "end of minor GC"); ok {
+		metrics.YoungGcCount = int64(v)
+	}
+	if v, ok := samples.sum([]string{"jvm_gc_pause_seconds_count"}, "action", "end of major GC"); ok {
+		metrics.OldGcCount = int64(v)
+	}
+
+	metrics.Status = models.StatusHealthy
+	return metrics, nil
+}
+
+// fetchPrometheusWithContext reads the text exposition of /actuator/prometheus
+func (c *ActuatorCollector) fetchPrometheusWithContext(ctx context.Context) (promSamples, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actuatorURL(c.endpoint, "prometheus"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	// The shared transport disables compression for the small metric responses, but the
+	// exposition of a large application is worth compressing
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var body io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+	return parsePrometheusText(io.LimitReader(body, maxPromBody))
+}
+
+// parsePrometheusText parses the Prometheus text exposition format
+// Comments and malformed lines are skipped
+func parsePrometheusText(r io.Reader) (promSamples, error) {
+	samples := make(promSamples)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name, sample, err := parsePromLine(line)
+		if err != nil {
+			continue
+		}
+		samples[name] = append(samples[name], sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// parsePromLine parses a sample line, e.g., hikaricp_connections_active{pool="HikariPool-1",} 4.0
+func parsePromLine(line string) (string, promSample, error) {
+	i := strings.IndexAny(line, "{ \t")
+	if i <= 0 {
+		return "", promSample{}, fmt.Errorf("missing value")
+	}
+	name, rest := line[:i], line[i:]
+
+	var labels map[string]string
+	if rest[0] == '{' {
+		var err error
+		if labels, rest, err = parsePromLabels(rest[1:]); err != nil {
+			return "", promSample{}, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", promSample{}, fmt.Errorf("missing value")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", promSample{}, err
+	}
+	return name, promSample{labels: labels, value: value}, nil
+}
+
+// parsePromLabels parses the labels after the opening brace and returns the rest of the line
+func parsePromLabels(s string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || eq+1 >= len(s) || s[eq+1] != '"' {
+			return nil, "", fmt.Errorf("invalid label")
+		}
+		key := strings.TrimSpace(s[:eq])
+
+		var value strings.Builder
+		j := eq + 2
+		for ; j < len(s) && s[j] != '"'; j++ {
+			if s[j] == '\\' && j+1 < len(s) {
+				j++
+				if s[j] == 'n' {
+					value.WriteByte('\n')
+				} else {
+					value.WriteByte(s[j])
+				}
+				continue
+			}
+			value.WriteByte(s[j])
+		}
+		if j >= len(s) {
+			return nil, "", fmt.Errorf("unterminated label value")
+		}
+		labels[key] = value.String()
+		s = s[j+1:]
+	}
+}
+
+// promName converts an actuator metric name to its Prometheus base name,
+// e.g., hikaricp.connections.active -> hikaricp_connections_active
+func promName(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// actuatorNames returns the sample names in actuator notation for pool detection
+func (s promSamples) actuatorNames() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, strings.ReplaceAll(name, "_", "."))
+	}
+	return names
+}
+
+// gauge returns the value of an actuator metric, trying the unit suffixes of its Prometheus name
+func (s promSamples) gauge(name string, labels ...string) (float64, bool) {
+	base := promName(name)
+	names := make([]string, len(promUnitSuffixes))
+	for i, suffix := range promUnitSuffixes {
+		names[i] = base + suffix
+	}
+	return s.sum(names, labels...)
+}
+
+// sum adds up the samples of the first of names with samples matching the labels, given as
+// key/value pairs; like the actuator metrics endpoint, it sums over all other labels
+func (s promSamples) sum(names []string, labels ...string) (float64, bool) {
+	for _, name := range names {
+		var total float64
+		found := false
+		for _, sample := range s[name] {
+			if math.IsNaN(sample.value) || !sample.matches(labels) {
+				continue
+			}
+			total += sample.value
+			found = true
+		}
+		if found {
+			return total, true
+		}
+	}
+	return 0, false
+}
+
+// matches reports whether the sample has all labels, given as key/value pairs
+func (p promSample) matches(labels []string) bool {
+	for i := 0; i+1 < len(labels); i += 2 {
+		if p.labels[labels[i]] != labels[i+1] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+const promExposition = `# HELP hikaricp_connections_active Active connections
+# TYPE hikaricp_connections_active gauge
+hikaricp_connections_active{pool="HikariPool-1",} 4.0
+hikaricp_connections_active{pool="HikariPool-2",} 1.0
+hikaricp_connections_idle{pool="HikariPool-1",} 6.0
+hikaricp_connections_idle{pool="HikariPool-2",} 4.0
+hikaricp_connections_pending{pool="HikariPool-1",} 0.0
+hikaricp_connections_pending{pool="HikariPool-2",} 2.0
+hikaricp_connections_max{pool="HikariPool-1",} 10.0
+hikaricp_connections_max{pool="HikariPool-2",} 5.0
+hikaricp_connections_timeout_total{pool="HikariPool-1",} 3.0
+hikaricp_connections_acquire_seconds{pool="HikariPool-1",quantile="0.5",} 0.002
+hikaricp_connections_acquire_seconds{pool="HikariPool-1",quantile="0.95",} 0.015
+hikaricp_connections_acquire_seconds{pool="HikariPool-1",quantile="0.99",} 0.04
+hikaricp_connections_acquire_seconds_count{pool="HikariPool-1",} 120.0
+hikaricp_connections_acquire_seconds_sum{pool="HikariPool-1",} 0.6
+hikaricp_connections_acquire_seconds_max{pool="HikariPool-1",} 0.25
+jvm_memory_used_bytes{area="heap",id="G1 Eden Space",} 1000.0
+jvm_memory_used_bytes{area="heap",id="G1 Old Gen",} 2000.0
+jvm_memory_used_bytes{area="nonheap",id="Metaspace",} 500.0
+jvm_memory_max_bytes{area="heap",id="G1 Old Gen",} 8000.0
+jvm_threads_live_threads 42.0
+process_cpu_usage 0.25
+process_start_time_seconds NaN
+jvm_gc_pause_seconds_count{action="end of minor GC",cause="G1 Evacuation Pause",} 10.0
+jvm_gc_pause_seconds_count{action="end of major GC",cause="System.gc()",} 2.0
+jvm_gc_pause_seconds_sum{action="end of minor GC",cause="G1 Evacuation Pause",} 0.5
+jvm_gc_pause_seconds_sum{action="end of major GC",cause="System.gc()",} 0.3
+`
+
+func TestParsePrometheusText(t *testing.T) {
+	input := `# TYPE http_requests_total counter
+http_requests_total{method="post",path="/a \"b\"\\c"} 1027 1395066363000
+http_requests_total{method="get"} 3
+
+malformed{ 1
+up +Inf
+`
+	samples, err := parsePrometheusText(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parsePrometheusText() error = %v", err)
+	}
+
+	requests := samples["http_requests_total"]
+	if len(requests) != 2 {
+		t.Fatalf("got %d http_requests_total samples, want 2", len(requests))
+	}
+	if got := requests[0].labels["path"]; got != `/a "b"\c` {
+		t.Errorf("escaped label = %q", got)
+	}
+	if v, ok := samples.sum([]string{"http_requests_total"}, "method", "get"); !ok || v != 3 {
+		t.Errorf("sum(method=get) = %v, %v; want 3", v, ok)
+	}
+	if v, ok := samples.sum([]string{"http_requests_total"}); !ok || v != 1030 {
+		t.Errorf("sum() = %v, %v; want 1030", v, ok)
+	}
+	if v := samples["up"]; len(v) != 1 || !math.IsInf(v[0].value, 1) {
+		t.Errorf("up = %v, want +Inf", v)
+	}
+	if _, ok := samples["malformed"]; ok {
+		t.Error("malformed line should be skipped")
+	}
+}
+
+// newPrometheusServer serves the exposition and health, counting all requests
+func newPrometheusServer(exposition string, requests *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/actuator/health":
+			fmt.Fprint(w, `{"status":"UP"}`)
+		case "/actuator/prometheus":
+			fmt.Fprint(w, exposition)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestActuatorCollector_Prometheus(t *testing.T) {
+	var requests atomic.Int64
+	srv := newPrometheusServer(promExposition, &requests)
+	defer srv.Close()
+
+	c, err := NewCollector(config.TargetConfig{Name: "orders", ScrapeMode: config.ScrapeModePrometheus},
+		config.InstanceConfig{ID: "default", Endpoint: srv.URL + "/actuator/metrics"})
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+	metrics, err := c.CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+
+	if n := requests.Load(); n != 2 {
+		t.Errorf("scrape sent %d requests, want 2", n)
+	}
+	if metrics.Status != models.StatusHealthy || metrics.Health != models.HealthUp {
+		t.Errorf("status = %s, health = %s; want healthy, UP", metrics.Status, metrics.Health)
+	}
+	if metrics.Active != 5 || metrics.Idle != 10 || metrics.Pending != 2 || metrics.Max != 15 || metrics.Timeout != 3 {
+		t.Errorf("pool = active %d idle %d pending %d max %d timeout %d, want 5/10/2/15/3",
+			metrics.Active, metrics.Idle, metrics.Pending, metrics.Max, metrics.Timeout)
+	}
+	if metrics.HeapUsed != 3000 || metrics.NonHeapUsed != 500 || metrics.HeapMax != 8000 || metrics.ThreadsLive != 42 {
+		t.Errorf("jvm = heap %d/%d nonheap %d threads %d, want 3000/8000 500 42",
+			metrics.HeapUsed, metrics.HeapMax, metrics.NonHeapUsed, metrics.ThreadsLive)
+	}
+	if metrics.GcCount != 12 || metrics.YoungGcCount != 10 || metrics.OldGcCount != 2 || math.Abs(metrics.GcTime-0.8) > 1e-9 {
+		t.Errorf("gc = count %d young %d old %d time %v, want 12/10/2/0.8",
+			metrics.GcCount, metrics.YoungGcCount, metrics.OldGcCount, metrics.GcTime)
+	}
+
+	want := map[string][2]float64{
+		"p50": {metrics.AcquireP50, 2},
+		"p95": {metrics.AcquireP95, 15},
+		"p99": {metrics.AcquireP99, 40},
+		"max": {metrics.AcquireMax, 250},
+		"cpu": {metrics.CpuUsage, 0.25},
+	}
+	for name, v := range want {
+		if math.Abs(v[0]-v[1]) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, v[0], v[1])
+		}
+	}
+}
+
+func TestActuatorCollector_PrometheusNoPool(t *testing.T) {
+	var requests atomic.Int64
+	srv := newPrometheusServer("jvm_threads_live_threads 42.0\n", &requests)
+	defer srv.Close()
+
+	c := NewActuatorCollector("orders", "default", srv.URL+"/actuator/metrics")
+	c.scrapeMode = config.ScrapeModePrometheus
+	metrics, err := c.CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+	if metrics.Status != models.StatusNoPool {
+		t.Errorf("Status = %s, want %s", metrics.Status, models.StatusNoPool)
+	}
+}
+
+func TestActuatorCollector_AutoFallsBackToMetrics(t *testing.T) {
+	srv := newActuatorServer(map[string]float64{
+		"hikaricp.connections.active":  4,
+		"hikaricp.connections.idle":    6,
+		"hikaricp.connections.pending": 0,
+		"hikaricp.connections.max":     10,
+	})
+	defer srv.Close()
+
+	c := NewActuatorCollector("orders", "default", srv.URL+"/actuator/metrics")
+	c.scrapeMode = config.ScrapeModeAuto
+	metrics, err := c.CollectWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("CollectWithContext() error = %v", err)
+	}
+	if metrics.Active != 4 || metrics.Max != 10 {
+		t.Errorf("pool = active %d max %d, want 4/10", metrics.Active, metrics.Max)
+	}
+	if c.usePrometheus() {
+		t.Error("auto mode should skip /actuator/prometheus after a 404")
+	}
+}
+
+func TestActuatorCollector_SkipsMissingOptionalMetrics(t *testing.T) {
+	values := map[string]float64{
+		"hikaricp.connections.active":  4,
+		"hikaricp.connections.idle":    6,
+		"hikaricp.connections.pending": 0,
+		"hikaricp.connections.max":     10,
+	}
+	var percentileRequests, actionRequests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/actuator/metrics/")
+		switch {
+		case r.URL.Path == "/actuator/health":
+			fmt.Fprint(w, `{"status":"UP"}`)
+		case name == "hikaricp.connections.acquire.percentile":
+			percentileRequests.Add(1)
+			http.NotFound(w, r)
+		case name == "jvm.gc.pause" && r.URL.Query().Get("tag") != "":
+			actionRequests.Add(1)
+			fmt.Fprint(w, `{"name":"jvm.gc.pause","measurements":[{"statistic":"COUNT","value":7}]}`)
+		case name == "jvm.gc.pause":
+			fmt.Fprint(w, `{"name":"jvm.gc.pause","measurements":[{"statistic":"COUNT","value":7}],`+
+				`"availableTags":[{"tag":"action","values":["end of minor GC"]}]}`)
+		default:
+			value, ok := values[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, `{"name":"%s","measurements":[{"statistic":"VALUE","value":%v}]}`, name, value)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewActuatorCollector("orders", "default", srv.URL+"/actuator/metrics")
+	c.poolType = config.PoolTypeHikari
+	for i := 0; i < 3; i++ {
+		metrics, err := c.CollectWithContext(context.Background())
+		if err != nil {
+			t.Fatalf("CollectWithContext() error = %v", err)
+		}
+		if metrics.YoungGcCount != 7 || metrics.OldGcCount != 0 {
+			t.Errorf("gc = young %d old %d, want 7/0", metrics.YoungGcCount, metrics.OldGcCount)
+		}
+	}
+
+	// Each percentile is asked once, then skipped until optionalRetry passes
+	if n := percentileRequests.Load(); n != 3 {
+		t.Errorf("percentile requests = %d, want 3", n)
+	}
+	// Only the action listed in the available tags is queried
+	if n := actionRequests.Load(); n != 3 {
+		t.Errorf("gc action requests = %d, want 3", n)
+	}
+}
@@ -0,0 +1,188 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// reconfigureTimeout bounds each request to an instance's management endpoints
+const reconfigureTimeout = 10 * time.Second
+
+// poolSettings maps the recommendation types that can be applied at runtime to their property names
+var poolSettings = map[string]string{
+	"maximumPoolSize":   "maximum-pool-size",
+	"minimumIdle":       "minimum-idle",
+	"connectionTimeout": "connection-timeout",
+}
+
+// IsPoolSetting reports whether a recommendation type can be applied at runtime
+func IsPoolSetting(setting string) bool {
+	_, ok := poolSettings[setting]
+	return ok
+}
+
+// PoolSettingProperty returns the property changed for a setting, e.g.,
+// spring.datasource.hikari.maximum-pool-size in env mode
+func PoolSettingProperty(rc *config.ReconfigureConfig, setting string) string {
+	if rc.GetMode() == config.ReconfigureModeCustom {
+		return setting
+	}
+	return rc.GetPropertyPrefix() + "." + poolSettings[setting]
+}
+
+// PoolSettingChange is the outcome of changing a pool setting on one instance
+type PoolSettingChange struct {
+	Instance string `json:"instance"`
+	Previous string `json:"previous,omitempty"` // Value before the change, empty when unknown
+	Value    string `json:"value"`
+	Applied  bool   `json:"applied"`
+	Error    string `json:"error,omitempty"`
+}
+
+// actuatorEnvProperty is the response of /actuator/env/{property}
+type actuatorEnvProperty struct {
+	Property *struct {
+		Value interface{} `json:"value"`
+	} `json:"property"`
+}
+
+// ApplyPoolSetting changes a pool setting on every running instance of a target
+// With dryRun only the current values are read. A failing instance doesn't stop the others.
+func (m *Manager) ApplyPoolSetting(ctx context.Context, target string, rc *config.ReconfigureConfig, setting, value string, dryRun bool) ([]PoolSettingChange, error) {
+	if !IsPoolSetting(setting) {
+		return nil, fmt.Errorf("setting %q cannot be changed at runtime", setting)
+	}
+
+	type instance struct{ name, endpoint, typ string }
+	m.mu.RLock()
+	var instances []instance
+	for _, info := range m.collectors {
+		if info.Collector.Name() == target {
+			instances = append(instances, instance{info.Collector.InstanceName(), info.Endpoint, info.Type})
+		}
+	}
+	m.mu.RUnlock()
+
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("%w: no running instances of %s", ErrInstanceNotFound, target)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].name < instances[j].name })
+
+	property := PoolSettingProperty(rc, setting)
+	changes := make([]PoolSettingChange, 0, len(instances))
+	for _, inst := range instances {
+		if inst.typ != "" && inst.typ != config.TargetTypeActuator {
+			return nil, ErrNotActuator
+		}
+
+		change := PoolSettingChange{Instance: inst.name, Value: value}
+		var err error
+		if rc.GetMode() == config.ReconfigureModeEnv {
+			change.Previous, err = readEnvProperty(ctx, inst.endpoint, property)
+			if err == nil && !dryRun {
+				err = writeEnvProperty(ctx, inst.endpoint, property, value, rc.IsRefreshEnabled())
+			}
+		} else if !dryRun {
+			err = postManagement(ctx, actuatorURL(inst.endpoint, strings.Trim(rc.Path, "/")),
+				map[string]string{"setting": setting, "value": value})
+		}
+
+		if err != nil {
+			change.Error = err.Error()
+		} else {
+			change.Applied = !dryRun
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// readEnvProperty returns the current value of a property, empty when it is not set
+func readEnvProperty(ctx context.Context, endpoint, property string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, reconfigureTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actuatorURL(endpoint, "env/"+property), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := managementClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("env returned HTTP %d", resp.StatusCode)
+	}
+
+	var env actuatorEnvProperty
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return "", fmt.Errorf("failed to decode env: %w", err)
+	}
+	if env.Property == nil || env.Property.Value == nil {
+		return "", nil
+	}
+	return fmt.Sprint(env.Property.Value), nil
+}
+
+// writeEnvProperty sets a property through /actuator/env and optionally refreshes the context
+func writeEnvProperty(ctx context.Context, endpoint, property, value string, refresh bool) error {
+	body := map[string]string{"name": property, "value": value}
+	if err := postManagement(ctx, actuatorURL(endpoint, "env"), body); err != nil {
+		return err
+	}
+	if refresh {
+		if err := postManagement(ctx, actuatorURL(endpoint, "refresh"), nil); err != nil {
+			return fmt.Errorf("property set but refresh failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// postManagement posts a JSON body to a management endpoint and checks for a 2xx answer
+func postManagement(ctx context.Context, url string, body interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, reconfigureTimeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := managementClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned HTTP %d", url[strings.LastIndex(url, "/")+1:], resp.StatusCode)
+	}
+	return nil
+}
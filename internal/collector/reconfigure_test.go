@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestManager_ApplyPoolSetting(t *testing.T) {
+	const property = "spring.datasource.hikari.maximum-pool-size"
+	value := "20"
+	refreshed := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /actuator/env/" + property:
+			io.WriteString(w, `{"property":{"source":"applicationConfig","value":"`+value+`"}}`)
+		case "POST /actuator/env":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["name"] != property {
+				http.Error(w, "unexpected property", http.StatusBadRequest)
+				return
+			}
+			value = body["value"]
+		case "POST /actuator/refresh":
+			refreshed++
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	m := NewManager(nil)
+	m.collectors["orders/default"] = &CollectorInfo{Collector: failingCollector{}, Endpoint: server.URL + "/actuator/metrics"}
+	rc := &config.ReconfigureConfig{Enabled: true}
+
+	preview, err := m.ApplyPoolSetting(context.Background(), "orders", rc, "maximumPoolSize", "30", true)
+	if err != nil {
+		t.Fatalf("ApplyPoolSetting(dry run) error = %v", err)
+	}
+	if len(preview) != 1 || preview[0].Previous != "20" || preview[0].Applied || value != "20" {
+		t.Fatalf("dry run = %+v, value = %s; want previous 20 and nothing applied", preview, value)
+	}
+
+	changes, err := m.ApplyPoolSetting(context.Background(), "orders", rc, "maximumPoolSize", "30", false)
+	if err != nil {
+		t.Fatalf("ApplyPoolSetting() error = %v", err)
+	}
+	if !changes[0].Applied || changes[0].Previous != "20" || value != "30" || refreshed != 1 {
+		t.Errorf("changes = %+v, value = %s, refreshed = %d", changes, value, refreshed)
+	}
+
+	// Custom endpoint that the instance doesn't expose
+	custom := &config.ReconfigureConfig{Enabled: true, Mode: config.ReconfigureModeCustom, Path: "poolconfig"}
+	changes, err = m.ApplyPoolSetting(context.Background(), "orders", custom, "minimumIdle", "5", false)
+	if err != nil || changes[0].Applied || changes[0].Error == "" {
+		t.Errorf("custom = %+v, %v; want a per-instance error", changes, err)
+	}
+
+	if _, err := m.ApplyPoolSetting(context.Background(), "orders", rc, "status", "OK", false); err == nil {
+		t.Error("expected error for a setting that can't be applied")
+	}
+	if _, err := m.ApplyPoolSetting(context.Background(), "payments", rc, "maximumPoolSize", "30", false); err == nil {
+		t.Error("expected error for a target without instances")
+	}
+}
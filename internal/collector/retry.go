@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// retryPolicy retries failed metric requests with exponential backoff
+type retryPolicy struct {
+	retries int           // Extra attempts after the first one
+	backoff time.Duration // Delay before the first retry, doubled each attempt
+}
+
+// do sends the request, retrying network errors and 5xx responses
+// The last response or error is returned when all attempts fail
+func (p retryPolicy) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	backoff := p.backoff
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := client.Do(req)
+		if attempt >= p.retries || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if !sleepContext(ctx, backoff) {
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether a request failure is worth retrying
+// Client errors such as 404 (metric not exported) are returned immediately
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// collectionJitter returns a random delay of up to 10% of the interval
+// so that targets sharing an interval don't scrape in lockstep
+func collectionJitter(interval time.Duration) time.Duration {
+	max := interval / 10
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
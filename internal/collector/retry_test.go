@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32 // requests answered with status before succeeding
+		status    int   // failure status code
+		retries   int
+		wantCode  int
+		wantCalls int32
+	}{
+		{"no retries", 1, http.StatusServiceUnavailable, 0, http.StatusServiceUnavailable, 1},
+		{"recovers", 2, http.StatusServiceUnavailable, 2, http.StatusOK, 3},
+		{"gives up", 5, http.StatusServiceUnavailable, 2, http.StatusServiceUnavailable, 3},
+		{"client error not retried", 1, http.StatusNotFound, 3, http.StatusNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				fmt.Fprint(w, "ok")
+			}))
+			defer srv.Close()
+
+			p := retryPolicy{retries: tt.retries, backoff: time.Millisecond}
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := p.do(srv.Client(), req)
+			if err != nil {
+				t.Fatalf("do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_DoCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	p := retryPolicy{retries: 3, backoff: time.Hour}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := p.do(srv.Client(), req); err == nil {
+		t.Error("do() should fail when the context ends during backoff")
+	}
+}
+
+func TestNewCollector_RequestSettings(t *testing.T) {
+	target := config.TargetConfig{
+		Name:         "orders",
+		Timeout:      2 * time.Second,
+		Retries:      3,
+		RetryBackoff: 100 * time.Millisecond,
+	}
+
+	c, err := NewCollector(target, config.InstanceConfig{ID: "default", Endpoint: "http://localhost:8080/actuator/metrics"})
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+	ac := c.(*ActuatorCollector)
+	if ac.client.Timeout != 2*time.Second {
+		t.Errorf("timeout = %v, want 2s", ac.client.Timeout)
+	}
+	if ac.retry.retries != 3 || ac.retry.backoff != 100*time.Millisecond {
+		t.Errorf("retry = %+v, want 3 retries with 100ms backoff", ac.retry)
+	}
+
+	// Defaults keep the previous behavior
+	c, _ = NewCollector(config.TargetConfig{Name: "orders"}, config.InstanceConfig{ID: "default", Endpoint: "http://localhost:8080/actuator/metrics"})
+	ac = c.(*ActuatorCollector)
+	if ac.client.Timeout != config.DefaultTargetTimeout || ac.retry.retries != 0 {
+		t.Errorf("defaults = %v / %d retries, want %v / 0", ac.client.Timeout, ac.retry.retries, config.DefaultTargetTimeout)
+	}
+}
+
+func TestCollectionJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if j := collectionJitter(10 * time.Second); j < 0 || j >= time.Second {
+			t.Fatalf("collectionJitter(10s) = %v, want [0, 1s)", j)
+		}
+	}
+	if j := collectionJitter(0); j != 0 {
+		t.Errorf("collectionJitter(0) = %v, want 0", j)
+	}
+}
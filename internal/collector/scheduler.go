@@ -0,0 +1,199 @@
+package collector
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// scheduler bounds the scrapes running at once across all collectors
+// Collectors wait for a slot when the limit is reached instead of piling up requests
+type scheduler struct {
+	mu       sync.Mutex
+	slots    chan struct{} // Buffered to the concurrent scrape limit; a scrape holds one entry
+	requests int           // Parallel HTTP requests of one scrape
+
+	running atomic.Int64
+	waiting atomic.Int64
+	delayed atomic.Int64 // Scrapes that had to wait for a slot since start
+}
+
+func newScheduler() *scheduler {
+	var cfg config.SchedulerConfig
+	return &scheduler{
+		slots:    make(chan struct{}, cfg.GetMaxConcurrent()),
+		requests: cfg.GetRequestsPerScrape(),
+	}
+}
+
+// configure applies new limits; scrapes holding a slot of the previous limit release it there
+func (s *scheduler) configure(cfg config.SchedulerConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cap(s.slots) != cfg.GetMaxConcurrent() {
+		s.slots = make(chan struct{}, cfg.GetMaxConcurrent())
+	}
+	s.requests = cfg.GetRequestsPerScrape()
+}
+
+// acquire waits for a scrape slot and returns the function releasing it
+// Returns false if ctx is cancelled first
+func (s *scheduler) acquire(ctx context.Context) (func(), bool) {
+	s.mu.Lock()
+	slots := s.slots
+	s.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		s.delayed.Add(1)
+		s.waiting.Add(1)
+		select {
+		case slots <- struct{}{}:
+			s.waiting.Add(-1)
+		case <-ctx.Done():
+			s.waiting.Add(-1)
+			return nil, false
+		}
+	}
+
+	s.running.Add(1)
+	return func() {
+		s.running.Add(-1)
+		<-slots
+	}, true
+}
+
+// requestLimit returns the parallel requests allowed within one scrape
+func (s *scheduler) requestLimit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// stats returns the current limits and load
+func (s *scheduler) stats() models.SchedulerStats {
+	s.mu.Lock()
+	maxConcurrent, requests := cap(s.slots), s.requests
+	s.mu.Unlock()
+
+	return models.SchedulerStats{
+		MaxConcurrent:     maxConcurrent,
+		RequestsPerScrape: requests,
+		Running:           int(s.running.Load()),
+		Waiting:           int(s.waiting.Load()),
+		Delayed:           s.delayed.Load(),
+	}
+}
+
+// requestLimitKey carries the parallel request limit of a scrape in its context
+type requestLimitKey struct{}
+
+// withRequestLimit returns a context limiting the parallel requests of a scrape
+func withRequestLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, requestLimitKey{}, limit)
+}
+
+// requestLimit returns the parallel request limit of a scrape, or the default without one
+func requestLimit(ctx context.Context) int {
+	if limit, ok := ctx.Value(requestLimitKey{}).(int); ok && limit > 0 {
+		return limit
+	}
+	var cfg config.SchedulerConfig
+	return cfg.GetRequestsPerScrape()
+}
+
+// taskGroup runs functions concurrently, at most limit at a time
+// Go blocks until a slot is free, so a scrape never has more than limit goroutines in flight
+type taskGroup struct {
+	wg  sync.WaitGroup
+	sem chan struct{}
+}
+
+func newTaskGroup(limit int) *taskGroup {
+	return &taskGroup{sem: make(chan struct{}, max(limit, 1))}
+}
+
+// Go runs fn in a goroutine once fewer than limit functions are running
+func (g *taskGroup) Go(fn func()) {
+	g.sem <- struct{}{}
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.sem
+			g.wg.Done()
+		}()
+		fn()
+	}()
+}
+
+// Wait waits for all functions to return
+func (g *taskGroup) Wait() {
+	g.wg.Wait()
+}
+
+// nextSlot returns the first time at or after t on a schedule of every interval, offset by phase
+func nextSlot(t time.Time, interval, phase time.Duration) time.Time {
+	if interval <= 0 {
+		return t
+	}
+	rem := time.Duration((t.UnixNano() - int64(phase)) % int64(interval))
+	if rem < 0 {
+		rem += interval
+	}
+	if rem == 0 {
+		return t
+	}
+	return t.Add(interval - rem)
+}
+
+// assignPhases spreads collectors sharing an interval evenly over it, e.g., four 10s
+// collectors scrape at 0s, 2.5s, 5s and 7.5s of each interval
+// Caller must hold m.mu
+func (m *Manager) assignPhases() {
+	byInterval := make(map[time.Duration][]string)
+	for key, info := range m.collectors {
+		byInterval[info.Interval] = append(byInterval[info.Interval], key)
+	}
+	for interval, keys := range byInterval {
+		sort.Strings(keys)
+		for i, key := range keys {
+			m.collectors[key].phase.Store(int64(interval) * int64(i) / int64(len(keys)))
+		}
+	}
+}
+
+// nextBase advances the base schedule of a collector by next
+// A scrape that waited for a slot or overran its interval skips the missed slots
+func nextBase(base time.Time, next time.Duration, now time.Time) time.Time {
+	base = base.Add(next)
+	if base.Before(now) {
+		return now
+	}
+	return base
+}
+
+// nextScrape returns when a collector scrapes next, at or after t
+// Spread collectors scrape at their phase of the interval; otherwise a random jitter
+// delays each scrape so that targets sharing an interval don't scrape in lockstep
+func (m *Manager) nextScrape(info *CollectorInfo, t time.Time) time.Time {
+	m.mu.RLock()
+	spread := m.spread
+	m.mu.RUnlock()
+
+	if !spread {
+		return t.Add(collectionJitter(info.Interval))
+	}
+	return nextSlot(t, info.Interval, time.Duration(info.phase.Load()))
+}
+
+// SchedulerStats returns the limits and load of the scrape scheduler
+func (m *Manager) SchedulerStats() models.SchedulerStats {
+	return m.sched.stats()
+}
@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+func TestNextSlot(t *testing.T) {
+	interval := 10 * time.Second
+	base := time.Unix(1700000000, 0) // Multiple of 10s
+
+	tests := []struct {
+		name  string
+		t     time.Time
+		phase time.Duration
+		want  time.Time
+	}{
+		{"on slot", base, 0, base},
+		{"after slot", base.Add(time.Second), 0, base.Add(interval)},
+		{"before phase", base, 2500 * time.Millisecond, base.Add(2500 * time.Millisecond)},
+		{"after phase", base.Add(3 * time.Second), 2500 * time.Millisecond, base.Add(12500 * time.Millisecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextSlot(tt.t, interval, tt.phase); !got.Equal(tt.want) {
+				t.Errorf("nextSlot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := nextSlot(base.Add(time.Second), 0, 0); !got.Equal(base.Add(time.Second)) {
+		t.Errorf("nextSlot() without interval = %v, want t", got)
+	}
+}
+
+func TestNextScrape_JitterDoesNotAccumulate(t *testing.T) {
+	m := NewManager(nil)
+	m.spread = false
+	interval := 10 * time.Second
+	info := &CollectorInfo{Interval: interval}
+
+	start := time.Unix(1700000000, 0)
+	base := start
+	for i := 1; i <= 100; i++ {
+		base = nextBase(base, interval, base)
+		due := m.nextScrape(info, base)
+		if offset := due.Sub(start) - time.Duration(i)*interval; offset < 0 || offset >= interval/10 {
+			t.Fatalf("scrape %d is %v off its schedule, want within the jitter of %v", i, offset, interval/10)
+		}
+	}
+
+	// Missed slots are skipped
+	now := base.Add(25 * time.Second)
+	if got := nextBase(base, interval, now); !got.Equal(now) {
+		t.Errorf("nextBase() behind schedule = %v, want %v", got, now)
+	}
+}
+
+func TestAssignPhases(t *testing.T) {
+	m := NewManager(nil)
+	for _, key := range []string{"a/1", "a/2", "a/3", "a/4"} {
+		m.collectors[key] = &CollectorInfo{Interval: 10 * time.Second}
+	}
+	m.collectors["b/1"] = &CollectorInfo{Interval: time.Minute}
+	m.assignPhases()
+
+	want := map[string]time.Duration{
+		"a/1": 0,
+		"a/2": 2500 * time.Millisecond,
+		"a/3": 5 * time.Second,
+		"a/4": 7500 * time.Millisecond,
+		"b/1": 0,
+	}
+	for key, phase := range want {
+		if got := time.Duration(m.collectors[key].phase.Load()); got != phase {
+			t.Errorf("phase of %s = %v, want %v", key, got, phase)
+		}
+	}
+}
+
+func TestScheduler_Acquire(t *testing.T) {
+	s := newScheduler()
+	s.configure(config.SchedulerConfig{MaxConcurrent: 2})
+
+	release1, ok1 := s.acquire(context.Background())
+	_, ok2 := s.acquire(context.Background())
+	if !ok1 || !ok2 {
+		t.Fatal("acquire() below the limit failed")
+	}
+	if stats := s.stats(); stats.Running != 2 || stats.MaxConcurrent != 2 {
+		t.Errorf("stats() = %+v, want 2 running of 2", stats)
+	}
+
+	// At capacity, acquire waits until cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, ok := s.acquire(ctx); ok {
+		t.Fatal("acquire() at capacity succeeded")
+	}
+
+	// A released slot is handed to a waiting scrape
+	done := make(chan bool)
+	go func() {
+		_, ok := s.acquire(context.Background())
+		done <- ok
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release1()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("acquire() after release failed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire() did not get the released slot")
+	}
+
+	if stats := s.stats(); stats.Delayed != 2 || stats.Waiting != 0 {
+		t.Errorf("stats() = %+v, want 2 delayed, 0 waiting", stats)
+	}
+}
+
+func TestTaskGroup_Limit(t *testing.T) {
+	g := newTaskGroup(3)
+	var running, peak atomic.Int64
+	var mu sync.Mutex
+	done := 0
+
+	for i := 0; i < 20; i++ {
+		g.Go(func() {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			mu.Lock()
+			done++
+			mu.Unlock()
+		})
+	}
+	g.Wait()
+
+	if done != 20 {
+		t.Errorf("ran %d functions, want 20", done)
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", p)
+	}
+}
+
+func TestRequestLimit(t *testing.T) {
+	if got := requestLimit(context.Background()); got != 4 {
+		t.Errorf("requestLimit() without limit = %d, want default 4", got)
+	}
+	if got := requestLimit(withRequestLimit(context.Background(), 2)); got != 2 {
+		t.Errorf("requestLimit() = %d, want 2", got)
+	}
+}
@@ -5,7 +5,13 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,13 +21,138 @@ import (
 )
 
 type Config struct {
-	Server    ServerConfig    `mapstructure:"server" yaml:"server"`
-	Storage   StorageConfig   `mapstructure:"storage" yaml:"storage"`
-	Logging   LoggingConfig   `mapstructure:"logging" yaml:"logging,omitempty"`
-	Retention RetentionConfig `mapstructure:"retention" yaml:"retention,omitempty"`
-	Alerting  AlertingConfig  `mapstructure:"alerting" yaml:"alerting,omitempty"`
-	Targets   []TargetConfig  `mapstructure:"targets" yaml:"targets"`
-	Timezone  string          `mapstructure:"timezone" yaml:"timezone,omitempty"` // e.g., "Asia/Seoul", "UTC", "Local"
+	Server         ServerConfig         `mapstructure:"server" yaml:"server"`
+	Storage        StorageConfig        `mapstructure:"storage" yaml:"storage"`
+	Logging        LoggingConfig        `mapstructure:"logging" yaml:"logging,omitempty"`
+	Retention      RetentionConfig      `mapstructure:"retention" yaml:"retention,omitempty"`
+	Backup         BackupConfig         `mapstructure:"backup" yaml:"backup,omitempty"`
+	Export         ExportConfig         `mapstructure:"export" yaml:"export,omitempty"`
+	Report         ReportConfig         `mapstructure:"report" yaml:"report,omitempty"`
+	Forecast       ForecastConfig       `mapstructure:"forecast" yaml:"forecast,omitempty"`
+	DerivedMetrics []DerivedMetricConfig `mapstructure:"derived_metrics" yaml:"derived_metrics,omitempty"`
+	Alerting       AlertingConfig       `mapstructure:"alerting" yaml:"alerting,omitempty"`
+	EventWebhooks  []EventWebhookConfig `mapstructure:"event_webhooks" yaml:"event_webhooks,omitempty"` // Lifecycle events for external automation
+	Bootstrap      BootstrapConfig      `mapstructure:"bootstrap" yaml:"bootstrap,omitempty"`
+	Discovery      DiscoveryConfig      `mapstructure:"discovery" yaml:"discovery,omitempty"`
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker" yaml:"circuit_breaker,omitempty"`
+	Scheduler      SchedulerConfig      `mapstructure:"scheduler" yaml:"scheduler,omitempty"`
+	Cluster        ClusterConfig        `mapstructure:"cluster" yaml:"cluster,omitempty"`
+	Targets        []TargetConfig       `mapstructure:"targets" yaml:"targets"`
+	Timezone       string               `mapstructure:"timezone" yaml:"timezone,omitempty"` // e.g., "Asia/Seoul", "UTC", "Local"
+}
+
+// ClusterConfig splits target collection across pondy replicas sharing one database
+// Each replica collects the targets it owns on a consistent hash ring of the live replicas
+type ClusterConfig struct {
+	Enabled           bool          `mapstructure:"enabled" yaml:"enabled"`
+	NodeID            string        `mapstructure:"node_id" yaml:"node_id,omitempty"`                       // Unique per replica (default: hostname)
+	Address           string        `mapstructure:"address" yaml:"address,omitempty"`                       // URL this replica is reachable at, shown in the cluster view
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval" yaml:"heartbeat_interval,omitempty"` // default: 10s
+	NodeTTL           time.Duration `mapstructure:"node_ttl" yaml:"node_ttl,omitempty"`                     // Replicas without a heartbeat for this long leave the ring (default: 3x heartbeat_interval)
+}
+
+// GetNodeID returns the replica ID, defaulting to the hostname
+func (c *ClusterConfig) GetNodeID() string {
+	if c.NodeID != "" {
+		return c.NodeID
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "pondy"
+}
+
+// GetHeartbeatInterval returns the heartbeat interval with default
+func (c *ClusterConfig) GetHeartbeatInterval() time.Duration {
+	if c.HeartbeatInterval <= 0 {
+		return 10 * time.Second
+	}
+	return c.HeartbeatInterval
+}
+
+// GetNodeTTL returns the time after which a silent replica leaves the ring
+func (c *ClusterConfig) GetNodeTTL() time.Duration {
+	if c.NodeTTL <= 0 {
+		return 3 * c.GetHeartbeatInterval()
+	}
+	return c.NodeTTL
+}
+
+// Validate checks the cluster settings
+func (c *ClusterConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.HeartbeatInterval < 0 || c.NodeTTL < 0 {
+		return fmt.Errorf("cluster heartbeat_interval and node_ttl must not be negative")
+	}
+	if c.GetNodeTTL() <= c.GetHeartbeatInterval() {
+		return fmt.Errorf("cluster.node_ttl must be longer than heartbeat_interval")
+	}
+	return nil
+}
+
+// CircuitBreakerConfig backs off scraping of endpoints that keep failing
+type CircuitBreakerConfig struct {
+	Enabled          *bool         `mapstructure:"enabled" yaml:"enabled,omitempty"`                     // default: true
+	FailureThreshold int           `mapstructure:"failure_threshold" yaml:"failure_threshold,omitempty"` // Consecutive failures before opening (default: 5)
+	MaxInterval      time.Duration `mapstructure:"max_interval" yaml:"max_interval,omitempty"`           // Backoff cap (default: 5m)
+}
+
+// IsEnabled returns whether the circuit breaker is enabled (default: true)
+func (c *CircuitBreakerConfig) IsEnabled() bool {
+	if c.Enabled == nil {
+		return true
+	}
+	return *c.Enabled
+}
+
+// GetFailureThreshold returns the consecutive failures before opening with default
+func (c *CircuitBreakerConfig) GetFailureThreshold() int {
+	if c.FailureThreshold <= 0 {
+		return 5
+	}
+	return c.FailureThreshold
+}
+
+// GetMaxInterval returns the backoff cap with default
+func (c *CircuitBreakerConfig) GetMaxInterval() time.Duration {
+	if c.MaxInterval <= 0 {
+		return 5 * time.Minute
+	}
+	return c.MaxInterval
+}
+
+// SchedulerConfig bounds and spreads scrapes across all collectors
+type SchedulerConfig struct {
+	MaxConcurrent     int   `mapstructure:"max_concurrent" yaml:"max_concurrent,omitempty"`           // Scrapes running at once across all targets (default: 32)
+	RequestsPerScrape int   `mapstructure:"requests_per_scrape" yaml:"requests_per_scrape,omitempty"` // Parallel HTTP requests of one actuator scrape (default: 4)
+	Spread            *bool `mapstructure:"spread" yaml:"spread,omitempty"`                           // Spread collectors sharing an interval evenly over it (default: true)
+}
+
+// GetMaxConcurrent returns the concurrent scrape limit with default
+func (s *SchedulerConfig) GetMaxConcurrent() int {
+	if s.MaxConcurrent <= 0 {
+		return 32
+	}
+	return s.MaxConcurrent
+}
+
+// GetRequestsPerScrape returns the parallel requests of a scrape with default
+func (s *SchedulerConfig) GetRequestsPerScrape() int {
+	if s.RequestsPerScrape <= 0 {
+		return 4
+	}
+	return s.RequestsPerScrape
+}
+
+// IsSpread returns whether collection is spread evenly over each interval (default: true)
+// When false, each scrape is delayed by a random jitter of up to 10% of the interval instead
+func (s *SchedulerConfig) IsSpread() bool {
+	if s.Spread == nil {
+		return true
+	}
+	return *s.Spread
 }
 
 // LoggingConfig holds logging configuration
@@ -30,182 +161,1888 @@ type LoggingConfig struct {
 	Format string `mapstructure:"format" yaml:"format,omitempty"` // text, json (default: text)
 }
 
-type RetentionConfig struct {
-	MaxAge          string `mapstructure:"max_age" yaml:"max_age,omitempty"`
-	CleanupInterval string `mapstructure:"cleanup_interval" yaml:"cleanup_interval,omitempty"`
+type RetentionConfig struct {
+	MaxAge          string       `mapstructure:"max_age" yaml:"max_age,omitempty"`
+	CleanupInterval string       `mapstructure:"cleanup_interval" yaml:"cleanup_interval,omitempty"`
+	Rollup          RollupConfig `mapstructure:"rollup" yaml:"rollup,omitempty"`
+
+	// Groups overrides max_age for the targets of a group, e.g., prod: 90d, dev: 7d
+	// A target's own retention takes precedence
+	Groups map[string]string `mapstructure:"groups" yaml:"groups,omitempty"`
+
+	// InstanceIdle marks instances without samples for this long as inactive (default: 7d)
+	InstanceIdle string `mapstructure:"instance_idle" yaml:"instance_idle,omitempty"`
+}
+
+func (r *RetentionConfig) GetMaxAge() time.Duration {
+	return parseDurationWithDays(r.MaxAge, 30*24*time.Hour)
+}
+
+// ParseMaxAge parses a retention age such as "7d" or "36h"
+func ParseMaxAge(s string) (time.Duration, error) {
+	d := parseDurationWithDays(s, -1)
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid retention '%s': use a positive duration such as 7d or 36h", s)
+	}
+	return d, nil
+}
+
+func (r *RetentionConfig) GetCleanupInterval() time.Duration {
+	return parseDurationWithDays(r.CleanupInterval, time.Hour)
+}
+
+// GetInstanceIdle returns how long an instance may go without samples before it is inactive, with default
+func (r *RetentionConfig) GetInstanceIdle() time.Duration {
+	return parseDurationWithDays(r.InstanceIdle, 7*24*time.Hour)
+}
+
+// RollupConfig controls the 1-minute and 1-hour aggregates kept beyond raw retention
+type RollupConfig struct {
+	Enabled      *bool  `mapstructure:"enabled" yaml:"enabled,omitempty"`               // default: true
+	MinuteMaxAge string `mapstructure:"minute_max_age" yaml:"minute_max_age,omitempty"` // 1-minute rollups (default: 90d)
+	HourMaxAge   string `mapstructure:"hour_max_age" yaml:"hour_max_age,omitempty"`     // 1-hour rollups (default: 365d)
+}
+
+// IsEnabled returns whether rollups are built (default: true)
+func (r *RollupConfig) IsEnabled() bool {
+	if r.Enabled == nil {
+		return true
+	}
+	return *r.Enabled
+}
+
+// GetMinuteMaxAge returns how long 1-minute rollups are kept with default
+func (r *RollupConfig) GetMinuteMaxAge() time.Duration {
+	return parseDurationWithDays(r.MinuteMaxAge, 90*24*time.Hour)
+}
+
+// GetHourMaxAge returns how long 1-hour rollups are kept with default
+func (r *RollupConfig) GetHourMaxAge() time.Duration {
+	return parseDurationWithDays(r.HourMaxAge, 365*24*time.Hour)
+}
+
+// BackupConfig holds backup settings
+type BackupConfig struct {
+	S3 S3BackupConfig `mapstructure:"s3" yaml:"s3,omitempty"`
+}
+
+// S3BackupConfig uploads backups to S3-compatible object storage (AWS S3, MinIO, GCS interoperability)
+type S3BackupConfig struct {
+	Enabled   bool          `mapstructure:"enabled" yaml:"enabled"`
+	Endpoint  string        `mapstructure:"endpoint" yaml:"endpoint,omitempty"`     // e.g., http://minio:9000 (default: https://s3.<region>.amazonaws.com)
+	Region    string        `mapstructure:"region" yaml:"region,omitempty"`         // default: us-east-1
+	Bucket    string        `mapstructure:"bucket" yaml:"bucket"`
+	Prefix    string        `mapstructure:"prefix" yaml:"prefix,omitempty"`         // Object key prefix (default: pondy/)
+	AccessKey string        `mapstructure:"access_key" yaml:"access_key,omitempty"` // default: $AWS_ACCESS_KEY_ID
+	SecretKey string        `mapstructure:"secret_key" yaml:"secret_key,omitempty"` // default: $AWS_SECRET_ACCESS_KEY
+	PathStyle bool          `mapstructure:"path_style" yaml:"path_style,omitempty"` // Use endpoint/bucket/key URLs (required by MinIO)
+	Interval  time.Duration `mapstructure:"interval" yaml:"interval,omitempty"`     // Scheduled backup interval (0 disables)
+	Keep      int           `mapstructure:"keep" yaml:"keep,omitempty"`             // Remote backups kept by scheduled runs (0 keeps all)
+}
+
+// GetRegion returns the signing region with default
+func (s *S3BackupConfig) GetRegion() string {
+	if s.Region == "" {
+		return "us-east-1"
+	}
+	return s.Region
+}
+
+// GetEndpoint returns the service endpoint with default
+func (s *S3BackupConfig) GetEndpoint() string {
+	if s.Endpoint == "" {
+		return "https://s3." + s.GetRegion() + ".amazonaws.com"
+	}
+	return strings.TrimSuffix(s.Endpoint, "/")
+}
+
+// GetPrefix returns the object key prefix with default
+func (s *S3BackupConfig) GetPrefix() string {
+	if s.Prefix == "" {
+		return "pondy/"
+	}
+	if !strings.HasSuffix(s.Prefix, "/") {
+		return s.Prefix + "/"
+	}
+	return s.Prefix
+}
+
+// GetAccessKey returns the access key, falling back to $AWS_ACCESS_KEY_ID
+func (s *S3BackupConfig) GetAccessKey() string {
+	if s.AccessKey == "" {
+		return os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	return s.AccessKey
+}
+
+// GetSecretKey returns the secret key, falling back to $AWS_SECRET_ACCESS_KEY
+func (s *S3BackupConfig) GetSecretKey() string {
+	if s.SecretKey == "" {
+		return os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	return s.SecretKey
+}
+
+// Validate checks required S3 settings when enabled
+func (s *S3BackupConfig) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if s.Bucket == "" {
+		return fmt.Errorf("backup.s3.bucket is required")
+	}
+	if s.GetAccessKey() == "" || s.GetSecretKey() == "" {
+		return fmt.Errorf("backup.s3 credentials are required")
+	}
+	if s.Interval < 0 || s.Keep < 0 {
+		return fmt.Errorf("backup.s3 interval and keep must not be negative")
+	}
+	return nil
+}
+
+// Lifecycle event types sent to event webhooks
+const (
+	EventTargetCreated   = "target.created"
+	EventTargetDeleted   = "target.deleted"
+	EventConfigReloaded  = "config.reloaded"
+	EventBackupCompleted = "backup.completed"
+	EventCollectorDown   = "collector.down"
+	EventCollectorUp     = "collector.up"
+)
+
+// EventTypes lists the lifecycle event types
+var EventTypes = []string{
+	EventTargetCreated, EventTargetDeleted, EventConfigReloaded,
+	EventBackupCompleted, EventCollectorDown, EventCollectorUp,
+}
+
+// EventWebhookConfig sends pondy lifecycle events to an HTTP endpoint
+type EventWebhookConfig struct {
+	Name    string            `mapstructure:"name" yaml:"name"`
+	URL     string            `mapstructure:"url" yaml:"url"`
+	Events  []string          `mapstructure:"events" yaml:"events,omitempty"` // Event types to send (empty = all)
+	Headers map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`
+	Secret  string            `mapstructure:"secret" yaml:"secret,omitempty"` // Signs the body with HMAC-SHA256 in X-Pondy-Signature
+}
+
+// Wants returns whether the webhook subscribes to the event type
+func (w *EventWebhookConfig) Wants(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// Validate checks the URL and event types
+func (w *EventWebhookConfig) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("event_webhooks: name is required")
+	}
+	if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") {
+		return fmt.Errorf("event_webhooks %s: url must start with http:// or https://", w.Name)
+	}
+	for _, e := range w.Events {
+		if !slices.Contains(EventTypes, e) {
+			return fmt.Errorf("event_webhooks %s: unknown event '%s' (valid: %s)", w.Name, e, strings.Join(EventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// ExportConfig holds settings for pushing collected metrics to external systems
+type ExportConfig struct {
+	OTLP   OTLPExportConfig   `mapstructure:"otlp" yaml:"otlp,omitempty"`
+	Influx InfluxExportConfig `mapstructure:"influxdb" yaml:"influxdb,omitempty"`
+}
+
+// OTLPExportConfig pushes the latest samples to an OpenTelemetry collector over OTLP/HTTP (JSON encoding)
+type OTLPExportConfig struct {
+	Enabled            bool              `mapstructure:"enabled" yaml:"enabled"`
+	Endpoint           string            `mapstructure:"endpoint" yaml:"endpoint,omitempty"`                       // e.g., http://otel-collector:4318 (/v1/metrics is appended)
+	Interval           time.Duration     `mapstructure:"interval" yaml:"interval,omitempty"`                       // Push interval (default: 30s)
+	Timeout            time.Duration     `mapstructure:"timeout" yaml:"timeout,omitempty"`                         // Request timeout (default: 10s)
+	Headers            map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`                         // e.g., Authorization
+	ResourceAttributes map[string]string `mapstructure:"resource_attributes" yaml:"resource_attributes,omitempty"` // Added to every resource, e.g., deployment.environment
+}
+
+// GetInterval returns the push interval with default
+func (o *OTLPExportConfig) GetInterval() time.Duration {
+	if o.Interval <= 0 {
+		return 30 * time.Second
+	}
+	return o.Interval
+}
+
+// GetTimeout returns the request timeout with default
+func (o *OTLPExportConfig) GetTimeout() time.Duration {
+	if o.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return o.Timeout
+}
+
+// GetURL returns the OTLP/HTTP metrics URL, appending the default path to a bare endpoint
+func (o *OTLPExportConfig) GetURL() string {
+	endpoint := strings.TrimSuffix(o.Endpoint, "/")
+	if strings.HasSuffix(endpoint, "/v1/metrics") {
+		return endpoint
+	}
+	return endpoint + "/v1/metrics"
+}
+
+// Validate checks the OTLP export settings
+func (o *OTLPExportConfig) Validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.Endpoint == "" {
+		return fmt.Errorf("export.otlp.endpoint is required")
+	}
+	u, err := url.Parse(o.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("export.otlp.endpoint must be an http or https URL (OTLP/gRPC is not supported)")
+	}
+	if o.Interval < 0 || o.Timeout < 0 {
+		return fmt.Errorf("export.otlp interval and timeout must not be negative")
+	}
+	return nil
+}
+
+// InfluxExportConfig mirrors every saved sample to InfluxDB as line protocol
+// SQLite stays the primary store; the mirror is buffered and retried on failure
+type InfluxExportConfig struct {
+	Enabled       bool          `mapstructure:"enabled" yaml:"enabled"`
+	URL           string        `mapstructure:"url" yaml:"url,omitempty"`                       // e.g., http://influxdb:8086
+	Bucket        string        `mapstructure:"bucket" yaml:"bucket,omitempty"`                 // InfluxDB 2.x bucket (uses /api/v2/write)
+	Org           string        `mapstructure:"org" yaml:"org,omitempty"`                       // InfluxDB 2.x organization
+	Token         string        `mapstructure:"token" yaml:"token,omitempty"`                   // InfluxDB 2.x API token
+	Database      string        `mapstructure:"database" yaml:"database,omitempty"`             // InfluxDB 1.x database (uses /write)
+	Username      string        `mapstructure:"username" yaml:"username,omitempty"`             // InfluxDB 1.x user
+	Password      string        `mapstructure:"password" yaml:"password,omitempty"`             // InfluxDB 1.x password
+	Measurement   string        `mapstructure:"measurement" yaml:"measurement,omitempty"`       // default: pondy_pool
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size,omitempty"`         // Lines per write request (default: 500)
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval,omitempty"` // Maximum delay before writing (default: 5s)
+	BufferSize    int           `mapstructure:"buffer_size" yaml:"buffer_size,omitempty"`       // Buffered samples before the oldest are dropped (default: 100000)
+	Timeout       time.Duration `mapstructure:"timeout" yaml:"timeout,omitempty"`               // Request timeout (default: 10s)
+}
+
+// GetMeasurement returns the measurement name with default
+func (i *InfluxExportConfig) GetMeasurement() string {
+	if i.Measurement == "" {
+		return "pondy_pool"
+	}
+	return i.Measurement
+}
+
+// GetBatchSize returns the lines per write request with default
+func (i *InfluxExportConfig) GetBatchSize() int {
+	if i.BatchSize <= 0 {
+		return 500
+	}
+	return i.BatchSize
+}
+
+// GetFlushInterval returns the maximum write delay with default
+func (i *InfluxExportConfig) GetFlushInterval() time.Duration {
+	if i.FlushInterval <= 0 {
+		return 5 * time.Second
+	}
+	return i.FlushInterval
+}
+
+// GetBufferSize returns the buffer capacity with default
+func (i *InfluxExportConfig) GetBufferSize() int {
+	if i.BufferSize <= 0 {
+		return 100000
+	}
+	return i.BufferSize
+}
+
+// GetTimeout returns the request timeout with default
+func (i *InfluxExportConfig) GetTimeout() time.Duration {
+	if i.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return i.Timeout
+}
+
+// GetWriteURL returns the write API URL for the configured InfluxDB version
+func (i *InfluxExportConfig) GetWriteURL() string {
+	base := strings.TrimSuffix(i.URL, "/")
+	q := url.Values{"precision": {"ns"}}
+	if i.Bucket != "" {
+		q.Set("bucket", i.Bucket)
+		if i.Org != "" {
+			q.Set("org", i.Org)
+		}
+		return base + "/api/v2/write?" + q.Encode()
+	}
+	q.Set("db", i.Database)
+	return base + "/write?" + q.Encode()
+}
+
+// Validate checks the InfluxDB export settings
+func (i *InfluxExportConfig) Validate() error {
+	if !i.Enabled {
+		return nil
+	}
+	u, err := url.Parse(i.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("export.influxdb.url must be an http or https URL")
+	}
+	if i.Bucket == "" && i.Database == "" {
+		return fmt.Errorf("export.influxdb requires bucket (2.x) or database (1.x)")
+	}
+	if i.Bucket != "" && i.Database != "" {
+		return fmt.Errorf("export.influxdb bucket and database are mutually exclusive")
+	}
+	if i.BatchSize < 0 || i.BufferSize < 0 || i.FlushInterval < 0 || i.Timeout < 0 {
+		return fmt.Errorf("export.influxdb sizes and intervals must not be negative")
+	}
+	return nil
+}
+
+// ReportConfig holds report rendering settings
+type ReportConfig struct {
+	ChromePath string `mapstructure:"chrome_path" yaml:"chrome_path,omitempty"` // Browser used for PDF export (default: chromium/chrome in PATH)
+	Workers    int    `mapstructure:"workers" yaml:"workers,omitempty"`         // Targets of a combined report analyzed at once (default: 4)
+}
+
+// GetWorkers returns the combined report concurrency with default
+func (r *ReportConfig) GetWorkers() int {
+	if r.Workers <= 0 {
+		return 4
+	}
+	return r.Workers
+}
+
+// ForecastConfig holds capacity forecasting thresholds
+type ForecastConfig struct {
+	PoolThreshold float64 `mapstructure:"pool_threshold" yaml:"pool_threshold,omitempty"` // Pool usage % treated as saturation (default: 90)
+	HeapThreshold float64 `mapstructure:"heap_threshold" yaml:"heap_threshold,omitempty"` // Heap usage % treated as exhaustion (default: 90)
+	Horizon       string  `mapstructure:"horizon" yaml:"horizon,omitempty"`               // How far ahead to predict (default: 30d)
+}
+
+// GetPoolThreshold returns the pool saturation threshold with default
+func (f *ForecastConfig) GetPoolThreshold() float64 {
+	if f.PoolThreshold <= 0 || f.PoolThreshold > 100 {
+		return 90
+	}
+	return f.PoolThreshold
+}
+
+// GetHeapThreshold returns the heap exhaustion threshold with default
+func (f *ForecastConfig) GetHeapThreshold() float64 {
+	if f.HeapThreshold <= 0 || f.HeapThreshold > 100 {
+		return 90
+	}
+	return f.HeapThreshold
+}
+
+// GetHorizon returns the forecast horizon with default
+func (f *ForecastConfig) GetHorizon() time.Duration {
+	return parseDurationWithDays(f.Horizon, 30*24*time.Hour)
+}
+
+// DerivedMetricConfig defines a series computed from collected metrics
+// e.g., name: gc_rate, expr: "delta(gc_count) / interval"
+type DerivedMetricConfig struct {
+	Name        string `mapstructure:"name" yaml:"name"`
+	Expr        string `mapstructure:"expr" yaml:"expr"`
+	Unit        string `mapstructure:"unit" yaml:"unit,omitempty"` // Display unit, e.g., "%", "/s"
+	Description string `mapstructure:"description" yaml:"description,omitempty"`
+}
+
+// Validate checks the derived metric name
+// The expression is checked when it is compiled
+func (d *DerivedMetricConfig) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("derived metric name is required")
+	}
+	for i, c := range d.Name {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (i > 0 && c >= '0' && c <= '9')) {
+			return fmt.Errorf("invalid derived metric name '%s': use lowercase letters, digits and underscores", d.Name)
+		}
+	}
+	if strings.TrimSpace(d.Expr) == "" {
+		return fmt.Errorf("derived metric '%s': expr is required", d.Name)
+	}
+	return nil
+}
+
+// DerivedMetricNames returns the names of the configured derived metrics
+func (c *Config) DerivedMetricNames() []string {
+	names := make([]string, 0, len(c.DerivedMetrics))
+	for _, d := range c.DerivedMetrics {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// BootstrapConfig holds cold-start history import settings
+type BootstrapConfig struct {
+	Prometheus PrometheusImportConfig `mapstructure:"prometheus" yaml:"prometheus,omitempty"`
+}
+
+// PrometheusImportConfig configures backfilling new targets from Prometheus
+type PrometheusImportConfig struct {
+	Enabled  bool              `mapstructure:"enabled" yaml:"enabled"`
+	URL      string            `mapstructure:"url" yaml:"url,omitempty"`           // e.g., http://prometheus:9090
+	Lookback string            `mapstructure:"lookback" yaml:"lookback,omitempty"` // e.g., 24h, 7d (default: 24h)
+	Step     time.Duration     `mapstructure:"step" yaml:"step,omitempty"`         // Range query resolution (default: 30s)
+	Headers  map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`   // e.g., Authorization
+}
+
+// GetLookback returns how far back to import with default
+func (p *PrometheusImportConfig) GetLookback() time.Duration {
+	return parseDurationWithDays(p.Lookback, 24*time.Hour)
+}
+
+// GetStep returns the range query step with default
+func (p *PrometheusImportConfig) GetStep() time.Duration {
+	if p.Step <= 0 {
+		return 30 * time.Second
+	}
+	return p.Step
+}
+
+// DiscoveryConfig holds service discovery settings
+type DiscoveryConfig struct {
+	Kubernetes      KubernetesDiscoveryConfig `mapstructure:"kubernetes" yaml:"kubernetes,omitempty"`
+	Eureka          EurekaConfig              `mapstructure:"eureka" yaml:"eureka,omitempty"`
+	Consul          ConsulConfig              `mapstructure:"consul" yaml:"consul,omitempty"`
+	RefreshInterval time.Duration             `mapstructure:"refresh_interval" yaml:"refresh_interval,omitempty"` // Registry lookup interval (default: 30s)
+}
+
+// GetRefreshInterval returns the service registry lookup interval with default
+func (d *DiscoveryConfig) GetRefreshInterval() time.Duration {
+	if d.RefreshInterval <= 0 {
+		return 30 * time.Second
+	}
+	return d.RefreshInterval
+}
+
+// EurekaConfig holds the Spring Cloud Eureka server settings
+type EurekaConfig struct {
+	URL string `mapstructure:"url" yaml:"url,omitempty"` // e.g., http://eureka:8761/eureka
+}
+
+// ConsulConfig holds the Consul agent settings
+type ConsulConfig struct {
+	URL        string `mapstructure:"url" yaml:"url,omitempty"` // e.g., http://consul:8500
+	Token      string `mapstructure:"token" yaml:"token,omitempty"`
+	Datacenter string `mapstructure:"datacenter" yaml:"datacenter,omitempty"`
+}
+
+// Supported service registry types for target discovery
+const (
+	RegistryEureka = "eureka"
+	RegistryConsul = "consul"
+	RegistryDNS    = "dns"
+)
+
+// DNS record types for dns discovery
+const (
+	DNSRecordA   = "a"   // A/AAAA records, port from config
+	DNSRecordSRV = "srv" // SRV records, port from DNS
+)
+
+// TargetDiscoveryConfig resolves a target's instances from a service registry
+type TargetDiscoveryConfig struct {
+	Type    string `mapstructure:"type" yaml:"type" json:"type"`                           // eureka, consul, dns
+	Service string `mapstructure:"service" yaml:"service" json:"service"`                  // Registered service name or DNS name
+	Path    string `mapstructure:"path" yaml:"path,omitempty" json:"path,omitempty"`       // Metrics path (default: /actuator/metrics)
+	Scheme  string `mapstructure:"scheme" yaml:"scheme,omitempty" json:"scheme,omitempty"` // http or https (default: http)
+	Record  string `mapstructure:"record" yaml:"record,omitempty" json:"record,omitempty"` // dns only: a or srv (default: a)
+	Port    int    `mapstructure:"port" yaml:"port,omitempty" json:"port,omitempty"`       // dns only: metrics port for A records
+}
+
+// GetPath returns the metrics path with default
+func (t *TargetDiscoveryConfig) GetPath() string {
+	if t.Path == "" {
+		return "/actuator/metrics"
+	}
+	if !strings.HasPrefix(t.Path, "/") {
+		return "/" + t.Path
+	}
+	return t.Path
+}
+
+// GetScheme returns the URL scheme with default
+func (t *TargetDiscoveryConfig) GetScheme() string {
+	if t.Scheme == "" {
+		return "http"
+	}
+	return t.Scheme
+}
+
+// GetRecord returns the DNS record type with default
+func (t *TargetDiscoveryConfig) GetRecord() string {
+	if t.Record == "" {
+		return DNSRecordA
+	}
+	return strings.ToLower(t.Record)
+}
+
+// Validate checks the registry type and service name
+func (t *TargetDiscoveryConfig) Validate() error {
+	if t.Type != RegistryEureka && t.Type != RegistryConsul && t.Type != RegistryDNS {
+		return fmt.Errorf("invalid discovery type '%s': use eureka, consul, or dns", t.Type)
+	}
+	if t.Service == "" {
+		return fmt.Errorf("discovery service is required")
+	}
+	if t.Scheme != "" && t.Scheme != "http" && t.Scheme != "https" {
+		return fmt.Errorf("discovery scheme must be http or https")
+	}
+	if t.Type == RegistryDNS {
+		switch t.GetRecord() {
+		case DNSRecordA:
+			if t.Port <= 0 || t.Port > 65535 {
+				return fmt.Errorf("discovery port is required for dns A records")
+			}
+		case DNSRecordSRV:
+		default:
+			return fmt.Errorf("invalid dns record '%s': use a or srv", t.Record)
+		}
+	}
+	return nil
+}
+
+// KubernetesDiscoveryConfig discovers target instances from Kubernetes pods
+// Discovered instances are collected but never written to the config file
+type KubernetesDiscoveryConfig struct {
+	Enabled         bool          `mapstructure:"enabled" yaml:"enabled"`
+	Kubeconfig      string        `mapstructure:"kubeconfig" yaml:"kubeconfig,omitempty"`             // Empty uses in-cluster service account
+	Namespace       string        `mapstructure:"namespace" yaml:"namespace,omitempty"`               // Empty watches all namespaces
+	LabelSelector   string        `mapstructure:"label_selector" yaml:"label_selector,omitempty"`     // default: pondy.io/scrape=true
+	RefreshInterval time.Duration `mapstructure:"refresh_interval" yaml:"refresh_interval,omitempty"` // Pod list interval (default: 30s)
+	Interval        time.Duration `mapstructure:"interval" yaml:"interval,omitempty"`                 // Collection interval (default: 10s)
+	Port            int           `mapstructure:"port" yaml:"port,omitempty"`                         // Default port when not annotated (default: 8080)
+	Path            string        `mapstructure:"path" yaml:"path,omitempty"`                         // Default path when not annotated (default: /actuator/metrics)
+	Group           string        `mapstructure:"group" yaml:"group,omitempty"`                       // Group for discovered targets
+}
+
+// GetLabelSelector returns the pod label selector with default
+func (k *KubernetesDiscoveryConfig) GetLabelSelector() string {
+	if k.LabelSelector == "" {
+		return "pondy.io/scrape=true"
+	}
+	return k.LabelSelector
+}
+
+// GetRefreshInterval returns the pod list interval with default
+func (k *KubernetesDiscoveryConfig) GetRefreshInterval() time.Duration {
+	if k.RefreshInterval <= 0 {
+		return 30 * time.Second
+	}
+	return k.RefreshInterval
+}
+
+// DefaultDiscoveryInterval is the collection interval for discovered targets
+const DefaultDiscoveryInterval = 10 * time.Second
+
+// GetInterval returns the collection interval for discovered targets with default
+func (k *KubernetesDiscoveryConfig) GetInterval() time.Duration {
+	if k.Interval <= 0 {
+		return DefaultDiscoveryInterval
+	}
+	return k.Interval
+}
+
+// GetPort returns the default metrics port with default
+func (k *KubernetesDiscoveryConfig) GetPort() int {
+	if k.Port <= 0 {
+		return 8080
+	}
+	return k.Port
+}
+
+// GetPath returns the default metrics path with default
+func (k *KubernetesDiscoveryConfig) GetPath() string {
+	if k.Path == "" {
+		return "/actuator/metrics"
+	}
+	return k.Path
+}
+
+// AlertingConfig holds alerting configuration
+type AlertingConfig struct {
+	Enabled        bool           `mapstructure:"enabled" yaml:"enabled"`
+	CheckInterval  time.Duration  `mapstructure:"check_interval" yaml:"check_interval,omitempty"`
+	Cooldown       time.Duration  `mapstructure:"cooldown" yaml:"cooldown,omitempty"`
+	RepeatInterval time.Duration  `mapstructure:"repeat_interval" yaml:"repeat_interval,omitempty"` // Re-notify while fired (0 = disabled)
+	Grouping       GroupingConfig `mapstructure:"grouping" yaml:"grouping,omitempty"`
+	Flapping       FlappingConfig `mapstructure:"flapping" yaml:"flapping,omitempty"`
+	Rules          []AlertRule    `mapstructure:"rules" yaml:"rules,omitempty"`
+	Channels       ChannelsConfig `mapstructure:"channels" yaml:"channels,omitempty"`
+
+	// FireAfterMaintenance fires alerts suppressed by a maintenance window once it ends
+	// if their condition still holds; when false they stay suppressed until it clears (default: true)
+	FireAfterMaintenance *bool `mapstructure:"fire_after_maintenance" yaml:"fire_after_maintenance,omitempty"`
+
+	// InlineCheck evaluates rules on each sample as soon as it is collected; every check_interval
+	// the samples stored since the last check are evaluated too, skipping those already checked (default: true)
+	InlineCheck *bool `mapstructure:"inline_check" yaml:"inline_check,omitempty"`
+
+	// SyncRules materializes condition rules into the database as config-managed rules, so config
+	// and API rules are listed and evaluated as one set; the API can't edit them (default: false)
+	SyncRules bool `mapstructure:"sync_rules" yaml:"sync_rules,omitempty"`
+
+	// ExternalURL is the address the dashboard is reached at, e.g., "https://pondy.example.com";
+	// notifications link to the target, instance and alert views under it (empty = no links)
+	ExternalURL string `mapstructure:"external_url" yaml:"external_url,omitempty"`
+}
+
+// ValidateExternalURL checks that the external URL is empty or an http or https URL
+func (a *AlertingConfig) ValidateExternalURL() error {
+	if a.ExternalURL == "" {
+		return nil
+	}
+	u, err := url.Parse(a.ExternalURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("alerting.external_url must be an http or https URL")
+	}
+	return nil
+}
+
+// Valid group-by keys for alert grouping
+const (
+	GroupByRule     = "rule"
+	GroupByTarget   = "target"
+	GroupBySeverity = "severity"
+)
+
+// GroupingConfig batches alerts fired within a window into one digest notification
+type GroupingConfig struct {
+	Enabled bool          `mapstructure:"enabled" yaml:"enabled"`
+	Window  time.Duration `mapstructure:"window" yaml:"window,omitempty"` // Wait time before sending a group (default: 30s)
+	By      []string      `mapstructure:"by" yaml:"by,omitempty"`         // rule, target, severity (default: rule, target)
+}
+
+// GetWindow returns the grouping window with default
+func (g *GroupingConfig) GetWindow() time.Duration {
+	if g.Window <= 0 {
+		return 30 * time.Second
+	}
+	return g.Window
+}
+
+// GetBy returns the group-by keys with default
+func (g *GroupingConfig) GetBy() []string {
+	if len(g.By) == 0 {
+		return []string{GroupByRule, GroupByTarget}
+	}
+	return g.By
+}
+
+// Validate checks the group-by keys
+func (g *GroupingConfig) Validate() error {
+	for _, key := range g.By {
+		switch key {
+		case GroupByRule, GroupByTarget, GroupBySeverity:
+		default:
+			return fmt.Errorf("invalid grouping key '%s': use rule, target, or severity", key)
+		}
+	}
+	return nil
+}
+
+// FlappingConfig holds back the notifications of alerts that keep firing and resolving
+type FlappingConfig struct {
+	Enabled bool          `mapstructure:"enabled" yaml:"enabled"`
+	Changes int           `mapstructure:"changes" yaml:"changes,omitempty"` // Fires and resolutions within the window that count as flapping (default: 4)
+	Window  time.Duration `mapstructure:"window" yaml:"window,omitempty"`   // How long changes are counted; the state settles after a window without changes (default: 15m)
+}
+
+// GetChanges returns the flapping threshold with default
+func (f *FlappingConfig) GetChanges() int {
+	if f.Changes < 2 {
+		return 4
+	}
+	return f.Changes
+}
+
+// GetWindow returns the flapping window with default
+func (f *FlappingConfig) GetWindow() time.Duration {
+	if f.Window <= 0 {
+		return 15 * time.Minute
+	}
+	return f.Window
+}
+
+// GetCheckInterval returns the check interval with default
+func (a *AlertingConfig) GetCheckInterval() time.Duration {
+	if a.CheckInterval <= 0 {
+		return 30 * time.Second
+	}
+	return a.CheckInterval
+}
+
+// GetCooldown returns the cooldown with default
+func (a *AlertingConfig) GetCooldown() time.Duration {
+	if a.Cooldown <= 0 {
+		return 5 * time.Minute
+	}
+	return a.Cooldown
+}
+
+// GetRepeatInterval returns the repeat interval for a rule
+// A rule-level interval overrides the global one; 0 means no repeat
+func (a *AlertingConfig) GetRepeatInterval(ruleName string) time.Duration {
+	for _, r := range a.Rules {
+		if r.Name == ruleName && r.RepeatInterval > 0 {
+			return r.RepeatInterval
+		}
+	}
+	if a.RepeatInterval <= 0 {
+		return 0
+	}
+	return a.RepeatInterval
+}
+
+// ShouldFireAfterMaintenance returns whether suppressed alerts fire when their window ends (default: true)
+func (a *AlertingConfig) ShouldFireAfterMaintenance() bool {
+	if a.FireAfterMaintenance == nil {
+		return true
+	}
+	return *a.FireAfterMaintenance
+}
+
+// IsInlineCheck reports whether samples are evaluated as they are collected
+func (a *AlertingConfig) IsInlineCheck() bool {
+	return a.InlineCheck == nil || *a.InlineCheck
+}
+
+// AlertRule defines an alerting rule
+type AlertRule struct {
+	Name           string        `mapstructure:"name" yaml:"name"`
+	Condition      string        `mapstructure:"condition" yaml:"condition"`                       // e.g., "usage > 80", "pending > 5"
+	Severity       string        `mapstructure:"severity" yaml:"severity"`                         // info, warning, critical
+	Message        string        `mapstructure:"message" yaml:"message,omitempty"`                 // Template message
+	Enabled        *bool         `mapstructure:"enabled" yaml:"enabled,omitempty"`                 // Default true if nil
+	RepeatInterval time.Duration `mapstructure:"repeat_interval" yaml:"repeat_interval,omitempty"` // Overrides global repeat_interval
+	Channels       []string      `mapstructure:"channels" yaml:"channels,omitempty"`               // Channels to notify (empty = all)
+
+	// ResolveCondition resolves the rule's alerts once it holds, e.g., "usage < 80" for a rule firing
+	// at "usage > 90", so values around the threshold don't fire and resolve it repeatedly;
+	// empty resolves them once the condition no longer holds
+	ResolveCondition string `mapstructure:"resolve_condition" yaml:"resolve_condition,omitempty"`
+
+	// EscalateCondition raises an active alert of the rule to EscalateSeverity once it holds,
+	// e.g., "usage >= 100" for a warning at "usage > 80", and notifies the channels again
+	EscalateCondition string `mapstructure:"escalate_condition" yaml:"escalate_condition,omitempty"`
+	EscalateSeverity  string `mapstructure:"escalate_severity" yaml:"escalate_severity,omitempty"` // Default: critical
+
+	// Labels limits the rule to targets having all of these labels (default: all targets)
+	Labels map[string]string `mapstructure:"labels" yaml:"labels,omitempty"`
+
+	// Targets and Groups limit the rule to these targets or the targets of these groups (default: all targets)
+	Targets []string `mapstructure:"targets" yaml:"targets,omitempty"`
+	Groups  []string `mapstructure:"groups" yaml:"groups,omitempty"`
+
+	// Type is condition (default), anomaly, leak or nodata; these rules have no condition.
+	// Anomaly and leak rules fire when the detector's risk level over a sliding window is high enough,
+	// nodata rules when a target or instance stops producing samples
+	Type    string             `mapstructure:"type" yaml:"type,omitempty"`
+	Anomaly *AnomalyRuleConfig `mapstructure:"anomaly" yaml:"anomaly,omitempty"`
+	Leak    *LeakRuleConfig    `mapstructure:"leak" yaml:"leak,omitempty"`
+	NoData  *NoDataRuleConfig  `mapstructure:"nodata" yaml:"nodata,omitempty"`
+}
+
+// Alert rule types
+const (
+	AlertRuleTypeCondition = "condition"
+	AlertRuleTypeAnomaly   = "anomaly"
+	AlertRuleTypeLeak      = "leak"
+	AlertRuleTypeNoData    = "nodata"
+)
+
+// AppliesToTarget returns whether the rule's targets, groups and labels select the target
+func (r *AlertRule) AppliesToTarget(target, group string, labels map[string]string) bool {
+	if len(r.Targets) > 0 && !slices.Contains(r.Targets, target) {
+		return false
+	}
+	if len(r.Groups) > 0 && !slices.Contains(r.Groups, group) {
+		return false
+	}
+	return MatchLabels(r.Labels, labels)
+}
+
+// IsEnabled returns whether the rule is enabled
+func (r *AlertRule) IsEnabled() bool {
+	if r.Enabled == nil {
+		return true
+	}
+	return *r.Enabled
+}
+
+// IsAnomaly returns whether the rule is evaluated by the anomaly detector instead of a condition
+func (r *AlertRule) IsAnomaly() bool {
+	return r.Type == AlertRuleTypeAnomaly
+}
+
+// IsLeak returns whether the rule is evaluated by the leak detector instead of a condition
+func (r *AlertRule) IsLeak() bool {
+	return r.Type == AlertRuleTypeLeak
+}
+
+// IsNoData returns whether the rule fires on missing samples instead of a condition
+func (r *AlertRule) IsNoData() bool {
+	return r.Type == AlertRuleTypeNoData
+}
+
+// GetEscalateSeverity returns the severity escalated alerts are raised to with default
+func (r *AlertRule) GetEscalateSeverity() string {
+	if r.EscalateSeverity == "" {
+		return "critical"
+	}
+	return r.EscalateSeverity
+}
+
+// IsCondition returns whether the rule is evaluated on each sample by its condition
+func (r *AlertRule) IsCondition() bool {
+	return !r.IsAnomaly() && !r.IsLeak() && !r.IsNoData()
+}
+
+// AnomalyRuleConfig holds the detector settings of an anomaly rule
+type AnomalyRuleConfig struct {
+	Window      time.Duration `mapstructure:"window" yaml:"window,omitempty"`           // Sliding window analyzed on each check (default: 1h)
+	RiskLevel   string        `mapstructure:"risk_level" yaml:"risk_level,omitempty"`   // Risk level that fires: elevated or high (default: elevated)
+	Algorithm   string        `mapstructure:"algorithm" yaml:"algorithm,omitempty"`     // Overrides the target's anomaly algorithm
+	Sensitivity string        `mapstructure:"sensitivity" yaml:"sensitivity,omitempty"` // Overrides the target's anomaly sensitivity
+	Targets     []string      `mapstructure:"targets" yaml:"targets,omitempty"`         // Targets checked (default: all)
+}
+
+// GetWindow returns the analyzed window with default
+func (a *AnomalyRuleConfig) GetWindow() time.Duration {
+	if a == nil || a.Window <= 0 {
+		return time.Hour
+	}
+	return a.Window
+}
+
+// GetRiskLevel returns the firing risk level with default
+func (a *AnomalyRuleConfig) GetRiskLevel() string {
+	if a == nil || a.RiskLevel == "" {
+		return "elevated"
+	}
+	return a.RiskLevel
+}
+
+// AppliesTo returns whether the rule checks the target
+func (a *AnomalyRuleConfig) AppliesTo(target string) bool {
+	if a == nil || len(a.Targets) == 0 {
+		return true
+	}
+	for _, t := range a.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the anomaly rule settings
+func (a *AnomalyRuleConfig) Validate() error {
+	switch a.GetRiskLevel() {
+	case "elevated", "high":
+	default:
+		return fmt.Errorf("anomaly: risk_level must be elevated or high")
+	}
+	if a == nil {
+		return nil
+	}
+	overrides := AnomalyConfig{Algorithm: a.Algorithm, Sensitivity: a.Sensitivity}
+	return overrides.Validate()
+}
+
+// LeakRuleConfig holds the leak detector settings of a leak rule
+type LeakRuleConfig struct {
+	Interval  time.Duration `mapstructure:"interval" yaml:"interval,omitempty"`     // Time between checks (default: 5m)
+	Window    time.Duration `mapstructure:"window" yaml:"window,omitempty"`         // Sliding window analyzed on each check (default: 1h)
+	RiskLevel string        `mapstructure:"risk_level" yaml:"risk_level,omitempty"` // Risk level that fires: medium or high (default: medium)
+	Targets   []string      `mapstructure:"targets" yaml:"targets,omitempty"`       // Targets checked (default: all)
+}
+
+// GetInterval returns the time between checks with default
+func (l *LeakRuleConfig) GetInterval() time.Duration {
+	if l == nil || l.Interval <= 0 {
+		return 5 * time.Minute
+	}
+	return l.Interval
+}
+
+// GetWindow returns the analyzed window with default
+func (l *LeakRuleConfig) GetWindow() time.Duration {
+	if l == nil || l.Window <= 0 {
+		return time.Hour
+	}
+	return l.Window
+}
+
+// GetRiskLevel returns the firing risk level with default
+func (l *LeakRuleConfig) GetRiskLevel() string {
+	if l == nil || l.RiskLevel == "" {
+		return "medium"
+	}
+	return l.RiskLevel
+}
+
+// AppliesTo returns whether the rule checks the target
+func (l *LeakRuleConfig) AppliesTo(target string) bool {
+	if l == nil || len(l.Targets) == 0 {
+		return true
+	}
+	for _, t := range l.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// NoDataRuleConfig holds the settings of a nodata rule
+type NoDataRuleConfig struct {
+	Intervals int      `mapstructure:"intervals" yaml:"intervals,omitempty"` // Missed collection intervals before firing (default: 3)
+	Targets   []string `mapstructure:"targets" yaml:"targets,omitempty"`     // Targets checked (default: all)
+}
+
+// GetIntervals returns the missed collection intervals before firing with default
+func (n *NoDataRuleConfig) GetIntervals() int {
+	if n == nil || n.Intervals <= 0 {
+		return 3
+	}
+	return n.Intervals
+}
+
+// AppliesTo returns whether the rule checks the target
+func (n *NoDataRuleConfig) AppliesTo(target string) bool {
+	if n == nil || len(n.Targets) == 0 {
+		return true
+	}
+	for _, t := range n.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the leak rule settings
+func (l *LeakRuleConfig) Validate() error {
+	switch l.GetRiskLevel() {
+	case "medium", "high":
+		return nil
+	default:
+		return fmt.Errorf("leak: risk_level must be medium or high")
+	}
+}
+
+// Clone returns a deep copy of the alerting configuration
+func (a AlertingConfig) Clone() AlertingConfig {
+	out := a
+
+	if a.Rules != nil {
+		out.Rules = make([]AlertRule, len(a.Rules))
+		for i, r := range a.Rules {
+			if r.Enabled != nil {
+				enabled := *r.Enabled
+				r.Enabled = &enabled
+			}
+			if r.Channels != nil {
+				r.Channels = append([]string(nil), r.Channels...)
+			}
+			r.Labels = maps.Clone(r.Labels)
+			r.Targets = slices.Clone(r.Targets)
+			r.Groups = slices.Clone(r.Groups)
+			if r.Anomaly != nil {
+				anomaly := *r.Anomaly
+				anomaly.Targets = append([]string(nil), r.Anomaly.Targets...)
+				r.Anomaly = &anomaly
+			}
+			if r.Leak != nil {
+				leak := *r.Leak
+				leak.Targets = append([]string(nil), r.Leak.Targets...)
+				r.Leak = &leak
+			}
+			if r.NoData != nil {
+				noData := *r.NoData
+				noData.Targets = append([]string(nil), r.NoData.Targets...)
+				r.NoData = &noData
+			}
+			out.Rules[i] = r
+		}
+	}
+
+	if a.Grouping.By != nil {
+		out.Grouping.By = append([]string(nil), a.Grouping.By...)
+	}
+	out.Channels.Webhook.Headers = cloneStringMap(a.Channels.Webhook.Headers)
+	out.Channels.Slack.Template = a.Channels.Slack.Template.Clone()
+	out.Channels.Discord.Template = a.Channels.Discord.Template.Clone()
+	out.Channels.Webhook.Template = a.Channels.Webhook.Template.Clone()
+	out.Channels.Email.Template = a.Channels.Email.Template.Clone()
+	if a.Channels.Email.To != nil {
+		out.Channels.Email.To = append([]string(nil), a.Channels.Email.To...)
+	}
+	if a.Channels.Plugins != nil {
+		out.Channels.Plugins = make([]PluginConfig, len(a.Channels.Plugins))
+		for i, p := range a.Channels.Plugins {
+			p.Headers = cloneStringMap(p.Headers)
+			out.Channels.Plugins[i] = p
+		}
+	}
+
+	return out
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// ChannelsConfig holds all notification channel configurations
+type ChannelsConfig struct {
+	Slack      SlackConfig      `mapstructure:"slack" yaml:"slack,omitempty"`
+	Discord    DiscordConfig    `mapstructure:"discord" yaml:"discord,omitempty"`
+	Mattermost MattermostConfig `mapstructure:"mattermost" yaml:"mattermost,omitempty"`
+	Webhook    WebhookConfig    `mapstructure:"webhook" yaml:"webhook,omitempty"`
+	Email      EmailConfig      `mapstructure:"email" yaml:"email,omitempty"`
+	Notion     NotionConfig     `mapstructure:"notion" yaml:"notion,omitempty"`
+	Plugins    []PluginConfig   `mapstructure:"plugins" yaml:"plugins,omitempty"`
+}
+
+// EnabledNames returns the names of enabled channels as used in rule routing
+// Plugin channels are named "plugin:<name>"
+func (c *ChannelsConfig) EnabledNames() []string {
+	var names []string
+	builtin := []struct {
+		name    string
+		enabled bool
+	}{
+		{"slack", c.Slack.Enabled},
+		{"discord", c.Discord.Enabled},
+		{"mattermost", c.Mattermost.Enabled},
+		{"webhook", c.Webhook.Enabled},
+		{"email", c.Email.Enabled},
+		{"notion", c.Notion.Enabled},
+	}
+	for _, b := range builtin {
+		if b.enabled {
+			names = append(names, b.name)
+		}
+	}
+	for _, p := range c.Plugins {
+		if p.Enabled {
+			names = append(names, "plugin:"+p.Name)
+		}
+	}
+	return names
+}
+
+// ValidateRuleChannels checks that every routed channel is enabled
+func (c *ChannelsConfig) ValidateRuleChannels(channels []string) error {
+	enabled := make(map[string]bool)
+	for _, name := range c.EnabledNames() {
+		enabled[strings.ToLower(name)] = true
+	}
+	for _, name := range channels {
+		if !enabled[strings.ToLower(name)] {
+			return fmt.Errorf("unknown or disabled channel '%s'", name)
+		}
+	}
+	return nil
+}
+
+// SlackConfig holds Slack notification settings
+type SlackConfig struct {
+	Enabled    bool   `mapstructure:"enabled" yaml:"enabled"`
+	WebhookURL string `mapstructure:"webhook_url" yaml:"webhook_url,omitempty"`
+	Channel    string `mapstructure:"channel" yaml:"channel,omitempty"`
+	Username   string `mapstructure:"username" yaml:"username,omitempty"`
+
+	Template *NotificationTemplate `mapstructure:"template" yaml:"template,omitempty"`
+}
+
+// DiscordConfig holds Discord notification settings
+type DiscordConfig struct {
+	Enabled    bool   `mapstructure:"enabled" yaml:"enabled"`
+	WebhookURL string `mapstructure:"webhook_url" yaml:"webhook_url,omitempty"`
+
+	Template *NotificationTemplate `mapstructure:"template" yaml:"template,omitempty"`
+}
+
+// MattermostConfig holds Mattermost notification settings
+type MattermostConfig struct {
+	Enabled    bool   `mapstructure:"enabled" yaml:"enabled"`
+	WebhookURL string `mapstructure:"webhook_url" yaml:"webhook_url,omitempty"`
+	Channel    string `mapstructure:"channel" yaml:"channel,omitempty"`
+	Username   string `mapstructure:"username" yaml:"username,omitempty"`
+}
+
+// WebhookConfig holds generic webhook notification settings
+type WebhookConfig struct {
+	Enabled bool              `mapstructure:"enabled" yaml:"enabled"`
+	URL     string            `mapstructure:"url" yaml:"url,omitempty"`
+	Method  string            `mapstructure:"method" yaml:"method,omitempty"`
+	Headers map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`
+
+	Template *NotificationTemplate `mapstructure:"template" yaml:"template,omitempty"` // Body replaces the JSON payload
+}
+
+// EmailConfig holds email notification settings
+type EmailConfig struct {
+	Enabled  bool     `mapstructure:"enabled" yaml:"enabled"`
+	SMTPHost string   `mapstructure:"smtp_host" yaml:"smtp_host,omitempty"`
+	SMTPPort int      `mapstructure:"smtp_port" yaml:"smtp_port,omitempty"`
+	Username string   `mapstructure:"username" yaml:"username,omitempty"`
+	Password string   `mapstructure:"password" yaml:"password,omitempty"`
+	From     string   `mapstructure:"from" yaml:"from,omitempty"`
+	To       []string `mapstructure:"to" yaml:"to,omitempty"`
+	UseTLS   bool     `mapstructure:"use_tls" yaml:"use_tls,omitempty"`
+
+	Template *NotificationTemplate `mapstructure:"template" yaml:"template,omitempty"` // Title is used as the subject
+}
+
+// NotificationTemplate overrides a channel's built-in message layout
+// Each part is a Go template; empty parts keep the built-in rendering
+type NotificationTemplate struct {
+	Title  string          `mapstructure:"title" yaml:"title,omitempty" json:"title,omitempty"`
+	Body   string          `mapstructure:"body" yaml:"body,omitempty" json:"body,omitempty"`
+	Fields []TemplateField `mapstructure:"fields" yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// TemplateField is a templated name/value pair (e.g., Slack attachment field)
+type TemplateField struct {
+	Name  string `mapstructure:"name" yaml:"name" json:"name"`
+	Value string `mapstructure:"value" yaml:"value" json:"value"`
+}
+
+// IsEmpty returns whether the template overrides nothing
+func (t *NotificationTemplate) IsEmpty() bool {
+	return t == nil || (t.Title == "" && t.Body == "" && len(t.Fields) == 0)
+}
+
+// Clone returns a deep copy of the template
+func (t *NotificationTemplate) Clone() *NotificationTemplate {
+	if t == nil {
+		return nil
+	}
+	out := *t
+	if t.Fields != nil {
+		out.Fields = append([]TemplateField(nil), t.Fields...)
+	}
+	return &out
+}
+
+// NotionConfig holds Notion notification settings
+type NotionConfig struct {
+	Enabled    bool   `mapstructure:"enabled" yaml:"enabled"`
+	Token      string `mapstructure:"token" yaml:"token,omitempty"`             // Notion integration token
+	DatabaseID string `mapstructure:"database_id" yaml:"database_id,omitempty"` // Notion database ID
+}
+
+// PluginConfig holds HTTP plugin settings
+type PluginConfig struct {
+	Name       string            `mapstructure:"name" yaml:"name"`
+	Enabled    bool              `mapstructure:"enabled" yaml:"enabled"`
+	URL        string            `mapstructure:"url" yaml:"url,omitempty"`               // HTTP endpoint to call
+	Method     string            `mapstructure:"method" yaml:"method,omitempty"`         // HTTP method (POST, PUT, etc.)
+	Headers    map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`       // Custom headers
+	Timeout    time.Duration     `mapstructure:"timeout" yaml:"timeout,omitempty"`       // Request timeout
+	RetryCount int               `mapstructure:"retry_count" yaml:"retry_count,omitempty"` // Number of retries
+	RetryDelay time.Duration     `mapstructure:"retry_delay" yaml:"retry_delay,omitempty"` // Delay between retries
+}
+
+// GetLocation returns the time.Location for the configured timezone
+func (c *Config) GetLocation() *time.Location {
+	if c.Timezone == "" || c.Timezone == "Local" {
+		return time.Local
+	}
+	if c.Timezone == "UTC" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		log.Printf("Invalid timezone %s, using Local: %v", c.Timezone, err)
+		return time.Local
+	}
+	return loc
+}
+
+func parseDurationWithDays(s string, defaultVal time.Duration) time.Duration {
+	if s == "" {
+		return defaultVal
+	}
+	// Handle "d" suffix for days
+	if len(s) > 1 && s[len(s)-1] == 'd' {
+		var days int
+		if _, err := fmt.Sscanf(s, "%dd", &days); err == nil {
+			return time.Duration(days) * 24 * time.Hour
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return defaultVal
+	}
+	return d
+}
+
+type ServerConfig struct {
+	Port    int            `mapstructure:"port" yaml:"port"`
+	APIKeys []APIKeyConfig `mapstructure:"api_keys" yaml:"api_keys,omitempty"` // Empty disables API key auth
+
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" yaml:"shutdown_timeout,omitempty"` // Time to stop gracefully on SIGTERM (default: 25s)
+
+	// Applied on reload
+	ReadOnly     bool             `mapstructure:"read_only" yaml:"read_only,omitempty"` // Reject API requests that change config, rules, alerts or data
+	CORS         CORSConfig       `mapstructure:"cors" yaml:"cors,omitempty"`
+	RateLimits   RateLimitsConfig `mapstructure:"rate_limits" yaml:"rate_limits,omitempty"`
+	MaxBodyBytes int64            `mapstructure:"max_body_bytes" yaml:"max_body_bytes,omitempty"` // Largest accepted request body (default: 10MB)
+
+	// RequestTimeout bounds reports, anomaly detection, comparisons and rule backtests (default: 30s)
+	RequestTimeout time.Duration `mapstructure:"request_timeout" yaml:"request_timeout,omitempty"`
+
+	// Proxies whose X-Forwarded-For is trusted for the client IP; empty trusts all
+	// Changing it requires a restart
+	TrustedProxies []string `mapstructure:"trusted_proxies" yaml:"trusted_proxies,omitempty"`
+
+	// TLS serves HTTPS, optionally verifying client certificates; changing it requires a restart
+	TLS TLSConfig `mapstructure:"tls" yaml:"tls,omitempty"`
+}
+
+// Client certificate modes of TLSConfig
+const (
+	ClientAuthRequire  = "require"  // Reject connections without a valid client certificate
+	ClientAuthOptional = "optional" // Verify client certificates when presented
+)
+
+// TLSConfig holds the certificate of the HTTPS server
+// Certificate, key and client CA files are reloaded when they change, so renewals need no restart
+type TLSConfig struct {
+	CertFile       string        `mapstructure:"cert_file" yaml:"cert_file,omitempty"`
+	KeyFile        string        `mapstructure:"key_file" yaml:"key_file,omitempty"`
+	ClientCAFile   string        `mapstructure:"client_ca_file" yaml:"client_ca_file,omitempty"`   // CA bundle verifying client certificates (mTLS)
+	ClientAuth     string        `mapstructure:"client_auth" yaml:"client_auth,omitempty"`         // require or optional (default: require with client_ca_file)
+	MinVersion     string        `mapstructure:"min_version" yaml:"min_version,omitempty"`         // 1.2 or 1.3 (default: 1.2)
+	ReloadInterval time.Duration `mapstructure:"reload_interval" yaml:"reload_interval,omitempty"` // How often files are checked for changes (default: 30s)
+}
+
+// Enabled returns whether the server serves HTTPS
+func (t *TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// GetClientAuth returns the client certificate mode, empty without a client CA
+func (t *TLSConfig) GetClientAuth() string {
+	if t.ClientCAFile == "" {
+		return ""
+	}
+	if t.ClientAuth == "" {
+		return ClientAuthRequire
+	}
+	return t.ClientAuth
+}
+
+// GetReloadInterval returns the file check interval with default
+func (t *TLSConfig) GetReloadInterval() time.Duration {
+	if t.ReloadInterval <= 0 {
+		return 30 * time.Second
+	}
+	return t.ReloadInterval
+}
+
+// Validate checks the TLS settings
+func (t *TLSConfig) Validate() error {
+	if !t.Enabled() {
+		if t.ClientCAFile != "" {
+			return fmt.Errorf("server.tls.client_ca_file requires cert_file and key_file")
+		}
+		return nil
+	}
+	if t.CertFile == "" || t.KeyFile == "" {
+		return fmt.Errorf("server.tls requires both cert_file and key_file")
+	}
+	switch t.ClientAuth {
+	case "", ClientAuthRequire, ClientAuthOptional:
+	default:
+		return fmt.Errorf("invalid server.tls.client_auth '%s': use require or optional", t.ClientAuth)
+	}
+	if t.ClientAuth != "" && t.ClientCAFile == "" {
+		return fmt.Errorf("server.tls.client_auth requires client_ca_file")
+	}
+	switch t.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("invalid server.tls.min_version '%s': use 1.2 or 1.3", t.MinVersion)
+	}
+	return nil
+}
+
+// CORSConfig controls which browser origins may call the API
+type CORSConfig struct {
+	AllowedOrigins []string `mapstructure:"allowed_origins" yaml:"allowed_origins,omitempty"` // "*" allows all (default: ["*"])
+}
+
+// GetAllowedOrigins returns the allowed origins with default
+func (c *CORSConfig) GetAllowedOrigins() []string {
+	if len(c.AllowedOrigins) == 0 {
+		return []string{"*"}
+	}
+	return c.AllowedOrigins
+}
+
+// RateLimitsConfig holds the per-client rate limits of the API
+type RateLimitsConfig struct {
+	General   RateLimitConfig `mapstructure:"general" yaml:"general,omitempty"`       // All API requests
+	Strict    RateLimitConfig `mapstructure:"strict" yaml:"strict,omitempty"`         // Exports, reports, backups and analysis
+	TestAlert RateLimitConfig `mapstructure:"test_alert" yaml:"test_alert,omitempty"` // Test notifications
+	Ingest    RateLimitConfig `mapstructure:"ingest" yaml:"ingest,omitempty"`         // Pushed metric batches
+	Auth      RateLimitConfig `mapstructure:"auth" yaml:"auth,omitempty"`             // Failed authentications per client IP
+}
+
+// RateLimitConfig is a token bucket: rate tokens are added every interval, up to burst
+type RateLimitConfig struct {
+	Rate     int           `mapstructure:"rate" yaml:"rate,omitempty"`
+	Interval time.Duration `mapstructure:"interval" yaml:"interval,omitempty"`
+	Burst    int           `mapstructure:"burst" yaml:"burst,omitempty"`
+}
+
+// withDefaults fills unset fields from def
+func (r RateLimitConfig) withDefaults(def RateLimitConfig) RateLimitConfig {
+	if r.Rate <= 0 {
+		r.Rate = def.Rate
+	}
+	if r.Interval <= 0 {
+		r.Interval = def.Interval
+	}
+	if r.Burst <= 0 {
+		r.Burst = def.Burst
+	}
+	return r
+}
+
+// Rate limiter names
+const (
+	RateLimitGeneral   = "general"
+	RateLimitStrict    = "strict"
+	RateLimitTestAlert = "test_alert"
+	RateLimitIngest    = "ingest"
+	RateLimitAuth      = "auth"
+)
+
+// Limits returns every rate limit by name with defaults
+func (r *RateLimitsConfig) Limits() map[string]RateLimitConfig {
+	return map[string]RateLimitConfig{
+		RateLimitGeneral:   r.General.withDefaults(RateLimitConfig{Rate: 100, Interval: time.Second, Burst: 200}),
+		RateLimitStrict:    r.Strict.withDefaults(RateLimitConfig{Rate: 10, Interval: time.Second, Burst: 20}),
+		RateLimitTestAlert: r.TestAlert.withDefaults(RateLimitConfig{Rate: 1, Interval: 10 * time.Second, Burst: 3}),
+		RateLimitIngest:    r.Ingest.withDefaults(RateLimitConfig{Rate: 20, Interval: time.Second, Burst: 50}),
+		RateLimitAuth:      r.Auth.withDefaults(RateLimitConfig{Rate: 10, Interval: time.Minute, Burst: 20}),
+	}
+}
+
+// GetMaxBodyBytes returns the request body limit with default
+func (s *ServerConfig) GetMaxBodyBytes() int64 {
+	if s.MaxBodyBytes <= 0 {
+		return 10 * 1024 * 1024
+	}
+	return s.MaxBodyBytes
+}
+
+// GetRequestTimeout returns the time limit of expensive API requests with default
+func (s *ServerConfig) GetRequestTimeout() time.Duration {
+	if s.RequestTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return s.RequestTimeout
+}
+
+// GetShutdownTimeout returns the graceful shutdown timeout with default
+// The default stays below the 30s Kubernetes waits before killing the pod
+func (s *ServerConfig) GetShutdownTimeout() time.Duration {
+	if s.ShutdownTimeout <= 0 {
+		return 25 * time.Second
+	}
+	return s.ShutdownTimeout
+}
+
+// APIKeyConfig defines a named API key for authenticating API clients
+type APIKeyConfig struct {
+	Name     string      `mapstructure:"name" yaml:"name"` // Identifies the integration in usage reports
+	Key      string      `mapstructure:"key" yaml:"key"`
+	Projects []string    `mapstructure:"projects" yaml:"projects,omitempty"` // Projects the key may access (empty = all)
+	Quota    APIKeyQuota `mapstructure:"quota" yaml:"quota,omitempty"`
+	Admin    bool        `mapstructure:"admin" yaml:"admin,omitempty"` // May read instance-wide usage
+}
+
+// APIKeyQuota sets per-key limits; zero means no limit
+type APIKeyQuota struct {
+	RequestsPerMinute int   `mapstructure:"requests_per_minute" yaml:"requests_per_minute,omitempty"` // Replaces the general rate limit for the key
+	ExportRowsPerDay  int64 `mapstructure:"export_rows_per_day" yaml:"export_rows_per_day,omitempty"` // Rows returned by metric exports per day, reset at midnight
+}
+
+// HasAdminKeys returns whether any API key is marked admin
+// Without admin keys, every key without a project scope counts as one
+func (s *ServerConfig) HasAdminKeys() bool {
+	for _, k := range s.APIKeys {
+		if k.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthEnabled returns whether API key authentication is required
+func (s *ServerConfig) AuthEnabled() bool {
+	return len(s.APIKeys) > 0
+}
+
+// APIKeyByName returns the configured key with the given name, or nil
+func (s *ServerConfig) APIKeyByName(name string) *APIKeyConfig {
+	for i := range s.APIKeys {
+		if s.APIKeys[i].Name == name {
+			return &s.APIKeys[i]
+		}
+	}
+	return nil
+}
+
+type StorageConfig struct {
+	Path         string           `mapstructure:"path" yaml:"path"`
+	QueryTimeout time.Duration    `mapstructure:"query_timeout" yaml:"query_timeout,omitempty"` // Statement timeout of queries (default: 30s)
+	WriteQueue   WriteQueueConfig `mapstructure:"write_queue" yaml:"write_queue,omitempty"`
+}
+
+// GetQueryTimeout returns the statement timeout of queries with default
+// Maintenance like cleanup, rollups and backups is not bounded by it
+func (s *StorageConfig) GetQueryTimeout() time.Duration {
+	if s.QueryTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return s.QueryTimeout
+}
+
+// WriteQueueConfig batches metric inserts into fewer transactions
+type WriteQueueConfig struct {
+	Enabled       *bool         `mapstructure:"enabled" yaml:"enabled,omitempty"`               // default: true
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size,omitempty"`         // Rows per transaction (default: 100)
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval,omitempty"` // Maximum delay before writing (default: 1s)
+	Capacity      int           `mapstructure:"capacity" yaml:"capacity,omitempty"`             // Queued rows before writes become synchronous (default: 10000)
+}
+
+// IsEnabled returns whether metric writes are queued (default: true)
+func (w *WriteQueueConfig) IsEnabled() bool {
+	if w.Enabled == nil {
+		return true
+	}
+	return *w.Enabled
+}
+
+// GetBatchSize returns the rows per transaction with default
+func (w *WriteQueueConfig) GetBatchSize() int {
+	if w.BatchSize <= 0 {
+		return 100
+	}
+	return w.BatchSize
+}
+
+// GetFlushInterval returns the maximum write delay with default
+func (w *WriteQueueConfig) GetFlushInterval() time.Duration {
+	if w.FlushInterval <= 0 {
+		return time.Second
+	}
+	return w.FlushInterval
+}
+
+// GetCapacity returns the queue capacity with default
+func (w *WriteQueueConfig) GetCapacity() int {
+	if w.Capacity <= 0 {
+		return 10000
+	}
+	return w.Capacity
+}
+
+// Supported target types
+const (
+	TargetTypeActuator = "actuator" // Spring Boot Actuator metrics endpoint
+	TargetTypeJolokia  = "jolokia"  // Jolokia JMX-over-HTTP agent
+	TargetTypePush     = "push"     // Metrics pushed to /api/v1/ingest/metrics, never scraped
+)
+
+// Supported connection pool types for actuator targets
+const (
+	PoolTypeAuto   = "auto"   // Detect from the actuator metric names (default)
+	PoolTypeHikari = "hikari" // HikariCP (hikaricp.*)
+	PoolTypeTomcat = "tomcat" // Tomcat JDBC pool (tomcat.jdbc.*)
+	PoolTypeDBCP2  = "dbcp2"  // Apache Commons DBCP2 (dbcp2.*)
+	PoolTypeDruid  = "druid"  // Alibaba Druid (druid.*)
+	PoolTypeR2DBC  = "r2dbc"  // Reactive R2DBC pool (r2dbc.pool.*)
+	PoolTypeJDBC   = "jdbc"   // Spring Boot generic DataSource metrics (jdbc.connections.*)
+)
+
+// ValidatePoolType checks that a pool type is supported
+func ValidatePoolType(poolType string) error {
+	switch poolType {
+	case "", PoolTypeAuto, PoolTypeHikari, PoolTypeTomcat, PoolTypeDBCP2, PoolTypeDruid, PoolTypeR2DBC, PoolTypeJDBC:
+		return nil
+	default:
+		return fmt.Errorf("invalid pool_type '%s': use auto, hikari, tomcat, dbcp2, druid, r2dbc, or jdbc", poolType)
+	}
+}
+
+// Scrape modes of actuator targets
+const (
+	ScrapeModeMetrics    = "metrics"    // One request per metric to /actuator/metrics (default)
+	ScrapeModePrometheus = "prometheus" // A single request to /actuator/prometheus
+	ScrapeModeAuto       = "auto"       // Prometheus when the instance exposes it, metrics otherwise
+)
+
+// ValidateScrapeMode checks that a scrape mode is supported
+func ValidateScrapeMode(mode string) error {
+	switch mode {
+	case "", ScrapeModeMetrics, ScrapeModePrometheus, ScrapeModeAuto:
+		return nil
+	default:
+		return fmt.Errorf("invalid scrape_mode '%s': use metrics, prometheus, or auto", mode)
+	}
+}
+
+type TargetConfig struct {
+	Name      string           `mapstructure:"name" yaml:"name"`
+	Type      string           `mapstructure:"type" yaml:"type"`
+	Endpoint  string           `mapstructure:"endpoint" yaml:"endpoint,omitempty"`
+	Interval  time.Duration    `mapstructure:"interval" yaml:"interval"`
+	Group     string           `mapstructure:"group" yaml:"group,omitempty"` // Environment group: dev, staging, prod, etc.
+	Instances []InstanceConfig `mapstructure:"instances" yaml:"instances,omitempty"`
+
+	// PrometheusSelector selects this target's series when bootstrapping from Prometheus,
+	// e.g., `application="orders",instance="$instance"`. $instance expands to the endpoint host:port.
+	// Defaults to `instance="$instance"`.
+	PrometheusSelector string `mapstructure:"prometheus_selector" yaml:"prometheus_selector,omitempty"`
+
+	// PoolType selects the connection pool metric names for actuator targets.
+	// Defaults to auto-detection from the actuator metric list.
+	PoolType string `mapstructure:"pool_type" yaml:"pool_type,omitempty"`
+
+	// ScrapeMode selects how actuator targets are scraped: metrics, prometheus or auto.
+	// prometheus reads every metric from /actuator/prometheus in one request (default: metrics)
+	ScrapeMode string `mapstructure:"scrape_mode" yaml:"scrape_mode,omitempty"`
+
+	// Discovery resolves instances from Eureka, Consul or DNS instead of a fixed list
+	Discovery *TargetDiscoveryConfig `mapstructure:"discovery" yaml:"discovery,omitempty"`
+
+	// Timeout is the HTTP timeout for each metrics request (default: 5s)
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout,omitempty"`
+
+	// Retries is how many times a failed request is retried (default: 0)
+	Retries int `mapstructure:"retries" yaml:"retries,omitempty"`
+
+	// RetryBackoff is the delay before the first retry, doubled on each attempt (default: 500ms)
+	RetryBackoff time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff,omitempty"`
+
+	// SLOs are service level objectives evaluated from the target's history
+	SLOs []SLOConfig `mapstructure:"slos" yaml:"slos,omitempty"`
+
+	// Paused stops collection and alerting without removing the target or its history
+	Paused      bool   `mapstructure:"paused" yaml:"paused,omitempty"`
+	PauseReason string `mapstructure:"pause_reason" yaml:"pause_reason,omitempty"`
+
+	// Reconfigure allows applying recommended pool settings to the running instances
+	Reconfigure *ReconfigureConfig `mapstructure:"reconfigure" yaml:"reconfigure,omitempty"`
+
+	// Diagnostics allows capturing thread and heap dumps of the instances
+	Diagnostics *DiagnosticsConfig `mapstructure:"diagnostics" yaml:"diagnostics,omitempty"`
+
+	// Anomaly sets the default anomaly detection algorithm and sensitivity
+	Anomaly *AnomalyConfig `mapstructure:"anomaly" yaml:"anomaly,omitempty"`
+
+	// Retention overrides how long raw metrics are kept, e.g., 7d (default: group or global max_age)
+	Retention string `mapstructure:"retention" yaml:"retention,omitempty"`
+
+	// Project is the team or tenant owning the target (default: DefaultProject)
+	// API keys limited to other projects can't see the target, its alerts or its maintenance windows
+	Project string `mapstructure:"project" yaml:"project,omitempty"`
+
+	// Labels are arbitrary key/value tags beyond Group, e.g., env: prod, team: payments
+	// They filter target lists, scope alert rules, select report targets and group summaries
+	Labels map[string]string `mapstructure:"labels" yaml:"labels,omitempty"`
+}
+
+// DefaultProject owns targets without a project
+const DefaultProject = "default"
+
+// labelKeyPattern is the allowed form of label keys
+var labelKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_.\-/]+$`)
+
+// ValidateLabels checks target label keys and values
+// Keys are letters, digits and _.-/; values must not contain commas so selectors stay parseable
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key '%s': use letters, digits, '_', '.', '-' or '/'", key)
+		}
+		if value == "" || strings.Contains(value, ",") {
+			return fmt.Errorf("invalid value '%s' of label '%s': must be non-empty without commas", value, key)
+		}
+	}
+	return nil
+}
+
+// ParseLabelSelector parses comma-separated "key:value" or "key=value" pairs, e.g., "team:payments,env=prod"
+func ParseLabelSelector(selector string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		i := strings.IndexAny(part, ":=")
+		if i <= 0 || i == len(part)-1 {
+			return nil, fmt.Errorf("invalid label selector '%s': use key:value", part)
+		}
+		labels[strings.TrimSpace(part[:i])] = strings.TrimSpace(part[i+1:])
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("label selector is empty")
+	}
+	if err := ValidateLabels(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// MatchLabels reports whether labels have every label of the selector; an empty selector matches all
+func MatchLabels(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// HasLabels reports whether the target has every label of the selector
+func (t *TargetConfig) HasLabels(selector map[string]string) bool {
+	return MatchLabels(selector, t.Labels)
+}
+
+// GetProject returns the project of the target with default
+func (t *TargetConfig) GetProject() string {
+	if t.Project == "" {
+		return DefaultProject
+	}
+	return t.Project
+}
+
+// DefaultTargetTimeout is the HTTP timeout for metrics requests
+const DefaultTargetTimeout = 5 * time.Second
+
+// GetTimeout returns the request timeout with default
+func (t *TargetConfig) GetTimeout() time.Duration {
+	if t.Timeout <= 0 {
+		return DefaultTargetTimeout
+	}
+	return t.Timeout
 }
 
-func (r *RetentionConfig) GetMaxAge() time.Duration {
-	return parseDurationWithDays(r.MaxAge, 30*24*time.Hour)
+// GetInterval returns the collection interval with default
+func (t *TargetConfig) GetInterval() time.Duration {
+	if t.Interval <= 0 {
+		return DefaultDiscoveryInterval
+	}
+	return t.Interval
 }
 
-func (r *RetentionConfig) GetCleanupInterval() time.Duration {
-	return parseDurationWithDays(r.CleanupInterval, time.Hour)
+// GetRetryBackoff returns the initial retry delay with default
+func (t *TargetConfig) GetRetryBackoff() time.Duration {
+	if t.RetryBackoff <= 0 {
+		return 500 * time.Millisecond
+	}
+	return t.RetryBackoff
 }
 
-// AlertingConfig holds alerting configuration
-type AlertingConfig struct {
-	Enabled       bool           `mapstructure:"enabled" yaml:"enabled"`
-	CheckInterval time.Duration  `mapstructure:"check_interval" yaml:"check_interval,omitempty"`
-	Cooldown      time.Duration  `mapstructure:"cooldown" yaml:"cooldown,omitempty"`
-	Rules         []AlertRule    `mapstructure:"rules" yaml:"rules,omitempty"`
-	Channels      ChannelsConfig `mapstructure:"channels" yaml:"channels,omitempty"`
+// AnomalyConfig holds a target's anomaly detection defaults, overridable per request
+type AnomalyConfig struct {
+	Algorithm   string `mapstructure:"algorithm" yaml:"algorithm,omitempty" json:"algorithm,omitempty"`       // zscore, mad, ewma or iqr (default: zscore)
+	Sensitivity string `mapstructure:"sensitivity" yaml:"sensitivity,omitempty" json:"sensitivity,omitempty"` // low, medium or high (default: medium)
 }
 
-// GetCheckInterval returns the check interval with default
-func (a *AlertingConfig) GetCheckInterval() time.Duration {
-	if a.CheckInterval <= 0 {
-		return 30 * time.Second
+// GetAlgorithm returns the detection algorithm with default
+func (a *AnomalyConfig) GetAlgorithm() string {
+	if a == nil || a.Algorithm == "" {
+		return "zscore"
 	}
-	return a.CheckInterval
+	return a.Algorithm
 }
 
-// GetCooldown returns the cooldown with default
-func (a *AlertingConfig) GetCooldown() time.Duration {
-	if a.Cooldown <= 0 {
-		return 5 * time.Minute
+// GetSensitivity returns the detection sensitivity with default
+func (a *AnomalyConfig) GetSensitivity() string {
+	if a == nil || a.Sensitivity == "" {
+		return "medium"
 	}
-	return a.Cooldown
+	return a.Sensitivity
 }
 
-// AlertRule defines an alerting rule
-type AlertRule struct {
-	Name      string `mapstructure:"name" yaml:"name"`
-	Condition string `mapstructure:"condition" yaml:"condition"` // e.g., "usage > 80", "pending > 5"
-	Severity  string `mapstructure:"severity" yaml:"severity"`   // info, warning, critical
-	Message   string `mapstructure:"message" yaml:"message,omitempty"` // Template message
-	Enabled   *bool  `mapstructure:"enabled" yaml:"enabled,omitempty"` // Default true if nil
+// Validate checks the anomaly detection settings
+func (a *AnomalyConfig) Validate() error {
+	switch a.GetAlgorithm() {
+	case "zscore", "mad", "ewma", "iqr":
+	default:
+		return fmt.Errorf("anomaly: algorithm must be zscore, mad, ewma or iqr")
+	}
+	switch a.GetSensitivity() {
+	case "low", "medium", "high":
+	default:
+		return fmt.Errorf("anomaly: sensitivity must be low, medium or high")
+	}
+	return nil
 }
 
-// IsEnabled returns whether the rule is enabled
-func (r *AlertRule) IsEnabled() bool {
-	if r.Enabled == nil {
-		return true
+// Reconfiguration modes
+const (
+	ReconfigureModeEnv    = "env"    // POST the property to /actuator/env, then /actuator/refresh
+	ReconfigureModeCustom = "custom" // POST the setting to a management endpoint of the application
+)
+
+// ReconfigureConfig defines how pool settings are changed on a target's instances
+type ReconfigureConfig struct {
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled"`
+	Mode    string `mapstructure:"mode" yaml:"mode,omitempty"` // env or custom (default: env)
+
+	// PropertyPrefix is prepended to the kebab-case setting in env mode (default: spring.datasource.hikari)
+	PropertyPrefix string `mapstructure:"property_prefix" yaml:"property_prefix,omitempty"`
+
+	// Refresh posts to /actuator/refresh after env changes so @RefreshScope beans rebind (default: true)
+	Refresh *bool `mapstructure:"refresh" yaml:"refresh,omitempty"`
+
+	// Path of the custom management endpoint, relative to the actuator base, e.g., "poolconfig"
+	Path string `mapstructure:"path" yaml:"path,omitempty"`
+}
+
+// GetMode returns the reconfiguration mode with default
+func (r *ReconfigureConfig) GetMode() string {
+	if r.Mode == "" {
+		return ReconfigureModeEnv
 	}
-	return *r.Enabled
+	return r.Mode
 }
 
-// ChannelsConfig holds all notification channel configurations
-type ChannelsConfig struct {
-	Slack      SlackConfig      `mapstructure:"slack" yaml:"slack,omitempty"`
-	Discord    DiscordConfig    `mapstructure:"discord" yaml:"discord,omitempty"`
-	Mattermost MattermostConfig `mapstructure:"mattermost" yaml:"mattermost,omitempty"`
-	Webhook    WebhookConfig    `mapstructure:"webhook" yaml:"webhook,omitempty"`
-	Email      EmailConfig      `mapstructure:"email" yaml:"email,omitempty"`
-	Notion     NotionConfig     `mapstructure:"notion" yaml:"notion,omitempty"`
-	Plugins    []PluginConfig   `mapstructure:"plugins" yaml:"plugins,omitempty"`
+// GetPropertyPrefix returns the env property prefix with default
+func (r *ReconfigureConfig) GetPropertyPrefix() string {
+	if r.PropertyPrefix == "" {
+		return "spring.datasource.hikari"
+	}
+	return strings.TrimSuffix(r.PropertyPrefix, ".")
 }
 
-// SlackConfig holds Slack notification settings
-type SlackConfig struct {
-	Enabled    bool   `mapstructure:"enabled" yaml:"enabled"`
-	WebhookURL string `mapstructure:"webhook_url" yaml:"webhook_url,omitempty"`
-	Channel    string `mapstructure:"channel" yaml:"channel,omitempty"`
-	Username   string `mapstructure:"username" yaml:"username,omitempty"`
+// IsRefreshEnabled returns whether /actuator/refresh is called after env changes (default: true)
+func (r *ReconfigureConfig) IsRefreshEnabled() bool {
+	if r.Refresh == nil {
+		return true
+	}
+	return *r.Refresh
 }
 
-// DiscordConfig holds Discord notification settings
-type DiscordConfig struct {
-	Enabled    bool   `mapstructure:"enabled" yaml:"enabled"`
-	WebhookURL string `mapstructure:"webhook_url" yaml:"webhook_url,omitempty"`
+// Validate checks the reconfiguration settings
+func (r *ReconfigureConfig) Validate() error {
+	switch r.GetMode() {
+	case ReconfigureModeEnv:
+	case ReconfigureModeCustom:
+		if strings.Trim(r.Path, "/") == "" {
+			return fmt.Errorf("reconfigure: path is required for custom mode")
+		}
+	default:
+		return fmt.Errorf("reconfigure: mode must be env or custom")
+	}
+	return nil
 }
 
-// MattermostConfig holds Mattermost notification settings
-type MattermostConfig struct {
-	Enabled    bool   `mapstructure:"enabled" yaml:"enabled"`
-	WebhookURL string `mapstructure:"webhook_url" yaml:"webhook_url,omitempty"`
-	Channel    string `mapstructure:"channel" yaml:"channel,omitempty"`
-	Username   string `mapstructure:"username" yaml:"username,omitempty"`
+// DiagnosticsConfig controls dump capture from a target's instances
+type DiagnosticsConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
 }
 
-// WebhookConfig holds generic webhook notification settings
-type WebhookConfig struct {
-	Enabled bool              `mapstructure:"enabled" yaml:"enabled"`
-	URL     string            `mapstructure:"url" yaml:"url,omitempty"`
-	Method  string            `mapstructure:"method" yaml:"method,omitempty"`
-	Headers map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`
+// DiagnosticsEnabled checks if thread and heap dumps may be captured from the target
+func (t *TargetConfig) DiagnosticsEnabled() bool {
+	return t.Diagnostics != nil && t.Diagnostics.Enabled
 }
 
-// EmailConfig holds email notification settings
-type EmailConfig struct {
-	Enabled  bool     `mapstructure:"enabled" yaml:"enabled"`
-	SMTPHost string   `mapstructure:"smtp_host" yaml:"smtp_host,omitempty"`
-	SMTPPort int      `mapstructure:"smtp_port" yaml:"smtp_port,omitempty"`
-	Username string   `mapstructure:"username" yaml:"username,omitempty"`
-	Password string   `mapstructure:"password" yaml:"password,omitempty"`
-	From     string   `mapstructure:"from" yaml:"from,omitempty"`
-	To       []string `mapstructure:"to" yaml:"to,omitempty"`
-	UseTLS   bool     `mapstructure:"use_tls" yaml:"use_tls,omitempty"`
+// SLOConfig defines a service level objective, e.g., "usage < 85 for 99.5% of 30 days"
+type SLOConfig struct {
+	Name           string        `mapstructure:"name" yaml:"name"`
+	Objective      string        `mapstructure:"objective" yaml:"objective"`                         // Condition a good sample meets, e.g., "usage < 85", "timeouts == 0"
+	Target         float64       `mapstructure:"target" yaml:"target"`                               // % of samples (or periods) meeting the objective, e.g., 99.5
+	Window         string        `mapstructure:"window" yaml:"window,omitempty"`                     // Compliance window (default: 30d)
+	Period         string        `mapstructure:"period" yaml:"period,omitempty"`                     // Judge whole periods such as 1d instead of samples (default: per sample)
+	Alert          *bool         `mapstructure:"alert" yaml:"alert,omitempty"`                       // Fire an alert on fast burn (default: true)
+	Severity       string        `mapstructure:"severity" yaml:"severity,omitempty"`                 // Fast burn alert severity (default: critical)
+	FastBurnRate   float64       `mapstructure:"fast_burn_rate" yaml:"fast_burn_rate,omitempty"`     // Burn rate that counts as fast (default: 14.4)
+	FastBurnWindow time.Duration `mapstructure:"fast_burn_window" yaml:"fast_burn_window,omitempty"` // Long burn window, the short one is 1/12 of it (default: 1h)
 }
 
-// NotionConfig holds Notion notification settings
-type NotionConfig struct {
-	Enabled    bool   `mapstructure:"enabled" yaml:"enabled"`
-	Token      string `mapstructure:"token" yaml:"token,omitempty"`             // Notion integration token
-	DatabaseID string `mapstructure:"database_id" yaml:"database_id,omitempty"` // Notion database ID
+// GetWindow returns the compliance window with default
+func (s *SLOConfig) GetWindow() time.Duration {
+	return parseDurationWithDays(s.Window, 30*24*time.Hour)
 }
 
-// PluginConfig holds HTTP plugin settings
-type PluginConfig struct {
-	Name       string            `mapstructure:"name" yaml:"name"`
-	Enabled    bool              `mapstructure:"enabled" yaml:"enabled"`
-	URL        string            `mapstructure:"url" yaml:"url,omitempty"`               // HTTP endpoint to call
-	Method     string            `mapstructure:"method" yaml:"method,omitempty"`         // HTTP method (POST, PUT, etc.)
-	Headers    map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`       // Custom headers
-	Timeout    time.Duration     `mapstructure:"timeout" yaml:"timeout,omitempty"`       // Request timeout
-	RetryCount int               `mapstructure:"retry_count" yaml:"retry_count,omitempty"` // Number of retries
-	RetryDelay time.Duration     `mapstructure:"retry_delay" yaml:"retry_delay,omitempty"` // Delay between retries
+// GetPeriod returns the judging period, 0 when samples are judged individually
+func (s *SLOConfig) GetPeriod() time.Duration {
+	return parseDurationWithDays(s.Period, 0)
 }
 
-// GetLocation returns the time.Location for the configured timezone
-func (c *Config) GetLocation() *time.Location {
-	if c.Timezone == "" || c.Timezone == "Local" {
-		return time.Local
-	}
-	if c.Timezone == "UTC" {
-		return time.UTC
-	}
-	loc, err := time.LoadLocation(c.Timezone)
-	if err != nil {
-		log.Printf("Invalid timezone %s, using Local: %v", c.Timezone, err)
-		return time.Local
+// IsAlertEnabled returns whether fast burn alerts are fired (default: true)
+func (s *SLOConfig) IsAlertEnabled() bool {
+	if s.Alert == nil {
+		return true
 	}
-	return loc
+	return *s.Alert
 }
 
-func parseDurationWithDays(s string, defaultVal time.Duration) time.Duration {
-	if s == "" {
-		return defaultVal
-	}
-	// Handle "d" suffix for days
-	if len(s) > 1 && s[len(s)-1] == 'd' {
-		var days int
-		if _, err := fmt.Sscanf(s, "%dd", &days); err == nil {
-			return time.Duration(days) * 24 * time.Hour
-		}
-	}
-	d, err := time.ParseDuration(s)
-	if err != nil {
-		return defaultVal
+// GetSeverity returns the fast burn alert severity with default
+func (s *SLOConfig) GetSeverity() string {
+	if s.Severity == "" {
+		return "critical"
 	}
-	return d
+	return s.Severity
 }
 
-type ServerConfig struct {
-	Port int `mapstructure:"port" yaml:"port"`
+// GetFastBurnRate returns the fast burn threshold with default
+// 14.4 spends 2% of a 30 day budget in one hour
+func (s *SLOConfig) GetFastBurnRate() float64 {
+	if s.FastBurnRate <= 0 {
+		return 14.4
+	}
+	return s.FastBurnRate
 }
 
-type StorageConfig struct {
-	Path string `mapstructure:"path" yaml:"path"`
+// GetFastBurnWindow returns the long fast burn window with default
+func (s *SLOConfig) GetFastBurnWindow() time.Duration {
+	if s.FastBurnWindow <= 0 {
+		return time.Hour
+	}
+	return s.FastBurnWindow
 }
 
-type TargetConfig struct {
-	Name      string           `mapstructure:"name" yaml:"name"`
-	Type      string           `mapstructure:"type" yaml:"type"`
-	Endpoint  string           `mapstructure:"endpoint" yaml:"endpoint,omitempty"`
-	Interval  time.Duration    `mapstructure:"interval" yaml:"interval"`
-	Group     string           `mapstructure:"group" yaml:"group,omitempty"` // Environment group: dev, staging, prod, etc.
-	Instances []InstanceConfig `mapstructure:"instances" yaml:"instances,omitempty"`
+// Validate checks the SLO settings that don't depend on the objective syntax
+func (s *SLOConfig) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("slo name is required")
+	}
+	if strings.TrimSpace(s.Objective) == "" {
+		return fmt.Errorf("slo %s: objective is required", s.Name)
+	}
+	if s.Target <= 0 || s.Target >= 100 {
+		return fmt.Errorf("slo %s: target must be between 0 and 100 (exclusive)", s.Name)
+	}
+	if period := s.GetPeriod(); period < 0 || period > s.GetWindow() {
+		return fmt.Errorf("slo %s: period must be shorter than the window", s.Name)
+	}
+	return nil
 }
 
 type InstanceConfig struct {
@@ -229,6 +2066,7 @@ func (t *TargetConfig) GetInstances() []InstanceConfig {
 type Manager struct {
 	mu           sync.RWMutex
 	config       *Config
+	templates    map[string]interpolation // Config values read with ${...} references
 	callbacks    []func(*Config)
 	configPath   string
 	lastHash     string
@@ -246,7 +2084,8 @@ func NewManager(path string) (*Manager, error) {
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "text")
 
-	if err := viper.ReadInConfig(); err != nil {
+	templates, err := loadConfigData(path)
+	if err != nil {
 		return nil, err
 	}
 
@@ -254,12 +2093,16 @@ func NewManager(path string) (*Manager, error) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Calculate initial hash
 	initialHash, _ := fileHash(path)
 
 	m := &Manager{
 		config:       &cfg,
+		templates:    templates,
 		callbacks:    make([]func(*Config), 0),
 		configPath:   path,
 		lastHash:     initialHash,
@@ -365,7 +2208,8 @@ func (m *Manager) reload() {
 	log.Printf("Config reload triggered, re-reading file: %s", m.configPath)
 
 	// Re-read config file first (viper caches values)
-	if err := viper.ReadInConfig(); err != nil {
+	templates, err := loadConfigData(m.configPath)
+	if err != nil {
 		log.Printf("Failed to re-read config file: %v", err)
 		return
 	}
@@ -375,6 +2219,10 @@ func (m *Manager) reload() {
 		log.Printf("Failed to unmarshal config: %v", err)
 		return
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Printf("Invalid config, keeping the previous one: %v", err)
+		return
+	}
 
 	// Log target details for debugging
 	var targetNames []string
@@ -385,6 +2233,7 @@ func (m *Manager) reload() {
 
 	m.mu.Lock()
 	m.config = &cfg
+	m.templates = templates
 	callbacks := m.callbacks
 	m.mu.Unlock()
 
@@ -405,7 +2254,7 @@ func Load(path string) (*Config, error) {
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "text")
 
-	if err := viper.ReadInConfig(); err != nil {
+	if _, err := loadConfigData(path); err != nil {
 		return nil, err
 	}
 
@@ -413,14 +2262,66 @@ func Load(path string) (*Config, error) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
 
+// ValidateCondition checks the syntax of a rule condition
+// The alerter sets it, as the condition grammar lives there
+var ValidateCondition func(condition string, derived ...string) error
+
+// ValidateChannelTemplates checks that the notification templates render
+// The alerter sets it, as the template functions live there
+var ValidateChannelTemplates func(channels *ChannelsConfig) error
+
+// Validate checks the settings that can't be defaulted, rejecting the whole file
+func (c *Config) Validate() error {
+	if err := c.Alerting.ValidateExternalURL(); err != nil {
+		return err
+	}
+	if ValidateChannelTemplates != nil {
+		if err := ValidateChannelTemplates(&c.Alerting.Channels); err != nil {
+			return fmt.Errorf("alerting.channels: %w", err)
+		}
+	}
+	for _, r := range c.Alerting.Rules {
+		if r.ResolveCondition == "" || ValidateCondition == nil {
+			continue
+		}
+		if err := ValidateCondition(r.ResolveCondition, c.DerivedMetricNames()...); err != nil {
+			return fmt.Errorf("rule %s: invalid resolve_condition: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// UpdateAlerting applies fn to a copy of the alerting configuration and swaps it in
+// atomically. Readers holding the previous *Config never observe a partial update.
+// If fn returns an error the current configuration is left untouched.
+func (m *Manager) UpdateAlerting(fn func(*AlertingConfig) error) (AlertingConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerting := m.config.Alerting.Clone()
+	if err := fn(&alerting); err != nil {
+		return AlertingConfig{}, err
+	}
+
+	next := *m.config
+	next.Alerting = alerting
+	m.config = &next
+
+	return alerting.Clone(), nil
+}
+
 // SaveConfig saves the current configuration to file
 func (m *Manager) SaveConfig() error {
 	m.mu.RLock()
 	cfg := m.config
+	templates := m.templates
 	callbacks := m.callbacks
 	m.mu.RUnlock()
 
@@ -429,6 +2330,11 @@ func (m *Manager) SaveConfig() error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	// Write ${...} references back instead of the values they expanded to
+	if data, err = restoreTemplates(data, templates); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
 	if err := os.WriteFile(m.configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
@@ -487,6 +2393,149 @@ func (m *Manager) UpdateTarget(name string, target TargetConfig) error {
 	return fmt.Errorf("target '%s' not found", name)
 }
 
+// SetTargetPaused pauses or resumes a target and returns its updated configuration
+// The reason is cleared on resume
+func (m *Manager) SetTargetPaused(name string, paused bool, reason string) (TargetConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, t := range m.config.Targets {
+		if t.Name == name {
+			t.Paused = paused
+			t.PauseReason = ""
+			if paused {
+				t.PauseReason = reason
+			}
+
+			next := *m.config
+			next.Targets = append([]TargetConfig{}, m.config.Targets...)
+			next.Targets[i] = t
+			m.config = &next
+			return t, nil
+		}
+	}
+
+	return TargetConfig{}, fmt.Errorf("target '%s' not found", name)
+}
+
+// RenameTarget renames a target and returns its updated configuration
+func (m *Manager) RenameTarget(oldName, newName string) (TargetConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index := -1
+	for i, t := range m.config.Targets {
+		if t.Name == newName {
+			return TargetConfig{}, fmt.Errorf("target with name '%s' already exists", newName)
+		}
+		if t.Name == oldName {
+			index = i
+		}
+	}
+	if index < 0 {
+		return TargetConfig{}, fmt.Errorf("target '%s' not found", oldName)
+	}
+
+	t := m.config.Targets[index]
+	t.Name = newName
+
+	next := *m.config
+	next.Targets = append([]TargetConfig{}, m.config.Targets...)
+	next.Targets[index] = t
+	m.config = &next
+	return t, nil
+}
+
+// TargetProjects returns the project of every target by name
+func (c *Config) TargetProjects() map[string]string {
+	projects := make(map[string]string, len(c.Targets))
+	for _, t := range c.Targets {
+		projects[t.Name] = t.GetProject()
+	}
+	return projects
+}
+
+// TargetGroups returns the group of every target that has one, by name
+func (c *Config) TargetGroups() map[string]string {
+	groups := make(map[string]string, len(c.Targets))
+	for _, t := range c.Targets {
+		if t.Group != "" {
+			groups[t.Name] = t.Group
+		}
+	}
+	return groups
+}
+
+// TargetLabels returns the labels of every target that has some, by name
+func (c *Config) TargetLabels() map[string]map[string]string {
+	labels := make(map[string]map[string]string, len(c.Targets))
+	for _, t := range c.Targets {
+		if len(t.Labels) > 0 {
+			labels[t.Name] = t.Labels
+		}
+	}
+	return labels
+}
+
+// Projects returns the projects of all targets, sorted
+func (c *Config) Projects() []string {
+	seen := make(map[string]bool)
+	var projects []string
+	for _, t := range c.Targets {
+		if p := t.GetProject(); !seen[p] {
+			seen[p] = true
+			projects = append(projects, p)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// PausedTargets returns the names of paused targets
+func (c *Config) PausedTargets() []string {
+	var names []string
+	for _, t := range c.Targets {
+		if t.Paused {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// GetTargetRetention returns how long raw metrics of a target are kept:
+// the target's retention, then its group's, then the global max_age
+func (c *Config) GetTargetRetention(t *TargetConfig) time.Duration {
+	global := c.Retention.GetMaxAge()
+	if t.Retention != "" {
+		return parseDurationWithDays(t.Retention, global)
+	}
+	if group, ok := c.Retention.Groups[t.Group]; ok && t.Group != "" {
+		return parseDurationWithDays(group, global)
+	}
+	return global
+}
+
+// RetentionOverrides returns the retention of each target kept longer or shorter than the global max_age
+func (c *Config) RetentionOverrides() map[string]time.Duration {
+	global := c.Retention.GetMaxAge()
+	overrides := make(map[string]time.Duration)
+	for i := range c.Targets {
+		if age := c.GetTargetRetention(&c.Targets[i]); age != global {
+			overrides[c.Targets[i].Name] = age
+		}
+	}
+	return overrides
+}
+
+// TargetIntervals returns the collection interval of each target by name
+func (c *Config) TargetIntervals() map[string]time.Duration {
+	intervals := make(map[string]time.Duration, len(c.Targets))
+	for i := range c.Targets {
+		intervals[c.Targets[i].Name] = c.Targets[i].GetInterval()
+	}
+	return intervals
+}
+
 // DeleteTarget removes a target from the configuration
 func (m *Manager) DeleteTarget(name string) error {
 	m.mu.Lock()
@@ -502,6 +2551,60 @@ func (m *Manager) DeleteTarget(name string) error {
 	return fmt.Errorf("target '%s' not found", name)
 }
 
+// ReplaceTargets swaps in a complete target list, e.g., from a bulk import
+// Names must be unique; other validation is up to the caller
+func (m *Manager) ReplaceTargets(targets []TargetConfig) error {
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate target name '%s'", t.Name)
+		}
+		seen[t.Name] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := *m.config
+	next.Targets = append([]TargetConfig{}, targets...)
+	m.config = &next
+	return nil
+}
+
+// AddDerivedMetric adds a derived metric definition
+func (m *Manager) AddDerivedMetric(metric DerivedMetricConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range m.config.DerivedMetrics {
+		if d.Name == metric.Name {
+			return fmt.Errorf("derived metric '%s' already exists", metric.Name)
+		}
+	}
+
+	next := *m.config
+	next.DerivedMetrics = append(append([]DerivedMetricConfig{}, m.config.DerivedMetrics...), metric)
+	m.config = &next
+	return nil
+}
+
+// DeleteDerivedMetric removes a derived metric definition
+func (m *Manager) DeleteDerivedMetric(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, d := range m.config.DerivedMetrics {
+		if d.Name == name {
+			next := *m.config
+			next.DerivedMetrics = append(append([]DerivedMetricConfig{}, m.config.DerivedMetrics[:i]...), m.config.DerivedMetrics[i+1:]...)
+			m.config = &next
+			return nil
+		}
+	}
+
+	return fmt.Errorf("derived metric '%s' not found", name)
+}
+
 // GetTarget returns a target by name
 func (m *Manager) GetTarget(name string) (*TargetConfig, error) {
 	m.mu.RLock()
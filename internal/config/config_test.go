@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -77,6 +79,28 @@ func TestRetentionConfig_GetCleanupInterval(t *testing.T) {
 	}
 }
 
+func TestRollupConfig_Defaults(t *testing.T) {
+	var r RollupConfig
+	if !r.IsEnabled() {
+		t.Error("rollups should be enabled by default")
+	}
+	if r.GetMinuteMaxAge() != 90*24*time.Hour || r.GetHourMaxAge() != 365*24*time.Hour {
+		t.Errorf("defaults = %v / %v, want 90d / 365d", r.GetMinuteMaxAge(), r.GetHourMaxAge())
+	}
+
+	disabled := false
+	r = RollupConfig{Enabled: &disabled, MinuteMaxAge: "14d", HourMaxAge: "2y"}
+	if r.IsEnabled() {
+		t.Error("IsEnabled() = true, want false")
+	}
+	if r.GetMinuteMaxAge() != 14*24*time.Hour {
+		t.Errorf("GetMinuteMaxAge() = %v, want 14d", r.GetMinuteMaxAge())
+	}
+	if r.GetHourMaxAge() != 365*24*time.Hour {
+		t.Errorf("invalid hour_max_age should use default, got %v", r.GetHourMaxAge())
+	}
+}
+
 func TestConfig_GetLocation(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -180,3 +204,768 @@ targets:
 		t.Errorf("expected group 'test', got %s", cfg.Targets[0].Group)
 	}
 }
+
+func TestExpandValue(t *testing.T) {
+	t.Setenv("PONDY_TEST_HOST", "smtp.example.com")
+	t.Setenv("PONDY_TEST_EMPTY", "")
+
+	secret := filepath.Join(t.TempDir(), "slack")
+	if err := os.WriteFile(secret, []byte("https://hooks.slack.com/services/T0/B0/x\n"), 0600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "plain", false},
+		{"${PONDY_TEST_HOST}", "smtp.example.com", false},
+		{"smtp://${PONDY_TEST_HOST}:25", "smtp://smtp.example.com:25", false},
+		{"${PONDY_TEST_UNSET:-fallback}", "fallback", false},
+		{"${PONDY_TEST_EMPTY:-fallback}", "fallback", false},
+		{"${PONDY_TEST_EMPTY}", "", false},
+		{"${file:" + secret + "}", "https://hooks.slack.com/services/T0/B0/x", false},
+		{"$${PONDY_TEST_HOST}", "${PONDY_TEST_HOST}", false},
+		{"${PONDY_TEST_UNSET}", "", true},
+		{"${file:" + secret + ".missing}", "", true},
+		{"${}", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := expandValue(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("expandValue(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("expandValue(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_Interpolation(t *testing.T) {
+	t.Setenv("PONDY_TEST_PORT", "9191")
+	t.Setenv("PONDY_TEST_SMTP_PASSWORD", "s3cret")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `
+server:
+  port: ${PONDY_TEST_PORT}
+alerting:
+  channels:
+    email:
+      enabled: true
+      password: "${PONDY_TEST_SMTP_PASSWORD}"
+    notion:
+      token: ${PONDY_TEST_NOTION_TOKEN:-none}
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Port != 9191 {
+		t.Errorf("expected port 9191, got %d", cfg.Server.Port)
+	}
+	if cfg.Alerting.Channels.Email.Password != "s3cret" {
+		t.Errorf("expected expanded SMTP password, got %q", cfg.Alerting.Channels.Email.Password)
+	}
+	if cfg.Alerting.Channels.Notion.Token != "none" {
+		t.Errorf("expected default Notion token, got %q", cfg.Alerting.Channels.Notion.Token)
+	}
+
+	if err := os.WriteFile(configPath, []byte("server:\n  port: ${PONDY_TEST_UNSET_PORT}\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if _, err := Load(configPath); err == nil {
+		t.Error("Load() should fail when a referenced variable is not set")
+	}
+}
+
+func TestLoad_ResolveCondition(t *testing.T) {
+	validate := ValidateCondition
+	defer func() { ValidateCondition = validate }()
+	ValidateCondition = func(condition string, derived ...string) error {
+		if condition != "usage < 80" {
+			return fmt.Errorf("unknown condition %q", condition)
+		}
+		return nil
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(resolve string) {
+		content := "alerting:\n  rules:\n    - name: high_usage\n      condition: usage > 90\n      resolve_condition: " + resolve + "\n"
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+	}
+
+	write("usage < 80")
+	if _, err := Load(configPath); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	write("usage <")
+	if _, err := Load(configPath); err == nil {
+		t.Error("Load() should fail on an invalid resolve_condition")
+	}
+}
+
+func TestLoad_ChannelTemplates(t *testing.T) {
+	validate := ValidateChannelTemplates
+	defer func() { ValidateChannelTemplates = validate }()
+	ValidateChannelTemplates = func(channels *ChannelsConfig) error {
+		if channels.Webhook.Template != nil && channels.Webhook.Template.Body != "{}" {
+			return fmt.Errorf("webhook template: body is not valid JSON")
+		}
+		return nil
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	for body, valid := range map[string]bool{"'{}'": true, "'{'": false} {
+		content := "alerting:\n  channels:\n    webhook:\n      template:\n        body: " + body + "\n"
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		if _, err := Load(configPath); (err == nil) != valid {
+			t.Errorf("Load() with webhook body %s error = %v, want valid %v", body, err, valid)
+		}
+	}
+}
+
+func TestLoad_ExternalURL(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	for url, valid := range map[string]bool{"https://pondy.example.com": true, "pondy.example.com": false, "ftp://pondy.example.com": false} {
+		if err := os.WriteFile(configPath, []byte("alerting:\n  external_url: "+url+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		if _, err := Load(configPath); (err == nil) != valid {
+			t.Errorf("Load() with external_url %s error = %v, want valid %v", url, err, valid)
+		}
+	}
+}
+
+func TestManager_SaveConfig_KeepsReferences(t *testing.T) {
+	t.Setenv("PONDY_TEST_SLACK_URL", "https://hooks.slack.com/services/T0/B0/x")
+	t.Setenv("PONDY_TEST_OPTIONAL", "")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `
+alerting:
+  channels:
+    slack:
+      enabled: true
+      webhook_url: ${PONDY_TEST_SLACK_URL}
+      channel: ${PONDY_TEST_OPTIONAL}
+    email:
+      password: ${PONDY_TEST_OPTIONAL:-}
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	_, templates, err := readInterpolated(configPath)
+	if err != nil {
+		t.Fatalf("readInterpolated() error = %v", err)
+	}
+
+	cfg.Alerting.Enabled = true
+	m := &Manager{config: cfg, templates: templates, configPath: configPath}
+	if err := m.SaveConfig(); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read saved config: %v", err)
+	}
+	saved := string(data)
+	if strings.Contains(saved, "hooks.slack.com") {
+		t.Errorf("saved config contains the expanded webhook URL:\n%s", saved)
+	}
+	for _, ref := range []string{"${PONDY_TEST_SLACK_URL}", "${PONDY_TEST_OPTIONAL}", "${PONDY_TEST_OPTIONAL:-}"} {
+		if !strings.Contains(saved, ref) {
+			t.Errorf("saved config should keep %s:\n%s", ref, saved)
+		}
+	}
+
+	reloaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() of saved config error = %v", err)
+	}
+	if !reloaded.Alerting.Enabled || reloaded.Alerting.Channels.Slack.WebhookURL != "https://hooks.slack.com/services/T0/B0/x" {
+		t.Errorf("saved config did not round-trip: %+v", reloaded.Alerting)
+	}
+}
+
+func TestAlertingConfig_Clone(t *testing.T) {
+	enabled := true
+	orig := AlertingConfig{
+		Rules: []AlertRule{{Name: "high_usage", Condition: "usage > 80", Enabled: &enabled, Channels: []string{"slack"}}},
+		Channels: ChannelsConfig{
+			Webhook: WebhookConfig{Headers: map[string]string{"X-Token": "a"}},
+			Email:   EmailConfig{To: []string{"ops@example.com"}},
+			Plugins: []PluginConfig{{Name: "p", Headers: map[string]string{"K": "v"}}},
+		},
+	}
+
+	clone := orig.Clone()
+	clone.Rules[0].Name = "changed"
+	*clone.Rules[0].Enabled = false
+	clone.Rules[0].Channels[0] = "discord"
+	clone.Channels.Webhook.Headers["X-Token"] = "b"
+	clone.Channels.Email.To[0] = "other@example.com"
+	clone.Channels.Plugins[0].Headers["K"] = "changed"
+
+	if orig.Rules[0].Name != "high_usage" || !*orig.Rules[0].Enabled || orig.Rules[0].Channels[0] != "slack" {
+		t.Error("Clone should not share rules with the original")
+	}
+	if orig.Channels.Webhook.Headers["X-Token"] != "a" {
+		t.Error("Clone should not share webhook headers with the original")
+	}
+	if orig.Channels.Email.To[0] != "ops@example.com" {
+		t.Error("Clone should not share email recipients with the original")
+	}
+	if orig.Channels.Plugins[0].Headers["K"] != "v" {
+		t.Error("Clone should not share plugin headers with the original")
+	}
+}
+
+func TestManager_UpdateAlerting(t *testing.T) {
+	m := &Manager{config: &Config{Alerting: AlertingConfig{Enabled: false}}}
+	before := m.Get()
+
+	updated, err := m.UpdateAlerting(func(a *AlertingConfig) error {
+		a.Enabled = true
+		a.Cooldown = time.Minute
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateAlerting() error = %v", err)
+	}
+
+	if !updated.Enabled || updated.Cooldown != time.Minute {
+		t.Errorf("returned config not updated: %+v", updated)
+	}
+	if !m.Get().Alerting.Enabled {
+		t.Error("manager config should reflect the update")
+	}
+	if before.Alerting.Enabled {
+		t.Error("previously returned config must not be mutated")
+	}
+}
+
+func TestManager_UpdateAlerting_Error(t *testing.T) {
+	m := &Manager{config: &Config{Alerting: AlertingConfig{Enabled: true}}}
+
+	_, err := m.UpdateAlerting(func(a *AlertingConfig) error {
+		a.Enabled = false
+		return os.ErrInvalid
+	})
+	if err == nil {
+		t.Fatal("expected error from UpdateAlerting")
+	}
+	if !m.Get().Alerting.Enabled {
+		t.Error("config should be unchanged when update fails")
+	}
+}
+
+func TestAlertingConfig_GetRepeatInterval(t *testing.T) {
+	a := AlertingConfig{
+		RepeatInterval: time.Hour,
+		Rules: []AlertRule{
+			{Name: "pool_exhausted", RepeatInterval: 10 * time.Minute},
+			{Name: "high_usage"},
+		},
+	}
+
+	if got := a.GetRepeatInterval("pool_exhausted"); got != 10*time.Minute {
+		t.Errorf("rule override = %v, want 10m", got)
+	}
+	if got := a.GetRepeatInterval("high_usage"); got != time.Hour {
+		t.Errorf("global fallback = %v, want 1h", got)
+	}
+	if got := (&AlertingConfig{}).GetRepeatInterval("high_usage"); got != 0 {
+		t.Errorf("default = %v, want 0 (disabled)", got)
+	}
+}
+
+func TestGroupingConfig_Defaults(t *testing.T) {
+	g := GroupingConfig{}
+
+	if g.GetWindow() != 30*time.Second {
+		t.Errorf("GetWindow() = %v, want 30s", g.GetWindow())
+	}
+	if by := g.GetBy(); len(by) != 2 || by[0] != GroupByRule || by[1] != GroupByTarget {
+		t.Errorf("GetBy() = %v, want [rule target]", by)
+	}
+	if err := (&GroupingConfig{By: []string{"rule", "instance"}}).Validate(); err == nil {
+		t.Error("Validate() should reject unknown keys")
+	}
+}
+
+func TestChannelsConfig_ValidateRuleChannels(t *testing.T) {
+	channels := ChannelsConfig{
+		Slack:   SlackConfig{Enabled: true},
+		Discord: DiscordConfig{Enabled: false},
+		Plugins: []PluginConfig{{Name: "PagerDuty", Enabled: true}},
+	}
+
+	tests := []struct {
+		channels []string
+		wantErr  bool
+	}{
+		{nil, false},
+		{[]string{"slack"}, false},
+		{[]string{"Slack", "plugin:pagerduty"}, false},
+		{[]string{"discord"}, true},
+		{[]string{"pagerduty"}, true},
+	}
+
+	for _, tt := range tests {
+		err := channels.ValidateRuleChannels(tt.channels)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateRuleChannels(%v) error = %v, wantErr %v", tt.channels, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	got, err := ParseLabelSelector("team:payments, env=prod")
+	if err != nil {
+		t.Fatalf("ParseLabelSelector() error = %v", err)
+	}
+	if len(got) != 2 || got["team"] != "payments" || got["env"] != "prod" {
+		t.Errorf("ParseLabelSelector() = %v, want team=payments env=prod", got)
+	}
+
+	for _, s := range []string{"", "team", "team:", ":payments", "bad key:x"} {
+		if _, err := ParseLabelSelector(s); err == nil {
+			t.Errorf("ParseLabelSelector(%q) = nil error, want error", s)
+		}
+	}
+}
+
+func TestMatchLabels(t *testing.T) {
+	target := TargetConfig{Name: "orders", Labels: map[string]string{"env": "prod", "team": "payments"}}
+
+	tests := []struct {
+		selector map[string]string
+		want     bool
+	}{
+		{nil, true},
+		{map[string]string{"team": "payments"}, true},
+		{map[string]string{"team": "payments", "env": "prod"}, true},
+		{map[string]string{"team": "payments", "env": "staging"}, false},
+		{map[string]string{"db": "orders-postgres"}, false},
+	}
+	for _, tt := range tests {
+		if got := target.HasLabels(tt.selector); got != tt.want {
+			t.Errorf("HasLabels(%v) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+
+	if err := ValidateLabels(map[string]string{"db": "orders-postgres", "k8s.io/app": "api"}); err != nil {
+		t.Errorf("ValidateLabels() error = %v", err)
+	}
+	if err := ValidateLabels(map[string]string{"team": "a,b"}); err == nil {
+		t.Error("ValidateLabels() should reject values with commas")
+	}
+}
+
+func TestAlertRule_AppliesToTarget(t *testing.T) {
+	labels := map[string]string{"team": "payments"}
+
+	tests := []struct {
+		name string
+		rule AlertRule
+		want bool
+	}{
+		{"all targets", AlertRule{}, true},
+		{"listed target", AlertRule{Targets: []string{"orders", "billing"}}, true},
+		{"other target", AlertRule{Targets: []string{"billing"}}, false},
+		{"listed group", AlertRule{Groups: []string{"shop"}}, true},
+		{"other group", AlertRule{Groups: []string{"infra"}}, false},
+		{"target and labels", AlertRule{Targets: []string{"orders"}, Labels: map[string]string{"team": "payments"}}, true},
+		{"other labels", AlertRule{Labels: map[string]string{"team": "search"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.AppliesToTarget("orders", "shop", labels); got != tt.want {
+			t.Errorf("%s: AppliesToTarget() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTargetDiscoveryConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       TargetDiscoveryConfig
+		wantErr bool
+	}{
+		{"eureka", TargetDiscoveryConfig{Type: RegistryEureka, Service: "orders"}, false},
+		{"missing service", TargetDiscoveryConfig{Type: RegistryConsul}, true},
+		{"unknown type", TargetDiscoveryConfig{Type: "zookeeper", Service: "orders"}, true},
+		{"dns a", TargetDiscoveryConfig{Type: RegistryDNS, Service: "orders.local", Port: 8080}, false},
+		{"dns a without port", TargetDiscoveryConfig{Type: RegistryDNS, Service: "orders.local"}, true},
+		{"dns srv", TargetDiscoveryConfig{Type: RegistryDNS, Service: "_http._tcp.orders.local", Record: "SRV"}, false},
+		{"dns unknown record", TargetDiscoveryConfig{Type: RegistryDNS, Service: "orders.local", Record: "txt"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.d.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_ServerLimits(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `
+server:
+  port: 8080
+  max_body_bytes: 1048576
+  cors:
+    allowed_origins: ["https://grafana.example.com"]
+  rate_limits:
+    general:
+      rate: 500
+    ingest:
+      rate: 5
+      interval: 1m
+      burst: 5
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	limits := cfg.Server.RateLimits.Limits()
+	// Unset fields keep their defaults
+	if got, want := limits[RateLimitGeneral], (RateLimitConfig{Rate: 500, Interval: time.Second, Burst: 200}); got != want {
+		t.Errorf("general = %+v, want %+v", got, want)
+	}
+	if got, want := limits[RateLimitIngest], (RateLimitConfig{Rate: 5, Interval: time.Minute, Burst: 5}); got != want {
+		t.Errorf("ingest = %+v, want %+v", got, want)
+	}
+	if got, want := limits[RateLimitTestAlert], (RateLimitConfig{Rate: 1, Interval: 10 * time.Second, Burst: 3}); got != want {
+		t.Errorf("test_alert = %+v, want %+v", got, want)
+	}
+	if got := cfg.Server.GetMaxBodyBytes(); got != 1048576 {
+		t.Errorf("GetMaxBodyBytes() = %d, want 1048576", got)
+	}
+	if got := cfg.Server.CORS.GetAllowedOrigins(); len(got) != 1 || got[0] != "https://grafana.example.com" {
+		t.Errorf("GetAllowedOrigins() = %v", got)
+	}
+
+	var empty ServerConfig
+	if got := empty.CORS.GetAllowedOrigins(); len(got) != 1 || got[0] != "*" {
+		t.Errorf("default GetAllowedOrigins() = %v, want [*]", got)
+	}
+}
+
+func TestManager_SetTargetPaused(t *testing.T) {
+	previous := &Config{Targets: []TargetConfig{{Name: "orders"}, {Name: "payments"}}}
+	m := &Manager{config: previous}
+
+	target, err := m.SetTargetPaused("orders", true, "migration")
+	if err != nil {
+		t.Fatalf("SetTargetPaused() error = %v", err)
+	}
+	if !target.Paused || target.PauseReason != "migration" {
+		t.Errorf("target = %+v, want paused with reason", target)
+	}
+	if got := m.Get().PausedTargets(); len(got) != 1 || got[0] != "orders" {
+		t.Errorf("PausedTargets() = %v, want [orders]", got)
+	}
+	// Readers holding the previous config don't see the change
+	if previous.Targets[0].Paused {
+		t.Error("previous config was modified")
+	}
+
+	// Resuming clears the reason
+	target, _ = m.SetTargetPaused("orders", false, "ignored")
+	if target.Paused || target.PauseReason != "" {
+		t.Errorf("resumed target = %+v", target)
+	}
+	if _, err := m.SetTargetPaused("missing", true, ""); err == nil {
+		t.Error("expected error for unknown target")
+	}
+}
+
+func TestManager_RenameTarget(t *testing.T) {
+	previous := &Config{Targets: []TargetConfig{{Name: "orders", Group: "prod"}, {Name: "payments"}}}
+	m := &Manager{config: previous}
+
+	if _, err := m.RenameTarget("orders", "payments"); err == nil {
+		t.Error("expected error for a taken name")
+	}
+	if _, err := m.RenameTarget("missing", "other"); err == nil {
+		t.Error("expected error for unknown target")
+	}
+
+	target, err := m.RenameTarget("orders", "order-service")
+	if err != nil {
+		t.Fatalf("RenameTarget() error = %v", err)
+	}
+	if target.Name != "order-service" || target.Group != "prod" {
+		t.Errorf("target = %+v, want renamed with settings kept", target)
+	}
+	if _, err := m.GetTarget("order-service"); err != nil {
+		t.Errorf("GetTarget() after rename error = %v", err)
+	}
+	if previous.Targets[0].Name != "orders" {
+		t.Error("previous config was modified")
+	}
+}
+
+func TestReconfigureConfig(t *testing.T) {
+	var r ReconfigureConfig
+	if r.GetMode() != ReconfigureModeEnv || r.GetPropertyPrefix() != "spring.datasource.hikari" || !r.IsRefreshEnabled() {
+		t.Errorf("defaults = %s / %s / %v", r.GetMode(), r.GetPropertyPrefix(), r.IsRefreshEnabled())
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	if err := (&ReconfigureConfig{Mode: ReconfigureModeCustom}).Validate(); err == nil {
+		t.Error("custom mode without a path should be invalid")
+	}
+	if err := (&ReconfigureConfig{Mode: "jmx"}).Validate(); err == nil {
+		t.Error("unknown mode should be invalid")
+	}
+}
+
+func TestTargetConfig_DiagnosticsEnabled(t *testing.T) {
+	var target TargetConfig
+	if target.DiagnosticsEnabled() {
+		t.Error("dump capture should be disabled by default")
+	}
+	target.Diagnostics = &DiagnosticsConfig{Enabled: true}
+	if !target.DiagnosticsEnabled() {
+		t.Error("dump capture should be enabled")
+	}
+}
+
+func TestAnomalyConfig(t *testing.T) {
+	var a *AnomalyConfig
+	if a.GetAlgorithm() != "zscore" || a.GetSensitivity() != "medium" {
+		t.Errorf("defaults = %s / %s, want zscore / medium", a.GetAlgorithm(), a.GetSensitivity())
+	}
+
+	if err := (&AnomalyConfig{Algorithm: "mad", Sensitivity: "high"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (&AnomalyConfig{Algorithm: "prophet"}).Validate(); err == nil {
+		t.Error("unknown algorithm should be invalid")
+	}
+	if err := (&AnomalyConfig{Sensitivity: "extreme"}).Validate(); err == nil {
+		t.Error("unknown sensitivity should be invalid")
+	}
+}
+
+func TestAnomalyRuleConfig(t *testing.T) {
+	var a *AnomalyRuleConfig
+	if a.GetWindow() != time.Hour || a.GetRiskLevel() != "elevated" || !a.AppliesTo("orders") {
+		t.Errorf("defaults = %v / %s / %v", a.GetWindow(), a.GetRiskLevel(), a.AppliesTo("orders"))
+	}
+	if err := a.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	a = &AnomalyRuleConfig{Targets: []string{"orders"}, RiskLevel: "high"}
+	if !a.AppliesTo("orders") || a.AppliesTo("billing") {
+		t.Error("rule should only apply to its targets")
+	}
+	if err := (&AnomalyRuleConfig{RiskLevel: "normal"}).Validate(); err == nil {
+		t.Error("normal risk level should be invalid")
+	}
+	if err := (&AnomalyRuleConfig{Algorithm: "stddev"}).Validate(); err == nil {
+		t.Error("unknown algorithm should be invalid")
+	}
+}
+
+func TestLeakRuleConfig(t *testing.T) {
+	var l *LeakRuleConfig
+	if l.GetInterval() != 5*time.Minute || l.GetWindow() != time.Hour || l.GetRiskLevel() != "medium" {
+		t.Errorf("defaults = %v / %v / %s", l.GetInterval(), l.GetWindow(), l.GetRiskLevel())
+	}
+	if err := l.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (&LeakRuleConfig{RiskLevel: "low"}).Validate(); err == nil {
+		t.Error("low risk level should be invalid")
+	}
+
+	rule := AlertRule{Type: AlertRuleTypeLeak}
+	if rule.IsCondition() || !rule.IsLeak() {
+		t.Error("leak rule should not be a condition rule")
+	}
+}
+
+func TestGetTargetRetention(t *testing.T) {
+	cfg := &Config{
+		Retention: RetentionConfig{MaxAge: "30d", Groups: map[string]string{"prod": "90d", "dev": "7d"}},
+		Targets: []TargetConfig{
+			{Name: "orders", Group: "prod"},
+			{Name: "sandbox", Group: "dev", Retention: "36h"},
+			{Name: "billing", Group: "staging"},
+		},
+	}
+
+	want := map[string]time.Duration{"orders": 90 * 24 * time.Hour, "sandbox": 36 * time.Hour, "billing": 30 * 24 * time.Hour}
+	for i := range cfg.Targets {
+		if got := cfg.GetTargetRetention(&cfg.Targets[i]); got != want[cfg.Targets[i].Name] {
+			t.Errorf("%s: retention = %v, want %v", cfg.Targets[i].Name, got, want[cfg.Targets[i].Name])
+		}
+	}
+
+	overrides := cfg.RetentionOverrides()
+	if len(overrides) != 2 || overrides["billing"] != 0 {
+		t.Errorf("overrides = %v, want orders and sandbox only", overrides)
+	}
+
+	if _, err := ParseMaxAge("soon"); err == nil {
+		t.Error("ParseMaxAge() should reject invalid ages")
+	}
+}
+
+func TestOTLPExportConfig(t *testing.T) {
+	o := OTLPExportConfig{Endpoint: "http://collector:4318/"}
+	if o.GetURL() != "http://collector:4318/v1/metrics" || o.GetInterval() != 30*time.Second || o.GetTimeout() != 10*time.Second {
+		t.Errorf("defaults = %s / %v / %v", o.GetURL(), o.GetInterval(), o.GetTimeout())
+	}
+	if o := (OTLPExportConfig{Endpoint: "https://otlp.example.com/v1/metrics"}); o.GetURL() != "https://otlp.example.com/v1/metrics" {
+		t.Errorf("GetURL() = %s", o.GetURL())
+	}
+
+	for _, endpoint := range []string{"", "collector:4317", "grpc://collector:4317"} {
+		o := OTLPExportConfig{Enabled: true, Endpoint: endpoint}
+		if err := o.Validate(); err == nil {
+			t.Errorf("endpoint %q should be invalid", endpoint)
+		}
+	}
+}
+
+func TestInfluxExportConfig(t *testing.T) {
+	v2 := InfluxExportConfig{Enabled: true, URL: "http://influxdb:8086/", Bucket: "pondy", Org: "ops"}
+	if err := v2.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if got := v2.GetWriteURL(); got != "http://influxdb:8086/api/v2/write?bucket=pondy&org=ops&precision=ns" {
+		t.Errorf("GetWriteURL() = %s", got)
+	}
+	v1 := InfluxExportConfig{URL: "http://influxdb:8086", Database: "pondy"}
+	if got := v1.GetWriteURL(); got != "http://influxdb:8086/write?db=pondy&precision=ns" {
+		t.Errorf("GetWriteURL() = %s", got)
+	}
+	if v1.GetMeasurement() != "pondy_pool" || v1.GetBatchSize() != 500 || v1.GetBufferSize() != 100000 {
+		t.Errorf("defaults = %s / %d / %d", v1.GetMeasurement(), v1.GetBatchSize(), v1.GetBufferSize())
+	}
+
+	for _, c := range []InfluxExportConfig{
+		{Enabled: true, URL: "influxdb:8086", Database: "pondy"},
+		{Enabled: true, URL: "http://influxdb:8086"},
+		{Enabled: true, URL: "http://influxdb:8086", Database: "pondy", Bucket: "pondy"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%+v should be invalid", c)
+		}
+	}
+}
+
+func TestAlertingConfig_ValidateExternalURL(t *testing.T) {
+	for _, u := range []string{"", "https://pondy.example.com", "http://10.0.0.5:8080/pondy"} {
+		a := AlertingConfig{ExternalURL: u}
+		if err := a.ValidateExternalURL(); err != nil {
+			t.Errorf("ValidateExternalURL(%q) error = %v", u, err)
+		}
+	}
+	for _, u := range []string{"pondy.example.com", "ftp://pondy.example.com", "https://"} {
+		a := AlertingConfig{ExternalURL: u}
+		if err := a.ValidateExternalURL(); err == nil {
+			t.Errorf("ValidateExternalURL(%q) should fail", u)
+		}
+	}
+}
+
+func TestClusterConfig(t *testing.T) {
+	c := ClusterConfig{Enabled: true, HeartbeatInterval: 5 * time.Second}
+	if c.GetNodeTTL() != 15*time.Second || c.GetNodeID() == "" {
+		t.Errorf("defaults = %v / %q", c.GetNodeTTL(), c.GetNodeID())
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	c.NodeTTL = 5 * time.Second
+	if err := c.Validate(); err == nil {
+		t.Error("node_ttl not longer than heartbeat_interval should be invalid")
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	valid := []TLSConfig{
+		{},
+		{CertFile: "server.crt", KeyFile: "server.key"},
+		{CertFile: "server.crt", KeyFile: "server.key", ClientCAFile: "ca.crt", ClientAuth: ClientAuthOptional, MinVersion: "1.3"},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validate(%+v) error = %v", c, err)
+		}
+	}
+
+	invalid := []TLSConfig{
+		{CertFile: "server.crt"},
+		{ClientCAFile: "ca.crt"},
+		{CertFile: "server.crt", KeyFile: "server.key", ClientAuth: ClientAuthRequire},
+		{CertFile: "server.crt", KeyFile: "server.key", ClientCAFile: "ca.crt", ClientAuth: "always"},
+		{CertFile: "server.crt", KeyFile: "server.key", MinVersion: "1.0"},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", c)
+		}
+	}
+
+	c := TLSConfig{CertFile: "server.crt", KeyFile: "server.key", ClientCAFile: "ca.crt"}
+	if c.GetClientAuth() != ClientAuthRequire || c.GetReloadInterval() != 30*time.Second {
+		t.Errorf("defaults = %q / %v", c.GetClientAuth(), c.GetReloadInterval())
+	}
+}
+
+func TestEventWebhookConfig(t *testing.T) {
+	w := EventWebhookConfig{Name: "cmdb", URL: "https://cmdb.example.com/hooks/pondy", Events: []string{EventTargetCreated, EventTargetDeleted}}
+	if err := w.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !w.Wants(EventTargetCreated) || w.Wants(EventCollectorDown) {
+		t.Error("Wants() should follow the events list")
+	}
+	if all := (EventWebhookConfig{}); !all.Wants(EventBackupCompleted) {
+		t.Error("webhook without events should want every event")
+	}
+
+	for _, c := range []EventWebhookConfig{
+		{URL: "https://cmdb.example.com"},
+		{Name: "cmdb", URL: "cmdb.example.com"},
+		{Name: "cmdb", URL: "https://cmdb.example.com", Events: []string{"target.renamed"}},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", c)
+		}
+	}
+}
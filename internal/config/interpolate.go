@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// interpolationPattern matches ${...} references and the $${ escape
+var interpolationPattern = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+
+// secretFilePrefix marks a reference to a file, such as a Docker or Kubernetes secret
+const secretFilePrefix = "file:"
+
+// interpolation is a config value that contained references, kept so SaveConfig
+// can write the reference back instead of the secret it expanded to
+type interpolation struct {
+	raw      string
+	expanded string
+}
+
+// expandValue replaces references in s:
+//
+//	${VAR}              environment variable, an error if not set
+//	${VAR:-default}     environment variable, default if not set or empty
+//	${file:/path}       file contents without surrounding whitespace
+//	$${                 a literal ${
+func expandValue(s string) (string, error) {
+	var firstErr error
+	out := interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		ref := match[2 : len(match)-1]
+
+		if path, ok := strings.CutPrefix(ref, secretFilePrefix); ok {
+			data, err := os.ReadFile(path)
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("failed to read secret file: %w", err)
+			}
+			return strings.TrimSpace(string(data))
+		}
+
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if name == "" {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("empty variable name in %s", match)
+			}
+			return ""
+		}
+		if v, ok := os.LookupEnv(name); ok && (v != "" || !hasDefault) {
+			return v
+		}
+		if !hasDefault && firstErr == nil {
+			firstErr = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return def
+	})
+	return out, firstErr
+}
+
+// readInterpolated reads a YAML config file and expands references in its string values
+// Returns the expanded document and the original values by path
+func readInterpolated(path string) ([]byte, map[string]interpolation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil, nil
+	}
+
+	templates := make(map[string]interpolation)
+	if err := expandNode(doc.Content[0], "", templates); err != nil {
+		return nil, nil, err
+	}
+	if len(templates) == 0 {
+		return data, nil, nil
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, templates, nil
+}
+
+// expandNode expands the scalars below n, recording changed values under their dotted path
+func expandNode(n *yaml.Node, path string, templates map[string]interpolation) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := expandNode(n.Content[i+1], joinPath(path, n.Content[i].Value), templates); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range n.Content {
+			if err := expandNode(child, joinPath(path, strconv.Itoa(i)), templates); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(n.Value, "${") {
+			return nil
+		}
+		expanded, err := expandValue(n.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		templates[path] = interpolation{raw: n.Value, expanded: expanded}
+		n.Value = expanded
+		if n.Style == 0 {
+			// Let an unquoted value such as port: ${PORT} resolve to its expanded type
+			n.Tag = ""
+		}
+	}
+	return nil
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// restoreTemplates writes the original references back into a marshaled config
+// where the value is still the one they expanded to, so secrets never end up in the file
+func restoreTemplates(data []byte, templates map[string]interpolation) ([]byte, error) {
+	if len(templates) == 0 {
+		return data, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+
+	for path, tmpl := range templates {
+		restoreTemplate(doc.Content[0], strings.Split(path, "."), tmpl)
+	}
+	return yaml.Marshal(&doc)
+}
+
+func restoreTemplate(n *yaml.Node, keys []string, tmpl interpolation) {
+	inList := false
+	for i, key := range keys {
+		switch n.Kind {
+		case yaml.MappingNode:
+			var next *yaml.Node
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				if n.Content[j].Value == key {
+					next = n.Content[j+1]
+					break
+				}
+			}
+			if next == nil {
+				// An empty expansion is dropped by omitempty, with its section if nothing else is set;
+				// keep the reference unless list items may have moved since the file was read
+				if tmpl.expanded == "" && !inList {
+					insertTemplate(n, keys[i:], tmpl.raw)
+				}
+				return
+			}
+			n = next
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx >= len(n.Content) {
+				return
+			}
+			n = n.Content[idx]
+			inList = true
+		default:
+			return
+		}
+	}
+
+	if n.Kind == yaml.ScalarNode && n.Value == tmpl.expanded {
+		n.Value = tmpl.raw
+		n.Tag = ""
+		n.Style = yaml.DoubleQuotedStyle
+	}
+}
+
+// insertTemplate adds the reference under keys to mapping n, creating missing sections
+// Nothing is added below a dropped list, whose items can't be told apart
+func insertTemplate(n *yaml.Node, keys []string, raw string) {
+	for _, key := range keys {
+		if _, err := strconv.Atoi(key); err == nil {
+			return
+		}
+	}
+	for _, key := range keys[:len(keys)-1] {
+		child := &yaml.Node{Kind: yaml.MappingNode}
+		n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+		n = child
+	}
+	n.Content = append(n.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: keys[len(keys)-1]},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: raw, Style: yaml.DoubleQuotedStyle})
+}
+
+// loadConfigData reads the config file into viper with references expanded
+func loadConfigData(path string) (map[string]interpolation, error) {
+	data, templates, err := readInterpolated(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
@@ -0,0 +1,284 @@
+// Package derived computes user-defined series from collected metrics,
+// e.g., utilization = active / max * 100 or gc_rate = delta(gc_count) / interval.
+package derived
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/expr"
+	"github.com/jiin/pondy/internal/models"
+)
+
+// IntervalVariable is the number of seconds since the previous sample of the same instance
+const IntervalVariable = "interval"
+
+// errNoPrevious is returned by delta() and interval on the first sample of an instance
+var errNoPrevious = errors.New("no previous sample")
+
+// variables are the native metrics expressions can read
+var variables = map[string]func(m *models.PoolMetrics) float64{
+	"active":  func(m *models.PoolMetrics) float64 { return float64(m.Active) },
+	"idle":    func(m *models.PoolMetrics) float64 { return float64(m.Idle) },
+	"pending": func(m *models.PoolMetrics) float64 { return float64(m.Pending) },
+	"max":     func(m *models.PoolMetrics) float64 { return float64(m.Max) },
+	"usage": func(m *models.PoolMetrics) float64 {
+		if m.Max <= 0 {
+			return 0
+		}
+		return float64(m.Active) / float64(m.Max) * 100
+	},
+	"timeout":      func(m *models.PoolMetrics) float64 { return float64(m.Timeout) },
+	"timeout_rate": func(m *models.PoolMetrics) float64 { return m.TimeoutRate },
+	"acquire_p50":  func(m *models.PoolMetrics) float64 { return m.AcquireP50 },
+	"acquire_p95":  func(m *models.PoolMetrics) float64 { return m.AcquireP95 },
+	"acquire_p99":  func(m *models.PoolMetrics) float64 { return m.AcquireP99 },
+	"acquire_max":  func(m *models.PoolMetrics) float64 { return m.AcquireMax },
+	"heap_used":    func(m *models.PoolMetrics) float64 { return float64(m.HeapUsed) },
+	"heap_max":     func(m *models.PoolMetrics) float64 { return float64(m.HeapMax) },
+	"heap_usage": func(m *models.PoolMetrics) float64 {
+		if m.HeapMax <= 0 {
+			return 0
+		}
+		return float64(m.HeapUsed) / float64(m.HeapMax) * 100
+	},
+	"non_heap_used":       func(m *models.PoolMetrics) float64 { return float64(m.NonHeapUsed) },
+	"non_heap_max":        func(m *models.PoolMetrics) float64 { return float64(m.NonHeapMax) },
+	"threads_live":        func(m *models.PoolMetrics) float64 { return float64(m.ThreadsLive) },
+	"cpu_usage":           func(m *models.PoolMetrics) float64 { return m.CpuUsage },
+	"gc_count":            func(m *models.PoolMetrics) float64 { return float64(m.GcCount) },
+	"gc_time":             func(m *models.PoolMetrics) float64 { return m.GcTime },
+	"young_gc_count":      func(m *models.PoolMetrics) float64 { return float64(m.YoungGcCount) },
+	"old_gc_count":        func(m *models.PoolMetrics) float64 { return float64(m.OldGcCount) },
+	"gc_pauses_per_min":   func(m *models.PoolMetrics) float64 { return m.GcPausesPerMin },
+	"gc_pause_ms_per_min": func(m *models.PoolMetrics) float64 { return m.GcPauseMsPerMin },
+	"avg_gc_pause_ms":     func(m *models.PoolMetrics) float64 { return m.AvgGcPauseMs },
+}
+
+// counters are cumulative variables, a drop means the instance restarted
+var counters = map[string]bool{
+	"timeout": true, "gc_count": true, "gc_time": true, "young_gc_count": true, "old_gc_count": true,
+}
+
+// Variables returns the native variable names, sorted
+func Variables() []string {
+	names := make([]string, 0, len(variables)+1)
+	for name := range variables {
+		names = append(names, name)
+	}
+	names = append(names, IntervalVariable)
+	sort.Strings(names)
+	return names
+}
+
+// Metric is a compiled derived metric
+type Metric struct {
+	Name        string
+	Unit        string
+	Description string
+	Expr        *expr.Expr
+}
+
+// Compile parses and checks derived metric definitions
+// A metric may read native variables, interval, and derived metrics defined before it
+func Compile(defs []config.DerivedMetricConfig) ([]Metric, error) {
+	metrics := make([]Metric, 0, len(defs))
+	defined := make(map[string]bool)
+
+	for i := range defs {
+		def := &defs[i]
+		if err := def.Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := variables[def.Name]; ok || def.Name == IntervalVariable {
+			return nil, fmt.Errorf("derived metric '%s' shadows a native variable", def.Name)
+		}
+		if defined[def.Name] {
+			return nil, fmt.Errorf("duplicate derived metric '%s'", def.Name)
+		}
+
+		e, err := expr.Parse(def.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("derived metric '%s': %w", def.Name, err)
+		}
+		if e.MaxWindow() > 0 {
+			return nil, fmt.Errorf("derived metric '%s': windowed aggregates are only supported in alert conditions", def.Name)
+		}
+		for _, v := range e.Vars() {
+			if _, ok := variables[v]; !ok && v != IntervalVariable && !defined[v] {
+				return nil, fmt.Errorf("derived metric '%s': unknown variable '%s'. Valid variables: %s",
+					def.Name, v, strings.Join(Variables(), ", "))
+			}
+		}
+
+		defined[def.Name] = true
+		metrics = append(metrics, Metric{Name: def.Name, Unit: def.Unit, Description: def.Description, Expr: e})
+	}
+	return metrics, nil
+}
+
+// sampleEnv resolves variables for one sample
+type sampleEnv struct {
+	cur, prev *models.PoolMetrics
+	derived   map[string]float64
+}
+
+func (e *sampleEnv) Value(name string) (float64, error) {
+	if name == IntervalVariable {
+		if e.prev == nil {
+			return 0, errNoPrevious
+		}
+		return e.cur.Timestamp.Sub(e.prev.Timestamp).Seconds(), nil
+	}
+	if fn, ok := variables[name]; ok {
+		return fn(e.cur), nil
+	}
+	if v, ok := e.derived[name]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("no value for %s", name)
+}
+
+func (e *sampleEnv) Delta(name string) (float64, error) {
+	if e.prev == nil {
+		return 0, errNoPrevious
+	}
+	cur, err := e.Value(name)
+	if err != nil {
+		return 0, err
+	}
+
+	var prev float64
+	if fn, ok := variables[name]; ok {
+		prev = fn(e.prev)
+	} else if v, ok := e.prev.Derived[name]; ok {
+		prev = v
+	} else {
+		return 0, errNoPrevious
+	}
+
+	if counters[name] && cur < prev {
+		return cur, nil
+	}
+	return cur - prev, nil
+}
+
+// Evaluate computes derived values for cur given the previous sample of the same instance
+// Values that can't be computed, e.g., delta() on the first sample or a division by zero, are omitted
+func Evaluate(metrics []Metric, cur, prev *models.PoolMetrics) map[string]float64 {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	env := &sampleEnv{cur: cur, prev: prev, derived: make(map[string]float64, len(metrics))}
+	for _, m := range metrics {
+		v, err := m.Expr.Eval(env)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		env.derived[m.Name] = v
+	}
+	if len(env.derived) == 0 {
+		return nil
+	}
+	return env.derived
+}
+
+// Fill computes derived values for samples stored without them, e.g., rollups
+// Samples must be in time order; the previous sample is tracked per instance
+func Fill(metrics []Metric, series []models.PoolMetrics) {
+	if len(metrics) == 0 {
+		return
+	}
+	prev := make(map[string]*models.PoolMetrics)
+	for i := range series {
+		m := &series[i]
+		if m.Status == models.StatusError {
+			continue
+		}
+		if m.Derived == nil {
+			m.Derived = Evaluate(metrics, m, prev[m.InstanceName])
+		}
+		prev[m.InstanceName] = m
+	}
+}
+
+// prevTTL is how long the previous sample of an instance that stopped reporting is kept,
+// so removed targets and replaced pods don't accumulate
+const prevTTL = time.Hour
+
+// Evaluator computes derived metrics at collection time
+// It remembers the previous sample of each target instance for delta() and interval
+type Evaluator struct {
+	mu      sync.Mutex
+	metrics []Metric
+	prev    map[string]prevSample // key: "targetName/instanceName"
+	swept   time.Time             // Last removal of expired previous samples
+	now     func() time.Time
+}
+
+// prevSample is the previous sample of an instance and when it was processed
+type prevSample struct {
+	metrics models.PoolMetrics
+	seen    time.Time
+}
+
+// NewEvaluator creates an evaluator without metrics
+func NewEvaluator() *Evaluator {
+	return &Evaluator{prev: make(map[string]prevSample), now: time.Now}
+}
+
+// SetMetrics replaces the compiled derived metrics
+func (e *Evaluator) SetMetrics(metrics []Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.metrics = metrics
+}
+
+// Process sets the counter deltas and derived values of a freshly collected sample
+func (e *Evaluator) Process(m *models.PoolMetrics) {
+	if m == nil || m.Status == models.StatusError {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	e.sweep(now)
+
+	key := m.TargetName + "/" + m.InstanceName
+	p, seen := e.prev[key]
+	var prev *models.PoolMetrics
+	if seen && p.metrics.Timestamp.Before(m.Timestamp) {
+		prev = &p.metrics
+	}
+
+	m.SetCounterDeltas(prev)
+	m.Derived = Evaluate(e.metrics, m, prev)
+
+	// Late samples, e.g., pushed backfill, don't replace a newer previous sample
+	if !seen || prev != nil {
+		e.prev[key] = prevSample{metrics: *m, seen: now}
+	}
+}
+
+// sweep removes the previous samples of instances not seen within prevTTL,
+// at most once per prevTTL. Caller must hold e.mu
+func (e *Evaluator) sweep(now time.Time) {
+	if now.Sub(e.swept) < prevTTL {
+		return
+	}
+	e.swept = now
+	for key, p := range e.prev {
+		if now.Sub(p.seen) > prevTTL {
+			delete(e.prev, key)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package derived
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestCompile(t *testing.T) {
+	metrics, err := Compile([]config.DerivedMetricConfig{
+		{Name: "utilization", Expr: "active / max * 100", Unit: "%"},
+		{Name: "gc_rate", Expr: "delta(gc_count) / interval"},
+		{Name: "headroom", Expr: "100 - utilization"},
+	})
+	if err != nil {
+		t.Fatalf("Compile error: %v", err)
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("len(metrics) = %d, want 3", len(metrics))
+	}
+	if metrics[0].Unit != "%" {
+		t.Errorf("Unit = %s, want %%", metrics[0].Unit)
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		defs []config.DerivedMetricConfig
+	}{
+		{"missing name", []config.DerivedMetricConfig{{Expr: "active"}}},
+		{"uppercase name", []config.DerivedMetricConfig{{Name: "Util", Expr: "active"}}},
+		{"missing expr", []config.DerivedMetricConfig{{Name: "util"}}},
+		{"shadows native", []config.DerivedMetricConfig{{Name: "active", Expr: "idle"}}},
+		{"shadows interval", []config.DerivedMetricConfig{{Name: "interval", Expr: "idle"}}},
+		{"duplicate", []config.DerivedMetricConfig{{Name: "a", Expr: "idle"}, {Name: "a", Expr: "active"}}},
+		{"unknown variable", []config.DerivedMetricConfig{{Name: "a", Expr: "foo * 2"}}},
+		{"defined later", []config.DerivedMetricConfig{{Name: "a", Expr: "b * 2"}, {Name: "b", Expr: "active"}}},
+		{"syntax", []config.DerivedMetricConfig{{Name: "a", Expr: "active /"}}},
+		{"window", []config.DerivedMetricConfig{{Name: "a", Expr: "avg(active, 5m)"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.defs); err == nil {
+				t.Errorf("Compile(%s) should fail", tt.name)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	metrics, err := Compile([]config.DerivedMetricConfig{
+		{Name: "utilization", Expr: "active / max * 100"},
+		{Name: "gc_rate", Expr: "delta(gc_count) / interval"},
+		{Name: "pending_ratio", Expr: "pending / idle"},
+	})
+	if err != nil {
+		t.Fatalf("Compile error: %v", err)
+	}
+
+	now := time.Now()
+	prev := &models.PoolMetrics{Active: 2, Max: 10, GcCount: 100, Timestamp: now.Add(-10 * time.Second)}
+	cur := &models.PoolMetrics{Active: 5, Max: 10, GcCount: 130, Timestamp: now}
+
+	// First sample has no delta, and a division by zero has no value
+	values := Evaluate(metrics, cur, nil)
+	if values["utilization"] != 50 {
+		t.Errorf("utilization = %f, want 50", values["utilization"])
+	}
+	if _, ok := values["gc_rate"]; ok {
+		t.Error("gc_rate should be omitted without a previous sample")
+	}
+	if _, ok := values["pending_ratio"]; ok {
+		t.Error("pending_ratio should be omitted on division by zero")
+	}
+
+	values = Evaluate(metrics, cur, prev)
+	if math.Abs(values["gc_rate"]-3) > 0.0001 {
+		t.Errorf("gc_rate = %f, want 3", values["gc_rate"])
+	}
+
+	// A counter reset counts from zero
+	prev.GcCount = 500
+	values = Evaluate(metrics, cur, prev)
+	if math.Abs(values["gc_rate"]-13) > 0.0001 {
+		t.Errorf("gc_rate after reset = %f, want 13", values["gc_rate"])
+	}
+}
+
+func TestFill(t *testing.T) {
+	metrics, err := Compile([]config.DerivedMetricConfig{
+		{Name: "gc_rate", Expr: "delta(gc_count) / interval"},
+	})
+	if err != nil {
+		t.Fatalf("Compile error: %v", err)
+	}
+
+	now := time.Now()
+	series := []models.PoolMetrics{
+		{InstanceName: "a", GcCount: 10, Timestamp: now},
+		{InstanceName: "b", GcCount: 50, Timestamp: now},
+		{InstanceName: "a", GcCount: 20, Timestamp: now.Add(10 * time.Second)},
+		{InstanceName: "b", GcCount: 60, Timestamp: now.Add(10 * time.Second), Derived: map[string]float64{"gc_rate": 7}},
+	}
+	Fill(metrics, series)
+
+	if series[0].Derived != nil {
+		t.Errorf("first sample Derived = %v, want nil", series[0].Derived)
+	}
+	if series[2].Derived["gc_rate"] != 1 {
+		t.Errorf("instance a gc_rate = %f, want 1", series[2].Derived["gc_rate"])
+	}
+	// Stored values are kept
+	if series[3].Derived["gc_rate"] != 7 {
+		t.Errorf("instance b gc_rate = %f, want 7", series[3].Derived["gc_rate"])
+	}
+}
+
+func TestEvaluator_Process(t *testing.T) {
+	metrics, err := Compile([]config.DerivedMetricConfig{
+		{Name: "gc_rate", Expr: "delta(gc_count) / interval"},
+	})
+	if err != nil {
+		t.Fatalf("Compile error: %v", err)
+	}
+
+	e := NewEvaluator()
+	e.SetMetrics(metrics)
+
+	now := time.Now()
+	first := &models.PoolMetrics{TargetName: "svc", InstanceName: "a", GcCount: 10, Timestamp: now}
+	e.Process(first)
+	if first.Derived != nil {
+		t.Errorf("first sample Derived = %v, want nil", first.Derived)
+	}
+
+	second := &models.PoolMetrics{TargetName: "svc", InstanceName: "a", GcCount: 30, Timestamp: now.Add(10 * time.Second)}
+	e.Process(second)
+	if second.Derived["gc_rate"] != 2 {
+		t.Errorf("gc_rate = %f, want 2", second.Derived["gc_rate"])
+	}
+
+	// A late sample isn't compared against newer data and doesn't replace it
+	late := &models.PoolMetrics{TargetName: "svc", InstanceName: "a", GcCount: 15, Timestamp: now.Add(5 * time.Second)}
+	e.Process(late)
+	if late.Derived != nil {
+		t.Errorf("late sample Derived = %v, want nil", late.Derived)
+	}
+
+	third := &models.PoolMetrics{TargetName: "svc", InstanceName: "a", GcCount: 50, Timestamp: now.Add(20 * time.Second)}
+	e.Process(third)
+	if third.Derived["gc_rate"] != 2 {
+		t.Errorf("gc_rate = %f, want 2", third.Derived["gc_rate"])
+	}
+
+	// Failed scrapes are skipped
+	failed := &models.PoolMetrics{TargetName: "svc", InstanceName: "a", Status: models.StatusError, Timestamp: now.Add(30 * time.Second)}
+	e.Process(failed)
+	if failed.Derived != nil {
+		t.Errorf("failed sample Derived = %v, want nil", failed.Derived)
+	}
+}
+
+func TestEvaluator_ExpiresPrevious(t *testing.T) {
+	e := NewEvaluator()
+	now := time.Now()
+	e.now = func() time.Time { return now }
+
+	e.Process(&models.PoolMetrics{TargetName: "svc", InstanceName: "old-pod", Timestamp: now})
+	e.Process(&models.PoolMetrics{TargetName: "svc", InstanceName: "a", Timestamp: now})
+
+	// Only the instance that kept reporting survives the next sweep
+	now = now.Add(prevTTL / 2)
+	e.Process(&models.PoolMetrics{TargetName: "svc", InstanceName: "a", Timestamp: now})
+	now = now.Add(prevTTL/2 + time.Second)
+	e.Process(&models.PoolMetrics{TargetName: "svc", InstanceName: "a", Timestamp: now})
+
+	if _, ok := e.prev["svc/old-pod"]; ok {
+		t.Error("previous sample of an instance that stopped reporting was kept")
+	}
+	if _, ok := e.prev["svc/a"]; !ok {
+		t.Error("previous sample of a reporting instance was removed")
+	}
+}
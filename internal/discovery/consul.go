@@ -0,0 +1,102 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// ConsulResolver resolves instances from the Consul health API
+type ConsulResolver struct {
+	cfg    config.ConsulConfig
+	client *http.Client
+}
+
+// consulServiceEntry is an entry of the /v1/health/service/{name} response
+type consulServiceEntry struct {
+	Node struct {
+		Node    string `json:"Node"`
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		ID      string            `json:"ID"`
+		Address string            `json:"Address"`
+		Port    int               `json:"Port"`
+		Meta    map[string]string `json:"Meta"`
+	} `json:"Service"`
+}
+
+// NewConsulResolver creates a resolver for the configured Consul agent
+func NewConsulResolver(cfg config.ConsulConfig, client *http.Client) *ConsulResolver {
+	return &ConsulResolver{cfg: cfg, client: client}
+}
+
+// Resolve returns the instances of a service that pass all health checks
+// The management_port service meta overrides the service port
+func (r *ConsulResolver) Resolve(ctx context.Context, d *config.TargetDiscoveryConfig) ([]config.InstanceConfig, error) {
+	if r.cfg.URL == "" {
+		return nil, fmt.Errorf("discovery.consul.url is not configured")
+	}
+
+	query := url.Values{"passing": {"true"}}
+	if r.cfg.Datacenter != "" {
+		query.Set("dc", r.cfg.Datacenter)
+	}
+	u := strings.TrimSuffix(r.cfg.URL, "/") + "/v1/health/service/" + url.PathEscape(d.Service) + "?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", r.cfg.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul: unexpected status code: %d", resp.StatusCode)
+	}
+
+	var entries []consulServiceEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("consul: %w", err)
+	}
+
+	var instances []config.InstanceConfig
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		port := e.Service.Port
+		if p, err := strconv.Atoi(e.Service.Meta["management_port"]); err == nil && p > 0 {
+			port = p
+		}
+
+		id := e.Service.ID
+		if id == "" {
+			id = e.Node.Node
+		}
+
+		instances = append(instances, config.InstanceConfig{
+			ID:       id,
+			Endpoint: d.GetScheme() + "://" + net.JoinHostPort(host, strconv.Itoa(port)) + d.GetPath(),
+		})
+	}
+
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	return instances, nil
+}
@@ -0,0 +1,42 @@
+package discovery
+
+import (
+	"github.com/jiin/pondy/internal/config"
+)
+
+// Sources of discovered targets, keyed separately so one source doesn't replace another's targets
+const (
+	SourceKubernetes = "kubernetes"
+	SourceRegistry   = "registry"
+)
+
+// Start runs the discovery sources of the config and reports their targets to setTargets by source
+// The server passes the collector manager's SetDiscoveredTargets. Kubernetes discovery is set up
+// from the config at startup; registry discovery re-reads the config on every refresh.
+// The returned function stops every started source
+func Start(cfgMgr *config.Manager, setTargets func(source string, targets []config.TargetConfig)) (func(), error) {
+	var stops []func()
+
+	if k8s := cfgMgr.Get().Discovery.Kubernetes; k8s.Enabled {
+		d, err := NewKubernetesDiscovery(k8s, func(targets []config.TargetConfig) {
+			setTargets(SourceKubernetes, targets)
+		})
+		if err != nil {
+			return nil, err
+		}
+		d.Start()
+		stops = append(stops, d.Stop)
+	}
+
+	registry := NewRegistryDiscovery(cfgMgr, func(targets []config.TargetConfig) {
+		setTargets(SourceRegistry, targets)
+	})
+	registry.Start()
+	stops = append(stops, registry.Stop)
+
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}, nil
+}
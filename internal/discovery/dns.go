@@ -0,0 +1,96 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// DNSResolver resolves instances from A/AAAA or SRV records
+// Useful for headless Kubernetes services and Route53-backed auto scaling groups
+type DNSResolver struct {
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	lookupSRV  func(ctx context.Context, name string) ([]*net.SRV, error)
+}
+
+// NewDNSResolver creates a resolver using the system DNS resolver
+func NewDNSResolver() *DNSResolver {
+	return &DNSResolver{
+		lookupHost: net.DefaultResolver.LookupHost,
+		lookupSRV: func(ctx context.Context, name string) ([]*net.SRV, error) {
+			_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+			return addrs, err
+		},
+	}
+}
+
+// Resolve returns one instance per resolved address
+// A records are named by IP, SRV records by target host name
+// A name that no longer resolves yields no instances so stale collectors are reaped
+func (r *DNSResolver) Resolve(ctx context.Context, d *config.TargetDiscoveryConfig) ([]config.InstanceConfig, error) {
+	var instances []config.InstanceConfig
+
+	switch d.GetRecord() {
+	case config.DNSRecordSRV:
+		records, err := r.lookupSRV(ctx, d.Service)
+		if isNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dns: %w", err)
+		}
+
+		seen := make(map[string]bool)
+		for _, srv := range records {
+			host := strings.TrimSuffix(srv.Target, ".")
+			id := host
+			if seen[id] {
+				// Several ports on the same host
+				id = net.JoinHostPort(host, strconv.Itoa(int(srv.Port)))
+			}
+			seen[id] = true
+
+			instances = append(instances, config.InstanceConfig{
+				ID:       id,
+				Endpoint: d.GetScheme() + "://" + net.JoinHostPort(host, strconv.Itoa(int(srv.Port))) + d.GetPath(),
+			})
+		}
+
+	default:
+		addrs, err := r.lookupHost(ctx, d.Service)
+		if isNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dns: %w", err)
+		}
+
+		seen := make(map[string]bool)
+		for _, ip := range addrs {
+			if seen[ip] {
+				continue
+			}
+			seen[ip] = true
+
+			instances = append(instances, config.InstanceConfig{
+				ID:       ip,
+				Endpoint: d.GetScheme() + "://" + net.JoinHostPort(ip, strconv.Itoa(d.Port)) + d.GetPath(),
+			})
+		}
+	}
+
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	return instances, nil
+}
+
+// isNotFound reports whether the DNS name has no records
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
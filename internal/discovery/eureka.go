@@ -0,0 +1,114 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// EurekaResolver resolves instances from a Spring Cloud Eureka server
+type EurekaResolver struct {
+	cfg    config.EurekaConfig
+	client *http.Client
+}
+
+// eurekaApplication is the /apps/{name} JSON response
+type eurekaApplication struct {
+	Application struct {
+		Instance []eurekaInstance `json:"instance"`
+	} `json:"application"`
+}
+
+type eurekaInstance struct {
+	InstanceID string            `json:"instanceId"`
+	HostName   string            `json:"hostName"`
+	IPAddr     string            `json:"ipAddr"`
+	Status     string            `json:"status"`
+	Port       eurekaPort        `json:"port"`
+	SecurePort eurekaPort        `json:"securePort"`
+	Metadata   map[string]string `json:"metadata"`
+}
+
+type eurekaPort struct {
+	Value   int    `json:"$"`
+	Enabled string `json:"@enabled"`
+}
+
+// NewEurekaResolver creates a resolver for the configured Eureka server
+func NewEurekaResolver(cfg config.EurekaConfig, client *http.Client) *EurekaResolver {
+	return &EurekaResolver{cfg: cfg, client: client}
+}
+
+// Resolve returns the UP instances of a service
+// The management.port metadata overrides the service port when actuator runs separately
+func (r *EurekaResolver) Resolve(ctx context.Context, d *config.TargetDiscoveryConfig) ([]config.InstanceConfig, error) {
+	if r.cfg.URL == "" {
+		return nil, fmt.Errorf("discovery.eureka.url is not configured")
+	}
+
+	u := strings.TrimSuffix(r.cfg.URL, "/") + "/apps/" + url.PathEscape(strings.ToUpper(d.Service))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Service has no registered instances
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("eureka: unexpected status code: %d", resp.StatusCode)
+	}
+
+	var app eurekaApplication
+	if err := json.NewDecoder(resp.Body).Decode(&app); err != nil {
+		return nil, fmt.Errorf("eureka: %w", err)
+	}
+
+	var instances []config.InstanceConfig
+	for _, inst := range app.Application.Instance {
+		if inst.Status != "UP" {
+			continue
+		}
+
+		host := inst.IPAddr
+		if host == "" {
+			host = inst.HostName
+		}
+		port := inst.Port.Value
+		if inst.SecurePort.Enabled == "true" && d.Scheme == "https" {
+			port = inst.SecurePort.Value
+		}
+		if p, err := strconv.Atoi(inst.Metadata["management.port"]); err == nil && p > 0 {
+			port = p
+		}
+
+		id := inst.InstanceID
+		if id == "" {
+			id = net.JoinHostPort(inst.HostName, strconv.Itoa(inst.Port.Value))
+		}
+
+		instances = append(instances, config.InstanceConfig{
+			ID:       id,
+			Endpoint: d.GetScheme() + "://" + net.JoinHostPort(host, strconv.Itoa(port)) + d.GetPath(),
+		})
+	}
+
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	return instances, nil
+}
@@ -0,0 +1,198 @@
+package discovery
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// In-cluster service account paths
+const (
+	serviceAccountDir   = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountToken = serviceAccountDir + "/token"
+	serviceAccountCA    = serviceAccountDir + "/ca.crt"
+)
+
+// apiConfig holds what is needed to call the Kubernetes API
+type apiConfig struct {
+	Server   string
+	Token    string
+	CAData   []byte
+	CertData []byte
+	KeyData  []byte
+	Insecure bool
+}
+
+// kubeconfig is the subset of the kubeconfig file format used by pondy
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// loadInClusterConfig reads the pod's service account credentials
+func loadInClusterConfig() (*apiConfig, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster (KUBERNETES_SERVICE_HOST not set); set discovery.kubernetes.kubeconfig")
+	}
+
+	token, err := os.ReadFile(serviceAccountToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(serviceAccountCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+	return &apiConfig{
+		Server: "https://" + host + ":" + port,
+		Token:  strings.TrimSpace(string(token)),
+		CAData: ca,
+	}, nil
+}
+
+// loadKubeconfig reads the current context from a kubeconfig file
+func loadKubeconfig(path string) (*apiConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	return parseKubeconfig(data)
+}
+
+func parseKubeconfig(data []byte) (*apiConfig, error) {
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+
+	contextName := kc.CurrentContext
+	if contextName == "" && len(kc.Contexts) > 0 {
+		contextName = kc.Contexts[0].Name
+	}
+
+	var clusterName, userName string
+	for _, c := range kc.Contexts {
+		if c.Name == contextName {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			break
+		}
+	}
+
+	cfg := &apiConfig{}
+	found := false
+	var err error
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		cfg.Server = strings.TrimSuffix(c.Cluster.Server, "/")
+		cfg.Insecure = c.Cluster.InsecureSkipTLSVerify
+		if cfg.CAData, err = dataOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority); err != nil {
+			return nil, fmt.Errorf("certificate-authority: %w", err)
+		}
+	}
+	if !found || cfg.Server == "" {
+		return nil, fmt.Errorf("kubeconfig context '%s' has no cluster server", contextName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		cfg.Token = u.User.Token
+		if cfg.Token == "" && u.User.TokenFile != "" {
+			token, err := os.ReadFile(u.User.TokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("tokenFile: %w", err)
+			}
+			cfg.Token = strings.TrimSpace(string(token))
+		}
+		if cfg.CertData, err = dataOrFile(u.User.ClientCertificateData, u.User.ClientCertificate); err != nil {
+			return nil, fmt.Errorf("client-certificate: %w", err)
+		}
+		if cfg.KeyData, err = dataOrFile(u.User.ClientKeyData, u.User.ClientKey); err != nil {
+			return nil, fmt.Errorf("client-key: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// dataOrFile decodes base64 inline data, or reads the file when data is empty
+func dataOrFile(data, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
+
+// newHTTPClient builds an API client with the cluster TLS settings
+func (c *apiConfig) newHTTPClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.Insecure,
+	}
+
+	if len(c.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(c.CAData) {
+			return nil, fmt.Errorf("invalid cluster CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(c.CertData) > 0 && len(c.KeyData) > 0 {
+		cert, err := tls.X509KeyPair(c.CertData, c.KeyData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}, nil
+}
@@ -0,0 +1,291 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// Pod annotations that override discovery defaults
+const (
+	AnnotationTarget     = "pondy.io/target"      // Target name (default: app label or pod name)
+	AnnotationPort       = "pondy.io/port"        // Metrics port
+	AnnotationPath       = "pondy.io/path"        // Metrics path
+	AnnotationScheme     = "pondy.io/scheme"      // http or https (default: http)
+	AnnotationType       = "pondy.io/type"        // Target type (actuator, jolokia)
+	AnnotationPoolType   = "pondy.io/pool-type"   // Connection pool type
+	AnnotationScrapeMode = "pondy.io/scrape-mode" // Scrape mode (metrics, prometheus, auto)
+	AnnotationGroup      = "pondy.io/group"       // Target group
+)
+
+// Labels used for the target name when no annotation is set
+var targetNameLabels = []string{"app.kubernetes.io/name", "app"}
+
+// Pod is the subset of the Kubernetes Pod object used for discovery
+type Pod struct {
+	Metadata struct {
+		Name              string            `json:"name"`
+		Namespace         string            `json:"namespace"`
+		Labels            map[string]string `json:"labels"`
+		Annotations       map[string]string `json:"annotations"`
+		DeletionTimestamp *time.Time        `json:"deletionTimestamp"`
+	} `json:"metadata"`
+	Status struct {
+		Phase      string `json:"phase"`
+		PodIP      string `json:"podIP"`
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// podList is the Kubernetes PodList response
+type podList struct {
+	Items []Pod `json:"items"`
+}
+
+// IsReady checks if the pod is running and passing readiness checks
+func (p *Pod) IsReady() bool {
+	if p.Metadata.DeletionTimestamp != nil || p.Status.Phase != "Running" || p.Status.PodIP == "" {
+		return false
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == "Ready" {
+			return c.Status == "True"
+		}
+	}
+	return true
+}
+
+// KubernetesDiscovery periodically lists pods matching a label selector
+// and reports them as collector targets
+type KubernetesDiscovery struct {
+	cfg       config.KubernetesDiscoveryConfig
+	api       *apiConfig
+	client    *http.Client
+	namespace string
+	onUpdate  func([]config.TargetConfig)
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewKubernetesDiscovery creates a discovery using the kubeconfig or in-cluster credentials
+// onUpdate is called with the full set of discovered targets after every refresh
+func NewKubernetesDiscovery(cfg config.KubernetesDiscoveryConfig, onUpdate func([]config.TargetConfig)) (*KubernetesDiscovery, error) {
+	var api *apiConfig
+	var err error
+	if cfg.Kubeconfig != "" {
+		api, err = loadKubeconfig(cfg.Kubeconfig)
+	} else {
+		api, err = loadInClusterConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := api.newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &KubernetesDiscovery{
+		cfg:       cfg,
+		api:       api,
+		client:    client,
+		namespace: cfg.Namespace,
+		onUpdate:  onUpdate,
+		stop:      make(chan struct{}),
+	}, nil
+}
+
+// Start begins refreshing discovered targets in the background
+func (d *KubernetesDiscovery) Start() {
+	go d.run()
+}
+
+// Stop stops the refresh loop
+func (d *KubernetesDiscovery) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+}
+
+func (d *KubernetesDiscovery) run() {
+	ticker := time.NewTicker(d.cfg.GetRefreshInterval())
+	defer ticker.Stop()
+
+	d.refresh()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.refresh()
+		}
+	}
+}
+
+// refresh lists pods and reports targets
+// Targets are kept on API errors so a flaky API server doesn't stop collection
+func (d *KubernetesDiscovery) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.GetRefreshInterval())
+	defer cancel()
+
+	targets, err := d.Discover(ctx)
+	if err != nil {
+		log.Printf("Kubernetes discovery failed: %v", err)
+		return
+	}
+	d.onUpdate(targets)
+}
+
+// Discover lists matching pods and converts them into targets
+func (d *KubernetesDiscovery) Discover(ctx context.Context) ([]config.TargetConfig, error) {
+	pods, err := d.listPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return PodsToTargets(pods, d.cfg), nil
+}
+
+func (d *KubernetesDiscovery) listPods(ctx context.Context) ([]Pod, error) {
+	path := "/api/v1/pods"
+	if d.namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(d.namespace) + "/pods"
+	}
+	query := url.Values{"labelSelector": {d.cfg.GetLabelSelector()}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.api.Server+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if d.api.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.api.Token)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list pods: unexpected status code: %d", resp.StatusCode)
+	}
+
+	var list podList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	return list.Items, nil
+}
+
+// PodsToTargets groups ready pods into targets, one instance per pod
+func PodsToTargets(pods []Pod, cfg config.KubernetesDiscoveryConfig) []config.TargetConfig {
+	byName := make(map[string]*config.TargetConfig)
+
+	for i := range pods {
+		pod := &pods[i]
+		if !pod.IsReady() {
+			continue
+		}
+
+		annotations := pod.Metadata.Annotations
+		name := targetName(pod)
+
+		target, exists := byName[name]
+		if !exists {
+			target = &config.TargetConfig{
+				Name:     name,
+				Type:     config.TargetTypeActuator,
+				Interval: cfg.GetInterval(),
+				Group:    cfg.Group,
+			}
+			if v := annotations[AnnotationType]; v != "" {
+				target.Type = v
+			}
+			if v := annotations[AnnotationPoolType]; v != "" {
+				target.PoolType = v
+			}
+			if v := annotations[AnnotationScrapeMode]; v != "" {
+				target.ScrapeMode = v
+			}
+			if v := annotations[AnnotationGroup]; v != "" {
+				target.Group = v
+			}
+			byName[name] = target
+		}
+
+		target.Instances = append(target.Instances, config.InstanceConfig{
+			ID:       podID(pod),
+			Endpoint: podEndpoint(pod, cfg),
+		})
+	}
+
+	targets := make([]config.TargetConfig, 0, len(byName))
+	for _, t := range byName {
+		sort.Slice(t.Instances, func(i, j int) bool { return t.Instances[i].ID < t.Instances[j].ID })
+		targets = append(targets, *t)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	return targets
+}
+
+// podID identifies a pod across namespaces, as pods of the same target name
+// may share a pod name in different namespaces
+func podID(pod *Pod) string {
+	if pod.Metadata.Namespace == "" {
+		return pod.Metadata.Name
+	}
+	return pod.Metadata.Namespace + "/" + pod.Metadata.Name
+}
+
+// targetName picks the target name from annotations or well-known labels
+func targetName(pod *Pod) string {
+	if v := pod.Metadata.Annotations[AnnotationTarget]; v != "" {
+		return v
+	}
+	for _, label := range targetNameLabels {
+		if v := pod.Metadata.Labels[label]; v != "" {
+			return v
+		}
+	}
+	return pod.Metadata.Name
+}
+
+// podEndpoint builds the metrics URL for a pod
+func podEndpoint(pod *Pod, cfg config.KubernetesDiscoveryConfig) string {
+	annotations := pod.Metadata.Annotations
+
+	scheme := "http"
+	if v := annotations[AnnotationScheme]; v == "https" {
+		scheme = v
+	}
+	port := cfg.GetPort()
+	if v, err := strconv.Atoi(annotations[AnnotationPort]); err == nil && v > 0 {
+		port = v
+	}
+	path := cfg.GetPath()
+	if v := annotations[AnnotationPath]; v != "" {
+		path = v
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return scheme + "://" + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(port)) + path
+}
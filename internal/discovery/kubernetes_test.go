@@ -0,0 +1,122 @@
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+const podsJSON = `{"items":[
+	{"metadata":{"name":"orders-7d9f-abc","namespace":"shop","labels":{"app":"orders"}},
+	 "status":{"phase":"Running","podIP":"10.0.0.1","conditions":[{"type":"Ready","status":"True"}]}},
+	{"metadata":{"name":"orders-7d9f-def","namespace":"shop","labels":{"app":"orders"},
+	 "annotations":{"pondy.io/port":"9090","pondy.io/path":"manage/metrics"}},
+	 "status":{"phase":"Running","podIP":"10.0.0.2","conditions":[{"type":"Ready","status":"True"}]}},
+	{"metadata":{"name":"orders-7d9f-ghi","namespace":"shop","labels":{"app":"orders"}},
+	 "status":{"phase":"Running","podIP":"10.0.0.3","conditions":[{"type":"Ready","status":"False"}]}},
+	{"metadata":{"name":"billing-0","namespace":"shop","labels":{"app":"billing"},
+	 "annotations":{"pondy.io/target":"legacy-billing","pondy.io/type":"jolokia","pondy.io/path":"/jolokia","pondy.io/port":"8778","pondy.io/group":"prod"}},
+	 "status":{"phase":"Running","podIP":"10.0.0.4"}},
+	{"metadata":{"name":"batch-1","namespace":"shop"},
+	 "status":{"phase":"Pending"}}
+]}`
+
+func TestDiscover(t *testing.T) {
+	var gotPath, gotSelector, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotSelector = r.URL.Query().Get("labelSelector")
+		gotAuth = r.Header.Get("Authorization")
+		fmt.Fprint(w, podsJSON)
+	}))
+	defer srv.Close()
+
+	d := &KubernetesDiscovery{
+		cfg:       config.KubernetesDiscoveryConfig{Namespace: "shop", Group: "k8s"},
+		api:       &apiConfig{Server: srv.URL, Token: "secret"},
+		client:    srv.Client(),
+		namespace: "shop",
+	}
+
+	targets, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if gotPath != "/api/v1/namespaces/shop/pods" || gotSelector != "pondy.io/scrape=true" || gotAuth != "Bearer secret" {
+		t.Errorf("unexpected request: path=%s selector=%s auth=%s", gotPath, gotSelector, gotAuth)
+	}
+
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %+v", targets)
+	}
+
+	billing := targets[0]
+	if billing.Name != "legacy-billing" || billing.Type != config.TargetTypeJolokia || billing.Group != "prod" {
+		t.Errorf("unexpected billing target: %+v", billing)
+	}
+	if billing.Instances[0].Endpoint != "http://10.0.0.4:8778/jolokia" {
+		t.Errorf("billing endpoint = %s", billing.Instances[0].Endpoint)
+	}
+
+	orders := targets[1]
+	if orders.Name != "orders" || orders.Group != "k8s" || orders.Interval != config.DefaultDiscoveryInterval {
+		t.Errorf("unexpected orders target: %+v", orders)
+	}
+	if len(orders.Instances) != 2 {
+		t.Fatalf("expected 2 ready orders instances, got %+v", orders.Instances)
+	}
+	if orders.Instances[0].ID != "shop/orders-7d9f-abc" || orders.Instances[0].Endpoint != "http://10.0.0.1:8080/actuator/metrics" {
+		t.Errorf("unexpected default instance: %+v", orders.Instances[0])
+	}
+	if orders.Instances[1].Endpoint != "http://10.0.0.2:9090/manage/metrics" {
+		t.Errorf("annotated endpoint = %s", orders.Instances[1].Endpoint)
+	}
+}
+
+func TestParseKubeconfig(t *testing.T) {
+	ca := base64.StdEncoding.EncodeToString([]byte("ca-pem"))
+	data := []byte(`
+apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+  - name: dev-cluster
+    cluster:
+      server: https://dev:6443
+  - name: prod-cluster
+    cluster:
+      server: https://prod:6443/
+      certificate-authority-data: ` + ca + `
+users:
+  - name: prod-user
+    user:
+      token: abc123
+contexts:
+  - name: dev
+    context:
+      cluster: dev-cluster
+      user: prod-user
+  - name: prod
+    context:
+      cluster: prod-cluster
+      user: prod-user
+`)
+
+	cfg, err := parseKubeconfig(data)
+	if err != nil {
+		t.Fatalf("parseKubeconfig() error = %v", err)
+	}
+	if cfg.Server != "https://prod:6443" || cfg.Token != "abc123" || string(cfg.CAData) != "ca-pem" {
+		t.Errorf("unexpected config: server=%s token=%s ca=%s", cfg.Server, cfg.Token, cfg.CAData)
+	}
+
+	if _, err := parseKubeconfig([]byte("current-context: missing\n")); err == nil {
+		t.Error("expected error for missing cluster")
+	}
+}
@@ -0,0 +1,128 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+// Resolver looks up the instances of a service in a registry
+type Resolver interface {
+	// Resolve returns the healthy instances of a service
+	Resolve(ctx context.Context, d *config.TargetDiscoveryConfig) ([]config.InstanceConfig, error)
+}
+
+// RegistryDiscovery resolves instances for targets configured with
+// `discovery: {type: eureka|consul|dns, service: ...}` and reports them as collector targets
+type RegistryDiscovery struct {
+	getConfig func() *config.Config
+	client    *http.Client
+	onUpdate  func([]config.TargetConfig)
+	resolvers func(cfg *config.DiscoveryConfig) map[string]Resolver
+
+	mu        sync.Mutex
+	instances map[string][]config.InstanceConfig // last resolved instances by target name
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRegistryDiscovery creates a registry discovery reading targets from the config manager
+// onUpdate is called with the full set of resolved targets after every refresh
+func NewRegistryDiscovery(cfgMgr *config.Manager, onUpdate func([]config.TargetConfig)) *RegistryDiscovery {
+	d := &RegistryDiscovery{
+		getConfig: cfgMgr.Get,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		onUpdate:  onUpdate,
+		instances: make(map[string][]config.InstanceConfig),
+		stop:      make(chan struct{}),
+	}
+	d.resolvers = d.newResolvers
+	return d
+}
+
+// newResolvers builds resolvers from the current registry settings
+func (d *RegistryDiscovery) newResolvers(cfg *config.DiscoveryConfig) map[string]Resolver {
+	return map[string]Resolver{
+		config.RegistryEureka: NewEurekaResolver(cfg.Eureka, d.client),
+		config.RegistryConsul: NewConsulResolver(cfg.Consul, d.client),
+		config.RegistryDNS:    NewDNSResolver(),
+	}
+}
+
+// Start begins resolving targets in the background
+func (d *RegistryDiscovery) Start() {
+	go d.run()
+}
+
+// Stop stops the refresh loop
+func (d *RegistryDiscovery) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+}
+
+func (d *RegistryDiscovery) run() {
+	ticker := time.NewTicker(d.getConfig().Discovery.GetRefreshInterval())
+	defer ticker.Stop()
+
+	d.Refresh(context.Background())
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.Refresh(context.Background())
+		}
+	}
+}
+
+// Refresh resolves all registry-backed targets and reports them
+// A target keeps its last known instances when its registry lookup fails
+func (d *RegistryDiscovery) Refresh(ctx context.Context) {
+	cfg := d.getConfig()
+	resolvers := d.resolvers(&cfg.Discovery)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var targets []config.TargetConfig
+	current := make(map[string][]config.InstanceConfig)
+
+	for _, target := range cfg.Targets {
+		if target.Discovery == nil {
+			continue
+		}
+
+		instances, err := d.resolve(ctx, resolvers, target.Discovery)
+		if err != nil {
+			log.Printf("Discovery failed for %s (%s/%s): %v", target.Name, target.Discovery.Type, target.Discovery.Service, err)
+			instances = d.instances[target.Name]
+		}
+		current[target.Name] = instances
+
+		resolved := target
+		resolved.Endpoint = ""
+		resolved.Instances = instances
+		targets = append(targets, resolved)
+	}
+
+	d.instances = current
+	d.onUpdate(targets)
+}
+
+func (d *RegistryDiscovery) resolve(ctx context.Context, resolvers map[string]Resolver, td *config.TargetDiscoveryConfig) ([]config.InstanceConfig, error) {
+	resolver, ok := resolvers[td.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported discovery type '%s'", td.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return resolver.Resolve(ctx, td)
+}
@@ -0,0 +1,220 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jiin/pondy/internal/config"
+)
+
+const eurekaJSON = `{"application":{"name":"ORDERS-SVC","instance":[
+	{"instanceId":"10.0.0.1:orders-svc:8080","hostName":"orders-1","ipAddr":"10.0.0.1","status":"UP",
+	 "port":{"$":8080,"@enabled":"true"},"securePort":{"$":8443,"@enabled":"false"},"metadata":{}},
+	{"instanceId":"10.0.0.2:orders-svc:8080","hostName":"orders-2","ipAddr":"10.0.0.2","status":"UP",
+	 "port":{"$":8080,"@enabled":"true"},"securePort":{"$":8443,"@enabled":"false"},"metadata":{"management.port":"9090"}},
+	{"instanceId":"10.0.0.3:orders-svc:8080","hostName":"orders-3","ipAddr":"10.0.0.3","status":"DOWN",
+	 "port":{"$":8080,"@enabled":"true"},"securePort":{"$":8443,"@enabled":"false"},"metadata":{}}
+]}}`
+
+const consulJSON = `[
+	{"Node":{"Node":"node-a","Address":"10.1.0.1"},"Service":{"ID":"orders-a","Address":"","Port":8080,"Meta":{}}},
+	{"Node":{"Node":"node-b","Address":"10.1.0.2"},"Service":{"ID":"orders-b","Address":"10.1.1.2","Port":8080,"Meta":{"management_port":"8081"}}}
+]`
+
+func TestEurekaResolver(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if r.URL.Path != "/eureka/apps/ORDERS-SVC" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, eurekaJSON)
+	}))
+	defer srv.Close()
+
+	r := NewEurekaResolver(config.EurekaConfig{URL: srv.URL + "/eureka/"}, srv.Client())
+
+	instances, err := r.Resolve(context.Background(), &config.TargetDiscoveryConfig{Type: config.RegistryEureka, Service: "orders-svc"})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if gotPath != "/eureka/apps/ORDERS-SVC" {
+		t.Errorf("path = %s, want /eureka/apps/ORDERS-SVC", gotPath)
+	}
+
+	want := []config.InstanceConfig{
+		{ID: "10.0.0.1:orders-svc:8080", Endpoint: "http://10.0.0.1:8080/actuator/metrics"},
+		{ID: "10.0.0.2:orders-svc:8080", Endpoint: "http://10.0.0.2:9090/actuator/metrics"},
+	}
+	if len(instances) != len(want) {
+		t.Fatalf("got %d instances, want %d: %+v", len(instances), len(want), instances)
+	}
+	for i := range want {
+		if instances[i] != want[i] {
+			t.Errorf("instance[%d] = %+v, want %+v", i, instances[i], want[i])
+		}
+	}
+
+	// Unknown services have no instances rather than an error
+	instances, err = r.Resolve(context.Background(), &config.TargetDiscoveryConfig{Type: config.RegistryEureka, Service: "missing"})
+	if err != nil || len(instances) != 0 {
+		t.Errorf("Resolve(missing) = %v, %v; want no instances", instances, err)
+	}
+}
+
+func TestConsulResolver(t *testing.T) {
+	var gotPath, gotPassing, gotDC, gotToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotPassing = r.URL.Query().Get("passing")
+		gotDC = r.URL.Query().Get("dc")
+		gotToken = r.Header.Get("X-Consul-Token")
+		fmt.Fprint(w, consulJSON)
+	}))
+	defer srv.Close()
+
+	r := NewConsulResolver(config.ConsulConfig{URL: srv.URL, Token: "secret", Datacenter: "dc1"}, srv.Client())
+
+	instances, err := r.Resolve(context.Background(), &config.TargetDiscoveryConfig{
+		Type:    config.RegistryConsul,
+		Service: "orders",
+		Path:    "manage/metrics",
+	})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if gotPath != "/v1/health/service/orders" || gotPassing != "true" || gotDC != "dc1" || gotToken != "secret" {
+		t.Errorf("request = %s passing=%s dc=%s token=%s", gotPath, gotPassing, gotDC, gotToken)
+	}
+
+	want := []config.InstanceConfig{
+		{ID: "orders-a", Endpoint: "http://10.1.0.1:8080/manage/metrics"},
+		{ID: "orders-b", Endpoint: "http://10.1.1.2:8081/manage/metrics"},
+	}
+	if len(instances) != len(want) {
+		t.Fatalf("got %d instances, want %d: %+v", len(instances), len(want), instances)
+	}
+	for i := range want {
+		if instances[i] != want[i] {
+			t.Errorf("instance[%d] = %+v, want %+v", i, instances[i], want[i])
+		}
+	}
+}
+
+// stubResolver returns fixed instances or an error
+type stubResolver struct {
+	instances []config.InstanceConfig
+	err       error
+}
+
+func (s *stubResolver) Resolve(ctx context.Context, d *config.TargetDiscoveryConfig) ([]config.InstanceConfig, error) {
+	return s.instances, s.err
+}
+
+func TestRegistryDiscovery_Refresh(t *testing.T) {
+	cfg := &config.Config{Targets: []config.TargetConfig{
+		{Name: "static", Endpoint: "http://localhost:8080/actuator/metrics"},
+		{
+			Name:      "orders",
+			Endpoint:  "http://ignored:8080/actuator/metrics",
+			Discovery: &config.TargetDiscoveryConfig{Type: config.RegistryEureka, Service: "orders-svc"},
+		},
+	}}
+
+	stub := &stubResolver{instances: []config.InstanceConfig{
+		{ID: "orders-1", Endpoint: "http://10.0.0.1:8080/actuator/metrics"},
+	}}
+
+	var got []config.TargetConfig
+	d := &RegistryDiscovery{
+		getConfig: func() *config.Config { return cfg },
+		onUpdate:  func(targets []config.TargetConfig) { got = targets },
+		resolvers: func(*config.DiscoveryConfig) map[string]Resolver {
+			return map[string]Resolver{config.RegistryEureka: stub}
+		},
+		instances: make(map[string][]config.InstanceConfig),
+	}
+
+	d.Refresh(context.Background())
+	if len(got) != 1 || got[0].Name != "orders" {
+		t.Fatalf("targets = %+v, want only orders", got)
+	}
+	if got[0].Endpoint != "" || len(got[0].Instances) != 1 || got[0].Instances[0].ID != "orders-1" {
+		t.Errorf("orders = %+v, want resolved instance orders-1", got[0])
+	}
+
+	// Registry errors keep the last known instances
+	stub.instances, stub.err = nil, fmt.Errorf("connection refused")
+	d.Refresh(context.Background())
+	if len(got) != 1 || len(got[0].Instances) != 1 {
+		t.Errorf("targets after error = %+v, want last known instances", got)
+	}
+}
+
+func TestDNSResolver(t *testing.T) {
+	r := &DNSResolver{
+		lookupHost: func(ctx context.Context, host string) ([]string, error) {
+			switch host {
+			case "orders.shop.svc.cluster.local":
+				return []string{"10.0.0.2", "10.0.0.1", "10.0.0.2"}, nil
+			default:
+				return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+			}
+		},
+		lookupSRV: func(ctx context.Context, name string) ([]*net.SRV, error) {
+			return []*net.SRV{
+				{Target: "orders-0.orders.shop.svc.cluster.local.", Port: 8080},
+				{Target: "orders-1.orders.shop.svc.cluster.local.", Port: 8080},
+			}, nil
+		},
+	}
+
+	instances, err := r.Resolve(context.Background(), &config.TargetDiscoveryConfig{
+		Type:    config.RegistryDNS,
+		Service: "orders.shop.svc.cluster.local",
+		Port:    8081,
+	})
+	if err != nil {
+		t.Fatalf("Resolve(A) error = %v", err)
+	}
+	want := []config.InstanceConfig{
+		{ID: "10.0.0.1", Endpoint: "http://10.0.0.1:8081/actuator/metrics"},
+		{ID: "10.0.0.2", Endpoint: "http://10.0.0.2:8081/actuator/metrics"},
+	}
+	if len(instances) != len(want) {
+		t.Fatalf("got %d instances, want %d: %+v", len(instances), len(want), instances)
+	}
+	for i := range want {
+		if instances[i] != want[i] {
+			t.Errorf("instance[%d] = %+v, want %+v", i, instances[i], want[i])
+		}
+	}
+
+	instances, err = r.Resolve(context.Background(), &config.TargetDiscoveryConfig{
+		Type:    config.RegistryDNS,
+		Service: "_http._tcp.orders.shop.svc.cluster.local",
+		Record:  config.DNSRecordSRV,
+	})
+	if err != nil {
+		t.Fatalf("Resolve(SRV) error = %v", err)
+	}
+	if len(instances) != 2 || instances[0].ID != "orders-0.orders.shop.svc.cluster.local" ||
+		instances[0].Endpoint != "http://orders-0.orders.shop.svc.cluster.local:8080/actuator/metrics" {
+		t.Errorf("SRV instances = %+v", instances)
+	}
+
+	// Names that stop resolving drop all instances
+	instances, err = r.Resolve(context.Background(), &config.TargetDiscoveryConfig{
+		Type:    config.RegistryDNS,
+		Service: "gone.shop.svc.cluster.local",
+		Port:    8080,
+	})
+	if err != nil || len(instances) != 0 {
+		t.Errorf("Resolve(gone) = %v, %v; want no instances", instances, err)
+	}
+}
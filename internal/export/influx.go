@@ -0,0 +1,329 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// maxInfluxBackoff caps the delay between retries of a failed write
+const maxInfluxBackoff = 5 * time.Minute
+
+// InfluxSinkStats describes the state of the InfluxDB mirror
+type InfluxSinkStats struct {
+	Buffered  int        `json:"buffered"` // Samples waiting to be written
+	Capacity  int        `json:"capacity"` // Buffered samples before the oldest are dropped
+	Written   int64      `json:"written"`
+	Dropped   int64      `json:"dropped"`  // Samples discarded because the buffer was full
+	Failures  int64      `json:"failures"` // Failed write requests, each retried later
+	LastWrite *time.Time `json:"last_write,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// InfluxSink mirrors every saved metrics record to InfluxDB
+// Records are saved to the wrapped storage first, then buffered and written in batches.
+// A failed write keeps the batch buffered and retries with exponential backoff.
+// All other methods pass through to the wrapped storage.
+type InfluxSink struct {
+	storage.Storage
+
+	client      *http.Client
+	url         string
+	cfg         config.InfluxExportConfig
+	measurement string
+	batchSize   int
+	capacity    int
+
+	mu      sync.Mutex
+	buf     []models.PoolMetrics
+	closed  bool
+	backoff time.Duration
+	retryAt time.Time
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+
+	written  atomic.Int64
+	dropped  atomic.Int64
+	failures atomic.Int64
+
+	statsMu   sync.Mutex
+	lastWrite time.Time
+	lastError string
+}
+
+// NewInfluxSink wraps a storage and starts writing saved records to InfluxDB
+func NewInfluxSink(store storage.Storage, cfg config.InfluxExportConfig) (*InfluxSink, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	s := &InfluxSink{
+		Storage:     store,
+		client:      &http.Client{Timeout: cfg.GetTimeout()},
+		url:         cfg.GetWriteURL(),
+		cfg:         cfg,
+		measurement: cfg.GetMeasurement(),
+		batchSize:   cfg.GetBatchSize(),
+		capacity:    cfg.GetBufferSize(),
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go s.run(cfg.GetFlushInterval())
+
+	log.Printf("InfluxDB mirror started: url=%s, measurement=%s", s.url, s.measurement)
+	return s, nil
+}
+
+// Save stores a metrics record and buffers it for InfluxDB
+func (s *InfluxSink) Save(ctx context.Context, metrics *models.PoolMetrics) error {
+	if err := s.Storage.Save(ctx, metrics); err != nil {
+		return err
+	}
+	s.enqueue(*metrics)
+	return nil
+}
+
+// SaveBatch stores metrics records and buffers them for InfluxDB
+func (s *InfluxSink) SaveBatch(ctx context.Context, metrics []models.PoolMetrics) error {
+	if err := s.Storage.SaveBatch(ctx, metrics); err != nil {
+		return err
+	}
+	s.enqueue(metrics...)
+	return nil
+}
+
+// enqueue appends records to the buffer, dropping the oldest beyond capacity
+func (s *InfluxSink) enqueue(metrics ...models.PoolMetrics) {
+	s.mu.Lock()
+	s.buf = append(s.buf, metrics...)
+	if over := len(s.buf) - s.capacity; over > 0 {
+		s.buf = append(s.buf[:0], s.buf[over:]...)
+		s.dropped.Add(int64(over))
+	}
+	full := len(s.buf) >= s.batchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *InfluxSink) run(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.stop:
+			// One last attempt for what was buffered before Close
+			s.flush(true)
+			return
+		}
+		s.flush(false)
+	}
+}
+
+// flush writes buffered records in batches until the buffer is empty or a write fails
+// Writes are skipped while backing off from a failure unless force is set
+func (s *InfluxSink) flush(force bool) {
+	for {
+		s.mu.Lock()
+		if len(s.buf) == 0 || !force && time.Now().Before(s.retryAt) {
+			s.mu.Unlock()
+			return
+		}
+		n := min(len(s.buf), s.batchSize)
+		batch := make([]models.PoolMetrics, n)
+		copy(batch, s.buf)
+		s.buf = s.buf[n:]
+		s.mu.Unlock()
+
+		if err := s.write(batch); err != nil {
+			s.failures.Add(1)
+			s.requeue(batch)
+			s.statsMu.Lock()
+			s.lastError = err.Error()
+			s.statsMu.Unlock()
+			log.Printf("InfluxDB mirror: write of %d samples failed, retrying later: %v", n, err)
+			return
+		}
+
+		s.written.Add(int64(n))
+		s.mu.Lock()
+		s.backoff = 0
+		s.retryAt = time.Time{}
+		s.mu.Unlock()
+		s.statsMu.Lock()
+		s.lastWrite = time.Now()
+		s.lastError = ""
+		s.statsMu.Unlock()
+	}
+}
+
+// requeue puts a failed batch back in front of the buffer and schedules the retry
+func (s *InfluxSink) requeue(batch []models.PoolMetrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Records saved while writing are newer and win over the failed batch
+	if over := len(batch) + len(s.buf) - s.capacity; over > 0 {
+		over = min(over, len(batch))
+		batch = batch[over:]
+		s.dropped.Add(int64(over))
+	}
+	s.buf = append(batch, s.buf...)
+
+	if s.backoff == 0 {
+		s.backoff = s.cfg.GetFlushInterval()
+	} else {
+		s.backoff = min(s.backoff*2, maxInfluxBackoff)
+	}
+	s.retryAt = time.Now().Add(s.backoff)
+}
+
+// write sends a batch in line protocol
+func (s *InfluxSink) write(batch []models.PoolMetrics) error {
+	var body bytes.Buffer
+	for i := range batch {
+		writeInfluxLine(&body, s.measurement, &batch[i])
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.cfg.Token)
+	} else if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("influxdb returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// Stats returns the buffer depth and write counters
+func (s *InfluxSink) Stats() InfluxSinkStats {
+	s.mu.Lock()
+	stats := InfluxSinkStats{
+		Buffered: len(s.buf),
+		Capacity: s.capacity,
+		Written:  s.written.Load(),
+		Dropped:  s.dropped.Load(),
+		Failures: s.failures.Load(),
+	}
+	s.mu.Unlock()
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	if !s.lastWrite.IsZero() {
+		t := s.lastWrite
+		stats.LastWrite = &t
+	}
+	stats.LastError = s.lastError
+	return stats
+}
+
+// Close writes buffered records and closes the wrapped storage
+func (s *InfluxSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+	if n := s.Stats().Buffered; n > 0 {
+		log.Printf("InfluxDB mirror: %d samples not written before shutdown", n)
+	}
+	return s.Storage.Close()
+}
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// writeInfluxLine appends one sample in line protocol
+// Pool fields are only written for healthy samples and JVM fields when they were collected
+func writeInfluxLine(w *bytes.Buffer, measurement string, m *models.PoolMetrics) {
+	w.WriteString(influxMeasurementEscaper.Replace(measurement))
+	for _, tag := range [][2]string{{"target", m.TargetName}, {"instance", m.InstanceName}, {"status", m.Status}} {
+		if tag[1] == "" {
+			continue
+		}
+		w.WriteString("," + tag[0] + "=" + influxTagEscaper.Replace(tag[1]))
+	}
+
+	w.WriteString(" healthy=" + strconv.FormatBool(m.Status == models.StatusHealthy))
+	intField := func(name string, v int64) {
+		w.WriteString("," + name + "=" + strconv.FormatInt(v, 10) + "i")
+	}
+	floatField := func(name string, v float64) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return
+		}
+		w.WriteString("," + name + "=" + strconv.FormatFloat(v, 'f', -1, 64))
+	}
+
+	if m.Status == models.StatusHealthy {
+		intField("active", int64(m.Active))
+		intField("idle", int64(m.Idle))
+		intField("pending", int64(m.Pending))
+		intField("max", int64(m.Max))
+		intField("timeout", m.Timeout)
+		intField("timeout_delta", m.TimeoutDelta)
+		floatField("acquire_p50", m.AcquireP50)
+		floatField("acquire_p95", m.AcquireP95)
+		floatField("acquire_p99", m.AcquireP99)
+		floatField("acquire_max", m.AcquireMax)
+	}
+	if m.HeapMax > 0 || m.ThreadsLive > 0 {
+		intField("heap_used", m.HeapUsed)
+		intField("heap_max", m.HeapMax)
+		intField("non_heap_used", m.NonHeapUsed)
+		intField("non_heap_max", m.NonHeapMax)
+		intField("threads_live", int64(m.ThreadsLive))
+		floatField("cpu_usage", m.CpuUsage)
+		intField("gc_count", m.GcCount)
+		floatField("gc_time", m.GcTime)
+		intField("young_gc_count", m.YoungGcCount)
+		intField("old_gc_count", m.OldGcCount)
+	}
+
+	w.WriteString(" " + strconv.FormatInt(m.Timestamp.UnixNano(), 10) + "\n")
+}
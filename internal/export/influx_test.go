@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestWriteInfluxLine(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	var buf bytes.Buffer
+	writeInfluxLine(&buf, "pondy pool", &models.PoolMetrics{
+		TargetName: "order,api", InstanceName: "pod=1", Status: models.StatusHealthy,
+		Active: 7, Max: 10, AcquireP99: 1.5, Timestamp: ts,
+	})
+	writeInfluxLine(&buf, "pondy_pool", &models.PoolMetrics{
+		TargetName: "billing", Status: models.StatusError, HeapMax: 100, HeapUsed: 40, CpuUsage: 0.25, Timestamp: ts,
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q", lines)
+	}
+	if want := `pondy\ pool,target=order\,api,instance=pod\=1,status=healthy healthy=true,active=7i,idle=0i,pending=0i,max=10i,`; !strings.HasPrefix(lines[0], want) {
+		t.Errorf("line[0] = %s, want prefix %s", lines[0], want)
+	}
+	if !strings.Contains(lines[0], "acquire_p99=1.5") || strings.Contains(lines[0], "heap_used") || !strings.HasSuffix(lines[0], " 1700000000000000000") {
+		t.Errorf("line[0] = %s", lines[0])
+	}
+	// Error samples carry JVM fields only, and empty tags are omitted
+	if want := "pondy_pool,target=billing,status=error healthy=false,heap_used=40i,heap_max=100i,"; !strings.HasPrefix(lines[1], want) {
+		t.Errorf("line[1] = %s, want prefix %s", lines[1], want)
+	}
+	if strings.Contains(lines[1], "active=") || !strings.Contains(lines[1], "cpu_usage=0.25") {
+		t.Errorf("line[1] = %s", lines[1])
+	}
+}
+
+func TestInfluxSink(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "influx.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	var mu sync.Mutex
+	var lines []string
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/write" || r.URL.Query().Get("bucket") != "pondy" || r.Header.Get("Authorization") != "Token secret" {
+			t.Errorf("unexpected request %s %s", r.URL, r.Header)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		lines = append(lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink, err := NewInfluxSink(store, config.InfluxExportConfig{
+		Enabled:       true,
+		URL:           srv.URL,
+		Bucket:        "pondy",
+		Token:         "secret",
+		FlushInterval: time.Hour,
+		BufferSize:    3,
+	})
+	if err != nil {
+		t.Fatalf("NewInfluxSink() error = %v", err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		m := models.PoolMetrics{TargetName: "orders", InstanceName: "a", Status: models.StatusHealthy, Active: i, Max: 10, Timestamp: now.Add(time.Duration(i) * time.Second)}
+		if err := sink.Save(context.Background(), &m); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if latest, err := store.GetLatestAllInstances(context.Background(), "orders"); err != nil || len(latest) != 1 || latest[0].Active != 3 {
+		t.Fatalf("primary storage latest = %+v, %v", latest, err)
+	}
+
+	// Buffer keeps the newest samples; a failed write stays buffered
+	sink.flush(true)
+	stats := sink.Stats()
+	if stats.Buffered != 3 || stats.Dropped != 1 || stats.Failures != 1 || stats.LastError == "" {
+		t.Fatalf("stats after failure = %+v", stats)
+	}
+
+	// Backing off until forced
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	sink.flush(false)
+	if sink.Stats().Buffered != 3 {
+		t.Fatal("flush should wait for the retry backoff")
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	stats = sink.Stats()
+	if stats.Buffered != 0 || stats.Written != 3 || stats.LastError != "" {
+		t.Fatalf("stats after close = %+v", stats)
+	}
+	if len(lines) != 3 || !strings.Contains(lines[0], "active=1i") {
+		t.Errorf("written lines = %q", lines)
+	}
+}
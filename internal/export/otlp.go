@@ -0,0 +1,333 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// scopeName identifies pondy as the instrumentation scope of exported metrics
+const scopeName = "github.com/jiin/pondy"
+
+// OTLP aggregation temporality of cumulative sums
+const temporalityCumulative = 2
+
+// OTLPExporter periodically pushes the latest sample of every instance to an
+// OpenTelemetry collector over OTLP/HTTP with JSON encoding
+// Each instance is a resource with service.name = target and service.instance.id = instance
+type OTLPExporter struct {
+	cfgMgr *config.Manager
+	store  storage.Storage
+	client *http.Client
+	url    string
+	cfg    config.OTLPExportConfig
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time // Timestamp of the last pushed sample by target/instance
+}
+
+// NewOTLPExporter creates an exporter for the configured collector endpoint
+func NewOTLPExporter(cfgMgr *config.Manager, store storage.Storage, cfg config.OTLPExportConfig) (*OTLPExporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &OTLPExporter{
+		cfgMgr:   cfgMgr,
+		store:    store,
+		client:   &http.Client{Timeout: cfg.GetTimeout()},
+		url:      cfg.GetURL(),
+		cfg:      cfg,
+		lastSent: make(map[string]time.Time),
+	}, nil
+}
+
+// Start begins pushing metrics every configured interval
+func (e *OTLPExporter) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	interval := e.cfg.GetInterval()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := e.Push(ctx); err != nil {
+					log.Printf("OTLP export failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	log.Printf("OTLP exporter started: url=%s, interval=%v", e.url, interval)
+}
+
+// Stop stops pushing metrics
+func (e *OTLPExporter) Stop() {
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+// Push sends the samples collected since the previous push
+// Samples already pushed are skipped, and a failed push is retried on the next call
+func (e *OTLPExporter) Push(ctx context.Context) error {
+	return e.push(ctx, e.cfgMgr.Get().Targets)
+}
+
+func (e *OTLPExporter) push(ctx context.Context, targets []config.TargetConfig) error {
+	samples, err := e.pending(ctx, targets)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(buildOTLPRequest(samples, e.cfg.ResourceAttributes))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	e.mu.Lock()
+	for _, m := range samples {
+		e.lastSent[m.TargetName+"/"+m.InstanceName] = m.Timestamp
+	}
+	e.mu.Unlock()
+	return nil
+}
+
+// pending returns the latest sample of each instance of the active targets not pushed yet
+func (e *OTLPExporter) pending(ctx context.Context, targets []config.TargetConfig) ([]models.PoolMetrics, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var samples []models.PoolMetrics
+	for _, target := range targets {
+		if target.Paused {
+			continue
+		}
+		latest, err := e.store.GetLatestAllInstances(ctx, target.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", target.Name, err)
+		}
+		for _, m := range latest {
+			if m.Timestamp.After(e.lastSent[m.TargetName+"/"+m.InstanceName]) {
+				samples = append(samples, m)
+			}
+		}
+	}
+	return samples, nil
+}
+
+// OTLP JSON messages, see opentelemetry-proto's ExportMetricsServiceRequest
+// 64-bit integers are encoded as strings, as the protobuf JSON mapping requires
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value otlpAttrString `json:"value"`
+}
+
+type otlpAttrString struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Gauge       *otlpGauge `json:"gauge,omitempty"`
+	Sum         *otlpSum   `json:"sum,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpDataPoint struct {
+	TimeUnixNano string   `json:"timeUnixNano"`
+	AsDouble     *float64 `json:"asDouble,omitempty"`
+	AsInt        string   `json:"asInt,omitempty"`
+}
+
+// otlpMetricDef maps a PoolMetrics field to an OTLP metric
+type otlpMetricDef struct {
+	name        string
+	description string
+	unit        string
+	monotonic   bool // Cumulative counter exported as a monotonic sum
+	jvm         bool // Omitted for samples without JVM metrics
+	value       func(m *models.PoolMetrics) (float64, bool)
+}
+
+func intValue(f func(m *models.PoolMetrics) int64) func(m *models.PoolMetrics) (float64, bool) {
+	return func(m *models.PoolMetrics) (float64, bool) { return float64(f(m)), true }
+}
+
+func doubleValue(f func(m *models.PoolMetrics) float64) func(m *models.PoolMetrics) (float64, bool) {
+	return func(m *models.PoolMetrics) (float64, bool) { return f(m), false }
+}
+
+// otlpMetrics lists the exported metrics; the value function reports whether it is an integer
+var otlpMetrics = []otlpMetricDef{
+	{name: "pondy.pool.connections.active", description: "Active connections", unit: "{connection}",
+		value: intValue(func(m *models.PoolMetrics) int64 { return int64(m.Active) })},
+	{name: "pondy.pool.connections.idle", description: "Idle connections", unit: "{connection}",
+		value: intValue(func(m *models.PoolMetrics) int64 { return int64(m.Idle) })},
+	{name: "pondy.pool.connections.pending", description: "Threads waiting for a connection", unit: "{thread}",
+		value: intValue(func(m *models.PoolMetrics) int64 { return int64(m.Pending) })},
+	{name: "pondy.pool.connections.max", description: "Maximum pool size", unit: "{connection}",
+		value: intValue(func(m *models.PoolMetrics) int64 { return int64(m.Max) })},
+	{name: "pondy.pool.timeouts", description: "Connection acquire timeouts", unit: "{timeout}", monotonic: true,
+		value: intValue(func(m *models.PoolMetrics) int64 { return m.Timeout })},
+	{name: "pondy.pool.acquire.p50", description: "Connection acquire time p50", unit: "ms",
+		value: doubleValue(func(m *models.PoolMetrics) float64 { return m.AcquireP50 })},
+	{name: "pondy.pool.acquire.p95", description: "Connection acquire time p95", unit: "ms",
+		value: doubleValue(func(m *models.PoolMetrics) float64 { return m.AcquireP95 })},
+	{name: "pondy.pool.acquire.p99", description: "Connection acquire time p99", unit: "ms",
+		value: doubleValue(func(m *models.PoolMetrics) float64 { return m.AcquireP99 })},
+	{name: "pondy.pool.acquire.max", description: "Maximum connection acquire time", unit: "ms",
+		value: doubleValue(func(m *models.PoolMetrics) float64 { return m.AcquireMax })},
+	{name: "pondy.jvm.memory.heap.used", description: "Used heap memory", unit: "By", jvm: true,
+		value: intValue(func(m *models.PoolMetrics) int64 { return m.HeapUsed })},
+	{name: "pondy.jvm.memory.heap.max", description: "Maximum heap memory", unit: "By", jvm: true,
+		value: intValue(func(m *models.PoolMetrics) int64 { return m.HeapMax })},
+	{name: "pondy.jvm.memory.nonheap.used", description: "Used non-heap memory", unit: "By", jvm: true,
+		value: intValue(func(m *models.PoolMetrics) int64 { return m.NonHeapUsed })},
+	{name: "pondy.jvm.threads.live", description: "Live threads", unit: "{thread}", jvm: true,
+		value: intValue(func(m *models.PoolMetrics) int64 { return int64(m.ThreadsLive) })},
+	{name: "pondy.jvm.cpu.usage", description: "Process CPU usage (0-1)", unit: "1", jvm: true,
+		value: doubleValue(func(m *models.PoolMetrics) float64 { return m.CpuUsage })},
+	{name: "pondy.jvm.gc.count", description: "Garbage collections", unit: "{collection}", monotonic: true, jvm: true,
+		value: intValue(func(m *models.PoolMetrics) int64 { return m.GcCount })},
+	{name: "pondy.jvm.gc.time", description: "Time spent in garbage collection", unit: "s", monotonic: true, jvm: true,
+		value: doubleValue(func(m *models.PoolMetrics) float64 { return m.GcTime })},
+}
+
+// buildOTLPRequest converts samples into one OTLP resource per target instance
+func buildOTLPRequest(samples []models.PoolMetrics, extra map[string]string) otlpRequest {
+	var extraAttrs []otlpAttribute
+	for k, v := range extra {
+		if k == "service.name" || k == "service.instance.id" {
+			continue
+		}
+		extraAttrs = append(extraAttrs, otlpAttribute{Key: k, Value: otlpAttrString{v}})
+	}
+	sort.Slice(extraAttrs, func(i, j int) bool { return extraAttrs[i].Key < extraAttrs[j].Key })
+
+	req := otlpRequest{ResourceMetrics: make([]otlpResourceMetrics, 0, len(samples))}
+	for i := range samples {
+		m := &samples[i]
+		attrs := append([]otlpAttribute{
+			{Key: "service.name", Value: otlpAttrString{m.TargetName}},
+			{Key: "service.instance.id", Value: otlpAttrString{m.InstanceName}},
+		}, extraAttrs...)
+
+		req.ResourceMetrics = append(req.ResourceMetrics, otlpResourceMetrics{
+			Resource: otlpResource{Attributes: attrs},
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope:   otlpScope{Name: scopeName},
+				Metrics: sampleMetrics(m),
+			}},
+		})
+	}
+	return req
+}
+
+// sampleMetrics converts one sample into OTLP metrics with a single data point each
+// Error samples carry no pool values and only report the data that was collected
+func sampleMetrics(m *models.PoolMetrics) []otlpMetric {
+	ts := strconv.FormatInt(m.Timestamp.UnixNano(), 10)
+	hasJVM := m.HeapMax > 0 || m.ThreadsLive > 0
+	hasPool := m.Status == models.StatusHealthy
+
+	var metrics []otlpMetric
+	for _, def := range otlpMetrics {
+		if def.jvm && !hasJVM || !def.jvm && !hasPool {
+			continue
+		}
+		v, isInt := def.value(m)
+		point := otlpDataPoint{TimeUnixNano: ts}
+		if isInt {
+			point.AsInt = strconv.FormatInt(int64(v), 10)
+		} else {
+			point.AsDouble = &v
+		}
+
+		metric := otlpMetric{Name: def.name, Description: def.description, Unit: def.unit}
+		if def.monotonic {
+			metric.Sum = &otlpSum{
+				DataPoints:             []otlpDataPoint{point},
+				AggregationTemporality: temporalityCumulative,
+				IsMonotonic:            true,
+			}
+		} else {
+			metric.Gauge = &otlpGauge{DataPoints: []otlpDataPoint{point}}
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestBuildOTLPRequest(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	req := buildOTLPRequest([]models.PoolMetrics{
+		{TargetName: "orders", InstanceName: "pod-1", Status: models.StatusHealthy, Active: 7, Max: 10, Timeout: 3, HeapMax: 1 << 30, CpuUsage: 0.25, Timestamp: ts},
+		{TargetName: "billing", InstanceName: "pod-2", Status: models.StatusError, Timestamp: ts},
+	}, map[string]string{"deployment.environment": "prod", "service.name": "ignored"})
+
+	if len(req.ResourceMetrics) != 2 {
+		t.Fatalf("resources = %d, want 2", len(req.ResourceMetrics))
+	}
+	attrs := req.ResourceMetrics[0].Resource.Attributes
+	want := []string{"service.name=orders", "service.instance.id=pod-1", "deployment.environment=prod"}
+	if len(attrs) != len(want) {
+		t.Fatalf("attributes = %+v", attrs)
+	}
+	for i, a := range attrs {
+		if got := a.Key + "=" + a.Value.StringValue; got != want[i] {
+			t.Errorf("attribute[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+
+	metrics := map[string]otlpMetric{}
+	for _, m := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	active := metrics["pondy.pool.connections.active"]
+	if active.Gauge == nil || active.Gauge.DataPoints[0].AsInt != "7" || active.Gauge.DataPoints[0].TimeUnixNano != "1700000000000000000" {
+		t.Errorf("active = %+v", active)
+	}
+	timeouts := metrics["pondy.pool.timeouts"]
+	if timeouts.Sum == nil || !timeouts.Sum.IsMonotonic || timeouts.Sum.AggregationTemporality != temporalityCumulative {
+		t.Errorf("timeouts = %+v", timeouts)
+	}
+	if cpu := metrics["pondy.jvm.cpu.usage"]; cpu.Gauge == nil || *cpu.Gauge.DataPoints[0].AsDouble != 0.25 {
+		t.Errorf("cpu = %+v", cpu)
+	}
+
+	// Error samples without JVM data export nothing
+	if got := req.ResourceMetrics[1].ScopeMetrics[0].Metrics; len(got) != 0 {
+		t.Errorf("error sample metrics = %d, want 0", len(got))
+	}
+}
+
+func TestOTLPExporter_Push(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "otlp.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	var mu sync.Mutex
+	var received []otlpRequest
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		received = append(received, req)
+	}))
+	defer srv.Close()
+
+	e, err := NewOTLPExporter(nil, store, config.OTLPExportConfig{
+		Enabled:  true,
+		Endpoint: srv.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() error = %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	save := func(instance string, ts time.Time) {
+		m := models.PoolMetrics{TargetName: "orders", InstanceName: instance, Status: models.StatusHealthy, Active: 1, Max: 10, Timestamp: ts}
+		if err := store.Save(context.Background(), &m); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	save("a", now.Add(-time.Minute))
+	save("b", now.Add(-time.Minute))
+	targets := []config.TargetConfig{{Name: "orders"}, {Name: "paused", Paused: true}}
+
+	if err := e.push(context.Background(), targets); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+	if len(received) != 1 || len(received[0].ResourceMetrics) != 2 {
+		t.Fatalf("first push = %+v", received)
+	}
+
+	// Nothing new: no request
+	if err := e.push(context.Background(), targets); err != nil || len(received) != 1 {
+		t.Fatalf("second push err=%v requests=%d, want no request", err, len(received))
+	}
+
+	// A failed push is retried on the next call
+	save("a", now)
+	fail = true
+	if err := e.push(context.Background(), targets); err == nil {
+		t.Fatal("push() should fail when the collector rejects the request")
+	}
+	fail = false
+	if err := e.push(context.Background(), targets); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+	if len(received) != 2 || len(received[1].ResourceMetrics) != 1 {
+		t.Fatalf("retry push = %+v", received[1:])
+	}
+}
@@ -0,0 +1,502 @@
+// Package expr evaluates arithmetic expressions over metric variables,
+// e.g., "active / max * 100" or "delta(gc_count) / interval".
+// It is shared by derived metrics and alert rule conditions.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Env resolves variables during evaluation
+type Env interface {
+	// Value returns the current value of a variable
+	Value(name string) (float64, error)
+
+	// Delta returns the change of a variable since the previous sample
+	Delta(name string) (float64, error)
+}
+
+// WindowEnv is an Env that can aggregate a variable over recent history
+type WindowEnv interface {
+	Env
+
+	// Window aggregates a variable over the given window, fn is avg, min, max or sum
+	Window(fn, name string, window time.Duration) (float64, error)
+}
+
+// Expr is a parsed expression
+type Expr struct {
+	src    string
+	root   node
+	window time.Duration // longest window used
+}
+
+// Parse parses an expression
+// Supported: numbers, variables, + - * /, parentheses, and the functions
+// delta(variable), abs(x), min(x, y) and max(x, y)
+// Windowed aggregates avg|min|max|sum(variable, duration), e.g., avg(usage, 5m),
+// need an env implementing WindowEnv
+func Parse(s string) (*Expr, error) {
+	p := &parser{src: s}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("expression cannot be empty")
+	}
+
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in expression '%s'", p.tokens[p.pos].text, s)
+	}
+	return &Expr{src: strings.TrimSpace(s), root: root, window: p.window}, nil
+}
+
+// String returns the expression source
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression
+// Division by zero yields NaN or Inf, which callers should treat as no value
+func (e *Expr) Eval(env Env) (float64, error) {
+	return e.root.eval(env)
+}
+
+// Vars returns the variables the expression reads, in order of first use
+func (e *Expr) Vars() []string {
+	var vars []string
+	seen := make(map[string]bool)
+	e.root.walk(func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			vars = append(vars, name)
+		}
+	})
+	return vars
+}
+
+// IsConstant reports whether the expression reads no variables
+func (e *Expr) IsConstant() bool {
+	return len(e.Vars()) == 0
+}
+
+// MaxWindow returns the longest aggregation window, or 0 without windowed aggregates
+func (e *Expr) MaxWindow() time.Duration {
+	return e.window
+}
+
+// Aggregate reduces values with a window function: avg, min, max or sum
+// Returns NaN for no values
+func Aggregate(fn string, values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	result := values[0]
+	if fn == "avg" || fn == "sum" {
+		result = 0
+	}
+	for i, v := range values {
+		switch fn {
+		case "min":
+			if i > 0 {
+				result = math.Min(result, v)
+			}
+		case "max":
+			if i > 0 {
+				result = math.Max(result, v)
+			}
+		default:
+			result += v
+		}
+	}
+	if fn == "avg" {
+		result /= float64(len(values))
+	}
+	return result
+}
+
+// node is an expression tree node
+type node interface {
+	eval(env Env) (float64, error)
+	walk(fn func(name string))
+}
+
+type numberNode float64
+
+func (n numberNode) eval(Env) (float64, error) { return float64(n), nil }
+func (n numberNode) walk(func(string))         {}
+
+type varNode string
+
+func (n varNode) eval(env Env) (float64, error) { return env.Value(string(n)) }
+func (n varNode) walk(fn func(string))          { fn(string(n)) }
+
+type deltaNode string
+
+func (n deltaNode) eval(env Env) (float64, error) { return env.Delta(string(n)) }
+func (n deltaNode) walk(fn func(string))          { fn(string(n)) }
+
+type windowNode struct {
+	fn     string
+	name   string
+	window time.Duration
+}
+
+func (n windowNode) eval(env Env) (float64, error) {
+	w, ok := env.(WindowEnv)
+	if !ok {
+		return 0, fmt.Errorf("%s(%s, %s) needs history, windowed aggregates are not supported here", n.fn, n.name, n.window)
+	}
+	return w.Window(n.fn, n.name, n.window)
+}
+func (n windowNode) walk(fn func(string)) { fn(n.name) }
+
+type negNode struct{ x node }
+
+func (n negNode) eval(env Env) (float64, error) {
+	v, err := n.x.eval(env)
+	return -v, err
+}
+func (n negNode) walk(fn func(string)) { n.x.walk(fn) }
+
+type binaryNode struct {
+	op   byte
+	l, r node
+}
+
+func (n binaryNode) eval(env Env) (float64, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	default:
+		if r == 0 {
+			return math.NaN(), nil
+		}
+		return l / r, nil
+	}
+}
+
+func (n binaryNode) walk(fn func(string)) {
+	n.l.walk(fn)
+	n.r.walk(fn)
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n callNode) eval(env Env) (float64, error) {
+	vals := make([]float64, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		vals[i] = v
+	}
+	switch n.name {
+	case "abs":
+		return math.Abs(vals[0]), nil
+	case "min":
+		return math.Min(vals[0], vals[1]), nil
+	default:
+		return math.Max(vals[0], vals[1]), nil
+	}
+}
+
+func (n callNode) walk(fn func(string)) {
+	for _, a := range n.args {
+		a.walk(fn)
+	}
+}
+
+// functionArgs is the number of arguments of each function
+var functionArgs = map[string]int{
+	"delta": 1,
+	"abs":   1,
+	"min":   2,
+	"max":   2,
+}
+
+// windowFunctions can aggregate a variable over a window, e.g., avg(usage, 5m)
+var windowFunctions = map[string]bool{
+	"avg": true,
+	"min": true,
+	"max": true,
+	"sum": true,
+}
+
+type tokenKind int
+
+const (
+	tokNumber tokenKind = iota
+	tokDuration
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	window time.Duration
+}
+
+func (p *parser) tokenize() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("+-*/(),", c):
+			p.tokens = append(p.tokens, token{tokOp, string(c)})
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			// A unit suffix makes a duration, e.g., 5m or 1h30m
+			k := j
+			if k < len(s) && unicode.IsLetter(rune(s[k])) {
+				for k < len(s) && (unicode.IsLetter(rune(s[k])) || unicode.IsDigit(rune(s[k])) || s[k] == '.') {
+					k++
+				}
+			}
+			if k > j {
+				p.tokens = append(p.tokens, token{tokDuration, strings.ToLower(s[i:k])})
+			} else {
+				p.tokens = append(p.tokens, token{tokNumber, s[i:j]})
+			}
+			i = k
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_') {
+				j++
+			}
+			p.tokens = append(p.tokens, token{tokIdent, strings.ToLower(s[i:j])})
+			i = j
+		default:
+			return fmt.Errorf("unexpected character '%c' in expression '%s'", c, s)
+		}
+	}
+	return nil
+}
+
+func (p *parser) peek() *token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *parser) peekOp(ops string) (byte, bool) {
+	t := p.peek()
+	if t == nil || t.kind != tokOp || !strings.Contains(ops, t.text) {
+		return 0, false
+	}
+	return t.text[0], true
+}
+
+func (p *parser) expect(op string) error {
+	t := p.peek()
+	if t == nil || t.kind != tokOp || t.text != op {
+		return fmt.Errorf("expected '%s' in expression '%s'", op, p.src)
+	}
+	p.pos++
+	return nil
+}
+
+// parseSum parses terms joined by + and -
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("+-")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, l: left, r: right}
+	}
+}
+
+// parseProduct parses factors joined by * and /
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("*/")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, l: left, r: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if _, ok := p.peekOp("-"); ok {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of expression '%s'", p.src)
+	}
+	p.pos++
+
+	switch t.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' in expression '%s'", t.text, p.src)
+		}
+		return numberNode(v), nil
+
+	case tokDuration:
+		return nil, fmt.Errorf("unexpected duration '%s' in expression '%s': durations are only allowed as a window, e.g., avg(usage, 5m)", t.text, p.src)
+
+	case tokIdent:
+		if _, ok := p.peekOp("("); !ok {
+			return varNode(t.text), nil
+		}
+		return p.parseCall(t.text)
+
+	default:
+		if t.text == "(" {
+			x, err := p.parseSum()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+		return nil, fmt.Errorf("unexpected '%s' in expression '%s'", t.text, p.src)
+	}
+}
+
+func (p *parser) parseCall(name string) (node, error) {
+	nargs, ok := functionArgs[name]
+	if !ok && !windowFunctions[name] {
+		return nil, fmt.Errorf("unknown function '%s'. Valid functions: delta, abs, min, max, avg, sum", name)
+	}
+	p.pos++ // (
+
+	var args []node
+	for {
+		// A duration argument makes a windowed aggregate
+		if t := p.peek(); windowFunctions[name] && len(args) == 1 && t != nil && t.kind == tokDuration {
+			p.pos++
+			return p.parseWindow(name, args[0], t.text)
+		}
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if _, ok := p.peekOp(","); !ok {
+			break
+		}
+		p.pos++
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s() takes a variable and a window, e.g., %s(usage, 5m)", name, name)
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if len(args) != nargs {
+		return nil, fmt.Errorf("%s() takes %d argument(s), got %d", name, nargs, len(args))
+	}
+
+	if name == "delta" {
+		v, ok := args[0].(varNode)
+		if !ok {
+			return nil, fmt.Errorf("delta() takes a variable name")
+		}
+		return deltaNode(v), nil
+	}
+	return callNode{name: name, args: args}, nil
+}
+
+// parseWindow finishes a windowed aggregate after its duration argument
+func (p *parser) parseWindow(name string, arg node, duration string) (node, error) {
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	v, ok := arg.(varNode)
+	if !ok {
+		return nil, fmt.Errorf("%s() window takes a variable name", name)
+	}
+	window, err := parseDuration(duration)
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid window '%s' in expression '%s'", duration, p.src)
+	}
+	if window > p.window {
+		p.window = window
+	}
+	return windowNode{fn: name, name: string(v), window: window}, nil
+}
+
+// parseDuration parses a window, supporting a "d" (days) suffix
+func parseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
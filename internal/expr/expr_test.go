@@ -0,0 +1,204 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// mapEnv resolves variables from maps of current and previous values
+type mapEnv struct {
+	cur, prev map[string]float64
+}
+
+func (e mapEnv) Value(name string) (float64, error) {
+	v, ok := e.cur[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown variable: %s", name)
+	}
+	return v, nil
+}
+
+func (e mapEnv) Delta(name string) (float64, error) {
+	p, ok := e.prev[name]
+	if !ok {
+		return 0, fmt.Errorf("no previous sample")
+	}
+	return e.cur[name] - p, nil
+}
+
+func TestEval(t *testing.T) {
+	env := mapEnv{
+		cur:  map[string]float64{"active": 8, "max": 20, "gc_count": 130, "interval": 15},
+		prev: map[string]float64{"gc_count": 100},
+	}
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"active / max * 100", 40},
+		{"delta(gc_count) / interval", 2},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"-active + 10", 2},
+		{"10 - 4 - 3", 3},
+		{"max(active, 10) + min(active, 10)", 18},
+		{"abs(0 - active)", 8},
+		{"ACTIVE", 8},
+		{"0.5 * max", 10},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+		got, err := e.Eval(env)
+		if err != nil {
+			t.Errorf("Eval(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEval_DivisionByZero(t *testing.T) {
+	e, _ := Parse("active / 0")
+	got, err := e.Eval(mapEnv{cur: map[string]float64{"active": 1}})
+	if err != nil || !math.IsNaN(got) {
+		t.Errorf("Eval() = %v, %v, want NaN", got, err)
+	}
+}
+
+func TestEval_UnknownVariable(t *testing.T) {
+	e, _ := Parse("cpu * 2")
+	if _, err := e.Eval(mapEnv{}); err == nil {
+		t.Error("Eval() should fail for unknown variable")
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	invalid := []string{"", "active +", "(active", "active max", "foo(1)", "delta(1 + 2)", "min(1)", "active # 2", "1..2",
+		"5m", "active + 5m", "avg(usage)", "avg(1, 2)", "avg(usage + 1, 5m)", "avg(usage, 5x)", "avg(usage, 5m, 1)", "abs(usage, 5m)"}
+	for _, s := range invalid {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) should fail", s)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	e, _ := Parse("delta(gc_time) / interval + gc_time * 2")
+	vars := e.Vars()
+	if len(vars) != 2 || vars[0] != "gc_time" || vars[1] != "interval" {
+		t.Errorf("Vars() = %v, want [gc_time interval]", vars)
+	}
+	if e.IsConstant() {
+		t.Error("IsConstant() = true")
+	}
+
+	c, _ := Parse("80")
+	if !c.IsConstant() {
+		t.Error("IsConstant() = false for a number")
+	}
+}
+
+// windowEnv aggregates fixed series
+type windowEnv struct {
+	mapEnv
+	series map[string][]float64
+}
+
+func (e windowEnv) Window(fn, name string, window time.Duration) (float64, error) {
+	values, ok := e.series[name]
+	if !ok {
+		return 0, fmt.Errorf("no data for %s", name)
+	}
+	// Each value is one minute apart, newest last
+	if n := int(window / time.Minute); n < len(values) {
+		values = values[len(values)-n:]
+	}
+	return Aggregate(fn, values), nil
+}
+
+func TestEval_Window(t *testing.T) {
+	env := windowEnv{
+		mapEnv: mapEnv{cur: map[string]float64{"usage": 90, "pending": 0}},
+		series: map[string][]float64{
+			"usage":   {50, 60, 70, 80, 90},
+			"pending": {8, 0, 2, 0, 0},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"avg(usage, 5m)", 70},
+		{"avg(usage, 2m)", 85},
+		{"min(usage, 1h)", 50},
+		{"max(pending, 10m)", 8},
+		{"max(pending, 3m)", 2},
+		{"sum(pending, 5m)", 10},
+		{"max(usage, 50)", 90}, // numeric max
+		{"avg(usage, 5m) - usage", -20},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+		got, err := e.Eval(env)
+		if err != nil {
+			t.Errorf("Eval(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	// Plain envs can't aggregate
+	e, _ := Parse("avg(usage, 5m)")
+	if _, err := e.Eval(env.mapEnv); err == nil {
+		t.Error("Eval() should fail without a WindowEnv")
+	}
+}
+
+func TestMaxWindow(t *testing.T) {
+	tests := []struct {
+		expr string
+		want time.Duration
+	}{
+		{"usage", 0},
+		{"avg(usage, 5m)", 5 * time.Minute},
+		{"avg(usage, 5m) + max(pending, 1h30m)", 90 * time.Minute},
+		{"min(usage, 1d)", 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if got := e.MaxWindow(); got != tt.want {
+			t.Errorf("MaxWindow(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	values := []float64{4, 1, 7}
+	for fn, want := range map[string]float64{"avg": 4, "min": 1, "max": 7, "sum": 12} {
+		if got := Aggregate(fn, values); got != want {
+			t.Errorf("Aggregate(%s) = %v, want %v", fn, got, want)
+		}
+	}
+	if !math.IsNaN(Aggregate("avg", nil)) {
+		t.Error("Aggregate() of no values should be NaN")
+	}
+}
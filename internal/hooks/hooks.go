@@ -0,0 +1,292 @@
+// Package hooks sends pondy lifecycle events (targets added or removed, config reloads,
+// backups, collectors going down) to the webhooks in event_webhooks
+package hooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jiin/pondy/internal/collector"
+	"github.com/jiin/pondy/internal/config"
+)
+
+const (
+	queueSize    = 256 // Events waiting for delivery; further events are dropped
+	maxAttempts  = 3
+	retryDelay   = 2 * time.Second
+	retryBackoff = 2 // exponential backoff multiplier
+
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body when the webhook has a secret
+	SignatureHeader = "X-Pondy-Signature"
+	// EventHeader carries the event type
+	EventHeader = "X-Pondy-Event"
+)
+
+// Event is the JSON body sent to event webhooks
+type Event struct {
+	ID        string                 `json:"id"` // Unique per event, for deduplicating retried deliveries
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+type delivery struct {
+	event    Event
+	webhooks []config.EventWebhookConfig
+}
+
+// Publisher delivers events to the configured webhooks in the background
+// All methods are safe on a nil Publisher, which drops events
+type Publisher struct {
+	mu       sync.Mutex
+	webhooks []config.EventWebhookConfig
+	targets  map[string]config.TargetConfig // Config targets at the last reload, to detect added and removed ones
+
+	client     *http.Client
+	queue      chan delivery
+	retryDelay time.Duration
+	stop       chan struct{}
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+// NewPublisher creates a publisher for the webhooks and targets of cfg and starts delivering
+func NewPublisher(cfg *config.Config) *Publisher {
+	p := &Publisher{
+		client:     &http.Client{Timeout: 10 * time.Second},
+		queue:      make(chan delivery, queueSize),
+		retryDelay: retryDelay,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	p.webhooks = validWebhooks(cfg.EventWebhooks)
+	p.targets = targetsByName(cfg.Targets)
+	go p.run()
+	return p
+}
+
+// validWebhooks returns the webhooks that pass validation, logging the others
+func validWebhooks(webhooks []config.EventWebhookConfig) []config.EventWebhookConfig {
+	var valid []config.EventWebhookConfig
+	for _, w := range webhooks {
+		if err := w.Validate(); err != nil {
+			log.Printf("Event webhooks: skipping invalid webhook: %v", err)
+			continue
+		}
+		valid = append(valid, w)
+	}
+	return valid
+}
+
+func targetsByName(targets []config.TargetConfig) map[string]config.TargetConfig {
+	byName := make(map[string]config.TargetConfig, len(targets))
+	for _, t := range targets {
+		byName[t.Name] = t
+	}
+	return byName
+}
+
+// Reload applies the webhooks of a reloaded config and publishes config.reloaded,
+// target.created and target.deleted, whether the change came from the file or the API
+func (p *Publisher) Reload(cfg *config.Config) {
+	if p == nil {
+		return
+	}
+	targets := targetsByName(cfg.Targets)
+
+	p.mu.Lock()
+	p.webhooks = validWebhooks(cfg.EventWebhooks)
+	previous := p.targets
+	p.targets = targets
+	p.mu.Unlock()
+
+	for _, t := range cfg.Targets {
+		if _, ok := previous[t.Name]; !ok {
+			p.Publish(config.EventTargetCreated, targetData(t))
+		}
+	}
+	for _, t := range previous {
+		if _, ok := targets[t.Name]; !ok {
+			p.Publish(config.EventTargetDeleted, targetData(t))
+		}
+	}
+	p.Publish(config.EventConfigReloaded, map[string]interface{}{"targets": len(cfg.Targets)})
+}
+
+func targetData(t config.TargetConfig) map[string]interface{} {
+	data := map[string]interface{}{"target": t.Name, "type": t.Type}
+	if t.Group != "" {
+		data["group"] = t.Group
+	}
+	if t.Project != "" {
+		data["project"] = t.Project
+	}
+	return data
+}
+
+// BackupCompleted publishes backup.completed; trigger is "manual" or "scheduled"
+// path is the local file and remoteKey the object storage key, each empty when not kept
+func (p *Publisher) BackupCompleted(trigger, path, remoteKey string) {
+	data := map[string]interface{}{"trigger": trigger}
+	if path != "" {
+		data["path"] = path
+	}
+	if remoteKey != "" {
+		data["remote_key"] = remoteKey
+	}
+	p.Publish(config.EventBackupCompleted, data)
+}
+
+// CollectorChanged publishes collector.down or collector.up for a circuit breaker change
+func (p *Publisher) CollectorChanged(change collector.StateChange) {
+	data := map[string]interface{}{
+		"target":   change.TargetName,
+		"instance": change.InstanceName,
+	}
+	if !change.Down {
+		p.Publish(config.EventCollectorUp, data)
+		return
+	}
+	data["failures"] = change.Failures
+	data["error"] = change.LastError
+	p.Publish(config.EventCollectorDown, data)
+}
+
+// Publish queues an event for the webhooks subscribed to its type
+// Never blocks: events are dropped when the queue is full
+func (p *Publisher) Publish(eventType string, data map[string]interface{}) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	var webhooks []config.EventWebhookConfig
+	for _, w := range p.webhooks {
+		if w.Wants(eventType) {
+			webhooks = append(webhooks, w)
+		}
+	}
+	p.mu.Unlock()
+	if len(webhooks) == 0 {
+		return
+	}
+
+	event := Event{ID: newEventID(), Type: eventType, Timestamp: time.Now().UTC(), Data: data}
+	select {
+	case p.queue <- delivery{event: event, webhooks: webhooks}:
+	default:
+		log.Printf("Event webhooks: queue full, dropping %s event", eventType)
+	}
+}
+
+func newEventID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func (p *Publisher) run() {
+	defer close(p.done)
+	for {
+		select {
+		case d := <-p.queue:
+			body, err := json.Marshal(d.event)
+			if err != nil {
+				log.Printf("Event webhooks: failed to encode %s event: %v", d.event.Type, err)
+				continue
+			}
+			for _, w := range d.webhooks {
+				if err := p.deliver(w, d.event.Type, body); err != nil {
+					log.Printf("Event webhooks: %s event to %s failed: %v", d.event.Type, w.Name, err)
+				}
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// deliver posts the body, retrying connection errors and 5xx responses with backoff
+func (p *Publisher) deliver(w config.EventWebhookConfig, eventType string, body []byte) error {
+	delay := p.retryDelay
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(delay):
+				delay *= retryBackoff
+			case <-p.stop:
+				return fmt.Errorf("stopped before retrying: %w", lastErr)
+			}
+		}
+
+		retry, err := p.post(w, eventType, body)
+		if err == nil {
+			return nil
+		}
+		if !retry {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("failed after %d attempts: %w", maxAttempts, lastErr)
+}
+
+// post sends one request and reports whether a failure is worth retrying
+func (p *Publisher) post(w config.EventWebhookConfig, eventType string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	for key, value := range w.Headers {
+		req.Header.Set(key, value)
+	}
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return false, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the signature header value of a body: "sha256=" and the hex HMAC-SHA256 with the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Stop stops delivering; queued events are dropped
+func (p *Publisher) Stop() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+}
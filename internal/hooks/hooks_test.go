@@ -0,0 +1,146 @@
+package hooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/collector"
+	"github.com/jiin/pondy/internal/config"
+)
+
+// recorder is a webhook endpoint recording the events it receives
+type recorder struct {
+	mu       sync.Mutex
+	events   []Event
+	headers  []http.Header
+	failures int // Requests to answer with 503 before accepting
+	received chan struct{}
+}
+
+func newRecorder(t *testing.T) (*recorder, *httptest.Server) {
+	r := &recorder{received: make(chan struct{}, 100)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.failures > 0 {
+			r.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("invalid event body: %v", err)
+		}
+		r.events = append(r.events, event)
+		h := req.Header.Clone()
+		h.Set("X-Body", string(body))
+		r.headers = append(r.headers, h)
+		r.received <- struct{}{}
+	}))
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+func (r *recorder) wait(t *testing.T, n int) []Event {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.received:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d events, want %d", i, n)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+func TestPublisher_Reload(t *testing.T) {
+	rec, srv := newRecorder(t)
+	webhooks := []config.EventWebhookConfig{{Name: "cmdb", URL: srv.URL}}
+	p := NewPublisher(&config.Config{
+		EventWebhooks: webhooks,
+		Targets:       []config.TargetConfig{{Name: "orders"}, {Name: "billing"}},
+	})
+	defer p.Stop()
+
+	p.Reload(&config.Config{
+		EventWebhooks: webhooks,
+		Targets:       []config.TargetConfig{{Name: "orders"}, {Name: "search", Type: "actuator", Project: "search"}},
+	})
+	events := rec.wait(t, 3)
+
+	want := []struct{ typ, target string }{
+		{config.EventTargetCreated, "search"},
+		{config.EventTargetDeleted, "billing"},
+		{config.EventConfigReloaded, ""},
+	}
+	for i, w := range want {
+		if events[i].Type != w.typ {
+			t.Errorf("event %d type = %s, want %s", i, events[i].Type, w.typ)
+		}
+		if w.target != "" && events[i].Data["target"] != w.target {
+			t.Errorf("event %d target = %v, want %s", i, events[i].Data["target"], w.target)
+		}
+	}
+	if events[0].Data["project"] != "search" {
+		t.Errorf("target.created data = %v, want the project", events[0].Data)
+	}
+	if events[0].ID == "" || events[0].ID == events[1].ID {
+		t.Errorf("event IDs = %q, %q, want unique", events[0].ID, events[1].ID)
+	}
+}
+
+func TestPublisher_FilterAndSign(t *testing.T) {
+	rec, srv := newRecorder(t)
+	p := NewPublisher(&config.Config{EventWebhooks: []config.EventWebhookConfig{
+		{Name: "chatops", URL: srv.URL, Events: []string{config.EventCollectorDown}, Secret: "s3cret", Headers: map[string]string{"X-Team": "sre"}},
+	}})
+	defer p.Stop()
+
+	p.CollectorChanged(collector.StateChange{TargetName: "orders", InstanceName: "a", Down: false})
+	p.CollectorChanged(collector.StateChange{TargetName: "orders", InstanceName: "a", Down: true, Failures: 5, LastError: "connection refused"})
+	events := rec.wait(t, 1)
+
+	if len(events) != 1 || events[0].Type != config.EventCollectorDown {
+		t.Fatalf("events = %+v, want only collector.down", events)
+	}
+	if events[0].Data["error"] != "connection refused" {
+		t.Errorf("collector.down data = %v", events[0].Data)
+	}
+
+	h := rec.headers[0]
+	if got, want := h.Get(SignatureHeader), Sign("s3cret", []byte(h.Get("X-Body"))); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if h.Get(EventHeader) != config.EventCollectorDown || h.Get("X-Team") != "sre" {
+		t.Errorf("headers = %v", h)
+	}
+}
+
+func TestPublisher_Retry(t *testing.T) {
+	rec, srv := newRecorder(t)
+	rec.failures = 2
+	p := NewPublisher(&config.Config{EventWebhooks: []config.EventWebhookConfig{{Name: "cmdb", URL: srv.URL}}})
+	p.retryDelay = time.Millisecond
+	defer p.Stop()
+
+	p.BackupCompleted("manual", "./data/backups/b.db", "")
+	events := rec.wait(t, 1)
+	if events[0].Type != config.EventBackupCompleted || events[0].Data["trigger"] != "manual" {
+		t.Errorf("event = %+v", events[0])
+	}
+}
+
+func TestPublisher_Nil(t *testing.T) {
+	var p *Publisher
+	p.Publish(config.EventConfigReloaded, nil)
+	p.Reload(&config.Config{})
+	p.Stop()
+}
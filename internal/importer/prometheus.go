@@ -0,0 +1,303 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// DefaultSelector is used when a target has no prometheus_selector configured
+const DefaultSelector = `instance="$instance"`
+
+// maxPointsPerQuery is Prometheus's limit of points per series in one range query;
+// longer lookbacks are split into several queries
+const maxPointsPerQuery = 11000
+
+// PrometheusImporter backfills target history from a Prometheus server
+type PrometheusImporter struct {
+	cfg    config.PrometheusImportConfig
+	store  storage.Storage
+	client *http.Client
+}
+
+// NewPrometheusImporter creates a new Prometheus importer
+func NewPrometheusImporter(cfg config.PrometheusImportConfig, store storage.Storage) *PrometheusImporter {
+	return &PrometheusImporter{
+		cfg:   cfg,
+		store: store,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// IsEnabled returns whether the importer is configured
+func (p *PrometheusImporter) IsEnabled() bool {
+	return p.cfg.Enabled && p.cfg.URL != ""
+}
+
+// BackfillResult summarizes a backfill run for one target
+type BackfillResult struct {
+	TargetName string         `json:"target_name"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Instances  map[string]int `json:"instances"` // instance ID -> imported datapoints
+	Skipped    []string       `json:"skipped,omitempty"`
+	Errors     []string       `json:"errors,omitempty"`
+}
+
+// Imported returns the total number of imported datapoints
+func (r *BackfillResult) Imported() int {
+	total := 0
+	for _, n := range r.Instances {
+		total += n
+	}
+	return total
+}
+
+// promSeries maps a PoolMetrics field to the PromQL expression that feeds it
+type promSeries struct {
+	query string // %s is replaced by the label selector
+	apply func(m *models.PoolMetrics, v float64)
+}
+
+// series lists Micrometer's Prometheus names for the metrics pondy collects
+var series = []promSeries{
+	{`sum(hikaricp_connections_active{%s})`, func(m *models.PoolMetrics, v float64) { m.Active = int(v) }},
+	{`sum(hikaricp_connections_idle{%s})`, func(m *models.PoolMetrics, v float64) { m.Idle = int(v) }},
+	{`sum(hikaricp_connections_pending{%s})`, func(m *models.PoolMetrics, v float64) { m.Pending = int(v) }},
+	{`sum(hikaricp_connections_max{%s})`, func(m *models.PoolMetrics, v float64) { m.Max = int(v) }},
+	{`sum(hikaricp_connections_timeout_total{%s})`, func(m *models.PoolMetrics, v float64) { m.Timeout = int64(v) }},
+	{`max(hikaricp_connections_acquire_seconds{quantile="0.5",%s}) * 1000`, func(m *models.PoolMetrics, v float64) { m.AcquireP50 = v }},
+	{`max(hikaricp_connections_acquire_seconds{quantile="0.95",%s}) * 1000`, func(m *models.PoolMetrics, v float64) { m.AcquireP95 = v }},
+	{`max(hikaricp_connections_acquire_seconds{quantile="0.99",%s}) * 1000`, func(m *models.PoolMetrics, v float64) { m.AcquireP99 = v }},
+	{`max(hikaricp_connections_acquire_seconds_max{%s}) * 1000`, func(m *models.PoolMetrics, v float64) { m.AcquireMax = v }},
+	{`sum(jvm_memory_used_bytes{area="heap",%s})`, func(m *models.PoolMetrics, v float64) { m.HeapUsed = int64(v) }},
+	{`sum(jvm_memory_max_bytes{area="heap",%s})`, func(m *models.PoolMetrics, v float64) { m.HeapMax = int64(v) }},
+	{`sum(jvm_memory_used_bytes{area="nonheap",%s})`, func(m *models.PoolMetrics, v float64) { m.NonHeapUsed = int64(v) }},
+	{`sum(jvm_memory_max_bytes{area="nonheap",%s})`, func(m *models.PoolMetrics, v float64) { m.NonHeapMax = int64(v) }},
+	{`sum(jvm_threads_live_threads{%s})`, func(m *models.PoolMetrics, v float64) { m.ThreadsLive = int(v) }},
+	{`max(process_cpu_usage{%s})`, func(m *models.PoolMetrics, v float64) { m.CpuUsage = v }},
+	{`sum(jvm_gc_pause_seconds_count{%s})`, func(m *models.PoolMetrics, v float64) { m.GcCount = int64(v) }},
+	{`sum(jvm_gc_pause_seconds_sum{%s})`, func(m *models.PoolMetrics, v float64) { m.GcTime = v }},
+	{`sum(jvm_gc_pause_seconds_count{action="end of minor GC",%s})`, func(m *models.PoolMetrics, v float64) { m.YoungGcCount = int64(v) }},
+	{`sum(jvm_gc_pause_seconds_count{action="end of major GC",%s})`, func(m *models.PoolMetrics, v float64) { m.OldGcCount = int64(v) }},
+}
+
+// BuildSelector expands a target selector for an instance endpoint
+func BuildSelector(selector, endpoint string) string {
+	if selector == "" {
+		selector = DefaultSelector
+	}
+	selector = strings.TrimSpace(selector)
+	selector = strings.TrimPrefix(selector, "{")
+	selector = strings.TrimSuffix(selector, "}")
+
+	host := endpoint
+	if parsed, err := url.Parse(endpoint); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	return strings.ReplaceAll(selector, "$instance", host)
+}
+
+// Backfill imports history for every instance of a target that has no data yet
+func (p *PrometheusImporter) Backfill(ctx context.Context, target config.TargetConfig) (*BackfillResult, error) {
+	if !p.IsEnabled() {
+		return nil, fmt.Errorf("prometheus bootstrap is not enabled")
+	}
+
+	to := time.Now()
+	from := to.Add(-p.cfg.GetLookback())
+	result := &BackfillResult{
+		TargetName: target.Name,
+		From:       from,
+		To:         to,
+		Instances:  make(map[string]int),
+	}
+
+	for _, inst := range target.GetInstances() {
+		// Never overwrite or interleave with data pondy already collected
+		existing, err := p.store.GetLatestByInstance(ctx, target.Name, inst.ID)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", inst.ID, err))
+			continue
+		}
+		if existing != nil {
+			result.Skipped = append(result.Skipped, inst.ID)
+			continue
+		}
+
+		selector := BuildSelector(target.PrometheusSelector, inst.Endpoint)
+		datapoints, err := p.fetchInstance(ctx, target.Name, inst.ID, selector, from, to)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", inst.ID, err))
+			continue
+		}
+
+		saved := 0
+		for i := range datapoints {
+			if i > 0 {
+				datapoints[i].SetCounterDeltas(&datapoints[i-1])
+			}
+			if err := p.store.Save(ctx, &datapoints[i]); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", inst.ID, err))
+				break
+			}
+			saved++
+		}
+		result.Instances[inst.ID] = saved
+	}
+
+	log.Printf("Prometheus bootstrap: imported %d datapoints for %s (%d instances, %d skipped)",
+		result.Imported(), target.Name, len(result.Instances), len(result.Skipped))
+
+	return result, nil
+}
+
+// fetchInstance range-queries all series for one instance and merges them by timestamp
+func (p *PrometheusImporter) fetchInstance(ctx context.Context, targetName, instanceID, selector string, from, to time.Time) ([]models.PoolMetrics, error) {
+	byTime := make(map[int64]*models.PoolMetrics)
+
+	for _, s := range series {
+		values, err := p.queryRange(ctx, fmt.Sprintf(s.query, selector), from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			m, exists := byTime[v.ts]
+			if !exists {
+				m = &models.PoolMetrics{
+					TargetName:   targetName,
+					InstanceName: instanceID,
+					Status:       models.StatusHealthy,
+					Timestamp:    time.Unix(v.ts, 0),
+				}
+				byTime[v.ts] = m
+			}
+			s.apply(m, v.value)
+		}
+	}
+
+	// Points without pool metrics are not useful for pool analysis
+	result := make([]models.PoolMetrics, 0, len(byTime))
+	for _, m := range byTime {
+		if m.Max > 0 {
+			result = append(result, *m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+
+	return result, nil
+}
+
+// promSample is a single value of a range query result
+type promSample struct {
+	ts    int64
+	value float64
+}
+
+// promRangeResponse is the Prometheus /api/v1/query_range response
+type promRangeResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]interface{}  `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// queryRange runs a range query and returns the samples of the first result series
+// The range is queried in chunks of at most maxPointsPerQuery steps
+func (p *PrometheusImporter) queryRange(ctx context.Context, query string, from, to time.Time) ([]promSample, error) {
+	step := p.cfg.GetStep()
+	span := time.Duration(maxPointsPerQuery-1) * step
+
+	var samples []promSample
+	for start := from; !start.After(to); start = start.Add(span + step) {
+		end := start.Add(span)
+		if end.After(to) {
+			end = to
+		}
+		chunk, err := p.queryChunk(ctx, query, start, end, step)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, chunk...)
+	}
+	return samples, nil
+}
+
+// queryChunk runs one range query and returns the samples of the first result series
+func (p *PrometheusImporter) queryChunk(ctx context.Context, query string, from, to time.Time, step time.Duration) ([]promSample, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(from.Unix(), 10))
+	params.Set("end", strconv.FormatInt(to.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	reqURL := strings.TrimSuffix(p.cfg.URL, "/") + "/api/v1/query_range?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range p.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("prometheus request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed promRangeResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid prometheus response (status %d): %w", resp.StatusCode, err)
+	}
+	if parsed.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", parsed.Error)
+	}
+	if len(parsed.Data.Result) == 0 {
+		return nil, nil
+	}
+
+	samples := make([]promSample, 0, len(parsed.Data.Result[0].Values))
+	for _, pair := range parsed.Data.Result[0].Values {
+		ts, ok := pair[0].(float64)
+		if !ok {
+			continue
+		}
+		str, ok := pair[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, promSample{ts: int64(ts), value: value})
+	}
+	return samples, nil
+}
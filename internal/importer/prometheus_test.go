@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+func TestBuildSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		endpoint string
+		want     string
+	}{
+		{"", "http://app:8080/actuator/metrics", `instance="app:8080"`},
+		{`{job="orders"}`, "http://app:8080", `job="orders"`},
+		{`job="orders",pod="$instance"`, "http://10.0.0.1:8080", `job="orders",pod="10.0.0.1:8080"`},
+	}
+
+	for _, tt := range tests {
+		if got := BuildSelector(tt.selector, tt.endpoint); got != tt.want {
+			t.Errorf("BuildSelector(%q, %q) = %q, want %q", tt.selector, tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	now := time.Now().Unix()
+	var queries []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+
+		value := "0"
+		switch {
+		case strings.Contains(query, "hikaricp_connections_active"):
+			value = "4"
+		case strings.Contains(query, "hikaricp_connections_max"):
+			value = "10"
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[%d,"%s"],[%d,"%s"]]}]}}`,
+			now-60, value, now-30, value)
+	}))
+	defer srv.Close()
+
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	imp := NewPrometheusImporter(config.PrometheusImportConfig{Enabled: true, URL: srv.URL}, store)
+	target := config.TargetConfig{Name: "orders", Endpoint: "http://app:8080/actuator"}
+
+	result, err := imp.Backfill(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	if result.Instances["default"] != 2 {
+		t.Errorf("Imported %d datapoints, want 2", result.Instances["default"])
+	}
+	if !strings.Contains(queries[0], `instance="app:8080"`) {
+		t.Errorf("Query %q does not use the instance selector", queries[0])
+	}
+
+	latest, err := store.GetLatestByInstance(context.Background(), "orders", "default")
+	if err != nil || latest == nil {
+		t.Fatalf("Expected imported metrics, got %v (err: %v)", latest, err)
+	}
+	if latest.Active != 4 || latest.Max != 10 || latest.Status != models.StatusHealthy {
+		t.Errorf("Unexpected metrics: active=%d max=%d status=%s", latest.Active, latest.Max, latest.Status)
+	}
+
+	// A second run must not import over existing data
+	result, err = imp.Backfill(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if len(result.Skipped) != 1 || result.Imported() != 0 {
+		t.Errorf("Expected instance to be skipped, got skipped=%v imported=%d", result.Skipped, result.Imported())
+	}
+}
+
+func TestQueryRange_Chunks(t *testing.T) {
+	var ranges [][2]int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var start, end int64
+		fmt.Sscan(r.URL.Query().Get("start"), &start)
+		fmt.Sscan(r.URL.Query().Get("end"), &end)
+		ranges = append(ranges, [2]int64{start, end})
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[%d,"1"]]}]}}`, start)
+	}))
+	defer srv.Close()
+
+	imp := NewPrometheusImporter(config.PrometheusImportConfig{Enabled: true, URL: srv.URL, Step: time.Minute}, nil)
+	to := time.Unix(1700000000, 0)
+	from := to.Add(-10 * 24 * time.Hour) // 14401 points at a 1m step
+
+	samples, err := imp.queryRange(context.Background(), "up", from, to)
+	if err != nil {
+		t.Fatalf("queryRange() error = %v", err)
+	}
+	if len(ranges) != 2 || len(samples) != 2 {
+		t.Fatalf("queries = %v, want 2 chunks", ranges)
+	}
+	for _, r := range ranges {
+		if points := (r[1]-r[0])/60 + 1; points > maxPointsPerQuery {
+			t.Errorf("chunk %v has %d points, over the Prometheus limit", r, points)
+		}
+	}
+	if ranges[0][0] != from.Unix() || ranges[1][0] != ranges[0][1]+60 || ranges[1][1] != to.Unix() {
+		t.Errorf("chunks %v don't cover %d..%d step by step", ranges, from.Unix(), to.Unix())
+	}
+}
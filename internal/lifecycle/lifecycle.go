@@ -0,0 +1,132 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Manager stops the registered components of the server on shutdown
+// Components are stopped in reverse order of registration, like deferred calls, so
+// registering each one right after creating it stops users before what they depend on
+type Manager struct {
+	mu         sync.Mutex
+	components []component
+	stopped    bool
+}
+
+type component struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// New creates an empty lifecycle manager
+func New() *Manager {
+	return &Manager{}
+}
+
+// Register adds a component whose stop function honors the shutdown deadline,
+// such as http.Server.Shutdown
+func (m *Manager) Register(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{name: name, stop: stop})
+}
+
+// RegisterFunc adds a component stopped by a plain Stop or Close method
+func (m *Manager) RegisterFunc(name string, stop func()) {
+	m.Register(name, func(context.Context) error {
+		stop()
+		return nil
+	})
+}
+
+// RegisterCloser adds a component stopped by a Close method that may fail
+func (m *Manager) RegisterCloser(name string, closeFn func() error) {
+	m.Register(name, func(context.Context) error {
+		return closeFn()
+	})
+}
+
+// Shutdown stops all components, returning the joined errors
+// A component still stopping when ctx is done is abandoned and the remaining ones are
+// only signalled to stop; use WithTimeout to keep time for the components after a slow one
+// Only the first call stops anything
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	components := m.components
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		start := time.Now()
+		if err := stopComponent(ctx, c); err != nil {
+			log.Printf("Shutdown: %s failed after %v: %v", c.name, time.Since(start).Round(time.Millisecond), err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		log.Printf("Shutdown: stopped %s in %v", c.name, time.Since(start).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
+
+// WithTimeout limits a stop function to d, so an HTTP drain for example can't use up
+// the time needed to flush pending writes and close the database
+func WithTimeout(d time.Duration, stop func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return stop(ctx)
+	}
+}
+
+// stopComponent runs the stop function, giving up waiting when ctx is done
+func stopComponent(ctx context.Context, c component) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- c.stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// Give a stop function that honors ctx the chance to report its own error
+		select {
+		case err := <-done:
+			return err
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// WaitForSignal blocks until SIGINT or SIGTERM is received or ctx is done,
+// then stops all components within timeout
+func (m *Manager) WaitForSignal(ctx context.Context, timeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop() // A second signal kills the process
+
+	log.Printf("Shutting down (timeout %v)", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return m.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,78 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManager_ShutdownOrder(t *testing.T) {
+	m := New()
+	var stopped []string
+	m.RegisterFunc("storage", func() { stopped = append(stopped, "storage") })
+	m.RegisterCloser("alerter", func() error {
+		stopped = append(stopped, "alerter")
+		return errors.New("boom")
+	})
+	m.Register("http", func(context.Context) error {
+		stopped = append(stopped, "http")
+		return nil
+	})
+
+	err := m.Shutdown(context.Background())
+	if err == nil || err.Error() != "alerter: boom" {
+		t.Errorf("Shutdown() error = %v, want alerter: boom", err)
+	}
+	// A failing component doesn't keep the rest running
+	if want := []string{"http", "alerter", "storage"}; !reflect.DeepEqual(stopped, want) {
+		t.Errorf("stopped = %v, want %v", stopped, want)
+	}
+
+	// Only the first shutdown stops anything
+	if err := m.Shutdown(context.Background()); err != nil || len(stopped) != 3 {
+		t.Errorf("second Shutdown() = %v, stopped %v", err, stopped)
+	}
+}
+
+func TestManager_ShutdownTimeout(t *testing.T) {
+	m := New()
+	closed := make(chan struct{})
+	m.RegisterFunc("storage", func() { close(closed) })
+	m.RegisterFunc("stuck", func() { select {} })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := m.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+
+	// Components after a stuck one are still told to stop
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("storage was not stopped after the stuck component")
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	m := New()
+	closed := false
+	m.RegisterFunc("storage", func() { closed = true })
+	m.Register("http", WithTimeout(20*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := m.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want the http drain to time out", err)
+	}
+	if !closed {
+		t.Error("storage was not stopped within the remaining time")
+	}
+}
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -14,11 +18,49 @@ type Alert struct {
 	RuleName     string     `json:"rule_name"`
 	Severity     string     `json:"severity"` // info, warning, critical
 	Message      string     `json:"message"`
-	Status       string     `json:"status"` // fired, resolved
+	Status       string     `json:"status"` // fired, resolved, suppressed
 	FiredAt      time.Time  `json:"fired_at"`
 	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
 	NotifiedAt   *time.Time `json:"notified_at,omitempty"`
 	Channels     string     `json:"channels"` // comma-separated channel names
+
+	// Acknowledged alerts stay active but no longer send repeat notifications
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+
+	// Flapping alerts fired or resolved while their rule kept changing state on the instance;
+	// their notifications are held back until the state settles
+	Flapping bool `json:"flapping"`
+
+	// Escalated alerts were raised from their original severity while active, as their rule's
+	// escalate_condition held
+	EscalatedFrom string     `json:"escalated_from,omitempty"`
+	EscalatedAt   *time.Time `json:"escalated_at,omitempty"`
+
+	// Snapshot holds the metric values the alert fired on; stored by SaveAlert and
+	// loaded separately by GetAlertSnapshot, as alert lists don't need it
+	Snapshot map[string]float64 `json:"-"`
+
+	// Links point to the dashboard views of the alert; set on alerts sent to the channels
+	// when alerting.external_url is configured
+	Links *AlertLinks `json:"-"`
+}
+
+// AlertEscalation records one severity change of an alert, an alert keeps one per step
+type AlertEscalation struct {
+	ID          int64     `json:"id"`
+	AlertID     int64     `json:"alert_id"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	EscalatedAt time.Time `json:"escalated_at"`
+}
+
+// AlertLinks are dashboard URLs for an alert
+type AlertLinks struct {
+	Target   string `json:"target"`   // Dashboard focused on the target
+	Instance string `json:"instance"` // Dashboard focused on the instance
+	Alert    string `json:"alert"`    // Dashboard with the alert opened
+	Report   string `json:"report"`   // HTML report of the target for the last 24h
 }
 
 // AlertStats contains alert statistics
@@ -29,6 +71,62 @@ type AlertStats struct {
 	BySeverity     map[string]int `json:"by_severity"`
 	ByTarget       map[string]int `json:"by_target"`
 	ByRule         map[string]int `json:"by_rule"`
+	FlappingAlerts int            `json:"flapping_alerts"` // Active alerts that are flapping
+}
+
+// AlertTimeseriesQuery selects the alerts aggregated by AlertTimeseries
+type AlertTimeseriesQuery struct {
+	From          time.Time
+	To            time.Time
+	TargetIn      []string // Only alerts of these targets; nil = all
+	Limit         int      // Maximum noisy rules and flapping alerts
+	FlapThreshold int      // Fires of the same rule on an instance that count as flapping
+}
+
+// AlertTimeseries holds alert trends and resolution times over a time range
+// Suppressed alerts are not counted
+type AlertTimeseries struct {
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	Days         []AlertDayCount `json:"days"`
+	MTTRSeconds  float64         `json:"mttr_seconds"` // Mean time to resolve of all resolved alerts
+	MTTRByRule   []AlertMTTR     `json:"mttr_by_rule"`
+	MTTRByTarget []AlertMTTR     `json:"mttr_by_target"`
+	NoisyRules   []NoisyRule     `json:"noisy_rules"`
+	Flapping     []FlappingAlert `json:"flapping"`
+}
+
+// AlertDayCount is the number of alerts fired and resolved on a day
+type AlertDayCount struct {
+	Date     string `json:"date"` // YYYY-MM-DD
+	Fired    int    `json:"fired"`
+	Resolved int    `json:"resolved"`
+}
+
+// AlertMTTR is the mean time to resolve of the alerts of a rule or target
+type AlertMTTR struct {
+	Name        string  `json:"name"`
+	Resolved    int     `json:"resolved"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	MaxSeconds  float64 `json:"max_seconds"`
+}
+
+// NoisyRule is a rule ranked by the number of alerts it fired
+type NoisyRule struct {
+	Rule      string `json:"rule"`
+	Fired     int    `json:"fired"`
+	Targets   int    `json:"targets"`   // Distinct targets alerted on
+	Instances int    `json:"instances"` // Distinct target instances alerted on
+}
+
+// FlappingAlert is a rule firing and resolving repeatedly on the same instance
+type FlappingAlert struct {
+	Rule                string    `json:"rule"`
+	TargetName          string    `json:"target_name"`
+	InstanceName        string    `json:"instance_name"`
+	Fires               int       `json:"fires"`
+	MeanDurationSeconds float64   `json:"mean_duration_seconds"` // Mean time until each fire resolved
+	LastFiredAt         time.Time `json:"last_fired_at"`
 }
 
 // Severity levels
@@ -42,27 +140,95 @@ const (
 const (
 	AlertStatusFired    = "fired"
 	AlertStatusResolved = "resolved"
+
+	// AlertStatusSuppressed marks an alert whose condition held during a maintenance window
+	// It is never notified; ResolvedAt is set once the condition clears or the alert fires after the window
+	AlertStatusSuppressed = "suppressed"
+)
+
+// Bulk alert actions
+const (
+	AlertActionResolve     = "resolve"
+	AlertActionAcknowledge = "acknowledge"
+	AlertActionDelete      = "delete"
+)
+
+// Alert sort fields
+const (
+	AlertSortFiredAt  = "fired_at"
+	AlertSortSeverity = "severity" // critical first when descending
+	AlertSortTarget   = "target_name"
+	AlertSortRule     = "rule_name"
 )
 
+// AlertQuery filters, sorts and pages alerts
+// Empty filters match all alerts
+type AlertQuery struct {
+	IDs        []int64 // Only these alerts
+	Status     string
+	TargetName string
+	TargetIn   []string // Only alerts of these targets; non-nil and empty matches nothing
+	RuleName   string
+	Flapping   bool // Only alerts marked flapping
+	Severities []string
+	From       time.Time // fired_at lower bound, zero = unbounded
+	To         time.Time // fired_at upper bound, zero = unbounded
+	SortBy     string    // one of the AlertSort constants, default fired_at
+	Ascending  bool
+	Limit      int
+	Offset     int
+	AfterID    int64 // Cursor: continue after this alert in sort order, replaces Offset
+}
+
 // AlertRule represents an alerting rule stored in DB
 type AlertRule struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Condition string    `json:"condition"` // e.g., "usage > 80", "pending > 5"
-	Severity  string    `json:"severity"`  // info, warning, critical
-	Message   string    `json:"message"`   // Template message
-	Enabled   bool      `json:"enabled"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                int64             `json:"id"`
+	Name              string            `json:"name"`
+	Condition         string            `json:"condition"`                    // e.g., "usage > 80", "pending > 5"
+	ResolveCondition  string            `json:"resolve_condition,omitempty"`  // Resolves alerts once it holds, e.g., "usage < 80" (empty = once the condition no longer holds)
+	EscalateCondition string            `json:"escalate_condition,omitempty"` // Raises active alerts to EscalateSeverity once it holds
+	EscalateSeverity  string            `json:"escalate_severity,omitempty"`  // Default: critical
+	RepeatInterval    string            `json:"repeat_interval,omitempty"`    // Re-notify interval, e.g., "30m" (empty = alerting.repeat_interval)
+	Severity          string            `json:"severity"`                     // info, warning, critical
+	Message           string            `json:"message"`                      // Template message
+	Enabled           bool              `json:"enabled"`
+	Channels          []string          `json:"channels,omitempty"` // Channels to notify (empty = all)
+	Project           string            `json:"project,omitempty"`  // Only evaluated for targets of this project (empty = all)
+	Labels            map[string]string `json:"labels,omitempty"`   // Only evaluated for targets with all of these labels (empty = all)
+	Targets           []string          `json:"targets,omitempty"`  // Only evaluated for these targets (empty = all)
+	Groups            []string          `json:"groups,omitempty"`   // Only evaluated for targets of these groups (empty = all)
+	Origin            string            `json:"origin"`             // api, or config for rules synced from the configuration file
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
 }
 
 // AlertRuleInput is used for creating/updating rules
 type AlertRuleInput struct {
-	Name      string `json:"name" binding:"required"`
-	Condition string `json:"condition" binding:"required"`
-	Severity  string `json:"severity" binding:"required"`
-	Message   string `json:"message"`
-	Enabled   *bool  `json:"enabled"`
+	Name              string            `json:"name" binding:"required"`
+	Condition         string            `json:"condition" binding:"required"`
+	ResolveCondition  string            `json:"resolve_condition"`
+	EscalateCondition string            `json:"escalate_condition"`
+	EscalateSeverity  string            `json:"escalate_severity"`
+	RepeatInterval    string            `json:"repeat_interval"`
+	Severity          string            `json:"severity" binding:"required"`
+	Message           string            `json:"message"`
+	Enabled           *bool             `json:"enabled"`
+	Channels          []string          `json:"channels"`
+	Project           string            `json:"project"`
+	Labels            map[string]string `json:"labels"`
+	Targets           []string          `json:"targets"`
+	Groups            []string          `json:"groups"`
+}
+
+// Origins of alert rules
+const (
+	RuleOriginAPI    = "api"    // Created through the API or an import
+	RuleOriginConfig = "config" // Synced from the configuration file, read-only through the API
+)
+
+// IsConfigManaged returns whether the rule is synced from the configuration file
+func (r *AlertRule) IsConfigManaged() bool {
+	return r.Origin == RuleOriginConfig
 }
 
 // IsEnabled returns whether the rule is enabled (defaults to true)
@@ -91,31 +257,52 @@ func (r *AlertRule) ToConfigRule() interface{} {
 // MaintenanceWindow represents a scheduled maintenance period
 // During a maintenance window, alerts are suppressed
 type MaintenanceWindow struct {
-	ID          int64      `json:"id"`
-	Name        string     `json:"name"`
-	Description string     `json:"description,omitempty"`
-	TargetName  string     `json:"target_name,omitempty"` // Empty means all targets
-	StartTime   time.Time  `json:"start_time"`
-	EndTime     time.Time  `json:"end_time"`
-	Recurring   bool       `json:"recurring"`           // If true, repeats weekly
-	DaysOfWeek  string     `json:"days_of_week,omitempty"` // Comma-separated days (0-6, 0=Sunday)
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description,omitempty"`
+	TargetName      string    `json:"target_name,omitempty"` // Target name or pattern; all targets when no target selector is set
+	Targets         []string  `json:"targets,omitempty"`     // More target names or patterns
+	Groups          []string  `json:"groups,omitempty"`      // Targets of these groups
+	Project         string    `json:"project,omitempty"`     // Only targets of this project (empty = all projects)
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	Recurring       bool      `json:"recurring"`                  // If true, repeats weekly
+	DaysOfWeek      string    `json:"days_of_week,omitempty"`     // Comma-separated days (0-6, 0=Sunday)
+	Cron            string    `json:"cron,omitempty"`             // Starts of a cron-scheduled window; start/end time bound when the schedule applies
+	DurationMinutes int       `json:"duration_minutes,omitempty"` // Length of each cron-scheduled window
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // MaintenanceWindowInput is used for creating/updating maintenance windows
 type MaintenanceWindowInput struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	TargetName  string `json:"target_name"`
-	StartTime   string `json:"start_time" binding:"required"` // RFC3339 format
-	EndTime     string `json:"end_time" binding:"required"`   // RFC3339 format
-	Recurring   bool   `json:"recurring"`
-	DaysOfWeek  string `json:"days_of_week"`
+	Name            string   `json:"name" binding:"required"`
+	Description     string   `json:"description"`
+	TargetName      string   `json:"target_name"`
+	Targets         []string `json:"targets"` // Names, globs ("orders-*") or regular expressions in slashes ("/^orders-[0-9]+$/")
+	Groups          []string `json:"groups"`
+	Project         string   `json:"project"`
+	StartTime       string   `json:"start_time"` // RFC3339 format; optional with cron
+	EndTime         string   `json:"end_time"`   // RFC3339 format; optional with cron
+	Recurring       bool     `json:"recurring"`
+	DaysOfWeek      string   `json:"days_of_week"`
+	Cron            string   `json:"cron"`             // e.g., "0 2 * * 0#1" for the first Sunday of each month at 02:00
+	DurationMinutes int      `json:"duration_minutes"` // Required with cron
+}
+
+// QuickMaintenanceInput silences a target from now on, for firefighting
+type QuickMaintenanceInput struct {
+	Target   string `json:"target" binding:"required"`
+	Duration string `json:"duration" binding:"required"` // Minutes, or a Go duration such as 30m or 2h
+	Reason   string `json:"reason"`
 }
 
 // IsActive checks if the maintenance window is currently active
+// Recurring and cron schedules are evaluated in the location of now, so pass the configured timezone
 func (m *MaintenanceWindow) IsActive(now time.Time) bool {
+	if m.Cron != "" {
+		return m.cronActive(now)
+	}
 	if m.Recurring {
 		// For recurring windows, check if current day matches and time is within range
 		currentDay := int(now.Weekday())
@@ -133,9 +320,10 @@ func (m *MaintenanceWindow) IsActive(now time.Time) bool {
 		}
 
 		// Check time range (using only hour:minute)
+		start, end := m.StartTime.In(now.Location()), m.EndTime.In(now.Location())
 		nowMinutes := now.Hour()*60 + now.Minute()
-		startMinutes := m.StartTime.Hour()*60 + m.StartTime.Minute()
-		endMinutes := m.EndTime.Hour()*60 + m.EndTime.Minute()
+		startMinutes := start.Hour()*60 + start.Minute()
+		endMinutes := end.Hour()*60 + end.Minute()
 
 		return nowMinutes >= startMinutes && nowMinutes <= endMinutes
 	}
@@ -144,9 +332,79 @@ func (m *MaintenanceWindow) IsActive(now time.Time) bool {
 	return now.After(m.StartTime) && now.Before(m.EndTime)
 }
 
-// MatchesTarget checks if this window applies to the given target
-func (m *MaintenanceWindow) MatchesTarget(targetName string) bool {
-	return m.TargetName == "" || m.TargetName == targetName
+// cronActive checks whether a cron-scheduled window started less than its duration ago
+// A set start or end time limits the period in which the schedule applies
+func (m *MaintenanceWindow) cronActive(now time.Time) bool {
+	if (!m.StartTime.IsZero() && now.Before(m.StartTime)) || (!m.EndTime.IsZero() && !now.Before(m.EndTime)) {
+		return false
+	}
+	schedule, err := parseCron(m.Cron)
+	if err != nil || m.DurationMinutes <= 0 {
+		return false
+	}
+	duration := time.Duration(m.DurationMinutes) * time.Minute
+	start, ok := schedule.lastRun(now, duration)
+	return ok && now.Before(start.Add(duration))
+}
+
+// MatchesTarget checks if this window applies to a target of the given group
+// A window without target_name, targets and groups applies to every target; otherwise any of them must match
+func (m *MaintenanceWindow) MatchesTarget(targetName, group string) bool {
+	if m.TargetName == "" && len(m.Targets) == 0 && len(m.Groups) == 0 {
+		return true
+	}
+	if m.TargetName != "" && MatchTargetPattern(m.TargetName, targetName) {
+		return true
+	}
+	for _, pattern := range m.Targets {
+		if MatchTargetPattern(pattern, targetName) {
+			return true
+		}
+	}
+	return group != "" && slices.Contains(m.Groups, group)
+}
+
+// IsTargetPattern returns whether a target selector is a glob or regular expression rather than a name
+func IsTargetPattern(pattern string) bool {
+	return isRegexPattern(pattern) || strings.ContainsAny(pattern, "*?[")
+}
+
+func isRegexPattern(pattern string) bool {
+	return len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/")
+}
+
+// ValidateTargetPattern checks a target name, glob or regular expression in slashes
+func ValidateTargetPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("target pattern must not be empty")
+	}
+	if strings.Contains(pattern, ",") {
+		return fmt.Errorf("target pattern '%s' must not contain commas", pattern)
+	}
+	if isRegexPattern(pattern) {
+		if _, err := regexp.Compile(pattern[1 : len(pattern)-1]); err != nil {
+			return fmt.Errorf("invalid regular expression '%s': %v", pattern, err)
+		}
+		return nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid glob pattern '%s'", pattern)
+	}
+	return nil
+}
+
+// MatchTargetPattern matches a target name against a name, glob or regular expression in slashes
+func MatchTargetPattern(pattern, targetName string) bool {
+	if isRegexPattern(pattern) {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		return err == nil && re.MatchString(targetName)
+	}
+	return pattern != "" && matchLabel(pattern, targetName)
+}
+
+// MatchesProject checks if this window applies to targets of the given project
+func (m *MaintenanceWindow) MatchesProject(project string) bool {
+	return m.Project == "" || m.Project == project
 }
 
 // parseDaysOfWeek parses a comma-separated string of day numbers
@@ -0,0 +1,47 @@
+package models
+
+import "testing"
+
+func TestMaintenanceWindow_MatchesTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		target string
+		group  string
+		want   bool
+	}{
+		{"no selector matches all", MaintenanceWindow{}, "orders", "", true},
+		{"exact name", MaintenanceWindow{TargetName: "orders"}, "orders", "", true},
+		{"other name", MaintenanceWindow{TargetName: "orders"}, "billing", "", false},
+		{"glob name", MaintenanceWindow{TargetName: "orders-*"}, "orders-api", "", true},
+		{"one of several targets", MaintenanceWindow{Targets: []string{"billing", "orders-*"}}, "orders-worker", "", true},
+		{"regular expression", MaintenanceWindow{Targets: []string{"/^orders-[0-9]+$/"}}, "orders-42", "", true},
+		{"regular expression mismatch", MaintenanceWindow{Targets: []string{"/^orders-[0-9]+$/"}}, "orders-api", "", false},
+		{"group", MaintenanceWindow{Groups: []string{"prod"}}, "orders", "prod", true},
+		{"other group", MaintenanceWindow{Groups: []string{"prod"}}, "orders", "dev", false},
+		{"target or group", MaintenanceWindow{TargetName: "billing", Groups: []string{"prod"}}, "billing", "dev", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.MatchesTarget(tt.target, tt.group); got != tt.want {
+				t.Errorf("MatchesTarget(%q, %q) = %v, want %v", tt.target, tt.group, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTargetPattern(t *testing.T) {
+	for _, p := range []string{"orders", "orders-*", "order?-[ab]", "/^orders-\\d+$/"} {
+		if err := ValidateTargetPattern(p); err != nil {
+			t.Errorf("ValidateTargetPattern(%q) error = %v", p, err)
+		}
+	}
+	for _, p := range []string{"", "orders,billing", "orders-[", "/orders-(/"} {
+		if err := ValidateTargetPattern(p); err == nil {
+			t.Errorf("ValidateTargetPattern(%q) = nil, want error", p)
+		}
+	}
+	if IsTargetPattern("orders") || !IsTargetPattern("orders-*") || !IsTargetPattern("/^o/") {
+		t.Error("IsTargetPattern should only report globs and regular expressions")
+	}
+}
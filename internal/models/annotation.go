@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Annotation is an operator note on the timeline, e.g., an incident or maintenance
+// An annotation without a target applies to all targets
+type Annotation struct {
+	ID         int64      `json:"id"`
+	TargetName string     `json:"target_name,omitempty"`
+	Time       time.Time  `json:"time"`
+	EndTime    *time.Time `json:"end_time,omitempty"` // Set for annotations spanning a period
+	Tags       []string   `json:"tags,omitempty"`
+	Text       string     `json:"text"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AnnotationInput is used for creating annotations
+type AnnotationInput struct {
+	TargetName string   `json:"target_name"`
+	Time       string   `json:"time"`     // RFC3339, defaults to now
+	EndTime    string   `json:"end_time"` // RFC3339, optional
+	Tags       []string `json:"tags"`
+	Text       string   `json:"text"`
+	CreatedBy  string   `json:"created_by"`
+}
+
+// HasTag checks if the annotation has the given tag
+func (a *Annotation) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry records a change made through the API
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Actor     string          `json:"actor"` // API key name, or the client IP without authentication
+	IP        string          `json:"ip"`
+	Action    string          `json:"action"` // e.g., target.update, rule.delete
+	Method    string          `json:"method"`
+	Path      string          `json:"path"`
+	Resource  string          `json:"resource,omitempty"` // Name or ID from the path
+	Status    int             `json:"status"`
+	Before    json.RawMessage `json:"before,omitempty"` // State before the change, secrets masked
+	After     json.RawMessage `json:"after,omitempty"`  // State after the change, secrets masked
+}
+
+// AuditQuery filters and pages audit entries, newest first
+// Empty filters match all entries
+type AuditQuery struct {
+	Actor    string
+	Action   string // Exact action, or a prefix ending in "." such as "rule."
+	Resource string
+	From     time.Time // zero = unbounded
+	To       time.Time // zero = unbounded
+	Limit    int       // <= 0 = no limit
+	Offset   int
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Health statuses of the actuator health endpoint
+// Applications may report custom statuses as well
+const (
+	HealthUp           = "UP"
+	HealthDown         = "DOWN"
+	HealthOutOfService = "OUT_OF_SERVICE"
+	HealthUnknown      = "UNKNOWN"
+)
+
+// HealthCheck is the health endpoint status of an instance at one scrape
+type HealthCheck struct {
+	TargetName   string    `json:"target_name"`
+	InstanceName string    `json:"instance_name"`
+	Status       string    `json:"status"`
+	Timestamp    time.Time `json:"timestamp"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ClusterNode is a pondy replica taking part in sharded collection
+type ClusterNode struct {
+	ID        string    `json:"id"`
+	Address   string    `json:"address,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	LastSeen  time.Time `json:"last_seen"`
+}
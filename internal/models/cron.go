@@ -0,0 +1,206 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed five-field cron expression: minute hour day-of-month month day-of-week
+// Day-of-week accepts "n#k" for the k-th weekday n of the month, e.g. "0#1" for the first Sunday.
+// As in standard cron, when both day fields are restricted a day matching either one matches.
+type cronSchedule struct {
+	minutes, hours, days, months uint64
+	weekdays                     []cronWeekday
+	anyDay, anyWeekday           bool
+}
+
+type cronWeekday struct {
+	day int // 0-6, 0=Sunday
+	nth int // 1-5 for the n-th weekday of the month, 0 for every week
+}
+
+var cronMacros = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+// ValidateCron checks a cron expression used as a maintenance window schedule
+func ValidateCron(expr string) error {
+	_, err := parseCron(expr)
+	return err
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	s := &cronSchedule{anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	var err error
+	if s.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.days, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day-of-month: %w", err)
+	}
+	if s.months, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.weekdays, err = parseCronWeekdays(fields[4]); err != nil {
+		return nil, fmt.Errorf("day-of-week: %w", err)
+	}
+	return s, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps into a bit set
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		lo, hi, step, err := parseCronRange(part, min, max, names)
+		if err != nil {
+			return 0, err
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronRange parses "*", "n", "a-b" with an optional "/step"
+func parseCronRange(part string, min, max int, names map[string]int) (lo, hi, step int, err error) {
+	step = 1
+	if base, s, ok := strings.Cut(part, "/"); ok {
+		if step, err = strconv.Atoi(s); err != nil || step <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid step in '%s'", part)
+		}
+		part = base
+	}
+
+	switch {
+	case part == "*":
+		return min, max, step, nil
+	case strings.Contains(part, "-"):
+		a, b, _ := strings.Cut(part, "-")
+		if lo, err = parseCronValue(a, min, max, names); err != nil {
+			return 0, 0, 0, err
+		}
+		if hi, err = parseCronValue(b, min, max, names); err != nil {
+			return 0, 0, 0, err
+		}
+		if lo > hi {
+			return 0, 0, 0, fmt.Errorf("invalid range '%s'", part)
+		}
+		return lo, hi, step, nil
+	default:
+		if lo, err = parseCronValue(part, min, max, names); err != nil {
+			return 0, 0, 0, err
+		}
+		// "n/step" runs from n to the end of the range
+		if step > 1 {
+			return lo, max, step, nil
+		}
+		return lo, lo, step, nil
+	}
+}
+
+func parseCronValue(s string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("'%s' is not between %d and %d", s, min, max)
+	}
+	return v, nil
+}
+
+// parseCronWeekdays parses the day-of-week field, where 7 is also Sunday and "n#k" selects the k-th weekday n
+func parseCronWeekdays(field string) ([]cronWeekday, error) {
+	var weekdays []cronWeekday
+	for _, part := range strings.Split(field, ",") {
+		if day, n, ok := strings.Cut(part, "#"); ok {
+			d, err := parseCronValue(day, 0, 7, cronDayNames)
+			if err != nil {
+				return nil, err
+			}
+			nth, err := strconv.Atoi(n)
+			if err != nil || nth < 1 || nth > 5 {
+				return nil, fmt.Errorf("'%s': occurrence must be between 1 and 5", part)
+			}
+			weekdays = append(weekdays, cronWeekday{day: d % 7, nth: nth})
+			continue
+		}
+
+		lo, hi, step, err := parseCronRange(part, 0, 7, cronDayNames)
+		if err != nil {
+			return nil, err
+		}
+		for d := lo; d <= hi; d += step {
+			weekdays = append(weekdays, cronWeekday{day: d % 7})
+		}
+	}
+	return weekdays, nil
+}
+
+// matchesDay reports whether the schedule runs on the day of t
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	if s.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dayMatches := s.days&(1<<uint(t.Day())) != 0
+	weekdayMatches := false
+	for _, w := range s.weekdays {
+		if w.day == int(t.Weekday()) && (w.nth == 0 || (t.Day()-1)/7+1 == w.nth) {
+			weekdayMatches = true
+			break
+		}
+	}
+
+	if s.anyDay || s.anyWeekday {
+		return dayMatches && weekdayMatches
+	}
+	return dayMatches || weekdayMatches
+}
+
+// lastRun returns the latest time the schedule fired at or before now, looking back at most limit
+// Runs are evaluated in the location of now
+func (s *cronSchedule) lastRun(now time.Time, limit time.Duration) (time.Time, bool) {
+	earliest := now.Add(-limit)
+	t := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
+	for !t.Before(earliest) {
+		switch {
+		case !s.matchesDay(t):
+			// Last minute of the previous day
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			// Last minute of the previous hour
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()).Add(-time.Minute)
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateCron(t *testing.T) {
+	valid := []string{"0 2 * * 0#1", "*/15 9-17 * * mon-fri", "30 3 1,15 * *", "0 0 * jan,jul 7", "@daily"}
+	for _, expr := range valid {
+		if err := ValidateCron(expr); err != nil {
+			t.Errorf("ValidateCron(%q) error = %v", expr, err)
+		}
+	}
+
+	invalid := []string{"", "0 2 * *", "60 * * * *", "0 24 * * *", "0 2 * * 0#6", "0 2 5-1 * *", "0 2 * * */0"}
+	for _, expr := range invalid {
+		if err := ValidateCron(expr); err == nil {
+			t.Errorf("ValidateCron(%q) = nil, want error", expr)
+		}
+	}
+}
+
+func TestMaintenanceWindow_CronActive(t *testing.T) {
+	seoul := time.FixedZone("KST", 9*60*60)
+	// 02:00-04:00 on the first Sunday of each month, in the configured timezone
+	w := MaintenanceWindow{Cron: "0 2 * * 0#1", DurationMinutes: 120}
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{time.Date(2024, 3, 3, 2, 0, 0, 0, seoul), true},    // First Sunday of March
+		{time.Date(2024, 3, 3, 3, 59, 0, 0, seoul), true},   // Last minute
+		{time.Date(2024, 3, 3, 4, 0, 0, 0, seoul), false},   // Ended
+		{time.Date(2024, 3, 3, 1, 59, 0, 0, seoul), false},  // Not started
+		{time.Date(2024, 3, 10, 2, 30, 0, 0, seoul), false}, // Second Sunday
+		{time.Date(2024, 3, 2, 17, 30, 0, 0, time.UTC).In(seoul), true},
+	}
+	for _, tt := range tests {
+		if got := w.IsActive(tt.now); got != tt.want {
+			t.Errorf("IsActive(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+
+	// Windows running past midnight
+	nightly := MaintenanceWindow{Cron: "30 23 * * *", DurationMinutes: 60}
+	if !nightly.IsActive(time.Date(2024, 3, 4, 0, 15, 0, 0, seoul)) {
+		t.Error("nightly window should still be active after midnight")
+	}
+
+	// Start and end time bound when the schedule applies
+	bounded := MaintenanceWindow{Cron: "0 2 * * *", DurationMinutes: 60, EndTime: time.Date(2024, 3, 1, 0, 0, 0, 0, seoul)}
+	if bounded.IsActive(time.Date(2024, 3, 3, 2, 30, 0, 0, seoul)) {
+		t.Error("cron window should not apply after its end_time")
+	}
+}
+
+func TestMaintenanceWindow_RecurringTimezone(t *testing.T) {
+	seoul := time.FixedZone("KST", 9*60*60)
+	// 02:00-04:00 KST stored as UTC, as read back from the database
+	w := MaintenanceWindow{
+		Recurring:  true,
+		DaysOfWeek: "0",
+		StartTime:  time.Date(2024, 1, 6, 17, 0, 0, 0, time.UTC),
+		EndTime:    time.Date(2024, 1, 6, 19, 0, 0, 0, time.UTC),
+	}
+	if !w.IsActive(time.Date(2024, 3, 3, 3, 0, 0, 0, seoul)) {
+		t.Error("recurring window should follow the time of day in the configured timezone")
+	}
+}
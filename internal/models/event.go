@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Event types
+const (
+	EventTypeDeploy   = "deploy"
+	EventTypeRollback = "rollback"
+	EventTypeConfig   = "config"
+)
+
+// Event marks a change to a target, e.g., a deployment, that metrics can be compared around
+type Event struct {
+	ID          int64     `json:"id"`
+	TargetName  string    `json:"target_name"`
+	Type        string    `json:"type"`
+	Version     string    `json:"version,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// EventInput is used for creating events
+type EventInput struct {
+	Type        string `json:"type"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Timestamp   string `json:"timestamp"` // RFC3339, defaults to now
+}
+
+// IsValidEventType checks if the event type is known
+func IsValidEventType(t string) bool {
+	return t == EventTypeDeploy || t == EventTypeRollback || t == EventTypeConfig
+}
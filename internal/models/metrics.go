@@ -24,6 +24,16 @@ type PoolMetrics struct {
 	Timeout    int64   `json:"timeout"`
 	AcquireP99 float64 `json:"acquire_p99"`
 
+	// Connection acquire time percentiles and maximum, in milliseconds
+	AcquireP50 float64 `json:"acquire_p50"`
+	AcquireP95 float64 `json:"acquire_p95"`
+	AcquireMax float64 `json:"acquire_max"`
+
+	// TimeoutDelta is the number of timeouts since the previous sample of the instance
+	// and TimeoutRate the same per minute; Timeout is the cumulative counter
+	TimeoutDelta int64   `json:"timeout_delta"`
+	TimeoutRate  float64 `json:"timeout_rate"`
+
 	// JVM metrics
 	HeapUsed    int64   `json:"heap_used"`     // bytes
 	HeapMax     int64   `json:"heap_max"`      // bytes
@@ -38,27 +48,157 @@ type PoolMetrics struct {
 	YoungGcCount int64  `json:"young_gc_count"` // young gen GC count
 	OldGcCount   int64  `json:"old_gc_count"`   // old gen GC count
 
+	// GC activity since the previous sample of the instance
+	GcCountDelta      int64   `json:"gc_count_delta"`
+	GcTimeDelta       float64 `json:"gc_time_delta"` // seconds
+	YoungGcCountDelta int64   `json:"young_gc_count_delta"`
+	OldGcCountDelta   int64   `json:"old_gc_count_delta"`
+	GcPausesPerMin    float64 `json:"gc_pauses_per_min"`
+	GcPauseMsPerMin   float64 `json:"gc_pause_ms_per_min"` // GC time per minute
+	AvgGcPauseMs      float64 `json:"avg_gc_pause_ms"`     // Average pause, computed from the deltas
+
+	// Derived holds user-defined derived metrics by name
+	Derived map[string]float64 `json:"derived,omitempty"`
+
+	// Samples, MinValues and MaxValues describe a point aggregated from several samples
+	// (not persisted): the sample count and the extremes of fields by JSON name, with the
+	// pool usage in % as "usage"
+	Samples   int                `json:"samples,omitempty"`
+	MinValues map[string]float64 `json:"min_values,omitempty"`
+	MaxValues map[string]float64 `json:"max_values,omitempty"`
+
+	// ScrapeFailures is the number of consecutive failed scrapes (not persisted)
+	ScrapeFailures int `json:"scrape_failures,omitempty"`
+
+	// Health is the health endpoint status at the scrape, e.g., UP or DOWN, for collectors
+	// that check it; stored separately as a HealthCheck
+	Health string `json:"health,omitempty"`
+
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// SetCounterDeltas sets the timeout and GC deltas and rates from the previous sample of
+// the same instance. A counter lower than before means the instance restarted, so the
+// delta counts from zero
+func (m *PoolMetrics) SetCounterDeltas(prev *PoolMetrics) {
+	m.TimeoutDelta, m.TimeoutRate = 0, 0
+	m.GcCountDelta, m.GcTimeDelta, m.YoungGcCountDelta, m.OldGcCountDelta = 0, 0, 0, 0
+	m.GcPausesPerMin, m.GcPauseMsPerMin, m.AvgGcPauseMs = 0, 0, 0
+	if prev == nil || !prev.Timestamp.Before(m.Timestamp) {
+		return
+	}
+
+	minutes := m.Timestamp.Sub(prev.Timestamp).Minutes()
+	m.TimeoutDelta = counterDelta(prev.Timeout, m.Timeout)
+	m.TimeoutRate = float64(m.TimeoutDelta) / minutes
+
+	m.GcCountDelta = counterDelta(prev.GcCount, m.GcCount)
+	m.GcTimeDelta = counterDelta(prev.GcTime, m.GcTime)
+	m.YoungGcCountDelta = counterDelta(prev.YoungGcCount, m.YoungGcCount)
+	m.OldGcCountDelta = counterDelta(prev.OldGcCount, m.OldGcCount)
+	m.GcPausesPerMin = float64(m.GcCountDelta) / minutes
+	m.GcPauseMsPerMin = m.GcTimeDelta * 1000 / minutes
+	m.SetAvgGcPause()
+}
+
+// SetAvgGcPause computes AvgGcPauseMs from the GC deltas, e.g., after summing them over a rollup bucket
+func (m *PoolMetrics) SetAvgGcPause() {
+	m.AvgGcPauseMs = 0
+	if m.GcCountDelta > 0 {
+		m.AvgGcPauseMs = m.GcTimeDelta * 1000 / float64(m.GcCountDelta)
+	}
+}
+
+// counterDelta returns the increase of a cumulative counter, treating a drop as a restart
+func counterDelta[T int64 | float64](prev, cur T) T {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
 // TargetStatus represents current status of a monitoring target
 type TargetStatus struct {
-	Name      string           `json:"name"`
-	Group     string           `json:"group,omitempty"` // Environment group: dev, staging, prod, etc.
-	Status    string           `json:"status"`          // healthy, unhealthy, unknown
-	Current   *PoolMetrics     `json:"current,omitempty"`
-	Instances []InstanceStatus `json:"instances,omitempty"`
+	Name      string            `json:"name"`
+	Group     string            `json:"group,omitempty"` // Environment group: dev, staging, prod, etc.
+	Project   string            `json:"project,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Status    string            `json:"status"` // healthy, unhealthy, unknown
+	Current   *PoolMetrics      `json:"current,omitempty"`
+	Instances []InstanceStatus  `json:"instances,omitempty"`
+
+	Paused      bool   `json:"paused,omitempty"` // Collection and alerts stopped; Status is paused
+	PauseReason string `json:"pause_reason,omitempty"`
 }
 
+// TargetStatusPaused is the status of a paused target
+const TargetStatusPaused = "paused"
+
 // InstanceStatus represents current status of an instance
 type InstanceStatus struct {
-	InstanceName string       `json:"instance_name"`
-	Status       string       `json:"status"`
-	Current      *PoolMetrics `json:"current,omitempty"`
+	InstanceName string           `json:"instance_name"`
+	Status       string           `json:"status"`
+	Current      *PoolMetrics     `json:"current,omitempty"`
+	Collector    *CollectorHealth `json:"collector,omitempty"` // Set while the circuit breaker is open
 }
 
 // HistoryResponse represents historical metrics data
 type HistoryResponse struct {
 	TargetName string        `json:"target_name"`
 	Datapoints []PoolMetrics `json:"datapoints"`
+	Events      []Event      `json:"events,omitempty"`      // Deployments and other events within the range
+	Annotations []Annotation `json:"annotations,omitempty"` // Only with annotations=true
+	Bands       []HistoryBand `json:"bands,omitempty"`      // One per datapoint, only for downsampled history
+}
+
+// Band is the range of a field within a downsampled bucket
+type Band struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+}
+
+// HistoryBand keeps the extremes averaging hides, e.g., a short saturation spike,
+// so charts can draw a band around the averaged line
+type HistoryBand struct {
+	Timestamp time.Time `json:"timestamp"`
+	Active    Band      `json:"active"`
+	Pending   Band      `json:"pending"`
+	Usage     Band      `json:"usage"` // % of max
+}
+
+// Collector scrape states
+const (
+	CollectorPending = "pending" // No scrape finished yet
+	CollectorUp      = "up"      // Last scrape succeeded
+	CollectorFailing = "failing" // Last scrape failed
+	CollectorDown    = "down"    // Circuit breaker open, scraping backed off
+)
+
+// CollectorHealth represents the scrape health of a single collector
+type CollectorHealth struct {
+	TargetName          string     `json:"target_name"`
+	InstanceName        string     `json:"instance_name"`
+	Type                string     `json:"type"`
+	Endpoint            string     `json:"endpoint"`
+	Interval            string     `json:"interval"`
+	State               string     `json:"state"` // pending, up, failing, down
+	LastScrape          *time.Time `json:"last_scrape,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastDurationMs      float64    `json:"last_duration_ms"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	TotalScrapes        int64      `json:"total_scrapes"`
+	TotalFailures       int64      `json:"total_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	BreakerOpen         bool       `json:"breaker_open"`
+	NextScrape          *time.Time `json:"next_scrape,omitempty"`
+}
+
+// SchedulerStats represents the limits and load of the global scrape scheduler
+type SchedulerStats struct {
+	MaxConcurrent     int   `json:"max_concurrent"`
+	RequestsPerScrape int   `json:"requests_per_scrape"`
+	Running           int   `json:"running"` // Scrapes in progress
+	Waiting           int   `json:"waiting"` // Scrapes waiting for a free slot
+	Delayed           int64 `json:"delayed"` // Scrapes that had to wait since start
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
 	"time"
 )
@@ -139,3 +140,52 @@ func TestHistoryResponse_JSON(t *testing.T) {
 		t.Errorf("expected 3 datapoints, got %d", len(unmarshaled.Datapoints))
 	}
 }
+
+func TestPoolMetrics_SetCounterDeltas(t *testing.T) {
+	now := time.Now()
+	prev := &PoolMetrics{Timeout: 40, Timestamp: now.Add(-30 * time.Second)}
+
+	tests := []struct {
+		name      string
+		timeout   int64
+		prev      *PoolMetrics
+		wantDelta int64
+		wantRate  float64
+	}{
+		{"increase", 43, prev, 3, 6},
+		{"counter reset", 2, prev, 2, 4},
+		{"first sample", 43, nil, 0, 0},
+		{"late sample", 43, &PoolMetrics{Timeout: 40, Timestamp: now.Add(time.Second)}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &PoolMetrics{Timeout: tt.timeout, Timestamp: now}
+			m.SetCounterDeltas(tt.prev)
+			if m.TimeoutDelta != tt.wantDelta || m.TimeoutRate != tt.wantRate {
+				t.Errorf("SetCounterDeltas() = %d, %v; want %d, %v", m.TimeoutDelta, m.TimeoutRate, tt.wantDelta, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestPoolMetrics_SetCounterDeltas_GC(t *testing.T) {
+	now := time.Now()
+	prev := &PoolMetrics{GcCount: 1000, GcTime: 50, YoungGcCount: 990, OldGcCount: 10, Timestamp: now.Add(-2 * time.Minute)}
+
+	m := &PoolMetrics{GcCount: 1010, GcTime: 50.4, YoungGcCount: 999, OldGcCount: 11, Timestamp: now}
+	m.SetCounterDeltas(prev)
+	if m.GcCountDelta != 10 || m.YoungGcCountDelta != 9 || m.OldGcCountDelta != 1 {
+		t.Errorf("GC count deltas = %d / %d / %d, want 10 / 9 / 1", m.GcCountDelta, m.YoungGcCountDelta, m.OldGcCountDelta)
+	}
+	if math.Abs(m.GcPauseMsPerMin-200) > 1e-6 || m.GcPausesPerMin != 5 || math.Abs(m.AvgGcPauseMs-40) > 1e-6 {
+		t.Errorf("GC rates = %v ms/min, %v pauses/min, %v ms avg; want 200, 5, 40", m.GcPauseMsPerMin, m.GcPausesPerMin, m.AvgGcPauseMs)
+	}
+
+	// After a restart the counters start over
+	restarted := &PoolMetrics{GcCount: 4, GcTime: 0.2, Timestamp: now.Add(time.Minute)}
+	restarted.SetCounterDeltas(m)
+	if restarted.GcCountDelta != 4 || restarted.GcTimeDelta != 0.2 || restarted.AvgGcPauseMs != 50 {
+		t.Errorf("after restart = %d pauses, %vs, %v ms avg; want 4, 0.2, 50", restarted.GcCountDelta, restarted.GcTimeDelta, restarted.AvgGcPauseMs)
+	}
+}
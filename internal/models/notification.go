@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Notification delivery events
+const (
+	DeliveryEventFired     = "fired"
+	DeliveryEventResolved  = "resolved"
+	DeliveryEventRepeat    = "repeat"
+	DeliveryEventEscalated = "escalated"
+	DeliveryEventDigest    = "digest"
+	DeliveryEventTest      = "test"
+)
+
+// Notification delivery status
+const (
+	DeliveryStatusSuccess = "success"
+	DeliveryStatusFailed  = "failed"
+)
+
+// NotificationDelivery records one attempt to deliver an alert to a channel
+type NotificationDelivery struct {
+	ID         int64     `json:"id"`
+	AlertID    int64     `json:"alert_id"` // 0 for test alerts
+	Channel    string    `json:"channel"`
+	Event      string    `json:"event"`  // fired, resolved, repeat, escalated, digest, test
+	Status     string    `json:"status"` // success, failed
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  int64     `json:"latency_ms"`
+	Retries    int       `json:"retries"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// Recommendation lifecycle statuses
+const (
+	RecommendationOpen      = "open"
+	RecommendationApplied   = "applied"
+	RecommendationDismissed = "dismissed"
+	RecommendationSnoozed   = "snoozed"
+)
+
+// IsValidRecommendationStatus checks if the recommendation status is known
+func IsValidRecommendationStatus(s string) bool {
+	return s == RecommendationOpen || s == RecommendationApplied || s == RecommendationDismissed || s == RecommendationSnoozed
+}
+
+// RecommendationRecord tracks a recommendation of a target across analyses
+// There is one record per target and recommendation type; the advice fields hold the latest analysis
+type RecommendationRecord struct {
+	ID          int64      `json:"id"`
+	TargetName  string     `json:"target_name"`
+	Type        string     `json:"type"`
+	Current     string     `json:"current"`
+	Recommended string     `json:"recommended"`
+	Reason      string     `json:"reason"`
+	Severity    string     `json:"severity"`
+	Status      string     `json:"status"`
+	Note        string     `json:"note,omitempty"`
+	SnoozeUntil *time.Time `json:"snooze_until,omitempty"`
+
+	// StatusCurrent and StatusSeverity are the advice when the status was set,
+	// used to re-raise it once the pool or the severity changes
+	StatusCurrent  string `json:"status_current,omitempty"`
+	StatusSeverity string `json:"status_severity,omitempty"`
+
+	FirstSeenAt     time.Time  `json:"first_seen_at"`
+	LastSeenAt      time.Time  `json:"last_seen_at"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+}
+
+// RecommendationStatusInput is used for changing the status of a recommendation
+type RecommendationStatusInput struct {
+	Status   string `json:"status"`   // open, applied, dismissed or snoozed
+	Note     string `json:"note"`     // e.g., why it was dismissed
+	Duration string `json:"duration"` // Snooze length, e.g., "72h" (default: 24h)
+}
+
+// ShouldReraise reports whether an acted-on recommendation should be shown again
+// given the latest advice. A snooze ends at its deadline, a dismissal when the severity
+// rises, and an applied recommendation when the current value changed but the advice stands.
+func (r *RecommendationRecord) ShouldReraise(current, severity string, now time.Time) bool {
+	switch r.Status {
+	case RecommendationSnoozed:
+		return r.SnoozeUntil == nil || !now.Before(*r.SnoozeUntil)
+	case RecommendationDismissed:
+		return SeverityRank(severity) > SeverityRank(r.StatusSeverity)
+	case RecommendationApplied:
+		return current != r.StatusCurrent
+	default:
+		return false
+	}
+}
+
+// SetStatus changes the status, remembering the advice it was set for
+func (r *RecommendationRecord) SetStatus(status, note string, snoozeUntil *time.Time, now time.Time) {
+	r.Status = status
+	r.Note = note
+	r.SnoozeUntil = nil
+	if status == RecommendationSnoozed {
+		r.SnoozeUntil = snoozeUntil
+	}
+	r.StatusCurrent = r.Current
+	r.StatusSeverity = r.Severity
+	r.StatusChangedAt = &now
+}
+
+// SeverityRank orders severities, higher is more severe
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
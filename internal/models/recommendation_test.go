@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecommendationRecord_ShouldReraise(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		status   string
+		snooze   *time.Time
+		current  string
+		severity string
+		want     bool
+	}{
+		{"open", RecommendationOpen, nil, "20", SeverityWarning, false},
+		{"snoozed", RecommendationSnoozed, &later, "20", SeverityCritical, false},
+		{"snooze ended", RecommendationSnoozed, &now, "20", SeverityWarning, true},
+		{"dismissed", RecommendationDismissed, nil, "25", SeverityWarning, false},
+		{"dismissed, severity rose", RecommendationDismissed, nil, "20", SeverityCritical, true},
+		{"applied", RecommendationApplied, nil, "20", SeverityCritical, false},
+		{"applied, pool changed", RecommendationApplied, nil, "30", SeverityWarning, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &RecommendationRecord{Current: "20", Severity: SeverityWarning}
+			r.SetStatus(tt.status, "", tt.snooze, now)
+			if got := r.ShouldReraise(tt.current, tt.severity, now); got != tt.want {
+				t.Errorf("ShouldReraise(%q, %q) = %v, want %v", tt.current, tt.severity, got, tt.want)
+			}
+		})
+	}
+}
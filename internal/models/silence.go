@@ -0,0 +1,67 @@
+package models
+
+import (
+	"path"
+	"time"
+)
+
+// Silence suppresses alerts matching all of its non-empty matchers until it expires
+// Matchers support glob patterns (e.g., "order-*")
+type Silence struct {
+	ID           int64     `json:"id"`
+	TargetName   string    `json:"target_name,omitempty"`
+	InstanceName string    `json:"instance_name,omitempty"`
+	RuleName     string    `json:"rule_name,omitempty"`
+	Severity     string    `json:"severity,omitempty"`
+	Comment      string    `json:"comment,omitempty"`
+	CreatedBy    string    `json:"created_by,omitempty"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SilenceInput is used for creating/updating silences
+type SilenceInput struct {
+	TargetName   string `json:"target_name"`
+	InstanceName string `json:"instance_name"`
+	RuleName     string `json:"rule_name"`
+	Severity     string `json:"severity"`
+	Comment      string `json:"comment"`
+	CreatedBy    string `json:"created_by"`
+	StartsAt     string `json:"starts_at"` // RFC3339, defaults to now
+	EndsAt       string `json:"ends_at"`   // RFC3339
+	Duration     string `json:"duration"`  // Alternative to ends_at, e.g., "2h"
+}
+
+// HasMatchers returns whether at least one matcher is set
+func (s *Silence) HasMatchers() bool {
+	return s.TargetName != "" || s.InstanceName != "" || s.RuleName != "" || s.Severity != ""
+}
+
+// IsActive checks if the silence is in effect at the given time
+func (s *Silence) IsActive(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// IsExpired checks if the silence has ended
+func (s *Silence) IsExpired(now time.Time) bool {
+	return !now.Before(s.EndsAt)
+}
+
+// Matches checks if the silence applies to the given alert labels
+func (s *Silence) Matches(targetName, instanceName, ruleName, severity string) bool {
+	return matchLabel(s.TargetName, targetName) &&
+		matchLabel(s.InstanceName, instanceName) &&
+		matchLabel(s.RuleName, ruleName) &&
+		matchLabel(s.Severity, severity)
+}
+
+// matchLabel matches a value against a pattern; an empty pattern matches anything
+func matchLabel(pattern, value string) bool {
+	if pattern == "" || pattern == value {
+		return true
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// StorageStats describes database size and contents
+type StorageStats struct {
+	DBSize    int64                `json:"db_size"`    // bytes
+	WALSize   int64                `json:"wal_size"`   // bytes
+	FreeBytes int64                `json:"free_bytes"` // unused pages reclaimable by vacuum
+	Tables    map[string]int64     `json:"tables"`     // row count per table
+	Targets   []TargetStorageStats `json:"targets"`
+}
+
+// TargetStorageStats describes the raw metrics stored for a target
+type TargetStorageStats struct {
+	TargetName string     `json:"target_name"`
+	Rows       int64      `json:"rows"`
+	Oldest     *time.Time `json:"oldest,omitempty"`
+	Newest     *time.Time `json:"newest,omitempty"`
+}
+
+// InactiveInstance is an instance that stopped reporting, e.g., after a scale-down
+// Its history is kept until purged
+type InactiveInstance struct {
+	TargetName   string    `json:"target_name"`
+	InstanceName string    `json:"instance_name"`
+	LastSeen     time.Time `json:"last_seen"`
+	MarkedAt     time.Time `json:"marked_at"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// View panels, each a chart of one group of metrics
+const (
+	ViewPanelPoolUsage = "pool_usage" // active, idle, pending and max connections
+	ViewPanelAcquire   = "acquire"    // connection acquire time percentiles
+	ViewPanelHeap      = "heap"       // heap and non-heap memory
+	ViewPanelGC        = "gc"         // GC pauses per minute and pause time
+	ViewPanelCPU       = "cpu"
+	ViewPanelThreads   = "threads"
+)
+
+// ViewPanels lists the valid view panels
+var ViewPanels = []string{ViewPanelPoolUsage, ViewPanelAcquire, ViewPanelHeap, ViewPanelGC, ViewPanelCPU, ViewPanelThreads}
+
+// View is a saved dashboard of selected targets and metric panels
+// It is also the scope of combined reports requested with ?view=
+type View struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Targets     []ViewTarget `json:"targets"`
+	Panels      []string     `json:"panels"`
+	Range       string       `json:"range,omitempty"`   // Default time range when rendering, e.g., "24h"
+	Project     string       `json:"project,omitempty"` // Owning project (empty = all)
+	CreatedBy   string       `json:"created_by,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// ViewTarget selects a target, or only some of its instances
+type ViewTarget struct {
+	Target    string   `json:"target"`
+	Instances []string `json:"instances,omitempty"` // Empty = all instances
+}
+
+// ViewInput is used for creating/updating views
+type ViewInput struct {
+	Name        string       `json:"name" binding:"required"`
+	Description string       `json:"description"`
+	Targets     []ViewTarget `json:"targets"`
+	Panels      []string     `json:"panels"`
+	Range       string       `json:"range"`
+	Project     string       `json:"project"`
+}
+
+// Validate checks the targets and panels of a view input
+func (in *ViewInput) Validate() error {
+	if strings.TrimSpace(in.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(in.Targets) == 0 {
+		return fmt.Errorf("at least one target is required")
+	}
+	seen := make(map[string]bool, len(in.Targets))
+	for _, t := range in.Targets {
+		if t.Target == "" {
+			return fmt.Errorf("target name is required")
+		}
+		if seen[t.Target] {
+			return fmt.Errorf("duplicate target '%s'", t.Target)
+		}
+		seen[t.Target] = true
+	}
+	if len(in.Panels) == 0 {
+		return fmt.Errorf("at least one panel is required")
+	}
+	for _, p := range in.Panels {
+		if !slices.Contains(ViewPanels, p) {
+			return fmt.Errorf("invalid panel '%s': use %s", p, strings.Join(ViewPanels, ", "))
+		}
+	}
+	return nil
+}
+
+// TargetNames returns the names of the view's targets
+func (v *View) TargetNames() []string {
+	names := make([]string, len(v.Targets))
+	for i, t := range v.Targets {
+		names[i] = t.Target
+	}
+	return names
+}
+
+// Target returns the view's selection of a target, or nil; safe on a nil View
+func (v *View) Target(name string) *ViewTarget {
+	if v == nil {
+		return nil
+	}
+	for i := range v.Targets {
+		if v.Targets[i].Target == name {
+			return &v.Targets[i]
+		}
+	}
+	return nil
+}
+
+// IncludesInstance returns whether an instance of a target is part of the view
+func (t *ViewTarget) IncludesInstance(instance string) bool {
+	return len(t.Instances) == 0 || slices.Contains(t.Instances, instance)
+}
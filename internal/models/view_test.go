@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestViewInput_Validate(t *testing.T) {
+	valid := ViewInput{
+		Name:    "checkout",
+		Targets: []ViewTarget{{Target: "orders", Instances: []string{"pod-1"}}, {Target: "payments"}},
+		Panels:  []string{ViewPanelPoolUsage, ViewPanelHeap},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input ViewInput
+	}{
+		{"no name", ViewInput{Name: " ", Targets: valid.Targets, Panels: valid.Panels}},
+		{"no targets", ViewInput{Name: "v", Panels: valid.Panels}},
+		{"empty target", ViewInput{Name: "v", Targets: []ViewTarget{{}}, Panels: valid.Panels}},
+		{"duplicate target", ViewInput{Name: "v", Targets: []ViewTarget{{Target: "orders"}, {Target: "orders"}}, Panels: valid.Panels}},
+		{"no panels", ViewInput{Name: "v", Targets: valid.Targets}},
+		{"unknown panel", ViewInput{Name: "v", Targets: valid.Targets, Panels: []string{"disk"}}},
+	}
+	for _, tt := range tests {
+		if err := tt.input.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want error", tt.name)
+		}
+	}
+}
+
+func TestView_Target(t *testing.T) {
+	v := &View{Targets: []ViewTarget{{Target: "orders", Instances: []string{"pod-1"}}, {Target: "payments"}}}
+	orders := v.Target("orders")
+	if orders == nil || !orders.IncludesInstance("pod-1") || orders.IncludesInstance("pod-2") {
+		t.Errorf("orders selection = %+v", orders)
+	}
+	if payments := v.Target("payments"); payments == nil || !payments.IncludesInstance("pod-9") {
+		t.Error("target without instances should include all of them")
+	}
+	if v.Target("billing") != nil {
+		t.Error("Target() of a target not in the view should be nil")
+	}
+	var none *View
+	if none.Target("orders") != nil {
+		t.Error("Target() on a nil view should be nil")
+	}
+}
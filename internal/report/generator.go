@@ -19,6 +19,8 @@ type ReportData struct {
 	Recommendations []analyzer.Recommendation
 	Anomalies       []analyzer.Anomaly
 	PeakTime        *analyzer.PeakTimeResult
+	Forecast        *analyzer.ForecastResult
+	Instances       *analyzer.InstanceComparisonResult
 	LeakAnalysis    *analyzer.LeakAnalysisResult
 }
 
@@ -30,16 +32,32 @@ type ReportSummary struct {
 	AvgActive     float64
 	AvgIdle       float64
 	AvgPending    float64
+	P50Usage      float64
+	P90Usage      float64
+	P99Usage      float64
+	TimeAbove     []analyzer.ThresholdTime
 	TotalTimeouts int64
 	HealthScore   int
 	RiskLevel     string
 }
 
+// MinutesAbove returns the minutes spent above a usage threshold
+func (s ReportSummary) MinutesAbove(threshold float64) float64 {
+	for _, t := range s.TimeAbove {
+		if t.Threshold == threshold {
+			return t.Minutes
+		}
+	}
+	return 0
+}
+
 // BuildReportData builds report data from metrics and analysis results
 // loc is the timezone for displaying timestamps (if nil, uses UTC)
 func BuildReportData(targetName string, rangeStr string, metrics []models.PoolMetrics,
 	recs *analyzer.AnalysisResult, leaks *analyzer.LeakAnalysisResult,
-	anomalies *analyzer.AnomalyResult, peakTime *analyzer.PeakTimeResult, loc *time.Location) ReportData {
+	anomalies *analyzer.AnomalyResult, peakTime *analyzer.PeakTimeResult,
+	forecast *analyzer.ForecastResult, instances *analyzer.InstanceComparisonResult,
+	loc *time.Location) ReportData {
 
 	if loc == nil {
 		loc = time.UTC
@@ -73,7 +91,7 @@ func BuildReportData(targetName string, rangeStr string, metrics []models.PoolMe
 			if usage < minUsage {
 				minUsage = usage
 			}
-			data.Summary.TotalTimeouts += m.Timeout
+			data.Summary.TotalTimeouts += m.TimeoutDelta
 		}
 
 		n := float64(len(metrics))
@@ -83,6 +101,12 @@ func BuildReportData(targetName string, rangeStr string, metrics []models.PoolMe
 		data.Summary.AvgActive = totalActive / n
 		data.Summary.AvgIdle = totalIdle / n
 		data.Summary.AvgPending = totalPending / n
+
+		dist := analyzer.CalculateUsageDistribution(metrics)
+		data.Summary.P50Usage = dist.P50
+		data.Summary.P90Usage = dist.P90
+		data.Summary.P99Usage = dist.P99
+		data.Summary.TimeAbove = dist.TimeAbove
 	}
 
 	// Add recommendations
@@ -111,13 +135,70 @@ func BuildReportData(targetName string, rangeStr string, metrics []models.PoolMe
 		data.PeakTime = peakTime
 	}
 
+	// Add capacity forecast
+	if forecast != nil {
+		data.Forecast = forecast
+	}
+
+	// Add instance comparison
+	if instances != nil {
+		data.Instances = instances
+	}
+
 	return *data
 }
 
 // Template helper functions
 var templateFuncs = template.FuncMap{
-	"add": func(a, b int) int { return a + b },
-	"sub": func(a, b int) int { return a - b },
+	"add":              func(a, b int) int { return a + b },
+	"sub":              func(a, b int) int { return a - b },
+	"forecastSeverity": forecastSeverity,
+	"weekdayRows":      weekdayRows,
+	"heatLevel":        heatLevel,
+	"weekdayName":      func(weekday int) string { return time.Weekday(weekday).String()[:3] },
+}
+
+// weekdayRows splits a weekly pattern into one row of 24 hours per day, Monday first
+func weekdayRows(pattern []analyzer.WeekdayHourStats) [][]analyzer.WeekdayHourStats {
+	if len(pattern) != 7*24 {
+		return nil
+	}
+	rows := make([][]analyzer.WeekdayHourStats, 0, 7)
+	for i := 1; i <= 7; i++ {
+		weekday := i % 7
+		rows = append(rows, pattern[weekday*24:(weekday+1)*24])
+	}
+	return rows
+}
+
+// heatLevel maps a heatmap bucket to a heat-N style, 0 meaning no data
+func heatLevel(b analyzer.WeekdayHourStats) int {
+	switch {
+	case b.SampleSize == 0:
+		return 0
+	case b.AvgUsage >= 80:
+		return 5
+	case b.AvgUsage >= 60:
+		return 4
+	case b.AvgUsage >= 40:
+		return 3
+	case b.AvgUsage >= 20:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// forecastSeverity maps a forecast to a recommendation style
+// Thresholds reached within a week are critical, later ones a warning
+func forecastSeverity(fc *analyzer.MetricForecast) string {
+	if fc == nil || fc.DaysUntil == nil {
+		return "info"
+	}
+	if *fc.DaysUntil <= 7 {
+		return "critical"
+	}
+	return "warning"
 }
 
 // GenerateHTMLReport generates an HTML report
@@ -140,11 +221,19 @@ type CombinedReportData struct {
 	GeneratedAt time.Time
 	Range       string
 	Reports     []ReportData
+	Failures    []ReportFailure // Targets left out of the report
+}
+
+// ReportFailure is a target whose analysis failed or ran out of time
+type ReportFailure struct {
+	TargetName string
+	Error      string
 }
 
 // GenerateCombinedHTMLReport generates a combined HTML report for multiple targets
+// Failures are listed in an errors section after the reports of the other targets
 // loc is the timezone for displaying timestamps (if nil, uses UTC)
-func GenerateCombinedHTMLReport(reports []ReportData, rangeStr string, loc *time.Location) ([]byte, error) {
+func GenerateCombinedHTMLReport(reports []ReportData, failures []ReportFailure, rangeStr string, loc *time.Location) ([]byte, error) {
 	if loc == nil {
 		loc = time.UTC
 	}
@@ -152,6 +241,7 @@ func GenerateCombinedHTMLReport(reports []ReportData, rangeStr string, loc *time
 		GeneratedAt: time.Now().In(loc),
 		Range:       rangeStr,
 		Reports:     reports,
+		Failures:    failures,
 	}
 
 	tmpl, err := template.New("combined").Funcs(templateFuncs).Parse(combinedReportTemplate)
@@ -271,6 +361,37 @@ const reportTemplate = `<!DOCTYPE html>
         .anomaly-critical { background: #fee2e2; }
         .anomaly-warning { background: #fef3c7; }
         .anomaly-type { font-weight: 600; }
+        .data-table {
+            width: 100%;
+            border-collapse: collapse;
+            margin: 16px 0;
+            font-size: 13px;
+        }
+        .data-table th, .data-table td {
+            padding: 8px 10px;
+            border-bottom: 1px solid #e5e7eb;
+            text-align: right;
+        }
+        .data-table th:first-child, .data-table td:first-child { text-align: left; }
+        .data-table th {
+            background: #f9fafb;
+            color: #374151;
+            font-weight: 600;
+        }
+        .data-table tr.outlier td { background: #fee2e2; }
+        .heatmap {
+            border-collapse: collapse;
+            margin: 16px 0;
+            font-size: 10px;
+        }
+        .heatmap th { color: #6b7280; font-weight: normal; padding: 2px; }
+        .heatmap td { width: 26px; height: 18px; border: 1px solid #fff; }
+        .heat-0 { background: #f3f4f6; }
+        .heat-1 { background: #dcfce7; }
+        .heat-2 { background: #bbf7d0; }
+        .heat-3 { background: #fde68a; }
+        .heat-4 { background: #fdba74; }
+        .heat-5 { background: #f87171; }
         .footer {
             margin-top: 40px;
             padding-top: 20px;
@@ -354,6 +475,24 @@ const reportTemplate = `<!DOCTYPE html>
                 <div class="stat-label">Total Timeouts</div>
             </div>
         </div>
+        <div class="stat-grid">
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .Summary.P50Usage}}%</div>
+                <div class="stat-label">P50 Usage</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .Summary.P90Usage}}%</div>
+                <div class="stat-label">P90 Usage</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .Summary.P99Usage}}%</div>
+                <div class="stat-label">P99 Usage</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.0f" (.Summary.MinutesAbove 80)}} min</div>
+                <div class="stat-label">Time Above 80%</div>
+            </div>
+        </div>
 
         {{if .PeakTime}}
         {{if .PeakTime.Summary}}
@@ -382,6 +521,138 @@ const reportTemplate = `<!DOCTYPE html>
             <div class="rec-reason">{{.PeakTime.Summary.Recommendation}}</div>
         </div>
         {{end}}
+        {{with weekdayRows .PeakTime.WeeklyPattern}}
+        <table class="heatmap">
+            <tr>
+                <th></th>
+                {{range $i, $_ := index . 0}}<th>{{printf "%02d" $i}}</th>{{end}}
+            </tr>
+            {{range .}}
+            <tr>
+                <th>{{(index . 0).Weekday | weekdayName}}</th>
+                {{range .}}<td class="heat-{{heatLevel .}}" title="{{printf "%.1f" .AvgUsage}}% avg, {{.SampleSize}} samples"></td>{{end}}
+            </tr>
+            {{end}}
+        </table>
+        {{end}}
+        {{with .PeakTime.WeekdayVsWeekend}}
+        {{if and .WeekdaySamples .WeekendSamples}}
+        <div class="stat-grid">
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .WeekdayAvgUsage}}%</div>
+                <div class="stat-label">Weekday Avg Usage</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .WeekdayPeakUsage}}%</div>
+                <div class="stat-label">Weekday Peak Usage</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .WeekendAvgUsage}}%</div>
+                <div class="stat-label">Weekend Avg Usage</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .WeekendPeakUsage}}%</div>
+                <div class="stat-label">Weekend Peak Usage</div>
+            </div>
+        </div>
+        {{end}}
+        {{end}}
+        {{if .PeakTime.WeeklySpikes}}
+        <table class="data-table">
+            <tr>
+                <th>Weekly Spike</th>
+                <th>Avg Usage</th>
+                <th>Same Hour, Other Days</th>
+                <th>Excess</th>
+            </tr>
+            {{range .PeakTime.WeeklySpikes}}
+            <tr>
+                <td>{{.WeekdayName}} {{.Hour}}:00</td>
+                <td>{{printf "%.1f" .AvgUsage}}%</td>
+                <td>{{printf "%.1f" .HourAvgUsage}}%</td>
+                <td>+{{printf "%.1f" .Excess}}</td>
+            </tr>
+            {{end}}
+        </table>
+        {{end}}
+        {{end}}
+        {{end}}
+
+        {{if .Forecast}}
+        {{if or .Forecast.Pool .Forecast.Heap}}
+        <h2>Capacity Forecast</h2>
+        <div class="stat-grid">
+            {{with .Forecast.Pool}}
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .Current}}%</div>
+                <div class="stat-label">Pool Usage (fitted)</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%+.1f" .SlopePerDay}}%</div>
+                <div class="stat-label">Pool Trend / Day</div>
+            </div>
+            {{end}}
+            {{with .Forecast.Heap}}
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%.1f" .Current}}%</div>
+                <div class="stat-label">Heap Usage (fitted)</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-value">{{printf "%+.1f" .SlopePerDay}}%</div>
+                <div class="stat-label">Heap Trend / Day</div>
+            </div>
+            {{end}}
+        </div>
+        {{with .Forecast.Pool}}
+        <div class="recommendation rec-{{forecastSeverity .}}">
+            <div class="rec-type">Connection Pool ({{printf "%.0f" .Threshold}}% threshold)</div>
+            <div class="rec-reason">{{.Message}}</div>
+            {{if .ExpectedAt}}<div class="rec-values">Expected at <strong>{{.ExpectedAt.Format "2006-01-02 15:04"}}</strong> ({{.Method}})</div>{{end}}
+        </div>
+        {{end}}
+        {{with .Forecast.Heap}}
+        <div class="recommendation rec-{{forecastSeverity .}}">
+            <div class="rec-type">Heap Memory ({{printf "%.0f" .Threshold}}% threshold)</div>
+            <div class="rec-reason">{{.Message}}</div>
+            {{if .ExpectedAt}}<div class="rec-values">Expected at <strong>{{.ExpectedAt.Format "2006-01-02 15:04"}}</strong> ({{.Method}})</div>{{end}}
+        </div>
+        {{end}}
+        {{end}}
+        {{end}}
+
+        {{if .Instances}}
+        {{if gt (len .Instances.Instances) 1}}
+        <h2>Instance Comparison</h2>
+        <table class="data-table">
+            <tr>
+                <th>Instance</th>
+                <th>Avg Usage</th>
+                <th>Peak Usage</th>
+                <th>Avg Pending</th>
+                <th>Max Pending</th>
+                <th>GC Count</th>
+                <th>GC Time</th>
+                <th>Timeouts</th>
+            </tr>
+            {{range .Instances.Instances}}
+            <tr{{if .Outlier}} class="outlier"{{end}}>
+                <td>{{.InstanceName}}</td>
+                <td>{{printf "%.1f" .AvgUsage}}%</td>
+                <td>{{printf "%.1f" .PeakUsage}}%</td>
+                <td>{{printf "%.1f" .AvgPending}}</td>
+                <td>{{.MaxPending}}</td>
+                <td>{{.GcCount}}</td>
+                <td>{{printf "%.1f" .GcTime}}s</td>
+                <td>{{.Timeouts}}</td>
+            </tr>
+            {{end}}
+        </table>
+        {{range .Instances.Outliers}}
+        <div class="recommendation rec-warning">
+            <div class="rec-type">Outlier: {{.InstanceName}}</div>
+            <div class="rec-reason">{{.Message}}</div>
+        </div>
+        {{end}}
         {{end}}
         {{end}}
 
@@ -615,7 +886,8 @@ const combinedReportTemplate = `<!DOCTYPE html>
             <div class="subtitle">
                 <strong>Generated:</strong> {{.GeneratedAt.Format "2006-01-02 15:04:05"}} |
                 <strong>Range:</strong> {{.Range}} |
-                <strong>Targets:</strong> {{len .Reports}}
+                <strong>Targets:</strong> {{len .Reports}}{{if .Failures}} |
+                <strong>Failed:</strong> {{len .Failures}}{{end}}
             </div>
             <div class="toc">
                 <div class="toc-title">Targets</div>
@@ -682,6 +954,20 @@ const combinedReportTemplate = `<!DOCTYPE html>
             </div>
             {{end}}{{end}}
 
+            {{if .Forecast}}{{if or .Forecast.Pool .Forecast.Heap}}
+            <h2>Capacity Forecast</h2>
+            {{with .Forecast.Pool}}
+            <div class="recommendation rec-{{forecastSeverity .}}">
+                <span class="rec-type">Pool</span>: <span class="rec-reason">{{.Message}}</span>
+            </div>
+            {{end}}
+            {{with .Forecast.Heap}}
+            <div class="recommendation rec-{{forecastSeverity .}}">
+                <span class="rec-type">Heap</span>: <span class="rec-reason">{{.Message}}</span>
+            </div>
+            {{end}}
+            {{end}}{{end}}
+
             {{if .Recommendations}}
             <h2>Recommendations ({{len .Recommendations}})</h2>
             {{range .Recommendations}}
@@ -721,6 +1007,20 @@ const combinedReportTemplate = `<!DOCTYPE html>
         </div>
         {{end}}
 
+        {{if .Failures}}
+        <div class="target-section">
+            <div class="target-header">
+                <span class="target-name">Errors</span>
+                <span class="badge badge-critical">{{len .Failures}} targets not reported</span>
+            </div>
+            {{range .Failures}}
+            <div class="recommendation rec-critical">
+                <span class="rec-type">{{.TargetName}}</span>: <span class="rec-reason">{{.Error}}</span>
+            </div>
+            {{end}}
+        </div>
+        {{end}}
+
         <div class="footer">
             Generated by <strong>Pondy</strong> - JVM Connection Pool Monitor<br>
             <a href="https://github.com/amazingkj/pondy" style="color: #6b7280;">https://github.com/amazingkj/pondy</a>
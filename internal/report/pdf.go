@@ -0,0 +1,90 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrPDFUnavailable is returned when no Chrome/Chromium executable is found
+var ErrPDFUnavailable = errors.New("PDF export requires Chrome or Chromium (set report.chrome_path)")
+
+// PDFTimeout bounds a single PDF rendering including browser startup
+const PDFTimeout = 60 * time.Second
+
+// chromeExecutables are looked up in PATH when no path is configured
+var chromeExecutables = []string{
+	"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "headless-shell", "chrome",
+}
+
+// findChrome returns the browser executable to use
+func findChrome(configured string) (string, error) {
+	if configured != "" {
+		path, err := exec.LookPath(configured)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrPDFUnavailable, err)
+		}
+		return path, nil
+	}
+	for _, name := range chromeExecutables {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrPDFUnavailable
+}
+
+// RenderPDF prints an HTML report to an A4 PDF with a headless browser
+// The report is self-contained, so no network access is needed while rendering
+func RenderPDF(ctx context.Context, html []byte, chromePath string) ([]byte, error) {
+	execPath, err := findChrome(chromePath)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, PDFTimeout)
+	defer cancel()
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.ExecPath(execPath),
+		chromedp.NoSandbox, // Containers usually run without the privileges the sandbox needs
+		chromedp.DisableGPU,
+	)
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancelAlloc()
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	defer cancelBrowser()
+
+	var pdf []byte
+	err = chromedp.Run(browserCtx,
+		chromedp.Navigate("about:blank"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			tree, err := page.GetFrameTree().Do(ctx)
+			if err != nil {
+				return err
+			}
+			return page.SetDocumentContent(tree.Frame.ID, string(html)).Do(ctx)
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			pdf, _, err = page.PrintToPDF().
+				WithPrintBackground(true).
+				WithPaperWidth(8.27). // A4 in inches
+				WithPaperHeight(11.69).
+				WithMarginTop(0.4).
+				WithMarginBottom(0.4).
+				WithMarginLeft(0.4).
+				WithMarginRight(0.4).
+				Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+	return pdf, nil
+}
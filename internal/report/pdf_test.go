@@ -0,0 +1,36 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRenderPDF_MissingBrowser(t *testing.T) {
+	_, err := RenderPDF(context.Background(), []byte("<html></html>"), "/nonexistent/chromium")
+	if !errors.Is(err, ErrPDFUnavailable) {
+		t.Errorf("RenderPDF() error = %v, want ErrPDFUnavailable", err)
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	if _, err := findChrome(""); err != nil {
+		t.Skip("no Chrome/Chromium available")
+	}
+
+	data := ReportData{TargetName: "orders", GeneratedAt: time.Now(), Range: "24h"}
+	html, err := GenerateHTMLReport(&data)
+	if err != nil {
+		t.Fatalf("GenerateHTMLReport() error = %v", err)
+	}
+
+	pdf, err := RenderPDF(context.Background(), html, "")
+	if err != nil {
+		t.Fatalf("RenderPDF() error = %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Errorf("output is not a PDF: %q", pdf[:min(len(pdf), 16)])
+	}
+}
@@ -3,6 +3,8 @@ package retention
 import (
 	"context"
 	"log"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/jiin/pondy/internal/config"
@@ -13,7 +15,12 @@ import (
 type Manager struct {
 	store  storage.Storage
 	maxAge time.Duration
+	idle   time.Duration
+	rollup config.RollupConfig
 	cancel context.CancelFunc
+
+	mu        sync.RWMutex
+	overrides map[string]time.Duration // raw data max age of targets not using maxAge, by name
 }
 
 // NewManager creates a new retention manager
@@ -21,9 +28,18 @@ func NewManager(store storage.Storage, cfg *config.RetentionConfig) *Manager {
 	return &Manager{
 		store:  store,
 		maxAge: cfg.GetMaxAge(),
+		idle:   cfg.GetInstanceIdle(),
+		rollup: cfg.Rollup,
 	}
 }
 
+// SetTargetRetention replaces the targets whose raw data is kept longer or shorter than max_age
+func (m *Manager) SetTargetRetention(overrides map[string]time.Duration) {
+	m.mu.Lock()
+	m.overrides = overrides
+	m.mu.Unlock()
+}
+
 // Start begins the background cleanup routine
 func (m *Manager) Start(interval time.Duration) {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -34,24 +50,68 @@ func (m *Manager) Start(interval time.Duration) {
 		defer ticker.Stop()
 
 		// Run cleanup immediately on start
-		m.runCleanup()
+		m.runCleanup(ctx)
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				m.runCleanup()
+				m.runCleanup(ctx)
 			}
 		}
 	}()
 
-	log.Printf("Retention manager started: max_age=%v, interval=%v", m.maxAge, interval)
+	log.Printf("Retention manager started: max_age=%v, interval=%v, rollups=%v", m.maxAge, interval, m.rollup.IsEnabled())
 }
 
-func (m *Manager) runCleanup() {
-	olderThan := time.Now().Add(-m.maxAge)
-	deleted, err := m.store.Cleanup(olderThan)
+func (m *Manager) runCleanup(ctx context.Context) {
+	now := time.Now()
+
+	// Raw data must be rolled up before it is deleted
+	if m.rollup.IsEnabled() {
+		buckets, err := m.store.Rollup(ctx, now)
+		if err != nil {
+			log.Printf("Retention rollup failed, keeping raw data: %v", err)
+			return
+		}
+		if buckets > 0 {
+			log.Printf("Retention rollup: wrote %d buckets", buckets)
+		}
+	}
+
+	// Decommissioned instances are hidden before their data ages out
+	marked, err := m.store.MarkInactiveInstances(ctx, now.Add(-m.idle))
+	if err != nil {
+		log.Printf("Retention: marking inactive instances failed: %v", err)
+	} else if marked > 0 {
+		log.Printf("Retention: marked %d instances idle for %v as inactive", marked, m.idle)
+	}
+
+	m.mu.RLock()
+	overrides := m.overrides
+	m.mu.RUnlock()
+
+	// Targets with their own retention are cleaned up separately
+	excluded := make([]string, 0, len(overrides))
+	for name := range overrides {
+		excluded = append(excluded, name)
+	}
+	sort.Strings(excluded)
+	for _, name := range excluded {
+		targetOlderThan := now.Add(-overrides[name])
+		deleted, err := m.store.CleanupTarget(ctx, name, targetOlderThan)
+		if err != nil {
+			log.Printf("Retention cleanup of %s failed: %v", name, err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Retention cleanup: deleted %d records of %s older than %v", deleted, name, targetOlderThan.Format(time.RFC3339))
+		}
+	}
+
+	olderThan := now.Add(-m.maxAge)
+	deleted, err := m.store.Cleanup(ctx, olderThan, excluded...)
 	if err != nil {
 		log.Printf("Retention cleanup failed: %v", err)
 		return
@@ -59,6 +119,49 @@ func (m *Manager) runCleanup() {
 	if deleted > 0 {
 		log.Printf("Retention cleanup: deleted %d records older than %v", deleted, olderThan.Format(time.RFC3339))
 	}
+
+	deliveries, err := m.store.CleanupNotificationLog(ctx, olderThan)
+	if err != nil {
+		log.Printf("Retention cleanup of notification log failed: %v", err)
+		return
+	}
+	if deliveries > 0 {
+		log.Printf("Retention cleanup: deleted %d notification log entries", deliveries)
+	}
+
+	checks, err := m.store.CleanupHealthChecks(ctx, olderThan)
+	if err != nil {
+		log.Printf("Retention cleanup of health checks failed: %v", err)
+		return
+	}
+	if checks > 0 {
+		log.Printf("Retention cleanup: deleted %d health checks", checks)
+	}
+
+	silences, err := m.store.CleanupSilences(ctx, olderThan)
+	if err != nil {
+		log.Printf("Retention cleanup of silences failed: %v", err)
+		return
+	}
+	if silences > 0 {
+		log.Printf("Retention cleanup: deleted %d expired silences", silences)
+	}
+
+	if m.rollup.IsEnabled() {
+		m.cleanupRollups(ctx, storage.ResolutionMinute, now.Add(-m.rollup.GetMinuteMaxAge()))
+		m.cleanupRollups(ctx, storage.ResolutionHour, now.Add(-m.rollup.GetHourMaxAge()))
+	}
+}
+
+func (m *Manager) cleanupRollups(ctx context.Context, resolution string, olderThan time.Time) {
+	deleted, err := m.store.CleanupRollups(ctx, resolution, olderThan)
+	if err != nil {
+		log.Printf("Retention cleanup of %s rollups failed: %v", resolution, err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Retention cleanup: deleted %d %s rollups older than %v", deleted, resolution, olderThan.Format(time.RFC3339))
+	}
 }
 
 // Stop stops the background cleanup routine
@@ -0,0 +1,115 @@
+package slo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jiin/pondy/internal/alerter"
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// AlertRulePrefix prefixes the rule name of fast burn alerts, e.g., "slo:pool-usage"
+const AlertRulePrefix = "slo:"
+
+// Manager periodically checks SLO burn rates and fires alerts on fast burn
+type Manager struct {
+	cfgMgr *config.Manager
+	store  storage.Storage
+	alerts *alerter.Manager
+	cancel context.CancelFunc
+}
+
+// NewManager creates an SLO manager
+func NewManager(cfgMgr *config.Manager, store storage.Storage, alerts *alerter.Manager) *Manager {
+	return &Manager{
+		cfgMgr: cfgMgr,
+		store:  store,
+		alerts: alerts,
+	}
+}
+
+// Start begins checking burn rates every interval
+func (m *Manager) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.check(ctx, time.Now())
+			}
+		}
+	}()
+
+	log.Printf("SLO manager started: interval=%v", interval)
+}
+
+// Stop stops the burn rate checks
+func (m *Manager) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+// check evaluates the fast burn windows of every alerting SLO
+func (m *Manager) check(ctx context.Context, now time.Time) {
+	cfg := m.cfgMgr.Get()
+	loc := cfg.GetLocation()
+
+	for _, target := range cfg.Targets {
+		history := StoreHistory(ctx, m.store, target.Name)
+		for _, s := range target.SLOs {
+			if !s.IsAlertEnabled() {
+				continue
+			}
+			if err := s.Validate(); err != nil {
+				continue
+			}
+			objective, err := ParseObjective(s.Objective)
+			if err != nil {
+				continue
+			}
+
+			fast, rate, err := FastBurn(s, objective, history, now, loc)
+			if err != nil {
+				log.Printf("SLO: failed to check %s/%s: %v", target.Name, s.Name, err)
+				continue
+			}
+			m.report(ctx, target.Name, s, fast, rate)
+		}
+	}
+}
+
+// report fires or resolves the fast burn alert of an SLO
+func (m *Manager) report(ctx context.Context, target string, s config.SLOConfig, fast bool, rate float64) {
+	if m.alerts == nil {
+		return
+	}
+
+	rule := &config.AlertRule{
+		Name:     AlertRulePrefix + s.Name,
+		Severity: s.GetSeverity(),
+		Message: fmt.Sprintf("SLO %s (%s for %.2f%% of %s) is burning its error budget %.1fx faster than allowed over the last %s",
+			s.Name, s.Objective, s.Target, formatDuration(s.GetWindow()), rate, formatDuration(s.GetFastBurnWindow())),
+	}
+
+	// The alert belongs to the target, so the latest sample only fills in the template context
+	latest := &models.PoolMetrics{TargetName: target}
+	if metrics, err := m.store.GetLatest(ctx, target); err == nil && metrics != nil {
+		latest = metrics
+	}
+	ruleCtx := alerter.NewRuleContext(latest)
+	ruleCtx.InstanceName = ""
+
+	m.alerts.Report(rule, ruleCtx, fast)
+}
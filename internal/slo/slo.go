@@ -0,0 +1,307 @@
+package slo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+	"github.com/jiin/pondy/internal/storage"
+)
+
+// burnWindows are the windows burn rates are reported for
+var burnWindows = []time.Duration{time.Hour, 6 * time.Hour, 24 * time.Hour, 72 * time.Hour}
+
+// HistoryFunc returns a target's metrics between from and to
+type HistoryFunc func(from, to time.Time) ([]models.PoolMetrics, error)
+
+// StoreHistory reads a target's history from storage
+func StoreHistory(ctx context.Context, store storage.Storage, target string) HistoryFunc {
+	return func(from, to time.Time) ([]models.PoolMetrics, error) {
+		return store.GetHistory(ctx, target, from, to)
+	}
+}
+
+// Status is the compliance of an SLO over its window
+type Status struct {
+	Name            string     `json:"name"`
+	Objective       string     `json:"objective"`
+	Target          float64    `json:"target"` // % of samples (or periods) that must meet the objective
+	Window          string     `json:"window"`
+	Period          string     `json:"period,omitempty"`
+	Good            int        `json:"good"`
+	Total           int        `json:"total"`
+	Compliance      float64    `json:"compliance"`       // % meeting the objective over the window
+	ErrorBudget     float64    `json:"error_budget"`     // % of the window allowed to miss the objective
+	BudgetRemaining float64    `json:"budget_remaining"` // % of the error budget left, negative when exhausted
+	Met             bool       `json:"met"`
+	BurnRates       []BurnRate `json:"burn_rates"`
+	FastBurn        bool       `json:"fast_burn"` // Both fast burn windows exceed the fast burn rate
+	EvaluatedAt     time.Time  `json:"evaluated_at"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// BurnRate is how fast the error budget is spent over a window
+// 1 spends exactly the budget over the SLO window, 10 spends it ten times as fast
+type BurnRate struct {
+	Window string  `json:"window"`
+	Rate   float64 `json:"rate"`
+	Total  int     `json:"total"`
+}
+
+// Objective is a parsed "variable operator value" condition
+type Objective struct {
+	variable string
+	operator string
+	value    float64
+}
+
+// objectiveVariables maps variable names to sample values
+// timeouts is the increase of the timeout counter since the previous sample of the same instance
+var objectiveVariables = map[string]func(cur, prev *models.PoolMetrics) (float64, bool){
+	"usage": func(cur, _ *models.PoolMetrics) (float64, bool) {
+		if cur.Max <= 0 {
+			return 0, false
+		}
+		return float64(cur.Active) / float64(cur.Max) * 100, true
+	},
+	"heap_usage": func(cur, _ *models.PoolMetrics) (float64, bool) {
+		if cur.HeapMax <= 0 {
+			return 0, false
+		}
+		return float64(cur.HeapUsed) / float64(cur.HeapMax) * 100, true
+	},
+	"active":      func(cur, _ *models.PoolMetrics) (float64, bool) { return float64(cur.Active), true },
+	"pending":     func(cur, _ *models.PoolMetrics) (float64, bool) { return float64(cur.Pending), true },
+	"acquire_p99": func(cur, _ *models.PoolMetrics) (float64, bool) { return cur.AcquireP99, true },
+	"timeouts": func(cur, prev *models.PoolMetrics) (float64, bool) {
+		if prev == nil {
+			return 0, false
+		}
+		// A counter reset counts as the new value
+		if cur.Timeout < prev.Timeout {
+			return float64(cur.Timeout), true
+		}
+		return float64(cur.Timeout - prev.Timeout), true
+	},
+}
+
+// ParseObjective parses an objective such as "usage < 85" or "timeouts == 0"
+func ParseObjective(s string) (*Objective, error) {
+	s = strings.TrimSpace(s)
+	for _, op := range []string{">=", "<=", "==", "!=", ">", "<"} {
+		idx := strings.Index(s, op)
+		if idx == -1 {
+			continue
+		}
+		variable := strings.ToLower(strings.TrimSpace(s[:idx]))
+		if _, ok := objectiveVariables[variable]; !ok {
+			return nil, fmt.Errorf("unknown objective variable '%s'. Valid variables: usage, heap_usage, active, pending, acquire_p99, timeouts", variable)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(s[idx+len(op):]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid objective value in '%s': must be a number", s)
+		}
+		return &Objective{variable: variable, operator: op, value: value}, nil
+	}
+	return nil, fmt.Errorf("invalid objective format: expected 'variable operator value', got '%s'", s)
+}
+
+// meets reports whether a sample meets the objective
+// ok is false when the sample can't be judged, e.g., a failed scrape
+func (o *Objective) meets(cur, prev *models.PoolMetrics) (good, ok bool) {
+	if cur.Status == models.StatusError {
+		return false, false
+	}
+	v, ok := objectiveVariables[o.variable](cur, prev)
+	if !ok {
+		return false, false
+	}
+	switch o.operator {
+	case ">":
+		return v > o.value, true
+	case ">=":
+		return v >= o.value, true
+	case "<":
+		return v < o.value, true
+	case "<=":
+		return v <= o.value, true
+	case "==":
+		return v == o.value, true
+	default:
+		return v != o.value, true
+	}
+}
+
+// Count returns how many samples meet the objective
+// With a period, samples are grouped into periods and a period is good only if all its samples are
+// Daily periods follow calendar days in loc.
+func (o *Objective) Count(metrics []models.PoolMetrics, period time.Duration, loc *time.Location) (good, total int) {
+	prev := make(map[string]*models.PoolMetrics)
+	periods := make(map[int64]bool)
+
+	for i := range metrics {
+		cur := &metrics[i]
+		ok, judged := o.meets(cur, prev[cur.InstanceName])
+		prev[cur.InstanceName] = cur
+		if !judged {
+			continue
+		}
+
+		if period <= 0 {
+			total++
+			if ok {
+				good++
+			}
+			continue
+		}
+
+		key := periodKey(cur.Timestamp, period, loc)
+		if wasGood, seen := periods[key]; seen {
+			periods[key] = wasGood && ok
+		} else {
+			periods[key] = ok
+		}
+	}
+
+	if period > 0 {
+		for _, ok := range periods {
+			total++
+			if ok {
+				good++
+			}
+		}
+	}
+	return good, total
+}
+
+func periodKey(t time.Time, period time.Duration, loc *time.Location) int64 {
+	if period == 24*time.Hour {
+		y, m, d := t.In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc).Unix()
+	}
+	return t.Truncate(period).Unix()
+}
+
+// Evaluate computes the compliance, error budget and burn rates of an SLO at now
+func Evaluate(cfg config.SLOConfig, history HistoryFunc, now time.Time, loc *time.Location) Status {
+	if loc == nil {
+		loc = time.UTC
+	}
+	window := cfg.GetWindow()
+	period := cfg.GetPeriod()
+
+	status := Status{
+		Name:        cfg.Name,
+		Objective:   cfg.Objective,
+		Target:      cfg.Target,
+		Window:      formatDuration(window),
+		ErrorBudget: 100 - cfg.Target,
+		EvaluatedAt: now.In(loc),
+		BurnRates:   []BurnRate{},
+	}
+	if period > 0 {
+		status.Period = formatDuration(period)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	objective, err := ParseObjective(cfg.Objective)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	metrics, err := history(now.Add(-window), now)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Good, status.Total = objective.Count(metrics, period, loc)
+	if status.Total == 0 {
+		status.Error = "no data in window"
+		return status
+	}
+
+	budget := 1 - cfg.Target/100
+	bad := 1 - float64(status.Good)/float64(status.Total)
+	status.Compliance = float64(status.Good) / float64(status.Total) * 100
+	status.BudgetRemaining = (budget - bad) / budget * 100
+	status.Met = status.Compliance >= cfg.Target
+
+	for _, w := range burnWindows {
+		if w > window {
+			break
+		}
+		rate, total, err := BurnRateOver(cfg, objective, history, w, now, loc)
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		status.BurnRates = append(status.BurnRates, BurnRate{Window: formatDuration(w), Rate: rate, Total: total})
+	}
+
+	status.FastBurn, _, err = FastBurn(cfg, objective, history, now, loc)
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// BurnRateOver returns the burn rate over the window ending at now and the number of samples (or periods) judged
+func BurnRateOver(cfg config.SLOConfig, objective *Objective, history HistoryFunc, window time.Duration, now time.Time, loc *time.Location) (float64, int, error) {
+	metrics, err := history(now.Add(-window), now)
+	if err != nil {
+		return 0, 0, err
+	}
+	good, total := objective.Count(metrics, cfg.GetPeriod(), loc)
+	if total == 0 {
+		return 0, 0, nil
+	}
+	bad := 1 - float64(good)/float64(total)
+	return bad / (1 - cfg.Target/100), total, nil
+}
+
+// FastBurn reports whether the burn rate over both the long fast burn window and
+// its 1/12 short window exceed the fast burn rate, and returns the long window rate.
+// The short window makes the alert resolve soon after the budget stops burning.
+func FastBurn(cfg config.SLOConfig, objective *Objective, history HistoryFunc, now time.Time, loc *time.Location) (bool, float64, error) {
+	long := cfg.GetFastBurnWindow()
+	threshold := cfg.GetFastBurnRate()
+
+	longRate, _, err := BurnRateOver(cfg, objective, history, long, now, loc)
+	if err != nil || longRate < threshold {
+		return false, longRate, err
+	}
+	shortRate, _, err := BurnRateOver(cfg, objective, history, long/12, now, loc)
+	if err != nil {
+		return false, longRate, err
+	}
+	return shortRate >= threshold, longRate, nil
+}
+
+// EvaluateTarget evaluates every SLO configured for a target
+func EvaluateTarget(ctx context.Context, store storage.Storage, target config.TargetConfig, now time.Time, loc *time.Location) []Status {
+	history := StoreHistory(ctx, store, target.Name)
+	statuses := make([]Status, 0, len(target.SLOs))
+	for _, cfg := range target.SLOs {
+		statuses = append(statuses, Evaluate(cfg, history, now, loc))
+	}
+	return statuses
+}
+
+// formatDuration formats whole days as "30d" and whole hours as "6h"
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	}
+	return d.String()
+}
@@ -0,0 +1,151 @@
+package slo
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/config"
+	"github.com/jiin/pondy/internal/models"
+)
+
+var sloNow = time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+// staticHistory serves metrics filtered to the requested range
+func staticHistory(metrics []models.PoolMetrics) HistoryFunc {
+	return func(from, to time.Time) ([]models.PoolMetrics, error) {
+		var out []models.PoolMetrics
+		for _, m := range metrics {
+			if !m.Timestamp.Before(from) && !m.Timestamp.After(to) {
+				out = append(out, m)
+			}
+		}
+		return out, nil
+	}
+}
+
+// usageHistory returns hourly samples for the 30 days before sloNow with usage from usage(t)
+func usageHistory(usage func(t time.Time) int) []models.PoolMetrics {
+	var metrics []models.PoolMetrics
+	for t := sloNow.Add(-30 * 24 * time.Hour).Add(time.Hour); !t.After(sloNow); t = t.Add(time.Hour) {
+		metrics = append(metrics, models.PoolMetrics{TargetName: "orders", Active: usage(t), Max: 100, Timestamp: t})
+	}
+	return metrics
+}
+
+func TestParseObjective(t *testing.T) {
+	valid := []string{"usage < 85", "timeouts == 0", "HEAP_USAGE<=90", "acquire_p99 < 0.5"}
+	for _, s := range valid {
+		if _, err := ParseObjective(s); err != nil {
+			t.Errorf("ParseObjective(%q) error = %v", s, err)
+		}
+	}
+
+	invalid := []string{"", "usage", "cpu < 50", "usage < high"}
+	for _, s := range invalid {
+		if _, err := ParseObjective(s); err == nil {
+			t.Errorf("ParseObjective(%q) should fail", s)
+		}
+	}
+}
+
+func TestObjective_CountPeriods(t *testing.T) {
+	objective, _ := ParseObjective("timeouts == 0")
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	metrics := []models.PoolMetrics{
+		// Day 1: counter stays at 3
+		{InstanceName: "a", Timeout: 3, Timestamp: day.Add(1 * time.Hour)},
+		{InstanceName: "b", Timeout: 7, Timestamp: day.Add(1 * time.Hour)},
+		{InstanceName: "a", Timeout: 3, Timestamp: day.Add(10 * time.Hour)},
+		// Day 2: instance b times out once
+		{InstanceName: "a", Timeout: 3, Timestamp: day.Add(25 * time.Hour)},
+		{InstanceName: "b", Timeout: 8, Timestamp: day.Add(26 * time.Hour)},
+		// Day 3: instance a restarts and its counter resets
+		{InstanceName: "a", Timeout: 0, Timestamp: day.Add(49 * time.Hour)},
+		{InstanceName: "b", Timeout: 8, Timestamp: day.Add(50 * time.Hour), Status: models.StatusError},
+	}
+
+	good, total := objective.Count(metrics, 24*time.Hour, time.UTC)
+	if good != 2 || total != 3 {
+		t.Errorf("Count() = %d/%d, want 2/3", good, total)
+	}
+
+	// Per sample, the first sample of each instance can't be judged and failed scrapes are skipped
+	good, total = objective.Count(metrics, 0, time.UTC)
+	if good != 3 || total != 4 {
+		t.Errorf("Count() = %d/%d, want 3/4", good, total)
+	}
+}
+
+func TestEvaluate_ErrorBudget(t *testing.T) {
+	// 720 hourly samples, 2 of them above 85%
+	metrics := usageHistory(func(ts time.Time) int {
+		if ts.Equal(sloNow.Add(-10*24*time.Hour)) || ts.Equal(sloNow.Add(-20*24*time.Hour)) {
+			return 95
+		}
+		return 50
+	})
+
+	cfg := config.SLOConfig{Name: "pool-usage", Objective: "usage < 85", Target: 99.5}
+	status := Evaluate(cfg, staticHistory(metrics), sloNow, nil)
+
+	if status.Error != "" {
+		t.Fatalf("Error = %s", status.Error)
+	}
+	if status.Total != 720 || status.Good != 718 {
+		t.Errorf("Good/Total = %d/%d, want 718/720", status.Good, status.Total)
+	}
+	if !status.Met {
+		t.Error("Met = false, want true")
+	}
+	// Budget is 3.6 samples, 2 are spent
+	if math.Abs(status.BudgetRemaining-44.4) > 0.1 {
+		t.Errorf("BudgetRemaining = %.2f, want ~44.4", status.BudgetRemaining)
+	}
+	if status.Window != "30d" || len(status.BurnRates) != 4 {
+		t.Errorf("Window = %s, burn rates = %d", status.Window, len(status.BurnRates))
+	}
+	if status.FastBurn {
+		t.Error("FastBurn = true, want false")
+	}
+}
+
+func TestEvaluate_FastBurn(t *testing.T) {
+	// Saturated for the last two hours, sampled every 5 minutes
+	var metrics []models.PoolMetrics
+	for ts := sloNow.Add(-24 * time.Hour); !ts.After(sloNow); ts = ts.Add(5 * time.Minute) {
+		active := 50
+		if ts.After(sloNow.Add(-2 * time.Hour)) {
+			active = 100
+		}
+		metrics = append(metrics, models.PoolMetrics{Active: active, Max: 100, Timestamp: ts})
+	}
+
+	cfg := config.SLOConfig{Name: "pool-usage", Objective: "usage < 85", Target: 99}
+	status := Evaluate(cfg, staticHistory(metrics), sloNow, nil)
+
+	if !status.FastBurn {
+		t.Error("FastBurn = false, want true")
+	}
+	if status.BurnRates[0].Window != "1h" || math.Abs(status.BurnRates[0].Rate-100) > 0.01 {
+		t.Errorf("1h burn rate = %+v, want 100", status.BurnRates[0])
+	}
+	if status.Met {
+		t.Error("Met = true, want false")
+	}
+}
+
+func TestEvaluate_InvalidConfig(t *testing.T) {
+	history := staticHistory(nil)
+
+	status := Evaluate(config.SLOConfig{Name: "x", Objective: "usage < 85", Target: 100}, history, sloNow, nil)
+	if status.Error == "" {
+		t.Error("target 100 should be rejected")
+	}
+
+	status = Evaluate(config.SLOConfig{Name: "x", Objective: "usage < 85", Target: 99}, history, sloNow, nil)
+	if status.Error != "no data in window" {
+		t.Errorf("Error = %q, want no data in window", status.Error)
+	}
+}
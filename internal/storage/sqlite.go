@@ -1,12 +1,15 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -52,9 +55,14 @@ func sanitizeSQLitePath(path string) (string, error) {
 }
 
 type SQLiteStorage struct {
-	db *sql.DB
+	db           *sql.DB
+	path         string
+	queryTimeout time.Duration // Bound of a single call; 0 leaves calls bounded by their context only
 }
 
+// DefaultQueryTimeout bounds a single storage call unless SetQueryTimeout changes it
+const DefaultQueryTimeout = 30 * time.Second
+
 func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
@@ -79,7 +87,7 @@ func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(time.Hour)
 
-	storage := &SQLiteStorage{db: db}
+	storage := &SQLiteStorage{db: db, path: dbPath, queryTimeout: DefaultQueryTimeout}
 	if err := storage.migrate(); err != nil {
 		db.Close()
 		return nil, err
@@ -88,6 +96,21 @@ func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 	return storage, nil
 }
 
+// SetQueryTimeout bounds every read and write call, so a slow query fails instead of
+// blocking its caller; maintenance such as cleanup, rollups, backups and vacuum is only
+// bounded by its context. Set before the storage is used
+func (s *SQLiteStorage) SetQueryTimeout(timeout time.Duration) {
+	s.queryTimeout = timeout
+}
+
+// withTimeout bounds a call by the query timeout, keeping an earlier deadline of ctx
+func (s *SQLiteStorage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
 func (s *SQLiteStorage) migrate() error {
 	// Create pool_metrics table
 	metricsQuery := `
@@ -101,7 +124,12 @@ func (s *SQLiteStorage) migrate() error {
 		pending INTEGER NOT NULL DEFAULT 0,
 		max INTEGER NOT NULL DEFAULT 0,
 		timeout INTEGER DEFAULT 0,
+		timeout_delta INTEGER DEFAULT 0,
+		timeout_rate REAL DEFAULT 0,
 		acquire_p99 REAL DEFAULT 0,
+		acquire_p50 REAL DEFAULT 0,
+		acquire_p95 REAL DEFAULT 0,
+		acquire_max REAL DEFAULT 0,
 		heap_used INTEGER DEFAULT 0,
 		heap_max INTEGER DEFAULT 0,
 		non_heap_used INTEGER DEFAULT 0,
@@ -112,6 +140,13 @@ func (s *SQLiteStorage) migrate() error {
 		gc_time REAL DEFAULT 0,
 		young_gc_count INTEGER DEFAULT 0,
 		old_gc_count INTEGER DEFAULT 0,
+		gc_count_delta INTEGER DEFAULT 0,
+		gc_time_delta REAL DEFAULT 0,
+		young_gc_count_delta INTEGER DEFAULT 0,
+		old_gc_count_delta INTEGER DEFAULT 0,
+		gc_pauses_per_min REAL DEFAULT 0,
+		gc_pause_ms_per_min REAL DEFAULT 0,
+		derived TEXT,
 		timestamp DATETIME NOT NULL
 	);
 
@@ -142,7 +177,14 @@ func (s *SQLiteStorage) migrate() error {
 		resolved_at DATETIME,
 		notified_at DATETIME,
 		channels TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		-- Columns below are also added by migrateAlertColumns, in the same order
+		acknowledged_at DATETIME,
+		acknowledged_by TEXT NOT NULL DEFAULT '',
+		flapping INTEGER NOT NULL DEFAULT 0,
+		escalated_from TEXT NOT NULL DEFAULT '',
+		escalated_at DATETIME,
+		snapshot TEXT
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_alerts_target
@@ -166,10 +208,27 @@ func (s *SQLiteStorage) migrate() error {
 
 	CREATE INDEX IF NOT EXISTS idx_alerts_status_rule
 	ON alerts(status, rule_name);
+
+	-- Indexes for QueryAlerts filters sorted by time
+	CREATE INDEX IF NOT EXISTS idx_alerts_fired
+	ON alerts(fired_at DESC);
+
+	CREATE INDEX IF NOT EXISTS idx_alerts_target_fired
+	ON alerts(target_name, fired_at DESC);
+
+	CREATE INDEX IF NOT EXISTS idx_alerts_severity_fired
+	ON alerts(severity, fired_at DESC);
 	`
 	if _, err := s.db.Exec(alertsQuery); err != nil {
 		return err
 	}
+	s.migrateAlertColumns()
+	if err := s.migrateAlertSearch(); err != nil {
+		return err
+	}
+	if err := s.migrateAlertVersion(); err != nil {
+		return err
+	}
 
 	// Migration: add columns if they don't exist
 	s.runMigration()
@@ -195,6 +254,18 @@ func (s *SQLiteStorage) runMigration() {
 		{"gc_time", "REAL DEFAULT 0"},
 		{"young_gc_count", "INTEGER DEFAULT 0"},
 		{"old_gc_count", "INTEGER DEFAULT 0"},
+		{"derived", "TEXT"},
+		{"timeout_delta", "INTEGER DEFAULT 0"},
+		{"timeout_rate", "REAL DEFAULT 0"},
+		{"gc_count_delta", "INTEGER DEFAULT 0"},
+		{"gc_time_delta", "REAL DEFAULT 0"},
+		{"young_gc_count_delta", "INTEGER DEFAULT 0"},
+		{"old_gc_count_delta", "INTEGER DEFAULT 0"},
+		{"gc_pauses_per_min", "REAL DEFAULT 0"},
+		{"gc_pause_ms_per_min", "REAL DEFAULT 0"},
+		{"acquire_p50", "REAL DEFAULT 0"},
+		{"acquire_p95", "REAL DEFAULT 0"},
+		{"acquire_max", "REAL DEFAULT 0"},
 	}
 
 	for _, col := range columns {
@@ -217,7 +288,15 @@ func (s *SQLiteStorage) runMigration() {
 	}
 }
 
-func (s *SQLiteStorage) Save(metrics *models.PoolMetrics) error {
+const insertMetricsQuery = `
+	INSERT INTO pool_metrics (target_name, instance_name, status, active, idle, pending, max, timeout, timeout_delta, timeout_rate, acquire_p99, acquire_p50, acquire_p95, acquire_max,
+		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count,
+		gc_count_delta, gc_time_delta, young_gc_count_delta, old_gc_count_delta, gc_pauses_per_min, gc_pause_ms_per_min, derived, timestamp)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+// insertMetricsArgs returns the insertMetricsQuery arguments with default values applied
+func insertMetricsArgs(metrics *models.PoolMetrics) []interface{} {
 	// Default values
 	instanceName := metrics.InstanceName
 	if instanceName == "" {
@@ -228,12 +307,7 @@ func (s *SQLiteStorage) Save(metrics *models.PoolMetrics) error {
 		status = models.StatusHealthy
 	}
 
-	query := `
-	INSERT INTO pool_metrics (target_name, instance_name, status, active, idle, pending, max, timeout, acquire_p99,
-		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count, timestamp)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	result, err := s.db.Exec(query,
+	return []interface{}{
 		metrics.TargetName,
 		instanceName,
 		status,
@@ -242,7 +316,12 @@ func (s *SQLiteStorage) Save(metrics *models.PoolMetrics) error {
 		metrics.Pending,
 		metrics.Max,
 		metrics.Timeout,
+		metrics.TimeoutDelta,
+		metrics.TimeoutRate,
 		metrics.AcquireP99,
+		metrics.AcquireP50,
+		metrics.AcquireP95,
+		metrics.AcquireMax,
 		metrics.HeapUsed,
 		metrics.HeapMax,
 		metrics.NonHeapUsed,
@@ -253,8 +332,75 @@ func (s *SQLiteStorage) Save(metrics *models.PoolMetrics) error {
 		metrics.GcTime,
 		metrics.YoungGcCount,
 		metrics.OldGcCount,
+		metrics.GcCountDelta,
+		metrics.GcTimeDelta,
+		metrics.YoungGcCountDelta,
+		metrics.OldGcCountDelta,
+		metrics.GcPausesPerMin,
+		metrics.GcPauseMsPerMin,
+		encodeDerived(metrics.Derived),
 		metrics.Timestamp,
-	)
+	}
+}
+
+// encodeDerived stores derived metrics as JSON, NULL when there are none
+func encodeDerived(derived map[string]float64) interface{} {
+	if len(derived) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(derived)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// scanMetrics scans a pool_metrics row selected with the derived column before timestamp
+func scanMetrics(scanner interface{ Scan(...interface{}) error }) (*models.PoolMetrics, error) {
+	var m models.PoolMetrics
+	var derived sql.NullString
+	if err := scanner.Scan(&m.ID, &m.TargetName, &m.InstanceName, &m.Status, &m.Active, &m.Idle, &m.Pending, &m.Max, &m.Timeout, &m.TimeoutDelta, &m.TimeoutRate, &m.AcquireP99, &m.AcquireP50, &m.AcquireP95, &m.AcquireMax,
+		&m.HeapUsed, &m.HeapMax, &m.NonHeapUsed, &m.NonHeapMax, &m.ThreadsLive, &m.CpuUsage, &m.GcCount, &m.GcTime, &m.YoungGcCount, &m.OldGcCount,
+		&m.GcCountDelta, &m.GcTimeDelta, &m.YoungGcCountDelta, &m.OldGcCountDelta, &m.GcPausesPerMin, &m.GcPauseMsPerMin,
+		&derived, &m.Timestamp); err != nil {
+		return nil, err
+	}
+	m.SetAvgGcPause()
+	if derived.String != "" {
+		// A malformed value only loses the derived metrics of this row
+		_ = json.Unmarshal([]byte(derived.String), &m.Derived)
+	}
+	return &m, nil
+}
+
+// storedTimeLayouts are the formats the driver writes time values in, time.String first
+var storedTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999-07:00",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+}
+
+// parseStoredTime parses a time column read through an aggregate, which the driver
+// returns as text rather than time.Time
+func parseStoredTime(value string) (time.Time, error) {
+	// Values written from time.Now() carry the monotonic clock reading
+	if i := strings.Index(value, " m="); i >= 0 {
+		value = value[:i]
+	}
+	for _, layout := range storedTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid stored time '%s'", value)
+}
+
+func (s *SQLiteStorage) Save(ctx context.Context, metrics *models.PoolMetrics) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, insertMetricsQuery, insertMetricsArgs(metrics)...)
 	if err != nil {
 		return err
 	}
@@ -266,56 +412,93 @@ func (s *SQLiteStorage) Save(metrics *models.PoolMetrics) error {
 	return nil
 }
 
-func (s *SQLiteStorage) GetLatest(targetName string) (*models.PoolMetrics, error) {
+// SaveBatch stores several metrics records in a single transaction
+func (s *SQLiteStorage) SaveBatch(ctx context.Context, metrics []models.PoolMetrics) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, insertMetricsQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := range metrics {
+		result, err := stmt.ExecContext(ctx, insertMetricsArgs(&metrics[i])...)
+		if err != nil {
+			return err
+		}
+		if id, err := result.LastInsertId(); err == nil {
+			metrics[i].ID = id
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) GetLatest(ctx context.Context, targetName string) (*models.PoolMetrics, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-	SELECT id, target_name, instance_name, status, active, idle, pending, max, timeout, acquire_p99,
-		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count, timestamp
+	SELECT id, target_name, instance_name, status, active, idle, pending, max, timeout, timeout_delta, timeout_rate, acquire_p99, acquire_p50, acquire_p95, acquire_max,
+		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count,
+		gc_count_delta, gc_time_delta, young_gc_count_delta, old_gc_count_delta, gc_pauses_per_min, gc_pause_ms_per_min, derived, timestamp
 	FROM pool_metrics
 	WHERE target_name = ?
 	ORDER BY timestamp DESC
 	LIMIT 1
 	`
-	row := s.db.QueryRow(query, targetName)
+	row := s.db.QueryRowContext(ctx, query, targetName)
 
-	var m models.PoolMetrics
-	err := row.Scan(&m.ID, &m.TargetName, &m.InstanceName, &m.Status, &m.Active, &m.Idle, &m.Pending, &m.Max, &m.Timeout, &m.AcquireP99,
-		&m.HeapUsed, &m.HeapMax, &m.NonHeapUsed, &m.NonHeapMax, &m.ThreadsLive, &m.CpuUsage, &m.GcCount, &m.GcTime, &m.YoungGcCount, &m.OldGcCount, &m.Timestamp)
+	m, err := scanMetrics(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &m, nil
+	return m, nil
 }
 
-func (s *SQLiteStorage) GetLatestByInstance(targetName, instanceName string) (*models.PoolMetrics, error) {
+func (s *SQLiteStorage) GetLatestByInstance(ctx context.Context, targetName, instanceName string) (*models.PoolMetrics, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-	SELECT id, target_name, instance_name, status, active, idle, pending, max, timeout, acquire_p99,
-		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count, timestamp
+	SELECT id, target_name, instance_name, status, active, idle, pending, max, timeout, timeout_delta, timeout_rate, acquire_p99, acquire_p50, acquire_p95, acquire_max,
+		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count,
+		gc_count_delta, gc_time_delta, young_gc_count_delta, old_gc_count_delta, gc_pauses_per_min, gc_pause_ms_per_min, derived, timestamp
 	FROM pool_metrics
 	WHERE target_name = ? AND instance_name = ?
 	ORDER BY timestamp DESC
 	LIMIT 1
 	`
-	row := s.db.QueryRow(query, targetName, instanceName)
+	row := s.db.QueryRowContext(ctx, query, targetName, instanceName)
 
-	var m models.PoolMetrics
-	err := row.Scan(&m.ID, &m.TargetName, &m.InstanceName, &m.Status, &m.Active, &m.Idle, &m.Pending, &m.Max, &m.Timeout, &m.AcquireP99,
-		&m.HeapUsed, &m.HeapMax, &m.NonHeapUsed, &m.NonHeapMax, &m.ThreadsLive, &m.CpuUsage, &m.GcCount, &m.GcTime, &m.YoungGcCount, &m.OldGcCount, &m.Timestamp)
+	m, err := scanMetrics(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &m, nil
+	return m, nil
 }
 
-func (s *SQLiteStorage) GetLatestAllInstances(targetName string) ([]models.PoolMetrics, error) {
+func (s *SQLiteStorage) GetLatestAllInstances(ctx context.Context, targetName string) ([]models.PoolMetrics, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-	SELECT p.id, p.target_name, p.instance_name, p.status, p.active, p.idle, p.pending, p.max, p.timeout, p.acquire_p99,
-		p.heap_used, p.heap_max, p.non_heap_used, p.non_heap_max, p.threads_live, p.cpu_usage, p.gc_count, p.gc_time, p.young_gc_count, p.old_gc_count, p.timestamp
+	SELECT p.id, p.target_name, p.instance_name, p.status, p.active, p.idle, p.pending, p.max, p.timeout, p.timeout_delta, p.timeout_rate, p.acquire_p99, p.acquire_p50, p.acquire_p95, p.acquire_max,
+		p.heap_used, p.heap_max, p.non_heap_used, p.non_heap_max, p.threads_live, p.cpu_usage, p.gc_count, p.gc_time, p.young_gc_count, p.old_gc_count,
+		p.gc_count_delta, p.gc_time_delta, p.young_gc_count_delta, p.old_gc_count_delta, p.gc_pauses_per_min, p.gc_pause_ms_per_min, p.derived, p.timestamp
 	FROM pool_metrics p
 	INNER JOIN (
 		SELECT instance_name, MAX(timestamp) as max_ts
@@ -326,7 +509,7 @@ func (s *SQLiteStorage) GetLatestAllInstances(targetName string) ([]models.PoolM
 	WHERE p.target_name = ?
 	ORDER BY p.instance_name
 	`
-	rows, err := s.db.Query(query, targetName, targetName)
+	rows, err := s.db.QueryContext(ctx, query, targetName, targetName)
 	if err != nil {
 		return nil, err
 	}
@@ -334,25 +517,124 @@ func (s *SQLiteStorage) GetLatestAllInstances(targetName string) ([]models.PoolM
 
 	var results []models.PoolMetrics
 	for rows.Next() {
-		var m models.PoolMetrics
-		if err := rows.Scan(&m.ID, &m.TargetName, &m.InstanceName, &m.Status, &m.Active, &m.Idle, &m.Pending, &m.Max, &m.Timeout, &m.AcquireP99,
-			&m.HeapUsed, &m.HeapMax, &m.NonHeapUsed, &m.NonHeapMax, &m.ThreadsLive, &m.CpuUsage, &m.GcCount, &m.GcTime, &m.YoungGcCount, &m.OldGcCount, &m.Timestamp); err != nil {
+		m, err := scanMetrics(rows)
+		if err != nil {
 			return nil, err
 		}
-		results = append(results, m)
+		results = append(results, *m)
 	}
 	return results, rows.Err()
 }
 
-func (s *SQLiteStorage) GetHistory(targetName string, from, to time.Time) ([]models.PoolMetrics, error) {
+func (s *SQLiteStorage) GetLatestTimestamps(ctx context.Context, targetName string) (map[string]time.Time, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// Walks the instances through the target/instance/time index, one seek per instance,
+	// instead of grouping every sample of the target
+	rows, err := s.db.QueryContext(ctx, `
+	WITH RECURSIVE instances(name) AS (
+		SELECT MIN(instance_name) FROM pool_metrics WHERE target_name = ?1
+		UNION ALL
+		SELECT (SELECT MIN(instance_name) FROM pool_metrics WHERE target_name = ?1 AND instance_name > instances.name)
+		FROM instances
+		WHERE name IS NOT NULL
+	)
+	SELECT name, (SELECT MAX(timestamp) FROM pool_metrics WHERE target_name = ?1 AND instance_name = instances.name)
+	FROM instances
+	WHERE name IS NOT NULL
+	`, targetName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	latest := make(map[string]time.Time)
+	for rows.Next() {
+		var instance, value string
+		if err := rows.Scan(&instance, &value); err != nil {
+			return nil, err
+		}
+		ts, err := parseStoredTime(value)
+		if err != nil {
+			return nil, err
+		}
+		latest[instance] = ts
+	}
+	return latest, rows.Err()
+}
+
+func (s *SQLiteStorage) GetFleetSnapshot(ctx context.Context, now, baseline time.Time, lookback time.Duration) ([]models.PoolMetrics, []models.PoolMetrics, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// snapshot 0 is the window before now, 1 the window before baseline
 	query := `
-	SELECT id, target_name, instance_name, status, active, idle, pending, max, timeout, acquire_p99,
-		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count, timestamp
+	SELECT snapshot, id, target_name, instance_name, status, active, idle, pending, max, timeout, timeout_delta, timeout_rate, acquire_p99, acquire_p50, acquire_p95, acquire_max,
+		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count,
+		gc_count_delta, gc_time_delta, young_gc_count_delta, old_gc_count_delta, gc_pauses_per_min, gc_pause_ms_per_min, derived, timestamp
+	FROM (
+		SELECT CASE WHEN timestamp > ? THEN 0 ELSE 1 END AS snapshot, *,
+			ROW_NUMBER() OVER (
+				PARTITION BY target_name, instance_name, CASE WHEN timestamp > ? THEN 0 ELSE 1 END
+				ORDER BY timestamp DESC
+			) AS rn
+		FROM pool_metrics
+		WHERE (timestamp > ? AND timestamp <= ?) OR (timestamp > ? AND timestamp <= ?)
+	)
+	WHERE rn = 1
+	ORDER BY target_name, instance_name
+	`
+	from := now.Add(-lookback)
+	rows, err := s.db.QueryContext(ctx, query, from, from, from, now, baseline.Add(-lookback), baseline)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var current, previous []models.PoolMetrics
+	for rows.Next() {
+		var snapshot int
+		m, err := scanMetrics(snapshotScanner{rows, &snapshot})
+		if err != nil {
+			return nil, nil, err
+		}
+		if snapshot == 0 {
+			current = append(current, *m)
+		} else {
+			previous = append(previous, *m)
+		}
+	}
+	return current, previous, rows.Err()
+}
+
+// snapshotScanner scans a leading snapshot column before the metrics columns
+type snapshotScanner struct {
+	rows     *sql.Rows
+	snapshot *int
+}
+
+func (s snapshotScanner) Scan(dest ...interface{}) error {
+	return s.rows.Scan(append([]interface{}{s.snapshot}, dest...)...)
+}
+
+func (s *SQLiteStorage) GetHistory(ctx context.Context, targetName string, from, to time.Time) ([]models.PoolMetrics, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if table := s.historySource(ctx, targetName, from, to); table != nil {
+		return s.getRollupHistory(ctx, table, targetName, "", from, to)
+	}
+
+	query := `
+	SELECT id, target_name, instance_name, status, active, idle, pending, max, timeout, timeout_delta, timeout_rate, acquire_p99, acquire_p50, acquire_p95, acquire_max,
+		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count,
+		gc_count_delta, gc_time_delta, young_gc_count_delta, old_gc_count_delta, gc_pauses_per_min, gc_pause_ms_per_min, derived, timestamp
 	FROM pool_metrics
 	WHERE target_name = ? AND timestamp BETWEEN ? AND ?
 	ORDER BY timestamp ASC
 	`
-	rows, err := s.db.Query(query, targetName, from, to)
+	rows, err := s.db.QueryContext(ctx, query, targetName, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -360,25 +642,32 @@ func (s *SQLiteStorage) GetHistory(targetName string, from, to time.Time) ([]mod
 
 	var results []models.PoolMetrics
 	for rows.Next() {
-		var m models.PoolMetrics
-		if err := rows.Scan(&m.ID, &m.TargetName, &m.InstanceName, &m.Status, &m.Active, &m.Idle, &m.Pending, &m.Max, &m.Timeout, &m.AcquireP99,
-			&m.HeapUsed, &m.HeapMax, &m.NonHeapUsed, &m.NonHeapMax, &m.ThreadsLive, &m.CpuUsage, &m.GcCount, &m.GcTime, &m.YoungGcCount, &m.OldGcCount, &m.Timestamp); err != nil {
+		m, err := scanMetrics(rows)
+		if err != nil {
 			return nil, err
 		}
-		results = append(results, m)
+		results = append(results, *m)
 	}
 	return results, rows.Err()
 }
 
-func (s *SQLiteStorage) GetHistoryByInstance(targetName, instanceName string, from, to time.Time) ([]models.PoolMetrics, error) {
+func (s *SQLiteStorage) GetHistoryByInstance(ctx context.Context, targetName, instanceName string, from, to time.Time) ([]models.PoolMetrics, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if table := s.historySource(ctx, targetName, from, to); table != nil {
+		return s.getRollupHistory(ctx, table, targetName, instanceName, from, to)
+	}
+
 	query := `
-	SELECT id, target_name, instance_name, status, active, idle, pending, max, timeout, acquire_p99,
-		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count, timestamp
+	SELECT id, target_name, instance_name, status, active, idle, pending, max, timeout, timeout_delta, timeout_rate, acquire_p99, acquire_p50, acquire_p95, acquire_max,
+		heap_used, heap_max, non_heap_used, non_heap_max, threads_live, cpu_usage, gc_count, gc_time, young_gc_count, old_gc_count,
+		gc_count_delta, gc_time_delta, young_gc_count_delta, old_gc_count_delta, gc_pauses_per_min, gc_pause_ms_per_min, derived, timestamp
 	FROM pool_metrics
 	WHERE target_name = ? AND instance_name = ? AND timestamp BETWEEN ? AND ?
 	ORDER BY timestamp ASC
 	`
-	rows, err := s.db.Query(query, targetName, instanceName, from, to)
+	rows, err := s.db.QueryContext(ctx, query, targetName, instanceName, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -386,19 +675,21 @@ func (s *SQLiteStorage) GetHistoryByInstance(targetName, instanceName string, fr
 
 	var results []models.PoolMetrics
 	for rows.Next() {
-		var m models.PoolMetrics
-		if err := rows.Scan(&m.ID, &m.TargetName, &m.InstanceName, &m.Status, &m.Active, &m.Idle, &m.Pending, &m.Max, &m.Timeout, &m.AcquireP99,
-			&m.HeapUsed, &m.HeapMax, &m.NonHeapUsed, &m.NonHeapMax, &m.ThreadsLive, &m.CpuUsage, &m.GcCount, &m.GcTime, &m.YoungGcCount, &m.OldGcCount, &m.Timestamp); err != nil {
+		m, err := scanMetrics(rows)
+		if err != nil {
 			return nil, err
 		}
-		results = append(results, m)
+		results = append(results, *m)
 	}
 	return results, rows.Err()
 }
 
-func (s *SQLiteStorage) GetInstances(targetName string) ([]string, error) {
+func (s *SQLiteStorage) GetInstances(ctx context.Context, targetName string) ([]string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT DISTINCT instance_name FROM pool_metrics WHERE target_name = ? ORDER BY instance_name`
-	rows, err := s.db.Query(query, targetName)
+	rows, err := s.db.QueryContext(ctx, query, targetName)
 	if err != nil {
 		return nil, err
 	}
@@ -415,9 +706,12 @@ func (s *SQLiteStorage) GetInstances(targetName string) ([]string, error) {
 	return instances, rows.Err()
 }
 
-func (s *SQLiteStorage) GetTargets() ([]string, error) {
+func (s *SQLiteStorage) GetTargets(ctx context.Context) ([]string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT DISTINCT target_name FROM pool_metrics`
-	rows, err := s.db.Query(query)
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -434,27 +728,59 @@ func (s *SQLiteStorage) GetTargets() ([]string, error) {
 	return targets, rows.Err()
 }
 
-func (s *SQLiteStorage) Cleanup(olderThan time.Time) (int64, error) {
+func (s *SQLiteStorage) Cleanup(ctx context.Context, olderThan time.Time, excludeTargets ...string) (int64, error) {
 	query := `DELETE FROM pool_metrics WHERE timestamp < ?`
-	result, err := s.db.Exec(query, olderThan)
+	args := []interface{}{olderThan}
+	if len(excludeTargets) > 0 {
+		query += ` AND target_name NOT IN (?` + strings.Repeat(`, ?`, len(excludeTargets)-1) + `)`
+		for _, name := range excludeTargets {
+			args = append(args, name)
+		}
+	}
+	result, err := s.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected()
 }
 
+func (s *SQLiteStorage) CleanupTarget(ctx context.Context, targetName string, olderThan time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM pool_metrics WHERE target_name = ? AND timestamp < ?`, targetName, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Close checkpoints the WAL into the database file and closes it
+// so the file is complete on its own, e.g., when copied out of a stopped container
 func (s *SQLiteStorage) Close() error {
+	if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		log.Printf("Failed to checkpoint WAL on close: %v", err)
+	}
 	return s.db.Close()
 }
 
 // Alert-related methods
 
-func (s *SQLiteStorage) SaveAlert(alert *models.Alert) error {
+func (s *SQLiteStorage) SaveAlert(ctx context.Context, alert *models.Alert) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-	INSERT INTO alerts (target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO alerts (target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels, flapping,
+		escalated_from, escalated_at, snapshot)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := s.db.Exec(query,
+	var snapshot sql.NullString
+	if len(alert.Snapshot) > 0 {
+		data, err := json.Marshal(alert.Snapshot)
+		if err != nil {
+			return err
+		}
+		snapshot = sql.NullString{String: string(data), Valid: true}
+	}
+	result, err := s.db.ExecContext(ctx, query,
 		alert.TargetName,
 		alert.InstanceName,
 		alert.RuleName,
@@ -465,6 +791,10 @@ func (s *SQLiteStorage) SaveAlert(alert *models.Alert) error {
 		alert.ResolvedAt,
 		alert.NotifiedAt,
 		alert.Channels,
+		alert.Flapping,
+		alert.EscalatedFrom,
+		alert.EscalatedAt,
+		snapshot,
 	)
 	if err != nil {
 		return err
@@ -477,7 +807,10 @@ func (s *SQLiteStorage) SaveAlert(alert *models.Alert) error {
 	return nil
 }
 
-func (s *SQLiteStorage) UpdateAlert(alert *models.Alert) error {
+func (s *SQLiteStorage) UpdateAlert(ctx context.Context, alert *models.Alert) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
 	UPDATE alerts SET
 		severity = ?,
@@ -485,31 +818,50 @@ func (s *SQLiteStorage) UpdateAlert(alert *models.Alert) error {
 		status = ?,
 		resolved_at = ?,
 		notified_at = ?,
-		channels = ?
+		channels = ?,
+		flapping = ?,
+		escalated_from = ?,
+		escalated_at = ?
 	WHERE id = ?
 	`
-	_, err := s.db.Exec(query,
+	_, err := s.db.ExecContext(ctx, query,
 		alert.Severity,
 		alert.Message,
 		alert.Status,
 		alert.ResolvedAt,
 		alert.NotifiedAt,
 		alert.Channels,
+		alert.Flapping,
+		alert.EscalatedFrom,
+		alert.EscalatedAt,
 		alert.ID,
 	)
 	return err
 }
 
-func (s *SQLiteStorage) GetAlert(id int64) (*models.Alert, error) {
+func (s *SQLiteStorage) MarkAlertNotified(ctx context.Context, id int64, notifiedAt time.Time, channels string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `UPDATE alerts SET notified_at = ?, channels = ? WHERE id = ?`, notifiedAt, channels, id)
+	return err
+}
+
+func (s *SQLiteStorage) GetAlert(ctx context.Context, id int64) (*models.Alert, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-	SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels
+	SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels,
+		acknowledged_at, acknowledged_by, flapping, escalated_from, escalated_at
 	FROM alerts
 	WHERE id = ?
 	`
-	row := s.db.QueryRow(query, id)
+	row := s.db.QueryRowContext(ctx, query, id)
 
 	var a models.Alert
-	err := row.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels)
+	err := row.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels,
+		&a.AcknowledgedAt, &a.AcknowledgedBy, &a.Flapping, &a.EscalatedFrom, &a.EscalatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -519,13 +871,17 @@ func (s *SQLiteStorage) GetAlert(id int64) (*models.Alert, error) {
 	return &a, nil
 }
 
-func (s *SQLiteStorage) GetAlerts(status string, limit int) ([]models.Alert, error) {
+func (s *SQLiteStorage) GetAlerts(ctx context.Context, status string, limit int) ([]models.Alert, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	var query string
 	var args []interface{}
 
 	if status != "" {
 		query = `
-		SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels
+		SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels,
+			acknowledged_at, acknowledged_by, flapping, escalated_from, escalated_at
 		FROM alerts
 		WHERE status = ?
 		ORDER BY fired_at DESC
@@ -534,7 +890,8 @@ func (s *SQLiteStorage) GetAlerts(status string, limit int) ([]models.Alert, err
 		args = []interface{}{status, limit}
 	} else {
 		query = `
-		SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels
+		SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels,
+			acknowledged_at, acknowledged_by, flapping, escalated_from, escalated_at
 		FROM alerts
 		ORDER BY fired_at DESC
 		LIMIT ?
@@ -542,7 +899,7 @@ func (s *SQLiteStorage) GetAlerts(status string, limit int) ([]models.Alert, err
 		args = []interface{}{limit}
 	}
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -551,7 +908,8 @@ func (s *SQLiteStorage) GetAlerts(status string, limit int) ([]models.Alert, err
 	var results []models.Alert
 	for rows.Next() {
 		var a models.Alert
-		if err := rows.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels); err != nil {
+		if err := rows.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels,
+			&a.AcknowledgedAt, &a.AcknowledgedBy, &a.Flapping, &a.EscalatedFrom, &a.EscalatedAt); err != nil {
 			return nil, err
 		}
 		results = append(results, a)
@@ -559,18 +917,64 @@ func (s *SQLiteStorage) GetAlerts(status string, limit int) ([]models.Alert, err
 	return results, rows.Err()
 }
 
-func (s *SQLiteStorage) GetActiveAlertByRule(targetName, instanceName, ruleName string) (*models.Alert, error) {
+func (s *SQLiteStorage) GetAlertsByTarget(ctx context.Context, targetName string, from, to time.Time) ([]models.Alert, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-	SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels
+	SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels,
+		acknowledged_at, acknowledged_by, flapping, escalated_from, escalated_at
 	FROM alerts
-	WHERE target_name = ? AND instance_name = ? AND rule_name = ? AND status = 'fired'
+	WHERE target_name = ? AND (fired_at BETWEEN ? AND ? OR status = 'fired')
+	ORDER BY fired_at DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, targetName, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.Alert
+	for rows.Next() {
+		var a models.Alert
+		if err := rows.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels,
+			&a.AcknowledgedAt, &a.AcknowledgedBy, &a.Flapping, &a.EscalatedFrom, &a.EscalatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, a)
+	}
+	return results, rows.Err()
+}
+
+func (s *SQLiteStorage) GetActiveAlertByRule(ctx context.Context, targetName, instanceName, ruleName string) (*models.Alert, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.getAlertByRule(ctx, targetName, instanceName, ruleName, "status = 'fired'")
+}
+
+func (s *SQLiteStorage) GetSuppressedAlertByRule(ctx context.Context, targetName, instanceName, ruleName string) (*models.Alert, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.getAlertByRule(ctx, targetName, instanceName, ruleName, "status = 'suppressed' AND resolved_at IS NULL")
+}
+
+// getAlertByRule returns the latest alert of a target/instance/rule matching the status condition
+func (s *SQLiteStorage) getAlertByRule(ctx context.Context, targetName, instanceName, ruleName, statusCond string) (*models.Alert, error) {
+	query := `
+	SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels,
+		acknowledged_at, acknowledged_by, flapping, escalated_from, escalated_at
+	FROM alerts
+	WHERE target_name = ? AND instance_name = ? AND rule_name = ? AND ` + statusCond + `
 	ORDER BY fired_at DESC
 	LIMIT 1
 	`
-	row := s.db.QueryRow(query, targetName, instanceName, ruleName)
+	row := s.db.QueryRowContext(ctx, query, targetName, instanceName, ruleName)
 
 	var a models.Alert
-	err := row.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels)
+	err := row.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels,
+		&a.AcknowledgedAt, &a.AcknowledgedBy, &a.Flapping, &a.EscalatedFrom, &a.EscalatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -580,7 +984,10 @@ func (s *SQLiteStorage) GetActiveAlertByRule(targetName, instanceName, ruleName
 	return &a, nil
 }
 
-func (s *SQLiteStorage) GetAlertStats() (*models.AlertStats, error) {
+func (s *SQLiteStorage) GetAlertStats(ctx context.Context) (*models.AlertStats, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	stats := &models.AlertStats{
 		BySeverity: make(map[string]int),
 		ByTarget:   make(map[string]int),
@@ -596,8 +1003,10 @@ func (s *SQLiteStorage) GetAlertStats() (*models.AlertStats, error) {
 		SELECT 'target', target_name, COUNT(*) FROM alerts WHERE status = 'fired' GROUP BY target_name
 		UNION ALL
 		SELECT 'rule', rule_name, COUNT(*) FROM alerts WHERE status = 'fired' GROUP BY rule_name
+		UNION ALL
+		SELECT 'flapping', '', COUNT(*) FROM alerts WHERE status = 'fired' AND flapping = 1
 	`
-	rows, err := s.db.Query(query)
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -624,19 +1033,28 @@ func (s *SQLiteStorage) GetAlertStats() (*models.AlertStats, error) {
 			stats.ByTarget[key] = count
 		case "rule":
 			stats.ByRule[key] = count
+		case "flapping":
+			stats.FlappingAlerts = count
 		}
 	}
 
 	return stats, nil
 }
 
-func (s *SQLiteStorage) CleanupAlerts(olderThan time.Time) (int64, error) {
-	query := `DELETE FROM alerts WHERE status = 'resolved' AND resolved_at < ?`
-	result, err := s.db.Exec(query, olderThan)
+func (s *SQLiteStorage) CleanupAlerts(ctx context.Context, olderThan time.Time) (int64, error) {
+	query := `DELETE FROM alerts WHERE status IN ('resolved', 'suppressed') AND resolved_at < ?`
+	result, err := s.db.ExecContext(ctx, query, olderThan)
 	if err != nil {
 		return 0, err
 	}
-	return result.RowsAffected()
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := s.cleanupAlertEscalations(ctx); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
 }
 
 // AlertRule-related methods
@@ -657,27 +1075,111 @@ func (s *SQLiteStorage) migrateAlertRules() error {
 	CREATE INDEX IF NOT EXISTS idx_alert_rules_name ON alert_rules(name);
 	CREATE INDEX IF NOT EXISTS idx_alert_rules_enabled ON alert_rules(enabled);
 	`
-	_, err := s.db.Exec(query)
-	return err
+	if _, err := s.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Add columns to tables created before rule routing, projects, labels, target scoping, config sync
+	// resolve conditions, escalation and repeat intervals
+	for _, col := range []string{"channels", "project", "labels", "targets", "target_groups", "origin", "resolve_condition", "escalate_condition", "escalate_severity", "repeat_interval"} {
+		var count int
+		err := s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('alert_rules') WHERE name=?`, col).Scan(&count)
+		if err == nil && count == 0 {
+			_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE alert_rules ADD COLUMN %s TEXT`, col))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *SQLiteStorage) SaveAlertRule(rule *models.AlertRule) error {
+// alertRuleColumns are the columns scanAlertRule reads
+const alertRuleColumns = `id, name, condition, resolve_condition, escalate_condition, escalate_severity, repeat_interval, severity, message, enabled, channels, project, labels, targets, target_groups, origin, created_at, updated_at`
+
+// scanAlertRule scans an alert rule row including its comma-separated channels, targets and groups,
+// project, JSON labels and origin; rules from before config sync were created through the API
+func scanAlertRule(scanner interface{ Scan(...interface{}) error }) (*models.AlertRule, error) {
+	var r models.AlertRule
+	var enabled int
+	var resolveCondition, escalateCondition, escalateSeverity, repeatInterval, channels, project, labels, targets, groups, origin sql.NullString
+	if err := scanner.Scan(&r.ID, &r.Name, &r.Condition, &resolveCondition, &escalateCondition, &escalateSeverity, &repeatInterval, &r.Severity, &r.Message, &enabled, &channels, &project, &labels, &targets, &groups, &origin, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	r.Enabled = enabled == 1
+	r.ResolveCondition = resolveCondition.String
+	r.EscalateCondition = escalateCondition.String
+	r.EscalateSeverity = escalateSeverity.String
+	r.RepeatInterval = repeatInterval.String
+	r.Project = project.String
+	r.Origin = origin.String
+	if r.Origin == "" {
+		r.Origin = models.RuleOriginAPI
+	}
+	if channels.Valid && channels.String != "" {
+		r.Channels = strings.Split(channels.String, ",")
+	}
+	if targets.String != "" {
+		r.Targets = strings.Split(targets.String, ",")
+	}
+	if groups.String != "" {
+		r.Groups = strings.Split(groups.String, ",")
+	}
+	if labels.String != "" {
+		if err := json.Unmarshal([]byte(labels.String), &r.Labels); err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+// encodeRuleLabels stores rule labels as JSON, or an empty string for unscoped rules
+func encodeRuleLabels(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(labels)
+	return string(data), err
+}
+
+func (s *SQLiteStorage) SaveAlertRule(ctx context.Context, rule *models.AlertRule) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	// Ensure table exists
 	if err := s.migrateAlertRules(); err != nil {
 		return err
 	}
 
+	labels, err := encodeRuleLabels(rule.Labels)
+	if err != nil {
+		return err
+	}
+
 	query := `
-	INSERT INTO alert_rules (name, condition, severity, message, enabled, created_at, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO alert_rules (name, condition, resolve_condition, escalate_condition, escalate_severity, repeat_interval, severity, message, enabled, channels, project, labels, targets, target_groups, origin, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
+	if rule.Origin == "" {
+		rule.Origin = models.RuleOriginAPI
+	}
 	now := time.Now()
-	result, err := s.db.Exec(query,
+	result, err := s.db.ExecContext(ctx, query,
 		rule.Name,
 		rule.Condition,
+		rule.ResolveCondition,
+		rule.EscalateCondition,
+		rule.EscalateSeverity,
+		rule.RepeatInterval,
 		rule.Severity,
 		rule.Message,
 		rule.Enabled,
+		strings.Join(rule.Channels, ","),
+		rule.Project,
+		labels,
+		strings.Join(rule.Targets, ","),
+		strings.Join(rule.Groups, ","),
+		rule.Origin,
 		now,
 		now,
 	)
@@ -694,24 +1196,55 @@ func (s *SQLiteStorage) SaveAlertRule(rule *models.AlertRule) error {
 	return nil
 }
 
-func (s *SQLiteStorage) UpdateAlertRule(rule *models.AlertRule) error {
+func (s *SQLiteStorage) UpdateAlertRule(ctx context.Context, rule *models.AlertRule) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	labels, err := encodeRuleLabels(rule.Labels)
+	if err != nil {
+		return err
+	}
+
 	query := `
 	UPDATE alert_rules SET
 		name = ?,
 		condition = ?,
+		resolve_condition = ?,
+		escalate_condition = ?,
+		escalate_severity = ?,
+		repeat_interval = ?,
 		severity = ?,
 		message = ?,
 		enabled = ?,
+		channels = ?,
+		project = ?,
+		labels = ?,
+		targets = ?,
+		target_groups = ?,
+		origin = ?,
 		updated_at = ?
 	WHERE id = ?
 	`
+	if rule.Origin == "" {
+		rule.Origin = models.RuleOriginAPI
+	}
 	now := time.Now()
-	_, err := s.db.Exec(query,
+	_, err = s.db.ExecContext(ctx, query,
 		rule.Name,
 		rule.Condition,
+		rule.ResolveCondition,
+		rule.EscalateCondition,
+		rule.EscalateSeverity,
+		rule.RepeatInterval,
 		rule.Severity,
 		rule.Message,
 		rule.Enabled,
+		strings.Join(rule.Channels, ","),
+		rule.Project,
+		labels,
+		strings.Join(rule.Targets, ","),
+		strings.Join(rule.Groups, ","),
+		rule.Origin,
 		now,
 		rule.ID,
 	)
@@ -721,50 +1254,53 @@ func (s *SQLiteStorage) UpdateAlertRule(rule *models.AlertRule) error {
 	return err
 }
 
-func (s *SQLiteStorage) DeleteAlertRule(id int64) error {
+func (s *SQLiteStorage) DeleteAlertRule(ctx context.Context, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM alert_rules WHERE id = ?`
-	_, err := s.db.Exec(query, id)
+	_, err := s.db.ExecContext(ctx, query, id)
 	return err
 }
 
-func (s *SQLiteStorage) GetAlertRule(id int64) (*models.AlertRule, error) {
+func (s *SQLiteStorage) GetAlertRule(ctx context.Context, id int64) (*models.AlertRule, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	// Ensure table exists
 	if err := s.migrateAlertRules(); err != nil {
 		return nil, err
 	}
 
 	query := `
-	SELECT id, name, condition, severity, message, enabled, created_at, updated_at
+	SELECT ` + alertRuleColumns + `
 	FROM alert_rules
 	WHERE id = ?
 	`
-	row := s.db.QueryRow(query, id)
+	row := s.db.QueryRowContext(ctx, query, id)
 
-	var r models.AlertRule
-	var enabled int
-	err := row.Scan(&r.ID, &r.Name, &r.Condition, &r.Severity, &r.Message, &enabled, &r.CreatedAt, &r.UpdatedAt)
+	r, err := scanAlertRule(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-	r.Enabled = enabled == 1
-	return &r, nil
+	return r, err
 }
 
-func (s *SQLiteStorage) GetAlertRules() ([]models.AlertRule, error) {
+func (s *SQLiteStorage) GetAlertRules(ctx context.Context) ([]models.AlertRule, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	// Ensure table exists
 	if err := s.migrateAlertRules(); err != nil {
 		return nil, err
 	}
 
 	query := `
-	SELECT id, name, condition, severity, message, enabled, created_at, updated_at
+	SELECT ` + alertRuleColumns + `
 	FROM alert_rules
 	ORDER BY created_at ASC
 	`
-	rows, err := s.db.Query(query)
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -772,45 +1308,40 @@ func (s *SQLiteStorage) GetAlertRules() ([]models.AlertRule, error) {
 
 	var results []models.AlertRule
 	for rows.Next() {
-		var r models.AlertRule
-		var enabled int
-		if err := rows.Scan(&r.ID, &r.Name, &r.Condition, &r.Severity, &r.Message, &enabled, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		r, err := scanAlertRule(rows)
+		if err != nil {
 			return nil, err
 		}
-		r.Enabled = enabled == 1
-		results = append(results, r)
+		results = append(results, *r)
 	}
 	return results, rows.Err()
 }
 
-func (s *SQLiteStorage) GetAlertRuleByName(name string) (*models.AlertRule, error) {
+func (s *SQLiteStorage) GetAlertRuleByName(ctx context.Context, name string) (*models.AlertRule, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	// Ensure table exists
 	if err := s.migrateAlertRules(); err != nil {
 		return nil, err
 	}
 
 	query := `
-	SELECT id, name, condition, severity, message, enabled, created_at, updated_at
+	SELECT ` + alertRuleColumns + `
 	FROM alert_rules
 	WHERE name = ?
 	`
-	row := s.db.QueryRow(query, name)
+	row := s.db.QueryRowContext(ctx, query, name)
 
-	var r models.AlertRule
-	var enabled int
-	err := row.Scan(&r.ID, &r.Name, &r.Condition, &r.Severity, &r.Message, &enabled, &r.CreatedAt, &r.UpdatedAt)
+	r, err := scanAlertRule(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-	r.Enabled = enabled == 1
-	return &r, nil
+	return r, err
 }
 
 // CreateBackup creates a backup of the database
-func (s *SQLiteStorage) CreateBackup(destPath string) error {
+func (s *SQLiteStorage) CreateBackup(ctx context.Context, destPath string) error {
 	// Sanitize path to prevent SQL injection
 	safePath, err := sanitizeSQLitePath(destPath)
 	if err != nil {
@@ -825,12 +1356,12 @@ func (s *SQLiteStorage) CreateBackup(destPath string) error {
 
 	// Use SQLite VACUUM INTO for online backup
 	query := fmt.Sprintf(`VACUUM INTO '%s'`, safePath)
-	_, err = s.db.Exec(query)
+	_, err = s.db.ExecContext(ctx, query)
 	return err
 }
 
 // RestoreBackup restores the database from a backup file
-func (s *SQLiteStorage) RestoreBackup(srcPath string) error {
+func (s *SQLiteStorage) RestoreBackup(ctx context.Context, srcPath string) error {
 	// Sanitize path to prevent SQL injection
 	safePath, err := sanitizeSQLitePath(srcPath)
 	if err != nil {
@@ -881,61 +1412,97 @@ func (s *SQLiteStorage) RestoreBackup(srcPath string) error {
 		return fmt.Errorf("backup file does not contain pondy data: %w", err)
 	}
 
-	// Delete existing data and import from backup
-	// Table names are hardcoded whitelist - safe from SQL injection
-	tables := []string{"pool_metrics", "alerts", "alert_rules"}
-	for _, table := range tables {
-		// Clear existing data using parameterized approach (table names whitelisted)
-		_, err := s.db.Exec(fmt.Sprintf("DELETE FROM %s", table))
-		if err != nil {
-			log.Printf("Warning: could not clear table %s: %v", table, err)
+	// Lazily created tables are created first, so backups that have them restore into them
+	for _, migrate := range []func() error{s.migrateAlertRules, s.migrateMaintenanceWindows, s.migrateSilences, s.migrateRollups, s.migrateNotificationLog, s.migrateViews, s.migrateAlertEscalations, s.migrateEvents, s.migrateAnnotations, s.migrateRecommendations, s.migrateHealthChecks, s.migrateInactiveInstances, s.migrateAuditLog} {
+		if err := migrate(); err != nil {
+			return fmt.Errorf("failed to prepare tables: %w", err)
 		}
 	}
 
-	// Attach backup database and copy data
-	_, err = s.db.Exec(fmt.Sprintf("ATTACH DATABASE '%s' AS backup", safePath))
-	if err != nil {
-		return fmt.Errorf("failed to attach backup: %w", err)
+	// Table names are hardcoded whitelist - safe from SQL injection
+	tables := []string{"pool_metrics", "alerts", "alert_rules", "maintenance_windows", "silences"}
+	for _, t := range rollupTables {
+		tables = append(tables, t.name)
 	}
-	defer s.db.Exec("DETACH DATABASE backup")
+	tables = append(tables, "notification_log", "views", "alert_escalations", "events", "annotations", "recommendations", "health_checks", "inactive_instances", "audit_log")
 
-	// Copy pool_metrics
-	_, err = s.db.Exec(`
-		INSERT INTO pool_metrics
-		SELECT * FROM backup.pool_metrics
-	`)
+	// A client disconnecting halfway must not cancel the restore, and ATTACH applies to one
+	// connection, so the restore runs on a dedicated connection in a single transaction
+	ctx = context.WithoutCancel(ctx)
+	conn, err := s.db.Conn(ctx)
 	if err != nil {
-		log.Printf("Warning: could not restore pool_metrics: %v", err)
+		return fmt.Errorf("failed to get connection: %w", err)
 	}
+	defer conn.Close()
 
-	// Copy alerts (if table exists in backup)
-	_, err = s.db.Exec(`
-		INSERT INTO alerts
-		SELECT * FROM backup.alerts
-	`)
-	if err != nil {
-		log.Printf("Warning: could not restore alerts: %v", err)
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("ATTACH DATABASE '%s' AS backup", safePath)); err != nil {
+		return fmt.Errorf("failed to attach backup: %w", err)
 	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE backup")
 
-	// Copy alert_rules (if table exists in backup)
-	_, err = s.db.Exec(`
-		INSERT INTO alert_rules
-		SELECT * FROM backup.alert_rules
-	`)
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Warning: could not restore alert_rules: %v", err)
+		return fmt.Errorf("failed to begin restore: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Copy maintenance_windows (if table exists in backup)
-	_, err = s.db.Exec(`
-		INSERT INTO maintenance_windows
-		SELECT * FROM backup.maintenance_windows
-	`)
+	// Existing data is replaced; tables missing from older backups are left empty
+	// Tables are qualified, as an unqualified name also resolves to the attached backup
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM main.%s", table)); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+
+		var exists int
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM backup.sqlite_master WHERE type='table' AND name=?", table).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to read backup tables: %w", err)
+		}
+		if exists == 0 {
+			continue
+		}
+
+		columns, err := restoreColumns(ctx, tx, table)
+		if err != nil {
+			return fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		query := fmt.Sprintf("INSERT INTO main.%[1]s (%[2]s) SELECT %[2]s FROM backup.%[1]s", table, columns)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// restoreColumns returns the quoted columns a table has both in the database and the attached backup
+// Columns are copied by name, as migrated tables and fresh ones may order them differently,
+// and columns added since the backup was taken keep their defaults
+func restoreColumns(ctx context.Context, tx *sql.Tx, table string) (string, error) {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT m.name FROM pragma_table_info(?, 'main') m
+	JOIN pragma_table_info(?, 'backup') b ON b.name = m.name
+	ORDER BY m.cid`, table, table)
 	if err != nil {
-		log.Printf("Warning: could not restore maintenance_windows: %v", err)
+		return "", err
 	}
+	defer rows.Close()
 
-	return nil
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		columns = append(columns, `"`+strings.ReplaceAll(name, `"`, `""`)+`"`)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("no columns in common")
+	}
+	return strings.Join(columns, ", "), nil
 }
 
 // MaintenanceWindow-related methods
@@ -958,28 +1525,80 @@ func (s *SQLiteStorage) migrateMaintenanceWindows() error {
 	CREATE INDEX IF NOT EXISTS idx_maintenance_windows_target ON maintenance_windows(target_name);
 	CREATE INDEX IF NOT EXISTS idx_maintenance_windows_time ON maintenance_windows(start_time, end_time);
 	`
-	_, err := s.db.Exec(query)
-	return err
+	if _, err := s.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Add columns to tables created before projects, cron schedules and target selectors
+	for _, col := range []struct{ name, def string }{
+		{"project", "TEXT"},
+		{"cron", "TEXT"},
+		{"duration_minutes", "INTEGER NOT NULL DEFAULT 0"},
+		{"targets", "TEXT"},
+		{"target_groups", "TEXT"},
+	} {
+		var count int
+		err := s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('maintenance_windows') WHERE name=?`, col.name).Scan(&count)
+		if err == nil && count == 0 {
+			_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE maintenance_windows ADD COLUMN %s %s`, col.name, col.def))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maintenanceWindowColumns are the columns read by scanMaintenanceWindow
+const maintenanceWindowColumns = `id, name, description, target_name, project, start_time, end_time, recurring, days_of_week, cron, duration_minutes, targets, target_groups, created_at, updated_at`
+
+// scanMaintenanceWindow scans a maintenance window row, mapping NULL text columns to empty strings
+func scanMaintenanceWindow(scanner interface{ Scan(...interface{}) error }) (*models.MaintenanceWindow, error) {
+	var w models.MaintenanceWindow
+	var description, targetName, project, daysOfWeek, cron, targets, groups sql.NullString
+	if err := scanner.Scan(&w.ID, &w.Name, &description, &targetName, &project, &w.StartTime, &w.EndTime, &w.Recurring, &daysOfWeek, &cron, &w.DurationMinutes, &targets, &groups, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if targets.String != "" {
+		w.Targets = strings.Split(targets.String, ",")
+	}
+	if groups.String != "" {
+		w.Groups = strings.Split(groups.String, ",")
+	}
+	w.Description = description.String
+	w.TargetName = targetName.String
+	w.Project = project.String
+	w.DaysOfWeek = daysOfWeek.String
+	w.Cron = cron.String
+	return &w, nil
 }
 
-func (s *SQLiteStorage) SaveMaintenanceWindow(window *models.MaintenanceWindow) error {
+func (s *SQLiteStorage) SaveMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	if err := s.migrateMaintenanceWindows(); err != nil {
 		return err
 	}
 
 	query := `
-	INSERT INTO maintenance_windows (name, description, target_name, start_time, end_time, recurring, days_of_week, created_at, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO maintenance_windows (name, description, target_name, project, start_time, end_time, recurring, days_of_week, cron, duration_minutes, targets, target_groups, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	now := time.Now()
-	result, err := s.db.Exec(query,
+	result, err := s.db.ExecContext(ctx, query,
 		window.Name,
 		window.Description,
 		window.TargetName,
+		window.Project,
 		window.StartTime,
 		window.EndTime,
 		window.Recurring,
 		window.DaysOfWeek,
+		window.Cron,
+		window.DurationMinutes,
+		strings.Join(window.Targets, ","),
+		strings.Join(window.Groups, ","),
 		now,
 		now,
 	)
@@ -996,28 +1615,41 @@ func (s *SQLiteStorage) SaveMaintenanceWindow(window *models.MaintenanceWindow)
 	return nil
 }
 
-func (s *SQLiteStorage) UpdateMaintenanceWindow(window *models.MaintenanceWindow) error {
+func (s *SQLiteStorage) UpdateMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
 	UPDATE maintenance_windows SET
 		name = ?,
 		description = ?,
 		target_name = ?,
+		project = ?,
 		start_time = ?,
 		end_time = ?,
 		recurring = ?,
 		days_of_week = ?,
+		cron = ?,
+		duration_minutes = ?,
+		targets = ?,
+		target_groups = ?,
 		updated_at = ?
 	WHERE id = ?
 	`
 	now := time.Now()
-	_, err := s.db.Exec(query,
+	_, err := s.db.ExecContext(ctx, query,
 		window.Name,
 		window.Description,
 		window.TargetName,
+		window.Project,
 		window.StartTime,
 		window.EndTime,
 		window.Recurring,
 		window.DaysOfWeek,
+		window.Cron,
+		window.DurationMinutes,
+		strings.Join(window.Targets, ","),
+		strings.Join(window.Groups, ","),
 		now,
 		window.ID,
 	)
@@ -1027,52 +1659,49 @@ func (s *SQLiteStorage) UpdateMaintenanceWindow(window *models.MaintenanceWindow
 	return err
 }
 
-func (s *SQLiteStorage) DeleteMaintenanceWindow(id int64) error {
+func (s *SQLiteStorage) DeleteMaintenanceWindow(ctx context.Context, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM maintenance_windows WHERE id = ?`
-	_, err := s.db.Exec(query, id)
+	_, err := s.db.ExecContext(ctx, query, id)
 	return err
 }
 
-func (s *SQLiteStorage) GetMaintenanceWindow(id int64) (*models.MaintenanceWindow, error) {
+func (s *SQLiteStorage) GetMaintenanceWindow(ctx context.Context, id int64) (*models.MaintenanceWindow, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	if err := s.migrateMaintenanceWindows(); err != nil {
 		return nil, err
 	}
 
 	query := `
-	SELECT id, name, description, target_name, start_time, end_time, recurring, days_of_week, created_at, updated_at
+	SELECT ` + maintenanceWindowColumns + `
 	FROM maintenance_windows
 	WHERE id = ?
 	`
-	row := s.db.QueryRow(query, id)
-
-	var w models.MaintenanceWindow
-	var description, targetName, daysOfWeek sql.NullString
-	err := row.Scan(&w.ID, &w.Name, &description, &targetName, &w.StartTime, &w.EndTime, &w.Recurring, &daysOfWeek, &w.CreatedAt, &w.UpdatedAt)
+	w, err := scanMaintenanceWindow(s.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-
-	w.Description = description.String
-	w.TargetName = targetName.String
-	w.DaysOfWeek = daysOfWeek.String
-
-	return &w, nil
+	return w, err
 }
 
-func (s *SQLiteStorage) GetAllMaintenanceWindows() ([]models.MaintenanceWindow, error) {
+func (s *SQLiteStorage) GetAllMaintenanceWindows(ctx context.Context) ([]models.MaintenanceWindow, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	if err := s.migrateMaintenanceWindows(); err != nil {
 		return nil, err
 	}
 
 	query := `
-	SELECT id, name, description, target_name, start_time, end_time, recurring, days_of_week, created_at, updated_at
+	SELECT ` + maintenanceWindowColumns + `
 	FROM maintenance_windows
 	ORDER BY created_at DESC
 	`
-	rows, err := s.db.Query(query)
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -1080,73 +1709,52 @@ func (s *SQLiteStorage) GetAllMaintenanceWindows() ([]models.MaintenanceWindow,
 
 	var windows []models.MaintenanceWindow
 	for rows.Next() {
-		var w models.MaintenanceWindow
-		var description, targetName, daysOfWeek sql.NullString
-		if err := rows.Scan(&w.ID, &w.Name, &description, &targetName, &w.StartTime, &w.EndTime, &w.Recurring, &daysOfWeek, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
 			return nil, err
 		}
-		w.Description = description.String
-		w.TargetName = targetName.String
-		w.DaysOfWeek = daysOfWeek.String
-		windows = append(windows, w)
+		windows = append(windows, *w)
 	}
 
 	return windows, rows.Err()
 }
 
-func (s *SQLiteStorage) GetActiveMaintenanceWindows() ([]models.MaintenanceWindow, error) {
-	if err := s.migrateMaintenanceWindows(); err != nil {
-		return nil, err
-	}
-
-	now := time.Now()
-	nowStr := now.Format(time.RFC3339)
+// GetActiveMaintenanceWindows returns the windows active at now
+// Recurring and cron schedules are evaluated in the location of now
+func (s *SQLiteStorage) GetActiveMaintenanceWindows(ctx context.Context, now time.Time) ([]models.MaintenanceWindow, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	// First, filter non-recurring windows at SQL level for efficiency
-	// Then load recurring windows and filter in Go
-	query := `
-		SELECT id, name, description, target_name, start_time, end_time, recurring, days_of_week, created_at, updated_at
-		FROM maintenance_windows
-		WHERE (recurring = 0 AND start_time <= ? AND end_time >= ?)
-		   OR recurring = 1
-		ORDER BY start_time ASC
-	`
-
-	rows, err := s.db.Query(query, nowStr, nowStr)
+	// Stored times keep the offset they were created with, so they can't be compared as
+	// text in SQL; the table is small enough to filter every window in Go
+	windows, err := s.GetAllMaintenanceWindows(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query maintenance windows: %w", err)
 	}
-	defer rows.Close()
 
 	var active []models.MaintenanceWindow
-	for rows.Next() {
-		var w models.MaintenanceWindow
-		var desc, targetName, daysOfWeek sql.NullString
-		if err := rows.Scan(&w.ID, &w.Name, &desc, &targetName, &w.StartTime, &w.EndTime, &w.Recurring, &daysOfWeek, &w.CreatedAt, &w.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
-		}
-		w.Description = desc.String
-		w.TargetName = targetName.String
-		w.DaysOfWeek = daysOfWeek.String
-
-		// For non-recurring, already filtered by SQL; for recurring, filter in Go
-		if !w.Recurring || w.IsActive(now) {
+	for _, w := range windows {
+		if w.IsActive(now) {
 			active = append(active, w)
 		}
 	}
-
-	return active, rows.Err()
+	sort.Slice(active, func(i, j int) bool { return active[i].StartTime.Before(active[j].StartTime) })
+	return active, nil
 }
 
-// IsInMaintenanceWindow checks if the given target is currently in a maintenance window
-func (s *SQLiteStorage) IsInMaintenanceWindow(targetName string) (bool, error) {
-	activeWindows, err := s.GetActiveMaintenanceWindows()
+// IsInMaintenanceWindow checks if the given target of a group is in a maintenance window at now
+// Windows scoped to another project don't apply
+func (s *SQLiteStorage) IsInMaintenanceWindow(ctx context.Context, targetName, group, project string, now time.Time) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	activeWindows, err := s.GetActiveMaintenanceWindows(ctx, now)
 	if err != nil {
 		return false, err
 	}
 
 	for _, w := range activeWindows {
-		if w.MatchesTarget(targetName) {
+		if w.MatchesTarget(targetName, group) && w.MatchesProject(project) {
 			return true, nil
 		}
 	}
@@ -0,0 +1,562 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// alertSortColumns maps sort fields to SQL expressions on the alerts table
+var alertSortColumns = map[string]string{
+	models.AlertSortFiredAt:  "fired_at",
+	models.AlertSortSeverity: "CASE severity WHEN 'critical' THEN 3 WHEN 'warning' THEN 2 WHEN 'info' THEN 1 ELSE 0 END",
+	models.AlertSortTarget:   "target_name",
+	models.AlertSortRule:     "rule_name",
+}
+
+// alertConditions returns the WHERE conditions and arguments of the query filters
+func alertConditions(q models.AlertQuery) ([]string, []interface{}) {
+	var where []string
+	var args []interface{}
+
+	if len(q.IDs) > 0 {
+		where = append(where, "id IN (?"+strings.Repeat(", ?", len(q.IDs)-1)+")")
+		for _, id := range q.IDs {
+			args = append(args, id)
+		}
+	}
+	if q.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, q.Status)
+	}
+	if q.TargetName != "" {
+		where = append(where, "target_name = ?")
+		args = append(args, q.TargetName)
+	}
+	if q.TargetIn != nil {
+		if len(q.TargetIn) == 0 {
+			where = append(where, "0 = 1")
+		} else {
+			where = append(where, "target_name IN (?"+strings.Repeat(", ?", len(q.TargetIn)-1)+")")
+			for _, name := range q.TargetIn {
+				args = append(args, name)
+			}
+		}
+	}
+	if q.RuleName != "" {
+		where = append(where, "rule_name = ?")
+		args = append(args, q.RuleName)
+	}
+	if q.Flapping {
+		where = append(where, "flapping = 1")
+	}
+	if len(q.Severities) > 0 {
+		where = append(where, "severity IN (?"+strings.Repeat(", ?", len(q.Severities)-1)+")")
+		for _, sev := range q.Severities {
+			args = append(args, sev)
+		}
+	}
+	if !q.From.IsZero() {
+		where = append(where, "fired_at >= ?")
+		args = append(args, q.From)
+	}
+	if !q.To.IsZero() {
+		where = append(where, "fired_at <= ?")
+		args = append(args, q.To)
+	}
+	return where, args
+}
+
+// QueryAlerts returns a page of alerts and the number of alerts matching the filters
+// Ties in the sort column are broken by ID in the same direction, so cursors are stable
+func (s *SQLiteStorage) QueryAlerts(ctx context.Context, q models.AlertQuery) ([]models.Alert, int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	where, args := alertConditions(q)
+
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM alerts `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	col, ok := alertSortColumns[q.SortBy]
+	if !ok {
+		col = alertSortColumns[models.AlertSortFiredAt]
+	}
+	dir, cmp := "DESC", "<"
+	if q.Ascending {
+		dir, cmp = "ASC", ">"
+	}
+
+	// The cursor alert's own sort value is looked up, so the cursor is just an ID
+	if q.AfterID > 0 {
+		cursor := "(SELECT " + col + " FROM alerts WHERE id = ?)"
+		where = append(where, "("+col+" "+cmp+" "+cursor+" OR ("+col+" = "+cursor+" AND id "+cmp+" ?))")
+		args = append(args, q.AfterID, q.AfterID, q.AfterID)
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := `
+	SELECT id, target_name, instance_name, rule_name, severity, message, status, fired_at, resolved_at, notified_at, channels,
+		acknowledged_at, acknowledged_by, flapping, escalated_from, escalated_at
+	FROM alerts
+	` + filter + `
+	ORDER BY ` + col + ` ` + dir + `, id ` + dir + `
+	LIMIT ?`
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1 // No limit
+	}
+	args = append(args, limit)
+	if q.AfterID <= 0 && q.Offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, q.Offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []models.Alert
+	for rows.Next() {
+		var a models.Alert
+		if err := rows.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels,
+			&a.AcknowledgedAt, &a.AcknowledgedBy, &a.Flapping, &a.EscalatedFrom, &a.EscalatedAt); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, a)
+	}
+	return results, total, rows.Err()
+}
+
+func (s *SQLiteStorage) GetAlertSnapshot(ctx context.Context, id int64) (map[string]float64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var data sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT snapshot FROM alerts WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil || data.String == "" {
+		return nil, err
+	}
+
+	var values map[string]float64
+	if err := json.Unmarshal([]byte(data.String), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// migrateAlertColumns adds columns introduced after the alerts table was created
+func (s *SQLiteStorage) migrateAlertColumns() {
+	columns := []struct {
+		name string
+		def  string
+	}{
+		{"acknowledged_at", "DATETIME"},
+		{"acknowledged_by", "TEXT NOT NULL DEFAULT ''"},
+		{"flapping", "INTEGER NOT NULL DEFAULT 0"},
+		{"escalated_from", "TEXT NOT NULL DEFAULT ''"},
+		{"escalated_at", "DATETIME"},
+		{"snapshot", "TEXT"},
+	}
+
+	for _, col := range columns {
+		var count int
+		err := s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('alerts') WHERE name=?`, col.name).Scan(&count)
+		if err == nil && count == 0 {
+			_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE alerts ADD COLUMN %s %s`, col.name, col.def))
+			if err != nil {
+				log.Printf("Migration warning: %v", err)
+			} else {
+				log.Printf("Migration: added alerts.%s column", col.name)
+			}
+		}
+	}
+}
+
+// migrateAlertSearch creates the full-text index of alert messages, kept in sync by triggers
+// Existing alerts are indexed once when the index is created
+func (s *SQLiteStorage) migrateAlertSearch() error {
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'alerts_fts'`).Scan(&exists); err != nil {
+		return err
+	}
+
+	query := `
+	CREATE VIRTUAL TABLE IF NOT EXISTS alerts_fts USING fts5(
+		message, rule_name, target_name,
+		content = 'alerts', content_rowid = 'id'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS alerts_fts_insert AFTER INSERT ON alerts BEGIN
+		INSERT INTO alerts_fts(rowid, message, rule_name, target_name)
+		VALUES (new.id, new.message, new.rule_name, new.target_name);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS alerts_fts_delete AFTER DELETE ON alerts BEGIN
+		INSERT INTO alerts_fts(alerts_fts, rowid, message, rule_name, target_name)
+		VALUES ('delete', old.id, old.message, old.rule_name, old.target_name);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS alerts_fts_update AFTER UPDATE OF message, rule_name, target_name ON alerts BEGIN
+		INSERT INTO alerts_fts(alerts_fts, rowid, message, rule_name, target_name)
+		VALUES ('delete', old.id, old.message, old.rule_name, old.target_name);
+		INSERT INTO alerts_fts(rowid, message, rule_name, target_name)
+		VALUES (new.id, new.message, new.rule_name, new.target_name);
+	END;
+	`
+	if _, err := s.db.Exec(query); err != nil {
+		return err
+	}
+
+	if exists == 0 {
+		_, err := s.db.Exec(`INSERT INTO alerts_fts(alerts_fts) VALUES ('rebuild')`)
+		return err
+	}
+	return nil
+}
+
+// migrateAlertVersion creates the change counter of the alerts table, kept up to date by triggers
+// Together with the highest alert ID it versions alert responses for conditional requests
+func (s *SQLiteStorage) migrateAlertVersion() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS alert_version (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		changes INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME
+	);
+
+	INSERT OR IGNORE INTO alert_version (id, changes) VALUES (1, 0);
+
+	CREATE TRIGGER IF NOT EXISTS alert_version_insert AFTER INSERT ON alerts BEGIN
+		UPDATE alert_version SET changes = changes + 1, updated_at = CURRENT_TIMESTAMP WHERE id = 1;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS alert_version_update AFTER UPDATE ON alerts BEGIN
+		UPDATE alert_version SET changes = changes + 1, updated_at = CURRENT_TIMESTAMP WHERE id = 1;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS alert_version_delete AFTER DELETE ON alerts BEGIN
+		UPDATE alert_version SET changes = changes + 1, updated_at = CURRENT_TIMESTAMP WHERE id = 1;
+	END;
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) GetAlertVersion(ctx context.Context) (string, time.Time, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var maxID, changes int64
+	var updatedAt sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+	SELECT (SELECT COALESCE(MAX(id), 0) FROM alerts), changes, updated_at
+	FROM alert_version
+	WHERE id = 1
+	`).Scan(&maxID, &changes, &updatedAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var updated time.Time
+	if updatedAt.Valid {
+		if updated, err = parseStoredTime(updatedAt.String); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+	return fmt.Sprintf("%d-%d", maxID, changes), updated, nil
+}
+
+// ftsQuery turns user input into an FTS5 query matching all terms as prefixes
+// Terms are quoted, so FTS5 operators and syntax characters in the input are matched literally
+func ftsQuery(input string) string {
+	terms := strings.Fields(input)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+func (s *SQLiteStorage) SearchAlerts(ctx context.Context, query string, limit int) ([]models.Alert, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT a.id, a.target_name, a.instance_name, a.rule_name, a.severity, a.message, a.status, a.fired_at, a.resolved_at, a.notified_at, a.channels,
+		a.acknowledged_at, a.acknowledged_by, a.flapping, a.escalated_from, a.escalated_at
+	FROM alerts_fts
+	JOIN alerts a ON a.id = alerts_fts.rowid
+	WHERE alerts_fts MATCH ?
+	ORDER BY a.fired_at DESC, a.id DESC
+	LIMIT ?
+	`, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.Alert
+	for rows.Next() {
+		var a models.Alert
+		if err := rows.Scan(&a.ID, &a.TargetName, &a.InstanceName, &a.RuleName, &a.Severity, &a.Message, &a.Status, &a.FiredAt, &a.ResolvedAt, &a.NotifiedAt, &a.Channels,
+			&a.AcknowledgedAt, &a.AcknowledgedBy, &a.Flapping, &a.EscalatedFrom, &a.EscalatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, a)
+	}
+	return results, rows.Err()
+}
+
+// ApplyAlertAction resolves, acknowledges or deletes the alerts matching q in one transaction
+// Resolve and acknowledge only change active alerts; acknowledging skips already acknowledged ones
+// Returns the IDs of the changed alerts
+func (s *SQLiteStorage) ApplyAlertAction(ctx context.Context, action string, q models.AlertQuery, actor string, at time.Time) ([]int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	where, args := alertConditions(q)
+
+	var update string
+	var updateArgs []interface{}
+	switch action {
+	case models.AlertActionResolve:
+		where = append(where, "status = 'fired'")
+		update = `UPDATE alerts SET status = 'resolved', resolved_at = ?`
+		updateArgs = []interface{}{at}
+	case models.AlertActionAcknowledge:
+		where = append(where, "status = 'fired'", "acknowledged_at IS NULL")
+		update = `UPDATE alerts SET acknowledged_at = ?, acknowledged_by = ?`
+		updateArgs = []interface{}{at, actor}
+	case models.AlertActionDelete:
+		update = `DELETE FROM alerts`
+	default:
+		return nil, fmt.Errorf("unknown alert action: %s", action)
+	}
+	filter := ""
+	if len(where) > 0 {
+		filter = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM alerts`+filter+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, update+filter, append(updateArgs, args...)...); err != nil {
+		return nil, err
+	}
+	return ids, tx.Commit()
+}
+
+// sqlDuration returns the whole seconds between two timestamp columns
+// Timestamps are stored as Go time strings in the server's zone, of which SQLite
+// date functions understand the leading "YYYY-MM-DD HH:MM:SS"
+func sqlDuration(from, to string) string {
+	return "ROUND((julianday(substr(" + to + ", 1, 19)) - julianday(substr(" + from + ", 1, 19))) * 86400)"
+}
+
+// GetAlertTimeseries aggregates alerts fired within the query's range: fired and resolved
+// alerts per day, mean time to resolve by rule and target, the noisiest rules and flapping alerts
+// Days are the dates of the server's zone
+func (s *SQLiteStorage) GetAlertTimeseries(ctx context.Context, q models.AlertTimeseriesQuery) (*models.AlertTimeseries, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result := &models.AlertTimeseries{
+		From:         q.From,
+		To:           q.To,
+		Days:         []models.AlertDayCount{},
+		MTTRByRule:   []models.AlertMTTR{},
+		MTTRByTarget: []models.AlertMTTR{},
+		NoisyRules:   []models.NoisyRule{},
+		Flapping:     []models.FlappingAlert{},
+	}
+
+	// Target scope; the time conditions differ per aggregate
+	where, scopeArgs := alertConditions(models.AlertQuery{TargetIn: q.TargetIn})
+	where = append(where, "status != '"+models.AlertStatusSuppressed+"'")
+	scope := strings.Join(where, " AND ")
+	fired := scope + " AND fired_at >= ? AND fired_at <= ?"
+	firedArgs := append(slices.Clone(scopeArgs), q.From, q.To)
+
+	if err := s.alertDays(ctx, result, scope, scopeArgs, q); err != nil {
+		return nil, err
+	}
+
+	duration := sqlDuration("fired_at", "resolved_at")
+	resolved := fired + " AND resolved_at IS NOT NULL"
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(AVG(`+duration+`), 0) FROM alerts WHERE `+resolved, firedArgs...).
+		Scan(&result.MTTRSeconds); err != nil {
+		return nil, err
+	}
+	var err error
+	if result.MTTRByRule, err = s.alertMTTR(ctx, "rule_name", duration, resolved, firedArgs); err != nil {
+		return nil, err
+	}
+	if result.MTTRByTarget, err = s.alertMTTR(ctx, "target_name", duration, resolved, firedArgs); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT rule_name, COUNT(*), COUNT(DISTINCT target_name), COUNT(DISTINCT target_name || '/' || instance_name)
+	FROM alerts
+	WHERE `+fired+`
+	GROUP BY rule_name
+	ORDER BY COUNT(*) DESC, rule_name
+	LIMIT ?`, append(slices.Clone(firedArgs), q.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r models.NoisyRule
+		if err := rows.Scan(&r.Rule, &r.Fired, &r.Targets, &r.Instances); err != nil {
+			return nil, err
+		}
+		result.NoisyRules = append(result.NoisyRules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Window functions keep the latest alert of each rule and instance, for its fired_at
+	rows, err = s.db.QueryContext(ctx, `
+	SELECT rule_name, target_name, instance_name, fires, mean_duration, fired_at
+	FROM (
+		SELECT rule_name, target_name, instance_name, fired_at,
+			COUNT(*) OVER w AS fires,
+			COALESCE(AVG(CASE WHEN resolved_at IS NOT NULL THEN `+duration+` END) OVER w, 0) AS mean_duration,
+			ROW_NUMBER() OVER (PARTITION BY rule_name, target_name, instance_name ORDER BY fired_at DESC, id DESC) AS latest
+		FROM alerts
+		WHERE `+fired+`
+		WINDOW w AS (PARTITION BY rule_name, target_name, instance_name)
+	)
+	WHERE latest = 1 AND fires >= ?
+	ORDER BY fires DESC, rule_name, target_name, instance_name
+	LIMIT ?`, append(slices.Clone(firedArgs), q.FlapThreshold, q.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f models.FlappingAlert
+		if err := rows.Scan(&f.Rule, &f.TargetName, &f.InstanceName, &f.Fires, &f.MeanDurationSeconds, &f.LastFiredAt); err != nil {
+			return nil, err
+		}
+		result.Flapping = append(result.Flapping, f)
+	}
+	return result, rows.Err()
+}
+
+// alertDays counts alerts fired and resolved on each day of the range, including days without any
+func (s *SQLiteStorage) alertDays(ctx context.Context, result *models.AlertTimeseries, scope string, scopeArgs []interface{}, q models.AlertTimeseriesQuery) error {
+	args := append(slices.Clone(scopeArgs), q.From, q.To)
+	args = append(args, scopeArgs...)
+	args = append(args, q.From, q.To)
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT day, SUM(fired), SUM(resolved) FROM (
+		SELECT substr(fired_at, 1, 10) AS day, 1 AS fired, 0 AS resolved
+		FROM alerts WHERE `+scope+` AND fired_at >= ? AND fired_at <= ?
+		UNION ALL
+		SELECT substr(resolved_at, 1, 10), 0, 1
+		FROM alerts WHERE `+scope+` AND resolved_at >= ? AND resolved_at <= ?
+	)
+	GROUP BY day`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]models.AlertDayCount)
+	for rows.Next() {
+		var d models.AlertDayCount
+		if err := rows.Scan(&d.Date, &d.Fired, &d.Resolved); err != nil {
+			return err
+		}
+		counts[d.Date] = d
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	from, to := q.From.In(time.Local), q.To.In(time.Local)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local); !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		d, ok := counts[date]
+		if !ok {
+			d = models.AlertDayCount{Date: date}
+		}
+		result.Days = append(result.Days, d)
+	}
+	return nil
+}
+
+// alertMTTR returns the mean and longest time to resolve of resolved alerts grouped by a column
+func (s *SQLiteStorage) alertMTTR(ctx context.Context, col, duration, where string, args []interface{}) ([]models.AlertMTTR, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT `+col+`, COUNT(*), AVG(`+duration+`), MAX(`+duration+`)
+	FROM alerts
+	WHERE `+where+`
+	GROUP BY `+col+`
+	ORDER BY AVG(`+duration+`) DESC, `+col, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mttr := []models.AlertMTTR{}
+	for rows.Next() {
+		var m models.AlertMTTR
+		if err := rows.Scan(&m.Name, &m.Resolved, &m.MeanSeconds, &m.MaxSeconds); err != nil {
+			return nil, err
+		}
+		mttr = append(mttr, m)
+	}
+	return mttr, rows.Err()
+}
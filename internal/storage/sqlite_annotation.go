@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Annotation-related methods
+
+func (s *SQLiteStorage) migrateAnnotations() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		target_name TEXT,
+		time DATETIME NOT NULL,
+		end_time DATETIME,
+		tags TEXT,
+		text TEXT NOT NULL,
+		created_by TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_annotations_time ON annotations(time);
+	CREATE INDEX IF NOT EXISTS idx_annotations_target ON annotations(target_name);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) SaveAnnotation(ctx context.Context, annotation *models.Annotation) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateAnnotations(); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO annotations (target_name, time, end_time, tags, text, created_by, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	now := time.Now()
+	result, err := s.db.ExecContext(ctx, query,
+		annotation.TargetName,
+		annotation.Time,
+		annotation.EndTime,
+		strings.Join(annotation.Tags, ","),
+		annotation.Text,
+		annotation.CreatedBy,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		annotation.ID = id
+		annotation.CreatedAt = now
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) DeleteAnnotation(ctx context.Context, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateAnnotations(); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM annotations WHERE id = ?`
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// scanAnnotation scans an annotation row, handling nullable text columns
+func scanAnnotation(scanner interface{ Scan(...interface{}) error }) (*models.Annotation, error) {
+	var a models.Annotation
+	var target, tags, createdBy sql.NullString
+	if err := scanner.Scan(&a.ID, &target, &a.Time, &a.EndTime, &tags, &a.Text, &createdBy, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	a.TargetName = target.String
+	if tags.String != "" {
+		a.Tags = strings.Split(tags.String, ",")
+	}
+	a.CreatedBy = createdBy.String
+	return &a, nil
+}
+
+func (s *SQLiteStorage) GetAnnotation(ctx context.Context, id int64) (*models.Annotation, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateAnnotations(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, target_name, time, end_time, tags, text, created_by, created_at
+	FROM annotations
+	WHERE id = ?
+	`
+	a, err := scanAnnotation(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *SQLiteStorage) GetAnnotations(ctx context.Context, targetName string, from, to time.Time) ([]models.Annotation, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateAnnotations(); err != nil {
+		return nil, err
+	}
+
+	// Annotations spanning a period match when any part of it is in range
+	query := `
+	SELECT id, target_name, time, end_time, tags, text, created_by, created_at
+	FROM annotations
+	WHERE time <= ? AND COALESCE(end_time, time) >= ?
+	`
+	args := []interface{}{to, from}
+	if targetName != "" {
+		query += ` AND (target_name = ? OR target_name = '' OR target_name IS NULL)`
+		args = append(args, targetName)
+	}
+	query += ` ORDER BY time ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var annotations []models.Annotation
+	for rows.Next() {
+		a, err := scanAnnotation(rows)
+		if err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, *a)
+	}
+	return annotations, rows.Err()
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Audit log methods
+
+func (s *SQLiteStorage) migrateAuditLog() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		actor TEXT NOT NULL,
+		ip TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		resource TEXT NOT NULL DEFAULT '',
+		status INTEGER NOT NULL,
+		before_state TEXT,
+		after_state TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, timestamp);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) SaveAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateAuditLog(); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO audit_log (timestamp, actor, ip, action, method, path, resource, status, before_state, after_state)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.ExecContext(ctx, query,
+		entry.Timestamp,
+		entry.Actor,
+		entry.IP,
+		entry.Action,
+		entry.Method,
+		entry.Path,
+		entry.Resource,
+		entry.Status,
+		nullableJSON(entry.Before),
+		nullableJSON(entry.After),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		entry.ID = id
+	}
+	return nil
+}
+
+// nullableJSON stores empty JSON as NULL
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+func (s *SQLiteStorage) QueryAuditLog(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateAuditLog(); err != nil {
+		return nil, 0, err
+	}
+
+	var where []string
+	var args []interface{}
+	if q.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, q.Actor)
+	}
+	if strings.HasSuffix(q.Action, ".") {
+		where = append(where, "substr(action, 1, ?) = ?")
+		args = append(args, len(q.Action), q.Action)
+	} else if q.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, q.Action)
+	}
+	if q.Resource != "" {
+		where = append(where, "resource = ?")
+		args = append(args, q.Resource)
+	}
+	if !q.From.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, q.From)
+	}
+	if !q.To.IsZero() {
+		where = append(where, "timestamp <= ?")
+		args = append(args, q.To)
+	}
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1 // No limit
+	}
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT id, timestamp, actor, ip, action, method, path, resource, status, before_state, after_state
+	FROM audit_log
+	`+filter+`
+	ORDER BY timestamp DESC, id DESC
+	LIMIT ? OFFSET ?`, append(args, limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Actor, &e.IP, &e.Action, &e.Method, &e.Path, &e.Resource, &e.Status, &before, &after); err != nil {
+			return nil, 0, err
+		}
+		if before.Valid {
+			e.Before = []byte(before.String)
+		}
+		if after.Valid {
+			e.After = []byte(after.String)
+		}
+		results = append(results, e)
+	}
+	return results, total, rows.Err()
+}
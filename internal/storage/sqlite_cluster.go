@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Cluster membership methods
+
+func (s *SQLiteStorage) migrateClusterNodes() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS cluster_nodes (
+		id TEXT PRIMARY KEY,
+		address TEXT,
+		started_at DATETIME NOT NULL,
+		last_seen DATETIME NOT NULL
+	);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) HeartbeatClusterNode(ctx context.Context, node *models.ClusterNode) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateClusterNodes(); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO cluster_nodes (id, address, started_at, last_seen)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		address = excluded.address,
+		started_at = excluded.started_at,
+		last_seen = excluded.last_seen
+	`
+	_, err := s.db.ExecContext(ctx, query, node.ID, node.Address, node.StartedAt, node.LastSeen)
+	return err
+}
+
+func (s *SQLiteStorage) GetClusterNodes(ctx context.Context, aliveSince time.Time) ([]models.ClusterNode, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateClusterNodes(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, COALESCE(address, ''), started_at, last_seen
+	FROM cluster_nodes
+	WHERE last_seen >= ?
+	ORDER BY id
+	`
+	rows, err := s.db.QueryContext(ctx, query, aliveSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []models.ClusterNode
+	for rows.Next() {
+		var n models.ClusterNode
+		if err := rows.Scan(&n.ID, &n.Address, &n.StartedAt, &n.LastSeen); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+func (s *SQLiteStorage) DeleteClusterNode(ctx context.Context, id string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateClusterNodes(); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM cluster_nodes WHERE id = ?`, id)
+	return err
+}
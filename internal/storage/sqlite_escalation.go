@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Alert escalation history methods
+
+func (s *SQLiteStorage) migrateAlertEscalations() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS alert_escalations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		alert_id INTEGER NOT NULL,
+		from_severity TEXT NOT NULL,
+		to_severity TEXT NOT NULL,
+		escalated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_alert_escalations_alert ON alert_escalations(alert_id);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) SaveAlertEscalation(ctx context.Context, e *models.AlertEscalation) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateAlertEscalations(); err != nil {
+		return err
+	}
+
+	if e.EscalatedAt.IsZero() {
+		e.EscalatedAt = time.Now()
+	}
+
+	query := `
+	INSERT INTO alert_escalations (alert_id, from_severity, to_severity, escalated_at)
+	VALUES (?, ?, ?, ?)
+	`
+	result, err := s.db.ExecContext(ctx, query, e.AlertID, e.From, e.To, e.EscalatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		e.ID = id
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) GetAlertEscalations(ctx context.Context, alertID int64) ([]models.AlertEscalation, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateAlertEscalations(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, alert_id, from_severity, to_severity, escalated_at
+	FROM alert_escalations
+	WHERE alert_id = ?
+	ORDER BY escalated_at ASC, id ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, alertID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var escalations []models.AlertEscalation
+	for rows.Next() {
+		var e models.AlertEscalation
+		if err := rows.Scan(&e.ID, &e.AlertID, &e.From, &e.To, &e.EscalatedAt); err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, e)
+	}
+	return escalations, rows.Err()
+}
+
+// cleanupAlertEscalations deletes the history of alerts that no longer exist
+func (s *SQLiteStorage) cleanupAlertEscalations(ctx context.Context) error {
+	if err := s.migrateAlertEscalations(); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM alert_escalations WHERE alert_id NOT IN (SELECT id FROM alerts)`)
+	return err
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Event-related methods
+
+func (s *SQLiteStorage) migrateEvents() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		target_name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		version TEXT,
+		description TEXT,
+		timestamp DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_events_target_time ON events(target_name, timestamp);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) SaveEvent(ctx context.Context, event *models.Event) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateEvents(); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO events (target_name, type, version, description, timestamp, created_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`
+	now := time.Now()
+	result, err := s.db.ExecContext(ctx, query,
+		event.TargetName,
+		event.Type,
+		event.Version,
+		event.Description,
+		event.Timestamp,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		event.ID = id
+		event.CreatedAt = now
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) DeleteEvent(ctx context.Context, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateEvents(); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM events WHERE id = ?`
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// scanEvent scans an event row, handling nullable text columns
+func scanEvent(scanner interface{ Scan(...interface{}) error }) (*models.Event, error) {
+	var e models.Event
+	var version, description sql.NullString
+	if err := scanner.Scan(&e.ID, &e.TargetName, &e.Type, &version, &description, &e.Timestamp, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	e.Version = version.String
+	e.Description = description.String
+	return &e, nil
+}
+
+func (s *SQLiteStorage) GetEvent(ctx context.Context, id int64) (*models.Event, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateEvents(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, target_name, type, version, description, timestamp, created_at
+	FROM events
+	WHERE id = ?
+	`
+	e, err := scanEvent(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *SQLiteStorage) GetEvents(ctx context.Context, targetName string, from, to time.Time) ([]models.Event, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateEvents(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, target_name, type, version, description, timestamp, created_at
+	FROM events
+	WHERE target_name = ? AND timestamp BETWEEN ? AND ?
+	ORDER BY timestamp ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, targetName, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
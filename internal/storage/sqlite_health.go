@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Health check methods
+
+func (s *SQLiteStorage) migrateHealthChecks() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS health_checks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		target_name TEXT NOT NULL,
+		instance_name TEXT NOT NULL DEFAULT 'default',
+		status TEXT NOT NULL,
+		timestamp DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_health_checks_target_time ON health_checks(target_name, timestamp);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) SaveHealthCheck(ctx context.Context, check *models.HealthCheck) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateHealthChecks(); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO health_checks (target_name, instance_name, status, timestamp) VALUES (?, ?, ?, ?)`,
+		check.TargetName, check.InstanceName, check.Status, check.Timestamp,
+	)
+	return err
+}
+
+func (s *SQLiteStorage) GetHealthChecks(ctx context.Context, targetName string, from, to time.Time) ([]models.HealthCheck, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateHealthChecks(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT target_name, instance_name, status, timestamp
+	FROM health_checks
+	WHERE target_name = ? AND timestamp >= ? AND timestamp <= ?
+	ORDER BY instance_name, timestamp
+	`, targetName, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []models.HealthCheck{}
+	for rows.Next() {
+		var c models.HealthCheck
+		if err := rows.Scan(&c.TargetName, &c.InstanceName, &c.Status, &c.Timestamp); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
+
+func (s *SQLiteStorage) CleanupHealthChecks(ctx context.Context, olderThan time.Time) (int64, error) {
+	if err := s.migrateHealthChecks(); err != nil {
+		return 0, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM health_checks WHERE timestamp < ?`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Inactive instance methods
+
+func (s *SQLiteStorage) migrateInactiveInstances() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS inactive_instances (
+		target_name TEXT NOT NULL,
+		instance_name TEXT NOT NULL,
+		last_seen DATETIME NOT NULL,
+		marked_at DATETIME NOT NULL,
+		PRIMARY KEY (target_name, instance_name)
+	);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) MarkInactiveInstances(ctx context.Context, idleSince time.Time) (int64, error) {
+	if err := s.migrateInactiveInstances(); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Instances that reported again are active
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM inactive_instances
+	WHERE EXISTS (
+		SELECT 1 FROM pool_metrics p
+		WHERE p.target_name = inactive_instances.target_name
+		  AND p.instance_name = inactive_instances.instance_name
+		  AND p.timestamp > inactive_instances.last_seen
+	)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	// Instances whose data aged out have nothing left to hide
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM inactive_instances
+	WHERE NOT EXISTS (
+		SELECT 1 FROM pool_metrics p
+		WHERE p.target_name = inactive_instances.target_name
+		  AND p.instance_name = inactive_instances.instance_name
+	)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+	INSERT OR IGNORE INTO inactive_instances (target_name, instance_name, last_seen, marked_at)
+	SELECT target_name, instance_name, MAX(timestamp), ?
+	FROM pool_metrics
+	GROUP BY target_name, instance_name
+	HAVING MAX(timestamp) < ?
+	`, time.Now(), idleSince)
+	if err != nil {
+		return 0, err
+	}
+	marked, _ := result.RowsAffected()
+	return marked, tx.Commit()
+}
+
+func (s *SQLiteStorage) GetInactiveInstances(ctx context.Context, targetName string) ([]models.InactiveInstance, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateInactiveInstances(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT target_name, instance_name, last_seen, marked_at FROM inactive_instances`
+	var args []interface{}
+	if targetName != "" {
+		query += ` WHERE target_name = ?`
+		args = append(args, targetName)
+	}
+	query += ` ORDER BY target_name, instance_name`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instances := make([]models.InactiveInstance, 0)
+	for rows.Next() {
+		var inst models.InactiveInstance
+		if err := rows.Scan(&inst.TargetName, &inst.InstanceName, &inst.LastSeen, &inst.MarkedAt); err != nil {
+			return nil, err
+		}
+		instances = append(instances, inst)
+	}
+	return instances, rows.Err()
+}
+
+func (s *SQLiteStorage) PurgeInstance(ctx context.Context, targetName, instanceName string) (int64, error) {
+	if err := s.migrateRollups(); err != nil {
+		return 0, err
+	}
+	if err := s.migrateHealthChecks(); err != nil {
+		return 0, err
+	}
+	if err := s.migrateInactiveInstances(); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Table names are hardcoded whitelist - safe from SQL injection
+	tables := []string{"pool_metrics", "alerts", "health_checks"}
+	for _, t := range rollupTables {
+		tables = append(tables, t.name)
+	}
+
+	var deleted int64
+	for _, table := range tables {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE target_name = ? AND instance_name = ?`, table), targetName, instanceName)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM inactive_instances WHERE target_name = ? AND instance_name = ?`, targetName, instanceName); err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Notification log methods
+
+func (s *SQLiteStorage) migrateNotificationLog() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS notification_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		alert_id INTEGER NOT NULL DEFAULT 0,
+		channel TEXT NOT NULL,
+		event TEXT NOT NULL,
+		status TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		retries INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_notification_log_alert ON notification_log(alert_id);
+	CREATE INDEX IF NOT EXISTS idx_notification_log_status ON notification_log(status, created_at);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) SaveNotificationDelivery(ctx context.Context, d *models.NotificationDelivery) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateNotificationLog(); err != nil {
+		return err
+	}
+
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+
+	query := `
+	INSERT INTO notification_log (alert_id, channel, event, status, status_code, error, latency_ms, retries, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.ExecContext(ctx, query,
+		d.AlertID,
+		d.Channel,
+		d.Event,
+		d.Status,
+		d.StatusCode,
+		d.Error,
+		d.LatencyMs,
+		d.Retries,
+		d.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		d.ID = id
+	}
+	return nil
+}
+
+// scanDeliveries scans notification_log rows, handling nullable text columns
+func scanDeliveries(rows *sql.Rows) ([]models.NotificationDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []models.NotificationDelivery
+	for rows.Next() {
+		var d models.NotificationDelivery
+		var errText sql.NullString
+		if err := rows.Scan(&d.ID, &d.AlertID, &d.Channel, &d.Event, &d.Status, &d.StatusCode,
+			&errText, &d.LatencyMs, &d.Retries, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Error = errText.String
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *SQLiteStorage) GetDeliveriesByAlert(ctx context.Context, alertID int64) ([]models.NotificationDelivery, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateNotificationLog(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, alert_id, channel, event, status, status_code, error, latency_ms, retries, created_at
+	FROM notification_log
+	WHERE alert_id = ?
+	ORDER BY created_at ASC, id ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, alertID)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+func (s *SQLiteStorage) GetFailedDeliveries(ctx context.Context, since time.Time, limit int) ([]models.NotificationDelivery, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateNotificationLog(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, alert_id, channel, event, status, status_code, error, latency_ms, retries, created_at
+	FROM notification_log
+	WHERE status = ? AND created_at >= ?
+	ORDER BY created_at DESC, id DESC
+	LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, models.DeliveryStatusFailed, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+func (s *SQLiteStorage) CleanupNotificationLog(ctx context.Context, olderThan time.Time) (int64, error) {
+	if err := s.migrateNotificationLog(); err != nil {
+		return 0, err
+	}
+
+	query := `DELETE FROM notification_log WHERE created_at < ?`
+	result, err := s.db.ExecContext(ctx, query, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Recommendation-related methods
+
+func (s *SQLiteStorage) migrateRecommendations() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS recommendations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		target_name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		current TEXT,
+		recommended TEXT,
+		reason TEXT,
+		severity TEXT,
+		status TEXT NOT NULL DEFAULT 'open',
+		note TEXT,
+		snooze_until DATETIME,
+		status_current TEXT,
+		status_severity TEXT,
+		first_seen_at DATETIME NOT NULL,
+		last_seen_at DATETIME NOT NULL,
+		status_changed_at DATETIME
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_recommendations_target_type ON recommendations(target_name, type);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) SaveRecommendation(ctx context.Context, rec *models.RecommendationRecord) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateRecommendations(); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO recommendations (target_name, type, current, recommended, reason, severity, status, note,
+		snooze_until, status_current, status_severity, first_seen_at, last_seen_at, status_changed_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.ExecContext(ctx, query,
+		rec.TargetName, rec.Type, rec.Current, rec.Recommended, rec.Reason, rec.Severity, rec.Status, rec.Note,
+		rec.SnoozeUntil, rec.StatusCurrent, rec.StatusSeverity, rec.FirstSeenAt, rec.LastSeenAt, rec.StatusChangedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		rec.ID = id
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) UpdateRecommendation(ctx context.Context, rec *models.RecommendationRecord) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateRecommendations(); err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE recommendations SET
+		current = ?,
+		recommended = ?,
+		reason = ?,
+		severity = ?,
+		status = ?,
+		note = ?,
+		snooze_until = ?,
+		status_current = ?,
+		status_severity = ?,
+		last_seen_at = ?,
+		status_changed_at = ?
+	WHERE id = ?
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		rec.Current, rec.Recommended, rec.Reason, rec.Severity, rec.Status, rec.Note,
+		rec.SnoozeUntil, rec.StatusCurrent, rec.StatusSeverity, rec.LastSeenAt, rec.StatusChangedAt,
+		rec.ID,
+	)
+	return err
+}
+
+const recommendationColumns = `id, target_name, type, current, recommended, reason, severity, status, note,
+	snooze_until, status_current, status_severity, first_seen_at, last_seen_at, status_changed_at`
+
+// scanRecommendation scans a recommendation row, handling nullable columns
+func scanRecommendation(scanner interface{ Scan(...interface{}) error }) (*models.RecommendationRecord, error) {
+	var r models.RecommendationRecord
+	var current, recommended, reason, severity, note, statusCurrent, statusSeverity sql.NullString
+	var snoozeUntil, statusChangedAt sql.NullTime
+	if err := scanner.Scan(&r.ID, &r.TargetName, &r.Type, &current, &recommended, &reason, &severity, &r.Status, &note,
+		&snoozeUntil, &statusCurrent, &statusSeverity, &r.FirstSeenAt, &r.LastSeenAt, &statusChangedAt); err != nil {
+		return nil, err
+	}
+	r.Current = current.String
+	r.Recommended = recommended.String
+	r.Reason = reason.String
+	r.Severity = severity.String
+	r.Note = note.String
+	r.StatusCurrent = statusCurrent.String
+	r.StatusSeverity = statusSeverity.String
+	if snoozeUntil.Valid {
+		r.SnoozeUntil = &snoozeUntil.Time
+	}
+	if statusChangedAt.Valid {
+		r.StatusChangedAt = &statusChangedAt.Time
+	}
+	return &r, nil
+}
+
+func (s *SQLiteStorage) GetRecommendation(ctx context.Context, id int64) (*models.RecommendationRecord, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateRecommendations(); err != nil {
+		return nil, err
+	}
+
+	rec, err := scanRecommendation(s.db.QueryRowContext(ctx, `SELECT `+recommendationColumns+` FROM recommendations WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (s *SQLiteStorage) GetRecommendations(ctx context.Context, targetName, status string) ([]models.RecommendationRecord, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateRecommendations(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + recommendationColumns + ` FROM recommendations WHERE 1=1`
+	var args []interface{}
+	if targetName != "" {
+		query += ` AND target_name = ?`
+		args = append(args, targetName)
+	}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY target_name, id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recs := []models.RecommendationRecord{}
+	for rows.Next() {
+		rec, err := scanRecommendation(rows)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, *rec)
+	}
+	return recs, rows.Err()
+}
@@ -0,0 +1,557 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Rollup resolutions
+const (
+	ResolutionRaw    = "raw"
+	ResolutionMinute = "1m"
+	ResolutionHour   = "1h"
+)
+
+// History queries spanning more than these ranges prefer coarser rollups
+const (
+	rawHistoryRange    = 24 * time.Hour
+	minuteHistoryRange = 7 * 24 * time.Hour
+)
+
+// rollupTable describes a table of aggregated metrics
+type rollupTable struct {
+	resolution string
+	name       string
+	bucket     time.Duration
+}
+
+// rollupTables are ordered from finest to coarsest; each is built from the previous one
+var rollupTables = []rollupTable{
+	{ResolutionMinute, "pool_metrics_1m", time.Minute},
+	{ResolutionHour, "pool_metrics_1h", time.Hour},
+}
+
+// rollupFields are the aggregated pool_metrics columns, in setMetricValues order
+var rollupFields = []string{
+	"active", "idle", "pending", "max", "timeout", "acquire_p99",
+	"heap_used", "heap_max", "non_heap_used", "non_heap_max", "threads_live", "cpu_usage",
+	"gc_count", "gc_time", "young_gc_count", "old_gc_count",
+	"timeout_delta", "timeout_rate",
+	"gc_count_delta", "gc_time_delta", "young_gc_count_delta", "old_gc_count_delta", "gc_pauses_per_min", "gc_pause_ms_per_min",
+	"acquire_p50", "acquire_p95", "acquire_max",
+}
+
+// deltaFields are the per-interval rollupFields; a bucket's value is their total rather than the average
+var deltaFields = map[string]bool{
+	"timeout_delta": true, "gc_count_delta": true, "gc_time_delta": true, "young_gc_count_delta": true, "old_gc_count_delta": true,
+}
+
+func setMetricValues(m *models.PoolMetrics, v []float64) {
+	m.Active = int(math.Round(v[0]))
+	m.Idle = int(math.Round(v[1]))
+	m.Pending = int(math.Round(v[2]))
+	m.Max = int(math.Round(v[3]))
+	m.Timeout = int64(math.Round(v[4]))
+	m.AcquireP99 = v[5]
+	m.HeapUsed = int64(math.Round(v[6]))
+	m.HeapMax = int64(math.Round(v[7]))
+	m.NonHeapUsed = int64(math.Round(v[8]))
+	m.NonHeapMax = int64(math.Round(v[9]))
+	m.ThreadsLive = int(math.Round(v[10]))
+	m.CpuUsage = v[11]
+	m.GcCount = int64(math.Round(v[12]))
+	m.GcTime = v[13]
+	m.YoungGcCount = int64(math.Round(v[14]))
+	m.OldGcCount = int64(math.Round(v[15]))
+	m.TimeoutDelta = int64(math.Round(v[16]))
+	m.TimeoutRate = v[17]
+	m.GcCountDelta = int64(math.Round(v[18]))
+	m.GcTimeDelta = v[19]
+	m.YoungGcCountDelta = int64(math.Round(v[20]))
+	m.OldGcCountDelta = int64(math.Round(v[21]))
+	m.GcPausesPerMin = v[22]
+	m.GcPauseMsPerMin = v[23]
+	m.AcquireP50 = v[24]
+	m.AcquireP95 = v[25]
+	m.AcquireMax = v[26]
+	m.SetAvgGcPause()
+}
+
+// rollupColumns returns the aggregate column names with the given suffixes
+func rollupColumns(suffixes ...string) []string {
+	var cols []string
+	for _, suffix := range suffixes {
+		for _, f := range rollupFields {
+			cols = append(cols, f+"_"+suffix)
+		}
+	}
+	return cols
+}
+
+func (s *SQLiteStorage) migrateRollups() error {
+	var defs []string
+	for _, col := range rollupColumns("avg", "min", "max") {
+		defs = append(defs, col+" REAL NOT NULL DEFAULT 0")
+	}
+
+	for _, t := range rollupTables {
+		// Table names come from the rollupTables whitelist
+		query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (
+			target_name TEXT NOT NULL,
+			instance_name TEXT NOT NULL,
+			bucket DATETIME NOT NULL,
+			samples INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL DEFAULT 'healthy',
+			%[2]s,
+			PRIMARY KEY (target_name, instance_name, bucket)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_target_bucket ON %[1]s(target_name, bucket);
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_bucket ON %[1]s(bucket);
+		`, t.name, strings.Join(defs, ",\n\t\t\t"))
+		if _, err := s.db.Exec(query); err != nil {
+			return err
+		}
+
+		// Tables created before a field was added get its columns
+		for _, col := range rollupColumns("avg", "min", "max") {
+			var count int
+			if err := s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, t.name, col).Scan(&count); err != nil {
+				return err
+			}
+			if count == 0 {
+				if _, err := s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s REAL NOT NULL DEFAULT 0`, t.name, col)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// rollupBucket accumulates samples of one target instance within a bucket
+type rollupBucket struct {
+	target   string
+	instance string
+	start    time.Time
+	samples  int64
+	healthy  bool
+	status   string
+	sum      []float64
+	min      []float64
+	max      []float64
+}
+
+// add merges samples into the bucket; avg is weighted by the sample count
+func (b *rollupBucket) add(status string, samples int64, avg, min, max []float64) {
+	if b.sum == nil {
+		b.sum = make([]float64, len(rollupFields))
+		b.min = append([]float64(nil), min...)
+		b.max = append([]float64(nil), max...)
+	}
+	for i := range rollupFields {
+		b.sum[i] += avg[i] * float64(samples)
+		b.min[i] = math.Min(b.min[i], min[i])
+		b.max[i] = math.Max(b.max[i], max[i])
+	}
+	b.samples += samples
+
+	// A bucket is healthy if any sample was, otherwise it keeps the latest status
+	if status == models.StatusHealthy {
+		b.healthy = true
+	}
+	b.status = status
+	if b.healthy {
+		b.status = models.StatusHealthy
+	}
+}
+
+// Rollup aggregates raw metrics into the 1-minute and 1-hour tables
+// Only buckets that ended before until are written; each run continues after the
+// newest existing bucket, so it must happen before raw data is cleaned up
+func (s *SQLiteStorage) Rollup(ctx context.Context, until time.Time) (int64, error) {
+	if err := s.migrateRollups(); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	var source *rollupTable
+	for i := range rollupTables {
+		n, err := s.rollupInto(ctx, &rollupTables[i], source, until)
+		if err != nil {
+			return total, fmt.Errorf("rollup %s: %w", rollupTables[i].resolution, err)
+		}
+		total += n
+		source = &rollupTables[i]
+	}
+	return total, nil
+}
+
+// rollupInto builds dst buckets from source, or from raw metrics when source is nil
+func (s *SQLiteStorage) rollupInto(ctx context.Context, dst, source *rollupTable, until time.Time) (int64, error) {
+	var from time.Time
+	var newest time.Time
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT bucket FROM %s ORDER BY bucket DESC LIMIT 1`, dst.name)).Scan(&newest)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == nil {
+		from = newest.Add(dst.bucket)
+	}
+	to := until.Truncate(dst.bucket)
+	if !from.Before(to) {
+		return 0, nil
+	}
+
+	var query string
+	if source == nil {
+		query = fmt.Sprintf(`
+		SELECT target_name, instance_name, status, 1, %s, timestamp
+		FROM pool_metrics
+		WHERE timestamp >= ? AND timestamp < ?
+		ORDER BY target_name, instance_name, timestamp
+		`, strings.Join(rollupFields, ", "))
+	} else {
+		query = fmt.Sprintf(`
+		SELECT target_name, instance_name, status, samples, %s, bucket
+		FROM %s
+		WHERE bucket >= ? AND bucket < ?
+		ORDER BY target_name, instance_name, bucket
+		`, strings.Join(rollupColumns("avg", "min", "max"), ", "), source.name)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var buckets []*rollupBucket
+	var current *rollupBucket
+	n := len(rollupFields)
+	for rows.Next() {
+		var target, instance, status string
+		var samples int64
+		var ts time.Time
+		values := make([]float64, n*3)
+		if source == nil {
+			values = values[:n]
+		}
+
+		dest := []interface{}{&target, &instance, &status, &samples}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &ts)
+		if err := rows.Scan(dest...); err != nil {
+			return 0, err
+		}
+
+		avg, min, max := values, values, values
+		if source != nil {
+			avg, min, max = values[:n], values[n:2*n], values[2*n:]
+		}
+
+		start := ts.Truncate(dst.bucket)
+		if current == nil || current.target != target || current.instance != instance || !current.start.Equal(start) {
+			current = &rollupBucket{target: target, instance: instance, start: start}
+			buckets = append(buckets, current)
+		}
+		current.add(status, samples, avg, min, max)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(buckets) == 0 {
+		return 0, nil
+	}
+
+	return int64(len(buckets)), s.saveRollupBuckets(ctx, dst, buckets)
+}
+
+func (s *SQLiteStorage) saveRollupBuckets(ctx context.Context, dst *rollupTable, buckets []*rollupBucket) error {
+	cols := append([]string{"target_name", "instance_name", "bucket", "samples", "status"}, rollupColumns("avg", "min", "max")...)
+	query := fmt.Sprintf(`INSERT OR REPLACE INTO %s (%s) VALUES (?%s)`,
+		dst.name, strings.Join(cols, ", "), strings.Repeat(", ?", len(cols)-1))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, b := range buckets {
+		args := []interface{}{b.target, b.instance, b.start, b.samples, b.status}
+		for _, sum := range b.sum {
+			args = append(args, sum/float64(b.samples))
+		}
+		for _, v := range b.min {
+			args = append(args, v)
+		}
+		for _, v := range b.max {
+			args = append(args, v)
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CleanupRollups deletes rollup rows of a resolution older than the given time
+func (s *SQLiteStorage) CleanupRollups(ctx context.Context, resolution string, olderThan time.Time) (int64, error) {
+	table := findRollupTable(resolution)
+	if table == nil {
+		return 0, fmt.Errorf("unknown rollup resolution '%s'", resolution)
+	}
+	if err := s.migrateRollups(); err != nil {
+		return 0, err
+	}
+
+	result, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE bucket < ?`, table.name), olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func findRollupTable(resolution string) *rollupTable {
+	for i := range rollupTables {
+		if rollupTables[i].resolution == resolution {
+			return &rollupTables[i]
+		}
+	}
+	return nil
+}
+
+// historySource picks the table for a history query, nil meaning raw metrics
+// The range decides the preferred resolution. When that table doesn't reach back
+// to from (raw data already cleaned up, or rollups not built yet), the next table
+// that does is used, otherwise the one with the oldest data
+func (s *SQLiteStorage) historySource(ctx context.Context, targetName string, from, to time.Time) *rollupTable {
+	if err := s.migrateRollups(); err != nil {
+		return nil
+	}
+
+	sources := []*rollupTable{nil, &rollupTables[0], &rollupTables[1]}
+	preferred := 0
+	switch span := to.Sub(from); {
+	case span > minuteHistoryRange:
+		preferred = 2
+	case span > rawHistoryRange:
+		preferred = 1
+	}
+
+	// Preferred first, then coarser, then finer
+	var order []int
+	for i := preferred; i < len(sources); i++ {
+		order = append(order, i)
+	}
+	for i := preferred - 1; i >= 0; i-- {
+		order = append(order, i)
+	}
+
+	var best *rollupTable
+	var bestOldest time.Time
+	for _, i := range order {
+		oldest, ok := s.oldestSample(ctx, sources[i], targetName)
+		if !ok {
+			continue
+		}
+		if !oldest.After(from) {
+			return sources[i]
+		}
+		if bestOldest.IsZero() || oldest.Before(bestOldest) {
+			best, bestOldest = sources[i], oldest
+		}
+	}
+	return best
+}
+
+// oldestSample returns the oldest timestamp of a target in a table, nil meaning raw metrics
+func (s *SQLiteStorage) oldestSample(ctx context.Context, table *rollupTable, targetName string) (time.Time, bool) {
+	query := `SELECT timestamp FROM pool_metrics WHERE target_name = ? ORDER BY timestamp ASC LIMIT 1`
+	if table != nil {
+		query = fmt.Sprintf(`SELECT bucket FROM %s WHERE target_name = ? ORDER BY bucket ASC LIMIT 1`, table.name)
+	}
+
+	var oldest time.Time
+	if err := s.db.QueryRowContext(ctx, query, targetName).Scan(&oldest); err != nil {
+		return time.Time{}, false
+	}
+	return oldest, true
+}
+
+// bucketStatus is healthy if any sample of a bucket was, as in rollups
+const bucketStatus = `CASE WHEN SUM(status = 'healthy') > 0 THEN 'healthy' ELSE MAX(status) END`
+
+// GetHistoryAggregated averages history into buckets with GROUP BY, reading the same raw
+// or rollup table as GetHistory. Points are timestamped at their first sample; deltas are
+// bucket totals and acquire_max the bucket maximum. MinValues and MaxValues, with the usage
+// extremes under "usage", are only set for points aggregating more than one sample
+func (s *SQLiteStorage) GetHistoryAggregated(ctx context.Context, targetName, instanceName string, from, to time.Time, bucket time.Duration) ([]models.PoolMetrics, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	table := s.historySource(ctx, targetName, from, to)
+	var cols []string
+	var source, tsCol, samples string
+	if table == nil {
+		source, tsCol, samples = "pool_metrics", "timestamp", "COUNT(*)"
+		for _, f := range rollupFields {
+			if deltaFields[f] {
+				cols = append(cols, "SUM("+f+")")
+			} else {
+				cols = append(cols, "AVG("+f+")")
+			}
+		}
+		for _, f := range rollupFields {
+			cols = append(cols, "MIN("+f+")")
+		}
+		for _, f := range rollupFields {
+			cols = append(cols, "MAX("+f+")")
+		}
+		cols = append(cols, "MIN(CASE WHEN max > 0 THEN active * 100.0 / max END)", "MAX(CASE WHEN max > 0 THEN active * 100.0 / max END)")
+	} else {
+		// Rollup averages are weighted by their sample counts
+		source, tsCol, samples = table.name, "bucket", "SUM(samples)"
+		for _, f := range rollupFields {
+			if deltaFields[f] {
+				cols = append(cols, "SUM("+f+"_avg * samples)")
+			} else {
+				cols = append(cols, "SUM("+f+"_avg * samples) / SUM(samples)")
+			}
+		}
+		for _, f := range rollupFields {
+			cols = append(cols, "MIN("+f+"_min)")
+		}
+		for _, f := range rollupFields {
+			cols = append(cols, "MAX("+f+"_max)")
+		}
+		// Rollups keep no usage, its extremes are taken against the average pool size
+		cols = append(cols, "MIN(CASE WHEN max_avg > 0 THEN active_min * 100.0 / max_avg END)",
+			"MAX(CASE WHEN max_avg > 0 THEN active_max * 100.0 / max_avg END)")
+	}
+
+	where := "target_name = ?"
+	args := []interface{}{max(int64(bucket/time.Second), 1), targetName}
+	if instanceName != "" {
+		where += " AND instance_name = ?"
+		args = append(args, instanceName)
+	}
+	args = append(args, from, to)
+
+	// Timestamps are stored with their zone suffix, so buckets are computed from the
+	// leading date and time like the alert durations
+	query := fmt.Sprintf(`
+	SELECT unixepoch(substr(%[1]s, 1, 19)) / ? AS slot, MIN(instance_name), %[2]s, %[3]s, %[4]s, MIN(%[1]s)
+	FROM %[5]s
+	WHERE %[6]s AND %[1]s BETWEEN ? AND ?
+	GROUP BY slot
+	ORDER BY slot ASC
+	`, tsCol, bucketStatus, samples, strings.Join(cols, ", "), source, where)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.PoolMetrics
+	n := len(rollupFields)
+	values := make([]float64, n*3)
+	for rows.Next() {
+		m := models.PoolMetrics{TargetName: targetName}
+		var slot int64
+		var ts string
+		var minUsage, maxUsage sql.NullFloat64
+		dest := []interface{}{&slot, &m.InstanceName, &m.Status, &m.Samples}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &minUsage, &maxUsage, &ts)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if m.Timestamp, err = parseStoredTime(ts); err != nil {
+			return nil, err
+		}
+
+		setMetricValues(&m, values[:n])
+		if m.Samples > 1 {
+			m.MinValues = make(map[string]float64, n)
+			m.MaxValues = make(map[string]float64, n)
+			for i, f := range rollupFields {
+				m.MinValues[f] = values[n+i]
+				m.MaxValues[f] = values[2*n+i]
+			}
+			if minUsage.Valid && maxUsage.Valid {
+				m.MinValues["usage"], m.MaxValues["usage"] = minUsage.Float64, maxUsage.Float64
+			}
+			m.AcquireMax = m.MaxValues["acquire_max"]
+		}
+		results = append(results, m)
+	}
+	return results, rows.Err()
+}
+
+// getRollupHistory returns bucket averages as metrics, timestamped at the bucket start
+// Deltas are bucket totals, so they add up the same as in raw history
+func (s *SQLiteStorage) getRollupHistory(ctx context.Context, table *rollupTable, targetName, instanceName string, from, to time.Time) ([]models.PoolMetrics, error) {
+	where := "target_name = ?"
+	args := []interface{}{targetName}
+	if instanceName != "" {
+		where += " AND instance_name = ?"
+		args = append(args, instanceName)
+	}
+	args = append(args, from, to)
+
+	query := fmt.Sprintf(`
+	SELECT target_name, instance_name, status, samples, %s, bucket
+	FROM %s
+	WHERE %s AND bucket BETWEEN ? AND ?
+	ORDER BY bucket ASC
+	`, strings.Join(rollupColumns("avg"), ", "), table.name, where)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.PoolMetrics
+	values := make([]float64, len(rollupFields))
+	for rows.Next() {
+		var m models.PoolMetrics
+		var samples int64
+		dest := []interface{}{&m.TargetName, &m.InstanceName, &m.Status, &samples}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &m.Timestamp)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, f := range rollupFields {
+			if deltaFields[f] {
+				values[i] *= float64(samples)
+			}
+		}
+		setMetricValues(&m, values)
+		results = append(results, m)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// Storage management methods
+
+// statsTables are the tables reported by GetStorageStats
+// Tables created lazily are skipped until they exist
+var statsTables = []string{
+	"pool_metrics", "pool_metrics_1m", "pool_metrics_1h",
+	"alerts", "alert_rules", "maintenance_windows", "silences", "notification_log", "events", "annotations",
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (s *SQLiteStorage) GetStorageStats(ctx context.Context) (*models.StorageStats, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	stats := &models.StorageStats{
+		DBSize:  fileSize(s.path),
+		WALSize: fileSize(s.path + "-wal"),
+		Tables:  make(map[string]int64),
+		Targets: []models.TargetStorageStats{},
+	}
+
+	var pageSize, freePages int64
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return nil, err
+	}
+	if err := s.db.QueryRowContext(ctx, `PRAGMA freelist_count`).Scan(&freePages); err != nil {
+		return nil, err
+	}
+	stats.FreeBytes = pageSize * freePages
+
+	for _, table := range statsTables {
+		var exists int
+		err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?`, table).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			continue
+		}
+
+		// Table names come from the statsTables whitelist
+		var count int64
+		if err := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table)).Scan(&count); err != nil {
+			return nil, err
+		}
+		stats.Tables[table] = count
+	}
+
+	targets, err := s.GetTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range targets {
+		t := models.TargetStorageStats{TargetName: name}
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pool_metrics WHERE target_name = ?`, name).Scan(&t.Rows); err != nil {
+			return nil, err
+		}
+
+		// MIN/MAX lose the column type, so read the boundary rows instead
+		var oldest, newest time.Time
+		if err := s.db.QueryRowContext(ctx, `SELECT timestamp FROM pool_metrics WHERE target_name = ? ORDER BY timestamp ASC LIMIT 1`, name).Scan(&oldest); err == nil {
+			t.Oldest = &oldest
+		}
+		if err := s.db.QueryRowContext(ctx, `SELECT timestamp FROM pool_metrics WHERE target_name = ? ORDER BY timestamp DESC LIMIT 1`, name).Scan(&newest); err == nil {
+			t.Newest = &newest
+		}
+		stats.Targets = append(stats.Targets, t)
+	}
+
+	return stats, nil
+}
+
+func (s *SQLiteStorage) Vacuum(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return err
+	}
+	// Shrink the WAL file as well
+	_, err := s.db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`)
+	return err
+}
+
+// migrateTargetTables creates the lazily created tables holding per-target data
+func (s *SQLiteStorage) migrateTargetTables() error {
+	for _, migrate := range []func() error{
+		s.migrateRollups,
+		s.migrateEvents,
+		s.migrateAnnotations,
+		s.migrateHealthChecks,
+		s.migrateRecommendations,
+		s.migrateInactiveInstances,
+	} {
+		if err := migrate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targetDataTables returns the tables holding a target's history
+// Table names are hardcoded whitelist - safe from SQL injection
+func targetDataTables() []string {
+	tables := []string{"pool_metrics", "alerts", "events", "annotations", "health_checks", "recommendations", "inactive_instances"}
+	for _, t := range rollupTables {
+		tables = append(tables, t.name)
+	}
+	return tables
+}
+
+func (s *SQLiteStorage) PurgeTarget(ctx context.Context, targetName string) (int64, error) {
+	if err := s.migrateTargetTables(); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var deleted int64
+	for _, table := range targetDataTables() {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE target_name = ?`, table), targetName)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, tx.Commit()
+}
+
+func (s *SQLiteStorage) RenameTarget(ctx context.Context, oldName, newName string) (int64, error) {
+	if err := s.migrateTargetTables(); err != nil {
+		return 0, err
+	}
+	if err := s.migrateMaintenanceWindows(); err != nil {
+		return 0, err
+	}
+	if err := s.migrateSilences(); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Maintenance windows and silences follow the target so they keep applying
+	tables := append(targetDataTables(), "maintenance_windows", "silences")
+
+	var moved int64
+	for _, table := range tables {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET target_name = ? WHERE target_name = ?`, table), newName, oldName)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", table, err)
+		}
+		n, _ := result.RowsAffected()
+		moved += n
+	}
+	return moved, tx.Commit()
+}
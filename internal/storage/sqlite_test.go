@@ -1,6 +1,10 @@
 package storage
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
@@ -60,7 +64,7 @@ func TestSQLiteStorage_Save(t *testing.T) {
 		Timestamp:    time.Now(),
 	}
 
-	err := storage.Save(metrics)
+	err := storage.Save(context.Background(), metrics)
 	if err != nil {
 		t.Fatalf("Save() error = %v", err)
 	}
@@ -82,13 +86,13 @@ func TestSQLiteStorage_GetLatest(t *testing.T) {
 			Max:          20,
 			Timestamp:    now.Add(time.Duration(i) * time.Minute),
 		}
-		if err := storage.Save(metrics); err != nil {
+		if err := storage.Save(context.Background(), metrics); err != nil {
 			t.Fatalf("Save() error = %v", err)
 		}
 	}
 
 	// Get latest
-	latest, err := storage.GetLatest("test-target")
+	latest, err := storage.GetLatest(context.Background(), "test-target")
 	if err != nil {
 		t.Fatalf("GetLatest() error = %v", err)
 	}
@@ -107,7 +111,7 @@ func TestSQLiteStorage_GetLatest_NotFound(t *testing.T) {
 	storage, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	latest, err := storage.GetLatest("non-existent")
+	latest, err := storage.GetLatest(context.Background(), "non-existent")
 	if err != nil {
 		t.Fatalf("GetLatest() error = %v", err)
 	}
@@ -117,6 +121,26 @@ func TestSQLiteStorage_GetLatest_NotFound(t *testing.T) {
 	}
 }
 
+func TestSQLiteStorage_ContextCancelled(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := storage.GetLatest(ctx, "test-target"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetLatest() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+	if err := storage.Save(ctx, &models.PoolMetrics{TargetName: "test-target", Timestamp: time.Now()}); err == nil {
+		t.Error("Save() with cancelled context should fail")
+	}
+
+	// The query timeout applies even without a deadline of the caller
+	storage.SetQueryTimeout(time.Nanosecond)
+	if _, err := storage.GetLatest(context.Background(), "test-target"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetLatest() past the query timeout error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
 func TestSQLiteStorage_GetHistory(t *testing.T) {
 	storage, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -132,7 +156,7 @@ func TestSQLiteStorage_GetHistory(t *testing.T) {
 			Max:          20,
 			Timestamp:    now.Add(time.Duration(-i*5) * time.Minute),
 		}
-		if err := storage.Save(metrics); err != nil {
+		if err := storage.Save(context.Background(), metrics); err != nil {
 			t.Fatalf("Save() error = %v", err)
 		}
 	}
@@ -140,7 +164,7 @@ func TestSQLiteStorage_GetHistory(t *testing.T) {
 	// Get 1 hour of history (from 1 hour ago to now)
 	from := now.Add(-time.Hour)
 	to := now
-	history, err := storage.GetHistory("test-target", from, to)
+	history, err := storage.GetHistory(context.Background(), "test-target", from, to)
 	if err != nil {
 		t.Fatalf("GetHistory() error = %v", err)
 	}
@@ -151,6 +175,68 @@ func TestSQLiteStorage_GetHistory(t *testing.T) {
 	}
 }
 
+func TestSQLiteStorage_GetHistoryAggregated(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Two instances sampled every 20s for 2 minutes
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	for i := 0; i < 6; i++ {
+		for _, inst := range []string{"a", "b"} {
+			m := &models.PoolMetrics{
+				TargetName:   "test-target",
+				InstanceName: inst,
+				Status:       models.StatusHealthy,
+				Active:       i,
+				Max:          20,
+				TimeoutDelta: 1,
+				AcquireMax:   float64(i * 10),
+				Timestamp:    base.Add(time.Duration(i) * 20 * time.Second),
+			}
+			if inst == "b" {
+				m.Status = models.StatusError
+				m.Active += 10
+			}
+			if err := storage.Save(context.Background(), m); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+		}
+	}
+
+	history, err := storage.GetHistoryAggregated(context.Background(), "test-target", "", base, base.Add(2*time.Minute), time.Minute)
+	if err != nil {
+		t.Fatalf("GetHistoryAggregated() error = %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("got %d points, want 2 one-minute buckets", len(history))
+	}
+	first := history[0]
+	if first.Samples != 6 || first.Active != 6 || first.MinValues["active"] != 0 || first.MaxValues["active"] != 12 {
+		t.Errorf("first bucket = %d samples, active %d (%v..%v); want 6, 6 (0..12)",
+			first.Samples, first.Active, first.MinValues["active"], first.MaxValues["active"])
+	}
+	if first.MinValues["usage"] != 0 || first.MaxValues["usage"] != 60 {
+		t.Errorf("first bucket usage = %v..%v, want 0..60", first.MinValues["usage"], first.MaxValues["usage"])
+	}
+	// Deltas add up, acquire_max keeps the peak, and a bucket is healthy if any sample was
+	if first.TimeoutDelta != 6 || first.AcquireMax != 20 || first.Status != models.StatusHealthy {
+		t.Errorf("first bucket = timeouts %d, acquire max %v, status %s; want 6, 20, healthy",
+			first.TimeoutDelta, first.AcquireMax, first.Status)
+	}
+	if !first.Timestamp.Equal(base) {
+		t.Errorf("first bucket timestamp = %v, want %v", first.Timestamp, base)
+	}
+
+	// One instance, with single-sample buckets carrying no extremes
+	history, err = storage.GetHistoryAggregated(context.Background(), "test-target", "b", base, base.Add(2*time.Minute), time.Second)
+	if err != nil {
+		t.Fatalf("GetHistoryAggregated() error = %v", err)
+	}
+	if len(history) != 6 || history[0].InstanceName != "b" || history[0].Status != models.StatusError || history[0].MinValues != nil {
+		t.Errorf("instance history = %+v, want 6 raw points of b", history)
+	}
+}
+
 func TestSQLiteStorage_Cleanup(t *testing.T) {
 	storage, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -174,12 +260,12 @@ func TestSQLiteStorage_Cleanup(t *testing.T) {
 		Timestamp:    now,
 	}
 
-	storage.Save(oldMetrics)
-	storage.Save(newMetrics)
+	storage.Save(context.Background(), oldMetrics)
+	storage.Save(context.Background(), newMetrics)
 
 	// Delete data older than 24 hours (pass the cutoff time, not duration)
 	olderThan := now.Add(-24 * time.Hour)
-	deleted, err := storage.Cleanup(olderThan)
+	deleted, err := storage.Cleanup(context.Background(), olderThan)
 	if err != nil {
 		t.Fatalf("Cleanup() error = %v", err)
 	}
@@ -191,12 +277,39 @@ func TestSQLiteStorage_Cleanup(t *testing.T) {
 	// Verify only new metrics remain (from 72 hours ago to now)
 	from := now.Add(-72 * time.Hour)
 	to := now.Add(time.Hour) // add buffer
-	history, _ := storage.GetHistory("test-target", from, to)
+	history, _ := storage.GetHistory(context.Background(), "test-target", from, to)
 	if len(history) != 1 {
 		t.Errorf("expected 1 remaining record, got %d", len(history))
 	}
 }
 
+func TestSQLiteStorage_CleanupPerTarget(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	for _, target := range []string{"prod", "dev", "other"} {
+		for _, age := range []time.Duration{10 * 24 * time.Hour, 3 * 24 * time.Hour} {
+			storage.Save(context.Background(), &models.PoolMetrics{TargetName: target, InstanceName: "default", Max: 20, Timestamp: now.Add(-age)})
+		}
+	}
+
+	// dev keeps 1 day, prod is excluded from the 7 day global cleanup
+	if deleted, err := storage.CleanupTarget(context.Background(), "dev", now.Add(-24*time.Hour)); err != nil || deleted != 2 {
+		t.Fatalf("CleanupTarget() = %d, %v, want 2", deleted, err)
+	}
+	if deleted, err := storage.Cleanup(context.Background(), now.Add(-7*24*time.Hour), "prod", "dev"); err != nil || deleted != 1 {
+		t.Fatalf("Cleanup() = %d, %v, want 1", deleted, err)
+	}
+
+	for target, want := range map[string]int{"prod": 2, "dev": 0, "other": 1} {
+		history, _ := storage.GetHistory(context.Background(), target, now.Add(-30*24*time.Hour), now.Add(time.Hour))
+		if len(history) != want {
+			t.Errorf("%s: %d remaining records, want %d", target, len(history), want)
+		}
+	}
+}
+
 func TestSQLiteStorage_GetLatestByInstance(t *testing.T) {
 	storage, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -213,13 +326,13 @@ func TestSQLiteStorage_GetLatestByInstance(t *testing.T) {
 			Max:          20,
 			Timestamp:    now,
 		}
-		if err := storage.Save(metrics); err != nil {
+		if err := storage.Save(context.Background(), metrics); err != nil {
 			t.Fatalf("Save() error = %v", err)
 		}
 	}
 
 	// Get latest for inst-2
-	latest, err := storage.GetLatestByInstance("test-target", "inst-2")
+	latest, err := storage.GetLatestByInstance(context.Background(), "test-target", "inst-2")
 	if err != nil {
 		t.Fatalf("GetLatestByInstance() error = %v", err)
 	}
@@ -252,11 +365,11 @@ func TestSQLiteStorage_GetLatestAllInstances(t *testing.T) {
 			Max:          20,
 			Timestamp:    now,
 		}
-		storage.Save(metrics)
+		storage.Save(context.Background(), metrics)
 	}
 
 	// Get all instances
-	all, err := storage.GetLatestAllInstances("test-target")
+	all, err := storage.GetLatestAllInstances(context.Background(), "test-target")
 	if err != nil {
 		t.Fatalf("GetLatestAllInstances() error = %v", err)
 	}
@@ -265,3 +378,1321 @@ func TestSQLiteStorage_GetLatestAllInstances(t *testing.T) {
 		t.Errorf("expected 2 instances, got %d", len(all))
 	}
 }
+
+func TestSQLiteStorage_GetLatestTimestamps(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	base := time.Now().Add(-time.Minute).Truncate(time.Second)
+	samples := []struct {
+		instance string
+		offset   time.Duration
+	}{{"inst-1", 0}, {"inst-1", 20 * time.Second}, {"inst-2", 10 * time.Second}}
+	for _, s := range samples {
+		storage.Save(context.Background(), &models.PoolMetrics{
+			TargetName:   "test-target",
+			InstanceName: s.instance,
+			Status:       models.StatusHealthy,
+			Max:          10,
+			Timestamp:    base.Add(s.offset),
+		})
+	}
+
+	latest, err := storage.GetLatestTimestamps(context.Background(), "test-target")
+	if err != nil {
+		t.Fatalf("GetLatestTimestamps() error = %v", err)
+	}
+	if len(latest) != 2 || !latest["inst-1"].Equal(base.Add(20*time.Second)) || !latest["inst-2"].Equal(base.Add(10*time.Second)) {
+		t.Errorf("GetLatestTimestamps() = %v", latest)
+	}
+}
+
+func TestSQLiteStorage_GetFleetSnapshot(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	baseline := now.Add(-24 * time.Hour)
+	samples := []models.PoolMetrics{
+		{TargetName: "a", InstanceName: "pod-1", Active: 1, Timestamp: now.Add(-2 * time.Minute)},
+		{TargetName: "a", InstanceName: "pod-1", Active: 2, Timestamp: now.Add(-time.Minute)},
+		{TargetName: "a", InstanceName: "pod-2", Active: 3, Timestamp: now.Add(-time.Minute)},
+		{TargetName: "b", InstanceName: "default", Active: 4, Timestamp: now.Add(-time.Minute)},
+		{TargetName: "b", InstanceName: "default", Active: 5, Timestamp: now.Add(-2 * time.Hour)}, // outside both windows
+		{TargetName: "a", InstanceName: "pod-1", Active: 6, Timestamp: baseline.Add(-5 * time.Minute)},
+		{TargetName: "a", InstanceName: "pod-1", Active: 7, Timestamp: baseline.Add(time.Minute)}, // after baseline
+	}
+	for i := range samples {
+		samples[i].Status = models.StatusHealthy
+		if err := storage.Save(context.Background(), &samples[i]); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	current, previous, err := storage.GetFleetSnapshot(context.Background(), now, baseline, time.Hour)
+	if err != nil {
+		t.Fatalf("GetFleetSnapshot() error = %v", err)
+	}
+
+	if len(current) != 3 {
+		t.Fatalf("len(current) = %d, want 3", len(current))
+	}
+	if current[0].InstanceName != "pod-1" || current[0].Active != 2 {
+		t.Errorf("current[0] = %s/%d, want latest pod-1 sample", current[0].InstanceName, current[0].Active)
+	}
+	if current[2].TargetName != "b" || current[2].Active != 4 {
+		t.Errorf("current[2] = %s/%d, want b/4", current[2].TargetName, current[2].Active)
+	}
+
+	if len(previous) != 1 || previous[0].Active != 6 {
+		t.Errorf("previous = %+v, want the pod-1 sample before the baseline", previous)
+	}
+}
+
+func TestSQLiteStorage_Silences(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	active := &models.Silence{
+		TargetName: "order-*",
+		RuleName:   "high_usage",
+		StartsAt:   now.Add(-time.Minute),
+		EndsAt:     now.Add(time.Hour),
+	}
+	expired := &models.Silence{
+		TargetName: "user-service",
+		StartsAt:   now.Add(-2 * time.Hour),
+		EndsAt:     now.Add(-time.Hour),
+	}
+	for _, s := range []*models.Silence{active, expired} {
+		if err := storage.SaveSilence(context.Background(), s); err != nil {
+			t.Fatalf("SaveSilence() error = %v", err)
+		}
+	}
+
+	all, err := storage.GetAllSilences(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllSilences() error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected 2 silences, got %d", len(all))
+	}
+
+	activeSilences, err := storage.GetActiveSilences(context.Background())
+	if err != nil {
+		t.Fatalf("GetActiveSilences() error = %v", err)
+	}
+	if len(activeSilences) != 1 || activeSilences[0].ID != active.ID {
+		t.Fatalf("expected only silence %d to be active, got %+v", active.ID, activeSilences)
+	}
+	if !activeSilences[0].Matches("order-service", "order-1", "high_usage", models.SeverityWarning) {
+		t.Error("expected active silence to match order-service/high_usage")
+	}
+
+	deleted, err := storage.CleanupSilences(context.Background(), now.Add(-time.Minute))
+	if err != nil || deleted != 1 {
+		t.Fatalf("CleanupSilences() = %d, %v, want the expired silence deleted", deleted, err)
+	}
+	if all, _ := storage.GetAllSilences(context.Background()); len(all) != 1 || all[0].ID != active.ID {
+		t.Errorf("silences after cleanup = %+v, want only the active one", all)
+	}
+
+	if err := storage.DeleteSilence(context.Background(), active.ID); err != nil {
+		t.Fatalf("DeleteSilence() error = %v", err)
+	}
+	got, err := storage.GetSilence(context.Background(), active.ID)
+	if err != nil {
+		t.Fatalf("GetSilence() error = %v", err)
+	}
+	if got != nil {
+		t.Error("expected silence to be deleted")
+	}
+}
+
+func TestSQLiteStorage_AlertRuleChannels(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	rule := &models.AlertRule{
+		Name:      "pool_exhausted",
+		Condition: "pending > 5",
+		Severity:  models.SeverityCritical,
+		Enabled:   true,
+		Channels:  []string{"slack", "plugin:pagerduty"},
+	}
+	if err := storage.SaveAlertRule(context.Background(), rule); err != nil {
+		t.Fatalf("SaveAlertRule() error = %v", err)
+	}
+
+	got, err := storage.GetAlertRuleByName(context.Background(), "pool_exhausted")
+	if err != nil {
+		t.Fatalf("GetAlertRuleByName() error = %v", err)
+	}
+	if len(got.Channels) != 2 || got.Channels[1] != "plugin:pagerduty" {
+		t.Errorf("expected channels [slack plugin:pagerduty], got %v", got.Channels)
+	}
+
+	got.Channels = nil
+	if err := storage.UpdateAlertRule(context.Background(), got); err != nil {
+		t.Fatalf("UpdateAlertRule() error = %v", err)
+	}
+	rules, err := storage.GetAlertRules(context.Background())
+	if err != nil {
+		t.Fatalf("GetAlertRules() error = %v", err)
+	}
+	if len(rules) != 1 || rules[0].Channels != nil {
+		t.Errorf("expected rule to route to all channels, got %+v", rules)
+	}
+}
+
+func TestSQLiteStorage_AlertRuleLabels(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	rule := &models.AlertRule{
+		Name:      "payments_pending",
+		Condition: "pending > 5",
+		Severity:  models.SeverityWarning,
+		Enabled:   true,
+		Labels:    map[string]string{"team": "payments", "env": "prod"},
+	}
+	if err := storage.SaveAlertRule(context.Background(), rule); err != nil {
+		t.Fatalf("SaveAlertRule() error = %v", err)
+	}
+	got, err := storage.GetAlertRule(context.Background(), rule.ID)
+	if err != nil {
+		t.Fatalf("GetAlertRule() error = %v", err)
+	}
+	if len(got.Labels) != 2 || got.Labels["team"] != "payments" {
+		t.Errorf("expected labels team=payments env=prod, got %v", got.Labels)
+	}
+
+	got.Labels = nil
+	if err := storage.UpdateAlertRule(context.Background(), got); err != nil {
+		t.Fatalf("UpdateAlertRule() error = %v", err)
+	}
+	if got, _ = storage.GetAlertRule(context.Background(), rule.ID); got.Labels != nil {
+		t.Errorf("expected rule to apply to all targets, got labels %v", got.Labels)
+	}
+}
+
+func TestSQLiteStorage_AlertRuleOrigin(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	rule := &models.AlertRule{Name: "pending", Condition: "pending > 5", Severity: models.SeverityWarning, Enabled: true}
+	if err := storage.SaveAlertRule(context.Background(), rule); err != nil {
+		t.Fatalf("SaveAlertRule() error = %v", err)
+	}
+	got, err := storage.GetAlertRule(context.Background(), rule.ID)
+	if err != nil || got.Origin != models.RuleOriginAPI || got.IsConfigManaged() {
+		t.Fatalf("GetAlertRule() = %+v, %v, want an API rule", got, err)
+	}
+
+	got.Origin = models.RuleOriginConfig
+	got.Targets = []string{"orders", "billing"}
+	got.Groups = []string{"prod"}
+	if err := storage.UpdateAlertRule(context.Background(), got); err != nil {
+		t.Fatalf("UpdateAlertRule() error = %v", err)
+	}
+	got, _ = storage.GetAlertRuleByName(context.Background(), "pending")
+	if !got.IsConfigManaged() || len(got.Targets) != 2 || got.Groups[0] != "prod" {
+		t.Errorf("expected config-managed rule for orders and billing in prod, got %+v", got)
+	}
+}
+
+func TestSQLiteStorage_ProjectScoping(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	rule := &models.AlertRule{Name: "payments_pending", Condition: "pending > 5", Severity: models.SeverityWarning, Enabled: true, Project: "payments"}
+	if err := storage.SaveAlertRule(context.Background(), rule); err != nil {
+		t.Fatalf("SaveAlertRule() error = %v", err)
+	}
+	got, err := storage.GetAlertRule(context.Background(), rule.ID)
+	if err != nil || got.Project != "payments" {
+		t.Fatalf("GetAlertRule() = %+v, %v, want project payments", got, err)
+	}
+
+	now := time.Now()
+	window := &models.MaintenanceWindow{Name: "payments deploy", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour), Project: "payments"}
+	if err := storage.SaveMaintenanceWindow(context.Background(), window); err != nil {
+		t.Fatalf("SaveMaintenanceWindow() error = %v", err)
+	}
+	if in, err := storage.IsInMaintenanceWindow(context.Background(), "orders", "", "payments", now); err != nil || !in {
+		t.Errorf("IsInMaintenanceWindow(orders, payments) = %v, %v, want true", in, err)
+	}
+	if in, err := storage.IsInMaintenanceWindow(context.Background(), "search-api", "", "search", now); err != nil || in {
+		t.Errorf("IsInMaintenanceWindow(search-api, search) = %v, %v, want false", in, err)
+	}
+
+	grouped := &models.MaintenanceWindow{Name: "prod patching", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour), Targets: []string{"inventory-*"}, Groups: []string{"prod"}}
+	if err := storage.SaveMaintenanceWindow(context.Background(), grouped); err != nil {
+		t.Fatalf("SaveMaintenanceWindow() error = %v", err)
+	}
+	if got, err := storage.GetMaintenanceWindow(context.Background(), grouped.ID); err != nil || len(got.Targets) != 1 || len(got.Groups) != 1 || got.Groups[0] != "prod" {
+		t.Errorf("GetMaintenanceWindow() = %+v, %v, want targets and groups", got, err)
+	}
+	if in, err := storage.IsInMaintenanceWindow(context.Background(), "search-api", "prod", "search", now); err != nil || !in {
+		t.Errorf("IsInMaintenanceWindow(search-api, prod) = %v, %v, want true", in, err)
+	}
+
+	for _, a := range []models.Alert{{TargetName: "orders", RuleName: "r", Status: models.AlertStatusFired}, {TargetName: "search-api", RuleName: "r", Status: models.AlertStatusFired}} {
+		a.FiredAt = now
+		if err := storage.SaveAlert(context.Background(), &a); err != nil {
+			t.Fatalf("SaveAlert() error = %v", err)
+		}
+	}
+	alerts, total, err := storage.QueryAlerts(context.Background(), models.AlertQuery{TargetIn: []string{"orders"}})
+	if err != nil || total != 1 || alerts[0].TargetName != "orders" {
+		t.Errorf("QueryAlerts(TargetIn orders) = %+v, %d, %v", alerts, total, err)
+	}
+	if _, total, _ := storage.QueryAlerts(context.Background(), models.AlertQuery{TargetIn: []string{}}); total != 0 {
+		t.Errorf("QueryAlerts(empty TargetIn) total = %d, want 0", total)
+	}
+}
+
+func TestSQLiteStorage_QueryAlerts(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	alerts := []models.Alert{
+		{TargetName: "orders", RuleName: "high_usage", Severity: models.SeverityWarning, Status: models.AlertStatusResolved},
+		{TargetName: "orders", RuleName: "pending", Severity: models.SeverityCritical, Status: models.AlertStatusFired},
+		{TargetName: "billing", RuleName: "high_usage", Severity: models.SeverityInfo, Status: models.AlertStatusFired},
+		{TargetName: "orders", RuleName: "high_usage", Severity: models.SeverityCritical, Status: models.AlertStatusFired},
+		{TargetName: "billing", RuleName: "pending", Severity: models.SeverityWarning, Status: models.AlertStatusResolved},
+	}
+	for i := range alerts {
+		alerts[i].InstanceName = "default"
+		alerts[i].FiredAt = base.Add(time.Duration(i) * time.Minute)
+		if err := storage.SaveAlert(context.Background(), &alerts[i]); err != nil {
+			t.Fatalf("SaveAlert failed: %v", err)
+		}
+	}
+
+	ids := func(got []models.Alert) []int64 {
+		var out []int64
+		for _, a := range got {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+	check := func(name string, q models.AlertQuery, wantTotal int, want ...int) {
+		t.Helper()
+		got, total, err := storage.QueryAlerts(context.Background(), q)
+		if err != nil {
+			t.Fatalf("%s: QueryAlerts failed: %v", name, err)
+		}
+		var wantIDs []int64
+		for _, i := range want {
+			wantIDs = append(wantIDs, alerts[i].ID)
+		}
+		if total != wantTotal || len(got) != len(wantIDs) {
+			t.Fatalf("%s: got %v (total %d), want %v (total %d)", name, ids(got), total, wantIDs, wantTotal)
+		}
+		for i := range got {
+			if got[i].ID != wantIDs[i] {
+				t.Fatalf("%s: got %v, want %v", name, ids(got), wantIDs)
+			}
+		}
+	}
+
+	check("all, newest first", models.AlertQuery{}, 5, 4, 3, 2, 1, 0)
+	check("filters", models.AlertQuery{TargetName: "orders", Status: models.AlertStatusFired}, 2, 3, 1)
+	check("severities and rule", models.AlertQuery{RuleName: "high_usage", Severities: []string{models.SeverityInfo, models.SeverityWarning}}, 2, 2, 0)
+	check("time range", models.AlertQuery{From: base.Add(time.Minute), To: base.Add(3 * time.Minute), Ascending: true}, 3, 1, 2, 3)
+	check("offset", models.AlertQuery{Limit: 2, Offset: 2}, 5, 2, 1)
+
+	// Severity sort breaks ties by ID; cursors continue after the given alert
+	check("severity sort", models.AlertQuery{SortBy: models.AlertSortSeverity, Limit: 3}, 5, 3, 1, 4)
+	check("cursor", models.AlertQuery{SortBy: models.AlertSortSeverity, Limit: 3, AfterID: alerts[4].ID}, 5, 0, 2)
+}
+
+func TestSQLiteStorage_GetAlertTimeseries(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now().Truncate(time.Second)
+	save := func(target, instance, rule string, firedAgo, resolvedAfter time.Duration, status string) {
+		t.Helper()
+		a := models.Alert{TargetName: target, InstanceName: instance, RuleName: rule, Severity: models.SeverityWarning, Status: status, FiredAt: now.Add(-firedAgo)}
+		if resolvedAfter > 0 {
+			resolved := a.FiredAt.Add(resolvedAfter)
+			a.ResolvedAt = &resolved
+		}
+		if err := storage.SaveAlert(context.Background(), &a); err != nil {
+			t.Fatalf("SaveAlert failed: %v", err)
+		}
+	}
+
+	// high_usage flaps on orders/a, pending fires once on billing and is still active
+	for i := 1; i <= 4; i++ {
+		save("orders", "a", "high_usage", time.Duration(i)*time.Hour, 2*time.Minute, models.AlertStatusResolved)
+	}
+	save("orders", "b", "high_usage", 30*time.Minute, 10*time.Minute, models.AlertStatusResolved)
+	save("billing", "a", "pending", 10*time.Minute, 0, models.AlertStatusFired)
+	save("billing", "a", "pending", 20*time.Minute, 0, models.AlertStatusSuppressed)
+	save("orders", "a", "high_usage", 40*24*time.Hour, time.Minute, models.AlertStatusResolved) // Out of range
+
+	q := models.AlertTimeseriesQuery{From: now.Add(-7 * 24 * time.Hour), To: now, Limit: 10, FlapThreshold: 3}
+	got, err := storage.GetAlertTimeseries(context.Background(), q)
+	if err != nil {
+		t.Fatalf("GetAlertTimeseries failed: %v", err)
+	}
+
+	fired, resolved := 0, 0
+	for _, d := range got.Days {
+		fired += d.Fired
+		resolved += d.Resolved
+	}
+	if len(got.Days) < 7 || fired != 6 || resolved != 5 {
+		t.Errorf("expected 6 fired and 5 resolved over %d+ days, got %d fired, %d resolved over %d days", 7, fired, resolved, len(got.Days))
+	}
+
+	// 4 alerts of 2m and one of 10m
+	if got.MTTRSeconds != 216 {
+		t.Errorf("expected MTTR of 216s, got %v", got.MTTRSeconds)
+	}
+	if len(got.MTTRByRule) != 1 || got.MTTRByRule[0].Name != "high_usage" || got.MTTRByRule[0].Resolved != 5 || got.MTTRByRule[0].MaxSeconds != 600 {
+		t.Errorf("unexpected MTTR by rule: %+v", got.MTTRByRule)
+	}
+	if len(got.MTTRByTarget) != 1 || got.MTTRByTarget[0].Name != "orders" {
+		t.Errorf("unexpected MTTR by target: %+v", got.MTTRByTarget)
+	}
+
+	if len(got.NoisyRules) != 2 || got.NoisyRules[0].Rule != "high_usage" || got.NoisyRules[0].Fired != 5 || got.NoisyRules[0].Instances != 2 {
+		t.Errorf("unexpected noisy rules: %+v", got.NoisyRules)
+	}
+
+	if len(got.Flapping) != 1 {
+		t.Fatalf("expected one flapping alert, got %+v", got.Flapping)
+	}
+	f := got.Flapping[0]
+	if f.TargetName != "orders" || f.InstanceName != "a" || f.Fires != 4 || f.MeanDurationSeconds != 120 || !f.LastFiredAt.Equal(now.Add(-time.Hour)) {
+		t.Errorf("unexpected flapping alert: %+v", f)
+	}
+
+	// Project scoping limits every aggregate to the given targets
+	q.TargetIn = []string{"billing"}
+	got, err = storage.GetAlertTimeseries(context.Background(), q)
+	if err != nil {
+		t.Fatalf("GetAlertTimeseries failed: %v", err)
+	}
+	if len(got.NoisyRules) != 1 || got.NoisyRules[0].Rule != "pending" || len(got.MTTRByRule) != 0 || len(got.Flapping) != 0 {
+		t.Errorf("expected only billing alerts, got %+v", got)
+	}
+}
+
+func TestSQLiteStorage_ApplyAlertAction(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	alerts := []models.Alert{
+		{TargetName: "orders", RuleName: "high_usage", Status: models.AlertStatusFired},
+		{TargetName: "orders", RuleName: "high_usage", Status: models.AlertStatusFired},
+		{TargetName: "orders", RuleName: "pending", Status: models.AlertStatusFired},
+		{TargetName: "billing", RuleName: "high_usage", Status: models.AlertStatusResolved},
+	}
+	for i := range alerts {
+		alerts[i].InstanceName = "default"
+		alerts[i].Severity = models.SeverityWarning
+		alerts[i].FiredAt = now
+		if err := storage.SaveAlert(context.Background(), &alerts[i]); err != nil {
+			t.Fatalf("SaveAlert failed: %v", err)
+		}
+	}
+
+	// Acknowledge by filter, twice: the second call changes nothing
+	q := models.AlertQuery{TargetName: "orders", RuleName: "high_usage"}
+	ids, err := storage.ApplyAlertAction(context.Background(), models.AlertActionAcknowledge, q, "ops", now)
+	if err != nil {
+		t.Fatalf("acknowledge failed: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != alerts[0].ID || ids[1] != alerts[1].ID {
+		t.Fatalf("acknowledged %v, want alerts 0 and 1", ids)
+	}
+	if ids, _ := storage.ApplyAlertAction(context.Background(), models.AlertActionAcknowledge, q, "ops", now); len(ids) != 0 {
+		t.Errorf("acknowledged again: %v", ids)
+	}
+	got, _ := storage.GetAlert(context.Background(), alerts[0].ID)
+	if got.AcknowledgedAt == nil || got.AcknowledgedBy != "ops" || got.Status != models.AlertStatusFired {
+		t.Errorf("alert after acknowledge = %+v", got)
+	}
+
+	// Resolve by ID skips alerts already resolved
+	ids, err = storage.ApplyAlertAction(context.Background(), models.AlertActionResolve, models.AlertQuery{IDs: []int64{alerts[2].ID, alerts[3].ID}}, "", now)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != alerts[2].ID {
+		t.Errorf("resolved %v, want alert 2", ids)
+	}
+	if got, _ := storage.GetAlert(context.Background(), alerts[2].ID); got.Status != models.AlertStatusResolved || got.ResolvedAt == nil {
+		t.Errorf("alert after resolve = %+v", got)
+	}
+
+	// Delete removes alerts in any state
+	ids, err = storage.ApplyAlertAction(context.Background(), models.AlertActionDelete, models.AlertQuery{RuleName: "high_usage"}, "", now)
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if len(ids) != 3 {
+		t.Errorf("deleted %v, want 3 alerts", ids)
+	}
+	if remaining, total, _ := storage.QueryAlerts(context.Background(), models.AlertQuery{}); total != 1 || remaining[0].ID != alerts[2].ID {
+		t.Errorf("remaining = %v, want alert 2", remaining)
+	}
+}
+
+func TestSQLiteStorage_AlertVersion(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	initial, updated, err := storage.GetAlertVersion(ctx)
+	if err != nil {
+		t.Fatalf("GetAlertVersion() error = %v", err)
+	}
+	if !updated.IsZero() {
+		t.Errorf("updated = %v before any alert, want zero", updated)
+	}
+
+	alert := &models.Alert{TargetName: "orders", InstanceName: "default", RuleName: "high_usage",
+		Severity: models.SeverityWarning, Status: models.AlertStatusFired, FiredAt: time.Now()}
+	if err := storage.SaveAlert(ctx, alert); err != nil {
+		t.Fatalf("SaveAlert failed: %v", err)
+	}
+	created, updated, _ := storage.GetAlertVersion(ctx)
+	if created == initial || updated.IsZero() {
+		t.Errorf("version after insert = %s at %v, want a change from %s", created, updated, initial)
+	}
+
+	// Updates keep the highest ID but still change the version
+	alert.Status = models.AlertStatusResolved
+	if err := storage.UpdateAlert(ctx, alert); err != nil {
+		t.Fatalf("UpdateAlert failed: %v", err)
+	}
+	resolved, _, _ := storage.GetAlertVersion(ctx)
+	if resolved == created {
+		t.Errorf("version after update = %s, want a change", resolved)
+	}
+	if again, _, _ := storage.GetAlertVersion(ctx); again != resolved {
+		t.Errorf("version changed without a write: %s -> %s", resolved, again)
+	}
+}
+
+func TestSQLiteStorage_AlertSnapshot(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	alert := &models.Alert{TargetName: "orders", InstanceName: "default", RuleName: "high_usage",
+		Severity: models.SeverityWarning, Status: models.AlertStatusFired, FiredAt: time.Now(),
+		Snapshot: map[string]float64{"usage": 92.5, "pending": 3}}
+	if err := storage.SaveAlert(ctx, alert); err != nil {
+		t.Fatalf("SaveAlert failed: %v", err)
+	}
+	values, err := storage.GetAlertSnapshot(ctx, alert.ID)
+	if err != nil {
+		t.Fatalf("GetAlertSnapshot() error = %v", err)
+	}
+	if values["usage"] != 92.5 || values["pending"] != 3 || len(values) != 2 {
+		t.Errorf("snapshot = %v, want usage and pending", values)
+	}
+
+	// Alerts stored without a snapshot, and unknown alerts, have none
+	plain := &models.Alert{TargetName: "orders", InstanceName: "default", RuleName: "no_idle",
+		Severity: models.SeverityCritical, Status: models.AlertStatusFired, FiredAt: time.Now()}
+	if err := storage.SaveAlert(ctx, plain); err != nil {
+		t.Fatalf("SaveAlert failed: %v", err)
+	}
+	for _, id := range []int64{plain.ID, 999} {
+		if values, err := storage.GetAlertSnapshot(ctx, id); err != nil || values != nil {
+			t.Errorf("GetAlertSnapshot(%d) = %v, %v; want nil", id, values, err)
+		}
+	}
+}
+
+func TestSQLiteStorage_SearchAlerts(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	alerts := []models.Alert{
+		{TargetName: "orders", RuleName: "high_usage", Message: "Pool usage at 92%"},
+		{TargetName: "billing", RuleName: "pending_connections", Message: "5 threads waiting for a connection"},
+		{TargetName: "orders-api", RuleName: "pending_connections", Message: "Connection \"acquire\" timeout"},
+	}
+	for i := range alerts {
+		alerts[i].InstanceName = "default"
+		alerts[i].Severity = models.SeverityWarning
+		alerts[i].Status = models.AlertStatusFired
+		alerts[i].FiredAt = now.Add(time.Duration(i) * time.Minute)
+		if err := storage.SaveAlert(context.Background(), &alerts[i]); err != nil {
+			t.Fatalf("SaveAlert failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"orders", []int{2, 0}},       // Target prefix, newest first
+		{"connect", []int{2, 1}},      // Message and rule prefix
+		{"pending billing", []int{1}}, // All terms must match
+		{`"acquire" OR`, []int{2}},    // Quotes and operators are plain terms
+		{"usage 92", []int{0}},
+		{"   ", nil},
+	}
+	for _, tt := range tests {
+		got, err := storage.SearchAlerts(context.Background(), tt.query, 10)
+		if err != nil {
+			t.Fatalf("SearchAlerts(%q) failed: %v", tt.query, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("SearchAlerts(%q) = %d alerts, want %d", tt.query, len(got), len(tt.want))
+		}
+		for i, idx := range tt.want {
+			if got[i].ID != alerts[idx].ID {
+				t.Errorf("SearchAlerts(%q)[%d] = %d, want %d", tt.query, i, got[i].ID, alerts[idx].ID)
+			}
+		}
+	}
+
+	// Deleted alerts leave the index
+	if _, err := storage.db.Exec(`DELETE FROM alerts WHERE id = ?`, alerts[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := storage.SearchAlerts(context.Background(), "usage", 10); len(got) != 0 {
+		t.Errorf("deleted alert still found: %v", got)
+	}
+}
+
+func TestSQLiteStorage_NotificationLog(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	deliveries := []*models.NotificationDelivery{
+		{AlertID: 1, Channel: "slack", Event: models.DeliveryEventFired, Status: models.DeliveryStatusSuccess, StatusCode: 200, LatencyMs: 120, CreatedAt: now.Add(-time.Minute)},
+		{AlertID: 1, Channel: "webhook", Event: models.DeliveryEventFired, Status: models.DeliveryStatusFailed, StatusCode: 503, Error: "webhook failed", Retries: 2, CreatedAt: now.Add(-time.Minute)},
+		{AlertID: 2, Channel: "email", Event: models.DeliveryEventFired, Status: models.DeliveryStatusFailed, Error: "dial timeout", CreatedAt: now.Add(-48 * time.Hour)},
+	}
+	for _, d := range deliveries {
+		if err := storage.SaveNotificationDelivery(context.Background(), d); err != nil {
+			t.Fatalf("SaveNotificationDelivery() error = %v", err)
+		}
+	}
+
+	byAlert, err := storage.GetDeliveriesByAlert(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetDeliveriesByAlert() error = %v", err)
+	}
+	if len(byAlert) != 2 {
+		t.Fatalf("expected 2 deliveries for alert 1, got %d", len(byAlert))
+	}
+	if byAlert[1].Retries != 2 || byAlert[1].StatusCode != 503 || byAlert[1].Error != "webhook failed" {
+		t.Errorf("unexpected delivery: %+v", byAlert[1])
+	}
+
+	failed, err := storage.GetFailedDeliveries(context.Background(), now.Add(-24*time.Hour), 100)
+	if err != nil {
+		t.Fatalf("GetFailedDeliveries() error = %v", err)
+	}
+	if len(failed) != 1 || failed[0].Channel != "webhook" {
+		t.Errorf("expected only the recent webhook failure, got %+v", failed)
+	}
+
+	deleted, err := storage.CleanupNotificationLog(context.Background(), now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("CleanupNotificationLog() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 deleted entry, got %d", deleted)
+	}
+}
+
+func TestSQLiteStorage_Rollup(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Two hours of samples every 30s, active cycling 0..9, a timeout every 2 minutes
+	base := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+	for i := 0; i < 240; i++ {
+		m := &models.PoolMetrics{
+			TargetName: "rollup-target",
+			Status:     models.StatusHealthy,
+			Active:     i % 10,
+			Max:        20,
+			CpuUsage:   0.5,
+			Timestamp:  base.Add(time.Duration(i) * 30 * time.Second),
+		}
+		if i%4 == 0 {
+			m.TimeoutDelta, m.TimeoutRate = 1, 2
+			m.GcCountDelta, m.GcTimeDelta = 2, 0.1
+		}
+		if err := storage.Save(context.Background(), m); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	buckets, err := storage.Rollup(context.Background(), base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Rollup() error = %v", err)
+	}
+	if buckets != 122 {
+		t.Errorf("Rollup() = %d buckets, want 120 minutes + 2 hours", buckets)
+	}
+
+	// Running again only picks up new buckets
+	if buckets, _ := storage.Rollup(context.Background(), base.Add(2*time.Hour)); buckets != 0 {
+		t.Errorf("second Rollup() = %d buckets, want 0", buckets)
+	}
+
+	var samples int64
+	var avg, min, max float64
+	err = storage.db.QueryRow(`SELECT samples, active_avg, active_min, active_max FROM pool_metrics_1h ORDER BY bucket LIMIT 1`).
+		Scan(&samples, &avg, &min, &max)
+	if err != nil {
+		t.Fatalf("query hourly rollup: %v", err)
+	}
+	if samples != 120 || avg != 4.5 || min != 0 || max != 9 {
+		t.Errorf("hourly rollup = %d samples, avg %v, min %v, max %v; want 120, 4.5, 0, 9", samples, avg, min, max)
+	}
+
+	// Short ranges use raw data
+	history, err := storage.GetHistory(context.Background(), "rollup-target", base, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	if len(history) != 240 {
+		t.Errorf("short range returned %d points, want 240 raw samples", len(history))
+	}
+
+	// Long ranges use hourly rollups
+	history, err = storage.GetHistory(context.Background(), "rollup-target", base.Add(-30*24*time.Hour), base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	if len(history) != 2 || history[0].Max != 20 || history[0].CpuUsage != 0.5 || history[0].Status != models.StatusHealthy {
+		t.Errorf("long range returned %+v, want 2 hourly points", history)
+	}
+	// Timeout deltas add up, rates average
+	if len(history) > 0 && (history[0].TimeoutDelta != 30 || history[0].TimeoutRate != 0.5) {
+		t.Errorf("hourly timeouts = %d, rate %v; want 30, 0.5", history[0].TimeoutDelta, history[0].TimeoutRate)
+	}
+	if len(history) > 0 && (history[0].GcCountDelta != 60 || math.Abs(history[0].AvgGcPauseMs-50) > 1e-6) {
+		t.Errorf("hourly GC = %d pauses, %v ms avg; want 60, 50", history[0].GcCountDelta, history[0].AvgGcPauseMs)
+	}
+
+	// Aggregated history weights rollup averages by their samples
+	history, err = storage.GetHistoryAggregated(context.Background(), "rollup-target", "", base.Add(-30*24*time.Hour), base.Add(2*time.Hour), 2*time.Hour)
+	if err != nil {
+		t.Fatalf("GetHistoryAggregated() error = %v", err)
+	}
+	// The two hours may fall into one or two buckets, depending on the hour of base
+	var aggregated, timeouts int64
+	for _, m := range history {
+		aggregated += int64(m.Samples)
+		timeouts += m.TimeoutDelta
+		if m.Active != 5 || m.MaxValues["active"] != 9 {
+			t.Errorf("aggregated active = %d (max %v), want 5 (max 9)", m.Active, m.MaxValues["active"])
+		}
+	}
+	if aggregated != 240 || timeouts != 60 {
+		t.Errorf("aggregated rollups = %d samples, %d timeouts; want 240, 60", aggregated, timeouts)
+	}
+
+	// Once raw data is cleaned up, minute rollups serve short ranges
+	if _, err := storage.Cleanup(context.Background(), time.Now()); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	history, err = storage.GetHistoryByInstance(context.Background(), "rollup-target", "default", base, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetHistoryByInstance() error = %v", err)
+	}
+	if len(history) != 120 {
+		t.Errorf("after cleanup returned %d points, want 120 minute rollups", len(history))
+	}
+
+	deleted, err := storage.CleanupRollups(context.Background(), ResolutionMinute, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("CleanupRollups() error = %v", err)
+	}
+	if deleted != 60 {
+		t.Errorf("CleanupRollups() = %d, want 60", deleted)
+	}
+	if _, err := storage.CleanupRollups(context.Background(), "5m", time.Now()); err == nil {
+		t.Error("CleanupRollups() should reject unknown resolutions")
+	}
+}
+
+func TestSQLiteStorage_StorageStatsAndPurge(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		storage.Save(context.Background(), &models.PoolMetrics{TargetName: "kept", Timestamp: now.Add(time.Duration(i) * time.Minute)})
+		storage.Save(context.Background(), &models.PoolMetrics{TargetName: "removed", Timestamp: now.Add(time.Duration(i) * time.Minute)})
+	}
+	storage.SaveAlert(context.Background(), &models.Alert{TargetName: "removed", InstanceName: "default", RuleName: "r", Severity: models.SeverityWarning, Message: "m", Status: models.AlertStatusFired, FiredAt: now})
+
+	stats, err := storage.GetStorageStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStorageStats() error = %v", err)
+	}
+	if stats.DBSize == 0 || stats.Tables["pool_metrics"] != 6 || stats.Tables["alerts"] != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if len(stats.Targets) != 2 {
+		t.Fatalf("expected 2 targets, got %+v", stats.Targets)
+	}
+	for _, target := range stats.Targets {
+		if target.Rows != 3 || target.Oldest == nil || target.Newest == nil || !target.Newest.After(*target.Oldest) {
+			t.Errorf("unexpected target stats: %+v", target)
+		}
+	}
+
+	deleted, err := storage.PurgeTarget(context.Background(), "removed")
+	if err != nil {
+		t.Fatalf("PurgeTarget() error = %v", err)
+	}
+	if deleted != 4 {
+		t.Errorf("PurgeTarget() = %d, want 3 metrics + 1 alert", deleted)
+	}
+	if err := storage.Vacuum(context.Background()); err != nil {
+		t.Fatalf("Vacuum() error = %v", err)
+	}
+
+	targets, _ := storage.GetTargets(context.Background())
+	if len(targets) != 1 || targets[0] != "kept" {
+		t.Errorf("targets after purge = %v, want [kept]", targets)
+	}
+}
+
+func TestSQLiteStorage_InactiveInstances(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	storage.Save(context.Background(), &models.PoolMetrics{TargetName: "orders", InstanceName: "pod-a", Max: 10, Timestamp: now.Add(-time.Minute)})
+	storage.Save(context.Background(), &models.PoolMetrics{TargetName: "orders", InstanceName: "pod-b", Max: 10, Timestamp: now.Add(-10 * 24 * time.Hour)})
+
+	marked, err := storage.MarkInactiveInstances(context.Background(), now.Add(-7*24*time.Hour))
+	if err != nil || marked != 1 {
+		t.Fatalf("MarkInactiveInstances() = %d, %v, want 1", marked, err)
+	}
+	inactive, _ := storage.GetInactiveInstances(context.Background(), "orders")
+	if len(inactive) != 1 || inactive[0].InstanceName != "pod-b" {
+		t.Fatalf("inactive = %+v, want pod-b", inactive)
+	}
+
+	// Reporting again makes the instance active
+	storage.Save(context.Background(), &models.PoolMetrics{TargetName: "orders", InstanceName: "pod-b", Max: 10, Timestamp: now})
+	if _, err := storage.MarkInactiveInstances(context.Background(), now.Add(-7*24*time.Hour)); err != nil {
+		t.Fatalf("MarkInactiveInstances() error = %v", err)
+	}
+	if inactive, _ := storage.GetInactiveInstances(context.Background(), ""); len(inactive) != 0 {
+		t.Fatalf("inactive = %+v, want none after pod-b reported", inactive)
+	}
+
+	deleted, err := storage.PurgeInstance(context.Background(), "orders", "pod-b")
+	if err != nil || deleted != 2 {
+		t.Fatalf("PurgeInstance() = %d, %v, want 2", deleted, err)
+	}
+	instances, _ := storage.GetInstances(context.Background(), "orders")
+	if len(instances) != 1 || instances[0] != "pod-a" {
+		t.Errorf("instances after purge = %v, want [pod-a]", instances)
+	}
+}
+
+func TestSQLiteStorage_RenameTarget(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	storage.Save(context.Background(), &models.PoolMetrics{TargetName: "orders", InstanceName: "default", Max: 10, Timestamp: now})
+	storage.Save(context.Background(), &models.PoolMetrics{TargetName: "payments", InstanceName: "default", Max: 10, Timestamp: now})
+	storage.SaveAlert(context.Background(), &models.Alert{TargetName: "orders", InstanceName: "default", RuleName: "r", Severity: models.SeverityWarning, Message: "m", Status: models.AlertStatusFired, FiredAt: now})
+	storage.SaveMaintenanceWindow(context.Background(), &models.MaintenanceWindow{Name: "deploy", TargetName: "orders", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)})
+
+	moved, err := storage.RenameTarget(context.Background(), "orders", "order-service")
+	if err != nil {
+		t.Fatalf("RenameTarget() error = %v", err)
+	}
+	if moved != 3 {
+		t.Errorf("RenameTarget() = %d, want 1 metric + 1 alert + 1 window", moved)
+	}
+
+	if history, _ := storage.GetHistory(context.Background(), "order-service", now.Add(-time.Hour), now.Add(time.Hour)); len(history) != 1 {
+		t.Errorf("history under the new name = %d records, want 1", len(history))
+	}
+	if alert, _ := storage.GetActiveAlertByRule(context.Background(), "order-service", "default", "r"); alert == nil {
+		t.Error("alert was not moved")
+	}
+	if windows, _ := storage.GetAllMaintenanceWindows(context.Background()); len(windows) != 1 || windows[0].TargetName != "order-service" {
+		t.Errorf("maintenance windows = %+v, want moved to order-service", windows)
+	}
+	if targets, _ := storage.GetTargets(context.Background()); len(targets) != 2 {
+		t.Errorf("targets = %v, want order-service and payments", targets)
+	}
+}
+
+func TestSQLiteStorage_Events(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	deploy := &models.Event{TargetName: "order-service", Type: models.EventTypeDeploy, Version: "v2.3.1", Timestamp: now.Add(-time.Hour)}
+	old := &models.Event{TargetName: "order-service", Type: models.EventTypeConfig, Timestamp: now.Add(-48 * time.Hour)}
+	other := &models.Event{TargetName: "user-service", Type: models.EventTypeDeploy, Timestamp: now.Add(-time.Hour)}
+	for _, e := range []*models.Event{deploy, old, other} {
+		if err := storage.SaveEvent(context.Background(), e); err != nil {
+			t.Fatalf("SaveEvent() error = %v", err)
+		}
+	}
+
+	events, err := storage.GetEvents(context.Background(), "order-service", now.Add(-24*time.Hour), now)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].ID != deploy.ID || events[0].Version != "v2.3.1" {
+		t.Fatalf("GetEvents() = %+v, want only the v2.3.1 deploy", events)
+	}
+
+	got, err := storage.GetEvent(context.Background(), old.ID)
+	if err != nil || got == nil || got.Type != models.EventTypeConfig || got.Version != "" {
+		t.Fatalf("GetEvent() = %+v, %v", got, err)
+	}
+
+	if err := storage.DeleteEvent(context.Background(), old.ID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if got, _ := storage.GetEvent(context.Background(), old.ID); got != nil {
+		t.Error("expected event to be deleted")
+	}
+
+	if _, err := storage.PurgeTarget(context.Background(), "order-service"); err != nil {
+		t.Fatalf("PurgeTarget() error = %v", err)
+	}
+	if got, _ := storage.GetEvent(context.Background(), deploy.ID); got != nil {
+		t.Error("expected purge to delete the target's events")
+	}
+	if got, _ := storage.GetEvent(context.Background(), other.ID); got == nil {
+		t.Error("purge should keep other targets' events")
+	}
+}
+
+func TestSQLiteStorage_Annotations(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	maintenanceEnd := now.Add(-time.Hour)
+	incident := &models.Annotation{TargetName: "order-service", Time: now.Add(-30 * time.Minute), Tags: []string{"incident", "db"}, Text: "Primary DB failover"}
+	maintenance := &models.Annotation{Time: now.Add(-3 * time.Hour), EndTime: &maintenanceEnd, Tags: []string{"maintenance"}, Text: "Network maintenance"}
+	other := &models.Annotation{TargetName: "user-service", Time: now.Add(-30 * time.Minute), Text: "Config change"}
+	for _, a := range []*models.Annotation{incident, maintenance, other} {
+		if err := storage.SaveAnnotation(context.Background(), a); err != nil {
+			t.Fatalf("SaveAnnotation() error = %v", err)
+		}
+	}
+
+	// The maintenance started before the range but overlaps it
+	annotations, err := storage.GetAnnotations(context.Background(), "order-service", now.Add(-2*time.Hour), now)
+	if err != nil {
+		t.Fatalf("GetAnnotations() error = %v", err)
+	}
+	if len(annotations) != 2 || annotations[0].ID != maintenance.ID || annotations[1].ID != incident.ID {
+		t.Fatalf("GetAnnotations() = %+v, want global maintenance and incident", annotations)
+	}
+	if annotations[0].EndTime == nil || !annotations[1].HasTag("db") {
+		t.Errorf("unexpected annotations: %+v", annotations)
+	}
+
+	all, err := storage.GetAnnotations(context.Background(), "", now.Add(-2*time.Hour), now)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("GetAnnotations(all) = %d, %v, want 3", len(all), err)
+	}
+
+	if err := storage.DeleteAnnotation(context.Background(), incident.ID); err != nil {
+		t.Fatalf("DeleteAnnotation() error = %v", err)
+	}
+	if got, _ := storage.GetAnnotation(context.Background(), incident.ID); got != nil {
+		t.Error("expected annotation to be deleted")
+	}
+	got, err := storage.GetAnnotation(context.Background(), other.ID)
+	if err != nil || got == nil || got.EndTime != nil || len(got.Tags) != 0 {
+		t.Errorf("GetAnnotation() = %+v, %v", got, err)
+	}
+}
+
+func TestSQLiteStorage_AuditLog(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	entries := []models.AuditEntry{
+		{Actor: "ci", Action: "rule.create", Resource: "", After: []byte(`{"name":"high_usage"}`)},
+		{Actor: "ci", Action: "rule.update", Resource: "1", Before: []byte(`{"enabled":true}`), After: []byte(`{"enabled":false}`)},
+		{Actor: "10.0.0.5", Action: "target.delete", Resource: "orders", Before: []byte(`{"name":"orders"}`)},
+	}
+	for i := range entries {
+		entries[i].Timestamp = now.Add(time.Duration(i) * time.Minute)
+		entries[i].Method = "POST"
+		entries[i].Path = "/api/v1/test"
+		entries[i].Status = 200
+		if err := storage.SaveAuditEntry(context.Background(), &entries[i]); err != nil {
+			t.Fatalf("SaveAuditEntry failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    models.AuditQuery
+		want []int
+	}{
+		{"all, newest first", models.AuditQuery{}, []int{2, 1, 0}},
+		{"actor", models.AuditQuery{Actor: "ci"}, []int{1, 0}},
+		{"exact action", models.AuditQuery{Action: "rule.update"}, []int{1}},
+		{"action prefix", models.AuditQuery{Action: "rule."}, []int{1, 0}},
+		{"resource", models.AuditQuery{Resource: "orders"}, []int{2}},
+		{"time range", models.AuditQuery{From: now.Add(30 * time.Second), To: now.Add(90 * time.Second)}, []int{1}},
+		{"page", models.AuditQuery{Limit: 1, Offset: 1}, []int{1}},
+	}
+	for _, tt := range tests {
+		got, total, err := storage.QueryAuditLog(context.Background(), tt.q)
+		if err != nil {
+			t.Fatalf("%s: QueryAuditLog failed: %v", tt.name, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d entries, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i, idx := range tt.want {
+			if got[i].ID != entries[idx].ID {
+				t.Errorf("%s: entry %d = %d, want %d", tt.name, i, got[i].ID, entries[idx].ID)
+			}
+		}
+		if tt.q.Limit == 0 && total != len(tt.want) {
+			t.Errorf("%s: total = %d, want %d", tt.name, total, len(tt.want))
+		}
+	}
+
+	got, _, err := storage.QueryAuditLog(context.Background(), models.AuditQuery{Action: "rule.update"})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("QueryAuditLog failed: %v", err)
+	}
+	if string(got[0].Before) != `{"enabled":true}` || string(got[0].After) != `{"enabled":false}` {
+		t.Errorf("states = %s -> %s", got[0].Before, got[0].After)
+	}
+	if got, _, _ := storage.QueryAuditLog(context.Background(), models.AuditQuery{Action: "target.delete"}); got[0].After != nil {
+		t.Errorf("missing after state should stay empty, got %s", got[0].After)
+	}
+}
+
+func TestSQLiteStorage_HealthChecks(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	checks := []models.HealthCheck{
+		{TargetName: "orders", InstanceName: "pod-2", Status: models.HealthUp, Timestamp: now.Add(-time.Minute)},
+		{TargetName: "orders", InstanceName: "pod-1", Status: models.HealthDown, Timestamp: now},
+		{TargetName: "orders", InstanceName: "pod-1", Status: models.HealthUp, Timestamp: now.Add(-time.Minute)},
+		{TargetName: "orders", InstanceName: "pod-1", Status: models.HealthUp, Timestamp: now.Add(-48 * time.Hour)},
+		{TargetName: "payments", InstanceName: "default", Status: models.HealthUp, Timestamp: now},
+	}
+	for i := range checks {
+		if err := storage.SaveHealthCheck(context.Background(), &checks[i]); err != nil {
+			t.Fatalf("SaveHealthCheck() error = %v", err)
+		}
+	}
+
+	got, err := storage.GetHealthChecks(context.Background(), "orders", now.Add(-time.Hour), now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("GetHealthChecks() error = %v", err)
+	}
+	// Ordered by instance, then time
+	if len(got) != 3 || got[0].InstanceName != "pod-1" || got[1].Status != models.HealthDown || got[2].InstanceName != "pod-2" {
+		t.Fatalf("GetHealthChecks() = %+v", got)
+	}
+
+	deleted, err := storage.CleanupHealthChecks(context.Background(), now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("CleanupHealthChecks() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("CleanupHealthChecks() deleted %d, want 1", deleted)
+	}
+}
+
+func TestSQLiteStorage_Recommendations(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	recs := []models.RecommendationRecord{
+		{TargetName: "orders", Type: "minimumIdle", Current: "10", Severity: models.SeverityInfo, Status: models.RecommendationOpen, FirstSeenAt: now, LastSeenAt: now},
+		{TargetName: "orders", Type: "maximumPoolSize", Current: "20", Severity: models.SeverityWarning, Status: models.RecommendationOpen, FirstSeenAt: now, LastSeenAt: now},
+		{TargetName: "payments", Type: "maximumPoolSize", Current: "10", Severity: models.SeverityInfo, Status: models.RecommendationOpen, FirstSeenAt: now, LastSeenAt: now},
+	}
+	for i := range recs {
+		if err := storage.SaveRecommendation(context.Background(), &recs[i]); err != nil {
+			t.Fatalf("SaveRecommendation() error = %v", err)
+		}
+	}
+	// One record per target and type
+	dup := recs[0]
+	if err := storage.SaveRecommendation(context.Background(), &dup); err == nil {
+		t.Error("expected error for a duplicate type")
+	}
+
+	until := now.Add(time.Hour)
+	recs[1].SetStatus(models.RecommendationSnoozed, "after release", &until, now)
+	if err := storage.UpdateRecommendation(context.Background(), &recs[1]); err != nil {
+		t.Fatalf("UpdateRecommendation() error = %v", err)
+	}
+
+	got, err := storage.GetRecommendation(context.Background(), recs[1].ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetRecommendation() = %v, %v", got, err)
+	}
+	if got.Status != models.RecommendationSnoozed || got.Note != "after release" || got.SnoozeUntil == nil || got.StatusCurrent != "20" {
+		t.Errorf("GetRecommendation() = %+v", got)
+	}
+	if missing, err := storage.GetRecommendation(context.Background(), 999); err != nil || missing != nil {
+		t.Errorf("GetRecommendation(999) = %v, %v; want nil, nil", missing, err)
+	}
+
+	open, _ := storage.GetRecommendations(context.Background(), "", models.RecommendationOpen)
+	if len(open) != 2 {
+		t.Errorf("open recommendations = %d, want 2", len(open))
+	}
+	orders, _ := storage.GetRecommendations(context.Background(), "orders", "")
+	if len(orders) != 2 {
+		t.Errorf("orders recommendations = %d, want 2", len(orders))
+	}
+
+	if _, err := storage.PurgeTarget(context.Background(), "orders"); err != nil {
+		t.Fatalf("PurgeTarget() error = %v", err)
+	}
+	if all, _ := storage.GetRecommendations(context.Background(), "", ""); len(all) != 1 {
+		t.Errorf("recommendations after purge = %d, want 1", len(all))
+	}
+}
+
+func TestSQLiteStorage_ClusterNodes(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	for _, n := range []models.ClusterNode{
+		{ID: "b", Address: "http://b:8080", StartedAt: now, LastSeen: now},
+		{ID: "a", StartedAt: now, LastSeen: now.Add(-time.Minute)},
+	} {
+		if err := store.HeartbeatClusterNode(context.Background(), &n); err != nil {
+			t.Fatalf("HeartbeatClusterNode() error = %v", err)
+		}
+	}
+
+	nodes, err := store.GetClusterNodes(context.Background(), now.Add(-30*time.Second))
+	if err != nil || len(nodes) != 1 || nodes[0].ID != "b" || nodes[0].Address != "http://b:8080" {
+		t.Fatalf("GetClusterNodes() = %+v, %v", nodes, err)
+	}
+
+	// A heartbeat updates the existing row
+	if err := store.HeartbeatClusterNode(context.Background(), &models.ClusterNode{ID: "a", StartedAt: now, LastSeen: now}); err != nil {
+		t.Fatalf("HeartbeatClusterNode() error = %v", err)
+	}
+	nodes, _ = store.GetClusterNodes(context.Background(), now.Add(-30*time.Second))
+	if len(nodes) != 2 || nodes[0].ID != "a" {
+		t.Fatalf("after heartbeat = %+v", nodes)
+	}
+
+	if err := store.DeleteClusterNode(context.Background(), "a"); err != nil {
+		t.Fatalf("DeleteClusterNode() error = %v", err)
+	}
+	if nodes, _ = store.GetClusterNodes(context.Background(), time.Time{}); len(nodes) != 1 {
+		t.Errorf("after delete = %+v", nodes)
+	}
+}
+
+func TestSQLiteStorage_Views(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	view := &models.View{
+		Name:    "checkout",
+		Targets: []models.ViewTarget{{Target: "order-service", Instances: []string{"pod-1", "pod-2"}}, {Target: "payment-service"}},
+		Panels:  []string{models.ViewPanelPoolUsage, models.ViewPanelGC},
+		Range:   "6h",
+		Project: "shop",
+	}
+	if err := storage.SaveView(context.Background(), view); err != nil {
+		t.Fatalf("SaveView() error = %v", err)
+	}
+
+	got, err := storage.GetView(context.Background(), view.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetView() = %v, %v", got, err)
+	}
+	if len(got.Targets) != 2 || len(got.Targets[0].Instances) != 2 || got.Targets[1].Instances != nil {
+		t.Errorf("targets = %+v, want instances of order-service only", got.Targets)
+	}
+	if len(got.Panels) != 2 || got.Panels[1] != models.ViewPanelGC || got.Range != "6h" || got.Project != "shop" {
+		t.Errorf("view = %+v", got)
+	}
+
+	got.Panels = []string{models.ViewPanelHeap}
+	if err := storage.UpdateView(context.Background(), got); err != nil {
+		t.Fatalf("UpdateView() error = %v", err)
+	}
+	views, err := storage.GetViews(context.Background())
+	if err != nil || len(views) != 1 || len(views[0].Panels) != 1 || views[0].Panels[0] != models.ViewPanelHeap {
+		t.Errorf("GetViews() = %+v, %v", views, err)
+	}
+
+	if err := storage.DeleteView(context.Background(), view.ID); err != nil {
+		t.Fatalf("DeleteView() error = %v", err)
+	}
+	if got, err := storage.GetView(context.Background(), view.ID); err != nil || got != nil {
+		t.Errorf("GetView() after delete = %v, %v, want nil", got, err)
+	}
+}
+
+func TestSQLiteStorage_RestoreBackup(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	src, cleanupSrc := setupTestDB(t)
+	defer cleanupSrc()
+	if err := src.Save(ctx, &models.PoolMetrics{TargetName: "orders", InstanceName: "a", Active: 5, Max: 10, Timestamp: now}); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	if err := src.SaveAlertRule(ctx, &models.AlertRule{Name: "busy", Condition: "usage > 80", Severity: "warning", Enabled: true}); err != nil {
+		t.Fatalf("SaveAlertRule error: %v", err)
+	}
+	if err := src.SaveEvent(ctx, &models.Event{TargetName: "orders", Type: models.EventTypeDeploy, Version: "1.2.0", Timestamp: now}); err != nil {
+		t.Fatalf("SaveEvent error: %v", err)
+	}
+	if err := src.SaveAnnotation(ctx, &models.Annotation{TargetName: "orders", Time: now, Text: "failover drill"}); err != nil {
+		t.Fatalf("SaveAnnotation error: %v", err)
+	}
+	if err := src.SaveRecommendation(ctx, &models.RecommendationRecord{TargetName: "orders", Type: "max_pool_size", Severity: "warning", Status: "dismissed"}); err != nil {
+		t.Fatalf("SaveRecommendation error: %v", err)
+	}
+	if err := src.SaveHealthCheck(ctx, &models.HealthCheck{TargetName: "orders", InstanceName: "a", Status: models.HealthUp, Timestamp: now}); err != nil {
+		t.Fatalf("SaveHealthCheck error: %v", err)
+	}
+	if _, err := src.MarkInactiveInstances(ctx, now.Add(time.Minute)); err != nil {
+		t.Fatalf("MarkInactiveInstances error: %v", err)
+	}
+	if err := src.SaveAuditEntry(ctx, &models.AuditEntry{Timestamp: now, Actor: "ops", Action: "rule.create", Method: "POST", Path: "/api/v1/rules", Status: 201}); err != nil {
+		t.Fatalf("SaveAuditEntry error: %v", err)
+	}
+	backupPath := filepath.Join(t.TempDir(), "backup.db")
+	if err := src.CreateBackup(ctx, backupPath); err != nil {
+		t.Fatalf("CreateBackup error: %v", err)
+	}
+
+	dst, cleanupDst := setupTestDB(t)
+	defer cleanupDst()
+	if err := dst.Save(ctx, &models.PoolMetrics{TargetName: "billing", InstanceName: "a", Timestamp: now}); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	// The restore completes even when the request that started it is gone
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := dst.RestoreBackup(cancelled, backupPath); err != nil {
+		t.Fatalf("RestoreBackup error: %v", err)
+	}
+
+	from, to := now.Add(-time.Minute), now.Add(time.Minute)
+	if history, err := dst.GetHistory(ctx, "orders", from, to); err != nil || len(history) != 1 || history[0].Active != 5 {
+		t.Errorf("restored history = %+v, %v; want the backed up sample", history, err)
+	}
+	if history, err := dst.GetHistory(ctx, "billing", from, to); err != nil || len(history) != 0 {
+		t.Errorf("history replaced by the restore = %+v, %v; want none", history, err)
+	}
+	if rules, err := dst.GetAlertRules(ctx); err != nil || len(rules) != 1 || rules[0].Name != "busy" {
+		t.Errorf("restored rules = %+v, %v", rules, err)
+	}
+	if events, err := dst.GetEvents(ctx, "orders", from, to); err != nil || len(events) != 1 || events[0].Version != "1.2.0" {
+		t.Errorf("restored events = %+v, %v", events, err)
+	}
+	if annotations, err := dst.GetAnnotations(ctx, "orders", from, to); err != nil || len(annotations) != 1 || annotations[0].Text != "failover drill" {
+		t.Errorf("restored annotations = %+v, %v", annotations, err)
+	}
+	if records, err := dst.GetRecommendations(ctx, "orders", "dismissed"); err != nil || len(records) != 1 {
+		t.Errorf("restored recommendations = %+v, %v", records, err)
+	}
+	if checks, err := dst.GetHealthChecks(ctx, "orders", from, to); err != nil || len(checks) != 1 {
+		t.Errorf("restored health checks = %+v, %v", checks, err)
+	}
+	if inactive, err := dst.GetInactiveInstances(ctx, "orders"); err != nil || len(inactive) != 1 {
+		t.Errorf("restored inactive instances = %+v, %v", inactive, err)
+	}
+	if entries, _, err := dst.QueryAuditLog(ctx, models.AuditQuery{}); err != nil || len(entries) != 1 || entries[0].Actor != "ops" {
+		t.Errorf("restored audit log = %+v, %v", entries, err)
+	}
+
+	// The backup is detached and left unchanged, so it can be restored again
+	if err := dst.RestoreBackup(ctx, backupPath); err != nil {
+		t.Fatalf("second RestoreBackup error: %v", err)
+	}
+	if rules, err := dst.GetAlertRules(ctx); err != nil || len(rules) != 1 {
+		t.Errorf("rules after restoring again = %+v, %v", rules, err)
+	}
+}
+
+func TestSQLiteStorage_RestoreBackup_MigratedColumns(t *testing.T) {
+	ctx := context.Background()
+
+	// A database created before the alert columns existed has them after created_at
+	backupPath := filepath.Join(t.TempDir(), "backup.db")
+	db, err := sql.Open("sqlite", backupPath)
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE pool_metrics (id INTEGER PRIMARY KEY AUTOINCREMENT, target_name TEXT NOT NULL)`,
+		`CREATE TABLE alerts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, target_name TEXT NOT NULL, instance_name TEXT NOT NULL,
+			rule_name TEXT NOT NULL, severity TEXT NOT NULL, message TEXT NOT NULL, status TEXT NOT NULL DEFAULT 'fired',
+			fired_at DATETIME NOT NULL, resolved_at DATETIME, notified_at DATETIME, channels TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP)`,
+		`ALTER TABLE alerts ADD COLUMN acknowledged_at DATETIME`,
+		`ALTER TABLE alerts ADD COLUMN acknowledged_by TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE alerts ADD COLUMN flapping INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE alerts ADD COLUMN escalated_from TEXT NOT NULL DEFAULT ''`,
+		`INSERT INTO alerts (target_name, instance_name, rule_name, severity, message, fired_at, channels, acknowledged_by, flapping, escalated_from)
+			VALUES ('orders', 'a', 'busy', 'critical', 'pool busy', '2024-01-15 10:00:00', 'slack', 'ops', 1, 'warning')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to prepare backup: %v", err)
+		}
+	}
+	db.Close()
+
+	dst, cleanup := setupTestDB(t)
+	defer cleanup()
+	if err := dst.RestoreBackup(ctx, backupPath); err != nil {
+		t.Fatalf("RestoreBackup error: %v", err)
+	}
+
+	alerts, err := dst.GetAlerts(ctx, "", 10)
+	if err != nil || len(alerts) != 1 {
+		t.Fatalf("restored alerts = %+v, %v", alerts, err)
+	}
+	a := alerts[0]
+	if a.RuleName != "busy" || a.AcknowledgedBy != "ops" || !a.Flapping || a.EscalatedFrom != "warning" || a.EscalatedAt != nil {
+		t.Errorf("restored alert = %+v, want the columns copied by name", a)
+	}
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// View-related methods
+
+func (s *SQLiteStorage) migrateViews() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS views (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		targets TEXT NOT NULL,
+		panels TEXT NOT NULL,
+		time_range TEXT,
+		project TEXT,
+		created_by TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteStorage) SaveView(ctx context.Context, view *models.View) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateViews(); err != nil {
+		return err
+	}
+
+	// Targets are stored as JSON since each may select instances
+	targets, err := json.Marshal(view.Targets)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO views (name, description, targets, panels, time_range, project, created_by, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	now := time.Now()
+	result, err := s.db.ExecContext(ctx, query,
+		view.Name,
+		view.Description,
+		string(targets),
+		strings.Join(view.Panels, ","),
+		view.Range,
+		view.Project,
+		view.CreatedBy,
+		now,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		view.ID = id
+		view.CreatedAt = now
+		view.UpdatedAt = now
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) UpdateView(ctx context.Context, view *models.View) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateViews(); err != nil {
+		return err
+	}
+
+	targets, err := json.Marshal(view.Targets)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE views SET
+		name = ?,
+		description = ?,
+		targets = ?,
+		panels = ?,
+		time_range = ?,
+		project = ?,
+		updated_at = ?
+	WHERE id = ?
+	`
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, query,
+		view.Name,
+		view.Description,
+		string(targets),
+		strings.Join(view.Panels, ","),
+		view.Range,
+		view.Project,
+		now,
+		view.ID,
+	)
+	if err == nil {
+		view.UpdatedAt = now
+	}
+	return err
+}
+
+func (s *SQLiteStorage) DeleteView(ctx context.Context, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateViews(); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM views WHERE id = ?`
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// scanView scans a view row, handling nullable text columns
+func scanView(scanner interface{ Scan(...interface{}) error }) (*models.View, error) {
+	var v models.View
+	var description, targets, panels, rng, project, createdBy sql.NullString
+	if err := scanner.Scan(&v.ID, &v.Name, &description, &targets, &panels, &rng, &project, &createdBy,
+		&v.CreatedAt, &v.UpdatedAt); err != nil {
+		return nil, err
+	}
+	v.Description = description.String
+	if err := json.Unmarshal([]byte(targets.String), &v.Targets); err != nil {
+		return nil, err
+	}
+	if panels.String != "" {
+		v.Panels = strings.Split(panels.String, ",")
+	}
+	v.Range = rng.String
+	v.Project = project.String
+	v.CreatedBy = createdBy.String
+	return &v, nil
+}
+
+func (s *SQLiteStorage) GetView(ctx context.Context, id int64) (*models.View, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateViews(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, name, description, targets, panels, time_range, project, created_by, created_at, updated_at
+	FROM views
+	WHERE id = ?
+	`
+	v, err := scanView(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *SQLiteStorage) GetViews(ctx context.Context) ([]models.View, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.migrateViews(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, name, description, targets, panels, time_range, project, created_by, created_at, updated_at
+	FROM views
+	ORDER BY name ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []models.View
+	for rows.Next() {
+		v, err := scanView(rows)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *v)
+	}
+	return views, rows.Err()
+}
@@ -1,113 +1,329 @@
 package storage
 
 import (
+	"context"
 	"time"
 
 	"github.com/jiin/pondy/internal/models"
 )
 
 // Storage defines the interface for metrics persistence
+// Calls stop when ctx is cancelled, e.g., when the HTTP client of a request disconnects
 type Storage interface {
 	// Save stores a new metrics record
-	Save(metrics *models.PoolMetrics) error
+	Save(ctx context.Context, metrics *models.PoolMetrics) error
+
+	// SaveBatch stores several metrics records in a single transaction
+	SaveBatch(ctx context.Context, metrics []models.PoolMetrics) error
 
 	// GetLatest returns the most recent metrics for a target (aggregated across instances)
-	GetLatest(targetName string) (*models.PoolMetrics, error)
+	GetLatest(ctx context.Context, targetName string) (*models.PoolMetrics, error)
 
 	// GetLatestByInstance returns the most recent metrics for a specific instance
-	GetLatestByInstance(targetName, instanceName string) (*models.PoolMetrics, error)
+	GetLatestByInstance(ctx context.Context, targetName, instanceName string) (*models.PoolMetrics, error)
 
 	// GetLatestAllInstances returns the most recent metrics for each instance of a target
-	GetLatestAllInstances(targetName string) ([]models.PoolMetrics, error)
+	GetLatestAllInstances(ctx context.Context, targetName string) ([]models.PoolMetrics, error)
+
+	// GetLatestTimestamps returns the time of the newest sample of each instance of a target,
+	// a cheap validator of responses built from the latest metrics
+	GetLatestTimestamps(ctx context.Context, targetName string) (map[string]time.Time, error)
+
+	// GetFleetSnapshot returns the latest sample of every instance of every target
+	// within lookback before now, and likewise before baseline, in a single query
+	GetFleetSnapshot(ctx context.Context, now, baseline time.Time, lookback time.Duration) (current, previous []models.PoolMetrics, err error)
 
 	// GetHistory returns metrics within a time range
-	GetHistory(targetName string, from, to time.Time) ([]models.PoolMetrics, error)
+	// Long ranges are served from 1-minute or 1-hour rollups when available
+	GetHistory(ctx context.Context, targetName string, from, to time.Time) ([]models.PoolMetrics, error)
 
 	// GetHistoryByInstance returns metrics for a specific instance within a time range
-	GetHistoryByInstance(targetName, instanceName string, from, to time.Time) ([]models.PoolMetrics, error)
+	GetHistoryByInstance(ctx context.Context, targetName, instanceName string, from, to time.Time) ([]models.PoolMetrics, error)
+
+	// GetHistoryAggregated returns the history of a target, or of one instance when instanceName
+	// is set, aggregated into buckets of the given size: one point per bucket with the average,
+	// minimum and maximum of each field. Buckets are grouped in SQL, so long ranges aren't loaded row by row
+	GetHistoryAggregated(ctx context.Context, targetName, instanceName string, from, to time.Time, bucket time.Duration) ([]models.PoolMetrics, error)
 
 	// GetInstances returns all instance names for a target
-	GetInstances(targetName string) ([]string, error)
+	GetInstances(ctx context.Context, targetName string) ([]string, error)
 
 	// GetTargets returns all known target names
-	GetTargets() ([]string, error)
+	GetTargets(ctx context.Context) ([]string, error)
+
+	// Cleanup deletes records older than the given time, except for the excluded targets
+	Cleanup(ctx context.Context, olderThan time.Time, excludeTargets ...string) (int64, error)
 
-	// Cleanup deletes records older than the given time
-	Cleanup(olderThan time.Time) (int64, error)
+	// CleanupTarget deletes a target's records older than the given time
+	CleanupTarget(ctx context.Context, targetName string, olderThan time.Time) (int64, error)
+
+	// Rollup aggregates raw metrics into 1-minute and 1-hour buckets that ended before until
+	Rollup(ctx context.Context, until time.Time) (int64, error)
+
+	// CleanupRollups deletes rollup buckets of a resolution older than the given time
+	CleanupRollups(ctx context.Context, resolution string, olderThan time.Time) (int64, error)
 
 	// Alert-related methods
 
 	// SaveAlert stores a new alert
-	SaveAlert(alert *models.Alert) error
+	SaveAlert(ctx context.Context, alert *models.Alert) error
 
 	// UpdateAlert updates an existing alert
-	UpdateAlert(alert *models.Alert) error
+	UpdateAlert(ctx context.Context, alert *models.Alert) error
+
+	// MarkAlertNotified records when and to which channels an alert was sent, leaving its state untouched
+	MarkAlertNotified(ctx context.Context, id int64, notifiedAt time.Time, channels string) error
 
 	// GetAlert returns an alert by ID
-	GetAlert(id int64) (*models.Alert, error)
+	GetAlert(ctx context.Context, id int64) (*models.Alert, error)
+
+	// GetAlertSnapshot returns the metric values an alert fired on, nil for alerts stored without them
+	GetAlertSnapshot(ctx context.Context, id int64) (map[string]float64, error)
+
+	// SaveAlertEscalation records a severity change of an alert
+	SaveAlertEscalation(ctx context.Context, e *models.AlertEscalation) error
+
+	// GetAlertEscalations returns the severity changes of an alert, oldest first
+	GetAlertEscalations(ctx context.Context, alertID int64) ([]models.AlertEscalation, error)
 
 	// GetAlerts returns alerts with optional filters
-	GetAlerts(status string, limit int) ([]models.Alert, error)
+	GetAlerts(ctx context.Context, status string, limit int) ([]models.Alert, error)
+
+	// QueryAlerts returns a page of alerts matching the query and the total number of matches
+	QueryAlerts(ctx context.Context, q models.AlertQuery) ([]models.Alert, int, error)
+
+	// SearchAlerts returns alerts whose message, rule or target matches all query terms, newest first
+	SearchAlerts(ctx context.Context, query string, limit int) ([]models.Alert, error)
+
+	// ApplyAlertAction resolves, acknowledges or deletes the alerts matching a query, returning their IDs
+	ApplyAlertAction(ctx context.Context, action string, q models.AlertQuery, actor string, at time.Time) ([]int64, error)
 
 	// GetActiveAlertByRule returns active alert for a specific target/instance/rule
-	GetActiveAlertByRule(targetName, instanceName, ruleName string) (*models.Alert, error)
+	GetActiveAlertByRule(ctx context.Context, targetName, instanceName, ruleName string) (*models.Alert, error)
+
+	// GetSuppressedAlertByRule returns the suppressed alert of a target/instance/rule whose condition has not cleared
+	GetSuppressedAlertByRule(ctx context.Context, targetName, instanceName, ruleName string) (*models.Alert, error)
+
+	// GetAlertsByTarget returns alerts of a target fired within a time range or still active
+	GetAlertsByTarget(ctx context.Context, targetName string, from, to time.Time) ([]models.Alert, error)
+
+	// GetAlertVersion returns an opaque version of the alerts, changed by every alert created,
+	// updated or deleted, and the time of the last change; zero before any change
+	GetAlertVersion(ctx context.Context) (string, time.Time, error)
 
 	// GetAlertStats returns alert statistics
-	GetAlertStats() (*models.AlertStats, error)
+	GetAlertStats(ctx context.Context) (*models.AlertStats, error)
+
+	// GetAlertTimeseries returns alerts per day, resolution times, noisy rules and flapping alerts of a time range
+	GetAlertTimeseries(ctx context.Context, q models.AlertTimeseriesQuery) (*models.AlertTimeseries, error)
 
 	// CleanupAlerts deletes resolved alerts older than the given time
-	CleanupAlerts(olderThan time.Time) (int64, error)
+	CleanupAlerts(ctx context.Context, olderThan time.Time) (int64, error)
 
 	// AlertRule-related methods
 
 	// SaveAlertRule creates a new alert rule
-	SaveAlertRule(rule *models.AlertRule) error
+	SaveAlertRule(ctx context.Context, rule *models.AlertRule) error
 
 	// UpdateAlertRule updates an existing alert rule
-	UpdateAlertRule(rule *models.AlertRule) error
+	UpdateAlertRule(ctx context.Context, rule *models.AlertRule) error
 
 	// DeleteAlertRule deletes an alert rule by ID
-	DeleteAlertRule(id int64) error
+	DeleteAlertRule(ctx context.Context, id int64) error
 
 	// GetAlertRule returns an alert rule by ID
-	GetAlertRule(id int64) (*models.AlertRule, error)
+	GetAlertRule(ctx context.Context, id int64) (*models.AlertRule, error)
 
 	// GetAlertRules returns all alert rules
-	GetAlertRules() ([]models.AlertRule, error)
+	GetAlertRules(ctx context.Context) ([]models.AlertRule, error)
 
 	// GetAlertRuleByName returns an alert rule by name
-	GetAlertRuleByName(name string) (*models.AlertRule, error)
+	GetAlertRuleByName(ctx context.Context, name string) (*models.AlertRule, error)
 
 	// Backup-related methods
 
 	// CreateBackup creates a backup of the database
-	CreateBackup(destPath string) error
+	CreateBackup(ctx context.Context, destPath string) error
 
 	// RestoreBackup restores the database from a backup file
-	RestoreBackup(srcPath string) error
+	RestoreBackup(ctx context.Context, srcPath string) error
 
 	// MaintenanceWindow-related methods
 
 	// SaveMaintenanceWindow creates a new maintenance window
-	SaveMaintenanceWindow(window *models.MaintenanceWindow) error
+	SaveMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error
 
 	// UpdateMaintenanceWindow updates an existing maintenance window
-	UpdateMaintenanceWindow(window *models.MaintenanceWindow) error
+	UpdateMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error
 
 	// DeleteMaintenanceWindow deletes a maintenance window by ID
-	DeleteMaintenanceWindow(id int64) error
+	DeleteMaintenanceWindow(ctx context.Context, id int64) error
 
 	// GetMaintenanceWindow returns a maintenance window by ID
-	GetMaintenanceWindow(id int64) (*models.MaintenanceWindow, error)
+	GetMaintenanceWindow(ctx context.Context, id int64) (*models.MaintenanceWindow, error)
 
 	// GetAllMaintenanceWindows returns all maintenance windows
-	GetAllMaintenanceWindows() ([]models.MaintenanceWindow, error)
+	GetAllMaintenanceWindows(ctx context.Context) ([]models.MaintenanceWindow, error)
+
+	// GetActiveMaintenanceWindows returns the maintenance windows active at now
+	// Recurring and cron schedules are evaluated in the location of now
+	GetActiveMaintenanceWindows(ctx context.Context, now time.Time) ([]models.MaintenanceWindow, error)
+
+	// IsInMaintenanceWindow checks if a target of the given group and project is in maintenance at now
+	IsInMaintenanceWindow(ctx context.Context, targetName, group, project string, now time.Time) (bool, error)
+
+	// Silence-related methods
+
+	// SaveSilence creates a new silence
+	SaveSilence(ctx context.Context, silence *models.Silence) error
+
+	// UpdateSilence updates an existing silence
+	UpdateSilence(ctx context.Context, silence *models.Silence) error
+
+	// DeleteSilence deletes a silence by ID
+	DeleteSilence(ctx context.Context, id int64) error
+
+	// GetSilence returns a silence by ID
+	GetSilence(ctx context.Context, id int64) (*models.Silence, error)
+
+	// GetAllSilences returns all silences, including expired ones
+	GetAllSilences(ctx context.Context) ([]models.Silence, error)
+
+	// GetActiveSilences returns silences currently in effect
+	GetActiveSilences(ctx context.Context) ([]models.Silence, error)
+
+	// CleanupSilences deletes silences that ended before the given time
+	CleanupSilences(ctx context.Context, endedBefore time.Time) (int64, error)
+
+	// Event-related methods
+
+	// SaveEvent stores a new target event
+	SaveEvent(ctx context.Context, event *models.Event) error
+
+	// DeleteEvent deletes an event by ID
+	DeleteEvent(ctx context.Context, id int64) error
+
+	// GetEvent returns an event by ID
+	GetEvent(ctx context.Context, id int64) (*models.Event, error)
+
+	// GetEvents returns the events of a target within a time range, oldest first
+	GetEvents(ctx context.Context, targetName string, from, to time.Time) ([]models.Event, error)
+
+	// Annotation-related methods
+
+	// SaveAnnotation stores a new annotation
+	SaveAnnotation(ctx context.Context, annotation *models.Annotation) error
+
+	// DeleteAnnotation deletes an annotation by ID
+	DeleteAnnotation(ctx context.Context, id int64) error
+
+	// GetAnnotation returns an annotation by ID
+	GetAnnotation(ctx context.Context, id int64) (*models.Annotation, error)
+
+	// GetAnnotations returns annotations overlapping a time range, oldest first
+	// With a target name, only that target's and global annotations are returned
+	GetAnnotations(ctx context.Context, targetName string, from, to time.Time) ([]models.Annotation, error)
+
+	// View-related methods
+
+	// SaveView creates a new saved view
+	SaveView(ctx context.Context, view *models.View) error
+
+	// UpdateView updates an existing view
+	UpdateView(ctx context.Context, view *models.View) error
+
+	// DeleteView deletes a view by ID
+	DeleteView(ctx context.Context, id int64) error
+
+	// GetView returns a view by ID
+	GetView(ctx context.Context, id int64) (*models.View, error)
+
+	// GetViews returns all views, ordered by name
+	GetViews(ctx context.Context) ([]models.View, error)
+
+	// Health check methods
+
+	// SaveHealthCheck records the health endpoint status of an instance
+	SaveHealthCheck(ctx context.Context, check *models.HealthCheck) error
+
+	// GetHealthChecks returns the health checks of a target within a time range,
+	// ordered by instance and then time
+	GetHealthChecks(ctx context.Context, targetName string, from, to time.Time) ([]models.HealthCheck, error)
+
+	// CleanupHealthChecks deletes health checks older than the given time
+	CleanupHealthChecks(ctx context.Context, olderThan time.Time) (int64, error)
+
+	// Recommendation methods
+
+	// SaveRecommendation stores a new recommendation record
+	SaveRecommendation(ctx context.Context, rec *models.RecommendationRecord) error
+
+	// UpdateRecommendation updates the advice and status of a recommendation record
+	UpdateRecommendation(ctx context.Context, rec *models.RecommendationRecord) error
+
+	// GetRecommendation returns a recommendation record by ID
+	GetRecommendation(ctx context.Context, id int64) (*models.RecommendationRecord, error)
+
+	// GetRecommendations returns recommendation records, optionally filtered by target and status
+	GetRecommendations(ctx context.Context, targetName, status string) ([]models.RecommendationRecord, error)
+
+	// Notification log methods
+
+	// SaveNotificationDelivery records a notification delivery attempt
+	SaveNotificationDelivery(ctx context.Context, d *models.NotificationDelivery) error
+
+	// GetDeliveriesByAlert returns delivery attempts for an alert, oldest first
+	GetDeliveriesByAlert(ctx context.Context, alertID int64) ([]models.NotificationDelivery, error)
+
+	// GetFailedDeliveries returns failed delivery attempts since the given time, newest first
+	GetFailedDeliveries(ctx context.Context, since time.Time, limit int) ([]models.NotificationDelivery, error)
+
+	// CleanupNotificationLog deletes delivery records older than the given time
+	CleanupNotificationLog(ctx context.Context, olderThan time.Time) (int64, error)
+
+	// Audit log methods
+
+	// SaveAuditEntry records a change made through the API
+	SaveAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+
+	// QueryAuditLog returns a page of audit entries, newest first, and the total number of matches
+	QueryAuditLog(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, int, error)
+
+	// Storage management methods
+
+	// GetStorageStats returns database size, row counts and per-target time ranges
+	GetStorageStats(ctx context.Context) (*models.StorageStats, error)
+
+	// Vacuum rebuilds the database file to reclaim unused space
+	Vacuum(ctx context.Context) error
+
+	// PurgeTarget deletes all metrics, rollups, alerts, events, annotations, health checks and recommendations of a target
+	PurgeTarget(ctx context.Context, targetName string) (int64, error)
+
+	// RenameTarget moves all history of a target, including maintenance windows and silences,
+	// to a new name in one transaction; returns the number of rows moved
+	RenameTarget(ctx context.Context, oldName, newName string) (int64, error)
+
+	// MarkInactiveInstances marks instances without samples since idleSince as inactive
+	// and unmarks inactive instances that reported again; returns the number newly marked
+	MarkInactiveInstances(ctx context.Context, idleSince time.Time) (int64, error)
+
+	// GetInactiveInstances returns the inactive instances of a target, or of all targets if empty
+	GetInactiveInstances(ctx context.Context, targetName string) ([]models.InactiveInstance, error)
+
+	// PurgeInstance deletes the metrics, rollups, alerts and health checks of an instance
+	PurgeInstance(ctx context.Context, targetName, instanceName string) (int64, error)
+
+	// Cluster membership methods
+
+	// HeartbeatClusterNode records that a replica is alive, registering it if new
+	HeartbeatClusterNode(ctx context.Context, node *models.ClusterNode) error
 
-	// GetActiveMaintenanceWindows returns currently active maintenance windows
-	GetActiveMaintenanceWindows() ([]models.MaintenanceWindow, error)
+	// GetClusterNodes returns the replicas with a heartbeat since the given time, ordered by ID
+	GetClusterNodes(ctx context.Context, aliveSince time.Time) ([]models.ClusterNode, error)
 
-	// IsInMaintenanceWindow checks if a target is in maintenance
-	IsInMaintenanceWindow(targetName string) (bool, error)
+	// DeleteClusterNode removes a replica that left the cluster
+	DeleteClusterNode(ctx context.Context, id string) error
 
 	// Close closes the storage connection
 	Close() error
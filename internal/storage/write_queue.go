@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+// WriteQueueStats describes the state of a write queue
+type WriteQueueStats struct {
+	Depth     int        `json:"depth"`    // Metrics waiting to be written
+	Capacity  int        `json:"capacity"` // Maximum queued metrics before Save writes synchronously
+	BatchSize int        `json:"batch_size"`
+	Written   int64      `json:"written"`
+	Batches   int64      `json:"batches"`
+	Overflows int64      `json:"overflows"` // Saves written synchronously because the queue was full
+	Failed    int64      `json:"failed"`
+	LastFlush *time.Time `json:"last_flush,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+
+	// Database write latency
+	WriteSeconds     float64 `json:"write_seconds"`      // Total time spent writing batches
+	LastWriteSeconds float64 `json:"last_write_seconds"` // Time spent writing the last batch
+}
+
+// WriteQueue batches metric inserts into fewer transactions
+// Save only enqueues the metrics, so records become visible to reads after the next flush.
+// All other methods pass through to the wrapped storage.
+type WriteQueue struct {
+	Storage
+
+	batchSize     int
+	flushInterval time.Duration
+	ch            chan models.PoolMetrics
+	stop          chan struct{}
+	done          chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	pending   atomic.Int64
+	written   atomic.Int64
+	batches   atomic.Int64
+	overflows atomic.Int64
+	failed    atomic.Int64
+	writeTime atomic.Int64 // Nanoseconds spent writing batches
+
+	statsMu       sync.Mutex
+	lastFlush     time.Time
+	lastWriteTime time.Duration
+	lastError     string
+}
+
+// NewWriteQueue wraps a storage and starts flushing every flushInterval or batchSize metrics
+func NewWriteQueue(store Storage, batchSize int, flushInterval time.Duration, capacity int) *WriteQueue {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	if capacity < batchSize {
+		capacity = batchSize
+	}
+
+	q := &WriteQueue{
+		Storage:       store,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		ch:            make(chan models.PoolMetrics, capacity),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Save enqueues a metrics record
+// When the queue is full or closed the record is written synchronously; queued records
+// are written in the background and outlive ctx
+func (q *WriteQueue) Save(ctx context.Context, metrics *models.PoolMetrics) error {
+	q.mu.RLock()
+	if !q.closed {
+		select {
+		case q.ch <- *metrics:
+			q.pending.Add(1)
+			q.mu.RUnlock()
+			return nil
+		default:
+		}
+	}
+	q.mu.RUnlock()
+
+	q.overflows.Add(1)
+	return q.Storage.Save(ctx, metrics)
+}
+
+func (q *WriteQueue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.PoolMetrics, 0, q.batchSize)
+	for {
+		select {
+		case m := <-q.ch:
+			batch = append(batch, m)
+			if len(batch) >= q.batchSize {
+				batch = q.flush(batch)
+			}
+		case <-ticker.C:
+			batch = q.flush(batch)
+		case <-q.stop:
+			// Drain what was queued before Close
+			for {
+				select {
+				case m := <-q.ch:
+					batch = append(batch, m)
+					if len(batch) >= q.batchSize {
+						batch = q.flush(batch)
+					}
+				default:
+					q.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes a batch and returns it emptied for reuse
+// A failed batch is retried row by row so one bad record doesn't lose the others
+func (q *WriteQueue) flush(batch []models.PoolMetrics) []models.PoolMetrics {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	err := q.Storage.SaveBatch(ctx, batch)
+	failed := 0
+	if err != nil {
+		log.Printf("Write queue: batch of %d failed, retrying individually: %v", len(batch), err)
+		for i := range batch {
+			if rowErr := q.Storage.Save(ctx, &batch[i]); rowErr != nil {
+				failed++
+				err = rowErr
+			}
+		}
+		if failed == 0 {
+			err = nil
+		}
+	}
+
+	elapsed := time.Since(start)
+	q.writeTime.Add(int64(elapsed))
+
+	q.pending.Add(-int64(len(batch)))
+	q.written.Add(int64(len(batch) - failed))
+	q.failed.Add(int64(failed))
+	q.batches.Add(1)
+
+	q.statsMu.Lock()
+	q.lastFlush = time.Now()
+	q.lastWriteTime = elapsed
+	if err != nil {
+		q.lastError = err.Error()
+		log.Printf("Write queue: dropped %d metrics: %v", failed, err)
+	}
+	q.statsMu.Unlock()
+
+	return batch[:0]
+}
+
+// Stats returns the queue depth and write counters
+func (q *WriteQueue) Stats() WriteQueueStats {
+	stats := WriteQueueStats{
+		Depth:     int(q.pending.Load()),
+		Capacity:  cap(q.ch),
+		BatchSize: q.batchSize,
+		Written:   q.written.Load(),
+		Batches:   q.batches.Load(),
+		Overflows: q.overflows.Load(),
+		Failed:    q.failed.Load(),
+
+		WriteSeconds: time.Duration(q.writeTime.Load()).Seconds(),
+	}
+
+	q.statsMu.Lock()
+	defer q.statsMu.Unlock()
+	if !q.lastFlush.IsZero() {
+		t := q.lastFlush
+		stats.LastFlush = &t
+	}
+	stats.LastError = q.lastError
+	stats.LastWriteSeconds = q.lastWriteTime.Seconds()
+	return stats
+}
+
+// Close flushes queued metrics and closes the wrapped storage
+func (q *WriteQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	close(q.stop)
+	<-q.done
+	return q.Storage.Close()
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jiin/pondy/internal/models"
+)
+
+func TestWriteQueue_FlushesBatches(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	q := NewWriteQueue(store, 10, time.Hour, 100)
+	now := time.Now()
+	for i := 0; i < 25; i++ {
+		if err := q.Save(context.Background(), &models.PoolMetrics{TargetName: "queued", Active: i, Timestamp: now.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	// Two full batches are written without waiting for the interval
+	deadline := time.Now().Add(2 * time.Second)
+	for q.Stats().Batches < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats := q.Stats()
+	if stats.Written != 20 || stats.Depth != 5 {
+		t.Errorf("stats = %+v, want 20 written and 5 queued", stats)
+	}
+	if stats.WriteSeconds <= 0 || stats.LastWriteSeconds <= 0 {
+		t.Errorf("stats = %+v, want write latency recorded", stats)
+	}
+
+	// Close flushes the rest
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if stats := q.Stats(); stats.Written != 25 || stats.Depth != 0 {
+		t.Errorf("after Close() stats = %+v, want 25 written", stats)
+	}
+}
+
+func TestWriteQueue_OverflowWritesSynchronously(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	q := NewWriteQueue(store, 100, time.Hour, 100)
+	defer q.Close()
+
+	// The writer drains the queue while it fills, so far more saves than fit are made
+	now := time.Now()
+	for i := 0; i < 2000; i++ {
+		q.Save(context.Background(), &models.PoolMetrics{TargetName: "overflow", Timestamp: now})
+	}
+	if q.Stats().Overflows == 0 {
+		t.Error("expected saves beyond capacity to be written synchronously")
+	}
+}
+
+func TestSQLiteStorage_SaveBatch(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	batch := []models.PoolMetrics{
+		{TargetName: "batch", InstanceName: "a", Active: 1, Timestamp: now},
+		{TargetName: "batch", Active: 2, Timestamp: now.Add(time.Second)},
+	}
+	if err := store.SaveBatch(context.Background(), batch); err != nil {
+		t.Fatalf("SaveBatch() error = %v", err)
+	}
+	if batch[0].ID == 0 || batch[1].ID == 0 {
+		t.Error("SaveBatch() should set IDs")
+	}
+
+	instances, err := store.GetInstances(context.Background(), "batch")
+	if err != nil {
+		t.Fatalf("GetInstances() error = %v", err)
+	}
+	if len(instances) != 2 || instances[1] != "default" {
+		t.Errorf("instances = %v, want [a default]", instances)
+	}
+}
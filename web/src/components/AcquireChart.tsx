@@ -0,0 +1,95 @@
+import { memo, useMemo } from 'react';
+import {
+  LineChart,
+  Line,
+  XAxis,
+  YAxis,
+  CartesianGrid,
+  Tooltip,
+  Legend,
+  ResponsiveContainer,
+} from 'recharts';
+import type { PoolMetrics } from '../types/metrics';
+import { useSettings, formatTime } from '../hooks/useMetrics';
+import { useTheme } from '../context/ThemeContext';
+
+interface AcquireChartProps {
+  data: PoolMetrics[];
+  height?: number;
+}
+
+// hasAcquireTimes reports whether any datapoint carries connection acquire times
+export function hasAcquireTimes(data: PoolMetrics[]): boolean {
+  return data.some((d) => (d.acquire_p50 ?? 0) > 0 || (d.acquire_p99 ?? 0) > 0 || (d.acquire_max ?? 0) > 0);
+}
+
+// AcquireChart plots connection acquire time percentiles and maximum (ms)
+export const AcquireChart = memo(function AcquireChart({ data, height = 160 }: AcquireChartProps) {
+  const { settings } = useSettings();
+  const { theme } = useTheme();
+  const timezone = settings?.timezone || 'Local';
+
+  const chartColors = useMemo(() => ({
+    grid: theme === 'dark' ? '#374151' : '#e5e7eb',
+    axis: theme === 'dark' ? '#9ca3af' : '#6b7280',
+    tooltipBg: theme === 'dark' ? '#1f2937' : '#ffffff',
+    tooltipBorder: theme === 'dark' ? '#374151' : '#e5e7eb',
+    p50: theme === 'dark' ? '#4ade80' : '#22c55e',
+    p95: theme === 'dark' ? '#fbbf24' : '#f59e0b',
+    p99: theme === 'dark' ? '#f87171' : '#ef4444',
+    max: theme === 'dark' ? '#a78bfa' : '#8b5cf6',
+  }), [theme]);
+
+  const chartData = useMemo(() => {
+    if (!data || !Array.isArray(data)) return [];
+    return data
+      .filter((d) => d && d.timestamp)
+      .map((d) => ({
+        time: formatTime(d.timestamp, timezone),
+        p50: d.acquire_p50 ?? 0,
+        p95: d.acquire_p95 ?? 0,
+        p99: d.acquire_p99 ?? 0,
+        max: d.acquire_max ?? 0,
+      }));
+  }, [data, timezone]);
+
+  const lines = [
+    { key: 'p50', name: 'p50', color: chartColors.p50 },
+    { key: 'p95', name: 'p95', color: chartColors.p95 },
+    { key: 'p99', name: 'p99', color: chartColors.p99 },
+    { key: 'max', name: 'Max', color: chartColors.max },
+  ];
+
+  return (
+    <ResponsiveContainer width="100%" height={height}>
+      <LineChart data={chartData} margin={{ top: 5, right: 30, left: 20, bottom: 5 }}>
+        <CartesianGrid strokeDasharray="3 3" stroke={chartColors.grid} />
+        <XAxis dataKey="time" stroke={chartColors.axis} fontSize={12} />
+        <YAxis stroke={chartColors.axis} fontSize={12} unit="ms" />
+        <Tooltip
+          contentStyle={{
+            backgroundColor: chartColors.tooltipBg,
+            border: `1px solid ${chartColors.tooltipBorder}`,
+            borderRadius: '8px',
+            fontSize: '11px',
+          }}
+          formatter={(value) => `${Number(value).toFixed(2)} ms`}
+        />
+        <Legend wrapperStyle={{ fontSize: '12px' }} />
+        {lines.map((l) => (
+          <Line
+            key={l.key}
+            type="monotone"
+            dataKey={l.key}
+            stroke={l.color}
+            strokeWidth={l.key === 'max' ? 1 : 2}
+            strokeDasharray={l.key === 'max' ? '5 3' : undefined}
+            dot={false}
+            name={l.name}
+            isAnimationActive={false}
+          />
+        ))}
+      </LineChart>
+    </ResponsiveContainer>
+  );
+});
@@ -21,6 +21,7 @@ export const AlertCard = memo(function AlertCard({
 }: AlertCardProps) {
   const sevColor = colors[alert.severity] || colors.info;
   const isActive = alert.status === 'fired';
+  const isSuppressed = alert.status === 'suppressed';
 
   return (
     <div
@@ -49,11 +50,11 @@ export const AlertCard = memo(function AlertCard({
             borderRadius: '4px',
             fontSize: '10px',
             fontWeight: 600,
-            backgroundColor: isActive ? '#ef4444' : '#22c55e',
+            backgroundColor: isActive ? '#ef4444' : isSuppressed ? '#6b7280' : '#22c55e',
             color: '#fff',
           }}
         >
-          {isActive ? 'ACTIVE' : 'RESOLVED'}
+          {isActive ? 'ACTIVE' : isSuppressed ? 'SUPPRESSED' : 'RESOLVED'}
         </span>
       </div>
 
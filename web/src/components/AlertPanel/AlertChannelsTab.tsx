@@ -13,7 +13,7 @@ export const AlertChannelsTab = memo(function AlertChannelsTab() {
 
   const fetchConfig = useCallback(async () => {
     try {
-      const res = await fetch('/api/config/alerting');
+      const res = await fetch('/api/v1/config/alerting');
       const data = await res.json() as AlertingConfig;
       setConfig(data);
     } catch (err) {
@@ -35,7 +35,7 @@ export const AlertChannelsTab = memo(function AlertChannelsTab() {
     setSuccess(null);
 
     try {
-      const res = await fetch('/api/config/alerting', {
+      const res = await fetch('/api/v1/config/alerting', {
         method: 'PUT',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(config),
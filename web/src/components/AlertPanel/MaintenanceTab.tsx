@@ -280,7 +280,9 @@ export const MaintenanceTab = memo(function MaintenanceTab() {
                       )}
                     </div>
                     <div style={{ fontSize: '11px', color: colors.textSecondary }}>
-                      {w.target_name || 'All targets'} | {formatDateTime(w.start_time)} - {formatDateTime(w.end_time)}
+                      {w.target_name || 'All targets'} | {w.cron
+                        ? `${w.cron} for ${w.duration_minutes}m`
+                        : `${formatDateTime(w.start_time)} - ${formatDateTime(w.end_time)}`}
                     </div>
                     {w.description && (
                       <div style={{ fontSize: '11px', color: colors.textSecondary, marginTop: '4px' }}>
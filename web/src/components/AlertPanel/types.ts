@@ -32,12 +32,14 @@ export interface AlertingConfig {
       smtp_host: string;
       smtp_port: number;
       username: string;
+      password?: string;
       from: string;
       to: string[];
       use_tls: boolean;
     };
     notion: {
       enabled: boolean;
+      token?: string;
       database_id: string;
     };
   };
@@ -62,8 +62,8 @@ export function AlertRuleConfigModal({ isOpen, onClose, rule, onSave }: AlertRul
 
     try {
       const url = rule?.id
-        ? `${API_BASE}/api/rules/${rule.id}`
-        : `${API_BASE}/api/rules`;
+        ? `${API_BASE}/api/v1/rules/${rule.id}`
+        : `${API_BASE}/api/v1/rules`;
 
       const method = rule?.id ? 'PUT' : 'POST';
 
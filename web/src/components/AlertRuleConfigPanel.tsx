@@ -34,7 +34,7 @@ export function AlertRuleConfigPanel({ isOpen, onClose }: AlertRuleConfigPanelPr
 
   const fetchRules = useCallback(async () => {
     try {
-      const res = await fetch(`${API_BASE}/api/rules`);
+      const res = await fetch(`${API_BASE}/api/v1/rules`);
       const data = await res.json();
       setRules(data.rules || []);
     } catch (err) {
@@ -52,7 +52,7 @@ export function AlertRuleConfigPanel({ isOpen, onClose }: AlertRuleConfigPanelPr
 
   const handleDelete = async (id: number) => {
     try {
-      const res = await fetch(`${API_BASE}/api/rules/${id}`, {
+      const res = await fetch(`${API_BASE}/api/v1/rules/${id}`, {
         method: 'DELETE',
       });
       if (res.ok) {
@@ -66,7 +66,7 @@ export function AlertRuleConfigPanel({ isOpen, onClose }: AlertRuleConfigPanelPr
 
   const handleToggle = async (rule: AlertRule) => {
     try {
-      const res = await fetch(`${API_BASE}/api/rules/${rule.id}/toggle`, {
+      const res = await fetch(`${API_BASE}/api/v1/rules/${rule.id}/toggle`, {
         method: 'PATCH',
       });
       if (res.ok) {
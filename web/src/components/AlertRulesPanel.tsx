@@ -19,13 +19,22 @@ const conditionVariables = [
   { name: 'idle', desc: 'Idle connections count' },
   { name: 'pending', desc: 'Pending connections count' },
   { name: 'max', desc: 'Max pool size' },
-  { name: 'timeout', desc: 'Timeout count' },
+  { name: 'timeout', desc: 'Cumulative timeout count' },
+  { name: 'timeout_rate', desc: 'Timeouts per minute since the previous sample' },
+  { name: 'acquire_p95', desc: 'Connection acquire time p95 (ms)' },
+  { name: 'acquire_p99', desc: 'Connection acquire time p99 (ms)' },
+  { name: 'acquire_max', desc: 'Max connection acquire time (ms)' },
   { name: 'heapusage', desc: 'Heap memory usage percentage' },
   { name: 'nonheap', desc: 'Non-heap memory (bytes)' },
   { name: 'cpu', desc: 'CPU usage percentage (0-100)' },
   { name: 'threads', desc: 'Live thread count' },
   { name: 'gccount', desc: 'GC count' },
   { name: 'gctime', desc: 'GC time (seconds)' },
+  { name: 'gc_pauses_per_min', desc: 'GC pauses per minute' },
+  { name: 'gc_pause_ms_per_min', desc: 'GC pause time per minute (ms)' },
+  { name: 'avg_gc_pause_ms', desc: 'Average GC pause (ms)' },
+  { name: 'scrape_failures', desc: 'Consecutive failed scrapes' },
+  { name: 'health', desc: 'Health endpoint: 1 when UP, 0 otherwise' },
 ];
 
 export function AlertRulesPanel() {
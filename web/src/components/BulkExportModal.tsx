@@ -18,7 +18,7 @@ const RANGE_OPTIONS = [
 export function BulkExportModal({ onClose }: BulkExportModalProps) {
   const { colors } = useTheme();
   const [range, setRange] = useState('24h');
-  const [exporting, setExporting] = useState<'csv' | 'report' | null>(null);
+  const [exporting, setExporting] = useState<'csv' | 'report' | 'pdf' | null>(null);
 
   const handleExportAll = () => {
     setExporting('csv');
@@ -32,6 +32,12 @@ export function BulkExportModal({ onClose }: BulkExportModalProps) {
     setTimeout(onClose, 300);
   };
 
+  const handleCombinedPDF = () => {
+    setExporting('pdf');
+    openCombinedReport(range, 'pdf');
+    setTimeout(onClose, 300);
+  };
+
   return (
     <div
       style={{
@@ -131,6 +137,24 @@ export function BulkExportModal({ onClose }: BulkExportModalProps) {
           >
             {exporting === 'report' ? 'Opening...' : 'Combined Report'}
           </button>
+          <button
+            onClick={handleCombinedPDF}
+            disabled={exporting !== null}
+            style={{
+              flex: 1,
+              padding: '10px 16px',
+              border: 'none',
+              borderRadius: '6px',
+              backgroundColor: '#8b5cf6',
+              color: '#fff',
+              cursor: exporting ? 'not-allowed' : 'pointer',
+              fontSize: '13px',
+              fontWeight: 600,
+              opacity: exporting ? 0.7 : 1,
+            }}
+          >
+            {exporting === 'pdf' ? 'Opening...' : 'PDF'}
+          </button>
         </div>
 
         {/* Cancel Button */}
@@ -83,7 +83,7 @@ export function ComparisonChart({ targetNames, range = '1h', metric = 'usage' }:
       await Promise.all(
         targetNames.map(async (name) => {
           try {
-            const res = await fetch(`/api/targets/${name}/history?range=${range}`);
+            const res = await fetch(`/api/v1/targets/${name}/history?range=${range}`);
             if (res.ok) {
               results[name] = await res.json();
             }
@@ -17,11 +17,22 @@ const STORAGE_KEYS = {
   SELECTED_GROUP: 'pondy-selected-group',
 };
 
+// Deep link from a notification, e.g., /?target=orders&instance=pod-1&alert=42
+function readDeepLink() {
+  const params = new URLSearchParams(window.location.search);
+  return {
+    target: params.get('target'),
+    instance: params.get('instance'),
+    alert: params.get('alert'),
+  };
+}
+
 export const Dashboard = memo(function Dashboard() {
   const { data, loading, error } = useTargets(5000);
   const { data: activeAlerts } = useActiveAlerts();
+  const [deepLink] = useState(readDeepLink);
   const [globalView, setGlobalView] = useState<GlobalView>(null);
-  const [showAlerts, setShowAlerts] = useState(false);
+  const [showAlerts, setShowAlerts] = useState(() => deepLink.alert !== null);
   const [showSettings, setShowSettings] = useState(false);
   const [targetOrder, setTargetOrder] = useState<string[]>(() => {
     try {
@@ -32,6 +43,8 @@ export const Dashboard = memo(function Dashboard() {
     }
   });
   const [selectedGroup, setSelectedGroup] = useState<string | null>(() => {
+    // Show all groups so the linked target isn't filtered out
+    if (deepLink.target) return null;
     try {
       return localStorage.getItem(STORAGE_KEYS.SELECTED_GROUP);
     } catch {
@@ -64,6 +77,14 @@ export const Dashboard = memo(function Dashboard() {
     }
   }, [selectedGroup]);
 
+  // Scroll the linked target into view once it is rendered
+  const hasTargets = !!data && data.targets.length > 0;
+  useEffect(() => {
+    if (hasTargets && deepLink.target) {
+      document.getElementById(`target-${deepLink.target}`)?.scrollIntoView({ behavior: 'smooth', block: 'center' });
+    }
+  }, [hasTargets, deepLink.target]);
+
   // Initialize target order when data loads (merge with saved order)
   useEffect(() => {
     if (data?.targets && data.targets.length > 0) {
@@ -327,7 +348,13 @@ export const Dashboard = memo(function Dashboard() {
               }}
             >
               {orderedTargets.map((target, index) => (
-                <TargetCard key={target.name} target={target} globalView={globalView} renderIndex={index} />
+                <TargetCard
+                  key={target.name}
+                  target={target}
+                  globalView={globalView}
+                  renderIndex={index}
+                  initialInstance={target.name === deepLink.target ? deepLink.instance : null}
+                />
               ))}
             </div>
           </>
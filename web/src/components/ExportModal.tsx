@@ -22,7 +22,7 @@ export function ExportModal({ targetName, instances, onClose }: ExportModalProps
   const { colors } = useTheme();
   const [range, setRange] = useState('24h');
   const [selectedInstance, setSelectedInstance] = useState<string>('');
-  const [exporting, setExporting] = useState<'csv' | 'report' | null>(null);
+  const [exporting, setExporting] = useState<'csv' | 'report' | 'pdf' | null>(null);
 
   const hasInstances = instances && instances.length > 1;
 
@@ -38,6 +38,12 @@ export function ExportModal({ targetName, instances, onClose }: ExportModalProps
     setTimeout(onClose, 300);
   };
 
+  const handleDownloadPDF = () => {
+    setExporting('pdf');
+    openReport(targetName, range, 'pdf');
+    setTimeout(onClose, 300);
+  };
+
   return (
     <div
       style={{
@@ -166,6 +172,24 @@ export function ExportModal({ targetName, instances, onClose }: ExportModalProps
           >
             {exporting === 'report' ? 'Opening...' : 'View Report'}
           </button>
+          <button
+            onClick={handleDownloadPDF}
+            disabled={exporting !== null}
+            style={{
+              flex: 1,
+              padding: '10px 16px',
+              border: 'none',
+              borderRadius: '6px',
+              backgroundColor: '#8b5cf6',
+              color: '#fff',
+              cursor: exporting ? 'not-allowed' : 'pointer',
+              fontSize: '13px',
+              fontWeight: 600,
+              opacity: exporting ? 0.7 : 1,
+            }}
+          >
+            {exporting === 'pdf' ? 'Opening...' : 'PDF'}
+          </button>
         </div>
 
         {/* Cancel Button */}
@@ -13,6 +13,7 @@ const statusColors = {
   warning: { bg: '#fbbf24', border: '#fde047', text: '#854d0e' },
   critical: { bg: '#f87171', border: '#fca5a5', text: '#991b1b' },
   unknown: { bg: '#d1d5db', border: '#e5e7eb', text: '#374151' },
+  down: { bg: '#991b1b', border: '#b91c1c', text: '#fef2f2' },
 };
 
 export function HexagonView({ targets, selectedTarget, onSelectTarget }: HexagonViewProps) {
@@ -24,7 +25,7 @@ export function HexagonView({ targets, selectedTarget, onSelectTarget }: Hexagon
       type: 'target' | 'instance';
       name: string;
       displayName: string;
-      status: 'healthy' | 'warning' | 'critical' | 'unknown';
+      status: 'healthy' | 'warning' | 'critical' | 'unknown' | 'down';
       metrics?: {
         usage: number;
         active: number;
@@ -142,7 +143,7 @@ type HexItem = {
   type: 'target' | 'instance';
   name: string;
   displayName: string;
-  status: 'healthy' | 'warning' | 'critical' | 'unknown';
+  status: 'healthy' | 'warning' | 'critical' | 'unknown' | 'down';
   metrics?: {
     usage: number;
     active: number;
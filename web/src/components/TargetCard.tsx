@@ -15,6 +15,7 @@ interface TargetCardProps {
   target: TargetStatus;
   globalView?: GlobalView;
   renderIndex?: number;
+  initialInstance?: string | null; // Instance selected when opened from a deep link
 }
 
 function formatBytes(bytes: number): string {
@@ -50,10 +51,12 @@ function ChartPlaceholder({ height = 160 }: { height?: number }) {
   );
 }
 
-export const TargetCard = memo(function TargetCard({ target, globalView, renderIndex = 0 }: TargetCardProps) {
+export const TargetCard = memo(function TargetCard({ target, globalView, renderIndex = 0, initialInstance = null }: TargetCardProps) {
   const [range, setRange] = useState('1h');
   const [showInstances, setShowInstances] = useState(false);
-  const [selectedInstance, setSelectedInstance] = useState<string | null>(null);
+  const [selectedInstance, setSelectedInstance] = useState<string | null>(() =>
+    target.instances?.some(i => i.instance_name === initialInstance) ? initialInstance : null
+  );
   const { theme, colors: themeColors } = useTheme();
 
   const hasMultipleInstances = target.instances && target.instances.length > 1;
@@ -90,6 +93,7 @@ export const TargetCard = memo(function TargetCard({ target, globalView, renderI
 
   return (
     <div
+      id={`target-${target.name}`}
       style={{
         border: `2px solid ${statusColor.border}`,
         borderRadius: '10px',
@@ -359,6 +363,12 @@ export const TargetCard = memo(function TargetCard({ target, globalView, renderI
                   </div>
                   <div style={{ fontSize: '10px', color: themeColors.textSecondary }}>GC Time</div>
                 </div>
+                <div style={{ textAlign: 'center' }}>
+                  <div style={{ fontSize: '18px', fontWeight: 'bold', color: '#f97316' }}>
+                    {(current.gc_pause_ms_per_min ?? 0).toFixed(0)}ms
+                  </div>
+                  <div style={{ fontSize: '10px', color: themeColors.textSecondary }}>GC Pause/min</div>
+                </div>
               </>
             )}
           </div>
@@ -404,7 +414,7 @@ export const TargetCard = memo(function TargetCard({ target, globalView, renderI
             </div>
           ) : history?.datapoints && history.datapoints.length > 0 ? (
             <Suspense fallback={<ChartPlaceholder height={160} />}>
-              <TrendChart data={history.datapoints} height={160} targetName={target.name} />
+              <TrendChart data={history.datapoints} bands={history.bands} events={history.events} annotations={history.annotations} height={160} targetName={target.name} />
             </Suspense>
           ) : (
             <div style={{ display: 'flex', alignItems: 'center', justifyContent: 'center', height: '100%', color: themeColors.textSecondary, fontSize: '12px' }}>
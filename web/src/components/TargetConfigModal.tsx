@@ -68,8 +68,8 @@ export function TargetConfigModal({ isOpen, onClose, target, onSave }: TargetCon
       };
 
       const url = target
-        ? `${API_BASE}/api/config/targets/${encodeURIComponent(target.name)}`
-        : `${API_BASE}/api/config/targets`;
+        ? `${API_BASE}/api/v1/config/targets/${encodeURIComponent(target.name)}`
+        : `${API_BASE}/api/v1/config/targets`;
 
       const method = target ? 'PUT' : 'POST';
 
@@ -40,7 +40,7 @@ export function TargetConfigPanel({ isOpen, onClose }: TargetConfigPanelProps) {
   const fetchTargets = useCallback(async () => {
     setLoading(true);
     try {
-      const res = await fetch(`${API_BASE}/api/config/targets`);
+      const res = await fetch(`${API_BASE}/api/v1/config/targets`);
       const data = await res.json();
       setTargets(data.targets || []);
     } catch (err) {
@@ -62,7 +62,7 @@ export function TargetConfigPanel({ isOpen, onClose }: TargetConfigPanelProps) {
 
     setDeleting(true);
     try {
-      const res = await fetch(`${API_BASE}/api/config/targets/${encodeURIComponent(deleteConfirm.name)}`, {
+      const res = await fetch(`${API_BASE}/api/v1/config/targets/${encodeURIComponent(deleteConfirm.name)}`, {
         method: 'DELETE',
       });
       if (res.ok) {
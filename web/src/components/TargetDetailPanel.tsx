@@ -1,12 +1,25 @@
-import { useState } from 'react';
-import { useHistory, useRecommendations, useLeakDetection, usePeakTime, useAnomalies, useComparison } from '../hooks/useMetrics';
-import type { AnomalySensitivity } from '../hooks/useMetrics';
-import type { InstanceStatus } from '../types/metrics';
+import { useMemo, useState } from 'react';
+import { useHistory, useRecommendations, useLeakDetection, usePeakTime, useAnomalies, useComparison, captureDump, previewRecommendation, applyRecommendation, updateRecommendationStatus } from '../hooks/useMetrics';
+import type { AnomalyAlgorithm, AnomalyMethod, AnomalySensitivity, DumpKind } from '../hooks/useMetrics';
+import type { ApplyRecommendationResult, InstanceStatus, Recommendation, RecommendationStatus } from '../types/metrics';
 import { TrendChart } from './TrendChart';
+import { AcquireChart, hasAcquireTimes } from './AcquireChart';
 import { HeatmapChart } from './HeatmapChart';
 import { ExportModal } from './ExportModal';
+import { ConfirmModal } from './ConfirmModal';
 import { useTheme } from '../context/ThemeContext';
 
+// Recommendation types that can be applied to running instances
+const APPLICABLE_RECOMMENDATIONS = ['maximumPoolSize', 'minimumIdle', 'connectionTimeout'];
+
+const anomalyAlgorithmTitles: Record<AnomalyAlgorithm, string> = {
+  default: 'Algorithm configured for the target (z-score unless set)',
+  zscore: 'Distance from the mean in standard deviations',
+  mad: 'Distance from the median, robust to frequent spikes',
+  ewma: 'Distance from a moving average that follows slow drifts',
+  iqr: 'Distance beyond the interquartile range',
+};
+
 export type DetailView = 'trend' | 'heatmap' | 'peakTime' | 'anomalies' | 'compare' | 'recs' | 'leaks' | null;
 
 interface TargetDetailPanelProps {
@@ -30,16 +43,85 @@ export function TargetDetailPanel({
   const [comparePeriod, setComparePeriod] = useState<'day' | 'week'>('day');
   const [anomalyRange, setAnomalyRange] = useState('24h');
   const [anomalySensitivity, setAnomalySensitivity] = useState<AnomalySensitivity>('medium');
+  const [anomalyMethod, setAnomalyMethod] = useState<AnomalyMethod>('global');
+  const [anomalyAlgorithm, setAnomalyAlgorithm] = useState<AnomalyAlgorithm>('default');
   const [showExportModal, setShowExportModal] = useState(false);
+  const [derivedKey, setDerivedKey] = useState('');
+  const [capturing, setCapturing] = useState<string | null>(null);
+  const [captureError, setCaptureError] = useState<string | null>(null);
+
+  const [pendingApply, setPendingApply] = useState<ApplyRecommendationResult | null>(null);
+  const [applying, setApplying] = useState(false);
+  const [applyMessage, setApplyMessage] = useState<string | null>(null);
+
+  // Preview first so the confirmation shows the values being replaced
+  const handleApplyClick = async (rec: Recommendation) => {
+    setApplyMessage(null);
+    try {
+      setPendingApply(await previewRecommendation(targetName, rec.type, rec.recommended));
+    } catch (err) {
+      setApplyMessage(err instanceof Error ? err.message : 'Failed to preview recommendation');
+    }
+  };
+
+  const handleApplyConfirm = async () => {
+    if (!pendingApply) return;
+    setApplying(true);
+    try {
+      const result = await applyRecommendation(targetName, pendingApply.setting, pendingApply.value);
+      const rollback = result.rollback ? ` Roll back with ${result.setting}=${result.rollback.value}.` : '';
+      setApplyMessage(`Applied ${result.setting}=${result.value} on ${result.applied}/${result.changes.length} instances.${rollback}`);
+    } catch (err) {
+      setApplyMessage(err instanceof Error ? err.message : 'Failed to apply recommendation');
+    } finally {
+      setApplying(false);
+      setPendingApply(null);
+    }
+  };
+
+  const handleRecStatus = async (rec: Recommendation, status: RecommendationStatus, duration?: string) => {
+    if (!rec.id) return;
+    setApplyMessage(null);
+    try {
+      await updateRecommendationStatus(rec.id, status, duration);
+      refetchRecs();
+    } catch (err) {
+      setApplyMessage(err instanceof Error ? err.message : 'Failed to update recommendation');
+    }
+  };
+
+  const handleCapture = async (instance: string, kind: DumpKind) => {
+    setCapturing(`${instance}/${kind}`);
+    setCaptureError(null);
+    if (!(await captureDump(targetName, instance, kind))) {
+      setCaptureError(`Failed to capture ${kind} from ${instance}`);
+    }
+    setCapturing(null);
+  };
 
   const needHistory = detailView === 'trend' || detailView === 'heatmap';
   const { data: history, loading: historyLoading } = useHistory(needHistory ? targetName : '', detailView === 'heatmap' ? '24h' : detailRange);
-  const { data: recs, loading: recsLoading } = useRecommendations(targetName, detailView === 'recs');
+  const { data: recs, loading: recsLoading, refetch: refetchRecs } = useRecommendations(targetName, detailView === 'recs');
   const { data: leaks, loading: leaksLoading } = useLeakDetection(targetName, detailView === 'leaks');
   const { data: peakTime, loading: peakTimeLoading } = usePeakTime(targetName, detailView === 'peakTime');
-  const { data: anomalies, loading: anomaliesLoading } = useAnomalies(targetName, detailView === 'anomalies', anomalyRange, anomalySensitivity);
+  const { data: anomalies, loading: anomaliesLoading } = useAnomalies(targetName, detailView === 'anomalies', anomalyRange, anomalySensitivity, anomalyMethod, anomalyAlgorithm);
   const { data: comparison, loading: comparisonLoading } = useComparison(targetName, comparePeriod, detailView === 'compare');
 
+  // Suppressed recommendations of one type share a record
+  const suppressedRecs = useMemo(
+    () => (recs?.suppressed ?? []).filter((rec, i, all) => all.findIndex((r) => r.id === rec.id) === i),
+    [recs]
+  );
+
+  // Derived metrics present in the loaded history
+  const derivedKeys = useMemo(() => {
+    const keys = new Set<string>();
+    for (const d of history?.datapoints ?? []) {
+      Object.keys(d.derived ?? {}).forEach((k) => keys.add(k));
+    }
+    return Array.from(keys).sort();
+  }, [history]);
+
   const views = [
     { key: 'trend', label: 'Trend' },
     { key: 'heatmap', label: 'Heatmap' },
@@ -124,6 +206,26 @@ export function TargetDetailPanel({
                 {r}
               </button>
             ))}
+            {derivedKeys.length > 0 && (
+              <select
+                value={derivedKey}
+                onChange={(e) => setDerivedKey(e.target.value)}
+                style={{
+                  marginLeft: 'auto',
+                  padding: '3px 6px',
+                  border: `1px solid ${colors.border}`,
+                  borderRadius: '4px',
+                  backgroundColor: colors.bgCard,
+                  color: colors.text,
+                  fontSize: '11px',
+                }}
+              >
+                <option value="">No derived metric</option>
+                {derivedKeys.map((k) => (
+                  <option key={k} value={k}>{k}</option>
+                ))}
+              </select>
+            )}
           </div>
           <div style={{ height: '200px' }}>
             {historyLoading && !history ? (
@@ -131,13 +233,19 @@ export function TargetDetailPanel({
                 Loading...
               </div>
             ) : history?.datapoints && history.datapoints.length > 0 ? (
-              <TrendChart data={history.datapoints} height={200} targetName={targetName} />
+              <TrendChart data={history.datapoints} bands={history.bands} events={history.events} annotations={history.annotations} derivedKey={derivedKeys.includes(derivedKey) ? derivedKey : undefined} height={200} targetName={targetName} />
             ) : (
               <div style={{ display: 'flex', alignItems: 'center', justifyContent: 'center', height: '100%', color: colors.textSecondary }}>
                 No data available
               </div>
             )}
           </div>
+          {history?.datapoints && hasAcquireTimes(history.datapoints) && (
+            <div style={{ marginTop: '12px' }}>
+              <div style={{ fontSize: '11px', color: colors.textSecondary, marginBottom: '4px' }}>Connection acquire time</div>
+              <AcquireChart data={history.datapoints} height={160} />
+            </div>
+          )}
         </div>
       )}
 
@@ -243,6 +351,52 @@ export function TargetDetailPanel({
                 </button>
               ))}
             </div>
+            <div style={{ display: 'flex', alignItems: 'center', gap: '4px' }}>
+              <span style={{ fontSize: '11px', color: colors.textSecondary }}>Baseline:</span>
+              {(['global', 'seasonal'] as const).map((m) => (
+                <button
+                  key={m}
+                  onClick={() => setAnomalyMethod(m)}
+                  title={m === 'seasonal' ? 'Compare against the same hour of day in the previous week' : 'Compare against the whole range'}
+                  style={{
+                    padding: '3px 8px',
+                    border: `1px solid ${colors.border}`,
+                    borderRadius: '4px',
+                    backgroundColor: anomalyMethod === m ? '#3b82f6' : colors.bgCard,
+                    color: anomalyMethod === m ? '#fff' : colors.text,
+                    cursor: 'pointer',
+                    fontSize: '10px',
+                    textTransform: 'capitalize',
+                  }}
+                >
+                  {m}
+                </button>
+              ))}
+            </div>
+            {anomalyMethod === 'global' && (
+              <div style={{ display: 'flex', alignItems: 'center', gap: '4px' }}>
+                <span style={{ fontSize: '11px', color: colors.textSecondary }}>Algorithm:</span>
+                {(['default', 'zscore', 'mad', 'ewma', 'iqr'] as const).map((a) => (
+                  <button
+                    key={a}
+                    onClick={() => setAnomalyAlgorithm(a)}
+                    title={anomalyAlgorithmTitles[a]}
+                    style={{
+                      padding: '3px 8px',
+                      border: `1px solid ${colors.border}`,
+                      borderRadius: '4px',
+                      backgroundColor: anomalyAlgorithm === a ? '#3b82f6' : colors.bgCard,
+                      color: anomalyAlgorithm === a ? '#fff' : colors.text,
+                      cursor: 'pointer',
+                      fontSize: '10px',
+                      textTransform: a === 'default' ? 'capitalize' : 'uppercase',
+                    }}
+                  >
+                    {a}
+                  </button>
+                ))}
+              </div>
+            )}
           </div>
 
           {anomaliesLoading ? (
@@ -385,7 +539,8 @@ export function TargetDetailPanel({
           ) : recs && recs.recommendations && Array.isArray(recs.recommendations) ? (
             <div>
               <div style={{ marginBottom: '8px', fontSize: '11px', color: colors.textSecondary }}>
-                Analyzed {recs.data_points || 0} points | Peak: {recs.stats?.peak_usage ?? 0}%
+                Analyzed {recs.data_points || 0} points | Peak: {recs.stats?.peak_usage ?? 0}% | p50/p90/p99: {recs.stats?.p50_usage ?? 0}/{recs.stats?.p90_usage ?? 0}/{recs.stats?.p99_usage ?? 0}%
+                {recs.stats?.time_above?.filter((t) => t.minutes > 0).map((t) => ` | >${t.threshold}%: ${t.minutes}m`).join('')}
               </div>
               {recs.recommendations.map((rec, i) => (
                 <div
@@ -405,13 +560,70 @@ export function TargetDetailPanel({
                     <span style={{ fontSize: '10px', textTransform: 'uppercase' }}>{rec?.severity || ''}</span>
                   </div>
                   <div style={{ color: '#374151' }}>{rec?.reason || ''}</div>
+                  {!!rec?.id && (
+                    <div style={{ display: 'flex', gap: '4px', marginTop: '4px' }}>
+                      {[
+                        { label: 'Dismiss', status: 'dismissed' as const },
+                        { label: 'Snooze 1d', status: 'snoozed' as const, duration: '24h' },
+                        { label: 'Snooze 7d', status: 'snoozed' as const, duration: '168h' },
+                      ].map((action) => (
+                        <button
+                          key={action.label}
+                          onClick={() => handleRecStatus(rec, action.status, action.duration)}
+                          style={{
+                            padding: '1px 6px',
+                            fontSize: '10px',
+                            border: '1px solid #d1d5db',
+                            borderRadius: '4px',
+                            backgroundColor: 'transparent',
+                            color: '#6b7280',
+                            cursor: 'pointer',
+                          }}
+                        >
+                          {action.label}
+                        </button>
+                      ))}
+                    </div>
+                  )}
                   {rec?.current !== rec?.recommended && (
-                    <div style={{ color: '#6b7280', marginTop: '2px' }}>
-                      {rec?.current || ''} → <strong>{rec?.recommended || ''}</strong>
+                    <div style={{ display: 'flex', alignItems: 'center', gap: '6px', color: '#6b7280', marginTop: '2px' }}>
+                      <span>
+                        {rec?.current || ''} → <strong>{rec?.recommended || ''}</strong>
+                      </span>
+                      {APPLICABLE_RECOMMENDATIONS.includes(rec?.type) && (
+                        <button
+                          onClick={() => handleApplyClick(rec)}
+                          disabled={applying}
+                          style={{
+                            marginLeft: 'auto',
+                            padding: '1px 8px',
+                            fontSize: '10px',
+                            border: '1px solid #9ca3af',
+                            borderRadius: '4px',
+                            backgroundColor: '#ffffff',
+                            color: '#374151',
+                            cursor: 'pointer',
+                          }}
+                        >
+                          Apply
+                        </button>
+                      )}
                     </div>
                   )}
                 </div>
               ))}
+              {suppressedRecs.length > 0 && (
+                <div style={{ marginTop: '6px', fontSize: '11px', color: colors.textSecondary }}>
+                  Hidden: {suppressedRecs.map((rec) => `${rec.type} (${rec.status})`).join(', ')}{' '}
+                  <button
+                    onClick={() => suppressedRecs.forEach((rec) => handleRecStatus(rec, 'open'))}
+                    style={{ padding: 0, fontSize: '11px', border: 'none', background: 'none', color: colors.textSecondary, textDecoration: 'underline', cursor: 'pointer' }}
+                  >
+                    Show again
+                  </button>
+                </div>
+              )}
+              {applyMessage && <div style={{ marginTop: '6px', fontSize: '11px', color: colors.textSecondary }}>{applyMessage}</div>}
             </div>
           ) : (
             <div style={{ textAlign: 'center', padding: '20px', color: colors.textSecondary }}>No recommendations</div>
@@ -468,6 +680,35 @@ export function TargetDetailPanel({
                   No leak indicators detected
                 </div>
               )}
+              {instances && instances.length > 0 && (
+                <div style={{ marginTop: '8px' }}>
+                  <div style={{ fontSize: '11px', color: colors.textSecondary, marginBottom: '4px' }}>Capture diagnostics (actuator only)</div>
+                  {instances.map((inst) => (
+                    <div key={inst.instance_name} style={{ display: 'flex', alignItems: 'center', gap: '6px', marginBottom: '4px', fontSize: '11px' }}>
+                      <span style={{ flex: 1, color: colors.text }}>{inst.instance_name}</span>
+                      {(['threaddump', 'heapdump'] as const).map((kind) => (
+                        <button
+                          key={kind}
+                          onClick={() => handleCapture(inst.instance_name, kind)}
+                          disabled={capturing !== null}
+                          style={{
+                            padding: '2px 8px',
+                            fontSize: '11px',
+                            border: `1px solid ${colors.border}`,
+                            borderRadius: '4px',
+                            backgroundColor: colors.bgCard,
+                            color: colors.text,
+                            cursor: capturing !== null ? 'wait' : 'pointer',
+                          }}
+                        >
+                          {capturing === `${inst.instance_name}/${kind}` ? 'Capturing...' : kind === 'threaddump' ? 'Thread dump' : 'Heap dump'}
+                        </button>
+                      ))}
+                    </div>
+                  ))}
+                  {captureError && <div style={{ fontSize: '11px', color: '#991b1b' }}>{captureError}</div>}
+                </div>
+              )}
             </div>
           ) : (
             <div style={{ textAlign: 'center', padding: '20px', color: colors.textSecondary }}>Unable to analyze</div>
@@ -475,6 +716,22 @@ export function TargetDetailPanel({
         </div>
       )}
 
+      <ConfirmModal
+        isOpen={!!pendingApply}
+        title="Apply Recommendation"
+        message={
+          pendingApply
+            ? `Set ${pendingApply.property} to ${pendingApply.value} on ${pendingApply.changes.length} running instance(s) of "${targetName}"? ` +
+              `Current: ${pendingApply.changes.map((c) => `${c.instance}=${c.previous || 'unknown'}`).join(', ')}`
+            : ''
+        }
+        confirmLabel="Apply"
+        variant="warning"
+        isLoading={applying}
+        onConfirm={handleApplyConfirm}
+        onCancel={() => setPendingApply(null)}
+      />
+
       {/* Export Modal */}
       {showExportModal && (
         <ExportModal
@@ -1,6 +1,7 @@
 import { memo, useMemo } from 'react';
 import {
-  LineChart,
+  ComposedChart,
+  Area,
   Line,
   XAxis,
   YAxis,
@@ -8,8 +9,9 @@ import {
   Tooltip,
   Legend,
   ResponsiveContainer,
+  ReferenceLine,
 } from 'recharts';
-import type { PoolMetrics } from '../types/metrics';
+import type { PoolMetrics, TargetEvent, Annotation, HistoryBand } from '../types/metrics';
 import { useSettings, formatTime } from '../hooks/useMetrics';
 import { useTheme } from '../context/ThemeContext';
 
@@ -17,9 +19,13 @@ interface TrendChartProps {
   data: PoolMetrics[];
   height?: number;
   targetName?: string;
+  events?: TargetEvent[];
+  annotations?: Annotation[];
+  derivedKey?: string; // Derived metric plotted on the right axis
+  bands?: HistoryBand[]; // Min/max of downsampled points, drawn around the lines
 }
 
-export const TrendChart = memo(function TrendChart({ data, height = 300, targetName }: TrendChartProps) {
+export const TrendChart = memo(function TrendChart({ data, height = 300, targetName, events, annotations, derivedKey, bands }: TrendChartProps) {
   const { settings } = useSettings();
   const { theme, colors } = useTheme();
   const timezone = settings?.timezone || 'Local';
@@ -33,19 +39,56 @@ export const TrendChart = memo(function TrendChart({ data, height = 300, targetN
     active: theme === 'dark' ? '#60a5fa' : '#3b82f6',
     idle: theme === 'dark' ? '#4ade80' : '#22c55e',
     pending: theme === 'dark' ? '#fbbf24' : '#f59e0b',
+    event: theme === 'dark' ? '#a78bfa' : '#8b5cf6',
+    annotation: theme === 'dark' ? '#f472b6' : '#ec4899',
+    derived: theme === 'dark' ? '#22d3ee' : '#06b6d4',
   }), [theme]);
 
+  // Bands are only drawn when they line up with the datapoints
+  const hasBands = !!bands && bands.length === data?.length;
+
   const chartData = useMemo(() => {
     if (!data || !Array.isArray(data)) return [];
     return data
-      .filter((d) => d && d.timestamp)
-      .map((d) => ({
+      .map((d, i) => ({ d, band: hasBands ? bands?.[i] : undefined }))
+      .filter(({ d }) => d && d.timestamp)
+      .map(({ d, band }) => ({
         time: formatTime(d.timestamp, timezone),
         active: d.active ?? 0,
         idle: d.idle ?? 0,
         pending: d.pending ?? 0,
+        activeRange: band ? [band.active.min, band.active.max] : undefined,
+        pendingRange: band ? [band.pending.min, band.pending.max] : undefined,
+        derived: derivedKey ? d.derived?.[derivedKey] : undefined,
       }));
-  }, [data, timezone]);
+  }, [data, timezone, derivedKey, bands, hasBands]);
+
+  // Place each event and annotation on the first datapoint at or after it
+  const markers = useMemo(() => {
+    if (!data || !Array.isArray(data)) return [];
+    const points = data.filter((d) => d && d.timestamp);
+    const place = (timestamp: string) => {
+      const at = new Date(timestamp).getTime();
+      const point = points.find((d) => new Date(d.timestamp).getTime() >= at);
+      return point ? formatTime(point.timestamp, timezone) : null;
+    };
+
+    const result: { key: string; time: string; label: string; color: string }[] = [];
+    for (const e of events ?? []) {
+      const time = place(e.timestamp);
+      if (time) {
+        result.push({ key: `event-${e.id}`, time, label: e.version ? `${e.type} ${e.version}` : e.type, color: chartColors.event });
+      }
+    }
+    for (const a of annotations ?? []) {
+      const time = place(a.time);
+      if (time) {
+        const label = a.text.length > 24 ? `${a.text.slice(0, 24)}…` : a.text;
+        result.push({ key: `annotation-${a.id}`, time, label, color: chartColors.annotation });
+      }
+    }
+    return result;
+  }, [events, annotations, data, timezone, chartColors]);
 
   if (chartData.length === 0) {
     return (
@@ -57,10 +100,11 @@ export const TrendChart = memo(function TrendChart({ data, height = 300, targetN
 
   return (
     <ResponsiveContainer width="100%" height={height}>
-      <LineChart data={chartData} margin={{ top: 5, right: 30, left: 20, bottom: 5 }}>
+      <ComposedChart data={chartData} margin={{ top: 5, right: 30, left: 20, bottom: 5 }}>
         <CartesianGrid strokeDasharray="3 3" stroke={chartColors.grid} />
         <XAxis dataKey="time" stroke={chartColors.axis} fontSize={12} />
-        <YAxis stroke={chartColors.axis} fontSize={12} />
+        <YAxis yAxisId="left" stroke={chartColors.axis} fontSize={12} />
+        {derivedKey && <YAxis yAxisId="right" orientation="right" stroke={chartColors.derived} fontSize={12} />}
         <Tooltip
           content={({ active, payload, label }) => {
             if (!active || !payload || payload.length === 0) return null;
@@ -84,7 +128,11 @@ export const TrendChart = memo(function TrendChart({ data, height = 300, targetN
                 {payload.map((entry, index) => (
                   <div key={index} style={{ display: 'flex', alignItems: 'center', gap: '6px', padding: '2px 0' }}>
                     <span style={{ width: '8px', height: '8px', borderRadius: '50%', backgroundColor: entry.color }} />
-                    <span>{entry.name}: {entry.value}</span>
+                    <span>
+                      {entry.name}: {Array.isArray(entry.value)
+                        ? entry.value.map((v) => Math.round(Number(v) * 10) / 10).join(' – ')
+                        : entry.value}
+                    </span>
                   </div>
                 ))}
               </div>
@@ -92,8 +140,45 @@ export const TrendChart = memo(function TrendChart({ data, height = 300, targetN
           }}
         />
         <Legend wrapperStyle={{ fontSize: '12px' }} />
+        {markers.map((m) => (
+          <ReferenceLine
+            key={m.key}
+            x={m.time}
+            yAxisId="left"
+            stroke={m.color}
+            strokeDasharray="4 4"
+            label={{ value: m.label, position: 'insideTopRight', fontSize: 10, fill: m.color }}
+          />
+        ))}
+        {hasBands && (
+          <Area
+            type="monotone"
+            yAxisId="left"
+            dataKey="activeRange"
+            stroke="none"
+            fill={chartColors.active}
+            fillOpacity={0.15}
+            name="Active min–max"
+            legendType="none"
+            isAnimationActive={false}
+          />
+        )}
+        {hasBands && (
+          <Area
+            type="monotone"
+            yAxisId="left"
+            dataKey="pendingRange"
+            stroke="none"
+            fill={chartColors.pending}
+            fillOpacity={0.15}
+            name="Pending min–max"
+            legendType="none"
+            isAnimationActive={false}
+          />
+        )}
         <Line
           type="monotone"
+          yAxisId="left"
           dataKey="active"
           stroke={chartColors.active}
           strokeWidth={2}
@@ -103,6 +188,7 @@ export const TrendChart = memo(function TrendChart({ data, height = 300, targetN
         />
         <Line
           type="monotone"
+          yAxisId="left"
           dataKey="idle"
           stroke={chartColors.idle}
           strokeWidth={2}
@@ -112,6 +198,7 @@ export const TrendChart = memo(function TrendChart({ data, height = 300, targetN
         />
         <Line
           type="monotone"
+          yAxisId="left"
           dataKey="pending"
           stroke={chartColors.pending}
           strokeWidth={2}
@@ -119,7 +206,21 @@ export const TrendChart = memo(function TrendChart({ data, height = 300, targetN
           name="Pending"
           isAnimationActive={false}
         />
-      </LineChart>
+        {derivedKey && (
+          <Line
+            yAxisId="right"
+            type="monotone"
+            dataKey="derived"
+            stroke={chartColors.derived}
+            strokeWidth={2}
+            strokeDasharray="5 3"
+            dot={false}
+            connectNulls
+            name={derivedKey}
+            isAnimationActive={false}
+          />
+        )}
+      </ComposedChart>
     </ResponsiveContainer>
   );
 });
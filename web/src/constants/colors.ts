@@ -8,6 +8,7 @@ export const statusColors: Record<string, { bg: string; text: string; border: st
   critical: { bg: '#fee2e2', text: '#991b1b', border: '#ef4444' },
   unknown: { bg: '#f3f4f6', text: '#374151', border: '#9ca3af' },
   offline: { bg: '#fef2f2', text: '#7f1d1d', border: '#991b1b' },
+  down: { bg: '#fef2f2', text: '#7f1d1d', border: '#991b1b' },
 };
 
 export const statusLabels: Record<string, string> = {
@@ -17,6 +18,7 @@ export const statusLabels: Record<string, string> = {
   critical: 'CRITICAL',
   unknown: 'UNKNOWN',
   offline: 'OFFLINE',
+  down: 'DOWN',
 };
 
 // Alert severity colors (light theme)
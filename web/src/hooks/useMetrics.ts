@@ -1,7 +1,7 @@
 import { useState, useEffect, useCallback, useRef } from 'react';
-import type { TargetsResponse, HistoryResponse, AnalysisResult, LeakAnalysisResult, Alert, AlertsResponse, AlertStats } from '../types/metrics';
+import type { TargetsResponse, HistoryResponse, AnalysisResult, ApplyRecommendationResult, RecommendationStatus, LeakAnalysisResult, Alert, AlertsResponse, AlertStats } from '../types/metrics';
 
-const API_BASE = '/api';
+const API_BASE = '/api/v1';
 
 // Helper to extract error message from API response
 async function extractErrorMessage(res: Response, defaultMessage: string): Promise<string> {
@@ -38,6 +38,7 @@ function usePageVisibility() {
 // Settings interface
 export interface Settings {
   timezone: string;
+  read_only?: boolean; // Server rejects changes (server.read_only)
 }
 
 // Cache for settings
@@ -201,33 +202,12 @@ export function useTargets(refreshInterval = 10000) {
 // History cache with TTL
 const historyCache = new Map<string, { data: HistoryResponse; timestamp: number }>();
 const HISTORY_CACHE_TTL = 10000; // 10 seconds - extended for better performance
-const MAX_CHART_POINTS = 200; // Maximum data points for smooth rendering
+const MAX_CHART_POINTS = 200; // Maximum data points for smooth rendering, downsampled by the server
 
 // Targets cache with TTL
 const targetsCache: { data: TargetsResponse | null; timestamp: number } = { data: null, timestamp: 0 };
 const TARGETS_CACHE_TTL = 3000; // 3 seconds - short TTL for freshness but reduces duplicate requests
 
-// Downsample data for better chart performance
-function downsampleData(datapoints: HistoryResponse['datapoints'], maxPoints: number): HistoryResponse['datapoints'] {
-  if (!datapoints || datapoints.length <= maxPoints) return datapoints;
-
-  const step = Math.ceil(datapoints.length / maxPoints);
-  const result: typeof datapoints = [];
-
-  for (let i = 0; i < datapoints.length; i += step) {
-    // Take the point with highest activity in each window
-    let maxPoint = datapoints[i];
-    for (let j = i + 1; j < Math.min(i + step, datapoints.length); j++) {
-      if (datapoints[j].active > maxPoint.active) {
-        maxPoint = datapoints[j];
-      }
-    }
-    result.push(maxPoint);
-  }
-
-  return result;
-}
-
 export function useHistory(targetName: string, range = '1h') {
   const [data, setData] = useState<HistoryResponse | null>(null);
   const [loading, setLoading] = useState(true);
@@ -256,7 +236,8 @@ export function useHistory(targetName: string, range = '1h') {
     abortControllerRef.current = new AbortController();
 
     try {
-      const res = await fetch(`${API_BASE}/targets/${targetName}/history?range=${range}`, {
+      // The server averages buckets and returns their min/max bands, so spikes stay visible
+      const res = await fetch(`${API_BASE}/targets/${targetName}/history?range=${range}&limit=${MAX_CHART_POINTS}&annotations=true`, {
         signal: abortControllerRef.current.signal,
       });
       if (!res.ok) {
@@ -265,11 +246,6 @@ export function useHistory(targetName: string, range = '1h') {
       }
       const json: HistoryResponse = await res.json();
 
-      // Downsample data for better performance
-      if (json.datapoints && json.datapoints.length > MAX_CHART_POINTS) {
-        json.datapoints = downsampleData(json.datapoints, MAX_CHART_POINTS);
-      }
-
       // Cache the result
       historyCache.set(cacheKey, { data: json, timestamp: Date.now() });
 
@@ -351,6 +327,41 @@ export function useRecommendations(targetName: string, enabled = false) {
   return { data, loading, error, refetch: fetchRecommendations };
 }
 
+// Reads the values a recommendation would replace on each instance
+export async function previewRecommendation(targetName: string, setting: string, value: string): Promise<ApplyRecommendationResult> {
+  const params = new URLSearchParams({ setting, value });
+  const res = await fetch(`${API_BASE}/targets/${targetName}/recommendations/apply?${params}`);
+  if (!res.ok) {
+    throw new Error(await extractErrorMessage(res, 'Failed to preview recommendation'));
+  }
+  return res.json();
+}
+
+// Applies a recommendation to the running instances of a target
+export async function applyRecommendation(targetName: string, setting: string, value: string): Promise<ApplyRecommendationResult> {
+  const res = await fetch(`${API_BASE}/targets/${targetName}/recommendations/apply`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ setting, value, confirm: true }),
+  });
+  if (!res.ok) {
+    throw new Error(await extractErrorMessage(res, 'Failed to apply recommendation'));
+  }
+  return res.json();
+}
+
+// Marks a tracked recommendation applied, dismissed, snoozed or open again
+export async function updateRecommendationStatus(id: number, status: RecommendationStatus, duration?: string): Promise<void> {
+  const res = await fetch(`${API_BASE}/recommendations/${id}/status`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ status, duration }),
+  });
+  if (!res.ok) {
+    throw new Error(await extractErrorMessage(res, 'Failed to update recommendation'));
+  }
+}
+
 export function useLeakDetection(targetName: string, enabled = false) {
   const [data, setData] = useState<LeakAnalysisResult | null>(null);
   const [loading, setLoading] = useState(false);
@@ -390,16 +401,41 @@ export function exportCSV(targetName: string, range = '24h', instance?: string)
   window.open(url, '_blank');
 }
 
-export function openReport(targetName: string, range = '24h') {
-  window.open(`${API_BASE}/targets/${targetName}/report?range=${range}`, '_blank');
+export type DumpKind = 'threaddump' | 'heapdump';
+
+// Captures a dump from an actuator instance and downloads it
+export async function captureDump(targetName: string, instance: string, kind: DumpKind): Promise<boolean> {
+  try {
+    const res = await fetch(
+      `${API_BASE}/targets/${targetName}/instances/${encodeURIComponent(instance)}/actions/${kind}`,
+      { method: 'POST' }
+    );
+    if (!res.ok) return false;
+    const blob = await res.blob();
+    const disposition = res.headers.get('Content-Disposition') || '';
+    const filename = disposition.match(/filename=(.+)$/)?.[1] || `${targetName}_${instance}_${kind}`;
+    const url = URL.createObjectURL(blob);
+    const a = document.createElement('a');
+    a.href = url;
+    a.download = filename;
+    a.click();
+    URL.revokeObjectURL(url);
+    return true;
+  } catch {
+    return false;
+  }
+}
+
+export function openReport(targetName: string, range = '24h', format: 'html' | 'pdf' = 'html') {
+  window.open(`${API_BASE}/targets/${targetName}/report?range=${range}&format=${format}`, '_blank');
 }
 
 export function exportAllCSV(range = '24h') {
   window.open(`${API_BASE}/export/all?range=${range}`, '_blank');
 }
 
-export function openCombinedReport(range = '24h') {
-  window.open(`${API_BASE}/report/combined?range=${range}`, '_blank');
+export function openCombinedReport(range = '24h', format: 'html' | 'pdf' = 'html') {
+  window.open(`${API_BASE}/report/combined?range=${range}&format=${format}`, '_blank');
 }
 
 export function usePeakTime(targetName: string, enabled = false) {
@@ -434,12 +470,17 @@ export function usePeakTime(targetName: string, enabled = false) {
 }
 
 export type AnomalySensitivity = 'low' | 'medium' | 'high';
+export type AnomalyMethod = 'global' | 'seasonal';
+// 'default' uses the algorithm configured for the target
+export type AnomalyAlgorithm = 'default' | 'zscore' | 'mad' | 'ewma' | 'iqr';
 
 export function useAnomalies(
   targetName: string,
   enabled = false,
   range = '24h',
-  sensitivity: AnomalySensitivity = 'medium'
+  sensitivity: AnomalySensitivity = 'medium',
+  method: AnomalyMethod = 'global',
+  algorithm: AnomalyAlgorithm = 'default'
 ) {
   const [data, setData] = useState<AnomalyResult | null>(null);
   const [loading, setLoading] = useState(false);
@@ -457,8 +498,9 @@ export function useAnomalies(
 
     setLoading(true);
     try {
+      const algorithmParam = method === 'global' && algorithm !== 'default' ? `&algorithm=${algorithm}` : '';
       const res = await fetch(
-        `${API_BASE}/targets/${targetName}/anomalies?range=${range}&sensitivity=${sensitivity}`,
+        `${API_BASE}/targets/${targetName}/anomalies?range=${range}&sensitivity=${sensitivity}&method=${method}${algorithmParam}`,
         { signal: abortControllerRef.current.signal }
       );
       if (!res.ok) {
@@ -474,7 +516,7 @@ export function useAnomalies(
     } finally {
       setLoading(false);
     }
-  }, [targetName, enabled, range, sensitivity]);
+  }, [targetName, enabled, range, sensitivity, method, algorithm]);
 
   useEffect(() => {
     fetchAnomalies();
@@ -522,8 +564,12 @@ interface AnomalyResult {
     threshold: number;
     anomaly_count: number;
     anomaly_percent: number;
+    center: number;
+    spread: number;
   };
   risk_level: string;
+  method: AnomalyMethod;
+  algorithm: Exclude<AnomalyAlgorithm, 'default'>;
 }
 
 interface Anomaly {
@@ -8,7 +8,13 @@ export interface PoolMetrics {
   pending: number;
   max: number;
   timeout: number;
+  timeout_delta: number;
+  timeout_rate: number;
+  // Connection acquire time (ms)
+  acquire_p50: number;
+  acquire_p95: number;
   acquire_p99: number;
+  acquire_max: number;
   // JVM metrics
   heap_used: number;
   heap_max: number;
@@ -21,21 +27,67 @@ export interface PoolMetrics {
   gc_time: number;
   young_gc_count: number;
   old_gc_count: number;
+  // GC activity since the previous sample
+  gc_count_delta: number;
+  gc_time_delta: number;
+  young_gc_count_delta: number;
+  old_gc_count_delta: number;
+  gc_pauses_per_min: number;
+  gc_pause_ms_per_min: number;
+  avg_gc_pause_ms: number;
+  // User-defined derived metrics by name
+  derived?: Record<string, number>;
+  // Downsampled history points: samples aggregated and the extremes of each field
+  samples?: number;
+  min_values?: Record<string, number>;
+  max_values?: Record<string, number>;
+  // Actuator /health status (UP, DOWN, ...)
+  health?: string;
   timestamp: string;
 }
 
+export interface CollectorHealth {
+  target_name: string;
+  instance_name: string;
+  type: string;
+  endpoint: string;
+  interval: string;
+  state: 'pending' | 'up' | 'failing' | 'down';
+  last_scrape?: string;
+  last_success?: string;
+  last_duration_ms: number;
+  consecutive_failures: number;
+  total_scrapes: number;
+  total_failures: number;
+  last_error?: string;
+  breaker_open: boolean;
+  next_scrape?: string;
+}
+
+export interface SchedulerStats {
+  max_concurrent: number;
+  requests_per_scrape: number;
+  running: number;
+  waiting: number;
+  delayed: number;
+}
+
 export interface InstanceStatus {
   instance_name: string;
-  status: 'healthy' | 'warning' | 'critical' | 'unknown';
+  status: 'healthy' | 'warning' | 'critical' | 'unknown' | 'down';
   current?: PoolMetrics;
+  collector?: CollectorHealth;
 }
 
 export interface TargetStatus {
   name: string;
   group?: string;
-  status: 'healthy' | 'warning' | 'critical' | 'unknown';
+  status: 'healthy' | 'warning' | 'critical' | 'unknown' | 'down' | 'paused';
   current?: PoolMetrics;
   instances?: InstanceStatus[];
+  paused?: boolean;
+  pause_reason?: string;
+  labels?: Record<string, string>;
 }
 
 export interface TargetsResponse {
@@ -43,9 +95,75 @@ export interface TargetsResponse {
   groups?: string[];
 }
 
+export interface TargetEvent {
+  id: number;
+  target_name: string;
+  type: 'deploy' | 'rollback' | 'config';
+  version?: string;
+  description?: string;
+  timestamp: string;
+  created_at: string;
+}
+
+export interface Annotation {
+  id: number;
+  target_name?: string;
+  time: string;
+  end_time?: string;
+  tags?: string[];
+  text: string;
+  created_by?: string;
+  created_at: string;
+}
+
+// Range of a field within a downsampled bucket
+export interface Band {
+  min: number;
+  avg: number;
+  max: number;
+}
+
+export interface HistoryBand {
+  timestamp: string;
+  active: Band;
+  pending: Band;
+  usage: Band; // % of max
+}
+
 export interface HistoryResponse {
   target_name: string;
   datapoints: PoolMetrics[];
+  events?: TargetEvent[];
+  annotations?: Annotation[];
+  // One per datapoint when the server downsampled the history
+  bands?: HistoryBand[];
+}
+
+// Saved view types
+export type ViewPanel = 'pool_usage' | 'acquire' | 'heap' | 'gc' | 'cpu' | 'threads';
+
+export interface ViewTarget {
+  target: string;
+  instances?: string[];
+}
+
+export interface View {
+  id: number;
+  name: string;
+  description?: string;
+  targets: ViewTarget[];
+  panels: ViewPanel[];
+  range?: string;
+  project?: string;
+  created_by?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ViewDataResponse {
+  view: View;
+  range: string;
+  series: { target_name: string; datapoints: PoolMetrics[] }[];
 }
 
 export interface Recommendation {
@@ -54,6 +172,29 @@ export interface Recommendation {
   recommended: string;
   reason: string;
   severity: 'info' | 'warning' | 'critical';
+  id?: number;
+  status?: RecommendationStatus;
+}
+
+export type RecommendationStatus = 'open' | 'applied' | 'dismissed' | 'snoozed';
+
+export interface PoolSettingChange {
+  instance: string;
+  previous?: string;
+  value: string;
+  applied: boolean;
+  error?: string;
+}
+
+export interface ApplyRecommendationResult {
+  target_name: string;
+  setting: string;
+  property: string;
+  mode: 'env' | 'custom';
+  value: string;
+  applied: number;
+  changes: PoolSettingChange[];
+  rollback?: { setting: string; value: string; confirm: boolean };
 }
 
 export interface PoolStats {
@@ -64,8 +205,19 @@ export interface PoolStats {
   max_pending: number;
   avg_usage: number;
   peak_usage: number;
+  p50_usage: number;
+  p90_usage: number;
+  p99_usage: number;
   current_max: number;
   timeout_count: number;
+  timeout_rate: number;
+  time_above: ThresholdTime[];
+}
+
+export interface ThresholdTime {
+  threshold: number;
+  minutes: number;
+  percent: number;
 }
 
 export interface AnalysisResult {
@@ -73,6 +225,7 @@ export interface AnalysisResult {
   analyzed_at: string;
   data_points: number;
   recommendations: Recommendation[];
+  suppressed?: Recommendation[];
   stats: PoolStats;
 }
 
@@ -105,15 +258,32 @@ export interface Alert {
   rule_name: string;
   severity: 'info' | 'warning' | 'critical';
   message: string;
-  status: 'fired' | 'resolved';
+  status: 'fired' | 'resolved' | 'suppressed';
   fired_at: string;
   resolved_at?: string;
   notified_at?: string;
   channels?: string;
+  acknowledged_at?: string;
+  acknowledged_by?: string;
+  flapping?: boolean;
+  escalated_from?: 'info' | 'warning';
+  escalated_at?: string;
 }
 
 export interface AlertsResponse {
   alerts: Alert[];
+  total?: number;
+  limit?: number;
+  offset?: number;
+  next_cursor?: string;
+}
+
+export interface AlertContextResponse {
+  alert: Alert;
+  values: Record<string, number>;
+  from: string;
+  to: string;
+  history: PoolMetrics[];
 }
 
 export interface AlertStats {
@@ -123,6 +293,38 @@ export interface AlertStats {
   critical_count: number;
   warning_count: number;
   info_count: number;
+  flapping_alerts?: number;
+}
+
+export interface AlertDayCount {
+  date: string;
+  fired: number;
+  resolved: number;
+}
+
+export interface AlertMTTR {
+  name: string;
+  resolved: number;
+  mean_seconds: number;
+  max_seconds: number;
+}
+
+export interface AlertTimeseries {
+  from: string;
+  to: string;
+  days: AlertDayCount[];
+  mttr_seconds: number;
+  mttr_by_rule: AlertMTTR[];
+  mttr_by_target: AlertMTTR[];
+  noisy_rules: { rule: string; fired: number; targets: number; instances: number }[];
+  flapping: {
+    rule: string;
+    target_name: string;
+    instance_name: string;
+    fires: number;
+    mean_duration_seconds: number;
+    last_fired_at: string;
+  }[];
 }
 
 // Alert Rule types
@@ -130,9 +332,17 @@ export interface AlertRule {
   id: number;
   name: string;
   condition: string;
+  resolve_condition?: string;
+  escalate_condition?: string;
+  escalate_severity?: 'warning' | 'critical';
+  repeat_interval?: string;
   severity: 'info' | 'warning' | 'critical';
   message: string;
   enabled: boolean;
+  labels?: Record<string, string>;
+  targets?: string[];
+  groups?: string[];
+  origin: 'api' | 'config';
   created_at: string;
   updated_at: string;
 }
@@ -140,9 +350,16 @@ export interface AlertRule {
 export interface AlertRuleInput {
   name: string;
   condition: string;
+  resolve_condition?: string;
+  escalate_condition?: string;
+  escalate_severity?: 'warning' | 'critical';
+  repeat_interval?: string;
   severity: 'info' | 'warning' | 'critical';
   message: string;
   enabled?: boolean;
+  labels?: Record<string, string>;
+  targets?: string[];
+  groups?: string[];
 }
 
 export interface AlertRulesResponse {
@@ -156,16 +373,52 @@ export interface AlertRulesResponse {
   }[];
 }
 
+export interface RuleTemplate {
+  id: string;
+  description: string;
+  type: 'condition' | 'nodata';
+  condition?: string;
+  severity: 'info' | 'warning' | 'critical';
+  message: string;
+  applied: boolean;
+}
+
+export interface ApplyRuleTemplatesRequest {
+  templates?: string[];
+  targets?: string[];
+  group?: string;
+  name_suffix?: string;
+  channels?: string[];
+}
+
+export interface ApplyRuleTemplatesResponse {
+  created: string[];
+  skipped: string[];
+}
+
+export interface RuleImportResponse {
+  dry_run: boolean;
+  created: string[];
+  updated: string[];
+  renamed: Record<string, string>;
+  skipped: string[];
+  alerting: boolean;
+}
+
 // Maintenance Window types
 export interface MaintenanceWindow {
   id: number;
   name: string;
   description?: string;
   target_name?: string;
+  targets?: string[];
+  groups?: string[];
   start_time: string;
   end_time: string;
   recurring: boolean;
   days_of_week?: string;
+  cron?: string;
+  duration_minutes?: number;
   created_at: string;
   updated_at: string;
 }